@@ -0,0 +1,531 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"bookmarks/internal/config"
+	"bookmarks/internal/model"
+	accountRepo "bookmarks/internal/repository/account"
+	"bookmarks/internal/service/account"
+	"bookmarks/internal/service/backup"
+	"bookmarks/internal/storage/sqlite"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+var (
+	ErrDatabaseNotFound = errors.New("database does not exist, run migrate")
+)
+
+func (c *cli) serve() error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	return serve(cfg)
+}
+
+type migrateResult struct {
+	From    int `json:"from"`
+	To      int `json:"to"`
+	Applied int `json:"applied"`
+}
+
+func (c *cli) migrate() error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	driver, err := makeSqliteDriver(cfg)
+	if err != nil {
+		return err
+	}
+	defer driver.DB.Close() //nolint:errcheck
+
+	from, to, err := sqlite.Migrate(driver)
+	if err != nil {
+		return err
+	}
+
+	result := migrateResult{From: from, To: to, Applied: to - from}
+
+	c.print(result, func(w io.Writer) {
+		if result.Applied == 0 {
+			fmt.Fprintf(w, "schema is up to date at version %d\n", to) //nolint:errcheck
+			return
+		}
+
+		fmt.Fprintf(w, "applied %d migrations: version %d -> %d\n", result.Applied, from, to) //nolint:errcheck
+	})
+
+	return nil
+}
+
+type checkConfigResult struct {
+	OK      bool     `json:"ok"`
+	Path    string   `json:"path"`
+	Errors  []string `json:"errors,omitempty"`
+	Env     string   `json:"env,omitempty"`
+	Storage string   `json:"storage,omitempty"`
+	Server  string   `json:"server,omitempty"`
+	Address string   `json:"address,omitempty"`
+}
+
+// checkConfig reports every problem of the config, not only the first one.
+func (c *cli) checkConfig() error {
+	path := config.Path(c.configPath)
+	result := checkConfigResult{Path: path}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		result.Errors = []string{err.Error()}
+	} else if err := cfg.Validate(); err != nil {
+		result.Errors = unjoin(err)
+	} else {
+		result.OK = true
+		result.Env = cfg.Env
+		result.Storage = cfg.Storage
+		result.Server = cfg.Type
+		result.Address = cfg.Address
+	}
+
+	c.print(result, func(w io.Writer) {
+		if !result.OK {
+			fmt.Fprintf(w, "config %s is invalid:\n", path) //nolint:errcheck
+			for _, e := range result.Errors {
+				fmt.Fprintf(w, "  %s\n", e) //nolint:errcheck
+			}
+
+			return
+		}
+
+		fmt.Fprintf(w, "config %s is valid: env %s, %s server on %s, storage %s\n", //nolint:errcheck
+			path, result.Env, result.Server, result.Address, result.Storage)
+	})
+
+	if !result.OK {
+		return &exitError{code: exitConfig}
+	}
+
+	return nil
+}
+
+type integrityResult struct {
+	OK         bool               `json:"ok"`
+	Problems   []string           `json:"problems"`
+	Duplicates []pkgsql.Duplicate `json:"duplicates"`
+	Collisions []sqlite.Collision `json:"collisions"`
+}
+
+func (c *cli) integrityCheck() error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	driver, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer driver.DB.Close() //nolint:errcheck
+
+	problems, err := driver.IntegrityCheck()
+	if err != nil {
+		return err
+	}
+
+	duplicates, err := driver.UniqueIndexCheck()
+	if err != nil {
+		return err
+	}
+
+	collisions, err := sqlite.Collisions(driver)
+	if err != nil {
+		return err
+	}
+
+	result := integrityResult{
+		OK:         len(problems) == 0 && len(duplicates) == 0 && len(collisions) == 0,
+		Problems:   append([]string{}, problems...),
+		Duplicates: append([]pkgsql.Duplicate{}, duplicates...),
+		Collisions: append([]sqlite.Collision{}, collisions...),
+	}
+
+	c.print(result, func(w io.Writer) {
+		if result.OK {
+			fmt.Fprintln(w, "ok") //nolint:errcheck
+			return
+		}
+
+		for _, p := range result.Problems {
+			fmt.Fprintln(w, p) //nolint:errcheck
+		}
+
+		for _, d := range result.Duplicates {
+			fmt.Fprintf(w, "%s of %s: key %v is stored %d times\n", d.Index, d.Table, d.Key, d.Count) //nolint:errcheck
+		}
+
+		for _, c := range result.Collisions {
+			fmt.Fprintf(w, "bookmark %s is not deduplicated: %q is bookmarked by %s\n", c.Uuid, c.CanonicalValue, collisionOwner(c)) //nolint:errcheck
+		}
+	})
+
+	if !result.OK {
+		return &exitError{code: exitFailure}
+	}
+
+	return nil
+}
+
+func collisionOwner(c sqlite.Collision) string {
+	if c.Owner == "" {
+		return "a deleted bookmark"
+	}
+
+	return c.Owner
+}
+
+type vacuumResult struct {
+	Before int64 `json:"size_before"`
+	After  int64 `json:"size_after"`
+}
+
+func (c *cli) vacuum() error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	driver, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer driver.DB.Close() //nolint:errcheck
+
+	var result vacuumResult
+
+	if result.Before, err = driver.Size(); err != nil {
+		return err
+	}
+
+	if err := driver.Vacuum(); err != nil {
+		return err
+	}
+
+	if result.After, err = driver.Size(); err != nil {
+		return err
+	}
+
+	c.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "vacuumed: %d -> %d bytes\n", result.Before, result.After) //nolint:errcheck
+	})
+
+	return nil
+}
+
+func (c *cli) stats() error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	driver, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer driver.DB.Close() //nolint:errcheck
+
+	stats, err := sqlite.ReadStats(driver)
+	if err != nil {
+		return err
+	}
+
+	c.print(stats, func(w io.Writer) {
+		fmt.Fprintf(w, "schema version  %d\n", stats.SchemaVersion)                                 //nolint:errcheck
+		fmt.Fprintf(w, "size            %d bytes\n", stats.Size)                                    //nolint:errcheck
+		fmt.Fprintf(w, "bookmarks       %d (%s)\n", stats.Bookmarks, counts(stats.BookmarksByKind)) //nolint:errcheck
+		fmt.Fprintf(w, "tombstones      %d\n", stats.Tombstones)                                    //nolint:errcheck
+		fmt.Fprintf(w, "metadata        %s\n", counts(stats.Metadata))                              //nolint:errcheck
+		fmt.Fprintf(w, "link health     %s\n", counts(stats.LinkHealth))                            //nolint:errcheck
+		fmt.Fprintf(w, "archives        %d, %d bytes\n", stats.Archives, stats.ArchiveBytes)        //nolint:errcheck
+		fmt.Fprintf(w, "jobs            %s\n", counts(stats.Jobs))                                  //nolint:errcheck
+		fmt.Fprintf(w, "webhooks        %d, %d disabled\n", stats.Webhooks, stats.WebhooksDisabled) //nolint:errcheck
+		fmt.Fprintf(w, "outbox pending  %d, %d dead\n", stats.OutboxPending, stats.OutboxDead)      //nolint:errcheck
+	})
+
+	return nil
+}
+
+func (c *cli) backup() error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	driver, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer driver.DB.Close() //nolint:errcheck
+
+	created, err := newBackups(slog.New(slog.DiscardHandler), cfg, driver).Backup(context.Background())
+	if err != nil {
+		return err
+	}
+
+	c.print(created, func(w io.Writer) {
+		fmt.Fprintf(w, "backup %s: %d bytes, sha256 %s, schema version %d\n", //nolint:errcheck
+			filepath.Join(cfg.Backup.Dir, created.Name), created.Size, created.SHA256, created.SchemaVersion)
+	})
+
+	return nil
+}
+
+func (c *cli) backups() error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// the backups left out of the list are reported on stderr
+	list, err := newBackups(slog.New(slog.NewTextHandler(c.stderr, nil)), cfg, nil).List()
+	if err != nil {
+		return err
+	}
+
+	c.print(list, func(w io.Writer) {
+		for _, b := range list {
+			fmt.Fprintf(w, "%s  %10d bytes  schema %d  %s\n", //nolint:errcheck
+				b.Name, b.Size, b.SchemaVersion, b.CreatedAt.Local().Format(time.DateTime))
+		}
+	})
+
+	return nil
+}
+
+type restoreResult struct {
+	Backup   model.Backup `json:"backup"`
+	Target   string       `json:"target"`
+	Previous string       `json:"previous,omitempty"`
+}
+
+// restore takes the path of a backup or its name in the backup directory.
+func (c *cli) restore() error {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	path := c.args[0]
+	if _, err := os.Stat(path); err != nil {
+		if path, err = newBackups(slog.New(slog.DiscardHandler), cfg, nil).Path(path); err != nil {
+			return err
+		}
+	}
+
+	result := restoreResult{Target: cfg.Storage}
+	if _, err := os.Stat(cfg.Storage); err == nil {
+		result.Previous = cfg.Storage + ".pre-restore"
+	}
+
+	if result.Backup, err = backup.Restore(path, cfg.Storage, sqlite.SchemaVersion()); err != nil {
+		return err
+	}
+
+	c.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "restored %s to %s, schema version %d\n", result.Backup.Name, result.Target, result.Backup.SchemaVersion) //nolint:errcheck
+		if result.Previous != "" {
+			fmt.Fprintf(w, "the replaced database is kept as %s\n", result.Previous) //nolint:errcheck
+		}
+	})
+
+	return nil
+}
+
+// userOptions are the flags of the user create command.
+type userOptions struct {
+	admin bool
+}
+
+func (c *cli) userFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.user.admin, "admin", false, "let the tokens of the user in to the admin API")
+}
+
+type userResult struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Admin     bool      `json:"admin"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (c *cli) createUser() error {
+	accounts, closeDB, err := c.openAccounts()
+	if err != nil {
+		return err
+	}
+	defer closeDB() //nolint:errcheck
+
+	user, err := accounts.CreateUser(c.args[0], c.user.admin)
+	if err != nil {
+		return err
+	}
+
+	result := userResult{ID: user.ID.String(), Name: user.Name, Admin: user.Admin, CreatedAt: user.CreatedAt}
+
+	c.print(result, func(w io.Writer) {
+		role := "user"
+		if result.Admin {
+			role = "admin user"
+		}
+
+		fmt.Fprintf(w, "created %s %s, issue a token with: app token issue %s\n", role, result.Name, result.Name) //nolint:errcheck
+	})
+
+	return nil
+}
+
+// tokenOptions are the flags of the token issue command.
+type tokenOptions struct {
+	name string
+	ttl  time.Duration
+}
+
+func (c *cli) tokenFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.token.name, "name", "", "what the token is for, e.g. the client using it")
+	fs.DurationVar(&c.token.ttl, "ttl", 0, "how long the token is valid, it never expires when 0")
+}
+
+type tokenResult struct {
+	ID        string     `json:"id"`
+	User      string     `json:"user"`
+	Name      string     `json:"name,omitempty"`
+	Token     string     `json:"token"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// issueToken prints the secret of the token, it cannot be read again.
+func (c *cli) issueToken() error {
+	if c.token.ttl < 0 {
+		return &exitError{code: exitUsage, err: errors.New("-ttl must not be negative")}
+	}
+
+	accounts, closeDB, err := c.openAccounts()
+	if err != nil {
+		return err
+	}
+	defer closeDB() //nolint:errcheck
+
+	token, secret, err := accounts.IssueToken(c.args[0], c.token.name, c.token.ttl)
+	if err != nil {
+		return err
+	}
+
+	result := tokenResult{
+		ID:        token.ID.String(),
+		User:      c.args[0],
+		Name:      token.Name,
+		Token:     secret,
+		CreatedAt: token.CreatedAt,
+	}
+	if !token.ExpiresAt.IsZero() {
+		result.ExpiresAt = &token.ExpiresAt
+	}
+
+	c.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\n", result.Token) //nolint:errcheck
+		if result.ExpiresAt != nil {
+			fmt.Fprintf(c.stderr, "the token of %s expires at %s, it is not shown again\n", //nolint:errcheck
+				result.User, result.ExpiresAt.Local().Format(time.DateTime))
+		} else {
+			fmt.Fprintf(c.stderr, "the token of %s never expires, it is not shown again\n", result.User) //nolint:errcheck
+		}
+	})
+
+	return nil
+}
+
+// openAccounts opens the accounts of a database migrated to the current schema.
+func (c *cli) openAccounts() (*account.Service, func() error, error) {
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	driver, err := openDatabase(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := sqlite.CheckSchema(driver); err != nil {
+		_ = driver.DB.Close()
+		return nil, nil, err
+	}
+
+	storage, err := sqlite.NewAccount(driver)
+	if err != nil {
+		_ = driver.DB.Close()
+		return nil, nil, err
+	}
+
+	return account.New(accountRepo.NewRepository(storage)), driver.DB.Close, nil
+}
+
+// openDatabase opens the database of the config, it is not created nor migrated.
+func openDatabase(cfg *config.Config) (*pkgsql.Sqlite, error) {
+	if _, err := os.Stat(cfg.Storage); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDatabaseNotFound, cfg.Storage)
+	}
+
+	return makeSqliteDriver(cfg)
+}
+
+// counts formats the counters in the order of their keys.
+func counts(m map[string]int) string {
+	if len(m) == 0 {
+		return "none"
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	s := ""
+	for i, key := range keys {
+		if i > 0 {
+			s += ", "
+		}
+
+		s += fmt.Sprintf("%s %d", key, m[key])
+	}
+
+	return s
+}
+
+// unjoin lists the errors of errors.Join.
+func unjoin(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	var messages []string
+	for _, e := range joined.Unwrap() {
+		messages = append(messages, e.Error())
+	}
+
+	return messages
+}
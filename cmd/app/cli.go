@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"bookmarks/internal/config"
+)
+
+// Exit codes, the failures of a command follow sysexits.h.
+const (
+	exitOK      = 0
+	exitFailure = 1 // the command failed or found problems
+	exitUsage   = 2
+	exitConfig  = 78 // EX_CONFIG
+)
+
+// exitError ends the command with the code, a nil err exits silently:
+// the command has reported the failure itself.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit %d", e.code)
+	}
+
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// cli is the state shared by the commands.
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath string
+	json       bool
+	args       []string // the positional arguments of the command
+
+	copy  copyOptions
+	seed  seedOptions
+	user  userOptions
+	token tokenOptions
+}
+
+type command struct {
+	usage string
+	run   func(c *cli) error
+	args  string // the positional arguments in the usage, none when empty
+}
+
+// commands are named by one word or, within a group, by two.
+var commands = map[string]command{
+	"serve":              {"run the server, the default command", (*cli).serve, ""},
+	"migrate":            {"apply the pending schema migrations", (*cli).migrate, ""},
+	"check-config":       {"validate the config file", (*cli).checkConfig, ""},
+	"db integrity-check": {"check the database and the unique indexes", (*cli).integrityCheck, ""},
+	"db vacuum":          {"rebuild the database file to reclaim the free space", (*cli).vacuum, ""},
+	"db backup":          {"take an online backup of the database", (*cli).backup, ""},
+	"db backups":         {"list the backups, newest first", (*cli).backups, ""},
+	"db restore":         {"verify a backup and restore it, the server must be stopped", (*cli).restore, "<backup>"},
+	"user create":        {"create a user of the API, -admin for the admin API", (*cli).createUser, "<name>"},
+	"token issue":        {"issue an API token to a user, the token is printed once", (*cli).issueToken, "<user>"},
+	"stats":              {"count the stored records", (*cli).stats, ""},
+	"copy":               {"copy the bookmarks between storages and verify the copy", (*cli).copyStorage, "<from> <to>"},
+	"seed":               {"generate bookmarks for load tests and demos", (*cli).seedStorage, ""},
+}
+
+// commandFlags adds the flags of a command to the common ones.
+var commandFlags = map[string]func(c *cli, fs *flag.FlagSet){
+	"copy":        (*cli).copyFlags,
+	"seed":        (*cli).seedFlags,
+	"user create": (*cli).userFlags,
+	"token issue": (*cli).tokenFlags,
+}
+
+func main() {
+	c := &cli{stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+// run executes the command of args and returns the exit code; without a command,
+// e.g. app -config path, the server is run.
+func (c *cli) run(args []string) int {
+	name, rest := commandOf(args)
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(c.stderr, "unknown command %q\n\n", name) //nolint:errcheck
+		c.usage()
+
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&c.configPath, "config", "", "path to config file, CONFIG_PATH by default")
+	fs.BoolVar(&c.json, "json", false, "print machine-readable JSON")
+
+	if flags, ok := commandFlags[name]; ok {
+		flags(c, fs)
+	}
+
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: app %s [-config path] [-json] %s\n\n%s\n\n", name, cmd.args, cmd.usage) //nolint:errcheck
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		return exitUsage
+	}
+
+	if fs.NArg() != len(strings.Fields(cmd.args)) {
+		if cmd.args == "" {
+			fmt.Fprintf(c.stderr, "unexpected arguments: %s\n\n", strings.Join(fs.Args(), " ")) //nolint:errcheck
+		} else {
+			fmt.Fprintf(c.stderr, "want arguments: %s\n\n", cmd.args) //nolint:errcheck
+		}
+
+		fs.Usage()
+
+		return exitUsage
+	}
+
+	c.args = fs.Args()
+
+	return c.exit(cmd.run(c))
+}
+
+// commandOf splits the command name off the arguments.
+func commandOf(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "serve", args
+	}
+
+	if len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+		if _, ok := commands[args[0]+" "+args[1]]; ok {
+			return args[0] + " " + args[1], args[2:]
+		}
+	}
+
+	return args[0], args[1:]
+}
+
+// exit reports the error and returns its exit code.
+func (c *cli) exit(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	code := exitFailure
+
+	var exitErr *exitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.code
+		if exitErr.err == nil {
+			return code
+		}
+	case errors.Is(err, config.ErrConfigPathNotSet), errors.Is(err, config.ErrInvalidConfig):
+		code = exitConfig
+	}
+
+	if c.json {
+		_ = json.NewEncoder(c.stderr).Encode(map[string]string{"error": err.Error()})
+	} else {
+		fmt.Fprintln(c.stderr, "error:", err) //nolint:errcheck
+	}
+
+	return code
+}
+
+// print writes the result as JSON with -json, as text otherwise.
+func (c *cli) print(result any, text func(w io.Writer)) {
+	if !c.json {
+		text(c.stdout)
+		return
+	}
+
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(result)
+}
+
+func (c *cli) usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintln(c.stderr, "usage: app [command] [-config path] [-json]\n\ncommands:") //nolint:errcheck
+
+	for _, name := range names {
+		fmt.Fprintf(c.stderr, "  %-20s %s\n", strings.TrimSpace(name+" "+commands[name].args), commands[name].usage) //nolint:errcheck
+	}
+}
+
+// loadConfig reads and validates the config of the -config flag or CONFIG_PATH.
+func (c *cli) loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(config.Path(c.configPath))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+// writeConfig writes a config of a database in a temporary directory and returns its path.
+func writeConfig(t *testing.T, extra string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := "env: local\n" +
+		"storage_path: " + filepath.Join(dir, "storage.db") + "\n" +
+		"http_server:\n  user: admin\n  password: secret\n" + extra
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+// runCLI runs the command and returns its exit code, stdout and stderr.
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	c := &cli{stdout: &stdout, stderr: &stderr}
+
+	return c.run(args), stdout.String(), stderr.String()
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	t.Setenv("CONFIG_PATH", "")
+
+	valid := writeConfig(t, "")
+	invalid := writeConfig(t, "shutdown_timeout: -1s\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{name: "unknown command", args: []string{"frobnicate"}, code: exitUsage, stderr: `unknown command "frobnicate"`},
+		{name: "unknown flag", args: []string{"stats", "-verbose"}, code: exitUsage, stderr: "flag provided but not defined"},
+		{name: "unexpected argument", args: []string{"stats", "extra", "-config", valid}, code: exitUsage, stderr: "unexpected arguments: extra"},
+		{name: "missing argument", args: []string{"db", "restore", "-config", valid}, code: exitUsage, stderr: "want arguments: <backup>"},
+		{name: "help", args: []string{"migrate", "-h"}, code: exitOK, stderr: "usage: app migrate"},
+		{name: "config path not set", args: []string{"stats"}, code: exitConfig, stderr: "error:"},
+		{name: "config missing", args: []string{"stats", "-config", filepath.Join(t.TempDir(), "none.yaml")}, code: exitConfig, stderr: "error:"},
+		{name: "config valid", args: []string{"check-config", "-config", valid}, code: exitOK, stdout: "is valid"},
+		{name: "config invalid", args: []string{"check-config", "-config", invalid}, code: exitConfig, stdout: "shutdown_timeout"},
+		{name: "database missing", args: []string{"stats", "-config", valid}, code: exitFailure, stderr: "database does not exist, run migrate"},
+		{name: "negative ttl", args: []string{"token", "issue", "-ttl", "-1h", "-config", valid, "alice"}, code: exitUsage, stderr: "-ttl must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, tt.args...)
+
+			require.Equal(t, tt.code, code, "stdout: %s\nstderr: %s", stdout, stderr)
+			require.Contains(t, stdout, tt.stdout)
+			require.Contains(t, stderr, tt.stderr)
+		})
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	path := writeConfig(t, "")
+
+	code, stdout, stderr := runCLI(t, "migrate", "-config", path, "-json")
+	require.Equal(t, exitOK, code, stderr)
+
+	var migrated migrateResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &migrated))
+	require.Zero(t, migrated.From)
+	require.Positive(t, migrated.Applied)
+
+	code, stdout, stderr = runCLI(t, "check-config", "-config", path, "-json")
+	require.Equal(t, exitOK, code, stderr)
+
+	var checked checkConfigResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &checked))
+	require.True(t, checked.OK)
+	require.Equal(t, path, checked.Path)
+
+	code, stdout, stderr = runCLI(t, "user", "create", "-config", path, "-json", "bob")
+	require.Equal(t, exitOK, code, stderr)
+
+	var user userResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &user))
+	require.Equal(t, "bob", user.Name)
+	require.NotEmpty(t, user.ID)
+
+	code, stdout, stderr = runCLI(t, "user", "create", "-config", path, "-json", "bob")
+	require.Equal(t, exitFailure, code)
+	require.Empty(t, stdout)
+
+	var failed map[string]string
+	require.NoError(t, json.Unmarshal([]byte(stderr), &failed), stderr)
+	require.Contains(t, failed["error"], "user already exists")
+}
+
+func TestRun_Accounts(t *testing.T) {
+	path := writeConfig(t, "")
+
+	code, _, stderr := runCLI(t, "user", "create", "-config", path, "alice")
+	require.Equal(t, exitFailure, code)
+	require.Contains(t, stderr, "database does not exist, run migrate")
+
+	code, _, stderr = runCLI(t, "migrate", "-config", path)
+	require.Equal(t, exitOK, code, stderr)
+
+	code, stdout, stderr := runCLI(t, "user", "create", "-config", path, "alice")
+	require.Equal(t, exitOK, code, stderr)
+	require.Contains(t, stdout, "created user alice")
+
+	code, stdout, stderr = runCLI(t, "user", "create", "-admin", "-config", path, "root")
+	require.Equal(t, exitOK, code, stderr)
+	require.Contains(t, stdout, "created admin user root")
+
+	code, _, stderr = runCLI(t, "user", "create", "-config", path, "Not A Name")
+	require.Equal(t, exitFailure, code)
+	require.Contains(t, stderr, "invalid user name")
+
+	code, _, stderr = runCLI(t, "token", "issue", "-config", path, "bob")
+	require.Equal(t, exitFailure, code)
+	require.Contains(t, stderr, "user not found")
+
+	code, stdout, stderr = runCLI(t, "token", "issue", "-name", "bmctl", "-config", path, "alice")
+	require.Equal(t, exitOK, code, stderr)
+	require.True(t, strings.HasPrefix(stdout, "bmt_"), stdout)
+	require.Contains(t, stderr, "never expires")
+
+	code, stdout, stderr = runCLI(t, "token", "issue", "-ttl", "24h", "-config", path, "-json", "alice")
+	require.Equal(t, exitOK, code, stderr)
+
+	var token tokenResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &token))
+	require.Equal(t, "alice", token.User)
+	require.True(t, strings.HasPrefix(token.Token, "bmt_"))
+	require.NotNil(t, token.ExpiresAt)
+}
+
+func TestRun_CheckConfigIntervals(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"outbox interval", "outbox:\n  enabled: true\n  interval: -1s\n", "outbox.interval must be positive"},
+		{"outbox batch size", "outbox:\n  enabled: true\n  batch_size: -1\n", "outbox.batch_size must be positive"},
+		{"link check interval", "link_check:\n  enabled: true\n  interval: -1h\n", "link_check.interval must be positive"},
+		{"jobs poll interval", "jobs:\n  enabled: true\n  poll_interval: -5s\n", "jobs.poll_interval must be positive"},
+		{"events heartbeat", "events:\n  enabled: true\n  heartbeat: -15s\n", "events.heartbeat must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, "check-config", "-json", "-config", writeConfig(t, tt.config))
+
+			var result checkConfigResult
+			require.NoError(t, json.Unmarshal([]byte(stdout), &result), stderr)
+
+			require.Equal(t, exitConfig, code)
+			require.Len(t, result.Errors, 1, result.Errors)
+			require.Contains(t, result.Errors[0], tt.want)
+		})
+	}
+}
+
+func TestRun_CopySource(t *testing.T) {
+	dir := t.TempDir()
+
+	// an old database is left as it is, the copy asks to migrate it first
+	outdated := filepath.Join(dir, "outdated.db")
+	driver, err := pkgsql.New(pkgsql.SourceName(outdated))
+	require.NoError(t, err)
+	_, err = driver.DB.Exec("PRAGMA user_version = 1")
+	require.NoError(t, err)
+	require.NoError(t, driver.Close())
+
+	code, _, stderr := runCLI(t, "copy", "sqlite:"+outdated, "memory:")
+	require.Equal(t, exitFailure, code)
+	require.Contains(t, stderr, "schema is outdated, run migrate")
+
+	driver, err = pkgsql.New(pkgsql.SourceName(outdated))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = driver.Close() })
+
+	version, err := driver.Version()
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+
+	path := writeConfig(t, "")
+	code, _, stderr = runCLI(t, "migrate", "-config", path)
+	require.Equal(t, exitOK, code, stderr)
+
+	current := filepath.Join(filepath.Dir(path), "storage.db")
+
+	code, stdout, stderr := runCLI(t, "copy", "-json", "sqlite:"+current, "memory:")
+	require.Equal(t, exitOK, code, stderr)
+
+	var result copyResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	require.True(t, result.Verify.OK())
+}
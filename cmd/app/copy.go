@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"bookmarks/internal/service/seed"
+	"bookmarks/internal/service/transfer"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/internal/storage/pgsql"
+	"bookmarks/internal/storage/sqlite"
+	"bookmarks/pkg/postgres"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+var ErrInvalidStorage = errors.New("storage must be memory:, sqlite:<path> or postgres://<dsn>")
+
+// bookmarkStorage is what the copy and seed commands need of a storage.
+type bookmarkStorage interface {
+	transfer.Target
+	seed.Storage
+}
+
+// copyOptions are the flags of the copy command.
+type copyOptions struct {
+	batch      int
+	checkpoint string
+	verifyOnly bool
+}
+
+func (c *cli) copyFlags(fs *flag.FlagSet) {
+	fs.IntVar(&c.copy.batch, "batch", 500, "bookmarks read from the source at a time")
+	fs.StringVar(&c.copy.checkpoint, "checkpoint", "", "file keeping the progress, an interrupted copy resumes from it")
+	fs.BoolVar(&c.copy.verifyOnly, "verify-only", false, "compare the storages without copying")
+}
+
+type copyResult struct {
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Copy   *transfer.Result `json:"copy,omitempty"`
+	Verify transfer.Report  `json:"verify"`
+}
+
+func (c *cli) copyStorage() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	source, closeSource, err := openStorage(c.args[0], false)
+	if err != nil {
+		return err
+	}
+	defer closeSource()
+
+	target, closeTarget, err := openStorage(c.args[1], true)
+	if err != nil {
+		return err
+	}
+	defer closeTarget()
+
+	result := copyResult{From: redact(c.args[0]), To: redact(c.args[1])}
+
+	options := []transfer.Option{transfer.BatchSize(c.copy.batch)}
+	if c.copy.checkpoint != "" {
+		options = append(options, transfer.Checkpoint(c.copy.checkpoint, result.From+" -> "+result.To))
+	}
+
+	copier := transfer.New(slog.New(slog.NewTextHandler(c.stderr, nil)), source, target, options...)
+
+	if !c.copy.verifyOnly {
+		copied, err := copier.Copy(ctx)
+		if err != nil {
+			return err
+		}
+
+		result.Copy = &copied
+	}
+
+	if result.Verify, err = copier.Verify(ctx); err != nil {
+		return err
+	}
+
+	c.print(result, func(w io.Writer) {
+		if result.Copy != nil {
+			fmt.Fprintf(w, "copied %d bookmarks, skipped %d already in the target\n", result.Copy.Copied, result.Copy.Skipped) //nolint:errcheck
+
+			for _, conflict := range result.Copy.Conflicts {
+				fmt.Fprintf(w, "conflict: %s %s\n", conflict.Uuid, conflict.Reason) //nolint:errcheck
+			}
+		}
+
+		report := result.Verify
+		fmt.Fprintf(w, "source %d bookmarks, target %d\n", report.SourceCount, report.TargetCount) //nolint:errcheck
+
+		for _, id := range report.Missing {
+			fmt.Fprintf(w, "missing: %s\n", id) //nolint:errcheck
+		}
+
+		for _, id := range report.Mismatched {
+			fmt.Fprintf(w, "mismatched: %s\n", id) //nolint:errcheck
+		}
+
+		if report.OK() {
+			fmt.Fprintln(w, "verified: the target matches the source") //nolint:errcheck
+		}
+	})
+
+	if !result.Verify.OK() || (result.Copy != nil && len(result.Copy.Conflicts) > 0) {
+		return &exitError{code: exitFailure}
+	}
+
+	return nil
+}
+
+// openStorage opens the bookmark storage of spec, a target database is created or migrated.
+// A source database must exist and is opened read-only at the current schema, it is not migrated.
+// A memory storage starts empty: it only makes sense as a target, for a trial run.
+func openStorage(spec string, target bool) (bookmarkStorage, func(), error) {
+	switch {
+	case spec == "memory:":
+		return memory.NewBookmarkStorage(), func() {}, nil
+	case strings.HasPrefix(spec, "sqlite:"):
+		path := strings.TrimPrefix(spec, "sqlite:")
+		if !target {
+			return openSource(path)
+		}
+
+		driver, err := pkgsql.New(pkgsql.SourceName(path))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		s, err := sqlite.NewBookmark(driver)
+		if err != nil {
+			_ = driver.DB.Close()
+			return nil, nil, err
+		}
+
+		return s, func() { _ = driver.DB.Close() }, nil
+	case strings.HasPrefix(spec, "postgres://"), strings.HasPrefix(spec, "postgresql://"):
+		pg, err := postgres.New(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		s, err := pgsql.NewBookmark(pg)
+		if err != nil {
+			pg.Close()
+			return nil, nil, err
+		}
+
+		return s, pg.Close, nil
+	}
+
+	return nil, nil, &exitError{code: exitUsage, err: fmt.Errorf("%w: %q", ErrInvalidStorage, spec)}
+}
+
+// openSource opens the sqlite database of a copy source read-only, an outdated schema is refused:
+// the source is left as it is, run migrate on it first.
+func openSource(path string) (bookmarkStorage, func(), error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrDatabaseNotFound, path)
+	}
+
+	driver, err := pkgsql.New(pkgsql.SourceName("file:" + path + "?mode=ro"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := sqlite.OpenBookmark(driver)
+	if err != nil {
+		_ = driver.DB.Close()
+		return nil, nil, err
+	}
+
+	return s, func() { _ = driver.DB.Close() }, nil
+}
+
+// redact hides the password of a postgres dsn, the specs are printed and kept in the checkpoint.
+func redact(spec string) string {
+	u, err := url.Parse(spec)
+	if err != nil || u.User == nil {
+		return spec
+	}
+
+	return u.Redacted()
+}
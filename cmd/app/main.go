@@ -1,23 +1,50 @@
 package main
 
 import (
+	"context"
+	"io"
 	"log/slog"
-	"os"
-	"os/signal"
-	"syscall"
+	"path/filepath"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
 
 	"bookmarks/internal/config"
 	"bookmarks/internal/handler/fiber"
 	fiberv1 "bookmarks/internal/handler/fiber/v1"
+	"bookmarks/internal/handler/graphql"
+	"bookmarks/internal/handler/grpc"
+	grpcv1 "bookmarks/internal/handler/grpc/v1"
 	"bookmarks/internal/handler/net"
 	netv1 "bookmarks/internal/handler/net/v1"
+	"bookmarks/internal/logger"
+	"bookmarks/internal/metrics"
+	"bookmarks/internal/model"
+	accountRepo "bookmarks/internal/repository/account"
 	bookmarkRepo "bookmarks/internal/repository/bookmark"
+	jobRepo "bookmarks/internal/repository/job"
+	webhookRepo "bookmarks/internal/repository/webhook"
+	"bookmarks/internal/service/account"
+	"bookmarks/internal/service/archive"
+	"bookmarks/internal/service/backup"
 	bookmarkServ "bookmarks/internal/service/bookmark"
+	"bookmarks/internal/service/enrich"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/internal/service/linkcheck"
+	"bookmarks/internal/service/outbox"
+	"bookmarks/internal/service/probe"
+	"bookmarks/internal/service/stream"
+	"bookmarks/internal/service/webhook"
 	"bookmarks/internal/storage/memory"
 	"bookmarks/internal/storage/sqlite"
+	"bookmarks/internal/tracing"
+	"bookmarks/pkg/blob/fsstore"
 	"bookmarks/pkg/http"
 	"bookmarks/pkg/http/fiberserver"
+	"bookmarks/pkg/http/grpcserver"
 	"bookmarks/pkg/http/netserver"
+	"bookmarks/pkg/http/tlsconfig"
+	"bookmarks/pkg/lifecycle"
 	pkgsql "bookmarks/pkg/sqlite"
 )
 
@@ -26,107 +53,725 @@ const (
 	envProd   = "production"
 	servFiber = "fiber"
 	servCore  = "net/http"
+	servGRPC  = "grpc"
 )
 
-func main() {
+// service is the bookmark service as the HTTP stacks, the GraphQL endpoint and the gRPC server consume it.
+type service interface {
+	fiberv1.Service
+	netv1.Service
+	grpcv1.Service
+	graphql.Service
+}
+
+// runner is a background worker, it runs from Start to Shutdown.
+type runner interface {
+	Start()
+	Shutdown(ctx context.Context) error
+}
+
+// serve runs the server and the background workers until a signal or a server error.
+func serve(cfg *config.Config) error {
 	var err error
 
-	cfg := config.NewConfig()
-	log := setupLogger(cfg.Env)
+	log, logFile, err := setupLogger(cfg.Env, cfg.Log)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close() //nolint:errcheck
+
+	// the layers serving no request log with the default logger, see logger.FromContext
+	slog.SetDefault(log)
 
 	log.Debug("app main", slog.Any("config", cfg))
 
-	server := makeServer(log, cfg)
-	server.Start()
+	if len(cfg.TrackingParams) > 0 {
+		model.SetTrackingParams(cfg.TrackingParams)
+	}
+
+	tracer, err := makeTracing(cfg)
+	if err != nil {
+		return err
+	}
+
+	// db restore refuses to swap the database while a server holds the lock
+	unlock, err := pkgsql.LockShared(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer unlock() //nolint:errcheck
+
+	driver, err := makeSqliteDriver(cfg)
+	if err != nil {
+		return err
+	}
+
+	// the app closes the driver once it runs, a failure building it closes the driver here
+	running := false
+	defer func() {
+		if !running {
+			_ = driver.Close()
+		}
+	}()
+
+	storage, err := makeSqliteStorage(log, driver)
+	if err != nil {
+		return err
+	}
+
+	if tracer != nil {
+		storage = bookmarkRepo.Trace(storage, semconv.DBSystemNameSQLite)
+	}
+
+	collector, err := makeMetrics(cfg, driver)
+	if err != nil {
+		return err
+	}
+
+	if collector != nil {
+		storage = bookmarkRepo.Instrument(storage, collector)
+	}
+
+	repository := bookmarkRepo.NewRepository(storage)
+
+	queue, err := makeJobQueue(log, cfg, driver)
+	if err != nil {
+		return err
+	}
+
+	// the backups are scheduled before the queue starts
+	backups, err := makeBackups(log, cfg, driver, queue)
+	if err != nil {
+		return err
+	}
+
+	var options []bookmarkServ.Option
+
+	enricher := makeEnricher(log, cfg, repository)
+	if enricher != nil {
+		options = append(options, bookmarkServ.Enrichment(enricher))
+	}
+
+	archiver, err := makeArchiver(log, cfg, repository)
+	if err != nil {
+		return err
+	}
+
+	if archiver != nil {
+		options = append(options, bookmarkServ.Archiving(archiver, cfg.Archive.OnAppend))
+	}
 
-	// Waiting signal
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	checker := makeLinkChecker(log, cfg, repository)
 
-	select {
-	case s := <-interrupt:
-		log.Info("application.signal", slog.String("signal", s.String()))
-	case err = <-server.Notify():
-		log.Error("application.Notify", slog.Attr{
-			Key:   "error",
-			Value: slog.StringValue(err.Error()),
-		})
+	webhooks, err := makeWebhooks(log, cfg, driver, queue)
+	if err != nil {
+		return err
+	}
+
+	broker := makeBroker(log, cfg)
+
+	// the stream goes first, a failing webhook sink does not delay it
+	var sinks []outbox.Sink
+	if broker != nil {
+		sinks = append(sinks, broker)
 	}
+	if webhooks != nil {
+		sinks = append(sinks, webhooks)
+	}
+
+	relay := makeRelay(log, cfg, repository, sinks...)
 
-	// Shutdown
-	err = server.Shutdown()
+	probes := makeProbes(log, cfg, driver, queue)
+
+	certs, err := makeCertificates(log, cfg)
 	if err != nil {
-		log.Error("application.Shutdown", slog.Attr{
-			Key:   "error",
-			Value: slog.StringValue(err.Error()),
-		})
+		return err
+	}
+
+	accounts, err := makeAccounts(driver)
+	if err != nil {
+		return err
+	}
+
+	server := makeServer(log, cfg, bookmarkServ.NewService(repository, options...), accounts, queue, webhooks, broker, backups, collector, probes, certs)
+
+	app := lifecycle.New(log, lifecycle.ShutdownTimeout(cfg.ShutdownTimeout))
+
+	// the tracer goes first, the last spans end with the workers
+	if tracer != nil {
+		app.Register(lifecycle.Component{Name: "tracing", Stop: tracer.Shutdown})
+	}
+
+	app.Register(lifecycle.Component{
+		Name: "sqlite",
+		Stop: func(context.Context) error { return driver.Close() },
+	})
+
+	// the workers and the server enqueue jobs
+	deps := []string{"sqlite"}
+
+	if queue != nil {
+		app.Register(worker("jobs", queue, deps...))
+		deps = append(deps, "jobs")
+	}
+
+	if relay != nil {
+		app.Register(worker("outbox", relay, deps...))
+	}
+
+	if archiver != nil {
+		app.Register(worker("archive", archiver, deps...))
+	}
+
+	if enricher != nil {
+		app.Register(worker("enrichment", enricher, deps...))
+	}
+
+	if checker != nil {
+		app.Register(worker("link_check", checker, deps...))
+	}
+
+	if certs != nil {
+		app.Register(worker("tls", certs))
+		deps = append(deps, "tls")
+	}
+
+	app.Register(lifecycle.Component{
+		Name:      "server",
+		DependsOn: deps,
+		Start: func(context.Context) error {
+			server.Start()
+			return nil
+		},
+		Stop:   stopServer(server, probes, drainDelay(cfg)),
+		Notify: server.Notify(),
+	})
+
+	running = true
+
+	return app.Run(context.Background())
+}
+
+// stopServer fails the readiness first and keeps serving for the delay: the load balancers
+// stop sending requests before the server stops accepting them. The delay ends with ctx.
+func stopServer(server http.Server, probes *probe.Registry, delay time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		probes.Shutdown()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+
+		return server.Shutdown()
 	}
 }
 
-func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
+// drainDelay is the drain delay of the config, the gRPC server serves no readiness to wait for.
+func drainDelay(cfg *config.Config) time.Duration {
+	if cfg.Type == servGRPC {
+		return 0
+	}
+
+	return cfg.Probes.DrainDelay
+}
+
+// worker makes the component of a background worker.
+func worker(name string, w runner, dependsOn ...string) lifecycle.Component {
+	return lifecycle.Component{
+		Name:      name,
+		DependsOn: dependsOn,
+		Start: func(context.Context) error {
+			w.Start()
+			return nil
+		},
+		Stop: w.Shutdown,
+	}
+}
+
+// setupLogger builds the logger of cfg, the env picks the format and the level cfg leaves empty.
+func setupLogger(env string, cfg config.Log) (*slog.Logger, io.Closer, error) {
+	format, level := logger.FormatJSON, slog.LevelInfo
 	switch env {
 	case envLocal:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		format, level = logger.FormatText, slog.LevelDebug
 	case envProd:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-	default:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		level = slog.LevelWarn
 	}
 
-	return log
+	if cfg.Format != "" {
+		format = cfg.Format
+	}
+
+	if cfg.Level != "" {
+		var err error
+		if level, err = logger.ParseLevel(cfg.Level); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	options := []logger.Option{logger.Format(format), logger.Level(level)}
+
+	if cfg.File != "" {
+		options = append(options, logger.File(cfg.File), logger.Rotation(cfg.MaxSize, cfg.MaxBackups, cfg.MaxAge))
+	}
+
+	for path, name := range cfg.Packages {
+		level, err := logger.ParseLevel(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		options = append(options, logger.PackageLevel(path, level))
+	}
+
+	return logger.New(options...)
 }
 
-func makeServer(log *slog.Logger, cfg *config.Config) http.Server {
-	storage := makeSqliteStorage(cfg)
-	repository := bookmarkRepo.NewRepository(storage)
-	service := bookmarkServ.NewService(repository)
+func makeServer(
+	log *slog.Logger,
+	cfg *config.Config,
+	service service,
+	accounts *account.Service,
+	queue *jobs.Queue,
+	webhooks *webhook.Service,
+	broker *stream.Broker,
+	backups *backup.Service,
+	collector *metrics.Metrics,
+	probes *probe.Registry,
+	certs *tlsconfig.Loader,
+) http.Server {
+	admins := map[string]string{cfg.User: cfg.Password}
 
 	switch cfg.Type {
-	case servFiber:
-		return fiberserver.New(
+	case servGRPC:
+		var options []grpcv1.Option
+		if broker != nil {
+			options = append(options, grpcv1.Events(broker))
+		}
+
+		bookmarkHnd := grpcv1.NewHandler(service, options...)
+
+		return grpcserver.New(
 			log,
-			fiber.Register(
-				log,
-				fiberv1.NewHandler(log, service),
-			),
+			grpc.Register(bookmarkHnd),
+			grpcserver.Address(cfg.Address),
+			grpcserver.ServerOptions(grpc.Interceptors(log)...),
+			grpcserver.ShutdownHooks(bookmarkHnd.Close),
+			grpcserver.ShutdownTimeout(cfg.Timeout),
+			grpcserver.ReflectionEnable(),
+		)
+	case servFiber:
+		options := []fiber.Option{
+			fiber.Admin(admins),
+			fiber.Tokens(accounts),
+			fiber.GraphQL(graphql.NewHandler(service)),
+			fiber.Probes(fiberv1.NewProbeHandler(probes)),
+		}
+		if cfg.Private {
+			options = append(options, fiber.Private())
+		}
+		if queue != nil {
+			options = append(options, fiber.Jobs(fiberv1.NewJobHandler(queue)))
+		}
+		if webhooks != nil {
+			options = append(options, fiber.Webhooks(fiberv1.NewWebhookHandler(webhooks)))
+		}
+		if broker != nil {
+			options = append(options, fiber.Events(fiberv1.NewEventHandler(broker)))
+		}
+		if backups != nil {
+			options = append(options, fiber.Backups(fiberv1.NewBackupHandler(backups)))
+		}
+		if collector != nil {
+			options = append(options, fiber.Metrics(collector))
+		}
+		if cfg.Tracing.Enabled {
+			options = append(options, fiber.Tracing())
+		}
+
+		serverOptions := []fiberserver.Option{
 			fiberserver.Address(cfg.Address),
 			fiberserver.ReadTimeout(cfg.Timeout),
 			fiberserver.WriteTimeout(cfg.Timeout),
 			fiberserver.ShutdownTimeout(cfg.Timeout),
 			fiberserver.IdleTimeout(cfg.IdleTimeout),
-		)
-	default:
-		return netserver.New(
+		}
+		if certs != nil {
+			serverOptions = append(serverOptions, fiberserver.TLS(certs.Config()))
+		}
+
+		return fiberserver.New(
 			log,
-			net.Register(
+			fiber.Register(
 				log,
-				netv1.NewHandler(log, service),
+				fiberv1.NewHandler(service),
+				options...,
 			),
+			serverOptions...,
+		)
+	default:
+		options := []net.Option{
+			net.Admin(admins),
+			net.Tokens(accounts),
+			net.GraphQL(graphql.NewHandler(service)),
+			net.Probes(netv1.NewProbeHandler(probes)),
+		}
+		if cfg.Private {
+			options = append(options, net.Private())
+		}
+		if queue != nil {
+			options = append(options, net.Jobs(netv1.NewJobHandler(queue)))
+		}
+		if webhooks != nil {
+			options = append(options, net.Webhooks(netv1.NewWebhookHandler(webhooks)))
+		}
+		if broker != nil {
+			options = append(options, net.Events(netv1.NewEventHandler(broker)))
+		}
+		if backups != nil {
+			options = append(options, net.Backups(netv1.NewBackupHandler(backups)))
+		}
+		if collector != nil {
+			options = append(options, net.Metrics(collector))
+		}
+		if cfg.Tracing.Enabled {
+			options = append(options, net.Tracing())
+		}
+
+		serverOptions := []netserver.Option{
 			netserver.Address(cfg.Address),
 			netserver.ReadTimeout(cfg.Timeout),
 			netserver.WriteTimeout(cfg.Timeout),
 			netserver.ShutdownTimeout(cfg.Timeout),
 			netserver.IdleTimeout(cfg.IdleTimeout),
+		}
+		if certs != nil {
+			serverOptions = append(serverOptions, netserver.TLS(certs.Config()))
+		}
+
+		return netserver.New(
+			log,
+			net.Register(
+				log,
+				netv1.NewHandler(service),
+				options...,
+			),
+			serverOptions...,
 		)
 	}
 }
 
+func makeEnricher(log *slog.Logger, cfg *config.Config, repository enrich.Repository) *enrich.Worker {
+	if !cfg.Enrichment.Enabled {
+		return nil
+	}
+
+	options := []enrich.Option{
+		enrich.Workers(cfg.Enrichment.Workers),
+		enrich.PerHost(cfg.Enrichment.PerHost),
+		enrich.QueueSize(cfg.Enrichment.QueueSize),
+		enrich.Timeout(cfg.Enrichment.Timeout),
+		enrich.MaxBodySize(cfg.Enrichment.MaxBodySize),
+		enrich.Retries(cfg.Enrichment.Retries, cfg.Enrichment.Backoff),
+		enrich.UserAgent(cfg.Enrichment.UserAgent),
+	}
+
+	if cfg.Enrichment.AllowPrivate {
+		options = append(options, enrich.AllowPrivateNetworks())
+	}
+
+	return enrich.New(log, repository, options...)
+}
+
+func makeLinkChecker(log *slog.Logger, cfg *config.Config, repository linkcheck.Repository) *linkcheck.Checker {
+	if !cfg.LinkCheck.Enabled {
+		return nil
+	}
+
+	options := []linkcheck.Option{
+		linkcheck.Interval(cfg.LinkCheck.Interval),
+		linkcheck.RecheckAfter(cfg.LinkCheck.RecheckAfter),
+		linkcheck.Concurrency(cfg.LinkCheck.Concurrency),
+		linkcheck.Timeout(cfg.LinkCheck.Timeout),
+		linkcheck.FailureThreshold(cfg.LinkCheck.FailureThreshold),
+		linkcheck.BatchSize(cfg.LinkCheck.BatchSize),
+		linkcheck.History(cfg.LinkCheck.History),
+		linkcheck.UserAgent(cfg.LinkCheck.UserAgent),
+	}
+
+	if cfg.LinkCheck.AllowPrivate {
+		options = append(options, linkcheck.AllowPrivateNetworks())
+	}
+
+	return linkcheck.New(log, repository, options...)
+}
+
+func makeArchiver(log *slog.Logger, cfg *config.Config, repository archive.Repository) (*archive.Worker, error) {
+	if !cfg.Archive.Enabled {
+		return nil, nil
+	}
+
+	store, err := fsstore.New(fsstore.Dir(cfg.Archive.BlobPath))
+	if err != nil {
+		return nil, err
+	}
+
+	options := []archive.Option{
+		archive.Workers(cfg.Archive.Workers),
+		archive.QueueSize(cfg.Archive.QueueSize),
+		archive.Timeout(cfg.Archive.Timeout),
+		archive.MaxPageSize(cfg.Archive.MaxPageSize),
+		archive.MaxResourceSize(cfg.Archive.MaxResourceSize),
+		archive.MaxTotalSize(cfg.Archive.MaxTotalSize),
+		archive.MaxResources(cfg.Archive.MaxResources),
+		archive.UserAgent(cfg.Archive.UserAgent),
+	}
+
+	if cfg.Archive.AllowPrivate {
+		options = append(options, archive.AllowPrivateNetworks())
+	}
+
+	return archive.New(log, repository, store, options...), nil
+}
+
+func makeJobQueue(log *slog.Logger, cfg *config.Config, driver *pkgsql.Sqlite) (*jobs.Queue, error) {
+	if !cfg.Jobs.Enabled {
+		return nil, nil
+	}
+
+	storage, err := sqlite.NewJob(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs.New(
+		log,
+		jobRepo.NewRepository(storage),
+		jobs.Workers(cfg.Jobs.Workers),
+		jobs.PollInterval(cfg.Jobs.PollInterval),
+		jobs.Lease(cfg.Jobs.Lease),
+		jobs.Backoff(cfg.Jobs.Backoff, cfg.Jobs.MaxBackoff),
+		jobs.MaxAttempts(cfg.Jobs.MaxAttempts),
+		jobs.Retention(cfg.Jobs.Retention),
+	), nil
+}
+
+// makeAccounts serves the users and the API tokens created with the user and token commands.
+func makeAccounts(driver *pkgsql.Sqlite) (*account.Service, error) {
+	storage, err := sqlite.NewAccount(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return account.New(accountRepo.NewRepository(storage)), nil
+}
+
+// makeWebhooks needs the job queue to deliver on, webhooks are off without it.
+func makeWebhooks(log *slog.Logger, cfg *config.Config, driver *pkgsql.Sqlite, queue *jobs.Queue) (*webhook.Service, error) {
+	if !cfg.Webhooks.Enabled || queue == nil {
+		return nil, nil
+	}
+
+	storage, err := sqlite.NewWebhook(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []webhook.Option{
+		webhook.Timeout(cfg.Webhooks.Timeout),
+		webhook.Attempts(cfg.Webhooks.Attempts),
+		webhook.DisableAfter(cfg.Webhooks.DisableAfter),
+		webhook.History(cfg.Webhooks.History),
+		webhook.UserAgent(cfg.Webhooks.UserAgent),
+	}
+
+	if cfg.Webhooks.AllowPrivate {
+		options = append(options, webhook.AllowPrivateNetworks())
+	}
+
+	return webhook.New(log, webhookRepo.NewRepository(storage), queue, options...), nil
+}
+
+// makeBackups schedules the backups on the job queue, without it backups are taken on request only.
+func makeBackups(log *slog.Logger, cfg *config.Config, driver *pkgsql.Sqlite, queue *jobs.Queue) (*backup.Service, error) {
+	if !cfg.Backup.Enabled {
+		return nil, nil
+	}
+
+	backups := newBackups(log, cfg, driver)
+
+	if queue == nil {
+		log.Warn("scheduled backups need the job queue", slog.String("schedule", cfg.Backup.Schedule))
+		return backups, nil
+	}
+
+	if err := backups.Schedule(queue, cfg.Backup.Schedule); err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+func newBackups(log *slog.Logger, cfg *config.Config, driver *pkgsql.Sqlite) *backup.Service {
+	return backup.New(
+		log,
+		driver,
+		backup.Dir(cfg.Backup.Dir),
+		backup.Retention(cfg.Backup.Retention),
+	)
+}
+
+// makeMetrics collects the storage calls and the stats of the database pool,
+// the HTTP servers count their requests and serve the metrics.
+func makeMetrics(cfg *config.Config, driver *pkgsql.Sqlite) (*metrics.Metrics, error) {
+	if !cfg.Metrics.Enabled {
+		return nil, nil
+	}
+
+	collector := metrics.New()
+	if err := collector.RegisterDB("main", driver.DB); err != nil {
+		return nil, err
+	}
+
+	return collector, nil
+}
+
+// makeProbes checks the storage, its schema, its free disk space and the job queue for /health/ready.
+func makeProbes(log *slog.Logger, cfg *config.Config, driver *pkgsql.Sqlite, queue *jobs.Queue) *probe.Registry {
+	probes := probe.New(
+		log,
+		probe.Timeout(cfg.Probes.Timeout),
+		probe.CacheTTL(cfg.Probes.CacheTTL),
+	)
+
+	probes.Register("storage", probe.Storage(driver))
+	probes.Register("migrations", probe.Migrations(driver, sqlite.SchemaVersion()))
+	probes.Register("disk", probe.DiskSpace(filepath.Dir(cfg.Storage), cfg.Probes.MinFreeDisk<<20))
+
+	if queue != nil {
+		probes.Register("jobs", probe.QueueLag(queue, cfg.Probes.MaxQueueLag))
+	}
+
+	return probes
+}
+
+// makeCertificates loads the TLS files of the net/http and fiber servers, config.Validate checked the settings.
+func makeCertificates(log *slog.Logger, cfg *config.Config) (*tlsconfig.Loader, error) {
+	if !cfg.TLS.Enabled {
+		return nil, nil
+	}
+
+	minVersion, err := tlsconfig.ParseVersion(cfg.TLS.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := tlsconfig.ParseCipherSuites(cfg.TLS.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []tlsconfig.Option{
+		tlsconfig.MinVersion(minVersion),
+		tlsconfig.WatchInterval(cfg.TLS.ReloadInterval),
+	}
+	if len(cipherSuites) > 0 {
+		options = append(options, tlsconfig.CipherSuites(cipherSuites))
+	}
+	if cfg.TLS.ClientCA != "" {
+		options = append(options, tlsconfig.ClientCA(cfg.TLS.ClientCA))
+	}
+
+	return tlsconfig.New(log, cfg.TLS.CertFile, cfg.TLS.KeyFile, options...)
+}
+
+func makeTracing(cfg *config.Config) (*tracing.Tracing, error) {
+	if !cfg.Tracing.Enabled {
+		return nil, nil
+	}
+
+	return tracing.New(
+		context.Background(),
+		tracing.ServiceName(cfg.Tracing.ServiceName),
+		tracing.Exporter(cfg.Tracing.Exporter),
+		tracing.Endpoint(cfg.Tracing.Endpoint),
+		tracing.SampleRatio(cfg.Tracing.SampleRatio),
+	)
+}
+
+// makeBroker needs the outbox relay to feed it, the event stream is off without it.
+func makeBroker(log *slog.Logger, cfg *config.Config) *stream.Broker {
+	if !cfg.Events.Enabled || !cfg.Outbox.Enabled {
+		return nil
+	}
+
+	return stream.New(
+		log,
+		stream.History(cfg.Events.History),
+		stream.Buffer(cfg.Events.Buffer),
+		stream.Heartbeat(cfg.Events.Heartbeat),
+	)
+}
+
+func makeRelay(log *slog.Logger, cfg *config.Config, repository outbox.Repository, sinks ...outbox.Sink) *outbox.Relay {
+	if !cfg.Outbox.Enabled {
+		return nil
+	}
+
+	options := []outbox.Option{
+		outbox.Sinks(sinks...),
+		outbox.Interval(cfg.Outbox.Interval),
+		outbox.BatchSize(cfg.Outbox.BatchSize),
+		outbox.Timeout(cfg.Outbox.Timeout),
+		outbox.Retention(cfg.Outbox.Retention),
+		outbox.MaxAttempts(cfg.Outbox.MaxAttempts),
+	}
+
+	for _, sink := range cfg.Outbox.Sinks {
+		switch sink {
+		case "log":
+			options = append(options, outbox.Sinks(outbox.LogSink(log)))
+		default:
+			// rejected by config.Validate
+			log.Warn("unknown outbox sink", slog.String("sink", sink))
+		}
+	}
+
+	return outbox.New(log, repository, options...)
+}
+
 // nolint:unused
 func makeMapStorage() bookmarkRepo.Storage {
 	return memory.NewBookmarkStorage()
 }
 
-func makeSqliteStorage(cfg *config.Config) bookmarkRepo.Storage {
-	driver, err := pkgsql.New(pkgsql.SourceName(cfg.Storage))
-	if err != nil {
-		panic(err)
+func makeSqliteDriver(cfg *config.Config) (*pkgsql.Sqlite, error) {
+	options := []pkgsql.Option{pkgsql.SourceName(cfg.Storage)}
+	if cfg.Tracing.Enabled {
+		options = append(options, pkgsql.Tracing())
 	}
 
+	return pkgsql.New(options...)
+}
+
+func makeSqliteStorage(log *slog.Logger, driver *pkgsql.Sqlite) (bookmarkRepo.Storage, error) {
 	storage, err := sqlite.NewBookmark(driver)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	collisions, err := sqlite.Collisions(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(collisions) > 0 {
+		log.Warn("bookmarks not deduplicated, see db integrity-check", slog.Int("count", len(collisions)))
 	}
 
-	return storage
+	return storage, nil
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/service/probe"
+)
+
+// server records the readiness seen when it is shut down.
+type server struct {
+	probes *probe.Registry
+	ready  probe.Report
+	at     time.Time
+}
+
+func (s *server) Start()               {}
+func (s *server) Notify() <-chan error { return nil }
+
+func (s *server) Shutdown() error {
+	s.ready = s.probes.Ready(context.Background())
+	s.at = time.Now()
+
+	return nil
+}
+
+func TestStopServer(t *testing.T) {
+	probes := probe.New(slog.New(slog.DiscardHandler))
+	require.True(t, probes.Ready(t.Context()).OK())
+
+	s := &server{probes: probes}
+	begin := time.Now()
+
+	require.NoError(t, stopServer(s, probes, 50*time.Millisecond)(t.Context()))
+	require.False(t, s.ready.OK(), "the readiness fails before the server shuts down")
+	require.GreaterOrEqual(t, s.at.Sub(begin), 50*time.Millisecond)
+
+	// the shutdown budget ends the delay
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	s = &server{probes: probe.New(slog.New(slog.DiscardHandler))}
+	begin = time.Now()
+
+	require.NoError(t, stopServer(s, s.probes, time.Hour)(ctx))
+	require.Less(t, s.at.Sub(begin), time.Second)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"bookmarks/internal/service/seed"
+)
+
+// seedOptions are the flags of the seed command.
+type seedOptions struct {
+	n     int
+	seed  uint64
+	batch int
+	span  time.Duration
+	until string
+	to    string
+}
+
+func (c *cli) seedFlags(fs *flag.FlagSet) {
+	fs.IntVar(&c.seed.n, "n", 1000, "number of bookmarks")
+	fs.Uint64Var(&c.seed.seed, "seed", 1, "seed of the generator, the same seed gives the same bookmarks")
+	fs.IntVar(&c.seed.batch, "batch", 1000, "bookmarks written at a time")
+	fs.DurationVar(&c.seed.span, "span", 2*365*24*time.Hour, "how far back the bookmarks are created")
+	fs.StringVar(&c.seed.until, "until", seed.DefaultUntil.Format(time.RFC3339), "RFC 3339 time of the newest bookmark")
+	fs.StringVar(&c.seed.to, "to", "", "memory:, sqlite:<path> or postgres://<dsn>, the storage of the config by default")
+}
+
+type seedResult struct {
+	Storage string `json:"storage"`
+	Seed    uint64 `json:"seed"`
+	seed.Result
+}
+
+func (c *cli) seedStorage() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	spec := c.seed.to
+	if spec == "" {
+		cfg, err := c.loadConfig()
+		if err != nil {
+			return err
+		}
+
+		spec = "sqlite:" + cfg.Storage
+	}
+
+	options := []seed.Option{seed.Seed(c.seed.seed), seed.Span(c.seed.span), seed.BatchSize(c.seed.batch)}
+
+	if c.seed.until != "" {
+		until, err := time.Parse(time.RFC3339, c.seed.until)
+		if err != nil {
+			return &exitError{code: exitUsage, err: fmt.Errorf("-until: %w", err)}
+		}
+
+		options = append(options, seed.Until(until))
+	}
+
+	storage, closeStorage, err := openStorage(spec, true)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	seeder := seed.New(slog.New(slog.NewTextHandler(c.stderr, nil)), storage, options...)
+
+	result := seedResult{Storage: redact(spec), Seed: c.seed.seed}
+	if result.Result, err = seeder.Run(ctx, c.seed.n); err != nil {
+		return err
+	}
+
+	c.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "seeded %d bookmarks into %s with seed %d, skipped %d already there\n", //nolint:errcheck
+			result.Created, result.Storage, result.Seed, result.Skipped)
+	})
+
+	return nil
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"bookmarks/pkg/client"
+)
+
+var (
+	errNoValue   = errors.New("value is required: pass it as an argument or on stdin")
+	errEmptyFile = errors.New("nothing to import")
+)
+
+func (c *cli) add(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	title := fs.String("t", "", "title, an URL may omit it")
+	kind := fs.String("k", "", "kind: url, text, code or contact; detected from the value by default")
+	onConflict := fs.String("on-conflict", client.ConflictError, "when the value is bookmarked: error, ignore or update_title")
+
+	return func(ctx context.Context, args []string) error {
+		value, err := c.readValue(args)
+		if err != nil {
+			return err
+		}
+
+		b, created, err := c.client.Append(ctx, client.AppendRequest{
+			Title:      *title,
+			Value:      value,
+			Kind:       *kind,
+			OnConflict: *onConflict,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !created {
+			fmt.Fprintln(c.stderr, "already bookmarked") //nolint:errcheck
+		}
+
+		return printBookmarks(c.stdout, c.output, []client.Bookmark{b})
+	}
+}
+
+func (c *cli) get(*flag.FlagSet) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		ids, err := parseUUIDs(args)
+		if err != nil {
+			return err
+		}
+
+		bookmarks := make([]client.Bookmark, 0, len(ids))
+
+		for _, id := range ids {
+			b, err := c.client.View(ctx, id)
+			if err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+
+			bookmarks = append(bookmarks, b)
+		}
+
+		return printBookmarks(c.stdout, c.output, bookmarks)
+	}
+}
+
+func (c *cli) rm(*flag.FlagSet) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		ids, err := parseUUIDs(args)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := c.client.Delete(ctx, id); err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// edit reads the bookmark and changes the given fields, the read version guards against
+// overwriting a change made in between.
+func (c *cli) edit(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	title := fs.String("t", "", "new title")
+	kind := fs.String("k", "", "new kind: url, text, code or contact")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) == 0 {
+			return errUsage
+		}
+
+		ids, err := parseUUIDs(args[:1])
+		if err != nil {
+			return err
+		}
+
+		b, err := c.client.View(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+
+		change := client.ChangeRequest{Title: b.Title, Value: b.Value, Kind: b.Kind, Version: b.Version}
+
+		if b.TitleAuto {
+			// keep the title derived from the value
+			change.Title = ""
+		}
+
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		if set["t"] {
+			change.Title = *title
+		}
+
+		if set["k"] {
+			change.Kind = *kind
+		}
+
+		// a value given on neither keeps the current one
+		if len(args) > 1 || !c.isTerminal() {
+			value, err := c.readValue(args[1:])
+			switch {
+			case err == nil:
+				change.Value = value
+			case !errors.Is(err, errNoValue):
+				return err
+			}
+		}
+
+		b, err = c.client.Change(ctx, ids[0], change)
+		if err != nil {
+			return err
+		}
+
+		return printBookmarks(c.stdout, c.output, []client.Bookmark{b})
+	}
+}
+
+func (c *cli) ls(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	var filter client.ListFilter
+
+	fs.StringVar(&filter.Kind, "k", "", "only the bookmarks of the kind")
+	fs.StringVar(&filter.Health, "health", "", "only the links of the health: ok, failing or broken")
+	fs.IntVar(&filter.Limit, "limit", 0, "page size, the server default if 0")
+	fs.IntVar(&filter.Offset, "offset", 0, "bookmarks to skip")
+	all := fs.Bool("a", false, "list all pages")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		if !*all {
+			page, err := c.client.List(ctx, filter)
+			if err != nil {
+				return err
+			}
+
+			return printBookmarks(c.stdout, c.output, page.Items)
+		}
+
+		var bookmarks []client.Bookmark
+
+		err := c.client.All(ctx, filter, func(b client.Bookmark) error {
+			bookmarks = append(bookmarks, b)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return printBookmarks(c.stdout, c.output, bookmarks)
+	}
+}
+
+// search matches the text in the titles and values, case-insensitive; the API has no search,
+// so all bookmarks are listed and filtered here.
+func (c *cli) search(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	kind := fs.String("k", "", "only the bookmarks of the kind")
+
+	return func(ctx context.Context, args []string) error {
+		text := strings.ToLower(strings.Join(args, " "))
+		if strings.TrimSpace(text) == "" {
+			return errUsage
+		}
+
+		var bookmarks []client.Bookmark
+
+		err := c.client.All(ctx, client.ListFilter{Kind: *kind}, func(b client.Bookmark) error {
+			if strings.Contains(strings.ToLower(b.Title), text) || strings.Contains(strings.ToLower(b.Value), text) {
+				bookmarks = append(bookmarks, b)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return printBookmarks(c.stdout, c.output, bookmarks)
+	}
+}
+
+// export writes all bookmarks as JSON, or YAML with -o yaml.
+func (c *cli) export(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	kind := fs.String("k", "", "only the bookmarks of the kind")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+
+		var bookmarks []client.Bookmark
+
+		err := c.client.All(ctx, client.ListFilter{Kind: *kind}, func(b client.Bookmark) error {
+			bookmarks = append(bookmarks, b)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		output := c.output
+		if output == outputTable {
+			output = outputJSON
+		}
+
+		return printBookmarks(c.stdout, output, bookmarks)
+	}
+}
+
+// importFile appends the bookmarks of a JSON or YAML list, as written by export or by hand
+// with title, value and kind keys. Bookmarked values are skipped by default.
+func (c *cli) importFile(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	onConflict := fs.String("on-conflict", client.ConflictIgnore, "when a value is bookmarked: error, ignore or update_title")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) > 1 {
+			return errUsage
+		}
+
+		var r io.Reader = c.stdin
+
+		if len(args) == 1 && args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close() //nolint:errcheck
+
+			r = f
+		}
+
+		items, err := readImport(r)
+		if err != nil {
+			return err
+		}
+
+		var created, skipped int
+
+		for i, item := range items {
+			item.OnConflict = *onConflict
+
+			_, ok, err := c.client.Append(ctx, item)
+			if err != nil {
+				return fmt.Errorf("item %d: %w", i+1, err)
+			}
+
+			if ok {
+				created++
+			} else {
+				skipped++
+			}
+		}
+
+		fmt.Fprintf(c.stderr, "imported %d, skipped %d\n", created, skipped) //nolint:errcheck
+
+		return nil
+	}
+}
+
+// readImport reads a list of bookmarks, the keys match case-insensitive so both
+// the export and hand written files are read; JSON is read as YAML.
+func readImport(r io.Reader) ([]client.AppendRequest, error) {
+	var raw []map[string]any
+
+	if err := yaml.NewDecoder(r).Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errEmptyFile
+		}
+
+		return nil, fmt.Errorf("read import: %w", err)
+	}
+
+	items := make([]client.AppendRequest, 0, len(raw))
+
+	for _, fields := range raw {
+		var item client.AppendRequest
+
+		for key, val := range fields {
+			s, _ := val.(string)
+
+			switch strings.ToLower(key) {
+			case "title":
+				item.Title = s
+			case "value":
+				item.Value = s
+			case "kind":
+				item.Kind = s
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		return nil, errEmptyFile
+	}
+
+	return items, nil
+}
+
+// readValue joins the arguments, without them or with "-" the value is read from stdin.
+func (c *cli) readValue(args []string) (string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.Join(args, " "), nil
+	}
+
+	if len(args) == 0 && c.isTerminal() {
+		return "", errNoValue
+	}
+
+	data, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return "", fmt.Errorf("read stdin: %w", err)
+	}
+
+	if strings.TrimSpace(string(data)) == "" {
+		return "", errNoValue
+	}
+
+	return string(data), nil
+}
+
+// isTerminal tells whether stdin is a terminal rather than a pipe or a file.
+func (c *cli) isTerminal() bool {
+	f, ok := c.stdin.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func parseUUIDs(args []string) ([]uuid.UUID, error) {
+	if len(args) == 0 {
+		return nil, errUsage
+	}
+
+	ids := make([]uuid.UUID, 0, len(args))
+
+	for _, arg := range args {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uuid %q", arg)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
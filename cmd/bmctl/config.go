@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+// config is read from the file, the environment overrides it.
+type config struct {
+	URL      string `yaml:"url" env:"BMCTL_URL" env-default:"http://localhost:8080"`
+	User     string `yaml:"user" env:"BMCTL_USER"`
+	Password string `yaml:"password" env:"BMCTL_PASSWORD"`
+	// Token is an API token issued with app token issue, it is sent instead of the user and password
+	Token   string        `yaml:"token" env:"BMCTL_TOKEN"`
+	Timeout time.Duration `yaml:"timeout" env:"BMCTL_TIMEOUT" env-default:"30s"`
+}
+
+// loadConfig reads the file at path, $BMCTL_CONFIG or the bmctl/config.yaml of the user
+// config directory; without a file the config comes from the environment alone.
+func loadConfig(path string) (config, error) {
+	var cfg config
+
+	if path == "" {
+		path = os.Getenv("BMCTL_CONFIG")
+	}
+
+	if path == "" {
+		path = defaultConfigPath()
+		if _, err := os.Stat(path); err != nil {
+			path = ""
+		}
+	}
+
+	if path == "" {
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return config{}, fmt.Errorf("read env: %w", err)
+		}
+
+		return cfg, nil
+	}
+
+	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return config{}, fmt.Errorf("config %s does not exist", path)
+		}
+
+		return config{}, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "bmctl", "config.yaml")
+}
+
+func (c config) httpClient() *http.Client {
+	return &http.Client{Timeout: c.Timeout}
+}
@@ -0,0 +1,213 @@
+// Command bmctl manages bookmarks of a running server from the terminal.
+//
+//	bmctl [-config path] [-o table|json|yaml] <command> [flags] [args]
+//
+// The server URL and credentials are read from the config file and the BMCTL_* environment.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"bookmarks/pkg/client"
+)
+
+var errUsage = errors.New("usage")
+
+// cli is the state shared by the commands.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath string
+	output     string
+	client     *client.Client
+}
+
+type command struct {
+	usage string
+	// setup registers the flags of the command and returns its run
+	setup func(c *cli, fs *flag.FlagSet) func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"add":    {"add [-t title] [-k kind] [-on-conflict mode] [value|-]", (*cli).add},
+	"get":    {"get <uuid>...", (*cli).get},
+	"rm":     {"rm <uuid>...", (*cli).rm},
+	"edit":   {"edit <uuid> [-t title] [-k kind] [value|-]", (*cli).edit},
+	"ls":     {"ls [-k kind] [-health status] [-limit n] [-offset n] [-a]", (*cli).ls},
+	"search": {"search [-k kind] <text>", (*cli).search},
+	"import": {"import [-on-conflict mode] [file|-]", (*cli).importFile},
+	"export": {"export [-k kind]", (*cli).export},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	err := c.run(ctx, os.Args[1:])
+
+	stop()
+
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "bmctl:", describe(err)) //nolint:errcheck
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bmctl", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	c.commonFlags(fs)
+	fs.Usage = func() { c.usage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	name := fs.Arg(0)
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(c.stderr, "bmctl: unknown command %q\n", name) //nolint:errcheck
+		fs.Usage()
+
+		return errUsage
+	}
+
+	sub := flag.NewFlagSet(name, flag.ContinueOnError)
+	sub.SetOutput(c.stderr)
+	c.commonFlags(sub)
+	sub.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: bmctl %s\n", cmd.usage) //nolint:errcheck
+		sub.PrintDefaults()
+	}
+
+	runCmd := cmd.setup(c, sub)
+
+	rest, err := parseInterleaved(sub, fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+
+	switch c.output {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return fmt.Errorf("unknown output %q, want table, json or yaml", c.output)
+	}
+
+	cfg, err := loadConfig(c.configPath)
+	if err != nil {
+		return err
+	}
+
+	options := []client.Option{client.HTTPClient(cfg.httpClient())}
+	switch {
+	case cfg.Token != "":
+		options = append(options, client.BearerToken(cfg.Token))
+	case cfg.User != "":
+		options = append(options, client.BasicAuth(cfg.User, cfg.Password))
+	}
+
+	c.client, err = client.New(cfg.URL, options...)
+	if err != nil {
+		return err
+	}
+
+	if err := runCmd(ctx, rest); err != nil {
+		if errors.Is(err, errUsage) {
+			sub.Usage()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (c *cli) commonFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.configPath, "config", c.configPath, "path to config file, $BMCTL_CONFIG by default")
+	fs.StringVar(&c.output, "o", defaultOutput(c.output), "output: table, json or yaml")
+	fs.StringVar(&c.output, "output", defaultOutput(c.output), "output: table, json or yaml")
+}
+
+func (c *cli) usage(fs *flag.FlagSet) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var b strings.Builder
+
+	b.WriteString("usage: bmctl [-config path] [-o table|json|yaml] <command> [flags] [args]\n\ncommands:\n")
+
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s\n", commands[name].usage)
+	}
+
+	b.WriteString("\nflags:\n")
+	fmt.Fprint(c.stderr, b.String()) //nolint:errcheck
+	fs.PrintDefaults()
+}
+
+// parseInterleaved parses flags given before, between and after the positional arguments.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		// "--" ends the flags, the rest is positional
+		if consumed := args[:len(args)-fs.NArg()]; len(consumed) > 0 && consumed[len(consumed)-1] == "--" {
+			return append(rest, fs.Args()...), nil
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+func defaultOutput(current string) string {
+	if current == "" {
+		return outputTable
+	}
+
+	return current
+}
+
+// describe adds the stored bookmark to a conflict, so the user can retry with its version.
+func describe(err error) string {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.Existing != nil {
+		return fmt.Sprintf("%s: %s version %d", err, apiErr.Existing.Uuid, apiErr.Existing.Version)
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	netRouter "bookmarks/internal/handler/net"
+	netv1 "bookmarks/internal/handler/net/v1"
+	repo "bookmarks/internal/repository/bookmark"
+	srv "bookmarks/internal/service/bookmark"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/pkg/client"
+)
+
+// makeServer serves the bookmark API from a memory storage and points bmctl at it.
+func makeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := &http.Server{} //nolint:gosec // never listens
+	service := srv.NewService(repo.NewRepository(memory.NewBookmarkStorage()))
+	netRouter.Register(slog.New(slog.DiscardHandler), netv1.NewHandler(service))(server)
+
+	ts := httptest.NewServer(server.Handler)
+	t.Cleanup(ts.Close)
+
+	isolateConfig(t)
+	t.Setenv("BMCTL_URL", ts.URL)
+
+	return ts
+}
+
+// isolateConfig keeps the config of the user and the BMCTL_* environment out of a test.
+func isolateConfig(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	for _, name := range []string{"BMCTL_CONFIG", "BMCTL_URL", "BMCTL_USER", "BMCTL_PASSWORD", "BMCTL_TOKEN", "BMCTL_TIMEOUT"} {
+		t.Setenv(name, "")
+		require.NoError(t, os.Unsetenv(name))
+	}
+}
+
+type result struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+func run(t *testing.T, stdin io.Reader, args ...string) result {
+	t.Helper()
+
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	c := &cli{stdin: stdin, stdout: &stdout, stderr: &stderr}
+	err := c.run(context.Background(), args)
+
+	return result{stdout: stdout.String(), stderr: stderr.String(), err: err}
+}
+
+func decode(t *testing.T, data string) []client.Bookmark {
+	t.Helper()
+
+	var bookmarks []client.Bookmark
+	require.NoError(t, json.Unmarshal([]byte(data), &bookmarks))
+
+	return bookmarks
+}
+
+func TestRun(t *testing.T) {
+	makeServer(t)
+
+	res := run(t, nil, "-o", "json", "add", "-t", "first", "https://example.com/bmctl")
+	require.NoError(t, res.err)
+	created := decode(t, res.stdout)
+	require.Len(t, created, 1)
+	require.Equal(t, "first", created[0].Title)
+	require.Equal(t, "url", created[0].Kind)
+
+	// flags after the value and a value read from stdin
+	res = run(t, strings.NewReader("echo hi\n"), "add", "-", "-k", "code", "-t", "snippet", "-o", "json")
+	require.NoError(t, res.err)
+	snippet := decode(t, res.stdout)[0]
+	require.Equal(t, "code", snippet.Kind)
+	require.Equal(t, "echo hi", snippet.Value)
+
+	res = run(t, nil, "add", "-on-conflict", "ignore", "https://example.com/bmctl")
+	require.NoError(t, res.err)
+	require.Contains(t, res.stderr, "already bookmarked")
+
+	res = run(t, nil, "add", "https://example.com/bmctl")
+	require.ErrorIs(t, res.err, client.ErrBookmarkExists)
+	require.Contains(t, describe(res.err), created[0].Uuid.String())
+
+	res = run(t, nil, "get", created[0].Uuid.String(), "-o", "json")
+	require.NoError(t, res.err)
+	require.Equal(t, created, decode(t, res.stdout))
+
+	res = run(t, nil, "edit", created[0].Uuid.String(), "-t", "renamed", "-o", "json")
+	require.NoError(t, res.err)
+	edited := decode(t, res.stdout)[0]
+	require.Equal(t, "renamed", edited.Title)
+	require.Equal(t, created[0].Value, edited.Value)
+
+	res = run(t, nil, "ls", "-k", "url")
+	require.NoError(t, res.err)
+	require.Contains(t, res.stdout, "UUID")
+	require.Contains(t, res.stdout, "renamed")
+	require.NotContains(t, res.stdout, "snippet")
+
+	res = run(t, nil, "search", "ECHO")
+	require.NoError(t, res.err)
+	require.Contains(t, res.stdout, snippet.Uuid.String())
+	require.NotContains(t, res.stdout, created[0].Uuid.String())
+
+	res = run(t, strings.NewReader("- title: imported\n  value: plain note\n- value: https://example.com/bmctl\n"), "import")
+	require.NoError(t, res.err)
+	require.Contains(t, res.stderr, "imported 1, skipped 1")
+
+	res = run(t, nil, "export", "-o", "yaml")
+	require.NoError(t, res.err)
+	require.Contains(t, res.stdout, "Title: imported")
+
+	res = run(t, nil, "export")
+	require.NoError(t, res.err)
+	require.Len(t, decode(t, res.stdout), 3)
+
+	res = run(t, nil, "rm", created[0].Uuid.String(), snippet.Uuid.String())
+	require.NoError(t, res.err)
+
+	res = run(t, nil, "get", created[0].Uuid.String())
+	require.ErrorIs(t, res.err, client.ErrBookmarkNotFound)
+}
+
+func TestRun_Usage(t *testing.T) {
+	makeServer(t)
+
+	tests := []struct {
+		name   string
+		args   []string
+		err    error
+		stderr string
+	}{
+		{name: "no command", args: nil, err: errUsage, stderr: "commands:"},
+		{name: "unknown command", args: []string{"open"}, err: errUsage, stderr: `unknown command "open"`},
+		{name: "help", args: []string{"-h"}, err: flag.ErrHelp},
+		{name: "unknown flag", args: []string{"ls", "-x"}, stderr: "usage: bmctl ls"},
+		{name: "missing uuid", args: []string{"get"}, err: errUsage, stderr: "usage: bmctl get"},
+		{name: "extra argument", args: []string{"ls", "more"}, err: errUsage},
+		{name: "invalid uuid", args: []string{"rm", "nope"}},
+		{name: "unknown output", args: []string{"ls", "-o", "xml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := run(t, nil, tt.args...)
+			require.Error(t, res.err)
+
+			if tt.err != nil {
+				require.ErrorIs(t, res.err, tt.err)
+			}
+
+			require.Contains(t, res.stderr, tt.stderr)
+		})
+	}
+}
+
+func TestParseInterleaved(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		rest  []string
+		title string
+		all   bool
+	}{
+		{name: "flags first", args: []string{"-t", "x", "-a", "one", "two"}, rest: []string{"one", "two"}, title: "x", all: true},
+		{name: "flags last", args: []string{"one", "two", "-t", "x"}, rest: []string{"one", "two"}, title: "x"},
+		{name: "flags between", args: []string{"one", "-a", "two", "-t=x"}, rest: []string{"one", "two"}, title: "x", all: true},
+		{name: "double dash", args: []string{"one", "--", "-t", "x"}, rest: []string{"one", "-t", "x"}},
+		{name: "dash is positional", args: []string{"-", "-t", "x"}, rest: []string{"-"}, title: "x"},
+		{name: "none", args: nil, rest: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			title := fs.String("t", "", "")
+			all := fs.Bool("a", false, "")
+
+			rest, err := parseInterleaved(fs, tt.args)
+			require.NoError(t, err)
+			require.Equal(t, tt.rest, rest)
+			require.Equal(t, tt.title, *title)
+			require.Equal(t, tt.all, *all)
+		})
+	}
+}
+
+func TestReadValue(t *testing.T) {
+	// /dev/null is a character device like a terminal
+	terminal, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = terminal.Close() })
+
+	pipe := func(data string) io.Reader {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = r.Close() })
+
+		go func() {
+			_, _ = io.WriteString(w, data)
+			_ = w.Close()
+		}()
+
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		stdin io.Reader
+		args  []string
+		value string
+		err   error
+	}{
+		{name: "arguments", stdin: terminal, args: []string{"a", "note"}, value: "a note"},
+		{name: "arguments ignore stdin", stdin: pipe("piped"), args: []string{"given"}, value: "given"},
+		{name: "pipe", stdin: pipe("piped\n"), value: "piped\n"},
+		{name: "dash reads stdin", stdin: strings.NewReader("file"), args: []string{"-"}, value: "file"},
+		{name: "terminal without arguments", stdin: terminal, err: errNoValue},
+		{name: "empty pipe", stdin: pipe(" \n"), err: errNoValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cli{stdin: tt.stdin}
+
+			value, err := c.readValue(tt.args)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.value, value)
+		})
+	}
+}
+
+func TestReadImport(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		items []client.AppendRequest
+		err   error
+	}{
+		{
+			name:  "export json",
+			input: `[{"Uuid": "0190c5c8-0000-7000-8000-000000000000", "Title": "a", "Value": "https://example.com/a", "Kind": "url", "Version": 3}]`,
+			items: []client.AppendRequest{{Title: "a", Value: "https://example.com/a", Kind: "url"}},
+		},
+		{
+			name:  "hand written yaml",
+			input: "- title: b\n  VALUE: plain note\n- value: c\n",
+			items: []client.AppendRequest{{Title: "b", Value: "plain note"}, {Value: "c"}},
+		},
+		{name: "empty file", input: "", err: errEmptyFile},
+		{name: "empty list", input: "[]", err: errEmptyFile},
+		{name: "not a list", input: "title: a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := readImport(strings.NewReader(tt.input))
+
+			switch {
+			case tt.err != nil:
+				require.ErrorIs(t, err, tt.err)
+			case tt.items == nil:
+				require.Error(t, err)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tt.items, items)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		return path
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		isolateConfig(t)
+
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		require.Equal(t, "http://localhost:8080", cfg.URL)
+		require.Equal(t, 30*time.Second, cfg.Timeout)
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		isolateConfig(t)
+		t.Setenv("BMCTL_URL", "http://env:1")
+		t.Setenv("BMCTL_USER", "env")
+
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		require.Equal(t, "http://env:1", cfg.URL)
+		require.Equal(t, "env", cfg.User)
+	})
+
+	t.Run("environment overrides the file", func(t *testing.T) {
+		isolateConfig(t)
+		path := write(t, "url: http://file:1\nuser: file\ntimeout: 5s\n")
+		t.Setenv("BMCTL_URL", "http://env:1")
+
+		cfg, err := loadConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, "http://env:1", cfg.URL)
+		require.Equal(t, "file", cfg.User)
+		require.Equal(t, 5*time.Second, cfg.Timeout)
+	})
+
+	t.Run("flag over BMCTL_CONFIG", func(t *testing.T) {
+		isolateConfig(t)
+		t.Setenv("BMCTL_CONFIG", write(t, "url: http://config-env:1\n"))
+
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		require.Equal(t, "http://config-env:1", cfg.URL)
+
+		cfg, err = loadConfig(write(t, "url: http://flag:1\n"))
+		require.NoError(t, err)
+		require.Equal(t, "http://flag:1", cfg.URL)
+	})
+
+	t.Run("user config directory", func(t *testing.T) {
+		isolateConfig(t)
+
+		dir, err := os.UserConfigDir()
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "bmctl"), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bmctl", "config.yaml"), []byte("url: http://user:1\n"), 0o600))
+
+		cfg, err := loadConfig("")
+		require.NoError(t, err)
+		require.Equal(t, "http://user:1", cfg.URL)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		isolateConfig(t)
+
+		_, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.ErrorContains(t, err, "does not exist")
+	})
+}
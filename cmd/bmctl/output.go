@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"bookmarks/pkg/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+
+	maxCellWidth = 60
+)
+
+// printBookmarks writes the bookmarks in the chosen output, JSON and YAML keep the
+// field names of the API so an export can be imported back.
+func printBookmarks(w io.Writer, output string, bookmarks []client.Bookmark) error {
+	if bookmarks == nil {
+		bookmarks = []client.Bookmark{}
+	}
+
+	switch output {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(bookmarks)
+	case outputYAML:
+		return writeYAML(w, bookmarks)
+	default:
+		return writeTable(w, bookmarks)
+	}
+}
+
+func writeTable(w io.Writer, bookmarks []client.Bookmark) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "UUID\tKIND\tTITLE\tVALUE\tCREATED") //nolint:errcheck
+
+	for _, b := range bookmarks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", //nolint:errcheck
+			b.Uuid,
+			b.Kind,
+			cell(b.Title),
+			cell(b.Value),
+			b.CreatedAt.Local().Format("2006-01-02 15:04"),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// cell fits a value into one line of a table.
+func cell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+
+	if utf8.RuneCountInString(s) > maxCellWidth {
+		s = string([]rune(s)[:maxCellWidth-1]) + "…"
+	}
+
+	return s
+}
+
+// writeYAML converts the JSON of v, a node read from JSON is in flow style and is
+// reset to the block style.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle
+
+	if node.Kind == yaml.ScalarNode && node.Style&yaml.DoubleQuotedStyle != 0 && node.Tag == "!!str" {
+		// plain where YAML reads it back as the same string
+		node.Style &^= yaml.DoubleQuotedStyle
+	}
+
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -1,39 +1,229 @@
 package config
 
 import (
-	"flag"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+
+	"bookmarks/pkg/http/tlsconfig"
+)
+
+var (
+	ErrConfigPathNotSet = errors.New("config path is not set: pass -config or set CONFIG_PATH")
+	ErrInvalidConfig    = errors.New("invalid config")
 )
 
 type contextKey uint
 
 const (
 	FieldUUID contextKey = iota
+	FieldJobID
+	FieldWebhookID
 )
 
 type Config struct {
 	Env        string `yaml:"env" env-default:"production"`
 	Storage    string `yaml:"storage_path" env-required:"true"`
 	HTTPServer `yaml:"http_server"`
+	Canonical  `yaml:"canonical"`
+	Enrichment Enrichment `yaml:"enrichment"`
+	LinkCheck  LinkCheck  `yaml:"link_check"`
+	Archive    Archive    `yaml:"archive"`
+	Jobs       Jobs       `yaml:"jobs"`
+	Outbox     Outbox     `yaml:"outbox"`
+	Webhooks   Webhooks   `yaml:"webhooks"`
+	Events     Events     `yaml:"events"`
+	Backup     Backup     `yaml:"backup"`
+	Metrics    Metrics    `yaml:"metrics"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Log        Log        `yaml:"log"`
+	Probes     Probes     `yaml:"probes"`
+
+	// ShutdownTimeout is the budget of stopping the server, the workers and the storage together
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"30s"`
 }
 
 type HTTPServer struct {
+	// Type selects the server: net/http, fiber or grpc; the gRPC server serves the bookmark API only
 	Type        string        `yaml:"type" env-default:"net/http"`
 	Address     string        `yaml:"address" env-default:"localhost:8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 	User        string        `yaml:"user" env-required:"true"`
 	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// Private asks for the admin credentials or an API token on the whole API, not on /v1/admin only
+	Private bool `yaml:"private" env-default:"false"`
+	TLS     TLS  `yaml:"tls"`
+}
+
+// TLS serves HTTPS from the net/http and fiber servers. The files are reloaded when they change
+// or on SIGHUP, a client CA requires the clients to present a certificate signed by it.
+type TLS struct {
+	Enabled  bool   `yaml:"enabled" env-default:"false"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	ClientCA string `yaml:"client_ca_file"`
+	// MinVersion is 1.2 or 1.3, CipherSuites restricts the TLS 1.2 suites by their Go names
+	MinVersion     string        `yaml:"min_version" env-default:"1.2"`
+	CipherSuites   []string      `yaml:"cipher_suites"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"30s"`
+}
+
+type Canonical struct {
+	// TrackingParams replaces the default list of URL query parameters dropped on deduplication
+	TrackingParams []string `yaml:"tracking_params" env:"CANONICAL_TRACKING_PARAMS"`
+}
+
+// Enrichment configures the background fetch of link metadata.
+type Enrichment struct {
+	Enabled      bool          `yaml:"enabled" env-default:"true"`
+	Workers      int           `yaml:"workers" env-default:"4"`
+	PerHost      int           `yaml:"per_host" env-default:"2"`
+	QueueSize    int           `yaml:"queue_size" env-default:"1024"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxBodySize  int64         `yaml:"max_body_size" env-default:"1048576"`
+	Retries      int           `yaml:"retries" env-default:"2"`
+	Backoff      time.Duration `yaml:"backoff" env-default:"1s"`
+	UserAgent    string        `yaml:"user_agent" env-default:"bookmarks-bot/1.0"`
+	AllowPrivate bool          `yaml:"allow_private_networks" env-default:"false"`
+}
+
+// LinkCheck configures the scheduled dead-link check of URL bookmarks.
+type LinkCheck struct {
+	Enabled          bool          `yaml:"enabled" env-default:"true"`
+	Interval         time.Duration `yaml:"interval" env-default:"1h"`
+	RecheckAfter     time.Duration `yaml:"recheck_after" env-default:"24h"`
+	Concurrency      int           `yaml:"concurrency" env-default:"4"`
+	Timeout          time.Duration `yaml:"timeout" env-default:"10s"`
+	FailureThreshold int           `yaml:"failure_threshold" env-default:"3"`
+	BatchSize        int           `yaml:"batch_size" env-default:"100"`
+	History          int           `yaml:"history" env-default:"20"`
+	UserAgent        string        `yaml:"user_agent" env-default:"bookmarks-bot/1.0"`
+	AllowPrivate     bool          `yaml:"allow_private_networks" env-default:"false"`
+}
+
+// Archive configures saving self-contained copies of bookmarked pages into the blob store.
+type Archive struct {
+	Enabled         bool          `yaml:"enabled" env-default:"true"`
+	OnAppend        bool          `yaml:"on_append" env-default:"false"`
+	BlobPath        string        `yaml:"blob_path" env-default:"./storage/blobs"`
+	Workers         int           `yaml:"workers" env-default:"2"`
+	QueueSize       int           `yaml:"queue_size" env-default:"256"`
+	Timeout         time.Duration `yaml:"timeout" env-default:"1m"`
+	MaxPageSize     int64         `yaml:"max_page_size" env-default:"5242880"`
+	MaxResourceSize int64         `yaml:"max_resource_size" env-default:"2097152"`
+	MaxTotalSize    int64         `yaml:"max_total_size" env-default:"20971520"`
+	MaxResources    int           `yaml:"max_resources" env-default:"100"`
+	UserAgent       string        `yaml:"user_agent" env-default:"bookmarks-bot/1.0"`
+	AllowPrivate    bool          `yaml:"allow_private_networks" env-default:"false"`
+}
+
+// Jobs configures the durable background job queue.
+type Jobs struct {
+	Enabled      bool          `yaml:"enabled" env-default:"true"`
+	Workers      int           `yaml:"workers" env-default:"4"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	Lease        time.Duration `yaml:"lease" env-default:"1m"`
+	Backoff      time.Duration `yaml:"backoff" env-default:"10s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env-default:"1h"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"5"`
+	Retention    time.Duration `yaml:"retention" env-default:"168h"`
+}
+
+// Outbox configures the relay publishing bookmark events written to the outbox.
+type Outbox struct {
+	Enabled   bool          `yaml:"enabled" env-default:"true"`
+	Interval  time.Duration `yaml:"interval" env-default:"1s"`
+	BatchSize int           `yaml:"batch_size" env-default:"100"`
+	Timeout   time.Duration `yaml:"timeout" env-default:"10s"`
+	Retention time.Duration `yaml:"retention" env-default:"168h"`
+	// MaxAttempts is how many runs an event may fail before it is dead and not published anymore
+	MaxAttempts int `yaml:"max_attempts" env-default:"10"`
+	// Sinks lists where events are published to: log
+	Sinks []string `yaml:"sinks" env-default:"log"`
+}
+
+// Webhooks configures the signed delivery of outbox events to subscribed endpoints,
+// deliveries run on the job queue.
+type Webhooks struct {
+	Enabled      bool          `yaml:"enabled" env-default:"true"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	Attempts     int           `yaml:"attempts" env-default:"8"`
+	DisableAfter int           `yaml:"disable_after" env-default:"15"`
+	History      int           `yaml:"history" env-default:"100"`
+	UserAgent    string        `yaml:"user_agent" env-default:"bookmarks-webhook/1.0"`
+	AllowPrivate bool          `yaml:"allow_private_networks" env-default:"false"`
+}
+
+// Events configures the server-sent event stream of the bookmark changes, it is fed by the outbox.
+type Events struct {
+	Enabled   bool          `yaml:"enabled" env-default:"true"`
+	History   int           `yaml:"history" env-default:"1000"`
+	Buffer    int           `yaml:"buffer" env-default:"64"`
+	Heartbeat time.Duration `yaml:"heartbeat" env-default:"15s"`
+}
+
+// Backup configures the online backups of the sqlite storage, scheduled backups run on the job queue.
+type Backup struct {
+	Enabled bool   `yaml:"enabled" env-default:"true"`
+	Dir     string `yaml:"dir" env-default:"./storage/backups"`
+	// Schedule is a cron expression, a macro such as @daily or "@every <duration>"
+	Schedule string `yaml:"schedule" env-default:"@daily"`
+	// Retention is the number of the newest backups kept
+	Retention int `yaml:"retention" env-default:"7"`
+}
+
+// Metrics configures the Prometheus metrics, served under /metrics by the net/http and fiber servers.
+type Metrics struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+}
+
+// Tracing configures the OpenTelemetry traces of the requests, exported over OTLP/HTTP or printed to stdout.
+type Tracing struct {
+	Enabled  bool   `yaml:"enabled" env-default:"false"`
+	Exporter string `yaml:"exporter" env-default:"otlp"`
+	// Endpoint is the URL of the OTLP/HTTP collector, e.g. http://localhost:4318; OTEL_EXPORTER_OTLP_ENDPOINT applies when empty
+	Endpoint    string  `yaml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"bookmarks"`
+}
+
+// Probes configures the readiness checks served under /health/ready by the net/http and fiber servers.
+type Probes struct {
+	// Timeout bounds every check, CacheTTL is how long a report answers the probes before the checks run again
+	Timeout     time.Duration `yaml:"timeout" env-default:"2s"`
+	CacheTTL    time.Duration `yaml:"cache_ttl" env-default:"5s"`
+	MaxQueueLag time.Duration `yaml:"max_queue_lag" env-default:"5m"`
+	// MinFreeDisk is the space in megabytes left on the file system of the storage
+	MinFreeDisk uint64 `yaml:"min_free_disk_mb" env-default:"100"`
+	// DrainDelay is how long the server keeps serving once the readiness fails on shutdown,
+	// the load balancers see the failing probe and stop sending requests meanwhile
+	DrainDelay time.Duration `yaml:"drain_delay" env-default:"5s"`
+}
+
+// Log configures the logger of the app. The format and the level default by env: text and debug
+// on local, json and warn on production, json and info otherwise.
+type Log struct {
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+	// File receives the records instead of stdout, it is rotated once it reaches MaxSize megabytes
+	File       string        `yaml:"file"`
+	MaxSize    int           `yaml:"max_size" env-default:"100"`
+	MaxBackups int           `yaml:"max_backups" env-default:"5"`
+	MaxAge     time.Duration `yaml:"max_age" env-default:"720h"`
+	// Packages overrides the level by import path, e.g. bookmarks/internal/service/outbox: warn
+	Packages map[string]string `yaml:"packages"`
 }
 
 func (c Config) LogValue() slog.Value {
 	return slog.GroupValue(
 		slog.String("env", c.Env),
 		slog.String("storage", c.Storage),
+		slog.Duration("shutdown_timeout", c.ShutdownTimeout),
 		slog.Group("http_server",
 			slog.String("type", c.Type),
 			slog.String("address", c.Address),
@@ -41,33 +231,269 @@ func (c Config) LogValue() slog.Value {
 			slog.Duration("idle_timeout", c.IdleTimeout),
 			slog.String("user", c.User),
 			slog.String("password", "***"),
+			slog.Bool("private", c.Private),
+			slog.Group("tls",
+				slog.Bool("enabled", c.TLS.Enabled),
+				slog.String("cert_file", c.TLS.CertFile),
+				slog.String("key_file", c.TLS.KeyFile),
+				slog.String("client_ca_file", c.TLS.ClientCA),
+				slog.String("min_version", c.TLS.MinVersion),
+				slog.Any("cipher_suites", c.TLS.CipherSuites),
+				slog.Duration("reload_interval", c.TLS.ReloadInterval),
+			),
+		),
+		slog.Group("canonical",
+			slog.Any("tracking_params", c.TrackingParams),
+		),
+		slog.Group("enrichment",
+			slog.Bool("enabled", c.Enrichment.Enabled),
+			slog.Int("workers", c.Enrichment.Workers),
+			slog.Int("per_host", c.Enrichment.PerHost),
+			slog.Duration("timeout", c.Enrichment.Timeout),
+			slog.Int("retries", c.Enrichment.Retries),
+			slog.Bool("allow_private_networks", c.Enrichment.AllowPrivate),
+		),
+		slog.Group("link_check",
+			slog.Bool("enabled", c.LinkCheck.Enabled),
+			slog.Duration("interval", c.LinkCheck.Interval),
+			slog.Duration("recheck_after", c.LinkCheck.RecheckAfter),
+			slog.Int("concurrency", c.LinkCheck.Concurrency),
+			slog.Int("failure_threshold", c.LinkCheck.FailureThreshold),
+			slog.Bool("allow_private_networks", c.LinkCheck.AllowPrivate),
+		),
+		slog.Group("archive",
+			slog.Bool("enabled", c.Archive.Enabled),
+			slog.Bool("on_append", c.Archive.OnAppend),
+			slog.String("blob_path", c.Archive.BlobPath),
+			slog.Int("workers", c.Archive.Workers),
+			slog.Duration("timeout", c.Archive.Timeout),
+			slog.Bool("allow_private_networks", c.Archive.AllowPrivate),
+		),
+		slog.Group("jobs",
+			slog.Bool("enabled", c.Jobs.Enabled),
+			slog.Int("workers", c.Jobs.Workers),
+			slog.Duration("poll_interval", c.Jobs.PollInterval),
+			slog.Duration("lease", c.Jobs.Lease),
+			slog.Int("max_attempts", c.Jobs.MaxAttempts),
+			slog.Duration("retention", c.Jobs.Retention),
+		),
+		slog.Group("outbox",
+			slog.Bool("enabled", c.Outbox.Enabled),
+			slog.Duration("interval", c.Outbox.Interval),
+			slog.Int("batch_size", c.Outbox.BatchSize),
+			slog.Duration("retention", c.Outbox.Retention),
+			slog.Int("max_attempts", c.Outbox.MaxAttempts),
+			slog.Any("sinks", c.Outbox.Sinks),
+		),
+		slog.Group("webhooks",
+			slog.Bool("enabled", c.Webhooks.Enabled),
+			slog.Duration("timeout", c.Webhooks.Timeout),
+			slog.Int("attempts", c.Webhooks.Attempts),
+			slog.Int("disable_after", c.Webhooks.DisableAfter),
+			slog.Bool("allow_private_networks", c.Webhooks.AllowPrivate),
+		),
+		slog.Group("events",
+			slog.Bool("enabled", c.Events.Enabled),
+			slog.Int("history", c.Events.History),
+			slog.Int("buffer", c.Events.Buffer),
+			slog.Duration("heartbeat", c.Events.Heartbeat),
+		),
+		slog.Group("backup",
+			slog.Bool("enabled", c.Backup.Enabled),
+			slog.String("dir", c.Backup.Dir),
+			slog.String("schedule", c.Backup.Schedule),
+			slog.Int("retention", c.Backup.Retention),
+		),
+		slog.Group("metrics",
+			slog.Bool("enabled", c.Metrics.Enabled),
+		),
+		slog.Group("tracing",
+			slog.Bool("enabled", c.Tracing.Enabled),
+			slog.String("exporter", c.Tracing.Exporter),
+			slog.String("endpoint", c.Tracing.Endpoint),
+			slog.Float64("sample_ratio", c.Tracing.SampleRatio),
+			slog.String("service_name", c.Tracing.ServiceName),
+		),
+		slog.Group("log",
+			slog.String("format", c.Log.Format),
+			slog.String("level", c.Log.Level),
+			slog.String("file", c.Log.File),
+			slog.Int("max_size", c.Log.MaxSize),
+			slog.Int("max_backups", c.Log.MaxBackups),
+			slog.Duration("max_age", c.Log.MaxAge),
+			slog.Any("packages", c.Log.Packages),
+		),
+		slog.Group("probes",
+			slog.Duration("timeout", c.Probes.Timeout),
+			slog.Duration("cache_ttl", c.Probes.CacheTTL),
+			slog.Duration("max_queue_lag", c.Probes.MaxQueueLag),
+			slog.Uint64("min_free_disk_mb", c.Probes.MinFreeDisk),
+			slog.Duration("drain_delay", c.Probes.DrainDelay),
 		),
 	)
 }
 
-func NewConfig() *Config {
-	cfgPath := fetchConfigPath()
-	if cfgPath == "" {
-		panic("CONFIG_PATH is not set")
+// Validate reports the settings cleanenv can not check, all problems joined.
+func (c Config) Validate() error {
+	var errs []error
+
+	switch c.Type {
+	case "net/http", "fiber", "grpc":
+	default:
+		errs = append(errs, fmt.Errorf("%w: http_server.type %q, want net/http, fiber or grpc", ErrInvalidConfig, c.Type))
 	}
 
-	var cfg Config
-	if err := cleanenv.ReadConfig(cfgPath, &cfg); err != nil {
-		panic(err)
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("%w: http_server.timeout must be positive", ErrInvalidConfig))
 	}
 
-	return &cfg
+	if c.Private && c.Type == "grpc" {
+		errs = append(errs, fmt.Errorf("%w: http_server.private is served by net/http and fiber only", ErrInvalidConfig))
+	}
+
+	if c.TLS.Enabled {
+		if c.Type == "grpc" {
+			errs = append(errs, fmt.Errorf("%w: http_server.tls is served by net/http and fiber only", ErrInvalidConfig))
+		}
+
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			errs = append(errs, fmt.Errorf("%w: http_server.tls needs cert_file and key_file", ErrInvalidConfig))
+		}
+
+		if _, err := tlsconfig.ParseVersion(c.TLS.MinVersion); err != nil {
+			errs = append(errs, fmt.Errorf("%w: http_server.tls.min_version: %w", ErrInvalidConfig, err))
+		}
+
+		if _, err := tlsconfig.ParseCipherSuites(c.TLS.CipherSuites); err != nil {
+			errs = append(errs, fmt.Errorf("%w: http_server.tls.cipher_suites: %w", ErrInvalidConfig, err))
+		}
+
+		if c.TLS.ReloadInterval < 0 {
+			errs = append(errs, fmt.Errorf("%w: http_server.tls.reload_interval must not be negative", ErrInvalidConfig))
+		}
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("%w: shutdown_timeout must be positive", ErrInvalidConfig))
+	}
+
+	if c.Outbox.Enabled && c.Outbox.MaxAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("%w: outbox.max_attempts must be positive", ErrInvalidConfig))
+	}
+
+	if c.Outbox.Enabled && c.Outbox.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("%w: outbox.batch_size must be positive", ErrInvalidConfig))
+	}
+
+	for _, sink := range c.Outbox.Sinks {
+		if sink != "log" {
+			errs = append(errs, fmt.Errorf("%w: outbox.sinks: unknown sink %q", ErrInvalidConfig, sink))
+		}
+	}
+
+	workers := []struct {
+		name    string
+		enabled bool
+		n       int
+	}{
+		{"enrichment.workers", c.Enrichment.Enabled, c.Enrichment.Workers},
+		{"link_check.concurrency", c.LinkCheck.Enabled, c.LinkCheck.Concurrency},
+		{"archive.workers", c.Archive.Enabled, c.Archive.Workers},
+		{"jobs.workers", c.Jobs.Enabled, c.Jobs.Workers},
+	}
+
+	for _, w := range workers {
+		if w.enabled && w.n <= 0 {
+			errs = append(errs, fmt.Errorf("%w: %s must be positive", ErrInvalidConfig, w.name))
+		}
+	}
+
+	// a ticker panics on a period of 0, a negative one is never due
+	intervals := []struct {
+		name    string
+		enabled bool
+		d       time.Duration
+	}{
+		{"outbox.interval", c.Outbox.Enabled, c.Outbox.Interval},
+		{"link_check.interval", c.LinkCheck.Enabled, c.LinkCheck.Interval},
+		{"jobs.poll_interval", c.Jobs.Enabled, c.Jobs.PollInterval},
+		{"events.heartbeat", c.Events.Enabled, c.Events.Heartbeat},
+	}
+
+	for _, i := range intervals {
+		if i.enabled && i.d <= 0 {
+			errs = append(errs, fmt.Errorf("%w: %s must be positive", ErrInvalidConfig, i.name))
+		}
+	}
+
+	if c.Backup.Retention <= 0 {
+		errs = append(errs, fmt.Errorf("%w: backup.retention must be positive", ErrInvalidConfig))
+	}
+
+	if c.Tracing.Enabled {
+		switch c.Tracing.Exporter {
+		case "otlp", "stdout":
+		default:
+			errs = append(errs, fmt.Errorf("%w: tracing.exporter %q, want otlp or stdout", ErrInvalidConfig, c.Tracing.Exporter))
+		}
+
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			errs = append(errs, fmt.Errorf("%w: tracing.sample_ratio must be between 0 and 1", ErrInvalidConfig))
+		}
+	}
+
+	switch c.Log.Format {
+	case "", "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("%w: log.format %q, want text or json", ErrInvalidConfig, c.Log.Format))
+	}
+
+	if c.Log.Level != "" {
+		if err := new(slog.Level).UnmarshalText([]byte(c.Log.Level)); err != nil {
+			errs = append(errs, fmt.Errorf("%w: log.level: %w", ErrInvalidConfig, err))
+		}
+	}
+
+	for path, level := range c.Log.Packages {
+		if err := new(slog.Level).UnmarshalText([]byte(level)); err != nil {
+			errs = append(errs, fmt.Errorf("%w: log.packages %s: %w", ErrInvalidConfig, path, err))
+		}
+	}
+
+	if c.Probes.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("%w: probes.timeout must be positive", ErrInvalidConfig))
+	}
+
+	if c.Probes.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("%w: probes.cache_ttl must not be negative", ErrInvalidConfig))
+	}
+
+	if c.Probes.DrainDelay < 0 || c.Probes.DrainDelay >= c.ShutdownTimeout {
+		errs = append(errs, fmt.Errorf("%w: probes.drain_delay must not be negative and be shorter than shutdown_timeout", ErrInvalidConfig))
+	}
+
+	return errors.Join(errs...)
 }
 
-func fetchConfigPath() string {
-	var res string
+// Load reads the config file at path, the environment overrides it.
+func Load(path string) (*Config, error) {
+	if path == "" {
+		return nil, ErrConfigPathNotSet
+	}
 
-	flag.StringVar(&res, "config", "", "path to config file")
-	flag.Parse()
+	var cfg Config
+	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	return &cfg, nil
+}
 
-	if res == "" {
-		res = os.Getenv("CONFIG_PATH")
+// Path returns the path given by flag, or CONFIG_PATH when the flag is empty.
+func Path(flag string) string {
+	if flag != "" {
+		return flag
 	}
 
-	return res
+	return os.Getenv("CONFIG_PATH")
 }
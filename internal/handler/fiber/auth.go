@@ -0,0 +1,81 @@
+package fiber
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/account"
+)
+
+// TokenAuthenticator resolves the principal of an API token, see account.Service.
+type TokenAuthenticator interface {
+	Authenticate(secret string) (model.Principal, error)
+}
+
+// authenticate lets a request through with a bearer token of a user or the basic credentials
+// of an admin, keeping its principal in the context; anything else is answered with 401.
+func authenticate(credentials map[string]string, tokens TokenAuthenticator) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		scheme, value, _ := strings.Cut(ctx.Get(fiber.HeaderAuthorization), " ")
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.EqualFold(scheme, "Bearer") && tokens != nil:
+			principal, err := tokens.Authenticate(value)
+			switch {
+			case err == nil:
+				log := logger.FromContext(ctx.Context()).With(slog.String("user", principal.Name))
+				ctx.SetContext(handler.NewPrincipalContext(logger.NewContext(ctx.Context(), log), principal))
+
+				return ctx.Next()
+			case !errors.Is(err, account.ErrInvalidToken):
+				return ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+			}
+		case strings.EqualFold(scheme, "Basic"):
+			if user, password, ok := basicCredentials(value); ok && validCredentials(credentials, user, password) {
+				ctx.SetContext(handler.NewPrincipalContext(ctx.Context(), model.Principal{Name: user, Admin: true}))
+
+				return ctx.Next()
+			}
+		}
+
+		ctx.Append(fiber.HeaderWWWAuthenticate, `Basic realm="admin"`, "Bearer")
+
+		return ErrorResponse(ctx, "unauthorized", http.StatusUnauthorized)
+	}
+}
+
+// requireAdmin answers 403 to a principal authenticate let in that is not an admin.
+func requireAdmin(ctx fiber.Ctx) error {
+	if principal, _ := handler.PrincipalFromContext(ctx.Context()); !principal.Admin {
+		return ErrorResponse(ctx, "forbidden", http.StatusForbidden)
+	}
+
+	return ctx.Next()
+}
+
+// basicCredentials decodes the user and the password of a basic Authorization header.
+func basicCredentials(value string) (string, string, bool) {
+	raw, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", "", false
+	}
+
+	return strings.Cut(string(raw), ":")
+}
+
+// validCredentials compares plain text credentials in constant time.
+func validCredentials(credentials map[string]string, user, password string) bool {
+	expected, ok := credentials[user]
+
+	return ok && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
@@ -1,17 +1,26 @@
 package fiber
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/requestid"
 
 	"bookmarks/internal/handler"
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/tracing"
 )
 
+// ErrorResponse writes the error and logs it with the logger of the request,
+// server faults are answered with their status text.
 func ErrorResponse(ctx fiber.Ctx, err string, code int) error {
+	logger.FromContext(ctx.Context()).Log(ctx.Context(), logger.StatusLevel(code), err, slog.Int("status", code))
+
 	errCtx := &handler.ErrorContext{
 		RequestID: requestid.FromContext(ctx),
+		TraceID:   tracing.TraceID(ctx.Context()),
 	}
 
 	if code >= 500 {
@@ -20,3 +29,14 @@ func ErrorResponse(ctx fiber.Ctx, err string, code int) error {
 
 	return ctx.Status(code).JSON(handler.NewError(err, errCtx))
 }
+
+func ConflictResponse(ctx fiber.Ctx, err string, existing model.Bookmark) error {
+	logger.FromContext(ctx.Context()).Warn(err, slog.Int("status", http.StatusConflict))
+
+	errCtx := &handler.ErrorContext{
+		RequestID: requestid.FromContext(ctx),
+		TraceID:   tracing.TraceID(ctx.Context()),
+	}
+
+	return ctx.Status(http.StatusConflict).JSON(handler.NewConflict(err, errCtx, existing))
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/pkg/http/tlsconfig"
+)
+
+// ClientIdentity puts the identity of a mutual TLS client in the context of the request,
+// the handlers read it with tlsconfig.FromContext.
+func ClientIdentity() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		if id, ok := tlsconfig.ClientIdentity(ctx.RequestCtx().TLSConnectionState()); ok {
+			ctx.SetContext(tlsconfig.NewContext(ctx.Context(), id))
+		}
+
+		return ctx.Next()
+	}
+}
@@ -1,36 +1,81 @@
 package middleware
 
 import (
+	"encoding/base64"
 	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/requestid"
+
+	"bookmarks/internal/logger"
+	"bookmarks/internal/tracing"
+	"bookmarks/pkg/http/tlsconfig"
 )
 
+// Logger puts the logger of the request in its context, with the request id, the basic auth user,
+// the mutual TLS client, the route and the trace id, and logs the completed request: client errors
+// below server faults. It runs after requestid, Tracing and ClientIdentity.
 func Logger(log *slog.Logger) fiber.Handler {
 	return func(ctx fiber.Ctx) error {
 		start := time.Now()
 
+		attrs := []any{slog.String("request_id", requestid.FromContext(ctx))}
+		if user, ok := basicAuthUser(ctx.Get(fiber.HeaderAuthorization)); ok {
+			attrs = append(attrs, slog.String("user", user))
+		}
+		if id, ok := tlsconfig.FromContext(ctx.Context()); ok {
+			attrs = append(attrs, slog.String("client", id.CommonName))
+		}
+		if traceID := tracing.TraceID(ctx.Context()); traceID != "" {
+			attrs = append(attrs, slog.String("trace_id", traceID))
+		}
+
+		requestLog, done := logger.WithRoute(log.With(attrs...), func() string { return routePattern(ctx) })
+		defer done()
+
+		ctx.SetContext(logger.NewContext(ctx.Context(), requestLog))
+
 		err := ctx.Next()
 
 		duration := time.Since(start)
 
-		entry := log.With(
+		entry := requestLog.With(
 			slog.String("method", ctx.Method()),
 			slog.String("remote_addr", ctx.IP()),
 			slog.String("user_agent", ctx.Get(fiber.HeaderUserAgent)),
-			slog.String("request_id", requestid.FromContext(ctx)),
 			slog.Duration("latency", duration),
 		)
 
-		entry.Info(
-			ctx.OriginalURL(),
-			slog.String("status", strconv.Itoa(ctx.Response().StatusCode())),
-			slog.String("bytes", strconv.Itoa(len(ctx.Response().Body()))),
-		)
+		status := responseStatus(ctx, err)
+		attrs = []any{slog.String("status", strconv.Itoa(status))}
+
+		// reading a streamed body would drain the stream before it is sent
+		if !ctx.Response().IsBodyStream() {
+			attrs = append(attrs, slog.String("bytes", strconv.Itoa(len(ctx.Response().Body()))))
+		}
+
+		entry.Log(ctx.Context(), logger.StatusLevel(status), ctx.OriginalURL(), attrs...)
 
 		return err
 	}
 }
+
+// basicAuthUser reads the user of basic credentials, they are checked on the admin routes only.
+func basicAuthUser(header string) (string, bool) {
+	encoded, ok := strings.CutPrefix(header, "Basic ")
+	if !ok {
+		return "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+
+	user, _, ok := strings.Cut(string(decoded), ":")
+
+	return user, ok
+}
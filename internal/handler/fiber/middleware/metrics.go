@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/metrics"
+)
+
+// Metrics counts the requests by the route pattern, e.g. /v1/bookmark/:uuid<guid>,
+// known once fiber has routed the request.
+func Metrics(m *metrics.Metrics) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		done := m.TrackRequest()
+
+		err := ctx.Next()
+
+		done(routePattern(ctx), ctx.Method(), responseStatus(ctx, err))
+
+		return err
+	}
+}
+
+// responseStatus is the status of the response to be written, a returned error
+// is written by the error handler after the middlewares.
+func responseStatus(ctx fiber.Ctx, err error) int {
+	if err == nil {
+		return ctx.Response().StatusCode()
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+
+	return http.StatusInternalServerError
+}
+
+func routePattern(ctx fiber.Ctx) string {
+	if ctx.Matched() {
+		return ctx.FullPath()
+	}
+
+	return metrics.Unmatched
+}
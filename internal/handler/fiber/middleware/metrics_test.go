@@ -0,0 +1,42 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler/fiber/middleware"
+	"bookmarks/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+
+	app := fiber.New()
+	app.Use(middleware.Metrics(m))
+	app.Get("/v1/bookmark/:uuid", func(ctx fiber.Ctx) error {
+		if ctx.Params("uuid") == "missing" {
+			return fiber.ErrNotFound
+		}
+
+		return ctx.SendString("ok")
+	})
+
+	for _, path := range []string{"/v1/bookmark/a", "/v1/bookmark/b", "/v1/bookmark/missing", "/wp-login.php"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	require.Contains(t, body, `bookmarks_http_requests_total{method="GET",route="/v1/bookmark/:uuid",status="200"} 2`)
+	require.Contains(t, body, `bookmarks_http_requests_total{method="GET",route="/v1/bookmark/:uuid",status="404"} 1`)
+	require.Contains(t, body, `bookmarks_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	require.Contains(t, body, `bookmarks_http_requests_in_flight 0`)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"bookmarks/internal/tracing"
+)
+
+// Tracing serves the request in a server span, the child of the span of the traceparent header if any.
+// The span is named by the route pattern, e.g. GET /v1/bookmark/:uuid<guid>, once fiber has routed the request.
+// The handlers find it in ctx.Context().
+func Tracing() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		parent := otel.GetTextMapPropagator().Extract(ctx.Context(), headerCarrier{ctx})
+
+		spanCtx, span := tracing.Tracer().Start(parent, ctx.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Method()),
+				semconv.URLPath(ctx.Path()),
+				semconv.ClientAddress(ctx.IP()),
+				semconv.UserAgentOriginal(ctx.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+
+		ctx.SetContext(spanCtx)
+
+		err := ctx.Next()
+
+		status, route := responseStatus(ctx, err), routePattern(ctx)
+
+		span.SetName(ctx.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
+
+// headerCarrier reads the propagated trace context from the request headers.
+type headerCarrier struct {
+	ctx fiber.Ctx
+}
+
+func (c headerCarrier) Get(key string) string {
+	return c.ctx.Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.ctx.Request().Header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.ctx.GetReqHeaders()))
+	for key := range c.ctx.GetReqHeaders() {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
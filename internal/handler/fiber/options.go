@@ -0,0 +1,134 @@
+package fiber
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/metrics"
+)
+
+type JobHandler interface {
+	List(ctx fiber.Ctx) error
+	View(ctx fiber.Ctx) error
+	Retry(ctx fiber.Ctx) error
+}
+
+type WebhookHandler interface {
+	Create(ctx fiber.Ctx) error
+	List(ctx fiber.Ctx) error
+	View(ctx fiber.Ctx) error
+	Update(ctx fiber.Ctx) error
+	Delete(ctx fiber.Ctx) error
+	Deliveries(ctx fiber.Ctx) error
+}
+
+type BackupHandler interface {
+	Create(ctx fiber.Ctx) error
+	List(ctx fiber.Ctx) error
+}
+
+type EventHandler interface {
+	Stream(ctx fiber.Ctx) error
+	Close()
+}
+
+type ProbeHandler interface {
+	Ready(ctx fiber.Ctx) error
+	Close()
+}
+
+// routes are the optional parts of the router.
+type routes struct {
+	jobHnd     JobHandler
+	webhookHnd WebhookHandler
+	backupHnd  BackupHandler
+	eventHnd   EventHandler
+	probeHnd   ProbeHandler
+	graphqlHnd http.Handler
+	metrics    *metrics.Metrics
+	tracing    bool
+	admins     map[string]string // user -> password
+	tokens     TokenAuthenticator
+	private    bool
+}
+
+type Option func(*routes)
+
+// Jobs mounts the job administration under /v1/admin/jobs, it requires Admin.
+func Jobs(h JobHandler) Option {
+	return func(r *routes) {
+		r.jobHnd = h
+	}
+}
+
+// Webhooks mounts the webhook subscriptions under /v1/admin/webhooks, it requires Admin.
+func Webhooks(h WebhookHandler) Option {
+	return func(r *routes) {
+		r.webhookHnd = h
+	}
+}
+
+// Backups mounts the database backups under /v1/admin/backups, it requires Admin.
+func Backups(h BackupHandler) Option {
+	return func(r *routes) {
+		r.backupHnd = h
+	}
+}
+
+// Events mounts the event stream under /v1/events, the streams are closed when the app shuts down.
+func Events(h EventHandler) Option {
+	return func(r *routes) {
+		r.eventHnd = h
+	}
+}
+
+// Probes serves the readiness under /health/ready, it fails as soon as the server shuts down.
+func Probes(h ProbeHandler) Option {
+	return func(r *routes) {
+		r.probeHnd = h
+	}
+}
+
+// GraphQL mounts the GraphQL endpoint under /graphql.
+func GraphQL(h http.Handler) Option {
+	return func(r *routes) {
+		r.graphqlHnd = h
+	}
+}
+
+// Metrics collects the request metrics and serves all metrics under /metrics.
+func Metrics(m *metrics.Metrics) Option {
+	return func(r *routes) {
+		r.metrics = m
+	}
+}
+
+// Tracing serves every request in a server span, continuing the trace of a W3C traceparent header.
+func Tracing() Option {
+	return func(r *routes) {
+		r.tracing = true
+	}
+}
+
+// Admin protects /v1/admin with basic authentication, without credentials or Tokens the admin routes are not mounted.
+func Admin(credentials map[string]string) Option {
+	return func(r *routes) {
+		r.admins = credentials
+	}
+}
+
+// Tokens lets the users in with their API tokens wherever the admin credentials are accepted,
+// the admin routes take the tokens of admin users only.
+func Tokens(t TokenAuthenticator) Option {
+	return func(r *routes) {
+		r.tokens = t
+	}
+}
+
+// Private requires the admin credentials or an API token on all of /v1 and /graphql.
+func Private() Option {
+	return func(r *routes) {
+		r.private = true
+	}
+}
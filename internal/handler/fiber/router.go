@@ -3,41 +3,98 @@ package fiber
 import (
 	"log/slog"
 	"net/http"
+	"sync"
 
 	"github.com/gofiber/contrib/v3/swaggo"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
 	"github.com/gofiber/fiber/v3/middleware/requestid"
 	"github.com/swaggo/swag"
+	"go.opentelemetry.io/otel/trace"
 
 	"bookmarks/docs"
 	"bookmarks/internal/handler/fiber/middleware"
+	"bookmarks/internal/logger"
 )
 
 type BookmarkHandler interface {
 	Append(ctx fiber.Ctx) error
 	View(ctx fiber.Ctx) error
+	List(ctx fiber.Ctx) error
+	Metadata(ctx fiber.Ctx) error
+	Health(ctx fiber.Ctx) error
+	RequestArchive(ctx fiber.Ctx) error
+	Archive(ctx fiber.Ctx) error
 	Change(ctx fiber.Ctx) error
 	Delete(ctx fiber.Ctx) error
+	Changes(ctx fiber.Ctx) error
+	Push(ctx fiber.Ctx) error
 }
 
+// swagger keeps a process wide registry that panics on a second registration
+var registerSwagger sync.Once
+
 // Swagger spec:
 // @title       Go Example REST API
 // @version     1.0
 // @host        localhost:8082
 // @BasePath    /v1
+// @securityDefinitions.basic BasicAuth
 func Register(
 	log *slog.Logger,
 	bookmarkHnd BookmarkHandler,
+	options ...Option,
 ) func(s *fiber.App) {
-	swag.Register(swag.Name, docs.SwaggerInfo)
+	registerSwagger.Do(func() { swag.Register(swag.Name, docs.SwaggerInfo) })
+
+	var opts routes
+	for _, opt := range options {
+		opt(&opts)
+	}
 
 	return func(s *fiber.App) {
 		s.Use(requestid.New())
+		if opts.tracing {
+			s.Use(middleware.Tracing())
+		}
+		s.Use(middleware.ClientIdentity())
 		s.Use(middleware.Logger(log))
+		if opts.metrics != nil {
+			s.Use(middleware.Metrics(opts.metrics))
+		}
 
 		s.Get("/health", healthHandler)
+		s.Get("/health/live", healthHandler)
+		if opts.probeHnd != nil {
+			s.Get("/health/ready", opts.probeHnd.Ready)
+
+			s.Hooks().OnPreShutdown(func() error {
+				opts.probeHnd.Close()
+				return nil
+			})
+		} else {
+			s.Get("/health/ready", healthHandler)
+		}
+
+		if opts.metrics != nil {
+			s.Get("/metrics", adaptor.HTTPHandler(opts.metrics.Handler()))
+		}
+
+		auth := authenticate(opts.admins, opts.tokens)
+
+		if opts.graphqlHnd != nil {
+			graphql := adaptor.HTTPHandlerWithContext(withRequest(opts.graphqlHnd))
+			if opts.private {
+				s.All("/graphql", auth, graphql)
+			} else {
+				s.All("/graphql", graphql)
+			}
+		}
 
 		v1 := s.Group("/v1")
+		if opts.private {
+			v1.Use(auth)
+		}
 
 		v1.Get("/swagger/*", swaggo.HandlerDefault)
 
@@ -46,9 +103,67 @@ func Register(
 		bookmark.Get("/:uuid<guid>", bookmarkHnd.View)
 		bookmark.Post("/:uuid<guid>", bookmarkHnd.Change)
 		bookmark.Delete("/:uuid<guid>", bookmarkHnd.Delete)
+		bookmark.Get("/:uuid<guid>/metadata", bookmarkHnd.Metadata)
+		bookmark.Get("/:uuid<guid>/health", bookmarkHnd.Health)
+		bookmark.Get("/:uuid<guid>/archive", bookmarkHnd.Archive)
+		bookmark.Post("/:uuid<guid>/archive", bookmarkHnd.RequestArchive)
+
+		v1.Get("/bookmarks", bookmarkHnd.List)
+		v1.Get("/sync", bookmarkHnd.Changes)
+		v1.Post("/sync", bookmarkHnd.Push)
+
+		if opts.eventHnd != nil {
+			v1.Get("/events", opts.eventHnd.Stream)
+
+			s.Hooks().OnPreShutdown(func() error {
+				opts.eventHnd.Close()
+				return nil
+			})
+		}
+
+		if (len(opts.admins) > 0 || opts.tokens != nil) && (opts.jobHnd != nil || opts.webhookHnd != nil || opts.backupHnd != nil) {
+			admin := v1.Group("/admin")
+			if !opts.private {
+				admin.Use(auth)
+			}
+			admin.Use(requireAdmin)
+
+			if opts.jobHnd != nil {
+				admin.Get("/jobs", opts.jobHnd.List)
+				admin.Get("/jobs/:id<int>", opts.jobHnd.View)
+				admin.Post("/jobs/:id<int>/retry", opts.jobHnd.Retry)
+			}
+
+			if opts.webhookHnd != nil {
+				admin.Post("/webhooks", opts.webhookHnd.Create)
+				admin.Get("/webhooks", opts.webhookHnd.List)
+				admin.Get("/webhooks/:id<guid>", opts.webhookHnd.View)
+				admin.Put("/webhooks/:id<guid>", opts.webhookHnd.Update)
+				admin.Delete("/webhooks/:id<guid>", opts.webhookHnd.Delete)
+				admin.Get("/webhooks/:id<guid>/deliveries", opts.webhookHnd.Deliveries)
+			}
+
+			if opts.backupHnd != nil {
+				admin.Post("/backups", opts.backupHnd.Create)
+				admin.Get("/backups", opts.backupHnd.List)
+			}
+		}
 	}
 }
 
+// withRequest moves the span and the logger of the request into the context of the adapted
+// request, which is the fasthttp one: its values, e.g. the request id, are still looked up there.
+func withRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if local, ok := adaptor.LocalContextFromHTTPRequest(r); ok {
+			ctx := trace.ContextWithSpan(r.Context(), trace.SpanFromContext(local))
+			r = r.WithContext(logger.NewContext(ctx, logger.FromContext(local)))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func healthHandler(ctx fiber.Ctx) error {
 	data := map[string]string{
 		"status": "ok",
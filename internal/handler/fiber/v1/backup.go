@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/handler"
+	router "bookmarks/internal/handler/fiber"
+	"bookmarks/internal/model"
+)
+
+type BackupService interface {
+	Backup(ctx context.Context) (model.Backup, error)
+	List() ([]model.Backup, error)
+}
+
+type backupHandler struct {
+	service BackupService
+}
+
+func NewBackupHandler(s BackupService) *backupHandler {
+	return &backupHandler{
+		service: s,
+	}
+}
+
+// @Summary     Create backup
+// @Description Take an online backup of the database with its checksum manifest
+// @ID          create-backup
+// @Tags  	    admin
+// @Produce     json
+// @Security    BasicAuth
+// @Success     201 {object} model.Backup
+// @Failure     401
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/backups [post]
+func (h *backupHandler) Create(ctx fiber.Ctx) error {
+	backup, err := h.service.Backup(ctx)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(backup)
+}
+
+// @Summary     List backups
+// @Description List the backups of the database, newest first
+// @ID          list-backups
+// @Tags  	    admin
+// @Produce     json
+// @Security    BasicAuth
+// @Success     200 {object} handler.BackupListResponse
+// @Failure     401
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/backups [get]
+func (h *backupHandler) List(ctx fiber.Ctx) error {
+	backups, err := h.service.List()
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.BackupListResponse{Items: backups})
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/backup"
+	"bookmarks/internal/storage/sqlite"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func TestBackups(t *testing.T) {
+	hdl := makeBackupHandler(t)
+
+	app := fiber.New()
+	app.Post("/v1/admin/backups", hdl.Create)
+	app.Get("/v1/admin/backups", hdl.List)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/v1/admin/backups", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created model.Backup
+	require.NoError(t, render.DecodeJSON(resp.Body, &created))
+	require.Equal(t, sqlite.SchemaVersion(), created.SchemaVersion)
+	require.NotEmpty(t, created.SHA256)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/v1/admin/backups", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var list handler.BackupListResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &list))
+	require.Len(t, list.Items, 1)
+	require.Equal(t, created.Name, list.Items[0].Name)
+}
+
+func makeBackupHandler(t *testing.T) *backupHandler {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	driver, err := pkgsql.New(pkgsql.SourceName(filepath.Join(dir, "main.db")))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = driver.DB.Close() })
+
+	_, err = sqlite.NewBookmark(driver)
+	require.NoError(t, err)
+
+	logger := slog.New(slog.DiscardHandler)
+
+	return NewBackupHandler(backup.New(logger, driver, backup.Dir(filepath.Join(dir, "backups"))))
+}
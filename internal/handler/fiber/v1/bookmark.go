@@ -1,38 +1,43 @@
 package v1
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"log/slog"
+	"io"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
-	"github.com/gofiber/fiber/v3/middleware/requestid"
 
+	"bookmarks/internal/handler"
 	router "bookmarks/internal/handler/fiber"
 	"bookmarks/internal/model"
 	"bookmarks/internal/service/bookmark"
 )
 
 type Service interface {
-	Append(title, val string) (model.Bookmark, error)
-	View(uuid string) (model.Bookmark, error)
-	Change()
-	Delete(uuid string) error
+	Append(ctx context.Context, title, val string, kind model.Kind, mode bookmark.ConflictMode) (model.Bookmark, bool, error)
+	View(ctx context.Context, uuid string) (model.Bookmark, error)
+	List(ctx context.Context, filter bookmark.ListFilter) ([]model.Bookmark, error)
+	Metadata(ctx context.Context, uuid string) (model.Metadata, error)
+	Health(ctx context.Context, uuid string) (model.LinkHealth, []model.LinkCheck, error)
+	RequestArchive(ctx context.Context, uuid string) error
+	Archive(ctx context.Context, uuid string) (model.Archive, io.ReadCloser, error)
+	Change(ctx context.Context, uuid, title, val string, kind model.Kind, version int64) (model.Bookmark, error)
+	Delete(ctx context.Context, uuid string) error
+	Changes(ctx context.Context, token string, limit int) (model.ChangeSet, string, error)
+	Push(ctx context.Context, mutations []bookmark.Mutation) ([]bookmark.PushResult, error)
 }
 
 type bookmarkHandler struct {
 	service   Service
 	validator *validator.Validate
-	logger    *slog.Logger
 }
 
-func NewHandler(l *slog.Logger, s Service) *bookmarkHandler {
+func NewHandler(s Service) *bookmarkHandler {
 	return &bookmarkHandler{
 		service:   s,
 		validator: validator.New(validator.WithRequiredStructEnabled()),
-		logger:    l,
 	}
 }
 
@@ -42,40 +47,50 @@ func NewHandler(l *slog.Logger, s Service) *bookmarkHandler {
 // @Tags  	    bookmark
 // @Accept      json
 // @Produce     json
-// @Success     200 {object} model.Bookmark
+// @Param       request     body  CreateBookmarkRequest true  "Bookmark"
+// @Param       on_conflict query string false "Conflict mode" Enums(error, ignore, update_title)
+// @Success     201 {object} handler.BookmarkResponse
+// @Success     200 {object} handler.BookmarkResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     409 {object} handler.ConflictResponse
+// @Failure     422 {object} handler.ErrorResponse
 // @Failure     500 {object} handler.ErrorResponse
 // @Router      /bookmark/append [post]
 func (h *bookmarkHandler) Append(ctx fiber.Ctx) error {
 	var input CreateBookmarkRequest
 
-	log := h.logger.With(
-		slog.String("op", "handler.v1.bookmark.Append"),
-		slog.String("request_id", requestid.FromContext(ctx)),
-	)
-
 	if err := ctx.Bind().Body(&input); err != nil {
-		log.Error(err.Error())
 		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
 	}
 
 	if err := h.validator.Struct(input); err != nil {
-		log.Error(err.Error())
 		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
 	}
 
-	entity, err := h.service.Append(input.Title, input.Value)
-	if err != nil {
-		log.Error(err.Error())
+	mode := bookmark.ConflictMode(ctx.Query("on_conflict"))
 
+	entity, created, err := h.service.Append(ctx.Context(), input.Title, input.Value, model.Kind(input.Kind), mode)
+	if err != nil {
 		if errors.Is(err, bookmark.ErrBookmarkExists) {
-			error := fmt.Sprintf("[%s] %s", entity.Uuid, bookmark.ErrBookmarkExists)
-			return router.ErrorResponse(ctx, error, http.StatusConflict)
+			return router.ConflictResponse(ctx, bookmark.ErrBookmarkExists.Error(), entity)
+		}
+
+		if errors.Is(err, bookmark.ErrInvalidConflictMode) {
+			return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+		}
+
+		if isInvalidBookmark(err) {
+			return router.ErrorResponse(ctx, err.Error(), http.StatusUnprocessableEntity)
 		}
 
 		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
 	}
 
-	return ctx.Status(http.StatusCreated).JSON(entity)
+	if !created {
+		return ctx.Status(http.StatusOK).JSON(handler.NewBookmark(entity))
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(handler.NewBookmark(entity))
 }
 
 // @Summary     Show bookmark
@@ -85,19 +100,12 @@ func (h *bookmarkHandler) Append(ctx fiber.Ctx) error {
 // @Accept      json
 // @Produce     json
 // @Param       uuid   path      string  true  "Bookmark UUID"
-// @Success     200 {object} model.Bookmark
+// @Success     200 {object} handler.BookmarkResponse
 // @Failure     500 {object} handler.ErrorResponse
 // @Router      /bookmark/{uuid} [get]
 func (h *bookmarkHandler) View(ctx fiber.Ctx) error {
-	log := h.logger.With(
-		slog.String("op", "handler.v1.bookmark.View"),
-		slog.String("request_id", requestid.FromContext(ctx)),
-	)
-
-	entity, err := h.service.View(ctx.Params("uuid"))
+	entity, err := h.service.View(ctx.Context(), ctx.Params("uuid"))
 	if err != nil {
-		log.Error(err.Error())
-
 		if errors.Is(err, bookmark.ErrBookmarkNotFound) {
 			return router.ErrorResponse(ctx, bookmark.ErrBookmarkNotFound.Error(), http.StatusNotFound)
 		}
@@ -105,22 +113,196 @@ func (h *bookmarkHandler) View(ctx fiber.Ctx) error {
 		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
 	}
 
-	return ctx.Status(http.StatusOK).JSON(entity)
+	return ctx.Status(http.StatusOK).JSON(handler.NewBookmark(entity))
 }
 
-func (h *bookmarkHandler) Change(ctx fiber.Ctx) error {
-	return ctx.SendString("Change")
+// @Summary     Show bookmark metadata
+// @Description Show link metadata fetched in the background for an URL bookmark
+// @ID          metadata
+// @Tags  	    bookmark
+// @Produce     json
+// @Param       uuid   path      string  true  "Bookmark UUID"
+// @Success     200 {object} model.Metadata
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /bookmark/{uuid}/metadata [get]
+func (h *bookmarkHandler) Metadata(ctx fiber.Ctx) error {
+	metadata, err := h.service.Metadata(ctx.Context(), ctx.Params("uuid"))
+	if err != nil {
+		if errors.Is(err, bookmark.ErrBookmarkNotFound) || errors.Is(err, bookmark.ErrMetadataNotFound) {
+			return router.ErrorResponse(ctx, err.Error(), http.StatusNotFound)
+		}
+
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(metadata)
 }
 
-func (h *bookmarkHandler) Delete(ctx fiber.Ctx) error {
-	log := h.logger.With(
-		slog.String("op", "handler.v1.bookmark.Delete"),
-		slog.String("request_id", requestid.FromContext(ctx)),
-	)
+// @Summary     Show link health
+// @Description Show the state of an URL bookmark link and its recent checks
+// @ID          health
+// @Tags  	    bookmark
+// @Produce     json
+// @Param       uuid   path      string  true  "Bookmark UUID"
+// @Success     200 {object} handler.HealthResponse
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /bookmark/{uuid}/health [get]
+func (h *bookmarkHandler) Health(ctx fiber.Ctx) error {
+	health, history, err := h.service.Health(ctx.Context(), ctx.Params("uuid"))
+	if err != nil {
+		if errors.Is(err, bookmark.ErrBookmarkNotFound) || errors.Is(err, bookmark.ErrHealthNotFound) {
+			return router.ErrorResponse(ctx, err.Error(), http.StatusNotFound)
+		}
 
-	if err := h.service.Delete(ctx.Params("uuid")); err != nil {
-		log.Error(err.Error())
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
 
+	return ctx.Status(http.StatusOK).JSON(handler.NewHealth(health, history))
+}
+
+// @Summary     Archive bookmark page
+// @Description Schedule saving a self-contained copy of the page an URL bookmark points to
+// @ID          request-archive
+// @Tags  	    bookmark
+// @Produce     json
+// @Param       uuid   path      string  true  "Bookmark UUID"
+// @Success     202
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     422 {object} handler.ErrorResponse
+// @Failure     501 {object} handler.ErrorResponse
+// @Failure     503 {object} handler.ErrorResponse
+// @Router      /bookmark/{uuid}/archive [post]
+func (h *bookmarkHandler) RequestArchive(ctx fiber.Ctx) error {
+	if err := h.service.RequestArchive(ctx.Context(), ctx.Params("uuid")); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), archiveStatus(err))
+	}
+
+	return ctx.SendStatus(http.StatusAccepted)
+}
+
+// @Summary     Show archived page
+// @Description Show the latest self-contained copy of the page an URL bookmark points to
+// @ID          archive
+// @Tags  	    bookmark
+// @Produce     html
+// @Param       uuid   path      string  true  "Bookmark UUID"
+// @Success     200 {string} string "archived HTML page"
+// @Success     304
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     501 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /bookmark/{uuid}/archive [get]
+func (h *bookmarkHandler) Archive(ctx fiber.Ctx) error {
+	archive, content, err := h.service.Archive(ctx.Context(), ctx.Params("uuid"))
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), archiveStatus(err))
+	}
+
+	headers := handler.ArchiveHeaders(archive)
+	if ctx.Get("If-None-Match") == headers["ETag"] {
+		_ = content.Close()
+
+		ctx.Set("ETag", headers["ETag"])
+
+		return ctx.SendStatus(http.StatusNotModified)
+	}
+
+	for key, val := range headers {
+		ctx.Set(key, val)
+	}
+
+	// the stream is closed once sent
+	return ctx.Status(http.StatusOK).SendStream(content, int(archive.Size))
+}
+
+// @Summary     List bookmarks
+// @Description List bookmarks, newest first
+// @ID          list
+// @Tags  	    bookmark
+// @Produce     json
+// @Param       kind   query     string  false "Bookmark kind" Enums(url, text, code, contact)
+// @Param       health query     string  false "Link health of URL bookmarks" Enums(ok, failing, broken)
+// @Param       limit  query     int     false "Page size"
+// @Param       offset query     int     false "Page offset"
+// @Success     200 {object} handler.ListResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /bookmarks [get]
+func (h *bookmarkHandler) List(ctx fiber.Ctx) error {
+	var input ListBookmarksRequest
+
+	if err := ctx.Bind().Query(&input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	filter := bookmark.ListFilter{
+		Kind:   model.Kind(input.Kind),
+		Health: model.HealthStatus(input.Health),
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	}.Normalize()
+
+	entities, err := h.service.List(ctx.Context(), filter)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewList(entities, filter.Limit, filter.Offset))
+}
+
+// @Summary     Change bookmark
+// @Description Replace the title and value of a bookmark still at the given version
+// @ID          change
+// @Tags  	    bookmark
+// @Accept      json
+// @Produce     json
+// @Param       uuid    path  string                true  "Bookmark UUID"
+// @Param       request body  ChangeBookmarkRequest true  "Bookmark"
+// @Success     200 {object} handler.BookmarkResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     409 {object} handler.ConflictResponse
+// @Failure     422 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /bookmark/{uuid} [post]
+func (h *bookmarkHandler) Change(ctx fiber.Ctx) error {
+	var input ChangeBookmarkRequest
+
+	if err := ctx.Bind().Body(&input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	entity, err := h.service.Change(ctx.Context(), ctx.Params("uuid"), input.Title, input.Value, model.Kind(input.Kind), input.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, bookmark.ErrBookmarkChanged):
+			return router.ConflictResponse(ctx, bookmark.ErrBookmarkChanged.Error(), entity)
+		case errors.Is(err, bookmark.ErrBookmarkExists):
+			return router.ConflictResponse(ctx, bookmark.ErrBookmarkExists.Error(), entity)
+		case errors.Is(err, bookmark.ErrBookmarkNotFound), errors.Is(err, bookmark.ErrBookmarkDeleted):
+			return router.ErrorResponse(ctx, bookmark.ErrBookmarkNotFound.Error(), http.StatusNotFound)
+		case isInvalidBookmark(err):
+			return router.ErrorResponse(ctx, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewBookmark(entity))
+}
+
+func (h *bookmarkHandler) Delete(ctx fiber.Ctx) error {
+	if err := h.service.Delete(ctx.Context(), ctx.Params("uuid")); err != nil {
 		if errors.Is(err, bookmark.ErrBookmarkNotFound) {
 			return router.ErrorResponse(ctx, err.Error(), http.StatusNotFound)
 		}
@@ -130,3 +312,24 @@ func (h *bookmarkHandler) Delete(ctx fiber.Ctx) error {
 
 	return ctx.SendStatus(http.StatusNoContent)
 }
+
+func archiveStatus(err error) int {
+	switch {
+	case errors.Is(err, bookmark.ErrBookmarkNotFound), errors.Is(err, bookmark.ErrArchiveNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, bookmark.ErrNotArchivable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, bookmark.ErrArchiveDisabled):
+		return http.StatusNotImplemented
+	case errors.Is(err, bookmark.ErrArchiveUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func isInvalidBookmark(err error) bool {
+	return errors.Is(err, model.ErrInvalidTitle) ||
+		errors.Is(err, model.ErrInvalidValue) ||
+		errors.Is(err, model.ErrInvalidKind)
+}
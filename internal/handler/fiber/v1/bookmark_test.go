@@ -1,6 +1,9 @@
 package v1
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -11,13 +14,16 @@ import (
 	"github.com/go-chi/render"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	"bookmarks/internal/handler"
 	"bookmarks/internal/model"
 	repo "bookmarks/internal/repository/bookmark"
+	"bookmarks/internal/service/archive"
 	srv "bookmarks/internal/service/bookmark"
 	"bookmarks/internal/storage/memory"
+	"bookmarks/pkg/blob/fsstore"
 )
 
 func TestAppend_Success(t *testing.T) {
@@ -98,6 +104,267 @@ func TestAppend_BodyBadRequest(t *testing.T) {
 	require.Contains(t, response.Error, "Field validation for 'Value' failed")
 }
 
+func TestAppend_Conflict(t *testing.T) {
+	target := "/v1/bookmark/append"
+
+	hdl := makeHandler()
+	app := makeFiber(target, hdl.Append)
+
+	resp := testAppend(t, app, target, `{"title": "first", "value": "value"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created model.Bookmark
+	require.NoError(t, render.DecodeJSON(resp.Body, &created))
+
+	resp = testAppend(t, app, target, `{"title": "second", "value": "value"}`)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var response handler.ConflictResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &response))
+	require.Equal(t, "bookmark already exists", response.Error)
+	require.NotEmpty(t, response.Context.RequestID)
+	require.Equal(t, created.Uuid, response.Existing.Uuid)
+	require.Equal(t, "first", response.Existing.Title)
+}
+
+func TestAppend_ConflictMode(t *testing.T) {
+	target := "/v1/bookmark/append"
+
+	hdl := makeHandler()
+	app := makeFiber(target, hdl.Append)
+
+	resp := testAppend(t, app, target, `{"title": "first", "value": "value"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = testAppend(t, app, target+"?on_conflict=ignore", `{"title": "second", "value": "value"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response model.Bookmark
+	require.NoError(t, render.DecodeJSON(resp.Body, &response))
+	require.Equal(t, "first", response.Title)
+
+	resp = testAppend(t, app, target+"?on_conflict=replace", `{"title": "third", "value": "value"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestAppend_InvalidKindValue(t *testing.T) {
+	target := "/v1/bookmark/append"
+
+	hdl := makeHandler()
+	app := makeFiber(target, hdl.Append)
+
+	resp := testAppend(t, app, target, `{"title": "test", "value": "call me", "kind": "contact"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp = testAppend(t, app, target, `{"title": "test", "value": "value", "kind": "image"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestChange(t *testing.T) {
+	target := "/v1/bookmark/append"
+
+	hdl := makeHandler()
+	app := makeFiber(target, hdl.Append)
+	app.Post("/v1/bookmark/:uuid<guid>", hdl.Change)
+
+	resp := testAppend(t, app, target, `{"title": "first", "value": "value"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created model.Bookmark
+	require.NoError(t, render.DecodeJSON(resp.Body, &created))
+
+	path := "/v1/bookmark/" + created.Uuid.String()
+	body := fmt.Sprintf(`{"title": "renamed", "value": "value", "version": %d}`, created.Version)
+
+	resp = testAppend(t, app, path, body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var changed model.Bookmark
+	require.NoError(t, render.DecodeJSON(resp.Body, &changed))
+	require.Equal(t, "renamed", changed.Title)
+	require.Greater(t, changed.Version, created.Version)
+
+	// the version read before the change is stale now
+	resp = testAppend(t, app, path, body)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var response handler.ConflictResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &response))
+	require.NotEmpty(t, response.Context.RequestID)
+	require.Equal(t, changed.Version, response.Existing.Version)
+
+	resp = testAppend(t, app, path, `{"value": "value"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = testAppend(t, app, "/v1/bookmark/"+uuid.NewString(), body)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestList_FilterKind(t *testing.T) {
+	target := "/v1/bookmark/append"
+
+	hdl := makeHandler()
+	app := makeFiber(target, hdl.Append)
+	app.Get("/v1/bookmarks", hdl.List)
+
+	for _, body := range []string{
+		`{"title": "site", "value": "https://example.com"}`,
+		`{"title": "cmd", "value": "git log --oneline"}`,
+		`{"title": "phone", "value": "+7 999 123-45-67"}`,
+	} {
+		resp := testAppend(t, app, target, body)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v1/bookmarks?kind=contact", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response handler.ListResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &response))
+	require.Len(t, response.Items, 1)
+	require.Equal(t, model.KindContact, response.Items[0].Kind)
+	require.Equal(t, "+79991234567", response.Items[0].Value)
+	require.Equal(t, "tel:+79991234567", response.Items[0].Render.Href)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/v1/bookmarks?limit=-1", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHealth_NotChecked(t *testing.T) {
+	target := "/v1/bookmark/append"
+
+	hdl := makeHandler()
+	app := makeFiber(target, hdl.Append)
+	app.Get("/v1/bookmark/:uuid<guid>/health", hdl.Health)
+	app.Get("/v1/bookmarks", hdl.List)
+
+	resp := testAppend(t, app, target, `{"value": "https://example.com"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created handler.BookmarkResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &created))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v1/bookmark/"+created.Uuid.String()+"/health", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/v1/bookmarks?health=broken", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response handler.ListResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &response))
+	require.Empty(t, response.Items)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/v1/bookmarks?health=dead", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestArchive_Serve(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><script>alert(1)</script></head><body>archived</body></html>`))
+	}))
+	defer site.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	store, err := fsstore.New(fsstore.Dir(t.TempDir()))
+	require.NoError(t, err)
+
+	worker := archive.New(slog.New(slog.DiscardHandler), repository, store, archive.AllowPrivateNetworks())
+	hdl := NewHandler(srv.NewService(repository, srv.Archiving(worker, false)))
+
+	target := "/v1/bookmark/append"
+	app := makeFiber(target, hdl.Append)
+	app.Get("/v1/bookmark/:uuid<guid>/archive", hdl.Archive)
+	app.Post("/v1/bookmark/:uuid<guid>/archive", makeHandler().RequestArchive)
+
+	resp := testAppend(t, app, target, `{"value": "`+site.URL+`/page"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created handler.BookmarkResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &created))
+
+	path := "/v1/bookmark/" + created.Uuid.String() + "/archive"
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, err = worker.Archive(context.Background(), created.Bookmark)
+	require.NoError(t, err)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Contains(t, resp.Header.Get("Content-Security-Policy"), "sandbox")
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "archived")
+	require.NotContains(t, string(body), "alert")
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPost, path, nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+func testAppend(t *testing.T, app *fiber.App, target, body string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, fiber.TestConfig{
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = resp.Body.Close()
+	})
+
+	return resp
+}
+
 func makeFiber(target string, handler fiber.Handler) *fiber.App {
 	app := fiber.New()
 	app.Use(requestid.New())
@@ -111,7 +378,6 @@ func makeHandler() *bookmarkHandler {
 	storage := memory.NewBookmarkStorage()
 	repository := repo.NewRepository(storage)
 	service := srv.NewService(repository)
-	logger := slog.New(slog.DiscardHandler)
 
-	return NewHandler(logger, service)
+	return NewHandler(service)
 }
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/handler"
+	router "bookmarks/internal/handler/fiber"
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/stream"
+)
+
+type EventBroker interface {
+	Subscribe(lastID int64, types []model.EventType) (*stream.Subscription, error)
+	Heartbeat() time.Duration
+	Close()
+}
+
+type eventHandler struct {
+	broker EventBroker
+}
+
+func NewEventHandler(b EventBroker) *eventHandler {
+	return &eventHandler{
+		broker: b,
+	}
+}
+
+// @Summary     Stream bookmark events
+// @Description Server-sent events of the bookmark changes the caller may read, the stream ends when
+// @Description its credentials expire. A resumed stream replays the missed events or starts with
+// @Description a reset event when they are no longer kept.
+// @ID          stream-events
+// @Tags  	    events
+// @Produce     text/event-stream
+// @Param       Last-Event-ID header    string false "Resume after this event"
+// @Param       last_event_id query     string false "Resume after this event, for clients unable to set headers"
+// @Param       types         query     string false "Comma separated event types, every type when empty"
+// @Success     200 {object} handler.EventResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     503 {object} handler.ErrorResponse
+// @Router      /events [get]
+func (h *eventHandler) Stream(ctx fiber.Ctx) error {
+	log := logger.FromContext(ctx.Context()).With(slog.String("op", "handler.v1.event.Stream"))
+
+	lastID, err := handler.ParseLastEventID(ctx.Get("Last-Event-ID"), ctx.Query("last_event_id"))
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	types, err := handler.ParseEventTypes(ctx.Query("types"))
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	sub, err := h.broker.Subscribe(lastID, types)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusServiceUnavailable)
+	}
+
+	ctx.Set("Content-Type", "text/event-stream")
+	ctx.Set("Cache-Control", "no-cache")
+	ctx.Set("X-Accel-Buffering", "no")
+	// an ended stream must not leave a keep-alive connection, shutdown waits for it to idle
+	ctx.Set("Connection", "close")
+
+	heartbeat := h.broker.Heartbeat()
+	principal, _ := handler.PrincipalFromContext(ctx.Context())
+
+	// the writer runs after the handler returned, the request context is released by then:
+	// a gone client fails the flush and Close ends the stream on shutdown
+	return ctx.SendStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		if err := handler.Stream(context.Background(), w, w.Flush, sub, heartbeat, principal); err != nil {
+			log.Debug(err.Error())
+		}
+	})
+}
+
+// Close ends the open streams, fiber.App.Shutdown waits for them otherwise.
+func (h *eventHandler) Close() {
+	h.broker.Close()
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler"
+	router "bookmarks/internal/handler/fiber"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/stream"
+)
+
+func TestEvents_Stream(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	broker := stream.New(logger, stream.Heartbeat(20*time.Millisecond))
+
+	bookmark, err := model.NewBookmark("Go", "https://go.dev")
+	require.NoError(t, err)
+
+	app := fiber.New()
+	router.Register(logger, makeHandler(), router.Events(NewEventHandler(broker)))(app)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go app.Listener(listener, fiber.ListenConfig{DisableStartupMessage: true}) //nolint:errcheck
+
+	// fiber closes a keep-alive connection on shutdown only after it idled for seconds
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	target := "http://" + listener.Addr().String() + "/v1/events"
+
+	resp, err := client.Get(target + "?types=bookmark.bogus") //nolint:noctx
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = client.Get(target + "?types=bookmark.deleted&last_event_id=1") //nolint:noctx
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	require.Equal(t, handler.EventReset, readEvent(t, reader)["event"])
+
+	require.NoError(t, broker.Publish(context.Background(), model.Event{ID: 1, Type: model.BookmarkAppended, Bookmark: bookmark}))
+	require.NoError(t, broker.Publish(context.Background(), model.Event{ID: 2, Type: model.BookmarkDeleted, Bookmark: bookmark}))
+
+	frame := readEvent(t, reader)
+	require.Equal(t, string(model.BookmarkDeleted), frame["event"])
+	require.Contains(t, frame["data"], bookmark.Uuid.String())
+
+	// heartbeats keep the stream open
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ": ping\n", line)
+
+	require.NoError(t, app.ShutdownWithTimeout(2*time.Second))
+
+	_, err = reader.ReadString('\n')
+	for err == nil {
+		_, err = reader.ReadString('\n')
+	}
+}
+
+// readEvent reads the fields of the next event, comments are skipped.
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	t.Helper()
+
+	fields := make(map[string]string)
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
@@ -0,0 +1,151 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/handler"
+	router "bookmarks/internal/handler/fiber"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/jobs"
+)
+
+var ErrInvalidJobID = errors.New("invalid job id")
+
+type JobService interface {
+	List(filter jobs.ListFilter) ([]model.Job, error)
+	Job(id int64) (model.Job, error)
+	Retry(id int64) (model.Job, error)
+}
+
+type jobHandler struct {
+	service   JobService
+	validator *validator.Validate
+}
+
+func NewJobHandler(s JobService) *jobHandler {
+	return &jobHandler{
+		service:   s,
+		validator: validator.New(validator.WithRequiredStructEnabled()),
+	}
+}
+
+// @Summary     List jobs
+// @Description List background jobs, newest first
+// @ID          list-jobs
+// @Tags  	    admin
+// @Produce     json
+// @Security    BasicAuth
+// @Param       status query     string  false "Job status" Enums(queued, running, succeeded, failed)
+// @Param       kind   query     string  false "Job kind"
+// @Param       limit  query     int     false "Page size"
+// @Param       offset query     int     false "Page offset"
+// @Success     200 {object} handler.JobListResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     401
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/jobs [get]
+func (h *jobHandler) List(ctx fiber.Ctx) error {
+	var input ListJobsRequest
+
+	if err := ctx.Bind().Query(&input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	filter := jobs.ListFilter{
+		Status: model.JobStatus(input.Status),
+		Kind:   input.Kind,
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	}.Normalize()
+
+	entities, err := h.service.List(filter)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewJobList(entities, filter.Limit, filter.Offset))
+}
+
+// @Summary     Show job
+// @Description Show a background job with its last error
+// @ID          view-job
+// @Tags  	    admin
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id     path      int     true  "Job ID"
+// @Success     200 {object} model.Job
+// @Failure     401
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     422 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/jobs/{id} [get]
+func (h *jobHandler) View(ctx fiber.Ctx) error {
+	id, err := parseJobID(ctx)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	job, err := h.service.Job(id)
+	if err != nil {
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			return router.ErrorResponse(ctx, err.Error(), http.StatusNotFound)
+		}
+
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(job)
+}
+
+// @Summary     Retry job
+// @Description Queue a failed background job again with a fresh set of attempts
+// @ID          retry-job
+// @Tags  	    admin
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id     path      int     true  "Job ID"
+// @Success     202 {object} model.Job
+// @Failure     401
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     409 {object} handler.ErrorResponse
+// @Failure     422 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/jobs/{id}/retry [post]
+func (h *jobHandler) Retry(ctx fiber.Ctx) error {
+	id, err := parseJobID(ctx)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	job, err := h.service.Retry(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrJobNotFound):
+			return router.ErrorResponse(ctx, err.Error(), http.StatusNotFound)
+		case errors.Is(err, jobs.ErrJobNotFailed):
+			return router.ErrorResponse(ctx, err.Error(), http.StatusConflict)
+		default:
+			return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(job)
+}
+
+func parseJobID(ctx fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidJobID
+	}
+
+	return id, nil
+}
@@ -0,0 +1,8 @@
+package v1
+
+type ListJobsRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=queued running succeeded failed"`
+	Kind   string `query:"kind"`
+	Limit  int    `query:"limit" validate:"gte=0"`
+	Offset int    `query:"offset" validate:"gte=0"`
+}
@@ -0,0 +1,196 @@
+package v1
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler"
+	router "bookmarks/internal/handler/fiber"
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	accountRepo "bookmarks/internal/repository/account"
+	jobRepo "bookmarks/internal/repository/job"
+	"bookmarks/internal/service/account"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/internal/storage/memory"
+)
+
+func TestJobs_Retry(t *testing.T) {
+	hdl, failed := makeJobHandler(t)
+
+	app := fiber.New()
+	app.Get("/v1/admin/jobs", hdl.List)
+	app.Get("/v1/admin/jobs/:id<int>", hdl.View)
+	app.Post("/v1/admin/jobs/:id<int>/retry", hdl.Retry)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v1/admin/jobs?status=failed", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var list handler.JobListResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &list))
+	require.Len(t, list.Items, 1)
+	require.Equal(t, failed.ID, list.Items[0].ID)
+	require.Equal(t, "smtp unavailable", list.Items[0].LastError)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/v1/admin/jobs?status=lost", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	target := fmt.Sprintf("/v1/admin/jobs/%d/retry", failed.ID)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPost, target, nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var job model.Job
+	require.NoError(t, render.DecodeJSON(resp.Body, &job))
+	require.Equal(t, model.JobQueued, job.Status)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodPost, target, nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/v1/admin/jobs/999", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestJobs_Auth(t *testing.T) {
+	hdl, _ := makeJobHandler(t)
+	logger := slog.New(slog.DiscardHandler)
+
+	app := fiber.New()
+	router.Register(logger, makeHandler(), router.Jobs(hdl), router.Admin(map[string]string{"admin": "secret"}))(app)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v1/admin/jobs", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/jobs", nil)
+	req.SetBasicAuth("admin", "wrong")
+
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/admin/jobs", nil)
+	req.SetBasicAuth("admin", "secret")
+
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestJobs_TokenAuth(t *testing.T) {
+	hdl, _ := makeJobHandler(t)
+	accounts, secret, adminSecret := makeAccounts(t)
+	logger := slog.New(slog.DiscardHandler)
+
+	serve := func(t *testing.T, app *fiber.App, path, authorization string) int {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close() //nolint:errcheck
+
+		return resp.StatusCode
+	}
+
+	app := fiber.New()
+	router.Register(logger, makeHandler(), router.Jobs(hdl), router.Tokens(accounts))(app)
+
+	require.Equal(t, http.StatusUnauthorized, serve(t, app, "/v1/admin/jobs", ""))
+	require.Equal(t, http.StatusUnauthorized, serve(t, app, "/v1/admin/jobs", "Bearer bmt_unknown"))
+	require.Equal(t, http.StatusForbidden, serve(t, app, "/v1/admin/jobs", "Bearer "+secret))
+	require.Equal(t, http.StatusOK, serve(t, app, "/v1/admin/jobs", "Bearer "+adminSecret))
+	require.Equal(t, http.StatusOK, serve(t, app, "/v1/bookmarks", ""))
+
+	private := fiber.New()
+	router.Register(logger, makeHandler(), router.Jobs(hdl), router.Admin(map[string]string{"admin": "secret"}), router.Tokens(accounts), router.Private())(private)
+
+	require.Equal(t, http.StatusUnauthorized, serve(t, private, "/v1/bookmarks", ""))
+	require.Equal(t, http.StatusOK, serve(t, private, "/v1/bookmarks", "Bearer "+secret))
+	require.Equal(t, http.StatusForbidden, serve(t, private, "/v1/admin/jobs", "Bearer "+secret))
+	require.Equal(t, http.StatusOK, serve(t, private, "/v1/admin/jobs", "Bearer "+adminSecret))
+	require.Equal(t, http.StatusOK, serve(t, private, "/v1/admin/jobs", "Basic YWRtaW46c2VjcmV0"))
+}
+
+// makeAccounts returns the accounts of a user and an admin user holding one token each,
+// with the secrets of the tokens.
+func makeAccounts(t *testing.T) (*account.Service, string, string) {
+	t.Helper()
+
+	accounts := account.New(accountRepo.NewRepository(memory.NewAccountStorage()))
+
+	_, err := accounts.CreateUser("alice", false)
+	require.NoError(t, err)
+
+	_, secret, err := accounts.IssueToken("alice", "test", time.Hour)
+	require.NoError(t, err)
+
+	_, err = accounts.CreateUser("root", true)
+	require.NoError(t, err)
+
+	_, adminSecret, err := accounts.IssueToken("root", "test", time.Hour)
+	require.NoError(t, err)
+
+	return accounts, secret, adminSecret
+}
+
+// makeJobHandler returns a handler of a queue holding one failed job.
+func makeJobHandler(t *testing.T) (*jobHandler, model.Job) {
+	t.Helper()
+
+	repository := jobRepo.NewRepository(memory.NewJobStorage())
+	now := time.Now()
+
+	job, err := repository.Enqueue(model.Job{Kind: "mail.send", Payload: []byte(`{}`), MaxAttempts: 1, RunAt: now, CreatedAt: now})
+	require.NoError(t, err)
+
+	_, err = repository.Lease(core.Lease{Owner: "test", Kinds: []string{"mail.send"}, Now: now, Until: now.Add(time.Minute), Limit: 1})
+	require.NoError(t, err)
+	require.NoError(t, repository.Fail(job.ID, "test", "smtp unavailable", now, time.Time{}))
+
+	queue := jobs.New(slog.New(slog.DiscardHandler), repository)
+
+	return NewJobHandler(queue), job
+}
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/service/probe"
+)
+
+type ProbeService interface {
+	Ready(ctx context.Context) probe.Report
+	Shutdown()
+}
+
+type probeHandler struct {
+	service ProbeService
+}
+
+func NewProbeHandler(s ProbeService) *probeHandler {
+	return &probeHandler{
+		service: s,
+	}
+}
+
+// Ready reports the checks of the dependencies, a failing check fails the readiness.
+func (h *probeHandler) Ready(ctx fiber.Ctx) error {
+	report := h.service.Ready(ctx)
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	return ctx.Status(status).JSON(report)
+}
+
+// Close fails the readiness, the server is shutting down.
+func (h *probeHandler) Close() {
+	h.service.Shutdown()
+}
@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/service/probe"
+	"bookmarks/internal/storage/sqlite"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func TestProbes(t *testing.T) {
+	driver, err := pkgsql.New(pkgsql.SourceName(filepath.Join(t.TempDir(), "main.db")))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = driver.DB.Close() })
+
+	probes := probe.New(slog.New(slog.DiscardHandler), probe.CacheTTL(0))
+	probes.Register("storage", probe.Storage(driver))
+	probes.Register("migrations", probe.Migrations(driver, sqlite.SchemaVersion()))
+
+	hdl := NewProbeHandler(probes)
+
+	app := fiber.New()
+	app.Get("/health/ready", hdl.Ready)
+
+	ready := func(status int) probe.Report {
+		t.Helper()
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		require.NoError(t, err)
+
+		defer resp.Body.Close() //nolint:errcheck
+
+		require.Equal(t, status, resp.StatusCode)
+
+		var report probe.Report
+		require.NoError(t, render.DecodeJSON(resp.Body, &report))
+
+		return report
+	}
+
+	report := ready(http.StatusServiceUnavailable)
+	require.Equal(t, probe.StatusOK, report.Checks["storage"].Status)
+	require.Equal(t, probe.ErrSchemaOutdated.Error(), report.Checks["migrations"].Error)
+
+	_, _, err = sqlite.Migrate(driver)
+	require.NoError(t, err)
+
+	report = ready(http.StatusOK)
+	require.Equal(t, probe.StatusOK, report.Status)
+
+	hdl.Close()
+
+	report = ready(http.StatusServiceUnavailable)
+	require.Equal(t, probe.ErrShuttingDown.Error(), report.Error)
+}
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/handler"
+	router "bookmarks/internal/handler/fiber"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/bookmark"
+)
+
+// @Summary     Sync changes
+// @Description Bookmark creates, updates and deletes after the sync token in version order, without a token everything
+// @ID          sync-changes
+// @Tags  	    sync
+// @Produce     json
+// @Param       since query     string  false "Sync token of the previous response"
+// @Param       limit query     int     false "Page size"
+// @Success     200 {object} handler.SyncResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     410 {object} handler.ErrorResponse "The token is unknown, a full resync is required"
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /sync [get]
+func (h *bookmarkHandler) Changes(ctx fiber.Ctx) error {
+	var input SyncChangesRequest
+
+	if err := ctx.Bind().Query(&input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	set, token, err := h.service.Changes(ctx.Context(), input.Token, input.Limit)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), syncStatus(err))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewSync(set, token))
+}
+
+// @Summary     Push changes
+// @Description Apply client mutations in order, a stale version is reported as a conflict with the server side bookmark
+// @ID          sync-push
+// @Tags  	    sync
+// @Accept      json
+// @Produce     json
+// @Param       request body    PushChangesRequest true "Mutations"
+// @Success     200 {object} handler.PushResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /sync [post]
+func (h *bookmarkHandler) Push(ctx fiber.Ctx) error {
+	var input PushChangesRequest
+
+	if err := ctx.Bind().Body(&input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	mutations := make([]bookmark.Mutation, 0, len(input.Mutations))
+	for _, m := range input.Mutations {
+		mutations = append(mutations, bookmark.Mutation{
+			Op:      bookmark.MutationOp(m.Op),
+			Uuid:    m.Uuid,
+			Title:   m.Title,
+			Value:   m.Value,
+			Kind:    model.Kind(m.Kind),
+			Version: m.Version,
+		})
+	}
+
+	results, err := h.service.Push(ctx.Context(), mutations)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), syncStatus(err))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewPush(results))
+}
+
+// syncStatus tells a client with an unknown token to drop its state and sync from scratch.
+func syncStatus(err error) int {
+	switch {
+	case errors.Is(err, bookmark.ErrInvalidSyncToken), errors.Is(err, bookmark.ErrTooManyMutations):
+		return http.StatusBadRequest
+	case errors.Is(err, bookmark.ErrResyncRequired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,22 @@
+package v1
+
+type SyncChangesRequest struct {
+	Token string `query:"since"`
+	Limit int    `query:"limit" validate:"gte=0"`
+}
+
+// PushChangesRequest holds client mutations, they are applied in order.
+type PushChangesRequest struct {
+	Mutations []MutationRequest `json:"mutations" validate:"required,max=100,dive"`
+}
+
+// MutationRequest is a client change: create may carry a client assigned uuid,
+// update and delete carry the version the client last saw.
+type MutationRequest struct {
+	Op      string `json:"op" validate:"required,oneof=create update delete"`
+	Uuid    string `json:"uuid" validate:"omitempty,uuid"`
+	Title   string `json:"title"`
+	Value   string `json:"value"`
+	Kind    string `json:"kind" validate:"omitempty,oneof=url text code contact"`
+	Version int64  `json:"version" validate:"gte=0"`
+}
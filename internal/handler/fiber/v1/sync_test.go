@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler"
+	srv "bookmarks/internal/service/bookmark"
+)
+
+func TestSync_PushAndChanges(t *testing.T) {
+	app := makeSyncFiber()
+	id := uuid.NewString()
+
+	resp := testAppend(t, app, "/v1/sync", fmt.Sprintf(`{"mutations": [
+		{"op": "create", "uuid": %q, "title": "offline", "value": "https://example.com/offline"},
+		{"op": "create", "title": "second", "value": "https://example.com/second"}
+	]}`, id))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var pushed handler.PushResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &pushed))
+	require.Len(t, pushed.Results, 2)
+	require.Equal(t, string(srv.PushApplied), pushed.Results[0].Status)
+	require.Equal(t, id, pushed.Results[0].Uuid)
+	version := pushed.Results[0].Bookmark.Version
+
+	changes := testChanges(t, app, "/v1/sync", http.StatusOK)
+	require.Len(t, changes.Changes, 2)
+	require.Equal(t, handler.ChangeUpsert, changes.Changes[0].Op)
+	require.Equal(t, id, changes.Changes[0].Bookmark.Uuid.String())
+	require.False(t, changes.More)
+	token := changes.Token
+
+	resp = testAppend(t, app, "/v1/sync", fmt.Sprintf(`{"mutations": [
+		{"op": "update", "uuid": %q, "version": %d, "title": "renamed", "value": "https://example.com/offline"},
+		{"op": "delete", "uuid": %q, "version": %d}
+	]}`, id, version, id, version))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	pushed = handler.PushResponse{}
+	require.NoError(t, render.DecodeJSON(resp.Body, &pushed))
+	require.Equal(t, string(srv.PushApplied), pushed.Results[0].Status)
+	require.Equal(t, string(srv.PushConflict), pushed.Results[1].Status)
+	require.Equal(t, "renamed", pushed.Results[1].Bookmark.Title)
+
+	changes = testChanges(t, app, "/v1/sync?limit=1&since="+token, http.StatusOK)
+	require.Len(t, changes.Changes, 1)
+	require.Equal(t, "renamed", changes.Changes[0].Bookmark.Title)
+	require.NotEqual(t, token, changes.Token)
+
+	testChanges(t, app, "/v1/sync?since=garbage", http.StatusBadRequest)
+	testChanges(t, app, "/v1/sync?since="+srv.EncodeSyncToken(1<<20), http.StatusGone)
+
+	resp = testAppend(t, app, "/v1/sync", `{"mutations": [{"op": "rename"}]}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func testChanges(t *testing.T, app *fiber.App, target string, code int) handler.SyncResponse {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, code, resp.StatusCode)
+
+	var changes handler.SyncResponse
+	if code == http.StatusOK {
+		require.NoError(t, render.DecodeJSON(resp.Body, &changes))
+	}
+
+	return changes
+}
+
+func makeSyncFiber() *fiber.App {
+	hdl := makeHandler()
+
+	app := fiber.New()
+	app.Use(requestid.New())
+
+	app.Get("/v1/sync", hdl.Changes)
+	app.Post("/v1/sync", hdl.Push)
+
+	return app
+}
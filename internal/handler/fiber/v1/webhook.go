@@ -0,0 +1,223 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+
+	"bookmarks/internal/handler"
+	router "bookmarks/internal/handler/fiber"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/webhook"
+)
+
+type WebhookService interface {
+	Create(sub webhook.Subscription) (model.Webhook, error)
+	Update(id string, sub webhook.Subscription) (model.Webhook, error)
+	Webhook(id string) (model.Webhook, error)
+	List() ([]model.Webhook, error)
+	Delete(id string) error
+	Deliveries(id string, filter webhook.ListFilter) ([]model.WebhookDelivery, error)
+}
+
+type webhookHandler struct {
+	service   WebhookService
+	validator *validator.Validate
+}
+
+func NewWebhookHandler(s WebhookService) *webhookHandler {
+	return &webhookHandler{
+		service:   s,
+		validator: validator.New(validator.WithRequiredStructEnabled()),
+	}
+}
+
+// @Summary     Create webhook
+// @Description Subscribe an endpoint to bookmark events, the secret signing the deliveries is shown only once
+// @ID          create-webhook
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Security    BasicAuth
+// @Param       request body     WebhookRequest true "Subscription"
+// @Success     201 {object} handler.WebhookResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     401
+// @Failure     422 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/webhooks [post]
+func (h *webhookHandler) Create(ctx fiber.Ctx) error {
+	sub, err := h.bindSubscription(ctx)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	entity, err := h.service.Create(sub)
+	if err != nil {
+		return webhookErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(handler.NewWebhook(entity, true))
+}
+
+// @Summary     List webhooks
+// @Description List webhook subscriptions, the oldest first
+// @ID          list-webhooks
+// @Tags  	    admin
+// @Produce     json
+// @Security    BasicAuth
+// @Success     200 {object} handler.WebhookListResponse
+// @Failure     401
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/webhooks [get]
+func (h *webhookHandler) List(ctx fiber.Ctx) error {
+	entities, err := h.service.List()
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewWebhookList(entities))
+}
+
+// @Summary     Show webhook
+// @Description Show a webhook subscription with its delivery failures
+// @ID          view-webhook
+// @Tags  	    admin
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id     path      string  true  "Webhook ID"
+// @Success     200 {object} handler.WebhookResponse
+// @Failure     401
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/webhooks/{id} [get]
+func (h *webhookHandler) View(ctx fiber.Ctx) error {
+	entity, err := h.service.Webhook(ctx.Params("id"))
+	if err != nil {
+		return webhookErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewWebhook(entity, false))
+}
+
+// @Summary     Update webhook
+// @Description Replace a webhook subscription, activating a disabled webhook clears its failures
+// @ID          update-webhook
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id      path     string         true "Webhook ID"
+// @Param       request body     WebhookRequest true "Subscription"
+// @Success     200 {object} handler.WebhookResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     401
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     422 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/webhooks/{id} [put]
+func (h *webhookHandler) Update(ctx fiber.Ctx) error {
+	sub, err := h.bindSubscription(ctx)
+	if err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	entity, err := h.service.Update(ctx.Params("id"), sub)
+	if err != nil {
+		return webhookErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewWebhook(entity, false))
+}
+
+// @Summary     Delete webhook
+// @Description Delete a webhook subscription with its delivery log
+// @ID          delete-webhook
+// @Tags  	    admin
+// @Security    BasicAuth
+// @Param       id     path      string  true  "Webhook ID"
+// @Success     204
+// @Failure     401
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/webhooks/{id} [delete]
+func (h *webhookHandler) Delete(ctx fiber.Ctx) error {
+	if err := h.service.Delete(ctx.Params("id")); err != nil {
+		return webhookErrorResponse(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary     List webhook deliveries
+// @Description List the delivery attempts of a webhook, newest first
+// @ID          list-webhook-deliveries
+// @Tags  	    admin
+// @Produce     json
+// @Security    BasicAuth
+// @Param       id     path      string  true  "Webhook ID"
+// @Param       limit  query     int     false "Page size"
+// @Param       offset query     int     false "Page offset"
+// @Success     200 {object} handler.DeliveryListResponse
+// @Failure     400 {object} handler.ErrorResponse
+// @Failure     401
+// @Failure     404 {object} handler.ErrorResponse
+// @Failure     500 {object} handler.ErrorResponse
+// @Router      /admin/webhooks/{id}/deliveries [get]
+func (h *webhookHandler) Deliveries(ctx fiber.Ctx) error {
+	var input ListDeliveriesRequest
+
+	if err := ctx.Bind().Query(&input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		return router.ErrorResponse(ctx, err.Error(), http.StatusBadRequest)
+	}
+
+	filter := webhook.ListFilter{Limit: input.Limit, Offset: input.Offset}.Normalize()
+
+	deliveries, err := h.service.Deliveries(ctx.Params("id"), filter)
+	if err != nil {
+		return webhookErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(handler.NewDeliveryList(deliveries, filter.Limit, filter.Offset))
+}
+
+func (h *webhookHandler) bindSubscription(ctx fiber.Ctx) (webhook.Subscription, error) {
+	var input WebhookRequest
+
+	if err := ctx.Bind().Body(&input); err != nil {
+		return webhook.Subscription{}, err
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		return webhook.Subscription{}, err
+	}
+
+	events := make([]model.EventType, 0, len(input.Events))
+	for _, event := range input.Events {
+		events = append(events, model.EventType(event))
+	}
+
+	return webhook.Subscription{
+		URL:    input.URL,
+		Events: events,
+		Secret: input.Secret,
+		Active: input.Active,
+	}, nil
+}
+
+func webhookErrorResponse(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, webhook.ErrWebhookNotFound):
+		return router.ErrorResponse(ctx, webhook.ErrWebhookNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, model.ErrInvalidWebhookURL), errors.Is(err, model.ErrInvalidEventType):
+		return router.ErrorResponse(ctx, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		return router.ErrorResponse(ctx, err.Error(), http.StatusInternalServerError)
+	}
+}
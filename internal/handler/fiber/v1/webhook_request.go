@@ -0,0 +1,15 @@
+package v1
+
+// WebhookRequest creates or replaces a subscription: empty Events subscribe to every event,
+// an omitted secret is generated on create and kept on update, an omitted active flag is kept.
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"dive,oneof=bookmark.appended bookmark.changed bookmark.deleted"`
+	Secret string   `json:"secret" validate:"omitempty,min=16"`
+	Active *bool    `json:"active"`
+}
+
+type ListDeliveriesRequest struct {
+	Limit  int `query:"limit" validate:"gte=0"`
+	Offset int `query:"offset" validate:"gte=0"`
+}
@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler"
+	router "bookmarks/internal/handler/fiber"
+	jobRepo "bookmarks/internal/repository/job"
+	webhookRepo "bookmarks/internal/repository/webhook"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/internal/service/webhook"
+	"bookmarks/internal/storage/memory"
+)
+
+func TestWebhooks_CRUD(t *testing.T) {
+	app := fiber.New()
+	router.Register(slog.New(slog.DiscardHandler), makeHandler(),
+		router.Webhooks(makeWebhookHandler()), router.Admin(map[string]string{"admin": "secret"}))(app)
+
+	do := func(method, target, body string) *http.Response {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth("admin", "secret")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/v1/admin/webhooks", `{"url":"https://example.com/hook","secret":"0123456789abcdef"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created handler.WebhookResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &created))
+	require.Equal(t, "0123456789abcdef", created.Secret)
+	require.Empty(t, created.Events)
+
+	target := "/v1/admin/webhooks/" + created.ID.String()
+
+	resp = do(http.MethodPut, target, `{"url":"https://example.com/hook","events":["bookmark.appended"]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var updated handler.WebhookResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &updated))
+	require.Empty(t, updated.Secret)
+	require.Len(t, updated.Events, 1)
+	require.True(t, updated.Active)
+
+	resp = do(http.MethodGet, target+"/deliveries?limit=1000", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var deliveries handler.DeliveryListResponse
+	require.NoError(t, render.DecodeJSON(resp.Body, &deliveries))
+	require.Equal(t, webhook.MaxListLimit, deliveries.Limit)
+
+	resp = do(http.MethodPost, "/v1/admin/webhooks", `{"url":"https://example.com","events":["bookmark.viewed"]}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = do(http.MethodDelete, target, "")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = do(http.MethodGet, target, "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/v1/admin/webhooks/1", "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks", nil))
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func makeWebhookHandler() *webhookHandler {
+	logger := slog.New(slog.DiscardHandler)
+	queue := jobs.New(logger, jobRepo.NewRepository(memory.NewJobStorage()), jobs.PollInterval(time.Second))
+
+	return NewWebhookHandler(webhook.New(logger, webhookRepo.NewRepository(memory.NewWebhookStorage()), queue))
+}
@@ -0,0 +1,192 @@
+package graphql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"bookmarks/internal/model"
+)
+
+const (
+	defaultHistoryLimit = 10
+	maxHistoryLimit     = 100
+)
+
+type bookmarkResolver struct {
+	b model.Bookmark
+}
+
+func (r *bookmarkResolver) UUID() graphql.ID {
+	return graphql.ID(r.b.Uuid.String())
+}
+
+func (r *bookmarkResolver) Title() string {
+	return r.b.Title
+}
+
+func (r *bookmarkResolver) TitleAuto() bool {
+	return r.b.TitleAuto
+}
+
+func (r *bookmarkResolver) Value() string {
+	return r.b.Value
+}
+
+func (r *bookmarkResolver) Kind() string {
+	return strings.ToUpper(string(r.b.Kind))
+}
+
+func (r *bookmarkResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.b.CreatedAt}
+}
+
+func (r *bookmarkResolver) Version() Int64 {
+	return Int64(r.b.Version)
+}
+
+func (r *bookmarkResolver) Metadata(ctx context.Context) (*metadataResolver, error) {
+	metadata, ok, err := loadersFrom(ctx).metadata.Load(ctx, r.b.Uuid)
+	if err != nil {
+		return nil, errorOf(err, model.Bookmark{})
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &metadataResolver{m: metadata}, nil
+}
+
+func (r *bookmarkResolver) Health(ctx context.Context) (*healthResolver, error) {
+	health, ok, err := loadersFrom(ctx).health.Load(ctx, r.b.Uuid)
+	if err != nil {
+		return nil, errorOf(err, model.Bookmark{})
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &healthResolver{h: health}, nil
+}
+
+func (r *bookmarkResolver) History(ctx context.Context, args struct{ Limit int32 }) ([]*checkResolver, error) {
+	limit := defaultHistoryLimit
+	if args.Limit > 0 {
+		limit = min(int(args.Limit), maxHistoryLimit)
+	}
+
+	checks, _, err := loadersFrom(ctx).checks(limit).Load(ctx, r.b.Uuid)
+	if err != nil {
+		return nil, errorOf(err, model.Bookmark{})
+	}
+
+	resolvers := make([]*checkResolver, 0, len(checks))
+	for _, check := range checks {
+		resolvers = append(resolvers, &checkResolver{c: check})
+	}
+
+	return resolvers, nil
+}
+
+type metadataResolver struct {
+	m model.Metadata
+}
+
+func (r *metadataResolver) Title() string {
+	return r.m.Title
+}
+
+func (r *metadataResolver) Description() string {
+	return r.m.Description
+}
+
+func (r *metadataResolver) CanonicalURL() string {
+	return r.m.CanonicalURL
+}
+
+func (r *metadataResolver) Favicon() string {
+	return r.m.Favicon
+}
+
+func (r *metadataResolver) Image() string {
+	return r.m.Image
+}
+
+func (r *metadataResolver) Status() string {
+	return string(r.m.Status)
+}
+
+func (r *metadataResolver) Error() string {
+	return r.m.Error
+}
+
+func (r *metadataResolver) Attempts() int32 {
+	return int32(r.m.Attempts) //nolint:gosec // a handful of attempts
+}
+
+func (r *metadataResolver) FetchedAt() graphql.Time {
+	return graphql.Time{Time: r.m.FetchedAt}
+}
+
+type healthResolver struct {
+	h model.LinkHealth
+}
+
+func (r *healthResolver) Status() string {
+	return strings.ToUpper(string(r.h.Status))
+}
+
+func (r *healthResolver) StatusCode() int32 {
+	return int32(r.h.StatusCode) //nolint:gosec // an HTTP status
+}
+
+func (r *healthResolver) RedirectTo() string {
+	return r.h.RedirectTo
+}
+
+func (r *healthResolver) LatencyMs() int32 {
+	return int32(r.h.Latency.Milliseconds()) //nolint:gosec // bounded by the check timeout
+}
+
+func (r *healthResolver) Error() string {
+	return r.h.Error
+}
+
+func (r *healthResolver) ConsecutiveFailures() int32 {
+	return int32(r.h.ConsecutiveFailures) //nolint:gosec // one per check
+}
+
+func (r *healthResolver) CheckedAt() graphql.Time {
+	return graphql.Time{Time: r.h.CheckedAt}
+}
+
+type checkResolver struct {
+	c model.LinkCheck
+}
+
+func (r *checkResolver) OK() bool {
+	return r.c.OK
+}
+
+func (r *checkResolver) StatusCode() int32 {
+	return int32(r.c.StatusCode) //nolint:gosec // an HTTP status
+}
+
+func (r *checkResolver) RedirectTo() string {
+	return r.c.RedirectTo
+}
+
+func (r *checkResolver) LatencyMs() int32 {
+	return int32(r.c.Latency.Milliseconds()) //nolint:gosec // bounded by the check timeout
+}
+
+func (r *checkResolver) Error() string {
+	return r.c.Error
+}
+
+func (r *checkResolver) CheckedAt() graphql.Time {
+	return graphql.Time{Time: r.c.CheckedAt}
+}
@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/bookmark"
+)
+
+//go:embed schema.graphql
+var schema string
+
+const (
+	maxBodySize    = 1 << 20
+	maxQueryLength = 16 << 10
+	maxDepth       = 8
+)
+
+type Service interface {
+	Append(ctx context.Context, title, val string, kind model.Kind, mode bookmark.ConflictMode) (model.Bookmark, bool, error)
+	View(ctx context.Context, uuid string) (model.Bookmark, error)
+	List(ctx context.Context, filter bookmark.ListFilter) ([]model.Bookmark, error)
+	Change(ctx context.Context, uuid, title, val string, kind model.Kind, version int64) (model.Bookmark, error)
+	Delete(ctx context.Context, uuid string) error
+	MetadataOf(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]model.Metadata, error)
+	HealthOf(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]model.LinkHealth, error)
+	ChecksOf(ctx context.Context, uuids []uuid.UUID, limit int) (map[uuid.UUID][]model.LinkCheck, error)
+}
+
+// Handler serves the GraphQL queries posted as JSON.
+type Handler struct {
+	schema  *graphql.Schema
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		schema: graphql.MustParseSchema(
+			schema,
+			&resolver{service: s},
+			graphql.MaxQueryLength(maxQueryLength),
+			graphql.MaxDepth(maxDepth),
+		),
+		service: s,
+	}
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx).With(slog.String("op", "handler.graphql.Serve"))
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&input); err != nil {
+		log.Warn(err.Error())
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response := h.schema.Exec(withLoaders(ctx, h.service), input.Query, input.OperationName, input.Variables)
+
+	for _, err := range response.Errors {
+		var resolverErr *Error
+
+		switch {
+		case errors.As(err.ResolverError, &resolverErr) && resolverErr.Code == CodeInternal:
+			log.Error(resolverErr.Err.Error(), slog.Any("path", err.Path))
+		case err.ResolverError != nil && resolverErr == nil:
+			log.Error(err.ResolverError.Error(), slog.Any("path", err.Path))
+		default:
+			// the client sent an invalid query or input
+			log.Debug(err.Error(), slog.Any("path", err.Path))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error(err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(graphql.Response{
+		Errors: []*gqlerrors.QueryError{{Message: message}},
+	})
+}
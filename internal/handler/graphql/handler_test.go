@@ -0,0 +1,254 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	repo "bookmarks/internal/repository/bookmark"
+	srv "bookmarks/internal/service/bookmark"
+	"bookmarks/internal/storage/memory"
+)
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// countingService counts the batch reads reaching the service.
+type countingService struct {
+	Service
+	metadata, health, checks atomic.Int32
+}
+
+func (s *countingService) MetadataOf(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]model.Metadata, error) {
+	s.metadata.Add(1)
+	return s.Service.MetadataOf(ctx, uuids)
+}
+
+func (s *countingService) HealthOf(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]model.LinkHealth, error) {
+	s.health.Add(1)
+	return s.Service.HealthOf(ctx, uuids)
+}
+
+func (s *countingService) ChecksOf(ctx context.Context, uuids []uuid.UUID, limit int) (map[uuid.UUID][]model.LinkCheck, error) {
+	s.checks.Add(1)
+	return s.Service.ChecksOf(ctx, uuids, limit)
+}
+
+func TestBookmarks_Batching(t *testing.T) {
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	service := &countingService{Service: srv.NewService(repository)}
+	server := httptest.NewServer(NewHandler(service))
+	defer server.Close()
+
+	var uuids []uuid.UUID
+	for _, value := range []string{"https://example.com", "https://example.org", "plain text"} {
+		bookmark, _, err := service.Append(t.Context(), "title", value, model.KindAuto, srv.ConflictError)
+		require.NoError(t, err)
+		uuids = append(uuids, bookmark.Uuid)
+	}
+
+	require.NoError(t, repository.SaveMetadata(t.Context(), model.Metadata{Uuid: uuids[0], Title: "Example", Status: model.MetadataOK}))
+
+	for i := range 3 {
+		check := model.LinkCheck{Uuid: uuids[1], OK: i != 1, StatusCode: 200, CheckedAt: time.Now()}
+		_, err := repository.RecordLinkCheck(t.Context(), check, 2, 0)
+		require.NoError(t, err)
+	}
+
+	resp := query(t, server.URL, `{
+		bookmarks(kind: URL) {
+			limit
+			items {
+				uuid
+				kind
+				metadata { title }
+				health { status }
+				history(limit: 2) { ok }
+				recent: history { ok }
+			}
+		}
+	}`, nil)
+	require.Empty(t, resp.Errors)
+
+	var page struct {
+		Limit int
+		Items []struct {
+			UUID     string
+			Kind     string
+			Metadata *struct{ Title string }
+			Health   *struct{ Status string }
+			History  []struct{ OK bool }
+			Recent   []struct{ OK bool }
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["bookmarks"], &page))
+
+	require.Equal(t, srv.DefaultListLimit, page.Limit)
+	require.Len(t, page.Items, 2)
+
+	// newest first
+	require.Equal(t, uuids[1].String(), page.Items[0].UUID)
+	require.Equal(t, "URL", page.Items[0].Kind)
+	require.Nil(t, page.Items[0].Metadata)
+	require.Equal(t, "OK", page.Items[0].Health.Status)
+	require.Len(t, page.Items[0].History, 2)
+	require.Len(t, page.Items[0].Recent, 3)
+	require.True(t, page.Items[0].History[0].OK)
+	require.False(t, page.Items[0].History[1].OK)
+
+	require.Equal(t, "Example", page.Items[1].Metadata.Title)
+	require.Nil(t, page.Items[1].Health)
+	require.Empty(t, page.Items[1].History)
+
+	// one call per field and history limit for the whole page
+	require.EqualValues(t, 1, service.metadata.Load())
+	require.EqualValues(t, 1, service.health.Load())
+	require.EqualValues(t, 2, service.checks.Load())
+}
+
+func TestMutations(t *testing.T) {
+	server := makeServer(t)
+
+	resp := query(t, server.URL, `mutation($value: String!) {
+		append(title: "first", value: $value) { created bookmark { uuid title version kind } }
+	}`, map[string]any{"value": "https://example.com/graphql"})
+	require.Empty(t, resp.Errors)
+
+	var appended struct {
+		Created  bool
+		Bookmark struct {
+			UUID    string
+			Title   string
+			Version int64
+			Kind    string
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["append"], &appended))
+	require.True(t, appended.Created)
+	require.Positive(t, appended.Bookmark.Version)
+	require.Equal(t, "URL", appended.Bookmark.Kind)
+
+	resp = query(t, server.URL, `mutation {
+		append(title: "second", value: "https://example.com/graphql", onConflict: IGNORE) { created bookmark { title } }
+	}`, nil)
+	require.Empty(t, resp.Errors)
+	require.JSONEq(t, `{"created": false, "bookmark": {"title": "first"}}`, string(resp.Data["append"]))
+
+	resp = query(t, server.URL, `mutation {
+		append(title: "second", value: "https://example.com/graphql") { created }
+	}`, nil)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, CodeConflict, resp.Errors[0].Extensions["code"])
+	require.Equal(t, appended.Bookmark.UUID, resp.Errors[0].Extensions["uuid"])
+
+	change := `mutation($uuid: ID!, $version: Int64!) {
+		change(uuid: $uuid, title: "renamed", value: "https://example.com/graphql", version: $version) { title version }
+	}`
+	variables := map[string]any{"uuid": appended.Bookmark.UUID, "version": appended.Bookmark.Version}
+
+	resp = query(t, server.URL, change, variables)
+	require.Empty(t, resp.Errors)
+
+	var changed struct {
+		Title   string
+		Version int64
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["change"], &changed))
+	require.Equal(t, "renamed", changed.Title)
+	require.Greater(t, changed.Version, appended.Bookmark.Version)
+
+	resp = query(t, server.URL, change, variables)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, CodeConflict, resp.Errors[0].Extensions["code"])
+	require.EqualValues(t, changed.Version, resp.Errors[0].Extensions["version"])
+
+	deleteQuery := `mutation($uuid: ID!) { delete(uuid: $uuid) }`
+
+	resp = query(t, server.URL, deleteQuery, map[string]any{"uuid": appended.Bookmark.UUID})
+	require.Empty(t, resp.Errors)
+	require.JSONEq(t, `true`, string(resp.Data["delete"]))
+
+	resp = query(t, server.URL, deleteQuery, map[string]any{"uuid": appended.Bookmark.UUID})
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, CodeNotFound, resp.Errors[0].Extensions["code"])
+
+	resp = query(t, server.URL, `query($uuid: ID!) { bookmark(uuid: $uuid) { title } }`, map[string]any{"uuid": appended.Bookmark.UUID})
+	require.Empty(t, resp.Errors)
+	require.JSONEq(t, `null`, string(resp.Data["bookmark"]))
+}
+
+func TestErrors(t *testing.T) {
+	server := makeServer(t)
+
+	for name, tc := range map[string]struct {
+		query string
+		code  string
+	}{
+		"invalid uuid":    {`{ bookmark(uuid: "nope") { title } }`, CodeBadUserInput},
+		"invalid value":   {`mutation { append(title: "title", value: " ") { created } }`, CodeBadUserInput},
+		"value of kind":   {`mutation { append(value: "some text", kind: URL) { created } }`, CodeBadUserInput},
+		"negative offset": {`{ bookmarks(offset: -1) { limit } }`, CodeBadUserInput},
+		"unknown field":   {`{ bookmarks { total } }`, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := query(t, server.URL, tc.query, nil)
+			require.Len(t, resp.Errors, 1)
+
+			if tc.code != "" {
+				require.Equal(t, tc.code, resp.Errors[0].Extensions["code"])
+			}
+		})
+	}
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, err = http.Post(server.URL, "application/json", bytes.NewBufferString("{"))
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func makeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	service := srv.NewService(repo.NewRepository(memory.NewBookmarkStorage()))
+	server := httptest.NewServer(NewHandler(service))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func query(t *testing.T, url, q string, variables map[string]any) response {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"query": q, "variables": variables})
+	require.NoError(t, err)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var out response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+
+	return out
+}
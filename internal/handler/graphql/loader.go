@@ -0,0 +1,158 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+)
+
+// Loader batches the loads of a request: the keys queued or loaded before a batch
+// is dispatched are fetched with one call, a loaded key is never fetched again.
+type Loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	open    *batch[K, V]
+	batches map[K]*batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys   []K
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+// NewLoader makes a loader fetching the missing keys with fetch, a key missing from its result has no value.
+// A batch is fetched with the context of the load dispatching it.
+func NewLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:   fetch,
+		batches: make(map[K]*batch[K, V]),
+	}
+}
+
+// Queue adds the keys to the next batch without dispatching it,
+// a resolver of a list queues the keys its items load.
+func (l *Loader[K, V]) Queue(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		l.enqueue(key)
+	}
+}
+
+// Load returns the value of key and whether it has one. The batch of the key is fetched
+// unless another load already did it, the load waits for it then.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, bool, error) {
+	var zero V
+
+	l.mu.Lock()
+	b := l.enqueue(key)
+	dispatch := b == l.open
+	if dispatch {
+		l.open = nil
+	}
+	l.mu.Unlock()
+
+	if dispatch {
+		func() {
+			defer close(b.done)
+			b.values, b.err = l.fetch(ctx, b.keys)
+		}()
+	}
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return zero, false, ctx.Err()
+	}
+
+	if b.err != nil {
+		return zero, false, b.err
+	}
+
+	value, ok := b.values[key]
+
+	return value, ok, nil
+}
+
+func (l *Loader[K, V]) enqueue(key K) *batch[K, V] {
+	if b, ok := l.batches[key]; ok {
+		return b
+	}
+
+	if l.open == nil {
+		l.open = &batch[K, V]{done: make(chan struct{})}
+	}
+
+	l.open.keys = append(l.open.keys, key)
+	l.batches[key] = l.open
+
+	return l.open
+}
+
+// loaders are the loaders of a request, they live in its context.
+type loaders struct {
+	metadata *Loader[uuid.UUID, model.Metadata]
+	health   *Loader[uuid.UUID, model.LinkHealth]
+
+	mu       sync.Mutex
+	service  Service
+	listed   []uuid.UUID
+	checksBy map[int]*Loader[uuid.UUID, []model.LinkCheck] // by limit
+}
+
+type loadersKey struct{}
+
+func newLoaders(s Service) *loaders {
+	return &loaders{
+		metadata: NewLoader(s.MetadataOf),
+		health:   NewLoader(s.HealthOf),
+		service:  s,
+		checksBy: make(map[int]*Loader[uuid.UUID, []model.LinkCheck]),
+	}
+}
+
+// queue prepares the loads of resolved bookmarks, their fields are fetched together.
+func (l *loaders) queue(uuids ...uuid.UUID) {
+	l.metadata.Queue(uuids...)
+	l.health.Queue(uuids...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.listed = append(l.listed, uuids...)
+	for _, checks := range l.checksBy {
+		checks.Queue(uuids...)
+	}
+}
+
+// checks returns the loader of the last limit checks, a loader made late still batches the bookmarks resolved so far.
+func (l *loaders) checks(limit int) *Loader[uuid.UUID, []model.LinkCheck] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loader, ok := l.checksBy[limit]
+	if !ok {
+		loader = NewLoader(func(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID][]model.LinkCheck, error) {
+			return l.service.ChecksOf(ctx, uuids, limit)
+		})
+		loader.Queue(l.listed...)
+		l.checksBy[limit] = loader
+	}
+
+	return loader
+}
+
+func withLoaders(ctx context.Context, s Service) context.Context {
+	return context.WithValue(ctx, loadersKey{}, newLoaders(s))
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
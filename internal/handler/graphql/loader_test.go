@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]int
+	)
+
+	loader := NewLoader(func(_ context.Context, keys []int) (map[int]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		batches = append(batches, keys)
+
+		values := make(map[int]string)
+		for _, key := range keys {
+			if key%2 == 0 {
+				values[key] = "even"
+			}
+		}
+
+		return values, nil
+	})
+
+	ctx := context.Background()
+	loader.Queue(1, 2, 3, 4)
+
+	type result struct {
+		value string
+		ok    bool
+		err   error
+	}
+
+	results := make([]result, 4)
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Go(func() {
+			value, ok, err := loader.Load(ctx, i+1)
+			results[i] = result{value, ok, err}
+		})
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		require.NoError(t, r.err)
+		require.Equal(t, (i+1)%2 == 0, r.ok)
+	}
+
+	require.Equal(t, "even", results[1].value)
+	require.Equal(t, [][]int{{1, 2, 3, 4}}, batches)
+
+	// a loaded key is not fetched again, a new one makes its own batch
+	_, ok, err := loader.Load(ctx, 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, ok, err = loader.Load(ctx, 6)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, [][]int{{1, 2, 3, 4}, {6}}, batches)
+}
+
+func TestLoader_Error(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+
+	loader := NewLoader(func(context.Context, []string) (map[string]int, error) {
+		return nil, errFetch
+	})
+
+	loader.Queue("a", "b")
+
+	_, _, err := loader.Load(context.Background(), "a")
+	require.ErrorIs(t, err, errFetch)
+
+	_, _, err = loader.Load(context.Background(), "b")
+	require.ErrorIs(t, err, errFetch)
+}
@@ -0,0 +1,299 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/bookmark"
+)
+
+// Error codes reported in the extensions of an error.
+const (
+	CodeBadUserInput = "BAD_USER_INPUT"
+	CodeNotFound     = "NOT_FOUND"
+	CodeConflict     = "CONFLICT"
+	CodeInternal     = "INTERNAL"
+)
+
+var ErrInvalidUUID = errors.New("invalid uuid")
+
+// resolver is the root of the schema.
+type resolver struct {
+	service Service
+}
+
+func (r *resolver) Bookmark(ctx context.Context, args struct{ UUID graphql.ID }) (*bookmarkResolver, error) {
+	if _, err := uuid.Parse(string(args.UUID)); err != nil {
+		return nil, newError(CodeBadUserInput, ErrInvalidUUID)
+	}
+
+	entity, err := r.service.View(ctx, string(args.UUID))
+	if err != nil {
+		if errors.Is(err, bookmark.ErrBookmarkNotFound) {
+			return nil, nil
+		}
+
+		return nil, errorOf(err, model.Bookmark{})
+	}
+
+	loadersFrom(ctx).queue(entity.Uuid)
+
+	return &bookmarkResolver{b: entity}, nil
+}
+
+func (r *resolver) Bookmarks(ctx context.Context, args struct {
+	Kind   *string
+	Health *string
+	Limit  *int32
+	Offset *int32
+},
+) (*pageResolver, error) {
+	var filter bookmark.ListFilter
+
+	if args.Kind != nil {
+		filter.Kind = model.Kind(strings.ToLower(*args.Kind))
+	}
+
+	if args.Health != nil {
+		filter.Health = model.HealthStatus(strings.ToLower(*args.Health))
+	}
+
+	if args.Limit != nil {
+		filter.Limit = int(*args.Limit)
+	}
+
+	if args.Offset != nil {
+		if *args.Offset < 0 {
+			return nil, newError(CodeBadUserInput, errors.New("offset must not be negative"))
+		}
+
+		filter.Offset = int(*args.Offset)
+	}
+
+	filter = filter.Normalize()
+
+	entities, err := r.service.List(ctx, filter)
+	if err != nil {
+		return nil, errorOf(err, model.Bookmark{})
+	}
+
+	items := make([]*bookmarkResolver, 0, len(entities))
+	uuids := make([]uuid.UUID, 0, len(entities))
+	for _, entity := range entities {
+		items = append(items, &bookmarkResolver{b: entity})
+		uuids = append(uuids, entity.Uuid)
+	}
+
+	loadersFrom(ctx).queue(uuids...)
+
+	return &pageResolver{items: items, limit: filter.Limit, offset: filter.Offset}, nil
+}
+
+func (r *resolver) Append(ctx context.Context, args struct {
+	Title      *string
+	Value      string
+	Kind       *string
+	OnConflict string
+},
+) (*appendResolver, error) {
+	var title, kind string
+
+	if args.Title != nil {
+		title = *args.Title
+	}
+
+	if args.Kind != nil {
+		kind = strings.ToLower(*args.Kind)
+	}
+
+	mode := bookmark.ConflictMode(strings.ToLower(args.OnConflict))
+
+	entity, created, err := r.service.Append(ctx, title, args.Value, model.Kind(kind), mode)
+	if err != nil {
+		return nil, errorOf(err, entity)
+	}
+
+	loadersFrom(ctx).queue(entity.Uuid)
+
+	return &appendResolver{bookmark: &bookmarkResolver{b: entity}, created: created}, nil
+}
+
+func (r *resolver) Change(ctx context.Context, args struct {
+	UUID    graphql.ID
+	Title   string
+	Value   string
+	Kind    *string
+	Version Int64
+},
+) (*bookmarkResolver, error) {
+	if _, err := uuid.Parse(string(args.UUID)); err != nil {
+		return nil, newError(CodeBadUserInput, ErrInvalidUUID)
+	}
+
+	var kind string
+	if args.Kind != nil {
+		kind = strings.ToLower(*args.Kind)
+	}
+
+	entity, err := r.service.Change(ctx, string(args.UUID), args.Title, args.Value, model.Kind(kind), int64(args.Version))
+	if err != nil {
+		return nil, errorOf(err, entity)
+	}
+
+	loadersFrom(ctx).queue(entity.Uuid)
+
+	return &bookmarkResolver{b: entity}, nil
+}
+
+func (r *resolver) Delete(ctx context.Context, args struct{ UUID graphql.ID }) (bool, error) {
+	if _, err := uuid.Parse(string(args.UUID)); err != nil {
+		return false, newError(CodeBadUserInput, ErrInvalidUUID)
+	}
+
+	if err := r.service.Delete(ctx, string(args.UUID)); err != nil {
+		return false, errorOf(err, model.Bookmark{})
+	}
+
+	return true, nil
+}
+
+type pageResolver struct {
+	items  []*bookmarkResolver
+	limit  int
+	offset int
+}
+
+func (p *pageResolver) Items() []*bookmarkResolver {
+	return p.items
+}
+
+func (p *pageResolver) Limit() int32 {
+	return int32(p.limit) //nolint:gosec // clamped to MaxListLimit
+}
+
+func (p *pageResolver) Offset() int32 {
+	return int32(p.offset) //nolint:gosec // read from an Int
+}
+
+type appendResolver struct {
+	bookmark *bookmarkResolver
+	created  bool
+}
+
+func (a *appendResolver) Bookmark() *bookmarkResolver {
+	return a.bookmark
+}
+
+func (a *appendResolver) Created() bool {
+	return a.created
+}
+
+// Int64 is the scalar of the bookmark versions, it is written as a JSON number
+// and read from a number or a string.
+type Int64 int64
+
+func (Int64) ImplementsGraphQLType(name string) bool {
+	return name == "Int64"
+}
+
+func (i *Int64) UnmarshalGraphQL(input any) error {
+	switch v := input.(type) {
+	case int32:
+		*i = Int64(v)
+	case int64:
+		*i = Int64(v)
+	case float64:
+		if v != float64(int64(v)) {
+			return fmt.Errorf("Int64 can not represent %v", v)
+		}
+
+		*i = Int64(v)
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Int64 can not represent %q", v)
+		}
+
+		*i = Int64(n)
+	default:
+		return fmt.Errorf("wrong type for Int64: %T", input)
+	}
+
+	return nil
+}
+
+func (i Int64) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(i), 10), nil
+}
+
+// Error is an error of a resolver, its code goes to the extensions of the response error.
+// A conflict carries the uuid and version of the stored bookmark.
+type Error struct {
+	Code     string
+	Err      error
+	Bookmark *model.Bookmark
+}
+
+func newError(code string, err error) *Error {
+	return &Error{Code: code, Err: err}
+}
+
+// errorOf maps a service error to its code, the message of an internal error is not disclosed.
+func errorOf(err error, entity model.Bookmark) *Error {
+	switch {
+	case errors.Is(err, bookmark.ErrBookmarkExists), errors.Is(err, bookmark.ErrBookmarkChanged):
+		e := newError(CodeConflict, err)
+		if entity.Uuid != uuid.Nil {
+			e.Bookmark = &entity
+		}
+
+		return e
+	case errors.Is(err, bookmark.ErrBookmarkNotFound), errors.Is(err, bookmark.ErrBookmarkDeleted):
+		return newError(CodeNotFound, err)
+	case isInvalidBookmark(err), errors.Is(err, bookmark.ErrInvalidConflictMode):
+		return newError(CodeBadUserInput, err)
+	default:
+		return newError(CodeInternal, err)
+	}
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Code == CodeInternal:
+		return "internal error"
+	case errors.Is(e.Err, bookmark.ErrBookmarkExists):
+		return bookmark.ErrBookmarkExists.Error()
+	case errors.Is(e.Err, bookmark.ErrBookmarkChanged):
+		return bookmark.ErrBookmarkChanged.Error()
+	default:
+		return e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Extensions() map[string]any {
+	extensions := map[string]any{"code": e.Code}
+
+	if e.Bookmark != nil {
+		extensions["uuid"] = e.Bookmark.Uuid.String()
+		extensions["version"] = e.Bookmark.Version
+	}
+
+	return extensions
+}
+
+func isInvalidBookmark(err error) bool {
+	return errors.Is(err, model.ErrInvalidTitle) ||
+		errors.Is(err, model.ErrInvalidValue) ||
+		errors.Is(err, model.ErrInvalidKind)
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"bookmarks/internal/logger"
+	"bookmarks/internal/tracing"
+)
+
+// UnaryLogger puts the logger of the call in its context and logs every completed call,
+// it runs after UnaryRequestID.
+func UnaryLogger(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		t1 := time.Now()
+
+		requestLog := requestLogger(ctx, log, info.FullMethod)
+
+		resp, err := handler(logger.NewContext(ctx, requestLog), req)
+
+		completed(ctx, entry(ctx, requestLog), err, time.Since(t1))
+
+		return resp, err
+	}
+}
+
+// StreamLogger puts the logger of the stream in its context and logs every completed stream,
+// it runs after StreamRequestID.
+func StreamLogger(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		t1 := time.Now()
+
+		ctx := ss.Context()
+		requestLog := requestLogger(ctx, log, info.FullMethod)
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: logger.NewContext(ctx, requestLog)})
+
+		completed(ctx, entry(ctx, requestLog), err, time.Since(t1))
+
+		return err
+	}
+}
+
+// requestLogger is the logger of a call, with its request id, method and trace id.
+func requestLogger(ctx context.Context, log *slog.Logger, method string) *slog.Logger {
+	attrs := []any{
+		slog.String("request_id", GetReqID(ctx)),
+		slog.String("route", method),
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		attrs = append(attrs, slog.String("trace_id", traceID))
+	}
+
+	return log.With(attrs...)
+}
+
+// completed logs the end of a call, the codes of client errors below the server faults.
+func completed(ctx context.Context, log *slog.Logger, err error, duration time.Duration) {
+	st := status.Convert(err)
+
+	attrs := []any{
+		slog.String("code", st.Code().String()),
+		slog.String("duration", duration.String()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", st.Message()))
+	}
+
+	log.Log(ctx, codeLevel(st.Code()), "request completed", attrs...)
+}
+
+// codeLevel is the level of a call ending with the code, after logger.StatusLevel.
+func codeLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unauthenticated:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+func entry(ctx context.Context, log *slog.Logger) *slog.Logger {
+	var remoteAddr, userAgent string
+
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if agents := md.Get("user-agent"); len(agents) > 0 {
+			userAgent = agents[0]
+		}
+	}
+
+	return log.With(
+		slog.String("op", "middleware/logger"),
+		slog.String("remote_addr", remoteAddr),
+		slog.String("user_agent", userAgent),
+	)
+}
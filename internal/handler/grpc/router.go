@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"log/slog"
+
+	"google.golang.org/grpc"
+
+	"bookmarks/internal/handler/grpc/middleware"
+	bookmarkv1 "bookmarks/pkg/grpc/bookmark/v1"
+)
+
+// Register mounts the bookmark service, the health and reflection services are added by the server.
+func Register(bookmarkHnd bookmarkv1.BookmarkServiceServer) func(*grpc.Server) {
+	return func(s *grpc.Server) {
+		bookmarkv1.RegisterBookmarkServiceServer(s, bookmarkHnd)
+	}
+}
+
+// Interceptors assign a request id to every call and log it, like the middleware of the HTTP routers.
+func Interceptors(log *slog.Logger) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			middleware.UnaryRequestID(),
+			middleware.UnaryLogger(log),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.StreamRequestID(),
+			middleware.StreamLogger(log),
+		),
+	}
+}
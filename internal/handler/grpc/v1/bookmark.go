@@ -0,0 +1,354 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/bookmark"
+	"bookmarks/internal/service/stream"
+	bookmarkv1 "bookmarks/pkg/grpc/bookmark/v1"
+)
+
+type Service interface {
+	Append(ctx context.Context, title, val string, kind model.Kind, mode bookmark.ConflictMode) (model.Bookmark, bool, error)
+	View(ctx context.Context, uuid string) (model.Bookmark, error)
+	List(ctx context.Context, filter bookmark.ListFilter) ([]model.Bookmark, error)
+	Change(ctx context.Context, uuid, title, val string, kind model.Kind, version int64) (model.Bookmark, error)
+	Delete(ctx context.Context, uuid string) error
+}
+
+type EventBroker interface {
+	Subscribe(lastID int64, types []model.EventType) (*stream.Subscription, error)
+	Close()
+}
+
+type Option func(*bookmarkHandler)
+
+// Events serves Watch from the broker, without it Watch is unimplemented.
+func Events(b EventBroker) Option {
+	return func(h *bookmarkHandler) {
+		h.broker = b
+	}
+}
+
+type bookmarkHandler struct {
+	bookmarkv1.UnimplementedBookmarkServiceServer
+
+	service Service
+	broker  EventBroker
+}
+
+func NewHandler(s Service, options ...Option) *bookmarkHandler {
+	h := &bookmarkHandler{
+		service: s,
+	}
+
+	for _, opt := range options {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *bookmarkHandler) Append(ctx context.Context, req *bookmarkv1.AppendRequest) (*bookmarkv1.AppendResponse, error) {
+	kind, err := kindToModel(req.GetKind())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	mode, err := conflictModeToModel(req.GetOnConflict())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	entity, created, err := h.service.Append(ctx, req.GetTitle(), req.GetValue(), kind, mode)
+	if err != nil {
+		return nil, errorStatus(err, entity)
+	}
+
+	return &bookmarkv1.AppendResponse{
+		Bookmark: bookmarkToProto(entity),
+		Created:  created,
+	}, nil
+}
+
+func (h *bookmarkHandler) View(ctx context.Context, req *bookmarkv1.ViewRequest) (*bookmarkv1.Bookmark, error) {
+	if err := validateUuid(req.GetUuid()); err != nil {
+		return nil, err
+	}
+
+	entity, err := h.service.View(ctx, req.GetUuid())
+	if err != nil {
+		return nil, errorStatus(err, entity)
+	}
+
+	return bookmarkToProto(entity), nil
+}
+
+func (h *bookmarkHandler) Change(ctx context.Context, req *bookmarkv1.ChangeRequest) (*bookmarkv1.Bookmark, error) {
+	if err := validateUuid(req.GetUuid()); err != nil {
+		return nil, err
+	}
+
+	kind, err := kindToModel(req.GetKind())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	entity, err := h.service.Change(ctx, req.GetUuid(), req.GetTitle(), req.GetValue(), kind, req.GetVersion())
+	if err != nil {
+		return nil, errorStatus(err, entity)
+	}
+
+	return bookmarkToProto(entity), nil
+}
+
+func (h *bookmarkHandler) Delete(ctx context.Context, req *bookmarkv1.DeleteRequest) (*bookmarkv1.DeleteResponse, error) {
+	if err := validateUuid(req.GetUuid()); err != nil {
+		return nil, err
+	}
+
+	if err := h.service.Delete(ctx, req.GetUuid()); err != nil {
+		return nil, errorStatus(err, model.Bookmark{})
+	}
+
+	return &bookmarkv1.DeleteResponse{}, nil
+}
+
+func (h *bookmarkHandler) List(ctx context.Context, req *bookmarkv1.ListRequest) (*bookmarkv1.ListResponse, error) {
+	kind, err := kindToModel(req.GetKind())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var health model.HealthStatus
+	if req.GetHealth() != "" {
+		if health, err = model.ParseHealthStatus(req.GetHealth()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	if req.GetLimit() < 0 || req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+
+	filter := bookmark.ListFilter{
+		Kind:   kind,
+		Health: health,
+		Limit:  int(req.GetLimit()),
+		Offset: int(req.GetOffset()),
+	}.Normalize()
+
+	entities, err := h.service.List(ctx, filter)
+	if err != nil {
+		return nil, errorStatus(err, model.Bookmark{})
+	}
+
+	items := make([]*bookmarkv1.Bookmark, 0, len(entities))
+	for _, entity := range entities {
+		items = append(items, bookmarkToProto(entity))
+	}
+
+	return &bookmarkv1.ListResponse{
+		Items:  items,
+		Limit:  int32(filter.Limit),  //nolint:gosec // clamped to MaxListLimit
+		Offset: int32(filter.Offset), //nolint:gosec // read from an int32
+	}, nil
+}
+
+// Watch streams the bookmark changes the caller may read, see handler.CanRead. A resumed stream
+// that missed events starts with a reset event, the client reloads its state then.
+func (h *bookmarkHandler) Watch(req *bookmarkv1.WatchRequest, srv grpc.ServerStreamingServer[bookmarkv1.Event]) error {
+	ctx := srv.Context()
+	log := logger.FromContext(ctx).With(slog.String("op", "handler.v1.bookmark.Watch"))
+
+	if h.broker == nil {
+		return status.Error(codes.Unimplemented, "event stream is disabled")
+	}
+
+	types := make([]model.EventType, 0, len(req.GetTypes()))
+	for _, raw := range req.GetTypes() {
+		eventType, err := model.ParseEventType(raw)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		types = append(types, eventType)
+	}
+
+	if req.GetLastEventId() < 0 {
+		return status.Error(codes.InvalidArgument, handler.ErrInvalidLastEventID.Error())
+	}
+
+	principal, _ := handler.PrincipalFromContext(ctx)
+	if !handler.CanRead(principal, time.Now()) {
+		return status.Error(codes.Unauthenticated, handler.ErrStreamExpired.Error())
+	}
+
+	sub, err := h.broker.Subscribe(req.GetLastEventId(), types)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer sub.Close()
+
+	// the headers go out even without events, a client knows the stream is open
+	if err := srv.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	if sub.Missed {
+		if err := srv.Send(&bookmarkv1.Event{Id: sub.Last, Type: handler.EventReset}); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range sub.Replay {
+		if err := srv.Send(eventToProto(msg)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+
+			if !handler.CanRead(principal, time.Now()) {
+				return status.Error(codes.Unauthenticated, handler.ErrStreamExpired.Error())
+			}
+
+			if err := srv.Send(eventToProto(msg)); err != nil {
+				log.Debug(err.Error())
+				return err
+			}
+		}
+	}
+}
+
+// Close ends the open streams, the server waits for them otherwise.
+func (h *bookmarkHandler) Close() {
+	if h.broker != nil {
+		h.broker.Close()
+	}
+}
+
+// errorStatus maps the service errors to status codes, a conflict carries the stored bookmark in its details.
+func errorStatus(err error, entity model.Bookmark) error {
+	var st *status.Status
+
+	switch {
+	case errors.Is(err, bookmark.ErrBookmarkExists):
+		st = status.New(codes.AlreadyExists, bookmark.ErrBookmarkExists.Error())
+	case errors.Is(err, bookmark.ErrBookmarkChanged):
+		st = status.New(codes.Aborted, bookmark.ErrBookmarkChanged.Error())
+	case errors.Is(err, bookmark.ErrBookmarkNotFound), errors.Is(err, bookmark.ErrBookmarkDeleted):
+		return status.Error(codes.NotFound, err.Error())
+	case isInvalidBookmark(err), errors.Is(err, bookmark.ErrInvalidConflictMode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	if entity.Uuid != uuid.Nil {
+		if detailed, detailErr := st.WithDetails(bookmarkToProto(entity)); detailErr == nil {
+			st = detailed
+		}
+	}
+
+	return st.Err()
+}
+
+func validateUuid(u string) error {
+	if _, err := uuid.Parse(u); err != nil {
+		return status.Error(codes.InvalidArgument, "invalid uuid")
+	}
+
+	return nil
+}
+
+func isInvalidBookmark(err error) bool {
+	return errors.Is(err, model.ErrInvalidTitle) ||
+		errors.Is(err, model.ErrInvalidValue) ||
+		errors.Is(err, model.ErrInvalidKind)
+}
+
+func bookmarkToProto(b model.Bookmark) *bookmarkv1.Bookmark {
+	return &bookmarkv1.Bookmark{
+		Uuid:           b.Uuid.String(),
+		Title:          b.Title,
+		TitleAuto:      b.TitleAuto,
+		Value:          b.Value,
+		Kind:           kindToProto(b.Kind),
+		CanonicalValue: b.CanonicalValue,
+		CreatedAt:      timestamppb.New(b.CreatedAt),
+		Version:        b.Version,
+	}
+}
+
+func eventToProto(msg stream.Message) *bookmarkv1.Event {
+	return &bookmarkv1.Event{
+		Id:         msg.ID,
+		Type:       string(msg.Event.Type),
+		OccurredAt: timestamppb.New(msg.Event.OccurredAt),
+		Bookmark:   bookmarkToProto(msg.Event.Bookmark),
+	}
+}
+
+var kinds = map[bookmarkv1.Kind]model.Kind{
+	bookmarkv1.Kind_KIND_UNSPECIFIED: model.KindAuto,
+	bookmarkv1.Kind_KIND_URL:         model.KindURL,
+	bookmarkv1.Kind_KIND_TEXT:        model.KindText,
+	bookmarkv1.Kind_KIND_CODE:        model.KindCode,
+	bookmarkv1.Kind_KIND_CONTACT:     model.KindContact,
+}
+
+func kindToModel(kind bookmarkv1.Kind) (model.Kind, error) {
+	k, ok := kinds[kind]
+	if !ok {
+		return "", model.ErrInvalidKind
+	}
+
+	return k, nil
+}
+
+func kindToProto(kind model.Kind) bookmarkv1.Kind {
+	for k, v := range kinds {
+		if v == kind {
+			return k
+		}
+	}
+
+	return bookmarkv1.Kind_KIND_UNSPECIFIED
+}
+
+var conflictModes = map[bookmarkv1.ConflictMode]bookmark.ConflictMode{
+	bookmarkv1.ConflictMode_CONFLICT_MODE_UNSPECIFIED:  bookmark.ConflictError,
+	bookmarkv1.ConflictMode_CONFLICT_MODE_ERROR:        bookmark.ConflictError,
+	bookmarkv1.ConflictMode_CONFLICT_MODE_IGNORE:       bookmark.ConflictIgnore,
+	bookmarkv1.ConflictMode_CONFLICT_MODE_UPDATE_TITLE: bookmark.ConflictUpdateTitle,
+}
+
+func conflictModeToModel(mode bookmarkv1.ConflictMode) (bookmark.ConflictMode, error) {
+	m, ok := conflictModes[mode]
+	if !ok {
+		return "", bookmark.ErrInvalidConflictMode
+	}
+
+	return m, nil
+}
@@ -0,0 +1,256 @@
+package v1
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	handlers "bookmarks/internal/handler"
+	handler "bookmarks/internal/handler/grpc"
+	"bookmarks/internal/handler/grpc/middleware"
+	"bookmarks/internal/model"
+	repo "bookmarks/internal/repository/bookmark"
+	srv "bookmarks/internal/service/bookmark"
+	"bookmarks/internal/service/stream"
+	"bookmarks/internal/storage/memory"
+	bookmarkv1 "bookmarks/pkg/grpc/bookmark/v1"
+)
+
+func TestAppend(t *testing.T) {
+	client, _ := makeClient(t)
+	ctx := context.Background()
+
+	var header metadata.MD
+	resp, err := client.Append(ctx, &bookmarkv1.AppendRequest{Title: "test", Value: "value"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.True(t, resp.GetCreated())
+	require.Equal(t, "value", resp.GetBookmark().GetValue())
+	require.Equal(t, bookmarkv1.Kind_KIND_TEXT, resp.GetBookmark().GetKind())
+	require.NotEmpty(t, header.Get(middleware.RequestIDHeader))
+
+	_, err = client.Append(ctx, &bookmarkv1.AppendRequest{Title: "second", Value: "value"})
+	st := status.Convert(err)
+	require.Equal(t, codes.AlreadyExists, st.Code())
+	require.Len(t, st.Details(), 1)
+	require.Equal(t, resp.GetBookmark().GetUuid(), st.Details()[0].(*bookmarkv1.Bookmark).GetUuid())
+
+	resp, err = client.Append(ctx, &bookmarkv1.AppendRequest{
+		Title:      "second",
+		Value:      "value",
+		OnConflict: bookmarkv1.ConflictMode_CONFLICT_MODE_UPDATE_TITLE,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.GetCreated())
+	require.Equal(t, "second", resp.GetBookmark().GetTitle())
+
+	_, err = client.Append(ctx, &bookmarkv1.AppendRequest{Title: "test"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Append(ctx, &bookmarkv1.AppendRequest{Title: "test", Value: "value", Kind: 42})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRequestID(t *testing.T) {
+	client, _ := makeClient(t)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), middleware.RequestIDHeader, "client-id")
+
+	var header metadata.MD
+	_, err := client.List(ctx, &bookmarkv1.ListRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, []string{"client-id"}, header.Get(middleware.RequestIDHeader))
+}
+
+func TestViewChangeDelete(t *testing.T) {
+	client, _ := makeClient(t)
+	ctx := context.Background()
+
+	created, err := client.Append(ctx, &bookmarkv1.AppendRequest{Value: "https://example.com/grpc"})
+	require.NoError(t, err)
+	bookmark := created.GetBookmark()
+
+	viewed, err := client.View(ctx, &bookmarkv1.ViewRequest{Uuid: bookmark.GetUuid()})
+	require.NoError(t, err)
+	require.Equal(t, bookmark.GetVersion(), viewed.GetVersion())
+
+	changed, err := client.Change(ctx, &bookmarkv1.ChangeRequest{
+		Uuid:    bookmark.GetUuid(),
+		Title:   "renamed",
+		Value:   bookmark.GetValue(),
+		Version: bookmark.GetVersion(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "renamed", changed.GetTitle())
+	require.Greater(t, changed.GetVersion(), bookmark.GetVersion())
+
+	_, err = client.Change(ctx, &bookmarkv1.ChangeRequest{
+		Uuid:    bookmark.GetUuid(),
+		Title:   "stale",
+		Value:   bookmark.GetValue(),
+		Version: bookmark.GetVersion(),
+	})
+	st := status.Convert(err)
+	require.Equal(t, codes.Aborted, st.Code())
+	require.Equal(t, "renamed", st.Details()[0].(*bookmarkv1.Bookmark).GetTitle())
+
+	_, err = client.Delete(ctx, &bookmarkv1.DeleteRequest{Uuid: bookmark.GetUuid()})
+	require.NoError(t, err)
+
+	_, err = client.View(ctx, &bookmarkv1.ViewRequest{Uuid: bookmark.GetUuid()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Delete(ctx, &bookmarkv1.DeleteRequest{Uuid: bookmark.GetUuid()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.View(ctx, &bookmarkv1.ViewRequest{Uuid: "nope"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestList(t *testing.T) {
+	client, _ := makeClient(t)
+	ctx := context.Background()
+
+	for _, value := range []string{"https://example.com", "plain text", "https://example.org"} {
+		_, err := client.Append(ctx, &bookmarkv1.AppendRequest{Title: "title", Value: value})
+		require.NoError(t, err)
+	}
+
+	resp, err := client.List(ctx, &bookmarkv1.ListRequest{Kind: bookmarkv1.Kind_KIND_URL, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.GetItems(), 1)
+	require.Equal(t, "https://example.org", resp.GetItems()[0].GetValue())
+	require.EqualValues(t, 1, resp.GetLimit())
+
+	resp, err = client.List(ctx, &bookmarkv1.ListRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetItems(), 3)
+	require.EqualValues(t, srv.DefaultListLimit, resp.GetLimit())
+
+	_, err = client.List(ctx, &bookmarkv1.ListRequest{Health: "sick"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.List(ctx, &bookmarkv1.ListRequest{Offset: -1})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestWatch(t *testing.T) {
+	client, broker := makeClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch, err := client.Watch(ctx, &bookmarkv1.WatchRequest{Types: []string{string(model.BookmarkDeleted)}})
+	require.NoError(t, err)
+
+	// the stream is subscribed once the headers arrive
+	_, err = watch.Header()
+	require.NoError(t, err)
+
+	bookmark, err := model.NewBookmark("title", "https://example.com/watch")
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Publish(ctx, model.Event{ID: 1, Type: model.BookmarkAppended, Bookmark: bookmark}))
+	require.NoError(t, broker.Publish(ctx, model.Event{ID: 2, Type: model.BookmarkDeleted, Bookmark: bookmark}))
+
+	event, err := watch.Recv()
+	require.NoError(t, err)
+	require.Equal(t, string(model.BookmarkDeleted), event.GetType())
+	require.Equal(t, bookmark.Uuid.String(), event.GetBookmark().GetUuid())
+
+	// a resumed stream replays the events after the last one seen
+	resumed, err := client.Watch(ctx, &bookmarkv1.WatchRequest{LastEventId: event.GetId() - 1})
+	require.NoError(t, err)
+
+	replayed, err := resumed.Recv()
+	require.NoError(t, err)
+	require.Equal(t, event.GetId(), replayed.GetId())
+
+	// an id the broker never issued can not be resumed
+	reset, err := client.Watch(ctx, &bookmarkv1.WatchRequest{LastEventId: 100})
+	require.NoError(t, err)
+
+	missed, err := reset.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "reset", missed.GetType())
+
+	invalid, err := client.Watch(ctx, &bookmarkv1.WatchRequest{Types: []string{"bookmark.renamed"}})
+	require.NoError(t, err)
+	_, err = invalid.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// closing the broker ends the streams
+	broker.Close()
+
+	_, err = watch.Recv()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestWatch_Expired(t *testing.T) {
+	principal := model.Principal{Name: "alice", ExpiresAt: time.Now().Add(-time.Second)}
+
+	// the server has no authentication of its own, the principal is put in by the test
+	client, _ := makeClient(t, grpc.StreamInterceptor(
+		func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+			return next(srv, &principalStream{ServerStream: ss, principal: principal})
+		},
+	))
+
+	watch, err := client.Watch(context.Background(), &bookmarkv1.WatchRequest{})
+	require.NoError(t, err)
+
+	_, err = watch.Recv()
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// principalStream authenticates a stream as the principal.
+type principalStream struct {
+	grpc.ServerStream
+	principal model.Principal
+}
+
+func (s *principalStream) Context() context.Context {
+	return handlers.NewPrincipalContext(s.ServerStream.Context(), s.principal)
+}
+
+func makeClient(t *testing.T, options ...grpc.ServerOption) (bookmarkv1.BookmarkServiceClient, *stream.Broker) {
+	t.Helper()
+
+	logger := slog.New(slog.DiscardHandler)
+	broker := stream.New(logger)
+	service := srv.NewService(repo.NewRepository(memory.NewBookmarkStorage()))
+
+	server := grpc.NewServer(append(handler.Interceptors(logger), options...)...)
+	handler.Register(NewHandler(service, Events(broker)))(server)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		server.Stop()
+	})
+
+	return bookmarkv1.NewBookmarkServiceClient(conn), broker
+}
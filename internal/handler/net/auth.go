@@ -0,0 +1,81 @@
+package net
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/account"
+)
+
+// TokenAuthenticator resolves the principal of an API token, see account.Service.
+type TokenAuthenticator interface {
+	Authenticate(secret string) (model.Principal, error)
+}
+
+// authenticate lets a request through with a bearer token of a user or the basic credentials
+// of an admin, keeping its principal in the context; anything else is answered with 401.
+func authenticate(credentials map[string]string, tokens TokenAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if secret, ok := bearerToken(r.Header.Get("Authorization")); ok && tokens != nil {
+				principal, err := tokens.Authenticate(secret)
+				switch {
+				case err == nil:
+					// the logger adds the basic auth user only
+					log := logger.FromContext(r.Context()).With(slog.String("user", principal.Name))
+					ctx := handler.NewPrincipalContext(logger.NewContext(r.Context(), log), principal)
+					next.ServeHTTP(w, r.WithContext(ctx))
+
+					return
+				case !errors.Is(err, account.ErrInvalidToken):
+					ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			} else if user, password, ok := r.BasicAuth(); ok && validCredentials(credentials, user, password) {
+				ctx := handler.NewPrincipalContext(r.Context(), model.Principal{Name: user, Admin: true})
+				next.ServeHTTP(w, r.WithContext(ctx))
+
+				return
+			}
+
+			w.Header().Add("WWW-Authenticate", `Basic realm="admin"`)
+			w.Header().Add("WWW-Authenticate", "Bearer")
+			ErrorResponse(w, r, "unauthorized", http.StatusUnauthorized)
+		})
+	}
+}
+
+// requireAdmin answers 403 to a principal authenticate let in that is not an admin.
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, _ := handler.PrincipalFromContext(r.Context()); !principal.Admin {
+			ErrorResponse(w, r, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerToken reads the token of an Authorization header.
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
+
+// validCredentials compares plain text credentials in constant time.
+func validCredentials(credentials map[string]string, user, password string) bool {
+	expected, ok := credentials[user]
+
+	return ok && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
@@ -1,17 +1,26 @@
 package net
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 
 	"bookmarks/internal/handler"
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/tracing"
 )
 
+// ErrorResponse writes the error and logs it with the logger of the request,
+// server faults are answered with their status text.
 func ErrorResponse(w http.ResponseWriter, r *http.Request, err string, status int) {
+	logger.FromContext(r.Context()).Log(r.Context(), logger.StatusLevel(status), err, slog.Int("status", status))
+
 	ctx := &handler.ErrorContext{
 		RequestID: middleware.GetReqID(r.Context()),
+		TraceID:   tracing.TraceID(r.Context()),
 	}
 
 	if status >= 500 {
@@ -21,3 +30,15 @@ func ErrorResponse(w http.ResponseWriter, r *http.Request, err string, status in
 	render.Status(r, status)
 	render.JSON(w, r, handler.NewError(err, ctx))
 }
+
+func ConflictResponse(w http.ResponseWriter, r *http.Request, err string, existing model.Bookmark) {
+	logger.FromContext(r.Context()).Warn(err, slog.Int("status", http.StatusConflict))
+
+	ctx := &handler.ErrorContext{
+		RequestID: middleware.GetReqID(r.Context()),
+		TraceID:   tracing.TraceID(r.Context()),
+	}
+
+	render.Status(r, http.StatusConflict)
+	render.JSON(w, r, handler.NewConflict(err, ctx, existing))
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"bookmarks/pkg/http/tlsconfig"
+)
+
+// ClientIdentity puts the identity of a mutual TLS client in the context of the request,
+// the handlers read it with tlsconfig.FromContext.
+func ClientIdentity(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := tlsconfig.ClientIdentity(r.TLS); ok {
+			r = r.WithContext(tlsconfig.NewContext(r.Context(), id))
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
@@ -6,34 +6,56 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+
+	"bookmarks/internal/logger"
+	"bookmarks/internal/tracing"
+	"bookmarks/pkg/http/tlsconfig"
 )
 
+// Logger puts the logger of the request in its context, with the request id, the basic auth user,
+// the mutual TLS client, the route and the trace id, and logs the completed request: client errors
+// below server faults. It runs after RequestID, Tracing and ClientIdentity.
 func Logger(log *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		log := log.With(
-			slog.String("op", "middleware/logger"),
-		)
-
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			entry := log.With(
+			ctx := r.Context()
+
+			attrs := []any{slog.String("request_id", middleware.GetReqID(ctx))}
+			if user, _, ok := r.BasicAuth(); ok {
+				attrs = append(attrs, slog.String("user", user))
+			}
+			if id, ok := tlsconfig.FromContext(ctx); ok {
+				attrs = append(attrs, slog.String("client", id.CommonName))
+			}
+			if traceID := tracing.TraceID(ctx); traceID != "" {
+				attrs = append(attrs, slog.String("trace_id", traceID))
+			}
+
+			requestLog, done := logger.WithRoute(log.With(attrs...), func() string { return routePattern(r) })
+
+			entry := requestLog.With(
+				slog.String("op", "middleware/logger"),
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
 				slog.String("remote_addr", r.RemoteAddr),
 				slog.String("user_agent", r.UserAgent()),
-				slog.String("request_id", middleware.GetReqID(r.Context())),
 			)
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
 			t1 := time.Now()
 			defer func() {
-				entry.Info("request completed",
-					slog.Int("status", ww.Status()),
+				status := responseStatus(ww)
+
+				entry.Log(ctx, logger.StatusLevel(status), "request completed",
+					slog.Int("status", status),
 					slog.Int("bytes", ww.BytesWritten()),
 					slog.String("duration", time.Since(t1).String()),
 				)
+
+				done()
 			}()
 
-			next.ServeHTTP(ww, r)
+			next.ServeHTTP(ww, r.WithContext(logger.NewContext(ctx, requestLog)))
 		}
 
 		return http.HandlerFunc(fn)
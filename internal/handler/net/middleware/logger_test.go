@@ -0,0 +1,46 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler/net/middleware"
+	"bookmarks/internal/logger"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	router := chi.NewRouter()
+	router.Use(chimiddleware.RequestID)
+	router.Use(middleware.Logger(slog.New(slog.NewTextHandler(&buf, nil))))
+	router.Get("/v1/bookmark/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("in handler")
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/bookmark/a", nil)
+	req.SetBasicAuth("guest", "secret")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	handler, completed := string(lines[0]), string(lines[1])
+	require.Contains(t, handler, "msg=\"in handler\"")
+	require.Contains(t, handler, "user=guest")
+	require.Contains(t, handler, "route=/v1/bookmark/{uuid}")
+	require.Regexp(t, `request_id=\S+`, handler)
+	require.NotContains(t, handler, "secret")
+
+	// a client error is logged below the server faults
+	require.Contains(t, completed, "level=WARN")
+	require.Contains(t, completed, "status=404")
+}
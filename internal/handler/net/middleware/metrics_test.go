@@ -0,0 +1,43 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler/net/middleware"
+	"bookmarks/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+
+	router := chi.NewRouter()
+	router.Use(middleware.Metrics(m))
+	router.Route("/v1/bookmark", func(r chi.Router) {
+		r.Get("/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+			if chi.URLParam(r, "uuid") == "missing" {
+				http.NotFound(w, r)
+				return
+			}
+
+			_, _ = w.Write([]byte("ok"))
+		})
+	})
+
+	for _, path := range []string{"/v1/bookmark/a", "/v1/bookmark/b", "/v1/bookmark/missing", "/wp-login.php"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	require.Contains(t, body, `bookmarks_http_requests_total{method="GET",route="/v1/bookmark/{uuid}",status="200"} 2`)
+	require.Contains(t, body, `bookmarks_http_requests_total{method="GET",route="/v1/bookmark/{uuid}",status="404"} 1`)
+	require.Contains(t, body, `bookmarks_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	require.Contains(t, body, `bookmarks_http_requests_in_flight 0`)
+}
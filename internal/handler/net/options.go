@@ -0,0 +1,132 @@
+package net
+
+import (
+	"net/http"
+
+	"bookmarks/internal/metrics"
+)
+
+type JobHandler interface {
+	List(w http.ResponseWriter, r *http.Request)
+	View(w http.ResponseWriter, r *http.Request)
+	Retry(w http.ResponseWriter, r *http.Request)
+}
+
+type WebhookHandler interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	View(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Deliveries(w http.ResponseWriter, r *http.Request)
+}
+
+type BackupHandler interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+}
+
+type EventHandler interface {
+	Stream(w http.ResponseWriter, r *http.Request)
+	Close()
+}
+
+type ProbeHandler interface {
+	Ready(w http.ResponseWriter, r *http.Request)
+	Close()
+}
+
+// routes are the optional parts of the router.
+type routes struct {
+	jobHnd     JobHandler
+	webhookHnd WebhookHandler
+	backupHnd  BackupHandler
+	eventHnd   EventHandler
+	probeHnd   ProbeHandler
+	graphqlHnd http.Handler
+	metrics    *metrics.Metrics
+	tracing    bool
+	admins     map[string]string // user -> password
+	tokens     TokenAuthenticator
+	private    bool
+}
+
+type Option func(*routes)
+
+// Jobs mounts the job administration under /v1/admin/jobs, it requires Admin.
+func Jobs(h JobHandler) Option {
+	return func(r *routes) {
+		r.jobHnd = h
+	}
+}
+
+// Webhooks mounts the webhook subscriptions under /v1/admin/webhooks, it requires Admin.
+func Webhooks(h WebhookHandler) Option {
+	return func(r *routes) {
+		r.webhookHnd = h
+	}
+}
+
+// Backups mounts the database backups under /v1/admin/backups, it requires Admin.
+func Backups(h BackupHandler) Option {
+	return func(r *routes) {
+		r.backupHnd = h
+	}
+}
+
+// Events mounts the event stream under /v1/events, the streams are closed when the server shuts down.
+func Events(h EventHandler) Option {
+	return func(r *routes) {
+		r.eventHnd = h
+	}
+}
+
+// Probes serves the readiness under /health/ready, it fails as soon as the server shuts down.
+func Probes(h ProbeHandler) Option {
+	return func(r *routes) {
+		r.probeHnd = h
+	}
+}
+
+// GraphQL mounts the GraphQL endpoint under /graphql.
+func GraphQL(h http.Handler) Option {
+	return func(r *routes) {
+		r.graphqlHnd = h
+	}
+}
+
+// Metrics collects the request metrics and serves all metrics under /metrics.
+func Metrics(m *metrics.Metrics) Option {
+	return func(r *routes) {
+		r.metrics = m
+	}
+}
+
+// Tracing serves every request in a server span, continuing the trace of a W3C traceparent header.
+func Tracing() Option {
+	return func(r *routes) {
+		r.tracing = true
+	}
+}
+
+// Admin protects /v1/admin with basic authentication, without credentials or Tokens the admin routes are not mounted.
+func Admin(credentials map[string]string) Option {
+	return func(r *routes) {
+		r.admins = credentials
+	}
+}
+
+// Tokens lets the users in with their API tokens wherever the admin credentials are accepted,
+// the admin routes take the tokens of admin users only.
+func Tokens(t TokenAuthenticator) Option {
+	return func(r *routes) {
+		r.tokens = t
+	}
+}
+
+// Private requires the admin credentials or an API token on all of /v1 and /graphql.
+func Private() Option {
+	return func(r *routes) {
+		r.private = true
+	}
+}
@@ -16,26 +16,70 @@ import (
 type BookmarkHandler interface {
 	Append(w http.ResponseWriter, r *http.Request)
 	View(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Metadata(w http.ResponseWriter, r *http.Request)
+	Health(w http.ResponseWriter, r *http.Request)
+	RequestArchive(w http.ResponseWriter, r *http.Request)
+	Archive(w http.ResponseWriter, r *http.Request)
 	Change(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
+	Changes(w http.ResponseWriter, r *http.Request)
+	Push(w http.ResponseWriter, r *http.Request)
 }
 
 func Register(
 	log *slog.Logger,
 	bookmarkHnd BookmarkHandler,
+	options ...Option,
 ) func(*http.Server) {
+	var opts routes
+	for _, opt := range options {
+		opt(&opts)
+	}
+
 	return func(s *http.Server) {
 		router := chi.NewRouter()
 
 		router.Use(middleware.RequestID)
+		if opts.tracing {
+			router.Use(customMiddleware.Tracing)
+		}
+		router.Use(customMiddleware.ClientIdentity)
 		router.Use(middleware.Logger)
 		router.Use(customMiddleware.Logger(log))
+		if opts.metrics != nil {
+			router.Use(customMiddleware.Metrics(opts.metrics))
+		}
 		router.Use(middleware.Recoverer)
 		router.Use(middleware.URLFormat)
 
 		router.Get("/health", healthHandler)
+		router.Get("/health/live", healthHandler)
+		if opts.probeHnd != nil {
+			router.Get("/health/ready", opts.probeHnd.Ready)
+		} else {
+			router.Get("/health/ready", healthHandler)
+		}
+
+		if opts.metrics != nil {
+			router.Method(http.MethodGet, "/metrics", opts.metrics.Handler())
+		}
+
+		auth := authenticate(opts.admins, opts.tokens)
+
+		if opts.graphqlHnd != nil {
+			if opts.private {
+				router.With(auth).Handle("/graphql", opts.graphqlHnd)
+			} else {
+				router.Handle("/graphql", opts.graphqlHnd)
+			}
+		}
 
 		router.Route("/v1", func(r chi.Router) {
+			if opts.private {
+				r.Use(auth)
+			}
+
 			r.Route("/bookmark", func(r chi.Router) {
 				r.Post("/append", bookmarkHnd.Append)
 
@@ -45,17 +89,68 @@ func Register(
 					r.Get("/", bookmarkHnd.View)
 					r.Post("/", bookmarkHnd.Change)
 					r.Delete("/", bookmarkHnd.Delete)
+					r.Get("/metadata", bookmarkHnd.Metadata)
+					r.Get("/health", bookmarkHnd.Health)
+					r.Get("/archive", bookmarkHnd.Archive)
+					r.Post("/archive", bookmarkHnd.RequestArchive)
 				})
 			})
 
-			r.Get("/bookmarks", func(w http.ResponseWriter, r *http.Request) {
-				data := map[string]string{"status": "bookmarks list"}
-				render.Status(r, http.StatusOK)
-				render.JSON(w, r, data)
-			})
+			r.Get("/bookmarks", bookmarkHnd.List)
+			r.Get("/sync", bookmarkHnd.Changes)
+			r.Post("/sync", bookmarkHnd.Push)
+
+			if opts.eventHnd != nil {
+				r.Get("/events", opts.eventHnd.Stream)
+			}
+
+			if (len(opts.admins) > 0 || opts.tokens != nil) && (opts.jobHnd != nil || opts.webhookHnd != nil || opts.backupHnd != nil) {
+				r.Route("/admin", func(r chi.Router) {
+					if !opts.private {
+						r.Use(auth)
+					}
+					r.Use(requireAdmin)
+
+					if opts.jobHnd != nil {
+						r.Get("/jobs", opts.jobHnd.List)
+						r.Route("/jobs/{id}", func(r chi.Router) {
+							r.Use(jobIDCtx)
+
+							r.Get("/", opts.jobHnd.View)
+							r.Post("/retry", opts.jobHnd.Retry)
+						})
+					}
+
+					if opts.webhookHnd != nil {
+						r.Post("/webhooks", opts.webhookHnd.Create)
+						r.Get("/webhooks", opts.webhookHnd.List)
+						r.Route("/webhooks/{id}", func(r chi.Router) {
+							r.Use(webhookIDCtx)
+
+							r.Get("/", opts.webhookHnd.View)
+							r.Put("/", opts.webhookHnd.Update)
+							r.Delete("/", opts.webhookHnd.Delete)
+							r.Get("/deliveries", opts.webhookHnd.Deliveries)
+						})
+					}
+
+					if opts.backupHnd != nil {
+						r.Post("/backups", opts.backupHnd.Create)
+						r.Get("/backups", opts.backupHnd.List)
+					}
+				})
+			}
 		})
 
 		s.Handler = router
+
+		if opts.eventHnd != nil {
+			s.RegisterOnShutdown(opts.eventHnd.Close)
+		}
+
+		if opts.probeHnd != nil {
+			s.RegisterOnShutdown(opts.probeHnd.Close)
+		}
 	}
 }
 
@@ -67,6 +162,22 @@ func uuidCtx(next http.Handler) http.Handler {
 	})
 }
 
+func jobIDCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		ctx := context.WithValue(r.Context(), config.FieldJobID, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func webhookIDCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		ctx := context.WithValue(r.Context(), config.FieldWebhookID, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	data := map[string]string{
 		"status": "ok",
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/handler/net"
+	"bookmarks/internal/model"
+)
+
+type BackupService interface {
+	Backup(ctx context.Context) (model.Backup, error)
+	List() ([]model.Backup, error)
+}
+
+type backupHandler struct {
+	service BackupService
+}
+
+func NewBackupHandler(s BackupService) *backupHandler {
+	return &backupHandler{
+		service: s,
+	}
+}
+
+func (h *backupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	backup, err := h.service.Backup(r.Context())
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, backup)
+}
+
+func (h *backupHandler) List(w http.ResponseWriter, r *http.Request) {
+	backups, err := h.service.List()
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.BackupListResponse{Items: backups})
+}
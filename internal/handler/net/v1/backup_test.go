@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/backup"
+	"bookmarks/internal/storage/sqlite"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func TestBackups(t *testing.T) {
+	hdl := makeBackupHandler(t)
+
+	rr := httptest.NewRecorder()
+	hdl.Create(rr, httptest.NewRequest(http.MethodPost, "/v1/admin/backups", nil))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var created model.Backup
+	require.NoError(t, render.DecodeJSON(rr.Body, &created))
+	require.Equal(t, sqlite.SchemaVersion(), created.SchemaVersion)
+	require.NotEmpty(t, created.SHA256)
+
+	rr = httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/admin/backups", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var list handler.BackupListResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &list))
+	require.Len(t, list.Items, 1)
+	require.Equal(t, created.Name, list.Items[0].Name)
+}
+
+func makeBackupHandler(t *testing.T) *backupHandler {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	driver, err := pkgsql.New(pkgsql.SourceName(filepath.Join(dir, "main.db")))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = driver.DB.Close() })
+
+	_, err = sqlite.NewBookmark(driver)
+	require.NoError(t, err)
+
+	logger := slog.New(slog.DiscardHandler)
+
+	return NewBackupHandler(backup.New(logger, driver, backup.Dir(filepath.Join(dir, "backups"))))
+}
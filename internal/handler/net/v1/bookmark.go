@@ -7,13 +7,15 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 
 	"bookmarks/internal/config"
+	"bookmarks/internal/handler"
 	"bookmarks/internal/handler/net"
+	"bookmarks/internal/logger"
 	"bookmarks/internal/model"
 	"bookmarks/internal/service/bookmark"
 )
@@ -21,63 +23,70 @@ import (
 var (
 	ErrRequestBodyIsEmpty = errors.New("request body is empty")
 	ErrUUIDIsEmpty        = errors.New("uuid is empty")
+	ErrInvalidQuery       = errors.New("invalid query parameter")
 )
 
 type Service interface {
-	Append(title, val string) (model.Bookmark, error)
-	View(uuid string) (model.Bookmark, error)
-	Change()
-	Delete(uuid string) error
+	Append(ctx context.Context, title, val string, kind model.Kind, mode bookmark.ConflictMode) (model.Bookmark, bool, error)
+	View(ctx context.Context, uuid string) (model.Bookmark, error)
+	List(ctx context.Context, filter bookmark.ListFilter) ([]model.Bookmark, error)
+	Metadata(ctx context.Context, uuid string) (model.Metadata, error)
+	Health(ctx context.Context, uuid string) (model.LinkHealth, []model.LinkCheck, error)
+	RequestArchive(ctx context.Context, uuid string) error
+	Archive(ctx context.Context, uuid string) (model.Archive, io.ReadCloser, error)
+	Change(ctx context.Context, uuid, title, val string, kind model.Kind, version int64) (model.Bookmark, error)
+	Delete(ctx context.Context, uuid string) error
+	Changes(ctx context.Context, token string, limit int) (model.ChangeSet, string, error)
+	Push(ctx context.Context, mutations []bookmark.Mutation) ([]bookmark.PushResult, error)
 }
 
 type bookmarkHandler struct {
 	service   Service
 	validator *validator.Validate
-	logger    *slog.Logger
 }
 
-func NewHandler(l *slog.Logger, s Service) *bookmarkHandler {
+func NewHandler(s Service) *bookmarkHandler {
 	return &bookmarkHandler{
 		service:   s,
 		validator: validator.New(validator.WithRequiredStructEnabled()),
-		logger:    l,
 	}
 }
 
 func (h *bookmarkHandler) Append(w http.ResponseWriter, r *http.Request) {
 	var input CreateBookmarkRequest
 
-	log := h.logger.With(
-		slog.String("op", "handler.v1.bookmark.Append"),
-		slog.String("request_id", middleware.GetReqID(r.Context())),
-	)
-
 	err := render.DecodeJSON(r.Body, &input)
 	if errors.Is(err, io.EOF) {
-		log.Error(ErrRequestBodyIsEmpty.Error())
 		net.ErrorResponse(w, r, ErrRequestBodyIsEmpty.Error(), http.StatusBadRequest)
 		return
 	}
 
 	if err != nil {
-		log.Error(err.Error())
 		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	if err := h.validator.Struct(input); err != nil {
-		log.Error(err.Error())
 		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	entity, err := h.service.Append(input.Title, input.Value)
-	if err != nil {
-		log.Error(err.Error())
+	mode := bookmark.ConflictMode(r.URL.Query().Get("on_conflict"))
 
+	entity, created, err := h.service.Append(r.Context(), input.Title, input.Value, model.Kind(input.Kind), mode)
+	if err != nil {
 		if errors.Is(err, bookmark.ErrBookmarkExists) {
-			error := fmt.Sprintf("[%s] %s", entity.Uuid, bookmark.ErrBookmarkExists)
-			net.ErrorResponse(w, r, error, http.StatusConflict)
+			net.ConflictResponse(w, r, bookmark.ErrBookmarkExists.Error(), entity)
+			return
+		}
+
+		if errors.Is(err, bookmark.ErrInvalidConflictMode) {
+			net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if isInvalidBookmark(err) {
+			net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
 			return
 		}
 
@@ -85,28 +94,27 @@ func (h *bookmarkHandler) Append(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !created {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, handler.NewBookmark(entity))
+		return
+	}
+
 	render.Status(r, http.StatusCreated)
-	render.JSON(w, r, entity)
+	render.JSON(w, r, handler.NewBookmark(entity))
 }
 
 func (h *bookmarkHandler) View(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	log := h.logger.With(
-		slog.String("op", "handler.v1.bookmark.View"),
-		slog.String("request_id", middleware.GetReqID(ctx)),
-	)
 
 	uuid, err := prepareUuid(ctx)
 	if err != nil {
-		log.Error(err.Error())
 		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
-	entity, err := h.service.View(uuid)
+	entity, err := h.service.View(r.Context(), uuid)
 	if err != nil {
-		log.Error(err.Error())
-
 		if errors.Is(err, bookmark.ErrBookmarkNotFound) {
 			net.ErrorResponse(w, r, bookmark.ErrBookmarkNotFound.Error(), http.StatusNotFound)
 			return
@@ -117,29 +125,198 @@ func (h *bookmarkHandler) View(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, entity)
+	render.JSON(w, r, handler.NewBookmark(entity))
 }
 
-func (h *bookmarkHandler) Change(w http.ResponseWriter, r *http.Request) {
+func (h *bookmarkHandler) Metadata(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	uuid, err := prepareUuid(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	metadata, err := h.service.Metadata(r.Context(), uuid)
+	if err != nil {
+		if errors.Is(err, bookmark.ErrBookmarkNotFound) || errors.Is(err, bookmark.ErrMetadataNotFound) {
+			net.ErrorResponse(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, metadata)
 }
 
-func (h *bookmarkHandler) Delete(w http.ResponseWriter, r *http.Request) {
+func (h *bookmarkHandler) Health(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	log := h.logger.With(
-		slog.String("op", "handler.v1.bookmark.Delete"),
-		slog.String("request_id", middleware.GetReqID(ctx)),
-	)
 
 	uuid, err := prepareUuid(ctx)
 	if err != nil {
-		log.Error(err.Error())
 		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
-	if err := h.service.Delete(uuid); err != nil {
+	health, history, err := h.service.Health(r.Context(), uuid)
+	if err != nil {
+		if errors.Is(err, bookmark.ErrBookmarkNotFound) || errors.Is(err, bookmark.ErrHealthNotFound) {
+			net.ErrorResponse(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewHealth(health, history))
+}
+
+func (h *bookmarkHandler) RequestArchive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	uuid, err := prepareUuid(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.service.RequestArchive(r.Context(), uuid); err != nil {
+		net.ErrorResponse(w, r, err.Error(), archiveStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *bookmarkHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx).With(slog.String("op", "handler.v1.bookmark.Archive"))
+
+	uuid, err := prepareUuid(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	archive, content, err := h.service.Archive(r.Context(), uuid)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), archiveStatus(err))
+		return
+	}
+
+	defer content.Close() //nolint:errcheck
+
+	headers := handler.ArchiveHeaders(archive)
+	if r.Header.Get("If-None-Match") == headers["ETag"] {
+		w.Header().Set("ETag", headers["ETag"])
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	for key, val := range headers {
+		w.Header().Set(key, val)
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
 		log.Error(err.Error())
+	}
+}
+
+func (h *bookmarkHandler) List(w http.ResponseWriter, r *http.Request) {
+	input, err := parseListRequest(r)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := bookmark.ListFilter{
+		Kind:   model.Kind(input.Kind),
+		Health: model.HealthStatus(input.Health),
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	}.Normalize()
+
+	entities, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewList(entities, filter.Limit, filter.Offset))
+}
+
+func (h *bookmarkHandler) Change(w http.ResponseWriter, r *http.Request) {
+	var input ChangeBookmarkRequest
+
+	ctx := r.Context()
+
+	uuid, err := prepareUuid(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = render.DecodeJSON(r.Body, &input)
+	if errors.Is(err, io.EOF) {
+		net.ErrorResponse(w, r, ErrRequestBodyIsEmpty.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entity, err := h.service.Change(r.Context(), uuid, input.Title, input.Value, model.Kind(input.Kind), input.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, bookmark.ErrBookmarkChanged):
+			net.ConflictResponse(w, r, bookmark.ErrBookmarkChanged.Error(), entity)
+		case errors.Is(err, bookmark.ErrBookmarkExists):
+			net.ConflictResponse(w, r, bookmark.ErrBookmarkExists.Error(), entity)
+		case errors.Is(err, bookmark.ErrBookmarkNotFound), errors.Is(err, bookmark.ErrBookmarkDeleted):
+			net.ErrorResponse(w, r, bookmark.ErrBookmarkNotFound.Error(), http.StatusNotFound)
+		case isInvalidBookmark(err):
+			net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewBookmark(entity))
+}
+
+func (h *bookmarkHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	uuid, err := prepareUuid(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
 
+	if err := h.service.Delete(r.Context(), uuid); err != nil {
 		if errors.Is(err, bookmark.ErrBookmarkNotFound) {
 			net.ErrorResponse(w, r, err.Error(), http.StatusNotFound)
 			return
@@ -161,3 +338,45 @@ func prepareUuid(ctx context.Context) (string, error) {
 
 	return uuid, nil
 }
+
+func parseListRequest(r *http.Request) (ListBookmarksRequest, error) {
+	query := r.URL.Query()
+	input := ListBookmarksRequest{
+		Kind:   query.Get("kind"),
+		Health: query.Get("health"),
+	}
+
+	for name, dst := range map[string]*int{"limit": &input.Limit, "offset": &input.Offset} {
+		if raw := query.Get(name); raw != "" {
+			val, err := strconv.Atoi(raw)
+			if err != nil {
+				return ListBookmarksRequest{}, fmt.Errorf("%w: %s", ErrInvalidQuery, name)
+			}
+
+			*dst = val
+		}
+	}
+
+	return input, nil
+}
+
+func archiveStatus(err error) int {
+	switch {
+	case errors.Is(err, bookmark.ErrBookmarkNotFound), errors.Is(err, bookmark.ErrArchiveNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, bookmark.ErrNotArchivable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, bookmark.ErrArchiveDisabled):
+		return http.StatusNotImplemented
+	case errors.Is(err, bookmark.ErrArchiveUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func isInvalidBookmark(err error) bool {
+	return errors.Is(err, model.ErrInvalidTitle) ||
+		errors.Is(err, model.ErrInvalidValue) ||
+		errors.Is(err, model.ErrInvalidKind)
+}
@@ -1,6 +1,23 @@
 package v1
 
+// CreateBookmarkRequest may omit the title of an URL, it is derived and later replaced by the page title.
 type CreateBookmarkRequest struct {
-	Title string `json:"title" validate:"required"`
+	Title string `json:"title"`
 	Value string `json:"value" validate:"required"`
+	Kind  string `json:"kind" validate:"omitempty,oneof=url text code contact"`
+}
+
+// ChangeBookmarkRequest carries the version the client last saw, a bookmark changed since is not overwritten.
+type ChangeBookmarkRequest struct {
+	Title   string `json:"title"`
+	Value   string `json:"value" validate:"required"`
+	Kind    string `json:"kind" validate:"omitempty,oneof=url text code contact"`
+	Version int64  `json:"version" validate:"gt=0"`
+}
+
+type ListBookmarksRequest struct {
+	Kind   string `validate:"omitempty,oneof=url text code contact"`
+	Health string `validate:"omitempty,oneof=ok failing broken"`
+	Limit  int    `validate:"gte=0"`
+	Offset int    `validate:"gte=0"`
 }
@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -8,13 +10,17 @@ import (
 	"testing"
 
 	"github.com/go-chi/render"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"bookmarks/internal/config"
 	"bookmarks/internal/handler"
 	"bookmarks/internal/model"
 	repo "bookmarks/internal/repository/bookmark"
+	"bookmarks/internal/service/archive"
 	srv "bookmarks/internal/service/bookmark"
 	"bookmarks/internal/storage/memory"
+	"bookmarks/pkg/blob/fsstore"
 )
 
 func TestAppend_Success(t *testing.T) {
@@ -59,11 +65,234 @@ func TestAppend_BodyBadRequest(t *testing.T) {
 	require.Contains(t, response.Error, "Field validation for 'Value' failed")
 }
 
+func TestAppend_Conflict(t *testing.T) {
+	hdl := makeHandler()
+
+	first := httptest.NewRecorder()
+	hdl.Append(first, makeAppendRequest("/v1/bookmark/append", `{"title": "first", "value": "value"}`))
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	var created model.Bookmark
+	require.NoError(t, render.DecodeJSON(first.Body, &created))
+
+	rr := httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append", `{"title": "second", "value": "value"}`))
+	require.Equal(t, http.StatusConflict, rr.Code)
+
+	var response handler.ConflictResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &response))
+	require.Equal(t, "bookmark already exists", response.Error)
+	require.Equal(t, created.Uuid, response.Existing.Uuid)
+	require.Equal(t, "first", response.Existing.Title)
+}
+
+func TestAppend_ConflictMode(t *testing.T) {
+	hdl := makeHandler()
+
+	rr := httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append", `{"title": "first", "value": "value"}`))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append?on_conflict=update_title", `{"title": "second", "value": "value"}`))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response model.Bookmark
+	require.NoError(t, render.DecodeJSON(rr.Body, &response))
+	require.Equal(t, "second", response.Title)
+
+	rr = httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append?on_conflict=replace", `{"title": "third", "value": "value"}`))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAppend_InvalidKindValue(t *testing.T) {
+	hdl := makeHandler()
+
+	rr := httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append", `{"title": "test", "value": "not an url", "kind": "url"}`))
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	rr = httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append", `{"title": "test", "value": "value", "kind": "image"}`))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestChange(t *testing.T) {
+	hdl := makeHandler()
+
+	rr := httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append", `{"title": "first", "value": "value"}`))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var created model.Bookmark
+	require.NoError(t, render.DecodeJSON(rr.Body, &created))
+
+	body := fmt.Sprintf(`{"title": "renamed", "value": "value", "version": %d}`, created.Version)
+
+	rr = httptest.NewRecorder()
+	hdl.Change(rr, withUUID(makeAppendRequest("/", body), created.Uuid.String()))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var changed model.Bookmark
+	require.NoError(t, render.DecodeJSON(rr.Body, &changed))
+	require.Equal(t, "renamed", changed.Title)
+	require.Greater(t, changed.Version, created.Version)
+
+	// the version read before the change is stale now
+	rr = httptest.NewRecorder()
+	hdl.Change(rr, withUUID(makeAppendRequest("/", body), created.Uuid.String()))
+	require.Equal(t, http.StatusConflict, rr.Code)
+
+	var response handler.ConflictResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &response))
+	require.Equal(t, changed.Version, response.Existing.Version)
+
+	rr = httptest.NewRecorder()
+	hdl.Change(rr, withUUID(makeAppendRequest("/", `{"value": "value"}`), created.Uuid.String()))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	hdl.Change(rr, withUUID(makeAppendRequest("/", body), uuid.NewString()))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestList_FilterKind(t *testing.T) {
+	hdl := makeHandler()
+
+	for _, body := range []string{
+		`{"title": "site", "value": "https://example.com"}`,
+		`{"title": "cmd", "value": "git log --oneline"}`,
+		`{"title": "mail", "value": "user@example.com"}`,
+	} {
+		rr := httptest.NewRecorder()
+		hdl.Append(rr, makeAppendRequest("/v1/bookmark/append", body))
+		require.Equal(t, http.StatusCreated, rr.Code)
+	}
+
+	rr := httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/bookmarks?kind=code", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response handler.ListResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &response))
+	require.Len(t, response.Items, 1)
+	require.Equal(t, model.KindCode, response.Items[0].Kind)
+	require.True(t, response.Items[0].Render.Monospace)
+	require.Equal(t, 20, response.Limit)
+
+	rr = httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/bookmarks?limit=2", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	require.NoError(t, render.DecodeJSON(rr.Body, &response))
+	require.Len(t, response.Items, 2)
+	require.Equal(t, "mailto:user@example.com", response.Items[0].Render.Href)
+	require.True(t, response.Items[1].Render.Monospace)
+
+	rr = httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/bookmarks?kind=url", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	require.NoError(t, render.DecodeJSON(rr.Body, &response))
+	require.Len(t, response.Items, 1)
+	require.Equal(t, "https://example.com", response.Items[0].Render.Href)
+
+	rr = httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/bookmarks?limit=x", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHealth_NotChecked(t *testing.T) {
+	hdl := makeHandler()
+
+	rr := httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append", `{"value": "https://example.com"}`))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var created handler.BookmarkResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &created))
+
+	rr = httptest.NewRecorder()
+	hdl.Health(rr, withUUID(httptest.NewRequest(http.MethodGet, "/", nil), created.Uuid.String()))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/bookmarks?health=broken", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response handler.ListResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &response))
+	require.Empty(t, response.Items)
+
+	rr = httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/bookmarks?health=dead", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestArchive_Serve(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><script>alert(1)</script></head><body>archived</body></html>`))
+	}))
+	defer site.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	store, err := fsstore.New(fsstore.Dir(t.TempDir()))
+	require.NoError(t, err)
+
+	worker := archive.New(slog.New(slog.DiscardHandler), repository, store, archive.AllowPrivateNetworks())
+	hdl := NewHandler(srv.NewService(repository, srv.Archiving(worker, false)))
+
+	rr := httptest.NewRecorder()
+	hdl.Append(rr, makeAppendRequest("/v1/bookmark/append", `{"value": "`+site.URL+`/page"}`))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var created handler.BookmarkResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &created))
+
+	rr = httptest.NewRecorder()
+	hdl.Archive(rr, withUUID(httptest.NewRequest(http.MethodGet, "/", nil), created.Uuid.String()))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	_, err = worker.Archive(context.Background(), created.Bookmark)
+	require.NoError(t, err)
+
+	rr = httptest.NewRecorder()
+	hdl.Archive(rr, withUUID(httptest.NewRequest(http.MethodGet, "/", nil), created.Uuid.String()))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	require.Contains(t, rr.Header().Get("Content-Security-Policy"), "sandbox")
+	require.Contains(t, rr.Body.String(), "archived")
+	require.NotContains(t, rr.Body.String(), "alert")
+
+	req := withUUID(httptest.NewRequest(http.MethodGet, "/", nil), created.Uuid.String())
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+
+	rr = httptest.NewRecorder()
+	hdl.Archive(rr, req)
+	require.Equal(t, http.StatusNotModified, rr.Code)
+
+	rr = httptest.NewRecorder()
+	makeHandler().RequestArchive(rr, withUUID(httptest.NewRequest(http.MethodPost, "/", nil), created.Uuid.String()))
+	require.Equal(t, http.StatusNotImplemented, rr.Code)
+}
+
+func withUUID(r *http.Request, uuid string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), config.FieldUUID, uuid))
+}
+
+func makeAppendRequest(target, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
 func makeHandler() *bookmarkHandler {
 	storage := memory.NewBookmarkStorage()
 	repository := repo.NewRepository(storage)
 	service := srv.NewService(repository)
-	logger := slog.New(slog.DiscardHandler)
 
-	return NewHandler(logger, service)
+	return NewHandler(service)
 }
@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/handler/net"
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/stream"
+)
+
+type EventBroker interface {
+	Subscribe(lastID int64, types []model.EventType) (*stream.Subscription, error)
+	Heartbeat() time.Duration
+	Close()
+}
+
+type eventHandler struct {
+	broker EventBroker
+}
+
+func NewEventHandler(b EventBroker) *eventHandler {
+	return &eventHandler{
+		broker: b,
+	}
+}
+
+// Stream sends the bookmark changes the caller may read as server-sent events.
+func (h *eventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context()).With(slog.String("op", "handler.v1.event.Stream"))
+
+	lastID, err := handler.ParseLastEventID(r.Header.Get("Last-Event-ID"), r.URL.Query().Get("last_event_id"))
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	types, err := handler.ParseEventTypes(r.URL.Query().Get("types"))
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.broker.Subscribe(lastID, types)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+
+	// the write timeout of the server would cut the stream
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Error(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	principal, _ := handler.PrincipalFromContext(r.Context())
+
+	if err := handler.Stream(r.Context(), w, rc.Flush, sub, h.broker.Heartbeat(), principal); err != nil {
+		log.Debug(err.Error())
+	}
+}
+
+// Close ends the open streams, http.Server.Shutdown waits for them otherwise.
+func (h *eventHandler) Close() {
+	h.broker.Close()
+}
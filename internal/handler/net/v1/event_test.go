@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	stdnet "net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/handler/net"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/stream"
+)
+
+func TestEvents_Stream(t *testing.T) {
+	broker := stream.New(slog.New(slog.DiscardHandler), stream.Heartbeat(time.Hour))
+
+	bookmark, err := model.NewBookmark("Go", "https://go.dev")
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Publish(context.Background(), model.Event{ID: 1, Type: model.BookmarkAppended, Bookmark: bookmark}))
+	first, err := broker.Subscribe(0, nil)
+	require.NoError(t, err)
+	require.NoError(t, broker.Publish(context.Background(), model.Event{ID: 2, Type: model.BookmarkChanged, Bookmark: bookmark}))
+	resumeAfter := (<-first.Events()).ID - 1
+	first.Close()
+
+	server := &http.Server{ReadHeaderTimeout: time.Second, WriteTimeout: 50 * time.Millisecond}
+	net.Register(slog.New(slog.DiscardHandler), makeHandler(), net.Events(NewEventHandler(broker)))(server)
+
+	listener, err := stdnet.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go server.Serve(listener) //nolint:errcheck
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+listener.Addr().String()+"/v1/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	// older than the history
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	require.Equal(t, handler.EventReset, readEvent(t, reader)["event"])
+	_ = resp.Body.Close()
+
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(resumeAfter, 10))
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	reader = bufio.NewReader(resp.Body)
+
+	frame := readEvent(t, reader)
+	require.Equal(t, string(model.BookmarkChanged), frame["event"])
+
+	var event handler.EventResponse
+	require.NoError(t, json.Unmarshal([]byte(frame["data"]), &event))
+	require.Equal(t, bookmark.Uuid, event.Bookmark.Uuid)
+
+	// live after the write timeout of the server
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, broker.Publish(context.Background(), model.Event{ID: 3, Type: model.BookmarkDeleted, Bookmark: bookmark}))
+	require.Equal(t, string(model.BookmarkDeleted), readEvent(t, reader)["event"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	require.NoError(t, server.Shutdown(ctx))
+
+	_, err = reader.ReadString('\n')
+	require.Error(t, err)
+}
+
+func TestEvents_Invalid(t *testing.T) {
+	hdl := NewEventHandler(stream.New(slog.New(slog.DiscardHandler)))
+
+	rr := httptest.NewRecorder()
+	hdl.Stream(rr, httptest.NewRequest(http.MethodGet, "/v1/events?types=bookmark.viewed", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+
+	rr = httptest.NewRecorder()
+	hdl.Stream(rr, req)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	hdl.Close()
+
+	rr = httptest.NewRecorder()
+	hdl.Stream(rr, httptest.NewRequest(http.MethodGet, "/v1/events", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestEvents_Expired(t *testing.T) {
+	broker := stream.New(slog.New(slog.DiscardHandler), stream.Heartbeat(10*time.Millisecond))
+	hdl := NewEventHandler(broker)
+
+	bookmark, err := model.NewBookmark("Go", "https://go.dev")
+	require.NoError(t, err)
+
+	require.NoError(t, broker.Publish(context.Background(), model.Event{ID: 1, Type: model.BookmarkAppended, Bookmark: bookmark}))
+
+	open := func(principal model.Principal) string {
+		req := httptest.NewRequest(http.MethodGet, "/v1/events?last_event_id=1", nil)
+		req = req.WithContext(handler.NewPrincipalContext(req.Context(), principal))
+
+		rr := httptest.NewRecorder()
+		hdl.Stream(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		return rr.Body.String()
+	}
+
+	// the replay is not sent to a principal that can no longer read it
+	require.NotContains(t, open(model.Principal{Name: "alice", ExpiresAt: time.Now().Add(-time.Second)}), "event:")
+
+	// a heartbeat after the expiry ends the stream
+	body := open(model.Principal{Name: "alice", ExpiresAt: time.Now().Add(50 * time.Millisecond)})
+	require.Contains(t, body, ": connected")
+}
+
+// readEvent reads the fields of the next event, comments are skipped.
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	t.Helper()
+
+	fields := make(map[string]string)
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"bookmarks/internal/config"
+	"bookmarks/internal/handler"
+	"bookmarks/internal/handler/net"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/jobs"
+)
+
+var ErrJobIDIsEmpty = errors.New("job id is empty")
+
+type JobService interface {
+	List(filter jobs.ListFilter) ([]model.Job, error)
+	Job(id int64) (model.Job, error)
+	Retry(id int64) (model.Job, error)
+}
+
+type jobHandler struct {
+	service   JobService
+	validator *validator.Validate
+}
+
+func NewJobHandler(s JobService) *jobHandler {
+	return &jobHandler{
+		service:   s,
+		validator: validator.New(validator.WithRequiredStructEnabled()),
+	}
+}
+
+func (h *jobHandler) List(w http.ResponseWriter, r *http.Request) {
+	input, err := parseListJobsRequest(r)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := jobs.ListFilter{
+		Status: model.JobStatus(input.Status),
+		Kind:   input.Kind,
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	}.Normalize()
+
+	entities, err := h.service.List(filter)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewJobList(entities, filter.Limit, filter.Offset))
+}
+
+func (h *jobHandler) View(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := prepareJobID(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	job, err := h.service.Job(id)
+	if err != nil {
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			net.ErrorResponse(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, job)
+}
+
+func (h *jobHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := prepareJobID(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	job, err := h.service.Retry(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrJobNotFound):
+			net.ErrorResponse(w, r, err.Error(), http.StatusNotFound)
+		case errors.Is(err, jobs.ErrJobNotFailed):
+			net.ErrorResponse(w, r, err.Error(), http.StatusConflict)
+		default:
+			net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, job)
+}
+
+func prepareJobID(ctx context.Context) (int64, error) {
+	raw, ok := ctx.Value(config.FieldJobID).(string)
+	if !ok {
+		return 0, ErrJobIDIsEmpty
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: id", ErrInvalidQuery)
+	}
+
+	return id, nil
+}
+
+func parseListJobsRequest(r *http.Request) (ListJobsRequest, error) {
+	query := r.URL.Query()
+	input := ListJobsRequest{
+		Status: query.Get("status"),
+		Kind:   query.Get("kind"),
+	}
+
+	for name, dst := range map[string]*int{"limit": &input.Limit, "offset": &input.Offset} {
+		if raw := query.Get(name); raw != "" {
+			val, err := strconv.Atoi(raw)
+			if err != nil {
+				return ListJobsRequest{}, fmt.Errorf("%w: %s", ErrInvalidQuery, name)
+			}
+
+			*dst = val
+		}
+	}
+
+	return input, nil
+}
@@ -0,0 +1,8 @@
+package v1
+
+type ListJobsRequest struct {
+	Status string `validate:"omitempty,oneof=queued running succeeded failed"`
+	Kind   string
+	Limit  int `validate:"gte=0"`
+	Offset int `validate:"gte=0"`
+}
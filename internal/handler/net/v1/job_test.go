@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/config"
+	"bookmarks/internal/handler"
+	"bookmarks/internal/handler/net"
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	accountRepo "bookmarks/internal/repository/account"
+	jobRepo "bookmarks/internal/repository/job"
+	"bookmarks/internal/service/account"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/internal/storage/memory"
+)
+
+func TestJobs_Retry(t *testing.T) {
+	hdl, failed := makeJobHandler(t)
+
+	rr := httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/admin/jobs?status=failed", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var list handler.JobListResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &list))
+	require.Len(t, list.Items, 1)
+	require.Equal(t, failed.ID, list.Items[0].ID)
+	require.Equal(t, "smtp unavailable", list.Items[0].LastError)
+
+	rr = httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/admin/jobs?status=lost", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	id := fmt.Sprint(failed.ID)
+
+	rr = httptest.NewRecorder()
+	hdl.Retry(rr, withJobID(httptest.NewRequest(http.MethodPost, "/v1/admin/jobs/"+id+"/retry", nil), id))
+	require.Equal(t, http.StatusAccepted, rr.Code)
+
+	var job model.Job
+	require.NoError(t, render.DecodeJSON(rr.Body, &job))
+	require.Equal(t, model.JobQueued, job.Status)
+
+	rr = httptest.NewRecorder()
+	hdl.Retry(rr, withJobID(httptest.NewRequest(http.MethodPost, "/v1/admin/jobs/"+id+"/retry", nil), id))
+	require.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = httptest.NewRecorder()
+	hdl.View(rr, withJobID(httptest.NewRequest(http.MethodGet, "/v1/admin/jobs/999", nil), "999"))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestJobs_Auth(t *testing.T) {
+	hdl, _ := makeJobHandler(t)
+	logger := slog.New(slog.DiscardHandler)
+
+	var server http.Server
+	net.Register(logger, makeHandler(), net.Jobs(hdl), net.Admin(map[string]string{"admin": "secret"}))(&server)
+
+	rr := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/admin/jobs", nil))
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/jobs", nil)
+	req.SetBasicAuth("admin", "secret")
+
+	rr = httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestJobs_TokenAuth(t *testing.T) {
+	hdl, _ := makeJobHandler(t)
+	accounts, secret, adminSecret := makeAccounts(t)
+	logger := slog.New(slog.DiscardHandler)
+
+	serve := func(t *testing.T, server *http.Server, path, authorization string) int {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		rr := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	var server http.Server
+	net.Register(logger, makeHandler(), net.Jobs(hdl), net.Tokens(accounts))(&server)
+
+	require.Equal(t, http.StatusUnauthorized, serve(t, &server, "/v1/admin/jobs", ""))
+	require.Equal(t, http.StatusUnauthorized, serve(t, &server, "/v1/admin/jobs", "Bearer bmt_unknown"))
+	require.Equal(t, http.StatusForbidden, serve(t, &server, "/v1/admin/jobs", "Bearer "+secret))
+	require.Equal(t, http.StatusOK, serve(t, &server, "/v1/admin/jobs", "Bearer "+adminSecret))
+	require.Equal(t, http.StatusOK, serve(t, &server, "/v1/bookmarks", ""))
+
+	var private http.Server
+	net.Register(logger, makeHandler(), net.Jobs(hdl), net.Admin(map[string]string{"admin": "secret"}), net.Tokens(accounts), net.Private())(&private)
+
+	require.Equal(t, http.StatusUnauthorized, serve(t, &private, "/v1/bookmarks", ""))
+	require.Equal(t, http.StatusOK, serve(t, &private, "/v1/bookmarks", "Bearer "+secret))
+	require.Equal(t, http.StatusForbidden, serve(t, &private, "/v1/admin/jobs", "Bearer "+secret))
+	require.Equal(t, http.StatusOK, serve(t, &private, "/v1/admin/jobs", "Bearer "+adminSecret))
+	require.Equal(t, http.StatusOK, serve(t, &private, "/v1/admin/jobs", "Basic YWRtaW46c2VjcmV0"))
+	require.Equal(t, http.StatusOK, serve(t, &private, "/health", ""))
+}
+
+func withJobID(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), config.FieldJobID, id))
+}
+
+// makeAccounts returns the accounts of a user and an admin user holding one token each,
+// with the secrets of the tokens.
+func makeAccounts(t *testing.T) (*account.Service, string, string) {
+	t.Helper()
+
+	accounts := account.New(accountRepo.NewRepository(memory.NewAccountStorage()))
+
+	_, err := accounts.CreateUser("alice", false)
+	require.NoError(t, err)
+
+	_, secret, err := accounts.IssueToken("alice", "test", time.Hour)
+	require.NoError(t, err)
+
+	_, err = accounts.CreateUser("root", true)
+	require.NoError(t, err)
+
+	_, adminSecret, err := accounts.IssueToken("root", "test", time.Hour)
+	require.NoError(t, err)
+
+	return accounts, secret, adminSecret
+}
+
+// makeJobHandler returns a handler of a queue holding one failed job.
+func makeJobHandler(t *testing.T) (*jobHandler, model.Job) {
+	t.Helper()
+
+	repository := jobRepo.NewRepository(memory.NewJobStorage())
+	now := time.Now()
+
+	job, err := repository.Enqueue(model.Job{Kind: "mail.send", Payload: []byte(`{}`), MaxAttempts: 1, RunAt: now, CreatedAt: now})
+	require.NoError(t, err)
+
+	_, err = repository.Lease(core.Lease{Owner: "test", Kinds: []string{"mail.send"}, Now: now, Until: now.Add(time.Minute), Limit: 1})
+	require.NoError(t, err)
+	require.NoError(t, repository.Fail(job.ID, "test", "smtp unavailable", now, time.Time{}))
+
+	queue := jobs.New(slog.New(slog.DiscardHandler), repository)
+
+	return NewJobHandler(queue), job
+}
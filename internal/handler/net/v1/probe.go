@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"bookmarks/internal/service/probe"
+)
+
+type ProbeService interface {
+	Ready(ctx context.Context) probe.Report
+	Shutdown()
+}
+
+type probeHandler struct {
+	service ProbeService
+}
+
+func NewProbeHandler(s ProbeService) *probeHandler {
+	return &probeHandler{
+		service: s,
+	}
+}
+
+// Ready reports the checks of the dependencies, a failing check fails the readiness.
+func (h *probeHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.service.Ready(r.Context())
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	render.Status(r, status)
+	render.JSON(w, r, report)
+}
+
+// Close fails the readiness, the server is shutting down.
+func (h *probeHandler) Close() {
+	h.service.Shutdown()
+}
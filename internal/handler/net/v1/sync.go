@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+
+	"bookmarks/internal/handler"
+	"bookmarks/internal/handler/net"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/bookmark"
+)
+
+// Changes returns the bookmark writes after the sync token, a client without a token gets everything.
+func (h *bookmarkHandler) Changes(w http.ResponseWriter, r *http.Request) {
+	input, err := parseSyncRequest(r)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	set, token, err := h.service.Changes(r.Context(), input.Token, input.Limit)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), syncStatus(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewSync(set, token))
+}
+
+// Push applies client mutations, the outcome of each is reported in the response.
+func (h *bookmarkHandler) Push(w http.ResponseWriter, r *http.Request) {
+	var input PushChangesRequest
+
+	err := render.DecodeJSON(r.Body, &input)
+	if errors.Is(err, io.EOF) {
+		net.ErrorResponse(w, r, ErrRequestBodyIsEmpty.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mutations := make([]bookmark.Mutation, 0, len(input.Mutations))
+	for _, m := range input.Mutations {
+		mutations = append(mutations, bookmark.Mutation{
+			Op:      bookmark.MutationOp(m.Op),
+			Uuid:    m.Uuid,
+			Title:   m.Title,
+			Value:   m.Value,
+			Kind:    model.Kind(m.Kind),
+			Version: m.Version,
+		})
+	}
+
+	results, err := h.service.Push(r.Context(), mutations)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), syncStatus(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewPush(results))
+}
+
+func parseSyncRequest(r *http.Request) (SyncChangesRequest, error) {
+	query := r.URL.Query()
+	input := SyncChangesRequest{Token: query.Get("since")}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return SyncChangesRequest{}, fmt.Errorf("%w: limit", ErrInvalidQuery)
+		}
+
+		input.Limit = limit
+	}
+
+	return input, nil
+}
+
+// syncStatus tells a client with an unknown token to drop its state and sync from scratch.
+func syncStatus(err error) int {
+	switch {
+	case errors.Is(err, bookmark.ErrInvalidSyncToken), errors.Is(err, bookmark.ErrTooManyMutations):
+		return http.StatusBadRequest
+	case errors.Is(err, bookmark.ErrResyncRequired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/handler"
+	srv "bookmarks/internal/service/bookmark"
+)
+
+func TestSync_PushAndChanges(t *testing.T) {
+	hdl := makeHandler()
+	id := uuid.NewString()
+
+	rr := httptest.NewRecorder()
+	hdl.Push(rr, makeAppendRequest("/v1/sync", fmt.Sprintf(`{"mutations": [
+		{"op": "create", "uuid": %q, "title": "offline", "value": "https://example.com/offline"},
+		{"op": "create", "title": "second", "value": "https://example.com/second"}
+	]}`, id)))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var pushed handler.PushResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &pushed))
+	require.Len(t, pushed.Results, 2)
+	require.Equal(t, string(srv.PushApplied), pushed.Results[0].Status)
+	require.Equal(t, id, pushed.Results[0].Uuid)
+	version := pushed.Results[0].Bookmark.Version
+
+	rr = httptest.NewRecorder()
+	hdl.Changes(rr, httptest.NewRequest(http.MethodGet, "/v1/sync", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var changes handler.SyncResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &changes))
+	require.Len(t, changes.Changes, 2)
+	require.Equal(t, handler.ChangeUpsert, changes.Changes[0].Op)
+	require.Equal(t, id, changes.Changes[0].Bookmark.Uuid.String())
+	require.False(t, changes.More)
+	token := changes.Token
+
+	rr = httptest.NewRecorder()
+	hdl.Push(rr, makeAppendRequest("/v1/sync", fmt.Sprintf(`{"mutations": [
+		{"op": "delete", "uuid": %q, "version": %d},
+		{"op": "update", "uuid": %q, "version": %d, "title": "stale", "value": "https://example.com/offline"}
+	]}`, id, version, id, version)))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	pushed = handler.PushResponse{}
+	require.NoError(t, render.DecodeJSON(rr.Body, &pushed))
+	require.Equal(t, string(srv.PushApplied), pushed.Results[0].Status)
+	require.True(t, pushed.Results[0].Deleted)
+	require.Equal(t, string(srv.PushConflict), pushed.Results[1].Status)
+	require.Equal(t, srv.ErrBookmarkDeleted.Error(), pushed.Results[1].Error)
+
+	rr = httptest.NewRecorder()
+	hdl.Changes(rr, httptest.NewRequest(http.MethodGet, "/v1/sync?since="+token, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	changes = handler.SyncResponse{}
+	require.NoError(t, render.DecodeJSON(rr.Body, &changes))
+	require.Len(t, changes.Changes, 1)
+	require.Equal(t, handler.ChangeDelete, changes.Changes[0].Op)
+	require.Equal(t, id, changes.Changes[0].Uuid.String())
+	require.NotNil(t, changes.Changes[0].DeletedAt)
+	require.NotEqual(t, token, changes.Token)
+}
+
+func TestSync_Errors(t *testing.T) {
+	hdl := makeHandler()
+
+	for target, code := range map[string]int{
+		"/v1/sync?since=garbage":                       http.StatusBadRequest,
+		"/v1/sync?limit=x":                             http.StatusBadRequest,
+		"/v1/sync?limit=-1":                            http.StatusBadRequest,
+		"/v1/sync?since=" + srv.EncodeSyncToken(1<<20): http.StatusGone,
+	} {
+		rr := httptest.NewRecorder()
+		hdl.Changes(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, code, rr.Code, target)
+	}
+
+	for _, body := range []string{``, `{}`, `{"mutations": [{"op": "rename"}]}`, `{"mutations": [{"op": "delete", "uuid": "1"}]}`} {
+		rr := httptest.NewRecorder()
+		hdl.Push(rr, makeAppendRequest("/v1/sync", body))
+		require.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+}
@@ -0,0 +1,250 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"bookmarks/internal/config"
+	"bookmarks/internal/handler"
+	"bookmarks/internal/handler/net"
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/webhook"
+)
+
+var ErrWebhookIDIsEmpty = errors.New("webhook id is empty")
+
+type WebhookService interface {
+	Create(sub webhook.Subscription) (model.Webhook, error)
+	Update(id string, sub webhook.Subscription) (model.Webhook, error)
+	Webhook(id string) (model.Webhook, error)
+	List() ([]model.Webhook, error)
+	Delete(id string) error
+	Deliveries(id string, filter webhook.ListFilter) ([]model.WebhookDelivery, error)
+}
+
+type webhookHandler struct {
+	service   WebhookService
+	validator *validator.Validate
+}
+
+func NewWebhookHandler(s WebhookService) *webhookHandler {
+	return &webhookHandler{
+		service:   s,
+		validator: validator.New(validator.WithRequiredStructEnabled()),
+	}
+}
+
+func (h *webhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.decodeSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	entity, err := h.service.Create(sub)
+	if err != nil {
+		if isInvalidWebhook(err) {
+			net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, handler.NewWebhook(entity, true))
+}
+
+func (h *webhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	entities, err := h.service.List()
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewWebhookList(entities))
+}
+
+func (h *webhookHandler) View(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := prepareWebhookID(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	entity, err := h.service.Webhook(id)
+	if err != nil {
+		webhookErrorResponse(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewWebhook(entity, false))
+}
+
+func (h *webhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := prepareWebhookID(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	sub, ok := h.decodeSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	entity, err := h.service.Update(id, sub)
+	if err != nil {
+		webhookErrorResponse(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewWebhook(entity, false))
+}
+
+func (h *webhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := prepareWebhookID(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		webhookErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *webhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := prepareWebhookID(ctx)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	input, err := parseListDeliveriesRequest(r)
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := webhook.ListFilter{Limit: input.Limit, Offset: input.Offset}.Normalize()
+
+	deliveries, err := h.service.Deliveries(id, filter)
+	if err != nil {
+		webhookErrorResponse(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, handler.NewDeliveryList(deliveries, filter.Limit, filter.Offset))
+}
+
+// decodeSubscription reads and validates the request body, an error response is written when it fails.
+func (h *webhookHandler) decodeSubscription(w http.ResponseWriter, r *http.Request) (webhook.Subscription, bool) {
+	var input WebhookRequest
+
+	err := render.DecodeJSON(r.Body, &input)
+	if errors.Is(err, io.EOF) {
+		net.ErrorResponse(w, r, ErrRequestBodyIsEmpty.Error(), http.StatusBadRequest)
+		return webhook.Subscription{}, false
+	}
+
+	if err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return webhook.Subscription{}, false
+	}
+
+	if err := h.validator.Struct(input); err != nil {
+		net.ErrorResponse(w, r, err.Error(), http.StatusBadRequest)
+		return webhook.Subscription{}, false
+	}
+
+	return input.subscription(), true
+}
+
+func (input WebhookRequest) subscription() webhook.Subscription {
+	events := make([]model.EventType, 0, len(input.Events))
+	for _, event := range input.Events {
+		events = append(events, model.EventType(event))
+	}
+
+	return webhook.Subscription{
+		URL:    input.URL,
+		Events: events,
+		Secret: input.Secret,
+		Active: input.Active,
+	}
+}
+
+func webhookErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrWebhookNotFound):
+		net.ErrorResponse(w, r, webhook.ErrWebhookNotFound.Error(), http.StatusNotFound)
+	case isInvalidWebhook(err):
+		net.ErrorResponse(w, r, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		net.ErrorResponse(w, r, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func isInvalidWebhook(err error) bool {
+	return errors.Is(err, model.ErrInvalidWebhookURL) || errors.Is(err, model.ErrInvalidEventType)
+}
+
+func prepareWebhookID(ctx context.Context) (string, error) {
+	id, ok := ctx.Value(config.FieldWebhookID).(string)
+	if !ok {
+		return "", ErrWebhookIDIsEmpty
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return "", fmt.Errorf("%w: id", ErrInvalidQuery)
+	}
+
+	return id, nil
+}
+
+func parseListDeliveriesRequest(r *http.Request) (ListDeliveriesRequest, error) {
+	query := r.URL.Query()
+
+	var input ListDeliveriesRequest
+	for name, dst := range map[string]*int{"limit": &input.Limit, "offset": &input.Offset} {
+		if raw := query.Get(name); raw != "" {
+			val, err := strconv.Atoi(raw)
+			if err != nil {
+				return ListDeliveriesRequest{}, fmt.Errorf("%w: %s", ErrInvalidQuery, name)
+			}
+
+			*dst = val
+		}
+	}
+
+	return input, nil
+}
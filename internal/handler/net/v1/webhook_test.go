@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/config"
+	"bookmarks/internal/handler"
+	"bookmarks/internal/handler/net"
+	"bookmarks/internal/model"
+	jobRepo "bookmarks/internal/repository/job"
+	webhookRepo "bookmarks/internal/repository/webhook"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/internal/service/webhook"
+	"bookmarks/internal/storage/memory"
+)
+
+func TestWebhooks_CRUD(t *testing.T) {
+	hdl := makeWebhookHandler()
+
+	rr := httptest.NewRecorder()
+	hdl.Create(rr, httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks",
+		strings.NewReader(`{"url":"https://example.com/hook","events":["bookmark.deleted"]}`)))
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var created handler.WebhookResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &created))
+	require.NotEmpty(t, created.Secret)
+	require.True(t, created.Active)
+	require.Equal(t, []model.EventType{model.BookmarkDeleted}, created.Events)
+
+	id := created.ID.String()
+
+	rr = httptest.NewRecorder()
+	hdl.List(rr, httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var list handler.WebhookListResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &list))
+	require.Len(t, list.Items, 1)
+	require.Empty(t, list.Items[0].Secret)
+
+	rr = httptest.NewRecorder()
+	hdl.Update(rr, withWebhookID(httptest.NewRequest(http.MethodPut, "/v1/admin/webhooks/"+id,
+		strings.NewReader(`{"url":"https://example.com/other","active":false}`)), id))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var updated handler.WebhookResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &updated))
+	require.Equal(t, "https://example.com/other", updated.URL)
+	require.Empty(t, updated.Events)
+	require.False(t, updated.Active)
+
+	rr = httptest.NewRecorder()
+	hdl.Deliveries(rr, withWebhookID(httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks/"+id+"/deliveries?limit=5", nil), id))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var deliveries handler.DeliveryListResponse
+	require.NoError(t, render.DecodeJSON(rr.Body, &deliveries))
+	require.Empty(t, deliveries.Items)
+	require.Equal(t, 5, deliveries.Limit)
+
+	rr = httptest.NewRecorder()
+	hdl.Delete(rr, withWebhookID(httptest.NewRequest(http.MethodDelete, "/v1/admin/webhooks/"+id, nil), id))
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	hdl.View(rr, withWebhookID(httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks/"+id, nil), id))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestWebhooks_Invalid(t *testing.T) {
+	hdl := makeWebhookHandler()
+
+	for _, body := range []string{
+		``,
+		`{"url":"example.com"}`,
+		`{"url":"https://example.com","events":["bookmark.viewed"]}`,
+		`{"url":"https://example.com","secret":"short"}`,
+	} {
+		rr := httptest.NewRecorder()
+		hdl.Create(rr, httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks", strings.NewReader(body)))
+		require.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+
+	rr := httptest.NewRecorder()
+	hdl.View(rr, withWebhookID(httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks/1", nil), "1"))
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func TestWebhooks_Auth(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+
+	var server http.Server
+	net.Register(logger, makeHandler(), net.Webhooks(makeWebhookHandler()), net.Admin(map[string]string{"admin": "secret"}))(&server)
+
+	rr := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks", nil))
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks", nil)
+	req.SetBasicAuth("admin", "secret")
+
+	rr = httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// the job routes are not mounted without a job handler
+	req = httptest.NewRequest(http.MethodGet, "/v1/admin/jobs", nil)
+	req.SetBasicAuth("admin", "secret")
+
+	rr = httptest.NewRecorder()
+	server.Handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func withWebhookID(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), config.FieldWebhookID, id))
+}
+
+func makeWebhookHandler() *webhookHandler {
+	logger := slog.New(slog.DiscardHandler)
+	queue := jobs.New(logger, jobRepo.NewRepository(memory.NewJobStorage()), jobs.PollInterval(time.Second))
+
+	return NewWebhookHandler(webhook.New(logger, webhookRepo.NewRepository(memory.NewWebhookStorage()), queue))
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"bookmarks/internal/model"
+)
+
+type principalKey struct{}
+
+// NewPrincipalContext returns ctx carrying the principal the request was authenticated as.
+func NewPrincipalContext(ctx context.Context, p model.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal of the request of ctx, false for a request
+// that went through no authentication.
+func PrincipalFromContext(ctx context.Context) (model.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(model.Principal)
+
+	return p, ok
+}
+
+// CanRead reports whether the principal may read the bookmark events at the time. A server lets in
+// only the callers allowed to read its bookmarks, a caller of a public server has no principal;
+// a principal loses the right with its credentials, its stream ends and it must authenticate again.
+func CanRead(p model.Principal, at time.Time) bool {
+	return !p.Expired(at)
+}
@@ -1,5 +1,26 @@
 package handler
 
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/bookmark"
+)
+
+// Change ops of a sync response: an upsert carries the bookmark as stored now, a delete its tombstone.
+const (
+	ChangeUpsert = "upsert"
+	ChangeDelete = "delete"
+)
+
+// archivePolicy lets an archived page show its inlined resources only, scripts never run.
+const archivePolicy = "sandbox; default-src 'none'; img-src data:; style-src 'unsafe-inline' data:; font-src data:"
+
 type ErrorResponse struct {
 	Error   string       `json:"error"`
 	Context ErrorContext `json:"context"`
@@ -7,6 +28,109 @@ type ErrorResponse struct {
 
 type ErrorContext struct {
 	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+}
+
+// ConflictResponse is an ErrorResponse carrying the bookmark that caused the conflict.
+type ConflictResponse struct {
+	ErrorResponse
+	Existing BookmarkResponse `json:"existing"`
+}
+
+// BookmarkResponse is a bookmark with hints on how a client should render its value.
+type BookmarkResponse struct {
+	model.Bookmark
+	Render RenderHints `json:"render"`
+}
+
+type RenderHints struct {
+	Href      string `json:"href,omitempty"`
+	Monospace bool   `json:"monospace,omitempty"`
+}
+
+type ListResponse struct {
+	Items  []BookmarkResponse `json:"items"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
+
+// HealthResponse is the current state of a bookmarked link with its recent checks, newest first.
+type HealthResponse struct {
+	Health  model.LinkHealth  `json:"health"`
+	History []model.LinkCheck `json:"history"`
+}
+
+// JobListResponse is a page of background jobs, newest first.
+type JobListResponse struct {
+	Items  []model.Job `json:"items"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// WebhookResponse is a webhook subscription, the secret is shown only when the webhook is created.
+type WebhookResponse struct {
+	ID         uuid.UUID         `json:"id"`
+	URL        string            `json:"url"`
+	Events     []model.EventType `json:"events"`
+	Secret     string            `json:"secret,omitempty"`
+	Active     bool              `json:"active"`
+	Failures   int               `json:"failures"`
+	DisabledAt *time.Time        `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+type WebhookListResponse struct {
+	Items []WebhookResponse `json:"items"`
+}
+
+// DeliveryListResponse is a page of the delivery log of a webhook, newest first.
+type DeliveryListResponse struct {
+	Items  []model.WebhookDelivery `json:"items"`
+	Limit  int                     `json:"limit"`
+	Offset int                     `json:"offset"`
+}
+
+// BackupListResponse lists the backups of the database, newest first.
+type BackupListResponse struct {
+	Items []model.Backup `json:"items"`
+}
+
+// EventResponse is a bookmark change as sent on the event stream.
+type EventResponse struct {
+	Type       model.EventType  `json:"type"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Bookmark   BookmarkResponse `json:"bookmark"`
+}
+
+// ChangeResponse is a bookmark write after the sync token.
+type ChangeResponse struct {
+	Op        string            `json:"op"`
+	Version   int64             `json:"version"`
+	Bookmark  *BookmarkResponse `json:"bookmark,omitempty"`
+	Uuid      *uuid.UUID        `json:"uuid,omitempty"`
+	DeletedAt *time.Time        `json:"deleted_at,omitempty"`
+}
+
+// SyncResponse is a page of the changes in version order, the token fetches the next changes.
+type SyncResponse struct {
+	Changes []ChangeResponse `json:"changes"`
+	Token   string           `json:"token"`
+	More    bool             `json:"more"`
+}
+
+// PushResultResponse is the outcome of a pushed mutation, a conflict carries the server side bookmark.
+type PushResultResponse struct {
+	Uuid     string            `json:"uuid,omitempty"`
+	Status   string            `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Bookmark *BookmarkResponse `json:"bookmark,omitempty"`
+	Deleted  bool              `json:"deleted,omitempty"`
+}
+
+// PushResponse holds the outcomes in the order of the pushed mutations.
+type PushResponse struct {
+	Results []PushResultResponse `json:"results"`
 }
 
 func NewError(err string, ctx *ErrorContext) *ErrorResponse {
@@ -21,3 +145,188 @@ func NewError(err string, ctx *ErrorContext) *ErrorResponse {
 		Error: err,
 	}
 }
+
+func NewConflict(err string, ctx *ErrorContext, existing model.Bookmark) *ConflictResponse {
+	return &ConflictResponse{
+		ErrorResponse: *NewError(err, ctx),
+		Existing:      NewBookmark(existing),
+	}
+}
+
+func NewBookmark(bookmark model.Bookmark) BookmarkResponse {
+	return BookmarkResponse{
+		Bookmark: bookmark,
+		Render:   renderHints(bookmark),
+	}
+}
+
+func NewList(bookmarks []model.Bookmark, limit, offset int) *ListResponse {
+	items := make([]BookmarkResponse, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		items = append(items, NewBookmark(bookmark))
+	}
+
+	return &ListResponse{
+		Items:  items,
+		Limit:  limit,
+		Offset: offset,
+	}
+}
+
+func NewHealth(health model.LinkHealth, history []model.LinkCheck) *HealthResponse {
+	return &HealthResponse{
+		Health:  health,
+		History: history,
+	}
+}
+
+// ArchiveHeaders are the response headers serving an archived page, ETag is quoted digest.
+func ArchiveHeaders(archive model.Archive) map[string]string {
+	return map[string]string{
+		"Content-Type":            "text/html; charset=utf-8",
+		"Content-Length":          strconv.FormatInt(archive.Size, 10),
+		"Content-Security-Policy": archivePolicy,
+		"X-Content-Type-Options":  "nosniff",
+		"ETag":                    `"` + archive.Digest + `"`,
+		"Last-Modified":           archive.ArchivedAt.UTC().Format(http.TimeFormat),
+	}
+}
+
+func renderHints(bookmark model.Bookmark) RenderHints {
+	switch bookmark.Kind {
+	case model.KindURL:
+		return RenderHints{Href: bookmark.Value}
+	case model.KindContact:
+		if strings.Contains(bookmark.Value, "@") {
+			return RenderHints{Href: "mailto:" + bookmark.Value}
+		}
+
+		return RenderHints{Href: "tel:" + bookmark.Value}
+	case model.KindCode:
+		return RenderHints{Monospace: true}
+	default:
+		return RenderHints{}
+	}
+}
+
+func NewJobList(jobs []model.Job, limit, offset int) *JobListResponse {
+	if jobs == nil {
+		jobs = []model.Job{}
+	}
+
+	return &JobListResponse{
+		Items:  jobs,
+		Limit:  limit,
+		Offset: offset,
+	}
+}
+
+func NewWebhook(webhook model.Webhook, withSecret bool) WebhookResponse {
+	response := WebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		Active:    webhook.Active,
+		Failures:  webhook.Failures,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+
+	if response.Events == nil {
+		response.Events = []model.EventType{}
+	}
+
+	if withSecret {
+		response.Secret = webhook.Secret
+	}
+
+	if !webhook.DisabledAt.IsZero() {
+		response.DisabledAt = &webhook.DisabledAt
+	}
+
+	return response
+}
+
+func NewWebhookList(webhooks []model.Webhook) *WebhookListResponse {
+	items := make([]WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		items = append(items, NewWebhook(webhook, false))
+	}
+
+	return &WebhookListResponse{Items: items}
+}
+
+func NewDeliveryList(deliveries []model.WebhookDelivery, limit, offset int) *DeliveryListResponse {
+	if deliveries == nil {
+		deliveries = []model.WebhookDelivery{}
+	}
+
+	return &DeliveryListResponse{
+		Items:  deliveries,
+		Limit:  limit,
+		Offset: offset,
+	}
+}
+
+func NewEvent(event model.Event) EventResponse {
+	return EventResponse{
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Bookmark:   NewBookmark(event.Bookmark),
+	}
+}
+
+// NewSync merges the bookmarks and the tombstones of a change set by version.
+func NewSync(set model.ChangeSet, token string) *SyncResponse {
+	changes := make([]ChangeResponse, 0, len(set.Bookmarks)+len(set.Tombstones))
+
+	b, t := 0, 0
+	for b < len(set.Bookmarks) || t < len(set.Tombstones) {
+		if t == len(set.Tombstones) || (b < len(set.Bookmarks) && set.Bookmarks[b].Version < set.Tombstones[t].Version) {
+			stored := NewBookmark(set.Bookmarks[b])
+			changes = append(changes, ChangeResponse{Op: ChangeUpsert, Version: stored.Version, Bookmark: &stored})
+			b++
+
+			continue
+		}
+
+		tombstone := set.Tombstones[t]
+		changes = append(changes, ChangeResponse{
+			Op:        ChangeDelete,
+			Version:   tombstone.Version,
+			Uuid:      &tombstone.Uuid,
+			DeletedAt: &tombstone.DeletedAt,
+		})
+		t++
+	}
+
+	return &SyncResponse{
+		Changes: changes,
+		Token:   token,
+		More:    set.More,
+	}
+}
+
+func NewPush(results []bookmark.PushResult) *PushResponse {
+	items := make([]PushResultResponse, 0, len(results))
+	for _, result := range results {
+		item := PushResultResponse{
+			Uuid:    result.Uuid,
+			Status:  string(result.Status),
+			Deleted: result.Deleted,
+		}
+
+		if result.Err != nil {
+			item.Error = result.Err.Error()
+		}
+
+		if result.Bookmark.Uuid != uuid.Nil {
+			stored := NewBookmark(result.Bookmark)
+			item.Bookmark = &stored
+		}
+
+		items = append(items, item)
+	}
+
+	return &PushResponse{Results: items}
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/stream"
+)
+
+// EventReset tells a resumed client it missed events and must reload its state.
+const EventReset = "reset"
+
+var (
+	ErrInvalidLastEventID = errors.New("invalid last event id")
+	ErrStreamExpired      = errors.New("credentials of the stream expired")
+)
+
+// ParseLastEventID reads the resume position of an event stream from the Last-Event-ID header,
+// or the last_event_id query parameter of a browser opening the stream for the first time.
+func ParseLastEventID(header, query string) (int64, error) {
+	value := header
+	if value == "" {
+		value = query
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidLastEventID, value)
+	}
+
+	return id, nil
+}
+
+// ParseEventTypes reads a comma separated list of event types, empty for every type.
+func ParseEventTypes(types string) ([]model.EventType, error) {
+	if types == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(types, ",")
+	result := make([]model.EventType, 0, len(parts))
+
+	for _, part := range parts {
+		eventType, err := model.ParseEventType(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, eventType)
+	}
+
+	return result, nil
+}
+
+// Stream writes the subscription as server-sent events until the broker ends it,
+// ctx is done or a write fails. flush pushes the written events to the client.
+// The principal gets the events it may read, the stream ends with ErrStreamExpired
+// at the first event or heartbeat it may no longer read, see CanRead.
+func Stream(ctx context.Context, w io.Writer, flush func() error, sub *stream.Subscription, heartbeat time.Duration, principal model.Principal) error {
+	if !CanRead(principal, time.Now()) {
+		return ErrStreamExpired
+	}
+
+	if sub.Missed {
+		if err := writeEvent(w, sub.Last, EventReset, struct{}{}); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range sub.Replay {
+		if err := writeEvent(w, msg.ID, string(msg.Event.Type), NewEvent(msg.Event)); err != nil {
+			return err
+		}
+	}
+
+	// the headers go out even without events, a client knows the stream is open
+	if _, err := io.WriteString(w, ": connected\n\n"); err != nil {
+		return err
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+
+			if !CanRead(principal, time.Now()) {
+				return ErrStreamExpired
+			}
+
+			if err := writeEvent(w, msg.ID, string(msg.Event.Type), NewEvent(msg.Event)); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if !CanRead(principal, time.Now()) {
+				return ErrStreamExpired
+			}
+
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return err
+			}
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// writeEvent writes one server-sent event, JSON keeps the data on a single line.
+func writeEvent(w io.Writer, id int64, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+
+	return err
+}
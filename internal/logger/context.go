@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying the logger of the request, the layers serving
+// the request log with it through FromContext.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of the request of ctx, or slog.Default outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+
+	return slog.Default()
+}
+
+// WithRoute returns the logger adding the route of the request to the records. A router
+// knows the route once it matched the request, so route is called as the records are
+// logged until done, which keeps the last route: the routers recycle their state after
+// the request ends, while the logger may still be used by the work the request started.
+func WithRoute(l *slog.Logger, route func() string) (_ *slog.Logger, done func()) {
+	r := &lazyRoute{resolve: route}
+
+	return slog.New(&routeHandler{next: l.Handler(), route: r}), r.fix
+}
+
+type lazyRoute struct {
+	mu      sync.Mutex
+	resolve func() string
+	value   string
+}
+
+func (r *lazyRoute) get() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.resolve != nil {
+		r.value = r.resolve()
+	}
+
+	return r.value
+}
+
+func (r *lazyRoute) fix() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.resolve != nil {
+		r.value = r.resolve()
+		r.resolve = nil
+	}
+}
+
+// routeHandler adds the route to the records, in the group the logger is in.
+type routeHandler struct {
+	next  slog.Handler
+	route *lazyRoute
+}
+
+func (h *routeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *routeHandler) Handle(ctx context.Context, record slog.Record) error {
+	record = record.Clone()
+	record.AddAttrs(slog.String("route", h.route.get()))
+
+	return h.next.Handle(ctx, record)
+}
+
+func (h *routeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &routeHandler{next: h.next.WithAttrs(attrs), route: h.route}
+}
+
+func (h *routeHandler) WithGroup(name string) slog.Handler {
+	return &routeHandler{next: h.next.WithGroup(name), route: h.route}
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// levels are the minimum levels of the records by the package logging them.
+type levels struct {
+	level    slog.Level
+	min      slog.Level // the lowest of all, the records below it are never logged
+	packages []packageLevel
+	byPC     sync.Map // program counter -> slog.Level
+}
+
+type packageLevel struct {
+	path  string
+	level slog.Level
+}
+
+func newLevels(level slog.Level, packages map[string]slog.Level) *levels {
+	l := &levels{level: level, min: level}
+
+	for path, level := range packages {
+		l.packages = append(l.packages, packageLevel{path: path, level: level})
+		l.min = min(l.min, level)
+	}
+
+	// the longest path is the most specific
+	sort.Slice(l.packages, func(i, j int) bool {
+		return len(l.packages[i].path) > len(l.packages[j].path)
+	})
+
+	return l
+}
+
+// of returns the level of the package of the function at pc, the calls of a
+// line always resolve to the same package so the result is kept.
+func (l *levels) of(pc uintptr) slog.Level {
+	if pc == 0 {
+		return l.level
+	}
+
+	if level, ok := l.byPC.Load(pc); ok {
+		return level.(slog.Level) //nolint:forcetypeassert // only levels are stored
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	path := packagePath(frame.Function)
+
+	level := l.level
+	for _, p := range l.packages {
+		if path == p.path || strings.HasPrefix(path, p.path+"/") {
+			level = p.level
+			break
+		}
+	}
+
+	l.byPC.Store(pc, level)
+
+	return level
+}
+
+// packagePath cuts the import path of the package off a function name,
+// e.g. bookmarks/internal/service/outbox.(*Relay).run.
+func packagePath(function string) string {
+	slash := strings.LastIndexByte(function, '/') + 1
+
+	if dot := strings.IndexByte(function[slash:], '.'); dot >= 0 {
+		return function[:slash+dot]
+	}
+
+	return function
+}
+
+// levelHandler drops the records below the level of the package logging them.
+type levelHandler struct {
+	next   slog.Handler
+	levels *levels
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.min && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < h.levels.of(record.PC) {
+		return nil
+	}
+
+	return h.next.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{next: h.next.WithAttrs(attrs), levels: h.levels}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{next: h.next.WithGroup(name), levels: h.levels}
+}
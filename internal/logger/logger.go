@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// New returns the logger of the app and the closer of its file, which does nothing
+// when the records go to the output.
+func New(opts ...Option) (*slog.Logger, io.Closer, error) {
+	const op = "logger.New"
+
+	o := &options{
+		format:   FormatJSON,
+		level:    slog.LevelInfo,
+		output:   os.Stdout,
+		maxSize:  100,
+		packages: make(map[string]slog.Level),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var (
+		w                = o.output
+		closer io.Closer = nopCloser{}
+	)
+
+	if o.file != "" {
+		file := &lumberjack.Logger{
+			Filename:   o.file,
+			MaxSize:    o.maxSize,
+			MaxBackups: o.maxBackups,
+			MaxAge:     days(o.maxAge),
+		}
+
+		w, closer = file, file
+	}
+
+	levels := newLevels(o.level, o.packages)
+
+	handlerOptions := &slog.HandlerOptions{Level: levels.min}
+
+	var handler slog.Handler
+	switch o.format {
+	case FormatText:
+		handler = slog.NewTextHandler(w, handlerOptions)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	default:
+		return nil, nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownFormat, o.format)
+	}
+
+	if len(o.packages) > 0 {
+		handler = &levelHandler{next: handler, levels: levels}
+	}
+
+	return slog.New(handler), closer, nil
+}
+
+// ParseLevel reads a level name, e.g. debug or WARN, as slog.Level.UnmarshalText does.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+
+	err := level.UnmarshalText([]byte(name))
+
+	return level, err
+}
+
+// StatusLevel is the level of a response of the status: client errors are logged
+// below the server faults, they are the client's to fix.
+func StatusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// days rounds the age up to whole days, the unit of lumberjack.
+func days(age time.Duration) int {
+	const day = 24 * time.Hour
+
+	return int((age + day - 1) / day)
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
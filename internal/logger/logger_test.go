@@ -0,0 +1,84 @@
+package logger_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/logger"
+)
+
+func TestNew_PackageLevel(t *testing.T) {
+	var buf bytes.Buffer
+
+	log, _, err := logger.New(
+		logger.Output(&buf),
+		logger.Format(logger.FormatText),
+		logger.Level(slog.LevelWarn),
+		logger.PackageLevel("bookmarks/internal", slog.LevelDebug),
+		logger.PackageLevel("bookmarks/internal/logger_test", slog.LevelInfo),
+	)
+	require.NoError(t, err)
+
+	// the records of this package follow its own level, the longest path
+	log.Debug("dropped")
+	log.Info("kept")
+
+	require.NotContains(t, buf.String(), "dropped")
+	require.Contains(t, buf.String(), "msg=kept")
+}
+
+func TestNew_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	log, closer, err := logger.New(logger.File(path), logger.Rotation(1, 2, 0))
+	require.NoError(t, err)
+
+	log.Warn("to the file")
+	require.NoError(t, closer.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"msg":"to the file"`)
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	_, _, err := logger.New(logger.Format("xml"))
+	require.ErrorIs(t, err, logger.ErrUnknownFormat)
+}
+
+func TestWithRoute(t *testing.T) {
+	var buf bytes.Buffer
+
+	route := "unmatched"
+	log, done := logger.WithRoute(slog.New(slog.NewTextHandler(&buf, nil)), func() string { return route })
+
+	route = "/v1/bookmark/{uuid}"
+	log.With(slog.String("op", "handler")).Info("matched")
+	require.Contains(t, buf.String(), "op=handler route=/v1/bookmark/{uuid}")
+
+	done()
+	route = "recycled"
+
+	buf.Reset()
+	log.Info("after the request")
+	require.Contains(t, buf.String(), "route=/v1/bookmark/{uuid}")
+}
+
+func TestFromContext(t *testing.T) {
+	require.Same(t, slog.Default(), logger.FromContext(t.Context()))
+
+	log := slog.New(slog.DiscardHandler)
+	require.Same(t, log, logger.FromContext(logger.NewContext(t.Context(), log)))
+}
+
+func TestStatusLevel(t *testing.T) {
+	require.Equal(t, slog.LevelInfo, logger.StatusLevel(http.StatusNotModified))
+	require.Equal(t, slog.LevelWarn, logger.StatusLevel(http.StatusNotFound))
+	require.Equal(t, slog.LevelError, logger.StatusLevel(http.StatusServiceUnavailable))
+}
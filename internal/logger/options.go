@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	format     string
+	level      slog.Level
+	output     io.Writer
+	file       string
+	maxSize    int
+	maxBackups int
+	maxAge     time.Duration
+	packages   map[string]slog.Level
+}
+
+// Format sets how the records are written, FormatText or FormatJSON.
+func Format(format string) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// Level sets the minimum level of the records, PackageLevel overrides it for a package.
+func Level(level slog.Level) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// PackageLevel sets the minimum level of the records logged from the package at path and
+// its subpackages, e.g. bookmarks/internal/service/outbox. The longest matching path wins.
+func PackageLevel(path string, level slog.Level) Option {
+	return func(o *options) {
+		o.packages[path] = level
+	}
+}
+
+// Output sets the writer of the records, when they are not written to a File.
+func Output(w io.Writer) Option {
+	return func(o *options) {
+		o.output = w
+	}
+}
+
+// File writes the records to the file at path, rotated once it grows past Rotation's size.
+func File(path string) Option {
+	return func(o *options) {
+		o.file = path
+	}
+}
+
+// Rotation sets the size in megabytes the file is rotated at, and how many rotated files
+// are kept for how long; zero keeps them all.
+func Rotation(maxSize, maxBackups int, maxAge time.Duration) Option {
+	return func(o *options) {
+		o.maxSize = maxSize
+		o.maxBackups = maxBackups
+		o.maxAge = maxAge
+	}
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"bookmarks/internal/repository"
+)
+
+const namespace = "bookmarks"
+
+// Unmatched is the route label of the requests no route matched,
+// their raw paths would make a label value per scanned URL.
+const Unmatched = "unmatched"
+
+// Metrics keeps the collectors of the app in its own registry, served by Handler
+// in the Prometheus text format along with the Go runtime and process stats.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests served, by route pattern, method and status.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the HTTP requests, by route pattern, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "HTTP requests being served.",
+		}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of the bookmark storage calls, by storage method.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 9), // 100µs to 6.5s
+		}, []string{"method"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "errors_total",
+			Help:      "Failed bookmark storage calls, by storage method.",
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.inFlight,
+		m.storageDuration,
+		m.storageErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// TrackRequest counts the request in flight until done is called with the route
+// pattern, e.g. /v1/bookmark/{uuid}, and the response status.
+func (m *Metrics) TrackRequest() (done func(route, method string, status int)) {
+	start := time.Now()
+
+	m.inFlight.Inc()
+
+	return func(route, method string, status int) {
+		m.inFlight.Dec()
+
+		code := strconv.Itoa(status)
+
+		m.requests.WithLabelValues(route, method, code).Inc()
+		m.duration.WithLabelValues(route, method, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveStorage records a storage call. Not found, exists and conflict errors are
+// outcomes the callers expect, they are not counted as errors.
+func (m *Metrics) ObserveStorage(method string, duration time.Duration, err error) {
+	m.storageDuration.WithLabelValues(method).Observe(duration.Seconds())
+
+	if err != nil && !errors.Is(err, repository.ErrNotFound) &&
+		!errors.Is(err, repository.ErrExists) && !errors.Is(err, repository.ErrConflict) {
+		m.storageErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// RegisterDB collects the sql.DBStats of the connection pool under the name.
+func (m *Metrics) RegisterDB(name string, db *sql.DB) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,56 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/metrics"
+	"bookmarks/internal/repository"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	return rec.Body.String()
+}
+
+func TestObserveStorage(t *testing.T) {
+	m := metrics.New()
+
+	m.ObserveStorage("GetByUUID", time.Millisecond, nil)
+	m.ObserveStorage("GetByUUID", time.Millisecond, repository.ErrNotFound)
+	m.ObserveStorage("Create", time.Millisecond, repository.ErrExists)
+	m.ObserveStorage("Create", time.Millisecond, errors.New("disk I/O error"))
+
+	body := scrape(t, m)
+	require.Contains(t, body, `bookmarks_storage_operation_duration_seconds_count{method="GetByUUID"} 2`)
+	require.Contains(t, body, `bookmarks_storage_operation_duration_seconds_count{method="Create"} 2`)
+	require.Contains(t, body, `bookmarks_storage_errors_total{method="Create"} 1`)
+	require.NotContains(t, body, `bookmarks_storage_errors_total{method="GetByUUID"}`)
+}
+
+func TestRegisterDB(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, db.Ping())
+
+	m := metrics.New()
+	require.NoError(t, m.RegisterDB("main", db))
+	require.Error(t, m.RegisterDB("main", db), "registered twice")
+
+	body := scrape(t, m)
+	require.Contains(t, body, `go_sql_open_connections{db_name="main"}`)
+	require.Contains(t, body, "go_goroutines")
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tokenPrefix marks the API tokens, so a leaked one is recognized by secret scanners.
+const tokenPrefix = "bmt_"
+
+var userName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// User is an account calling the API with its tokens.
+type User struct {
+	ID        uuid.UUID
+	Name      string
+	Admin     bool // may call the admin API
+	CreatedAt time.Time
+}
+
+// Principal is who a request was authenticated as: an admin of the config or the user of a token.
+type Principal struct {
+	Name      string
+	Admin     bool
+	ExpiresAt time.Time // the expiry of the token, zero for credentials that never expire
+}
+
+// Expired reports whether the credentials of the principal are no longer valid at the time.
+func (p Principal) Expired(at time.Time) bool {
+	return !p.ExpiresAt.IsZero() && !at.Before(p.ExpiresAt)
+}
+
+// Token is an API token of a user. Only the hash of the secret is kept,
+// the secret is shown once when the token is issued.
+type Token struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	Hash      string
+	CreatedAt time.Time
+	ExpiresAt time.Time // zero for a token that never expires
+}
+
+// NewUser creates a user, the name is lowercase letters, digits, dots, dashes and underscores.
+// Only an admin user may call the admin API.
+func NewUser(name string, admin bool) (User, error) {
+	const op = "model.user.New"
+
+	name = strings.TrimSpace(name)
+	if !userName.MatchString(name) {
+		return User{}, fmt.Errorf("%s: %w: %q", op, ErrInvalidUserName, name)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return User{ID: id, Name: name, Admin: admin, CreatedAt: time.Now()}, nil
+}
+
+// NewToken issues a token of the user and returns it with its secret,
+// a positive ttl limits how long the token is valid.
+func NewToken(user User, name string, ttl time.Duration) (Token, string, error) {
+	const op = "model.token.New"
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return Token{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	secret := tokenPrefix + newSecret()
+	token := Token{
+		ID:        id,
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(name),
+		Hash:      HashToken(secret),
+		CreatedAt: time.Now(),
+	}
+
+	if ttl > 0 {
+		token.ExpiresAt = token.CreatedAt.Add(ttl)
+	}
+
+	return token, secret, nil
+}
+
+// HashToken is the stored form of a token secret.
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Expired reports whether the token is no longer valid at the time.
+func (t Token) Expired(at time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !at.Before(t.ExpiresAt)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUser(t *testing.T) {
+	user, err := NewUser(" ci-bot.1 ", false)
+	require.NoError(t, err)
+	require.Equal(t, "ci-bot.1", user.Name)
+	require.False(t, user.Admin)
+
+	admin, err := NewUser("root", true)
+	require.NoError(t, err)
+	require.True(t, admin.Admin)
+
+	for _, name := range []string{"", "Alice", "-dash", "white space", strings.Repeat("a", 65)} {
+		_, err := NewUser(name, false)
+		require.ErrorIs(t, err, ErrInvalidUserName, name)
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	user, err := NewUser("alice", false)
+	require.NoError(t, err)
+
+	token, secret, err := NewToken(user, "ci", time.Hour)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, tokenPrefix))
+	require.Equal(t, HashToken(secret), token.Hash)
+	require.NotContains(t, token.Hash, secret)
+	require.Equal(t, user.ID, token.UserID)
+	require.False(t, token.Expired(token.CreatedAt))
+	require.True(t, token.Expired(token.CreatedAt.Add(time.Hour)))
+
+	other, otherSecret, err := NewToken(user, "", 0)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, otherSecret)
+	require.False(t, other.Expired(time.Now().Add(100*365*24*time.Hour)))
+}
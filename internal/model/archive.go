@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Archive is a self-contained copy of the page an URL bookmark points to.
+type Archive struct {
+	Uuid       uuid.UUID // bookmark uuid
+	Digest     string    // address of the archived HTML in the blob store
+	Size       int64
+	Resources  int    // stylesheets and images inlined into the page
+	SourceURL  string // final URL of the archived page
+	ArchivedAt time.Time
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Backup is a consistent copy of the sqlite database, verified against the checksum of its manifest.
+type Backup struct {
+	Name          string // file name in the backup directory
+	Size          int64
+	SHA256        string // hex encoded checksum of the file
+	SchemaVersion int    // migrations applied to the copy
+	CreatedAt     time.Time
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -9,34 +10,73 @@ import (
 )
 
 type Bookmark struct {
-	Uuid      uuid.UUID
-	Title     string
-	Value     string // основное значение, которое нужно запомнить
-	CreatedAt time.Time
+	Uuid           uuid.UUID
+	Title          string
+	TitleAuto      bool   // заголовок выведен из значения, а не задан пользователем
+	Value          string // основное значение, которое нужно запомнить
+	Kind           Kind
+	CanonicalValue string // форма Value для дедупликации
+	CreatedAt      time.Time
+	Version        int64 // change sequence of the last write, sync clients detect conflicts with it
 }
 
+// NewBookmark creates a bookmark detecting the kind from the value.
 func NewBookmark(title, value string) (Bookmark, error) {
+	return NewBookmarkOfKind(title, value, KindAuto)
+}
+
+// NewBookmarkOfKind creates a bookmark validating and normalizing the value according to kind.
+// An URL bookmark may omit the title, it is derived from the URL then.
+func NewBookmarkOfKind(title, value string, kind Kind) (Bookmark, error) {
 	const op = "model.bookmark.New"
 
 	title = strings.TrimSpace(title)
-	if title == "" {
+	value = strings.TrimSpace(value)
+
+	if kind == KindAuto {
+		kind = DetectKind(value)
+	}
+
+	if title == "" && (kind != KindURL || value == "") {
 		return Bookmark{}, fmt.Errorf("%s: %w", op, ErrInvalidTitle)
 	}
 
-	value = strings.TrimSpace(value)
 	if value == "" {
 		return Bookmark{}, fmt.Errorf("%s: %w", op, ErrInvalidValue)
 	}
 
+	value, err := normalizeValue(kind, value)
+	if err != nil {
+		return Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	titleAuto := title == ""
+	if titleAuto {
+		title = titleFromURL(value)
+	}
+
 	uuid, err := uuid.NewV7()
 	if err != nil {
 		return Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return Bookmark{
-		Uuid:      uuid,
-		Title:     title,
-		Value:     value,
-		CreatedAt: time.Now(),
+		Uuid:           uuid,
+		Title:          title,
+		TitleAuto:      titleAuto,
+		Value:          value,
+		Kind:           kind,
+		CanonicalValue: CanonicalValue(value),
+		CreatedAt:      time.Now(),
 	}, nil
 }
+
+// titleFromURL derives a title from a normalized URL: host and path, without "www.".
+func titleFromURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+
+	return strings.TrimPrefix(u.Hostname(), "www.") + strings.TrimRight(u.EscapedPath(), "/")
+}
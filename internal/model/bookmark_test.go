@@ -22,6 +22,17 @@ func TestNewPrepare_Success(t *testing.T) {
 	require.Equal(t, "value", bookmark.Value)
 }
 
+func TestNew_CanonicalValue(t *testing.T) {
+	first, err := NewBookmark("test", "https://Example.com/a/?utm_source=x")
+	require.NoError(t, err)
+
+	second, err := NewBookmark("test", "https://example.com/a")
+	require.NoError(t, err)
+
+	require.Equal(t, "https://Example.com/a/?utm_source=x", first.Value)
+	require.Equal(t, first.CanonicalValue, second.CanonicalValue)
+}
+
 func TestNew_Error(t *testing.T) {
 	tests := []struct {
 		title string
@@ -52,3 +63,16 @@ func TestNew_Error(t *testing.T) {
 		require.ErrorIs(t, err, tt.err)
 	}
 }
+
+func TestNew_TitleFromURL(t *testing.T) {
+	bookmark, err := NewBookmark(" ", "https://www.example.com/docs/")
+
+	require.NoError(t, err)
+	require.Equal(t, "example.com/docs", bookmark.Title)
+	require.True(t, bookmark.TitleAuto)
+
+	bookmark, err = NewBookmark("docs", "https://www.example.com/docs/")
+
+	require.NoError(t, err)
+	require.False(t, bookmark.TitleAuto)
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultTrackingParams are query parameters dropped from URLs before deduplication.
+// A trailing "*" matches any parameter with that prefix.
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"yclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"_openstat",
+	"igshid",
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+var canonicalizer atomic.Pointer[Canonicalizer]
+
+func init() {
+	canonicalizer.Store(NewCanonicalizer(DefaultTrackingParams))
+}
+
+// Canonicalizer reduces a bookmark value to the form used for deduplication.
+type Canonicalizer struct {
+	params   map[string]struct{}
+	prefixes []string
+}
+
+func NewCanonicalizer(trackingParams []string) *Canonicalizer {
+	c := &Canonicalizer{
+		params: make(map[string]struct{}, len(trackingParams)),
+	}
+
+	for _, param := range trackingParams {
+		param = strings.ToLower(strings.TrimSpace(param))
+		if param == "" {
+			continue
+		}
+
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			c.prefixes = append(c.prefixes, prefix)
+			continue
+		}
+
+		c.params[param] = struct{}{}
+	}
+
+	return c
+}
+
+// SetTrackingParams replaces the tracking parameters used by NewBookmark and CanonicalValue.
+func SetTrackingParams(trackingParams []string) {
+	canonicalizer.Store(NewCanonicalizer(trackingParams))
+}
+
+// CanonicalValue canonicalizes the value with the configured tracking parameters.
+func CanonicalValue(value string) string {
+	return canonicalizer.Load().Canonicalize(value)
+}
+
+// Canonicalize returns the canonical form of an URL or, for anything else, of a text.
+func (c *Canonicalizer) Canonicalize(value string) string {
+	value = strings.TrimSpace(value)
+
+	if u, ok := parseURL(value); ok {
+		return c.canonicalURL(u)
+	}
+
+	return canonicalText(value)
+}
+
+// canonicalURL lowercases scheme and host, drops default port, fragment,
+// trailing slash and tracking parameters, and sorts the query.
+func (c *Canonicalizer) canonicalURL(u *url.URL) string {
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+
+	switch {
+	case port != "":
+		u.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+
+	u.Fragment, u.RawFragment = "", ""
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+
+	query := u.Query()
+	for key := range query {
+		if c.isTracking(key) {
+			query.Del(key)
+		}
+	}
+
+	u.RawQuery = query.Encode() // Encode sorts by key
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+func (c *Canonicalizer) isTracking(param string) bool {
+	param = strings.ToLower(param)
+	if _, ok := c.params[param]; ok {
+		return true
+	}
+
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(param, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canonicalText applies Unicode NFC and folds whitespace runs into a single space.
+func canonicalText(value string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(value)), " ")
+}
+
+func parseURL(value string) (*url.URL, bool) {
+	if strings.ContainsAny(value, " \t\r\n") {
+		return nil, false
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Opaque != "" {
+		return nil, false
+	}
+
+	return u, true
+}
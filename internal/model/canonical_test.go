@@ -0,0 +1,56 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize_URL(t *testing.T) {
+	c := NewCanonicalizer(DefaultTrackingParams)
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "https://Example.com/a/?utm_source=x", want: "https://example.com/a"},
+		{value: "https://example.com/a", want: "https://example.com/a"},
+		{value: "HTTP://EXAMPLE.com:80/", want: "http://example.com"},
+		{value: "https://example.com:443/a#section", want: "https://example.com/a"},
+		{value: "https://example.com:8443/a", want: "https://example.com:8443/a"},
+		{value: "https://example.com/a?b=2&a=1&fbclid=x", want: "https://example.com/a?a=1&b=2"},
+		{value: "https://[::1]:443/a", want: "https://[::1]/a"},
+		{value: "https://example.com/A/b", want: "https://example.com/A/b"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, c.Canonicalize(tt.value), tt.value)
+	}
+}
+
+func TestCanonicalize_TrackingParams(t *testing.T) {
+	c := NewCanonicalizer([]string{"ref", "mtm_*"})
+
+	require.Equal(t,
+		"https://example.com/a?utm_source=x",
+		c.Canonicalize("https://example.com/a?utm_source=x&ref=feed&mtm_campaign=y"),
+	)
+}
+
+func TestCanonicalize_Text(t *testing.T) {
+	c := NewCanonicalizer(nil)
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "  git   log\t--oneline \n", want: "git log --oneline"},
+		{value: "cafe\u0301", want: "caf\u00e9"},
+		{value: "Case Matters", want: "Case Matters"},
+		{value: "mailto:user@example.com", want: "mailto:user@example.com"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, c.Canonicalize(tt.value), tt.value)
+	}
+}
@@ -5,4 +5,12 @@ import "errors"
 var (
 	ErrInvalidTitle = errors.New("invalid bookmark name")
 	ErrInvalidValue = errors.New("invalid bookmark value")
+	ErrInvalidKind  = errors.New("invalid bookmark kind")
+
+	ErrInvalidHealthStatus = errors.New("invalid link health status")
+
+	ErrInvalidEventType  = errors.New("invalid event type")
+	ErrInvalidWebhookURL = errors.New("invalid webhook url")
+
+	ErrInvalidUserName = errors.New("invalid user name")
 )
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventType names a domain event, it is stable across releases: sinks route on it.
+type EventType string
+
+const (
+	BookmarkAppended EventType = "bookmark.appended"
+	BookmarkChanged  EventType = "bookmark.changed"
+	BookmarkDeleted  EventType = "bookmark.deleted"
+)
+
+// ParseEventType validates a client supplied event type.
+func ParseEventType(eventType string) (EventType, error) {
+	switch t := EventType(eventType); t {
+	case BookmarkAppended, BookmarkChanged, BookmarkDeleted:
+		return t, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidEventType, eventType)
+	}
+}
+
+// Event is a bookmark change recorded in the outbox.
+type Event struct {
+	ID         int64 // outbox sequence, increasing in commit order
+	Type       EventType
+	Bookmark   Bookmark // state after the change, the last state for BookmarkDeleted
+	OccurredAt time.Time
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HealthStatus is the state of a bookmarked link derived from its recent checks.
+type HealthStatus string
+
+const (
+	HealthOK      HealthStatus = "ok"
+	HealthFailing HealthStatus = "failing" // failed recently, not broken yet
+	HealthBroken  HealthStatus = "broken"
+)
+
+// ParseHealthStatus validates a client supplied status.
+func ParseHealthStatus(status string) (HealthStatus, error) {
+	switch s := HealthStatus(status); s {
+	case HealthOK, HealthFailing, HealthBroken:
+		return s, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidHealthStatus, status)
+	}
+}
+
+// LinkCheck is the outcome of one request to a bookmarked URL.
+type LinkCheck struct {
+	Uuid       uuid.UUID // bookmark uuid
+	OK         bool
+	StatusCode int    // zero when no response was received
+	RedirectTo string // final URL when the request was redirected
+	Latency    time.Duration
+	Error      string
+	CheckedAt  time.Time
+}
+
+// LinkHealth is the current state of a bookmarked URL.
+type LinkHealth struct {
+	Uuid                uuid.UUID // bookmark uuid
+	Status              HealthStatus
+	StatusCode          int
+	RedirectTo          string
+	Latency             time.Duration
+	Error               string
+	ConsecutiveFailures int
+	CheckedAt           time.Time
+}
+
+// Record folds the check into the health,
+// the link is broken after threshold consecutive failures.
+func (h LinkHealth) Record(check LinkCheck, threshold int) LinkHealth {
+	h.Uuid = check.Uuid
+	h.StatusCode = check.StatusCode
+	h.RedirectTo = check.RedirectTo
+	h.Latency = check.Latency
+	h.Error = check.Error
+	h.CheckedAt = check.CheckedAt
+
+	switch {
+	case check.OK:
+		h.Status = HealthOK
+		h.ConsecutiveFailures = 0
+	case h.ConsecutiveFailures+1 >= max(threshold, 1):
+		h.Status = HealthBroken
+		h.ConsecutiveFailures++
+	default:
+		h.Status = HealthFailing
+		h.ConsecutiveFailures++
+	}
+
+	return h
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobStatus is the stage of a background job.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"  // waiting for RunAt, also between retries
+	JobRunning   JobStatus = "running" // leased by a worker
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed" // out of attempts or failed permanently
+)
+
+// Job is a durable unit of background work, its payload is decoded by the handler of its kind.
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	UniqueKey   string // at most one job per key, empty for none
+	Status      JobStatus
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LeaseOwner  string
+	LeasedUntil time.Time // a running job with an expired lease is resumed by another worker
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FinishedAt  time.Time
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Kind is the type of the value a bookmark remembers.
+type Kind string
+
+const (
+	KindAuto    Kind = "" // detect from value
+	KindURL     Kind = "url"
+	KindText    Kind = "text"
+	KindCode    Kind = "code"    // shell commands, snippets, IP addresses
+	KindContact Kind = "contact" // e-mail or phone number
+)
+
+var urlSchemes = []string{"http", "https", "ftp", "ws", "wss"}
+
+// commands are first words making a one-liner with a flag or a path a shell snippet.
+var commands = []string{
+	"cat", "cd", "chmod", "chown", "cp", "curl", "dig", "docker", "find", "git",
+	"go", "grep", "helm", "kubectl", "ls", "make", "mkdir", "mv", "npm", "ping",
+	"psql", "rm", "scp", "sed", "ssh", "sudo", "systemctl", "tail", "wget",
+}
+
+// DetectKind guesses the kind of a trimmed value.
+func DetectKind(value string) Kind {
+	switch {
+	case isURL(value):
+		return KindURL
+	case isIP(value):
+		return KindCode
+	case isEmail(value) || isPhone(value):
+		return KindContact
+	case isCode(value):
+		return KindCode
+	default:
+		return KindText
+	}
+}
+
+// normalizeValue validates the value against its kind and returns its stored form.
+func normalizeValue(kind Kind, value string) (string, error) {
+	switch kind {
+	case KindURL:
+		return normalizeURL(value)
+	case KindContact:
+		return normalizeContact(value)
+	case KindText, KindCode:
+		return value, nil
+	default:
+		return "", ErrInvalidKind
+	}
+}
+
+func normalizeURL(value string) (string, error) {
+	if !strings.Contains(value, "://") {
+		value = "https://" + value
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidValue, err)
+	}
+
+	if !slices.Contains(urlSchemes, strings.ToLower(u.Scheme)) || u.Hostname() == "" ||
+		strings.ContainsAny(value, " \t\r\n") {
+		return "", fmt.Errorf("%w: not an url", ErrInvalidValue)
+	}
+
+	return u.String(), nil
+}
+
+func normalizeContact(value string) (string, error) {
+	if email, ok := strings.CutPrefix(value, "mailto:"); ok || strings.Contains(value, "@") {
+		if !isEmail(email) {
+			return "", fmt.Errorf("%w: not an e-mail", ErrInvalidValue)
+		}
+
+		local, domain, _ := strings.Cut(email, "@")
+
+		return local + "@" + strings.ToLower(domain), nil
+	}
+
+	phone := strings.TrimPrefix(value, "tel:")
+	if !isPhone(phone) {
+		return "", fmt.Errorf("%w: not a phone number", ErrInvalidValue)
+	}
+
+	var b strings.Builder
+	if strings.HasPrefix(phone, "+") {
+		b.WriteByte('+')
+	}
+
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func isURL(value string) bool {
+	if strings.HasPrefix(strings.ToLower(value), "www.") && !strings.ContainsAny(value, " \t\r\n") {
+		return true
+	}
+
+	u, ok := parseURL(value)
+
+	return ok && slices.Contains(urlSchemes, strings.ToLower(u.Scheme))
+}
+
+func isIP(value string) bool {
+	if _, err := netip.ParseAddr(value); err == nil {
+		return true
+	}
+
+	if _, err := netip.ParsePrefix(value); err == nil {
+		return true
+	}
+
+	_, err := netip.ParseAddrPort(value)
+
+	return err == nil
+}
+
+func isEmail(value string) bool {
+	value = strings.TrimPrefix(value, "mailto:")
+
+	addr, err := mail.ParseAddress(value)
+
+	return err == nil && addr.Address == value && addr.Name == ""
+}
+
+// isPhone accepts digits with common separators, 7 to 15 digits (E.164).
+func isPhone(value string) bool {
+	value = strings.TrimPrefix(value, "tel:")
+
+	digits := 0
+	for i, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0:
+		case strings.ContainsRune(" -().", r):
+		default:
+			return false
+		}
+	}
+
+	return digits >= 7 && digits <= 15
+}
+
+// isCode looks for a shell prompt, shell operators, indented lines or statements ended
+// by a semicolon or a brace. A command is taken for code with a flag or a path only,
+// "go to the store" is a note.
+func isCode(value string) bool {
+	if strings.HasPrefix(value, "$ ") || strings.HasPrefix(value, "#!") {
+		return true
+	}
+
+	for _, token := range []string{"&&", "||", " | ", "$(", "`", "\n\t", "\n  ", ";\n", "{\n"} {
+		if strings.Contains(value, token) {
+			return true
+		}
+	}
+
+	if strings.HasSuffix(value, ";") || strings.HasSuffix(value, "}") {
+		return true
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) < 2 || !slices.Contains(commands, fields[0]) {
+		return false
+	}
+
+	return slices.ContainsFunc(fields[1:], func(arg string) bool {
+		return len(arg) > 1 && strings.HasPrefix(arg, "-") ||
+			strings.HasPrefix(arg, "/") || strings.HasPrefix(arg, "./") || strings.HasPrefix(arg, "~/")
+	})
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectKind(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Kind
+	}{
+		{value: "https://example.com/a", want: KindURL},
+		{value: "www.example.com", want: KindURL},
+		{value: "10.0.0.1", want: KindCode},
+		{value: "192.168.100.200", want: KindCode},
+		{value: "10.0.0.0/8", want: KindCode},
+		{value: "[::1]:8080", want: KindCode},
+		{value: "git log --oneline", want: KindCode},
+		{value: "ps aux | grep app", want: KindCode},
+		{value: "$ make build", want: KindCode},
+		{value: "cd ~/src/bookmarks", want: KindCode},
+		{value: "if err != nil {\n\treturn err\n}", want: KindCode},
+		{value: "SELECT * FROM bookmark;", want: KindCode},
+		{value: "go to the store", want: KindText},
+		{value: "make dinner - after work", want: KindText},
+		{value: "user@example.com", want: KindContact},
+		{value: "+7 (999) 123-45-67", want: KindContact},
+		{value: "tel:+79991234567", want: KindContact},
+		{value: "remember the milk", want: KindText},
+		{value: "mailto:user@example.com", want: KindContact},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, DetectKind(tt.value), tt.value)
+	}
+}
+
+func TestNewOfKind_Normalize(t *testing.T) {
+	tests := []struct {
+		value string
+		kind  Kind
+		want  string
+	}{
+		{value: "example.com/a", kind: KindURL, want: "https://example.com/a"},
+		{value: "www.example.com", kind: KindAuto, want: "https://www.example.com"},
+		{value: "mailto:User@Example.COM", kind: KindContact, want: "User@example.com"},
+		{value: "+7 (999) 123-45-67", kind: KindContact, want: "+79991234567"},
+		{value: "ls -la", kind: KindCode, want: "ls -la"},
+	}
+
+	for _, tt := range tests {
+		bookmark, err := NewBookmarkOfKind("title", tt.value, tt.kind)
+		require.NoError(t, err, tt.value)
+		require.Equal(t, tt.want, bookmark.Value)
+	}
+}
+
+func TestNewOfKind_Error(t *testing.T) {
+	tests := []struct {
+		value string
+		kind  Kind
+		err   error
+	}{
+		{value: "not an url", kind: KindURL, err: ErrInvalidValue},
+		{value: "mailto:nobody", kind: KindContact, err: ErrInvalidValue},
+		{value: "call me", kind: KindContact, err: ErrInvalidValue},
+		{value: "value", kind: Kind("image"), err: ErrInvalidKind},
+	}
+
+	for _, tt := range tests {
+		_, err := NewBookmarkOfKind("title", tt.value, tt.kind)
+		require.ErrorIs(t, err, tt.err, tt.value)
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MetadataStatus is the outcome of the last attempt to fetch link metadata.
+type MetadataStatus string
+
+const (
+	MetadataOK         MetadataStatus = "ok"
+	MetadataFailed     MetadataStatus = "failed"
+	MetadataDisallowed MetadataStatus = "disallowed" // robots.txt forbids fetching
+)
+
+// Metadata describes the page an URL bookmark points to.
+type Metadata struct {
+	Uuid         uuid.UUID // bookmark uuid
+	Title        string
+	Description  string
+	CanonicalURL string
+	Favicon      string
+	Image        string // Open Graph image
+	Status       MetadataStatus
+	Error        string
+	Attempts     int
+	FetchedAt    time.Time
+}
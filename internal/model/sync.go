@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tombstone marks a deleted bookmark for the clients syncing it.
+type Tombstone struct {
+	Uuid      uuid.UUID
+	Version   int64
+	DeletedAt time.Time
+}
+
+// ChangeSet is a page of the bookmark writes after a version, in version order:
+// the bookmarks as stored now and the tombstones of the deleted ones.
+type ChangeSet struct {
+	Bookmarks  []Bookmark
+	Tombstones []Tombstone
+	Version    int64 // the changes are complete up to this version
+	More       bool  // the page was cut, the changes after Version follow
+}
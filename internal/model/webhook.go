@@ -0,0 +1,102 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is a subscription of an external endpoint to bookmark events.
+type Webhook struct {
+	ID         uuid.UUID
+	URL        string
+	Events     []EventType // empty means every event
+	Secret     string      // signs the deliveries
+	Active     bool
+	Failures   int       // consecutive failed deliveries
+	DisabledAt time.Time // zero unless disabled after repeated failure
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// WebhookDelivery is one attempt to deliver an event to a webhook.
+type WebhookDelivery struct {
+	ID          int64
+	WebhookID   uuid.UUID
+	EventID     int64
+	EventType   EventType
+	Attempt     int
+	Success     bool
+	StatusCode  int // zero when no response was received
+	Error       string
+	Duration    time.Duration
+	DeliveredAt time.Time
+}
+
+// NewWebhook creates an active webhook, a secret is generated when none is given.
+func NewWebhook(target string, events []EventType, secret string) (Webhook, error) {
+	const op = "model.webhook.New"
+
+	webhook := Webhook{Active: true, CreatedAt: time.Now()}
+	webhook.UpdatedAt = webhook.CreatedAt
+
+	if err := webhook.Configure(target, events, secret); err != nil {
+		return Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret = newSecret()
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	webhook.ID = id
+
+	return webhook, nil
+}
+
+// Configure validates and sets the target, the events and, when not empty, the secret.
+func (w *Webhook) Configure(target string, events []EventType, secret string) error {
+	target = strings.TrimSpace(target)
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidWebhookURL, target)
+	}
+
+	for _, event := range events {
+		if _, err := ParseEventType(string(event)); err != nil {
+			return err
+		}
+	}
+
+	w.URL = target
+	w.Events = slices.Compact(slices.Sorted(slices.Values(events)))
+
+	if secret != "" {
+		w.Secret = secret
+	}
+
+	return nil
+}
+
+// Subscribed reports whether the webhook receives events of the type.
+func (w Webhook) Subscribed(eventType EventType) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, eventType)
+}
+
+func newSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b) // never fails
+
+	return hex.EncodeToString(b)
+}
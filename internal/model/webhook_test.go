@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhook(t *testing.T) {
+	webhook, err := NewWebhook(" https://example.com/hook ", []EventType{BookmarkDeleted, BookmarkAppended, BookmarkDeleted}, "")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/hook", webhook.URL)
+	require.Equal(t, []EventType{BookmarkAppended, BookmarkDeleted}, webhook.Events)
+	require.Len(t, webhook.Secret, 64)
+	require.True(t, webhook.Active)
+
+	require.True(t, webhook.Subscribed(BookmarkAppended))
+	require.False(t, webhook.Subscribed(BookmarkChanged))
+
+	webhook.Events = nil
+	require.True(t, webhook.Subscribed(BookmarkChanged))
+
+	for _, target := range []string{"", "example.com/hook", "ftp://example.com", "https://"} {
+		_, err := NewWebhook(target, nil, "")
+		require.ErrorIs(t, err, ErrInvalidWebhookURL, target)
+	}
+
+	_, err = NewWebhook("https://example.com/hook", []EventType{"bookmark.viewed"}, "")
+	require.ErrorIs(t, err, ErrInvalidEventType)
+}
@@ -0,0 +1,113 @@
+package account
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/storage"
+)
+
+type Storage interface {
+	CreateUser(record storage.User) error
+	GetUser(name string) (storage.User, error)
+	CreateToken(record storage.Token) error
+	FindToken(hash string) (storage.Token, storage.User, error)
+}
+
+type repository struct {
+	storage Storage
+}
+
+func NewRepository(s Storage) *repository {
+	return &repository{storage: s}
+}
+
+func (r *repository) CreateUser(user model.User) error {
+	const op = "repository.account.CreateUser"
+
+	err := r.storage.CreateUser(storage.User{
+		ID:        user.ID.String(),
+		Name:      user.Name,
+		Admin:     user.Admin,
+		CreatedAt: user.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetUser(name string) (model.User, error) {
+	const op = "repository.account.GetUser"
+
+	record, err := r.storage.GetUser(name)
+	if err != nil {
+		return model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := castUser(record)
+	if err != nil {
+		return model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (r *repository) CreateToken(token model.Token) error {
+	const op = "repository.account.CreateToken"
+
+	err := r.storage.CreateToken(storage.Token{
+		ID:        token.ID.String(),
+		UserID:    token.UserID.String(),
+		Name:      token.Name,
+		Hash:      token.Hash,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FindToken returns the token of the hash with its user.
+func (r *repository) FindToken(hash string) (model.Token, model.User, error) {
+	const op = "repository.account.FindToken"
+
+	record, owner, err := r.storage.FindToken(hash)
+	if err != nil {
+		return model.Token{}, model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := castUser(owner)
+	if err != nil {
+		return model.Token{}, model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := uuid.Parse(record.ID)
+	if err != nil {
+		return model.Token{}, model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return model.Token{
+		ID:        id,
+		UserID:    user.ID,
+		Name:      record.Name,
+		Hash:      record.Hash,
+		CreatedAt: record.CreatedAt,
+		ExpiresAt: record.ExpiresAt,
+	}, user, nil
+}
+
+func castUser(r storage.User) (model.User, error) {
+	id, err := uuid.Parse(r.ID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return model.User{ID: id, Name: r.Name, Admin: r.Admin, CreatedAt: r.CreatedAt}, nil
+}
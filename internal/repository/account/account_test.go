@@ -0,0 +1,85 @@
+package account
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/internal/storage/sqlite"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func TestAccount_Tokens(t *testing.T) {
+	for _, repo := range makeRepositoryProvider(t) {
+		user, err := model.NewUser("alice", true)
+		require.NoError(t, err)
+		require.NoError(t, repo.CreateUser(user))
+
+		taken, err := model.NewUser("alice", false)
+		require.NoError(t, err)
+		require.ErrorIs(t, repo.CreateUser(taken), core.ErrExists)
+
+		stored, err := repo.GetUser("alice")
+		require.NoError(t, err)
+		require.Equal(t, user.ID, stored.ID)
+		require.True(t, stored.Admin)
+
+		_, err = repo.GetUser("bob")
+		require.ErrorIs(t, err, core.ErrNotFound)
+
+		token, secret, err := model.NewToken(user, "ci", time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, repo.CreateToken(token))
+
+		found, owner, err := repo.FindToken(model.HashToken(secret))
+		require.NoError(t, err)
+		require.Equal(t, token.ID, found.ID)
+		require.Equal(t, "ci", found.Name)
+		require.WithinDuration(t, token.ExpiresAt, found.ExpiresAt, time.Millisecond)
+		require.Equal(t, "alice", owner.Name)
+		require.True(t, owner.Admin)
+
+		_, _, err = repo.FindToken(model.HashToken("bmt_unknown"))
+		require.ErrorIs(t, err, core.ErrNotFound)
+
+		// a token of an unknown user is refused
+		bob, err := model.NewUser("bob", false)
+		require.NoError(t, err)
+		orphan, _, err := model.NewToken(bob, "", 0)
+		require.NoError(t, err)
+		require.ErrorIs(t, repo.CreateToken(orphan), core.ErrNotFound)
+	}
+}
+
+func makeRepositoryProvider(t *testing.T) []*repository {
+	t.Helper()
+
+	var provider []*repository
+
+	// memory storage
+	provider = append(provider, NewRepository(memory.NewAccountStorage()))
+
+	// sqlite storage
+	dbSourceName := "../../../storage/test_account.db"
+	_ = os.Remove(dbSourceName)
+
+	driver, err := pkgsql.New(pkgsql.SourceName(dbSourceName))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = driver.DB.Close()
+		_ = os.Remove(dbSourceName)
+	})
+
+	storage, err := sqlite.NewAccount(driver)
+	require.NoError(t, err)
+
+	provider = append(provider, NewRepository(storage))
+
+	return provider
+}
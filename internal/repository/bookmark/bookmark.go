@@ -1,21 +1,44 @@
 package bookmark
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 
+	"bookmarks/internal/logger"
 	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
 	"bookmarks/internal/storage"
+	"bookmarks/internal/tracing"
 )
 
 type Storage interface {
-	Create(uuid uuid.UUID, title, val string, time time.Time) (storage.Bookmark, error)
-	Update(uuid uuid.UUID, title string) (storage.Bookmark, error)
-	GetByUUID(uuid uuid.UUID) (storage.Bookmark, error)
-	GetByValue(val string) (storage.Bookmark, error)
-	Delete(uuid uuid.UUID) error
+	Create(ctx context.Context, record storage.Bookmark, mode core.OnConflict, emit core.EmitRecord) (storage.Bookmark, error)
+	Update(ctx context.Context, record storage.Bookmark, version int64, emit core.EmitRecord) (storage.Bookmark, error)
+	GetByUUID(ctx context.Context, uuid uuid.UUID) (storage.Bookmark, error)
+	GetByValue(ctx context.Context, val string) (storage.Bookmark, error)
+	List(ctx context.Context, filter core.Filter) ([]storage.Bookmark, error)
+	Delete(ctx context.Context, uuid uuid.UUID, version int64, emit core.EmitRecord) error
+	UpdateAutoTitle(ctx context.Context, uuid uuid.UUID, title string, emit core.EmitRecord) error
+	SaveMetadata(ctx context.Context, metadata storage.Metadata) error
+	GetMetadata(ctx context.Context, uuid uuid.UUID) (storage.Metadata, error)
+	ListMetadata(ctx context.Context, uuids []uuid.UUID) ([]storage.Metadata, error)
+	DueForCheck(ctx context.Context, before time.Time, limit int) ([]storage.Bookmark, error)
+	SaveLinkCheck(ctx context.Context, check storage.LinkCheck, fold core.FoldHealth, keep int) error
+	GetLinkHealth(ctx context.Context, uuid uuid.UUID) (storage.LinkHealth, error)
+	ListLinkChecks(ctx context.Context, uuid uuid.UUID, limit int) ([]storage.LinkCheck, error)
+	ListLinkHealth(ctx context.Context, uuids []uuid.UUID) ([]storage.LinkHealth, error)
+	ListRecentLinkChecks(ctx context.Context, uuids []uuid.UUID, limit int) ([]storage.LinkCheck, error)
+	SaveArchive(ctx context.Context, archive storage.Archive) error
+	GetArchive(ctx context.Context, uuid uuid.UUID) (storage.Archive, error)
+	PendingEvents(ctx context.Context, after int64, limit int) ([]storage.Event, error)
+	MarkDispatched(ctx context.Context, ids []int64, at time.Time) error
+	FailEvent(ctx context.Context, id int64, reason string, maxAttempts int, at time.Time) (bool, error)
+	PurgeEvents(ctx context.Context, before time.Time) (int, error)
+	Changes(ctx context.Context, since int64, limit int) (storage.ChangeSet, error)
 }
 
 type repository struct {
@@ -26,15 +49,13 @@ func NewRepository(s Storage) *repository {
 	return &repository{storage: s}
 }
 
-func (r *repository) Create(bookmark model.Bookmark) (model.Bookmark, error) {
+func (r *repository) Create(ctx context.Context, bookmark model.Bookmark) (_ model.Bookmark, err error) {
 	const op = "repository.bookmark.Create"
 
-	_, err := r.storage.Create(
-		bookmark.Uuid,
-		bookmark.Title,
-		bookmark.Value,
-		bookmark.CreatedAt,
-	)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	_, err = r.storage.Create(ctx, castToStorage(bookmark), core.OnConflictError, nil)
 	if err != nil {
 		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -42,14 +63,72 @@ func (r *repository) Create(bookmark model.Bookmark) (model.Bookmark, error) {
 	return bookmark, nil
 }
 
-func (r *repository) Update(bookmark model.Bookmark) (model.Bookmark, error) {
-	return bookmark, nil
+// Append stores the bookmark, resolving a collision on value according to mode.
+// When the value is taken, the stored bookmark is returned (along with ErrExists for OnConflictError).
+// The events of emit are written to the outbox along with the change.
+func (r *repository) Append(ctx context.Context, bookmark model.Bookmark, mode core.OnConflict, emit core.Emit) (_ model.Bookmark, err error) {
+	const op = "repository.bookmark.Append"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	record, err := r.storage.Create(ctx, castToStorage(bookmark), mode, emitRecord(emit))
+	if err != nil && record.Uuid == "" {
+		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	entity, castErr := castToModel(record)
+	if castErr != nil {
+		return model.Bookmark{}, fmt.Errorf("%s: %w", op, castErr)
+	}
+
+	if err != nil {
+		return entity, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if entity.Uuid != bookmark.Uuid {
+		logger.FromContext(ctx).Debug("value already bookmarked",
+			slog.String("op", op),
+			slog.String("uuid", entity.Uuid.String()),
+		)
+	}
+
+	return entity, nil
+}
+
+// Update replaces the bookmark if it is still at version, the events of emit are written to the outbox
+// along with the change. The current bookmark is returned with ErrConflict when it changed since,
+// the owner of the value with ErrExists when the value is taken.
+func (r *repository) Update(ctx context.Context, bookmark model.Bookmark, version int64, emit core.Emit) (_ model.Bookmark, err error) {
+	const op = "repository.bookmark.Update"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	record, err := r.storage.Update(ctx, castToStorage(bookmark), version, emitRecord(emit))
+	if err != nil && record.Uuid == "" {
+		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	entity, castErr := castToModel(record)
+	if castErr != nil {
+		return model.Bookmark{}, fmt.Errorf("%s: %w", op, castErr)
+	}
+
+	if err != nil {
+		return entity, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entity, nil
 }
 
-func (r *repository) GetByUUID(uuid uuid.UUID) (model.Bookmark, error) {
+func (r *repository) GetByUUID(ctx context.Context, uuid uuid.UUID) (_ model.Bookmark, err error) {
 	const op = "repository.bookmark.GetByUUID"
 
-	record, err := r.storage.GetByUUID(uuid)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	record, err := r.storage.GetByUUID(ctx, uuid)
 	if err != nil {
 		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -57,10 +136,13 @@ func (r *repository) GetByUUID(uuid uuid.UUID) (model.Bookmark, error) {
 	return castToModel(record)
 }
 
-func (r *repository) GetByValue(val string) (model.Bookmark, error) {
+func (r *repository) GetByValue(ctx context.Context, val string) (_ model.Bookmark, err error) {
 	const op = "repository.bookmark.GetByValue"
 
-	record, err := r.storage.GetByValue(val)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	record, err := r.storage.GetByValue(ctx, val)
 	if err != nil {
 		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -68,16 +150,363 @@ func (r *repository) GetByValue(val string) (model.Bookmark, error) {
 	return castToModel(record)
 }
 
-func (r *repository) Delete(uuid uuid.UUID) error {
+func (r *repository) List(ctx context.Context, filter core.Filter) (_ []model.Bookmark, err error) {
+	const op = "repository.bookmark.List"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	records, err := r.storage.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bookmarks := make([]model.Bookmark, 0, len(records))
+	for _, record := range records {
+		bookmark, err := castToModel(record)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, nil
+}
+
+// Delete removes the bookmark, the events of emit are written to the outbox along with the change.
+// A positive version must match the bookmark, ErrConflict is returned otherwise.
+func (r *repository) Delete(ctx context.Context, uuid uuid.UUID, version int64, emit core.Emit) (err error) {
 	const op = "repository.bookmark.Delete"
 
-	if err := r.storage.Delete(uuid); err != nil {
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if err := r.storage.Delete(ctx, uuid, version, emitRecord(emit)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateAutoTitle replaces a title derived from the value, a title set by the user is kept.
+// The events of emit are written to the outbox along with a replaced title.
+func (r *repository) UpdateAutoTitle(ctx context.Context, uuid uuid.UUID, title string, emit core.Emit) (err error) {
+	const op = "repository.bookmark.UpdateAutoTitle"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if err := r.storage.UpdateAutoTitle(ctx, uuid, title, emitRecord(emit)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *repository) SaveMetadata(ctx context.Context, metadata model.Metadata) (err error) {
+	const op = "repository.bookmark.SaveMetadata"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	err = r.storage.SaveMetadata(ctx, storage.Metadata{
+		Uuid:         metadata.Uuid.String(),
+		Title:        metadata.Title,
+		Description:  metadata.Description,
+		CanonicalURL: metadata.CanonicalURL,
+		Favicon:      metadata.Favicon,
+		Image:        metadata.Image,
+		Status:       string(metadata.Status),
+		Error:        metadata.Error,
+		Attempts:     metadata.Attempts,
+		FetchedAt:    metadata.FetchedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetMetadata(ctx context.Context, uuid uuid.UUID) (_ model.Metadata, err error) {
+	const op = "repository.bookmark.GetMetadata"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	record, err := r.storage.GetMetadata(ctx, uuid)
+	if err != nil {
+		return model.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return castMetadataToModel(uuid, record), nil
+}
+
+// ListMetadata returns the metadata of the given bookmarks by uuid, bookmarks without it are missing.
+func (r *repository) ListMetadata(ctx context.Context, uuids []uuid.UUID) (_ map[uuid.UUID]model.Metadata, err error) {
+	const op = "repository.bookmark.ListMetadata"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	records, err := r.storage.ListMetadata(ctx, uuids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	metadata := make(map[uuid.UUID]model.Metadata, len(records))
+	for _, record := range records {
+		id, err := uuid.Parse(record.Uuid)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		metadata[id] = castMetadataToModel(id, record)
+	}
+
+	return metadata, nil
+}
+
+// DueForCheck returns URL bookmarks whose link was not checked since the given time.
+func (r *repository) DueForCheck(ctx context.Context, before time.Time, limit int) (_ []model.Bookmark, err error) {
+	const op = "repository.bookmark.DueForCheck"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	records, err := r.storage.DueForCheck(ctx, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bookmarks := make([]model.Bookmark, 0, len(records))
+	for _, record := range records {
+		bookmark, err := castToModel(record)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, nil
+}
+
+// RecordLinkCheck stores the check and the link health it results in,
+// the history is trimmed to the last keep checks.
+func (r *repository) RecordLinkCheck(ctx context.Context, check model.LinkCheck, threshold, keep int) (_ model.LinkHealth, err error) {
+	const op = "repository.bookmark.RecordLinkCheck"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	var health model.LinkHealth
+
+	err = r.storage.SaveLinkCheck(ctx,
+		storage.LinkCheck{
+			Uuid:       check.Uuid.String(),
+			OK:         check.OK,
+			StatusCode: check.StatusCode,
+			RedirectTo: check.RedirectTo,
+			Latency:    check.Latency,
+			Error:      check.Error,
+			CheckedAt:  check.CheckedAt,
+		},
+		func(current *storage.LinkHealth) storage.LinkHealth {
+			var before model.LinkHealth
+			if current != nil {
+				before = castHealthToModel(check.Uuid, *current)
+			}
+
+			health = before.Record(check, threshold)
+
+			return storage.LinkHealth{
+				Uuid:                health.Uuid.String(),
+				Status:              string(health.Status),
+				StatusCode:          health.StatusCode,
+				RedirectTo:          health.RedirectTo,
+				Latency:             health.Latency,
+				Error:               health.Error,
+				ConsecutiveFailures: health.ConsecutiveFailures,
+				CheckedAt:           health.CheckedAt,
+			}
+		},
+		keep,
+	)
+	if err != nil {
+		return model.LinkHealth{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return health, nil
+}
+
+func (r *repository) GetLinkHealth(ctx context.Context, uuid uuid.UUID) (_ model.LinkHealth, err error) {
+	const op = "repository.bookmark.GetLinkHealth"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	record, err := r.storage.GetLinkHealth(ctx, uuid)
+	if err != nil {
+		return model.LinkHealth{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return castHealthToModel(uuid, record), nil
+}
+
+// ListLinkChecks returns the check history of a link, newest first.
+func (r *repository) ListLinkChecks(ctx context.Context, uuid uuid.UUID, limit int) (_ []model.LinkCheck, err error) {
+	const op = "repository.bookmark.ListLinkChecks"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	records, err := r.storage.ListLinkChecks(ctx, uuid, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	checks := make([]model.LinkCheck, 0, len(records))
+	for _, record := range records {
+		checks = append(checks, castCheckToModel(uuid, record))
+	}
+
+	return checks, nil
+}
+
+// ListLinkHealth returns the current health of the given links by uuid, links never checked are missing.
+func (r *repository) ListLinkHealth(ctx context.Context, uuids []uuid.UUID) (_ map[uuid.UUID]model.LinkHealth, err error) {
+	const op = "repository.bookmark.ListLinkHealth"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	records, err := r.storage.ListLinkHealth(ctx, uuids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	health := make(map[uuid.UUID]model.LinkHealth, len(records))
+	for _, record := range records {
+		id, err := uuid.Parse(record.Uuid)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		health[id] = castHealthToModel(id, record)
+	}
+
+	return health, nil
+}
+
+// ListRecentLinkChecks returns the last limit checks of each of the given links by uuid, newest first.
+func (r *repository) ListRecentLinkChecks(ctx context.Context, uuids []uuid.UUID, limit int) (_ map[uuid.UUID][]model.LinkCheck, err error) {
+	const op = "repository.bookmark.ListRecentLinkChecks"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	records, err := r.storage.ListRecentLinkChecks(ctx, uuids, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	checks := make(map[uuid.UUID][]model.LinkCheck, len(uuids))
+	for _, record := range records {
+		id, err := uuid.Parse(record.Uuid)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		checks[id] = append(checks[id], castCheckToModel(id, record))
+	}
+
+	return checks, nil
+}
+
+func (r *repository) SaveArchive(ctx context.Context, archive model.Archive) (err error) {
+	const op = "repository.bookmark.SaveArchive"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	err = r.storage.SaveArchive(ctx, storage.Archive{
+		Uuid:       archive.Uuid.String(),
+		Digest:     archive.Digest,
+		Size:       archive.Size,
+		Resources:  archive.Resources,
+		SourceURL:  archive.SourceURL,
+		ArchivedAt: archive.ArchivedAt,
+	})
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
+func (r *repository) GetArchive(ctx context.Context, uuid uuid.UUID) (_ model.Archive, err error) {
+	const op = "repository.bookmark.GetArchive"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	record, err := r.storage.GetArchive(ctx, uuid)
+	if err != nil {
+		return model.Archive{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return model.Archive{
+		Uuid:       uuid,
+		Digest:     record.Digest,
+		Size:       record.Size,
+		Resources:  record.Resources,
+		SourceURL:  record.SourceURL,
+		ArchivedAt: record.ArchivedAt,
+	}, nil
+}
+
+func castMetadataToModel(uuid uuid.UUID, r storage.Metadata) model.Metadata {
+	return model.Metadata{
+		Uuid:         uuid,
+		Title:        r.Title,
+		Description:  r.Description,
+		CanonicalURL: r.CanonicalURL,
+		Favicon:      r.Favicon,
+		Image:        r.Image,
+		Status:       model.MetadataStatus(r.Status),
+		Error:        r.Error,
+		Attempts:     r.Attempts,
+		FetchedAt:    r.FetchedAt,
+	}
+}
+
+func castCheckToModel(uuid uuid.UUID, r storage.LinkCheck) model.LinkCheck {
+	return model.LinkCheck{
+		Uuid:       uuid,
+		OK:         r.OK,
+		StatusCode: r.StatusCode,
+		RedirectTo: r.RedirectTo,
+		Latency:    r.Latency,
+		Error:      r.Error,
+		CheckedAt:  r.CheckedAt,
+	}
+}
+
+func castHealthToModel(uuid uuid.UUID, r storage.LinkHealth) model.LinkHealth {
+	return model.LinkHealth{
+		Uuid:                uuid,
+		Status:              model.HealthStatus(r.Status),
+		StatusCode:          r.StatusCode,
+		RedirectTo:          r.RedirectTo,
+		Latency:             r.Latency,
+		Error:               r.Error,
+		ConsecutiveFailures: r.ConsecutiveFailures,
+		CheckedAt:           r.CheckedAt,
+	}
+}
+
 func castToModel(r storage.Bookmark) (model.Bookmark, error) {
 	const op = "repository.bookmark.castModel"
 
@@ -87,9 +516,26 @@ func castToModel(r storage.Bookmark) (model.Bookmark, error) {
 	}
 
 	return model.Bookmark{
-		Uuid:      uuid,
-		Title:     r.Title,
-		Value:     r.Value,
-		CreatedAt: r.CreatedAt,
+		Uuid:           uuid,
+		Title:          r.Title,
+		TitleAuto:      r.TitleAuto,
+		Value:          r.Value,
+		Kind:           model.Kind(r.Kind),
+		CanonicalValue: r.CanonicalValue,
+		CreatedAt:      r.CreatedAt,
+		Version:        r.Version,
 	}, nil
 }
+
+func castToStorage(b model.Bookmark) storage.Bookmark {
+	return storage.Bookmark{
+		Uuid:           b.Uuid.String(),
+		Title:          b.Title,
+		TitleAuto:      b.TitleAuto,
+		Value:          b.Value,
+		Kind:           string(b.Kind),
+		CanonicalValue: b.CanonicalValue,
+		CreatedAt:      b.CreatedAt,
+		Version:        b.Version,
+	}
+}
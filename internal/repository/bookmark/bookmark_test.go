@@ -1,7 +1,9 @@
 package bookmark
 
 import (
+	"context"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -22,7 +24,7 @@ func TestCreate_Success(t *testing.T) {
 
 	bookmark := makeBookmark()
 
-	entity, err := repo.Create(bookmark)
+	entity, err := repo.Create(t.Context(), bookmark)
 	require.NoError(t, err)
 	require.Equal(t, bookmark.Title, entity.Title)
 	require.Equal(t, bookmark.Value, entity.Value)
@@ -33,16 +35,72 @@ func TestCreate_ErrExists(t *testing.T) {
 	bookmark := makeBookmark()
 
 	for _, repo := range makeRepositoryProvider(bookmark) {
-		_, err := repo.Create(bookmark)
+		_, err := repo.Create(t.Context(), bookmark)
 		require.ErrorIs(t, err, core.ErrExists)
 	}
 }
 
+func TestAppend_OnConflict(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		duplicate, err := model.NewBookmark(gofakeit.Word(), bookmark.Value)
+		require.NoError(t, err)
+
+		entity, err := repo.Append(t.Context(), duplicate, core.OnConflictError, nil)
+		require.ErrorIs(t, err, core.ErrExists)
+		require.Equal(t, bookmark.Uuid, entity.Uuid)
+		require.Equal(t, bookmark.Title, entity.Title)
+
+		entity, err = repo.Append(t.Context(), duplicate, core.OnConflictIgnore, nil)
+		require.NoError(t, err)
+		require.Equal(t, bookmark.Uuid, entity.Uuid)
+		require.Equal(t, bookmark.Title, entity.Title)
+
+		entity, err = repo.Append(t.Context(), duplicate, core.OnConflictUpdateTitle, nil)
+		require.NoError(t, err)
+		require.Equal(t, bookmark.Uuid, entity.Uuid)
+		require.Equal(t, duplicate.Title, entity.Title)
+
+		stored, err := repo.GetByValue(t.Context(), bookmark.Value)
+		require.NoError(t, err)
+		require.Equal(t, duplicate.Title, stored.Title)
+	}
+}
+
+func TestAppend_Created(t *testing.T) {
+	for _, repo := range makeRepositoryProvider(makeBookmark()) {
+		bookmark, err := model.NewBookmark(gofakeit.Word(), gofakeit.UUID())
+		require.NoError(t, err)
+
+		entity, err := repo.Append(t.Context(), bookmark, core.OnConflictUpdateTitle, nil)
+		require.NoError(t, err)
+		require.Equal(t, bookmark.Uuid, entity.Uuid)
+		require.Equal(t, bookmark.Value, entity.Value)
+	}
+}
+
+func TestAppend_CanonicalValue(t *testing.T) {
+	bookmark, err := model.NewBookmark("first", "https://Example.com/a/?utm_source=x")
+	require.NoError(t, err)
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		duplicate, err := model.NewBookmark("second", "https://example.com/a")
+		require.NoError(t, err)
+
+		entity, err := repo.Append(t.Context(), duplicate, core.OnConflictError, nil)
+		require.ErrorIs(t, err, core.ErrExists)
+		require.Equal(t, bookmark.Uuid, entity.Uuid)
+		require.Equal(t, bookmark.Value, entity.Value)
+		require.Equal(t, "https://example.com/a", entity.CanonicalValue)
+	}
+}
+
 func TestGetUUID_Success(t *testing.T) {
 	bookmark := makeBookmark()
 
 	for _, repo := range makeRepositoryProvider(bookmark) {
-		entity, err := repo.GetByUUID(bookmark.Uuid)
+		entity, err := repo.GetByUUID(t.Context(), bookmark.Uuid)
 		require.NoError(t, err)
 		require.Equal(t, bookmark.Title, entity.Title)
 		require.Equal(t, bookmark.Uuid, entity.Uuid)
@@ -54,7 +112,7 @@ func TestGetUUID_NotFound(t *testing.T) {
 
 	for _, repo := range makeRepositoryProvider(bookmark) {
 		uuid7, _ := uuid.NewV7()
-		_, err := repo.GetByUUID(uuid7)
+		_, err := repo.GetByUUID(t.Context(), uuid7)
 		require.Error(t, err)
 		require.ErrorIs(t, err, core.ErrNotFound)
 	}
@@ -64,7 +122,7 @@ func TestGetValue_Success(t *testing.T) {
 	bookmark := makeBookmark()
 
 	for _, repo := range makeRepositoryProvider(bookmark) {
-		entity, err := repo.GetByValue(bookmark.Value)
+		entity, err := repo.GetByValue(t.Context(), bookmark.Value)
 		require.NoError(t, err)
 		require.Equal(t, bookmark.Title, entity.Title)
 		require.Equal(t, bookmark.Uuid, entity.Uuid)
@@ -75,25 +133,281 @@ func TestGetValue_NotFound(t *testing.T) {
 	bookmark := makeBookmark()
 
 	for _, repo := range makeRepositoryProvider(bookmark) {
-		_, err := repo.GetByValue("not-found")
+		_, err := repo.GetByValue(t.Context(), "not-found")
 		require.Error(t, err)
 		require.ErrorIs(t, err, core.ErrNotFound)
 	}
 }
 
+func TestList_Success(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		code, err := model.NewBookmark(gofakeit.Word(), "git status --short")
+		require.NoError(t, err)
+
+		_, err = repo.Create(t.Context(), code)
+		require.NoError(t, err)
+
+		entities, err := repo.List(t.Context(), core.Filter{})
+		require.NoError(t, err)
+		require.Len(t, entities, 2)
+		require.Equal(t, code.Uuid, entities[0].Uuid)
+		require.Equal(t, model.KindCode, entities[0].Kind)
+
+		entities, err = repo.List(t.Context(), core.Filter{Kind: string(model.KindCode)})
+		require.NoError(t, err)
+		require.Len(t, entities, 1)
+		require.Equal(t, code.Uuid, entities[0].Uuid)
+
+		entities, err = repo.List(t.Context(), core.Filter{Limit: 1, Offset: 1})
+		require.NoError(t, err)
+		require.Len(t, entities, 1)
+		require.Equal(t, bookmark.Uuid, entities[0].Uuid)
+	}
+}
+
+func TestMetadata_Success(t *testing.T) {
+	bookmark, err := model.NewBookmark("", "https://example.com/"+gofakeit.Word())
+	require.NoError(t, err)
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		_, err := repo.GetMetadata(t.Context(), bookmark.Uuid)
+		require.ErrorIs(t, err, core.ErrNotFound)
+
+		err = repo.SaveMetadata(t.Context(), model.Metadata{
+			Uuid:      bookmark.Uuid,
+			Title:     "Example",
+			Status:    model.MetadataOK,
+			Attempts:  1,
+			FetchedAt: time.Now(),
+		})
+		require.NoError(t, err)
+
+		metadata, err := repo.GetMetadata(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, "Example", metadata.Title)
+		require.Equal(t, model.MetadataOK, metadata.Status)
+
+		require.NoError(t, repo.UpdateAutoTitle(t.Context(), bookmark.Uuid, metadata.Title, emitChange))
+
+		entity, err := repo.GetByUUID(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, "Example", entity.Title)
+		require.True(t, entity.TitleAuto)
+
+		events, err := repo.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, model.BookmarkChanged, events[0].Type)
+		require.Equal(t, "Example", events[0].Bookmark.Title)
+
+		uuid7, _ := uuid.NewV7()
+		err = repo.SaveMetadata(t.Context(), model.Metadata{Uuid: uuid7, Status: model.MetadataOK})
+		require.ErrorIs(t, err, core.ErrNotFound)
+	}
+}
+
+func TestUpdateAutoTitle_KeepUserTitle(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		require.NoError(t, repo.UpdateAutoTitle(t.Context(), bookmark.Uuid, "fetched", emitChange))
+
+		entity, err := repo.GetByUUID(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, bookmark.Title, entity.Title)
+
+		// a kept title is not a change
+		events, err := repo.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, events)
+
+		uuid7, _ := uuid.NewV7()
+		require.ErrorIs(t, repo.UpdateAutoTitle(t.Context(), uuid7, "fetched", emitChange), core.ErrNotFound)
+	}
+}
+
+func TestLinkCheck_Broken(t *testing.T) {
+	bookmark, err := model.NewBookmark("", "https://example.com/"+gofakeit.Word())
+	require.NoError(t, err)
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		_, err := repo.GetLinkHealth(t.Context(), bookmark.Uuid)
+		require.ErrorIs(t, err, core.ErrNotFound)
+
+		due, err := repo.DueForCheck(t.Context(), time.Now(), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		require.Equal(t, bookmark.Uuid, due[0].Uuid)
+
+		checkedAt := time.Now()
+		for i, ok := range []bool{true, false, false, false} {
+			check := model.LinkCheck{
+				Uuid:       bookmark.Uuid,
+				OK:         ok,
+				StatusCode: 404,
+				Latency:    time.Duration(i) * time.Millisecond,
+				CheckedAt:  checkedAt.Add(time.Duration(i) * time.Second),
+			}
+
+			_, err := repo.RecordLinkCheck(t.Context(), check, 2, 3)
+			require.NoError(t, err)
+		}
+
+		health, err := repo.GetLinkHealth(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, model.HealthBroken, health.Status)
+		require.Equal(t, 3, health.ConsecutiveFailures)
+		require.Equal(t, 3*time.Millisecond, health.Latency)
+
+		checks, err := repo.ListLinkChecks(t.Context(), bookmark.Uuid, 0)
+		require.NoError(t, err)
+		require.Len(t, checks, 3)
+		require.Equal(t, 3*time.Millisecond, checks[0].Latency)
+		require.False(t, checks[2].OK)
+
+		due, err = repo.DueForCheck(t.Context(), checkedAt, 10)
+		require.NoError(t, err)
+		require.Empty(t, due)
+
+		broken, err := repo.List(t.Context(), core.Filter{Health: string(model.HealthBroken)})
+		require.NoError(t, err)
+		require.Len(t, broken, 1)
+
+		healthy, err := repo.List(t.Context(), core.Filter{Health: string(model.HealthOK)})
+		require.NoError(t, err)
+		require.Empty(t, healthy)
+
+		uuid7, _ := uuid.NewV7()
+		_, err = repo.RecordLinkCheck(t.Context(), model.LinkCheck{Uuid: uuid7, OK: true}, 2, 3)
+		require.ErrorIs(t, err, core.ErrNotFound)
+	}
+}
+
+func TestLinkCheck_Concurrent(t *testing.T) {
+	bookmark, err := model.NewBookmark("", "https://example.com/"+gofakeit.Word())
+	require.NoError(t, err)
+
+	const checks = 32
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		var wg sync.WaitGroup
+		for range checks {
+			wg.Go(func() {
+				check := model.LinkCheck{Uuid: bookmark.Uuid, StatusCode: 503, CheckedAt: time.Now()}
+
+				_, err := repo.RecordLinkCheck(t.Context(), check, checks, 0)
+				require.NoError(t, err)
+			})
+		}
+		wg.Wait()
+
+		// no failure is lost, the last one breaks the link
+		health, err := repo.GetLinkHealth(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, checks, health.ConsecutiveFailures)
+		require.Equal(t, model.HealthBroken, health.Status)
+	}
+}
+
+func TestBatch_Success(t *testing.T) {
+	bookmark, err := model.NewBookmark("", "https://example.com/"+gofakeit.Word())
+	require.NoError(t, err)
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		other, err := model.NewBookmark("other", "https://example.org/"+gofakeit.Word())
+		require.NoError(t, err)
+		other, err = repo.Append(t.Context(), other, core.OnConflictError, nil)
+		require.NoError(t, err)
+
+		uuids := []uuid.UUID{bookmark.Uuid, other.Uuid}
+
+		metadata, err := repo.ListMetadata(t.Context(), uuids)
+		require.NoError(t, err)
+		require.Empty(t, metadata)
+
+		require.NoError(t, repo.SaveMetadata(t.Context(), model.Metadata{Uuid: other.Uuid, Title: "Other", Status: model.MetadataOK}))
+
+		checkedAt := time.Now()
+		for i := range 3 {
+			check := model.LinkCheck{Uuid: bookmark.Uuid, OK: i%2 == 0, CheckedAt: checkedAt.Add(time.Duration(i) * time.Second)}
+			_, err := repo.RecordLinkCheck(t.Context(), check, 2, 0)
+			require.NoError(t, err)
+		}
+
+		metadata, err = repo.ListMetadata(t.Context(), uuids)
+		require.NoError(t, err)
+		require.Len(t, metadata, 1)
+		require.Equal(t, "Other", metadata[other.Uuid].Title)
+
+		health, err := repo.ListLinkHealth(t.Context(), uuids)
+		require.NoError(t, err)
+		require.Len(t, health, 1)
+		require.Equal(t, model.HealthOK, health[bookmark.Uuid].Status)
+
+		checks, err := repo.ListRecentLinkChecks(t.Context(), uuids, 2)
+		require.NoError(t, err)
+		require.Len(t, checks, 1)
+		require.Len(t, checks[bookmark.Uuid], 2)
+		require.True(t, checks[bookmark.Uuid][0].OK, "newest first")
+		require.False(t, checks[bookmark.Uuid][1].OK)
+
+		checks, err = repo.ListRecentLinkChecks(t.Context(), uuids, 0)
+		require.NoError(t, err)
+		require.Len(t, checks[bookmark.Uuid], 3)
+	}
+}
+
+func TestArchive_Success(t *testing.T) {
+	bookmark, err := model.NewBookmark("", "https://example.com/"+gofakeit.Word())
+	require.NoError(t, err)
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		_, err := repo.GetArchive(t.Context(), bookmark.Uuid)
+		require.ErrorIs(t, err, core.ErrNotFound)
+
+		for _, digest := range []string{"sha256:first", "sha256:second"} {
+			err = repo.SaveArchive(t.Context(), model.Archive{
+				Uuid:       bookmark.Uuid,
+				Digest:     digest,
+				Size:       42,
+				Resources:  3,
+				SourceURL:  bookmark.Value,
+				ArchivedAt: time.Now(),
+			})
+			require.NoError(t, err)
+		}
+
+		archive, err := repo.GetArchive(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, "sha256:second", archive.Digest)
+		require.Equal(t, int64(42), archive.Size)
+		require.Equal(t, 3, archive.Resources)
+
+		require.NoError(t, repo.Delete(t.Context(), bookmark.Uuid, 0, nil))
+
+		_, err = repo.GetArchive(t.Context(), bookmark.Uuid)
+		require.ErrorIs(t, err, core.ErrNotFound)
+
+		err = repo.SaveArchive(t.Context(), model.Archive{Uuid: bookmark.Uuid, Digest: "sha256:third"})
+		require.ErrorIs(t, err, core.ErrNotFound)
+	}
+}
+
 func TestDelete_Success(t *testing.T) {
 	bookmark := makeBookmark()
 
 	var err error
 	for _, repo := range makeRepositoryProvider(bookmark) {
-		err = repo.Delete(bookmark.Uuid)
+		err = repo.Delete(t.Context(), bookmark.Uuid, 0, nil)
 		require.NoError(t, err)
 
-		_, err = repo.GetByUUID(bookmark.Uuid)
+		_, err = repo.GetByUUID(t.Context(), bookmark.Uuid)
 		require.Error(t, err)
 		require.ErrorIs(t, err, core.ErrNotFound)
 
-		_, err = repo.GetByValue(bookmark.Value)
+		_, err = repo.GetByValue(t.Context(), bookmark.Value)
 		require.Error(t, err)
 		require.ErrorIs(t, err, core.ErrNotFound)
 	}
@@ -104,7 +418,7 @@ func TestDelete_NotFound(t *testing.T) {
 
 	for _, repo := range makeRepositoryProvider(bookmark) {
 		uuid7, _ := uuid.NewV7()
-		err := repo.Delete(uuid7)
+		err := repo.Delete(t.Context(), uuid7, 0, nil)
 		require.Error(t, err)
 		require.ErrorIs(t, err, core.ErrNotFound)
 	}
@@ -124,7 +438,7 @@ func makeRepositoryProvider(bookmark model.Bookmark) []*repository {
 
 	// memory storage
 	repo := NewRepository(memory.NewBookmarkStorage())
-	_, _ = repo.Create(bookmark)
+	_, _ = repo.Create(context.Background(), bookmark)
 	provider = append(provider, repo)
 
 	// sqlite storage
@@ -141,7 +455,7 @@ func makeRepositoryProvider(bookmark model.Bookmark) []*repository {
 	}
 
 	repo = NewRepository(storage)
-	_, _ = repo.Create(bookmark)
+	_, _ = repo.Create(context.Background(), bookmark)
 	provider = append(provider, repo)
 
 	return provider
@@ -0,0 +1,146 @@
+package bookmark
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// Observer records the latency and the outcome of a storage call, see metrics.Metrics.
+type Observer interface {
+	ObserveStorage(method string, duration time.Duration, err error)
+}
+
+// instrumented passes the calls to the storage, observing each one by the method name.
+type instrumented struct {
+	next     Storage
+	observer Observer
+}
+
+// Instrument returns the storage with every call observed.
+func Instrument(s Storage, o Observer) Storage {
+	return &instrumented{next: s, observer: o}
+}
+
+func (s *instrumented) observe(method string, start time.Time, err *error) {
+	s.observer.ObserveStorage(method, time.Since(start), *err)
+}
+
+func (s *instrumented) Create(ctx context.Context, record storage.Bookmark, mode core.OnConflict, emit core.EmitRecord) (_ storage.Bookmark, err error) {
+	defer s.observe("Create", time.Now(), &err)
+	return s.next.Create(ctx, record, mode, emit)
+}
+
+func (s *instrumented) Update(ctx context.Context, record storage.Bookmark, version int64, emit core.EmitRecord) (_ storage.Bookmark, err error) {
+	defer s.observe("Update", time.Now(), &err)
+	return s.next.Update(ctx, record, version, emit)
+}
+
+func (s *instrumented) GetByUUID(ctx context.Context, uuid uuid.UUID) (_ storage.Bookmark, err error) {
+	defer s.observe("GetByUUID", time.Now(), &err)
+	return s.next.GetByUUID(ctx, uuid)
+}
+
+func (s *instrumented) GetByValue(ctx context.Context, val string) (_ storage.Bookmark, err error) {
+	defer s.observe("GetByValue", time.Now(), &err)
+	return s.next.GetByValue(ctx, val)
+}
+
+func (s *instrumented) List(ctx context.Context, filter core.Filter) (_ []storage.Bookmark, err error) {
+	defer s.observe("List", time.Now(), &err)
+	return s.next.List(ctx, filter)
+}
+
+func (s *instrumented) Delete(ctx context.Context, uuid uuid.UUID, version int64, emit core.EmitRecord) (err error) {
+	defer s.observe("Delete", time.Now(), &err)
+	return s.next.Delete(ctx, uuid, version, emit)
+}
+
+func (s *instrumented) UpdateAutoTitle(ctx context.Context, uuid uuid.UUID, title string, emit core.EmitRecord) (err error) {
+	defer s.observe("UpdateAutoTitle", time.Now(), &err)
+	return s.next.UpdateAutoTitle(ctx, uuid, title, emit)
+}
+
+func (s *instrumented) SaveMetadata(ctx context.Context, metadata storage.Metadata) (err error) {
+	defer s.observe("SaveMetadata", time.Now(), &err)
+	return s.next.SaveMetadata(ctx, metadata)
+}
+
+func (s *instrumented) GetMetadata(ctx context.Context, uuid uuid.UUID) (_ storage.Metadata, err error) {
+	defer s.observe("GetMetadata", time.Now(), &err)
+	return s.next.GetMetadata(ctx, uuid)
+}
+
+func (s *instrumented) ListMetadata(ctx context.Context, uuids []uuid.UUID) (_ []storage.Metadata, err error) {
+	defer s.observe("ListMetadata", time.Now(), &err)
+	return s.next.ListMetadata(ctx, uuids)
+}
+
+func (s *instrumented) DueForCheck(ctx context.Context, before time.Time, limit int) (_ []storage.Bookmark, err error) {
+	defer s.observe("DueForCheck", time.Now(), &err)
+	return s.next.DueForCheck(ctx, before, limit)
+}
+
+func (s *instrumented) SaveLinkCheck(ctx context.Context, check storage.LinkCheck, fold core.FoldHealth, keep int) (err error) {
+	defer s.observe("SaveLinkCheck", time.Now(), &err)
+	return s.next.SaveLinkCheck(ctx, check, fold, keep)
+}
+
+func (s *instrumented) GetLinkHealth(ctx context.Context, uuid uuid.UUID) (_ storage.LinkHealth, err error) {
+	defer s.observe("GetLinkHealth", time.Now(), &err)
+	return s.next.GetLinkHealth(ctx, uuid)
+}
+
+func (s *instrumented) ListLinkChecks(ctx context.Context, uuid uuid.UUID, limit int) (_ []storage.LinkCheck, err error) {
+	defer s.observe("ListLinkChecks", time.Now(), &err)
+	return s.next.ListLinkChecks(ctx, uuid, limit)
+}
+
+func (s *instrumented) ListLinkHealth(ctx context.Context, uuids []uuid.UUID) (_ []storage.LinkHealth, err error) {
+	defer s.observe("ListLinkHealth", time.Now(), &err)
+	return s.next.ListLinkHealth(ctx, uuids)
+}
+
+func (s *instrumented) ListRecentLinkChecks(ctx context.Context, uuids []uuid.UUID, limit int) (_ []storage.LinkCheck, err error) {
+	defer s.observe("ListRecentLinkChecks", time.Now(), &err)
+	return s.next.ListRecentLinkChecks(ctx, uuids, limit)
+}
+
+func (s *instrumented) SaveArchive(ctx context.Context, archive storage.Archive) (err error) {
+	defer s.observe("SaveArchive", time.Now(), &err)
+	return s.next.SaveArchive(ctx, archive)
+}
+
+func (s *instrumented) GetArchive(ctx context.Context, uuid uuid.UUID) (_ storage.Archive, err error) {
+	defer s.observe("GetArchive", time.Now(), &err)
+	return s.next.GetArchive(ctx, uuid)
+}
+
+func (s *instrumented) PendingEvents(ctx context.Context, after int64, limit int) (_ []storage.Event, err error) {
+	defer s.observe("PendingEvents", time.Now(), &err)
+	return s.next.PendingEvents(ctx, after, limit)
+}
+
+func (s *instrumented) MarkDispatched(ctx context.Context, ids []int64, at time.Time) (err error) {
+	defer s.observe("MarkDispatched", time.Now(), &err)
+	return s.next.MarkDispatched(ctx, ids, at)
+}
+
+func (s *instrumented) FailEvent(ctx context.Context, id int64, reason string, maxAttempts int, at time.Time) (_ bool, err error) {
+	defer s.observe("FailEvent", time.Now(), &err)
+	return s.next.FailEvent(ctx, id, reason, maxAttempts, at)
+}
+
+func (s *instrumented) PurgeEvents(ctx context.Context, before time.Time) (_ int, err error) {
+	defer s.observe("PurgeEvents", time.Now(), &err)
+	return s.next.PurgeEvents(ctx, before)
+}
+
+func (s *instrumented) Changes(ctx context.Context, since int64, limit int) (_ storage.ChangeSet, err error) {
+	defer s.observe("Changes", time.Now(), &err)
+	return s.next.Changes(ctx, since, limit)
+}
@@ -0,0 +1,154 @@
+package bookmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/internal/tracing"
+)
+
+// eventPayload is the bookmark as written to the outbox.
+type eventPayload struct {
+	Uuid           uuid.UUID `json:"uuid"`
+	Title          string    `json:"title"`
+	TitleAuto      bool      `json:"title_auto"`
+	Value          string    `json:"value"`
+	Kind           string    `json:"kind"`
+	CanonicalValue string    `json:"canonical_value"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PendingEvents returns up to limit events after the given id neither dispatched nor dead yet, in the order they were written.
+// An event whose payload can not be decoded is dead right away and left out.
+func (r *repository) PendingEvents(ctx context.Context, after int64, limit int) (_ []model.Event, err error) {
+	const op = "repository.bookmark.PendingEvents"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	records, err := r.storage.PendingEvents(ctx, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	events := make([]model.Event, 0, len(records))
+	for _, record := range records {
+		var payload eventPayload
+		if err := json.Unmarshal(record.Payload, &payload); err != nil {
+			if _, err := r.storage.FailEvent(ctx, record.ID, err.Error(), 0, time.Now()); err != nil {
+				return nil, fmt.Errorf("%s: event %d: %w", op, record.ID, err)
+			}
+
+			continue
+		}
+
+		events = append(events, model.Event{
+			ID:   record.ID,
+			Type: model.EventType(record.Type),
+			Bookmark: model.Bookmark{
+				Uuid:           payload.Uuid,
+				Title:          payload.Title,
+				TitleAuto:      payload.TitleAuto,
+				Value:          payload.Value,
+				Kind:           model.Kind(payload.Kind),
+				CanonicalValue: payload.CanonicalValue,
+				CreatedAt:      payload.CreatedAt,
+			},
+			OccurredAt: record.OccurredAt,
+		})
+	}
+
+	return events, nil
+}
+
+func (r *repository) MarkDispatched(ctx context.Context, ids []int64, at time.Time) (err error) {
+	const op = "repository.bookmark.MarkDispatched"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if err := r.storage.MarkDispatched(ctx, ids, at); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FailEvent counts a failed publish of an event and reports whether the event is dead,
+// a dead event is not pending anymore once it failed maxAttempts times.
+func (r *repository) FailEvent(ctx context.Context, id int64, reason string, maxAttempts int, at time.Time) (_ bool, err error) {
+	const op = "repository.bookmark.FailEvent"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	dead, err := r.storage.FailEvent(ctx, id, reason, maxAttempts, at)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return dead, nil
+}
+
+// PurgeEvents deletes the events dispatched or dead before the given time and returns their number.
+func (r *repository) PurgeEvents(ctx context.Context, before time.Time) (_ int, err error) {
+	const op = "repository.bookmark.PurgeEvents"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	n, err := r.storage.PurgeEvents(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// emitRecord adapts emit to the storage records.
+func emitRecord(emit core.Emit) core.EmitRecord {
+	if emit == nil {
+		return nil
+	}
+
+	return func(change core.Change, record storage.Bookmark) ([]storage.Event, error) {
+		bookmark, err := castToModel(record)
+		if err != nil {
+			return nil, err
+		}
+
+		events := emit(change, bookmark)
+		records := make([]storage.Event, 0, len(events))
+
+		for _, event := range events {
+			payload, err := json.Marshal(eventPayload{
+				Uuid:           event.Bookmark.Uuid,
+				Title:          event.Bookmark.Title,
+				TitleAuto:      event.Bookmark.TitleAuto,
+				Value:          event.Bookmark.Value,
+				Kind:           string(event.Bookmark.Kind),
+				CanonicalValue: event.Bookmark.CanonicalValue,
+				CreatedAt:      event.Bookmark.CreatedAt,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			records = append(records, storage.Event{
+				Type:        string(event.Type),
+				AggregateID: event.Bookmark.Uuid.String(),
+				Payload:     payload,
+				OccurredAt:  event.OccurredAt,
+			})
+		}
+
+		return records, nil
+	}
+}
@@ -0,0 +1,189 @@
+package bookmark
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+var changeTypes = map[core.Change]model.EventType{
+	core.ChangeCreated: model.BookmarkAppended,
+	core.ChangeUpdated: model.BookmarkChanged,
+	core.ChangeDeleted: model.BookmarkDeleted,
+}
+
+func emitChange(change core.Change, bookmark model.Bookmark) []model.Event {
+	return []model.Event{{Type: changeTypes[change], Bookmark: bookmark, OccurredAt: time.Now()}}
+}
+
+func TestOutbox_Events(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		created, err := model.NewBookmark("new", "https://example.com/outbox")
+		require.NoError(t, err)
+
+		_, err = repo.Append(t.Context(), created, core.OnConflictError, emitChange)
+		require.NoError(t, err)
+
+		// a kept conflict is not a change
+		duplicate, err := model.NewBookmark("ignored", "https://example.com/outbox")
+		require.NoError(t, err)
+		_, err = repo.Append(t.Context(), duplicate, core.OnConflictIgnore, emitChange)
+		require.NoError(t, err)
+		_, err = repo.Append(t.Context(), duplicate, core.OnConflictError, emitChange)
+		require.ErrorIs(t, err, core.ErrExists)
+
+		duplicate.Title = "renamed"
+		_, err = repo.Append(t.Context(), duplicate, core.OnConflictUpdateTitle, emitChange)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Delete(t.Context(), created.Uuid, 0, emitChange))
+
+		events, err := repo.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+
+		require.Equal(t, model.BookmarkAppended, events[0].Type)
+		require.Equal(t, "new", events[0].Bookmark.Title)
+		require.Equal(t, model.BookmarkChanged, events[1].Type)
+		require.Equal(t, "renamed", events[1].Bookmark.Title)
+		require.Equal(t, model.BookmarkDeleted, events[2].Type)
+		require.Equal(t, created.Uuid, events[2].Bookmark.Uuid)
+		require.Less(t, events[0].ID, events[1].ID)
+		require.Less(t, events[1].ID, events[2].ID)
+
+		now := time.Now()
+		require.NoError(t, repo.MarkDispatched(t.Context(), []int64{events[0].ID, events[1].ID}, now))
+
+		events, err = repo.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, model.BookmarkDeleted, events[0].Type)
+
+		n, err := repo.PurgeEvents(t.Context(), now.Add(time.Second))
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+	}
+}
+
+func TestOutbox_RollbackWithChange(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		// an event that cannot be encoded aborts the write
+		broken := func(change core.Change, bookmark model.Bookmark) []model.Event {
+			bookmark.CreatedAt = time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			return []model.Event{{Type: changeTypes[change], Bookmark: bookmark, OccurredAt: time.Now()}}
+		}
+
+		created, err := model.NewBookmark("new", "https://example.com/rollback")
+		require.NoError(t, err)
+
+		_, err = repo.Append(t.Context(), created, core.OnConflictError, broken)
+		require.Error(t, err)
+
+		_, err = repo.GetByUUID(t.Context(), created.Uuid)
+		require.ErrorIs(t, err, core.ErrNotFound)
+
+		err = repo.Delete(t.Context(), bookmark.Uuid, 0, broken)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, core.ErrNotFound))
+
+		_, err = repo.GetByUUID(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+
+		events, err := repo.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, events)
+	}
+}
+
+func TestOutbox_FailEvent(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		for _, title := range []string{"a", "b"} {
+			created, err := model.NewBookmark(title, "https://example.com/"+title)
+			require.NoError(t, err)
+			_, err = repo.Append(t.Context(), created, core.OnConflictError, emitChange)
+			require.NoError(t, err)
+		}
+
+		events, err := repo.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+
+		// the cursor pages past the events read before
+		after, err := repo.PendingEvents(t.Context(), events[0].ID, 10)
+		require.NoError(t, err)
+		require.Len(t, after, 1)
+		require.Equal(t, events[1].ID, after[0].ID)
+
+		now := time.Now()
+
+		dead, err := repo.FailEvent(t.Context(), events[0].ID, "sink unavailable", 2, now)
+		require.NoError(t, err)
+		require.False(t, dead)
+
+		dead, err = repo.FailEvent(t.Context(), events[0].ID, "sink unavailable", 2, now)
+		require.NoError(t, err)
+		require.True(t, dead)
+
+		pending, err := repo.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		require.Equal(t, events[1].ID, pending[0].ID)
+
+		// a dead event is not counted anymore
+		dead, err = repo.FailEvent(t.Context(), events[0].ID, "sink unavailable", 2, now)
+		require.NoError(t, err)
+		require.False(t, dead)
+
+		n, err := repo.PurgeEvents(t.Context(), now.Add(time.Second))
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	}
+}
+
+func TestOutbox_UndecodablePayload(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		garbage := func(core.Change, storage.Bookmark) ([]storage.Event, error) {
+			return []storage.Event{{
+				Type:        string(model.BookmarkAppended),
+				AggregateID: "garbage",
+				Payload:     []byte("{"),
+				OccurredAt:  time.Now(),
+			}}, nil
+		}
+
+		created, err := model.NewBookmark("garbage", "https://example.com/garbage")
+		require.NoError(t, err)
+		_, err = repo.storage.Create(t.Context(), castToStorage(created), core.OnConflictError, garbage)
+		require.NoError(t, err)
+
+		created, err = model.NewBookmark("valid", "https://example.com/valid")
+		require.NoError(t, err)
+		_, err = repo.Append(t.Context(), created, core.OnConflictError, emitChange)
+		require.NoError(t, err)
+
+		// the undecodable event is dead and does not block the events after it
+		events, err := repo.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "valid", events[0].Bookmark.Title)
+
+		records, err := repo.storage.PendingEvents(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+	}
+}
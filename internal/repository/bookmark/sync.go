@@ -0,0 +1,70 @@
+package bookmark
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/tracing"
+)
+
+// Changes returns up to limit bookmark writes after since in version order.
+// When the page is cut, Version is the last version included and More is set.
+func (r *repository) Changes(ctx context.Context, since int64, limit int) (_ model.ChangeSet, err error) {
+	const op = "repository.bookmark.Changes"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	set, err := r.storage.Changes(ctx, since, limit)
+	if err != nil {
+		return model.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	changes := model.ChangeSet{
+		Bookmarks:  make([]model.Bookmark, 0, len(set.Bookmarks)),
+		Tombstones: make([]model.Tombstone, 0, len(set.Tombstones)),
+		Version:    set.Version,
+	}
+
+	// each list holds up to limit items, merging them by version keeps the first limit writes;
+	// a cut list may have left out writes before the last version of the other one
+	b, t := 0, 0
+	for b+t < limit && (b < len(set.Bookmarks) || t < len(set.Tombstones)) {
+		if t == len(set.Tombstones) || (b < len(set.Bookmarks) && set.Bookmarks[b].Version < set.Tombstones[t].Version) {
+			bookmark, err := castToModel(set.Bookmarks[b])
+			if err != nil {
+				return model.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+			}
+
+			changes.Bookmarks = append(changes.Bookmarks, bookmark)
+			changes.Version = bookmark.Version
+			b++
+
+			continue
+		}
+
+		id, err := uuid.Parse(set.Tombstones[t].Uuid)
+		if err != nil {
+			return model.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		changes.Tombstones = append(changes.Tombstones, model.Tombstone{
+			Uuid:      id,
+			Version:   set.Tombstones[t].Version,
+			DeletedAt: set.Tombstones[t].DeletedAt,
+		})
+		changes.Version = set.Tombstones[t].Version
+		t++
+	}
+
+	changes.More = b < len(set.Bookmarks) || t < len(set.Tombstones) ||
+		len(set.Bookmarks) == limit || len(set.Tombstones) == limit
+	if !changes.More {
+		changes.Version = set.Version
+	}
+
+	return changes, nil
+}
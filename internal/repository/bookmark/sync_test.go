@@ -0,0 +1,108 @@
+package bookmark
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+)
+
+func TestChanges(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		set, err := repo.Changes(t.Context(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, set.Bookmarks, 1)
+		require.Empty(t, set.Tombstones)
+		require.False(t, set.More)
+
+		stored := set.Bookmarks[0]
+		require.Equal(t, bookmark.Uuid, stored.Uuid)
+		require.Positive(t, stored.Version)
+		require.Equal(t, stored.Version, set.Version)
+
+		second, err := model.NewBookmark("second", "https://example.com/sync")
+		require.NoError(t, err)
+		second, err = repo.Append(t.Context(), second, core.OnConflictError, nil)
+		require.NoError(t, err)
+		require.Greater(t, second.Version, stored.Version)
+
+		require.NoError(t, repo.Delete(t.Context(), bookmark.Uuid, stored.Version, nil))
+
+		set, err = repo.Changes(t.Context(), stored.Version, 10)
+		require.NoError(t, err)
+		require.Len(t, set.Bookmarks, 1)
+		require.Equal(t, second.Uuid, set.Bookmarks[0].Uuid)
+		require.Len(t, set.Tombstones, 1)
+		require.Equal(t, bookmark.Uuid, set.Tombstones[0].Uuid)
+		require.Greater(t, set.Tombstones[0].Version, second.Version)
+		require.Equal(t, set.Tombstones[0].Version, set.Version)
+
+		// a cut page ends at its last write
+		page, err := repo.Changes(t.Context(), 0, 1)
+		require.NoError(t, err)
+		require.True(t, page.More)
+		require.Len(t, page.Bookmarks, 1)
+		require.Empty(t, page.Tombstones)
+		require.Equal(t, second.Version, page.Version)
+
+		page, err = repo.Changes(t.Context(), page.Version, 1)
+		require.NoError(t, err)
+		require.Empty(t, page.Bookmarks)
+		require.Len(t, page.Tombstones, 1)
+		require.Equal(t, set.Version, page.Version)
+
+		set, err = repo.Changes(t.Context(), set.Version, 10)
+		require.NoError(t, err)
+		require.Empty(t, set.Bookmarks)
+		require.Empty(t, set.Tombstones)
+		require.False(t, set.More)
+	}
+}
+
+func TestUpdate_Version(t *testing.T) {
+	bookmark := makeBookmark()
+
+	for _, repo := range makeRepositoryProvider(bookmark) {
+		stored, err := repo.GetByUUID(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+
+		changed := stored
+		changed.Title = "changed"
+
+		updated, err := repo.Update(t.Context(), changed, stored.Version, nil)
+		require.NoError(t, err)
+		require.Equal(t, "changed", updated.Title)
+		require.Greater(t, updated.Version, stored.Version)
+
+		// a stale version returns the current bookmark
+		changed.Title = "stale"
+		current, err := repo.Update(t.Context(), changed, stored.Version, nil)
+		require.ErrorIs(t, err, core.ErrConflict)
+		require.Equal(t, updated, current)
+
+		other, err := model.NewBookmark("other", "https://example.com/other")
+		require.NoError(t, err)
+		other, err = repo.Append(t.Context(), other, core.OnConflictError, nil)
+		require.NoError(t, err)
+
+		changed.Value = other.Value
+		changed.CanonicalValue = other.CanonicalValue
+		owner, err := repo.Update(t.Context(), changed, updated.Version, nil)
+		require.ErrorIs(t, err, core.ErrExists)
+		require.Equal(t, other.Uuid, owner.Uuid)
+
+		require.ErrorIs(t, repo.Delete(t.Context(), bookmark.Uuid, stored.Version, nil), core.ErrConflict)
+		require.NoError(t, repo.Delete(t.Context(), bookmark.Uuid, updated.Version, nil))
+		require.ErrorIs(t, repo.Delete(t.Context(), bookmark.Uuid, 0, nil), core.ErrDeleted)
+
+		_, err = repo.Update(t.Context(), changed, updated.Version, nil)
+		require.ErrorIs(t, err, core.ErrDeleted)
+
+		_, err = repo.Append(t.Context(), bookmark, core.OnConflictError, nil)
+		require.ErrorIs(t, err, core.ErrDeleted)
+	}
+}
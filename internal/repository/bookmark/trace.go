@@ -0,0 +1,191 @@
+package bookmark
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/internal/tracing"
+)
+
+// traced passes the calls to the storage, each one in a span of the storage method.
+type traced struct {
+	next   Storage
+	system attribute.KeyValue
+}
+
+// Trace returns the storage with every call of a traced context in a span,
+// system is its db.system.name attribute, e.g. semconv.DBSystemNameSQLite.
+func Trace(s Storage, system attribute.KeyValue) Storage {
+	return &traced{next: s, system: system}
+}
+
+func (s *traced) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "storage.bookmark."+method, s.system)
+}
+
+func (s *traced) Create(ctx context.Context, record storage.Bookmark, mode core.OnConflict, emit core.EmitRecord) (_ storage.Bookmark, err error) {
+	ctx, span := s.start(ctx, "Create")
+	defer tracing.End(span, &err)
+
+	return s.next.Create(ctx, record, mode, emit)
+}
+
+func (s *traced) Update(ctx context.Context, record storage.Bookmark, version int64, emit core.EmitRecord) (_ storage.Bookmark, err error) {
+	ctx, span := s.start(ctx, "Update")
+	defer tracing.End(span, &err)
+
+	return s.next.Update(ctx, record, version, emit)
+}
+
+func (s *traced) GetByUUID(ctx context.Context, uuid uuid.UUID) (_ storage.Bookmark, err error) {
+	ctx, span := s.start(ctx, "GetByUUID")
+	defer tracing.End(span, &err)
+
+	return s.next.GetByUUID(ctx, uuid)
+}
+
+func (s *traced) GetByValue(ctx context.Context, val string) (_ storage.Bookmark, err error) {
+	ctx, span := s.start(ctx, "GetByValue")
+	defer tracing.End(span, &err)
+
+	return s.next.GetByValue(ctx, val)
+}
+
+func (s *traced) List(ctx context.Context, filter core.Filter) (_ []storage.Bookmark, err error) {
+	ctx, span := s.start(ctx, "List")
+	defer tracing.End(span, &err)
+
+	return s.next.List(ctx, filter)
+}
+
+func (s *traced) Delete(ctx context.Context, uuid uuid.UUID, version int64, emit core.EmitRecord) (err error) {
+	ctx, span := s.start(ctx, "Delete")
+	defer tracing.End(span, &err)
+
+	return s.next.Delete(ctx, uuid, version, emit)
+}
+
+func (s *traced) UpdateAutoTitle(ctx context.Context, uuid uuid.UUID, title string, emit core.EmitRecord) (err error) {
+	ctx, span := s.start(ctx, "UpdateAutoTitle")
+	defer tracing.End(span, &err)
+
+	return s.next.UpdateAutoTitle(ctx, uuid, title, emit)
+}
+
+func (s *traced) SaveMetadata(ctx context.Context, metadata storage.Metadata) (err error) {
+	ctx, span := s.start(ctx, "SaveMetadata")
+	defer tracing.End(span, &err)
+
+	return s.next.SaveMetadata(ctx, metadata)
+}
+
+func (s *traced) GetMetadata(ctx context.Context, uuid uuid.UUID) (_ storage.Metadata, err error) {
+	ctx, span := s.start(ctx, "GetMetadata")
+	defer tracing.End(span, &err)
+
+	return s.next.GetMetadata(ctx, uuid)
+}
+
+func (s *traced) ListMetadata(ctx context.Context, uuids []uuid.UUID) (_ []storage.Metadata, err error) {
+	ctx, span := s.start(ctx, "ListMetadata")
+	defer tracing.End(span, &err)
+
+	return s.next.ListMetadata(ctx, uuids)
+}
+
+func (s *traced) DueForCheck(ctx context.Context, before time.Time, limit int) (_ []storage.Bookmark, err error) {
+	ctx, span := s.start(ctx, "DueForCheck")
+	defer tracing.End(span, &err)
+
+	return s.next.DueForCheck(ctx, before, limit)
+}
+
+func (s *traced) SaveLinkCheck(ctx context.Context, check storage.LinkCheck, fold core.FoldHealth, keep int) (err error) {
+	ctx, span := s.start(ctx, "SaveLinkCheck")
+	defer tracing.End(span, &err)
+
+	return s.next.SaveLinkCheck(ctx, check, fold, keep)
+}
+
+func (s *traced) GetLinkHealth(ctx context.Context, uuid uuid.UUID) (_ storage.LinkHealth, err error) {
+	ctx, span := s.start(ctx, "GetLinkHealth")
+	defer tracing.End(span, &err)
+
+	return s.next.GetLinkHealth(ctx, uuid)
+}
+
+func (s *traced) ListLinkChecks(ctx context.Context, uuid uuid.UUID, limit int) (_ []storage.LinkCheck, err error) {
+	ctx, span := s.start(ctx, "ListLinkChecks")
+	defer tracing.End(span, &err)
+
+	return s.next.ListLinkChecks(ctx, uuid, limit)
+}
+
+func (s *traced) ListLinkHealth(ctx context.Context, uuids []uuid.UUID) (_ []storage.LinkHealth, err error) {
+	ctx, span := s.start(ctx, "ListLinkHealth")
+	defer tracing.End(span, &err)
+
+	return s.next.ListLinkHealth(ctx, uuids)
+}
+
+func (s *traced) ListRecentLinkChecks(ctx context.Context, uuids []uuid.UUID, limit int) (_ []storage.LinkCheck, err error) {
+	ctx, span := s.start(ctx, "ListRecentLinkChecks")
+	defer tracing.End(span, &err)
+
+	return s.next.ListRecentLinkChecks(ctx, uuids, limit)
+}
+
+func (s *traced) SaveArchive(ctx context.Context, archive storage.Archive) (err error) {
+	ctx, span := s.start(ctx, "SaveArchive")
+	defer tracing.End(span, &err)
+
+	return s.next.SaveArchive(ctx, archive)
+}
+
+func (s *traced) GetArchive(ctx context.Context, uuid uuid.UUID) (_ storage.Archive, err error) {
+	ctx, span := s.start(ctx, "GetArchive")
+	defer tracing.End(span, &err)
+
+	return s.next.GetArchive(ctx, uuid)
+}
+
+func (s *traced) PendingEvents(ctx context.Context, after int64, limit int) (_ []storage.Event, err error) {
+	ctx, span := s.start(ctx, "PendingEvents")
+	defer tracing.End(span, &err)
+
+	return s.next.PendingEvents(ctx, after, limit)
+}
+
+func (s *traced) MarkDispatched(ctx context.Context, ids []int64, at time.Time) (err error) {
+	ctx, span := s.start(ctx, "MarkDispatched")
+	defer tracing.End(span, &err)
+
+	return s.next.MarkDispatched(ctx, ids, at)
+}
+
+func (s *traced) FailEvent(ctx context.Context, id int64, reason string, maxAttempts int, at time.Time) (_ bool, err error) {
+	ctx, span := s.start(ctx, "FailEvent")
+	defer tracing.End(span, &err)
+
+	return s.next.FailEvent(ctx, id, reason, maxAttempts, at)
+}
+
+func (s *traced) PurgeEvents(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, span := s.start(ctx, "PurgeEvents")
+	defer tracing.End(span, &err)
+
+	return s.next.PurgeEvents(ctx, before)
+}
+
+func (s *traced) Changes(ctx context.Context, since int64, limit int) (_ storage.ChangeSet, err error) {
+	ctx, span := s.start(ctx, "Changes")
+	defer tracing.End(span, &err)
+
+	return s.next.Changes(ctx, since, limit)
+}
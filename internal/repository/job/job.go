@@ -0,0 +1,193 @@
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+type Storage interface {
+	Enqueue(record storage.Job) (storage.Job, error)
+	Lease(lease core.Lease) ([]storage.Job, error)
+	ExtendLease(id int64, owner string, until time.Time) error
+	Complete(id int64, owner string, at time.Time) error
+	Release(id int64, owner string, at time.Time) error
+	Fail(id int64, owner, message string, at, retryAt time.Time) error
+	Get(id int64) (storage.Job, error)
+	List(filter core.JobFilter) ([]storage.Job, error)
+	Retry(id int64, at time.Time) (storage.Job, error)
+	Purge(before time.Time) (int, error)
+	OldestDue(kinds []string, now time.Time) (time.Time, error)
+}
+
+type repository struct {
+	storage Storage
+}
+
+func NewRepository(s Storage) *repository {
+	return &repository{storage: s}
+}
+
+// Enqueue stores a queued job, ErrExists is returned when its unique key is taken.
+func (r *repository) Enqueue(job model.Job) (model.Job, error) {
+	const op = "repository.job.Enqueue"
+
+	record, err := r.storage.Enqueue(storage.Job{
+		Kind:        job.Kind,
+		Payload:     job.Payload,
+		UniqueKey:   job.UniqueKey,
+		Status:      string(model.JobQueued),
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		CreatedAt:   job.CreatedAt,
+	})
+	if err != nil {
+		return model.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return castToModel(record), nil
+}
+
+// Lease hands due jobs to the lease owner until the lease expires.
+func (r *repository) Lease(lease core.Lease) ([]model.Job, error) {
+	const op = "repository.job.Lease"
+
+	records, err := r.storage.Lease(lease)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return castSliceToModel(records), nil
+}
+
+// ExtendLease keeps a long running job leased, ErrLeaseLost means another worker took it over.
+func (r *repository) ExtendLease(id int64, owner string, until time.Time) error {
+	const op = "repository.job.ExtendLease"
+
+	if err := r.storage.ExtendLease(id, owner, until); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *repository) Complete(id int64, owner string, at time.Time) error {
+	const op = "repository.job.Complete"
+
+	if err := r.storage.Complete(id, owner, at); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Release queues a job interrupted before it finished again, the attempt is not counted.
+func (r *repository) Release(id int64, owner string, at time.Time) error {
+	const op = "repository.job.Release"
+
+	if err := r.storage.Release(id, owner, at); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Fail records a failed attempt, the job is queued again at retryAt or fails for good when it is zero.
+func (r *repository) Fail(id int64, owner, message string, at, retryAt time.Time) error {
+	const op = "repository.job.Fail"
+
+	if err := r.storage.Fail(id, owner, message, at, retryAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *repository) Get(id int64) (model.Job, error) {
+	const op = "repository.job.Get"
+
+	record, err := r.storage.Get(id)
+	if err != nil {
+		return model.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return castToModel(record), nil
+}
+
+func (r *repository) List(filter core.JobFilter) ([]model.Job, error) {
+	const op = "repository.job.List"
+
+	records, err := r.storage.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return castSliceToModel(records), nil
+}
+
+// Retry queues a failed job again, ErrConflict is returned for a job that has not failed.
+func (r *repository) Retry(id int64, at time.Time) (model.Job, error) {
+	const op = "repository.job.Retry"
+
+	record, err := r.storage.Retry(id, at)
+	if err != nil {
+		return model.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return castToModel(record), nil
+}
+
+// Purge deletes finished jobs older than the given time and returns their number.
+func (r *repository) Purge(before time.Time) (int, error) {
+	const op = "repository.job.Purge"
+
+	purged, err := r.storage.Purge(before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return purged, nil
+}
+
+// OldestDue returns the run time of the oldest queued job of the kinds due at now, zero without due jobs.
+func (r *repository) OldestDue(kinds []string, now time.Time) (time.Time, error) {
+	const op = "repository.job.OldestDue"
+
+	runAt, err := r.storage.OldestDue(kinds, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return runAt, nil
+}
+
+func castSliceToModel(records []storage.Job) []model.Job {
+	jobs := make([]model.Job, 0, len(records))
+	for _, record := range records {
+		jobs = append(jobs, castToModel(record))
+	}
+
+	return jobs
+}
+
+func castToModel(r storage.Job) model.Job {
+	return model.Job{
+		ID:          r.ID,
+		Kind:        r.Kind,
+		Payload:     r.Payload,
+		UniqueKey:   r.UniqueKey,
+		Status:      model.JobStatus(r.Status),
+		Attempts:    r.Attempts,
+		MaxAttempts: r.MaxAttempts,
+		RunAt:       r.RunAt,
+		LeaseOwner:  r.LeaseOwner,
+		LeasedUntil: r.LeasedUntil,
+		LastError:   r.LastError,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+		FinishedAt:  r.FinishedAt,
+	}
+}
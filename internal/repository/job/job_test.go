@@ -0,0 +1,312 @@
+package job
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/internal/storage/pgsql"
+	"bookmarks/internal/storage/sqlite"
+	"bookmarks/pkg/postgres/postgrestest"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func TestEnqueue_UniqueKey(t *testing.T) {
+	now := time.Now()
+
+	for _, repo := range makeRepositoryProvider(t) {
+		job, err := repo.Enqueue(makeJob("mail", now, "digest:2026-10-19"))
+		require.NoError(t, err)
+		require.NotZero(t, job.ID)
+		require.Equal(t, model.JobQueued, job.Status)
+		require.JSONEq(t, `{"n":1}`, string(job.Payload))
+
+		_, err = repo.Enqueue(makeJob("mail", now, "digest:2026-10-19"))
+		require.ErrorIs(t, err, core.ErrExists)
+
+		_, err = repo.Enqueue(makeJob("mail", now, ""))
+		require.NoError(t, err)
+
+		_, err = repo.Enqueue(makeJob("mail", now, ""))
+		require.NoError(t, err)
+	}
+}
+
+func TestLease_Lifecycle(t *testing.T) {
+	now := time.Now()
+
+	for _, repo := range makeRepositoryProvider(t) {
+		first, err := repo.Enqueue(makeJob("mail", now.Add(-time.Minute), ""))
+		require.NoError(t, err)
+		second, err := repo.Enqueue(makeJob("mail", now.Add(-time.Second), ""))
+		require.NoError(t, err)
+		_, err = repo.Enqueue(makeJob("mail", now.Add(time.Hour), ""))
+		require.NoError(t, err)
+		_, err = repo.Enqueue(makeJob("other", now.Add(-time.Hour), ""))
+		require.NoError(t, err)
+
+		leased, err := repo.Lease(core.Lease{Owner: "a", Kinds: []string{"mail"}, Now: now, Until: now.Add(time.Minute), Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, leased, 2)
+		require.Equal(t, first.ID, leased[0].ID)
+		require.Equal(t, second.ID, leased[1].ID)
+		require.Equal(t, model.JobRunning, leased[0].Status)
+		require.Equal(t, 1, leased[0].Attempts)
+		require.Equal(t, "a", leased[0].LeaseOwner)
+
+		// leased jobs are not handed out twice
+		leased, err = repo.Lease(core.Lease{Owner: "b", Kinds: []string{"mail"}, Now: now, Until: now.Add(time.Minute), Limit: 10})
+		require.NoError(t, err)
+		require.Empty(t, leased)
+
+		require.ErrorIs(t, repo.Complete(first.ID, "b", now), core.ErrLeaseLost)
+		require.NoError(t, repo.ExtendLease(first.ID, "a", now.Add(2*time.Minute)))
+		require.NoError(t, repo.Complete(first.ID, "a", now))
+
+		job, err := repo.Get(first.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.JobSucceeded, job.Status)
+		require.False(t, job.FinishedAt.IsZero())
+
+		require.NoError(t, repo.Fail(second.ID, "a", "timeout", now, now.Add(time.Second)))
+
+		job, err = repo.Get(second.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.JobQueued, job.Status)
+		require.Equal(t, "timeout", job.LastError)
+		require.WithinDuration(t, now.Add(time.Second), job.RunAt, time.Millisecond)
+
+		leased, err = repo.Lease(core.Lease{Owner: "a", Kinds: []string{"mail"}, Now: now.Add(2 * time.Second), Until: now.Add(time.Minute), Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, leased, 1)
+		require.Equal(t, 2, leased[0].Attempts)
+
+		require.NoError(t, repo.Fail(second.ID, "a", "boom", now, time.Time{}))
+
+		jobs, err := repo.List(core.JobFilter{Status: string(model.JobFailed)})
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		require.Equal(t, second.ID, jobs[0].ID)
+		require.Equal(t, "boom", jobs[0].LastError)
+
+		_, err = repo.Retry(first.ID, now)
+		require.ErrorIs(t, err, core.ErrConflict)
+
+		_, err = repo.Retry(12345, now)
+		require.ErrorIs(t, err, core.ErrNotFound)
+
+		job, err = repo.Retry(second.ID, now)
+		require.NoError(t, err)
+		require.Equal(t, model.JobQueued, job.Status)
+		require.Zero(t, job.Attempts)
+		require.True(t, job.FinishedAt.IsZero())
+	}
+}
+
+func TestLease_Expired(t *testing.T) {
+	now := time.Now()
+
+	for _, repo := range makeRepositoryProvider(t) {
+		job, err := repo.Enqueue(makeJob("mail", now, ""))
+		require.NoError(t, err)
+
+		leased, err := repo.Lease(core.Lease{Owner: "crashed", Kinds: []string{"mail"}, Now: now, Until: now.Add(time.Minute), Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, leased, 1)
+
+		leased, err = repo.Lease(core.Lease{Owner: "b", Kinds: []string{"mail"}, Now: now.Add(2 * time.Minute), Until: now.Add(3 * time.Minute), Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, leased, 1)
+		require.Equal(t, job.ID, leased[0].ID)
+		require.Equal(t, "b", leased[0].LeaseOwner)
+		require.Equal(t, 2, leased[0].Attempts)
+
+		require.ErrorIs(t, repo.Complete(job.ID, "crashed", now), core.ErrLeaseLost)
+	}
+}
+
+func TestLease_AttemptsExhausted(t *testing.T) {
+	now := time.Now()
+
+	for _, repo := range makeRepositoryProvider(t) {
+		job, err := repo.Enqueue(makeJob("mail", now, ""))
+		require.NoError(t, err)
+
+		// every worker holding the job crashes before its lease runs out
+		at := now
+		for attempt := 1; attempt <= job.MaxAttempts; attempt++ {
+			leased, err := repo.Lease(core.Lease{Owner: "crashed", Kinds: []string{"mail"}, Now: at, Until: at.Add(time.Minute), Limit: 1})
+			require.NoError(t, err)
+			require.Len(t, leased, 1)
+			require.Equal(t, attempt, leased[0].Attempts)
+
+			at = at.Add(2 * time.Minute)
+		}
+
+		leased, err := repo.Lease(core.Lease{Owner: "b", Kinds: []string{"mail"}, Now: at, Until: at.Add(time.Minute), Limit: 1})
+		require.NoError(t, err)
+		require.Empty(t, leased)
+
+		job, err = repo.Get(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.JobFailed, job.Status)
+		require.Equal(t, job.MaxAttempts, job.Attempts)
+		require.Equal(t, core.LeaseExpired, job.LastError)
+		require.True(t, job.LeasedUntil.IsZero())
+		require.WithinDuration(t, at, job.FinishedAt, time.Millisecond)
+
+		// it runs again once retried by hand
+		_, err = repo.Retry(job.ID, at)
+		require.NoError(t, err)
+
+		leased, err = repo.Lease(core.Lease{Owner: "b", Kinds: []string{"mail"}, Now: at, Until: at.Add(time.Minute), Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, leased, 1)
+		require.Equal(t, 1, leased[0].Attempts)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	now := time.Now()
+
+	for _, repo := range makeRepositoryProvider(t) {
+		job, err := repo.Enqueue(makeJob("mail", now.Add(-time.Minute), ""))
+		require.NoError(t, err)
+
+		leased, err := repo.Lease(core.Lease{Owner: "a", Kinds: []string{"mail"}, Now: now, Until: now.Add(time.Minute), Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, leased, 1)
+		require.Equal(t, 1, leased[0].Attempts)
+
+		require.ErrorIs(t, repo.Release(job.ID, "b", now), core.ErrLeaseLost)
+		require.NoError(t, repo.Release(job.ID, "a", now))
+
+		job, err = repo.Get(job.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.JobQueued, job.Status)
+		require.Zero(t, job.Attempts)
+		require.True(t, job.LeasedUntil.IsZero())
+
+		leased, err = repo.Lease(core.Lease{Owner: "b", Kinds: []string{"mail"}, Now: now, Until: now.Add(time.Minute), Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, leased, 1)
+		require.Equal(t, 1, leased[0].Attempts)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Now()
+
+	for _, repo := range makeRepositoryProvider(t) {
+		done, err := repo.Enqueue(makeJob("mail", now, "once"))
+		require.NoError(t, err)
+		queued, err := repo.Enqueue(makeJob("mail", now.Add(time.Hour), ""))
+		require.NoError(t, err)
+
+		_, err = repo.Lease(core.Lease{Owner: "a", Kinds: []string{"mail"}, Now: now, Until: now.Add(time.Minute), Limit: 1})
+		require.NoError(t, err)
+		require.NoError(t, repo.Complete(done.ID, "a", now.Add(-48*time.Hour)))
+
+		purged, err := repo.Purge(now.Add(-24 * time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 1, purged)
+
+		_, err = repo.Get(done.ID)
+		require.ErrorIs(t, err, core.ErrNotFound)
+		_, err = repo.Get(queued.ID)
+		require.NoError(t, err)
+
+		// the unique key is free again
+		_, err = repo.Enqueue(makeJob("mail", now, "once"))
+		require.NoError(t, err)
+	}
+}
+
+func TestOldestDue(t *testing.T) {
+	now := time.Now()
+
+	for _, repo := range makeRepositoryProvider(t) {
+		oldest, err := repo.OldestDue([]string{"mail"}, now)
+		require.NoError(t, err)
+		require.True(t, oldest.IsZero())
+
+		for _, job := range []model.Job{
+			makeJob("mail", now.Add(-10*time.Minute), ""),
+			makeJob("mail", now.Add(-time.Minute), ""),
+			makeJob("mail", now.Add(time.Hour), ""),
+			makeJob("unhandled", now.Add(-time.Hour), ""),
+		} {
+			_, err := repo.Enqueue(job)
+			require.NoError(t, err)
+		}
+
+		oldest, err = repo.OldestDue([]string{"mail"}, now)
+		require.NoError(t, err)
+		require.WithinDuration(t, now.Add(-10*time.Minute), oldest, time.Millisecond)
+
+		// a leased job is not waiting anymore
+		_, err = repo.Lease(core.Lease{Owner: "a", Kinds: []string{"mail"}, Now: now, Until: now.Add(time.Minute), Limit: 1})
+		require.NoError(t, err)
+
+		oldest, err = repo.OldestDue([]string{"mail"}, now)
+		require.NoError(t, err)
+		require.WithinDuration(t, now.Add(-time.Minute), oldest, time.Millisecond)
+
+		oldest, err = repo.OldestDue(nil, now)
+		require.NoError(t, err)
+		require.True(t, oldest.IsZero())
+	}
+}
+
+func makeJob(kind string, runAt time.Time, uniqueKey string) model.Job {
+	return model.Job{
+		Kind:        kind,
+		Payload:     json.RawMessage(`{"n":1}`),
+		UniqueKey:   uniqueKey,
+		MaxAttempts: 3,
+		RunAt:       runAt,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func makeRepositoryProvider(t *testing.T) []*repository {
+	t.Helper()
+
+	var provider []*repository
+
+	// memory storage
+	provider = append(provider, NewRepository(memory.NewJobStorage()))
+
+	// sqlite storage
+	dbSourceName := "../../../storage/test_job.db"
+	_ = os.Remove(dbSourceName)
+
+	driver, err := pkgsql.New(pkgsql.SourceName(dbSourceName))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = driver.DB.Close()
+		_ = os.Remove(dbSourceName)
+	})
+
+	storage, err := sqlite.NewJob(driver)
+	require.NoError(t, err)
+
+	provider = append(provider, NewRepository(storage))
+
+	// pgsql storage, with a test database only
+	if postgrestest.Enabled() {
+		storage, err := pgsql.NewJob(postgrestest.New(t))
+		require.NoError(t, err)
+
+		provider = append(provider, NewRepository(storage))
+	}
+
+	return provider
+}
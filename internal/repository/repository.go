@@ -1,8 +1,82 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/storage"
+)
 
 var (
-	ErrNotFound = errors.New("record not found")
-	ErrExists   = errors.New("record exists")
+	ErrNotFound  = errors.New("record not found")
+	ErrExists    = errors.New("record exists")
+	ErrLeaseLost = errors.New("job lease lost")
+	ErrConflict  = errors.New("record state conflict")
+	// ErrDeleted is the ErrNotFound of a record that left a tombstone.
+	ErrDeleted = fmt.Errorf("%w: deleted", ErrNotFound)
+)
+
+// OnConflict defines how storage resolves an insert that collides with an existing value.
+type OnConflict uint8
+
+const (
+	// OnConflictError keeps the existing record and reports ErrExists.
+	OnConflictError OnConflict = iota
+	// OnConflictIgnore keeps the existing record and returns it.
+	OnConflictIgnore
+	// OnConflictUpdateTitle replaces the title of the existing record and returns it.
+	OnConflictUpdateTitle
+)
+
+// Change tells what a write did to a bookmark.
+type Change uint8
+
+const (
+	ChangeCreated Change = iota + 1
+	ChangeUpdated
+	ChangeDeleted
 )
+
+// Emit returns the domain events of a bookmark change.
+type Emit func(change Change, bookmark model.Bookmark) []model.Event
+
+// EmitRecord returns the outbox records of a bookmark change. Storage calls it
+// inside the write transaction and only when the write took effect,
+// the records are committed along with the change or not at all.
+type EmitRecord func(change Change, record storage.Bookmark) ([]storage.Event, error)
+
+// FoldHealth returns the health of a link after a check from its current health,
+// nil for a link never checked. Storage calls it inside the write transaction of the check,
+// so concurrent checks of a link are folded one after the other.
+type FoldHealth func(current *storage.LinkHealth) storage.LinkHealth
+
+// Filter narrows a storage listing, zero values mean no restriction.
+type Filter struct {
+	Kind   string
+	Health string // only bookmarks whose last link check has this status
+	Limit  int
+	Offset int
+}
+
+// JobFilter narrows a job listing, zero values mean no restriction.
+type JobFilter struct {
+	Status string
+	Kind   string
+	Limit  int
+	Offset int
+}
+
+// Lease describes which jobs a worker takes and for how long.
+type Lease struct {
+	Owner string
+	Kinds []string // job kinds the worker has handlers for
+	Now   time.Time
+	Until time.Time
+	Limit int
+}
+
+// LeaseExpired is the error of a job failed because its last attempt ran out its lease,
+// a job that keeps crashing its worker is not taken again.
+const LeaseExpired = "lease expired on the last attempt"
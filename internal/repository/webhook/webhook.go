@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/storage"
+)
+
+type Storage interface {
+	Create(record storage.Webhook) error
+	Update(record storage.Webhook) error
+	Get(id string) (storage.Webhook, error)
+	List() ([]storage.Webhook, error)
+	Delete(id string) error
+	RecordDelivery(delivery storage.WebhookDelivery, disableAfter, keep int) (storage.Webhook, error)
+	ListDeliveries(id string, limit, offset int) ([]storage.WebhookDelivery, error)
+}
+
+type repository struct {
+	storage Storage
+}
+
+func NewRepository(s Storage) *repository {
+	return &repository{storage: s}
+}
+
+func (r *repository) Create(webhook model.Webhook) error {
+	const op = "repository.webhook.Create"
+
+	if err := r.storage.Create(castToStorage(webhook)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *repository) Update(webhook model.Webhook) error {
+	const op = "repository.webhook.Update"
+
+	if err := r.storage.Update(castToStorage(webhook)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *repository) Get(id uuid.UUID) (model.Webhook, error) {
+	const op = "repository.webhook.Get"
+
+	record, err := r.storage.Get(id.String())
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	webhook, err := castToModel(record)
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhook, nil
+}
+
+// List returns every webhook, the oldest first.
+func (r *repository) List() ([]model.Webhook, error) {
+	const op = "repository.webhook.List"
+
+	records, err := r.storage.List()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	webhooks := make([]model.Webhook, 0, len(records))
+	for _, record := range records {
+		webhook, err := castToModel(record)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+// Delete removes the webhook with its delivery log.
+func (r *repository) Delete(id uuid.UUID) error {
+	const op = "repository.webhook.Delete"
+
+	if err := r.storage.Delete(id.String()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecordDelivery logs the delivery, keeping the last keep ones, and returns the webhook
+// with its consecutive failures counted; it is disabled once they reach disableAfter.
+func (r *repository) RecordDelivery(delivery model.WebhookDelivery, disableAfter, keep int) (model.Webhook, error) {
+	const op = "repository.webhook.RecordDelivery"
+
+	record, err := r.storage.RecordDelivery(storage.WebhookDelivery{
+		WebhookID:   delivery.WebhookID.String(),
+		EventID:     delivery.EventID,
+		EventType:   string(delivery.EventType),
+		Attempt:     delivery.Attempt,
+		Success:     delivery.Success,
+		StatusCode:  delivery.StatusCode,
+		Error:       delivery.Error,
+		Duration:    delivery.Duration,
+		DeliveredAt: delivery.DeliveredAt,
+	}, disableAfter, keep)
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	webhook, err := castToModel(record)
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhook, nil
+}
+
+// ListDeliveries returns a page of the delivery log of the webhook, newest first.
+func (r *repository) ListDeliveries(id uuid.UUID, limit, offset int) ([]model.WebhookDelivery, error) {
+	const op = "repository.webhook.ListDeliveries"
+
+	records, err := r.storage.ListDeliveries(id.String(), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(records))
+	for _, record := range records {
+		deliveries = append(deliveries, model.WebhookDelivery{
+			ID:          record.ID,
+			WebhookID:   id,
+			EventID:     record.EventID,
+			EventType:   model.EventType(record.EventType),
+			Attempt:     record.Attempt,
+			Success:     record.Success,
+			StatusCode:  record.StatusCode,
+			Error:       record.Error,
+			Duration:    record.Duration,
+			DeliveredAt: record.DeliveredAt,
+		})
+	}
+
+	return deliveries, nil
+}
+
+func castToModel(r storage.Webhook) (model.Webhook, error) {
+	const op = "repository.webhook.castModel"
+
+	id, err := uuid.Parse(r.ID)
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	events := make([]model.EventType, 0, len(r.Events))
+	for _, event := range r.Events {
+		events = append(events, model.EventType(event))
+	}
+
+	return model.Webhook{
+		ID:         id,
+		URL:        r.URL,
+		Events:     events,
+		Secret:     r.Secret,
+		Active:     r.Active,
+		Failures:   r.Failures,
+		DisabledAt: r.DisabledAt,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}, nil
+}
+
+func castToStorage(w model.Webhook) storage.Webhook {
+	events := make([]string, 0, len(w.Events))
+	for _, event := range w.Events {
+		events = append(events, string(event))
+	}
+
+	return storage.Webhook{
+		ID:         w.ID.String(),
+		URL:        w.URL,
+		Events:     events,
+		Secret:     w.Secret,
+		Active:     w.Active,
+		Failures:   w.Failures,
+		DisabledAt: w.DisabledAt,
+		CreatedAt:  w.CreatedAt,
+		UpdatedAt:  w.UpdatedAt,
+	}
+}
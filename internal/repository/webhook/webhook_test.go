@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/internal/storage/sqlite"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func TestWebhook_CRUD(t *testing.T) {
+	for _, repo := range makeRepositoryProvider(t) {
+		webhook, err := model.NewWebhook("https://example.com/hook", []model.EventType{model.BookmarkDeleted}, "")
+		require.NoError(t, err)
+		require.NoError(t, repo.Create(webhook))
+
+		stored, err := repo.Get(webhook.ID)
+		require.NoError(t, err)
+		require.Equal(t, webhook.URL, stored.URL)
+		require.Equal(t, []model.EventType{model.BookmarkDeleted}, stored.Events)
+		require.Equal(t, webhook.Secret, stored.Secret)
+		require.True(t, stored.Active)
+
+		stored.Events = nil
+		stored.URL = "https://example.com/other"
+		require.NoError(t, repo.Update(stored))
+
+		webhooks, err := repo.List()
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		require.Equal(t, "https://example.com/other", webhooks[0].URL)
+		require.Empty(t, webhooks[0].Events)
+
+		require.NoError(t, repo.Delete(webhook.ID))
+
+		_, err = repo.Get(webhook.ID)
+		require.ErrorIs(t, err, core.ErrNotFound)
+		require.ErrorIs(t, repo.Update(stored), core.ErrNotFound)
+		require.ErrorIs(t, repo.Delete(webhook.ID), core.ErrNotFound)
+	}
+}
+
+func TestRecordDelivery_Disable(t *testing.T) {
+	now := time.Now()
+
+	for _, repo := range makeRepositoryProvider(t) {
+		webhook, err := model.NewWebhook("https://example.com/hook", nil, "secret")
+		require.NoError(t, err)
+		require.NoError(t, repo.Create(webhook))
+
+		record := func(success bool, event int64) model.Webhook {
+			webhook, err := repo.RecordDelivery(model.WebhookDelivery{
+				WebhookID:   webhook.ID,
+				EventID:     event,
+				EventType:   model.BookmarkAppended,
+				Attempt:     1,
+				Success:     success,
+				StatusCode:  500,
+				DeliveredAt: now,
+			}, 3, 4)
+			require.NoError(t, err)
+
+			return webhook
+		}
+
+		require.Equal(t, 1, record(false, 1).Failures)
+		require.Equal(t, 2, record(false, 2).Failures)
+		require.Zero(t, record(true, 3).Failures)
+		record(false, 4)
+		record(false, 5)
+
+		disabled := record(false, 6)
+		require.False(t, disabled.Active)
+		require.Equal(t, 3, disabled.Failures)
+		require.WithinDuration(t, now, disabled.DisabledAt, time.Second)
+
+		deliveries, err := repo.ListDeliveries(webhook.ID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 4)
+		require.Equal(t, int64(6), deliveries[0].EventID)
+		require.Equal(t, int64(3), deliveries[3].EventID)
+
+		deliveries, err = repo.ListDeliveries(webhook.ID, 2, 1)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.Equal(t, int64(5), deliveries[0].EventID)
+
+		_, err = repo.RecordDelivery(model.WebhookDelivery{WebhookID: model.Webhook{}.ID, DeliveredAt: now}, 3, 4)
+		require.ErrorIs(t, err, core.ErrNotFound)
+	}
+}
+
+func makeRepositoryProvider(t *testing.T) []*repository {
+	t.Helper()
+
+	var provider []*repository
+
+	// memory storage
+	provider = append(provider, NewRepository(memory.NewWebhookStorage()))
+
+	// sqlite storage
+	dbSourceName := "../../../storage/test_webhook.db"
+	_ = os.Remove(dbSourceName)
+
+	driver, err := pkgsql.New(pkgsql.SourceName(dbSourceName))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = driver.DB.Close()
+		_ = os.Remove(dbSourceName)
+	})
+
+	storage, err := sqlite.NewWebhook(driver)
+	require.NoError(t, err)
+
+	provider = append(provider, NewRepository(storage))
+
+	return provider
+}
@@ -0,0 +1,105 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/repository"
+)
+
+var (
+	ErrUserExists   = errors.New("user already exists")
+	ErrUserNotFound = errors.New("user not found")
+	ErrInvalidToken = errors.New("invalid or expired API token")
+)
+
+type Repository interface {
+	CreateUser(user model.User) error
+	GetUser(name string) (model.User, error)
+	CreateToken(token model.Token) error
+	FindToken(hash string) (model.Token, model.User, error)
+}
+
+// Service manages the users and authenticates the API tokens they are issued.
+// The tokens of admin users only are let in to the admin API.
+type Service struct {
+	repo Repository
+}
+
+func New(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// CreateUser creates a user, an admin may call the admin API.
+func (s *Service) CreateUser(name string, admin bool) (model.User, error) {
+	const op = "service.account.CreateUser"
+
+	user, err := model.NewUser(name, admin)
+	if err != nil {
+		return model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.CreateUser(user); err != nil {
+		if errors.Is(err, repository.ErrExists) {
+			return model.User{}, fmt.Errorf("%s: %w: %s", op, ErrUserExists, user.Name)
+		}
+
+		return model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// IssueToken issues a token to the user and returns it with its secret, which is not kept.
+// A positive ttl limits how long the token is valid.
+func (s *Service) IssueToken(userName, name string, ttl time.Duration) (model.Token, string, error) {
+	const op = "service.account.IssueToken"
+
+	user, err := s.repo.GetUser(userName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.Token{}, "", fmt.Errorf("%s: %w: %s", op, ErrUserNotFound, userName)
+		}
+
+		return model.Token{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	token, secret, err := model.NewToken(user, name, ttl)
+	if err != nil {
+		return model.Token{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.CreateToken(token); err != nil {
+		return model.Token{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, secret, nil
+}
+
+// Authenticate returns the principal of a token secret, ErrInvalidToken for an unknown or expired one.
+func (s *Service) Authenticate(secret string) (model.Principal, error) {
+	const op = "service.account.Authenticate"
+
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return model.Principal{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	token, user, err := s.repo.FindToken(model.HashToken(secret))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.Principal{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		return model.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if token.Expired(time.Now()) {
+		return model.Principal{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	return model.Principal{Name: user.Name, Admin: user.Admin, ExpiresAt: token.ExpiresAt}, nil
+}
@@ -0,0 +1,65 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	accountRepo "bookmarks/internal/repository/account"
+	"bookmarks/internal/storage/memory"
+)
+
+func TestService_Tokens(t *testing.T) {
+	s := New(accountRepo.NewRepository(memory.NewAccountStorage()))
+
+	_, err := s.CreateUser("alice", false)
+	require.NoError(t, err)
+
+	_, err = s.CreateUser("alice", true)
+	require.ErrorIs(t, err, ErrUserExists)
+
+	_, err = s.CreateUser("Not Valid", false)
+	require.ErrorIs(t, err, model.ErrInvalidUserName)
+
+	_, _, err = s.IssueToken("bob", "", 0)
+	require.ErrorIs(t, err, ErrUserNotFound)
+
+	_, secret, err := s.IssueToken("alice", "ci", 0)
+	require.NoError(t, err)
+
+	principal, err := s.Authenticate(secret)
+	require.NoError(t, err)
+	require.Equal(t, model.Principal{Name: "alice"}, principal)
+
+	_, err = s.CreateUser("root", true)
+	require.NoError(t, err)
+
+	_, rootSecret, err := s.IssueToken("root", "", 0)
+	require.NoError(t, err)
+
+	principal, err = s.Authenticate(rootSecret)
+	require.NoError(t, err)
+	require.Equal(t, model.Principal{Name: "root", Admin: true}, principal)
+
+	for _, wrong := range []string{"", "bmt_unknown", model.HashToken(secret)} {
+		_, err := s.Authenticate(wrong)
+		require.ErrorIs(t, err, ErrInvalidToken, wrong)
+	}
+
+	token, limited, err := s.IssueToken("alice", "limited", time.Hour)
+	require.NoError(t, err)
+
+	principal, err = s.Authenticate(limited)
+	require.NoError(t, err)
+	require.Equal(t, token.ExpiresAt, principal.ExpiresAt)
+
+	_, expired, err := s.IssueToken("alice", "short", time.Nanosecond)
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond)
+
+	_, err = s.Authenticate(expired)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
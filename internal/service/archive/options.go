@@ -0,0 +1,68 @@
+package archive
+
+import "time"
+
+type Option func(*Worker)
+
+// Workers sets the number of pages archived at the same time.
+func Workers(n int) Option {
+	return func(w *Worker) {
+		w.workers = n
+	}
+}
+
+// QueueSize sets how many bookmarks may wait for a worker before Enqueue refuses them.
+func QueueSize(n int) Option {
+	return func(w *Worker) {
+		w.queueSize = n
+	}
+}
+
+// Timeout limits archiving of a page along with all of its resources.
+func Timeout(timeout time.Duration) Option {
+	return func(w *Worker) {
+		w.timeout = timeout
+	}
+}
+
+// MaxPageSize limits the bytes of the HTML page.
+func MaxPageSize(size int64) Option {
+	return func(w *Worker) {
+		w.maxPageSize = size
+	}
+}
+
+// MaxResourceSize limits the bytes of a single stylesheet or image,
+// a larger one is left as a link to the original.
+func MaxResourceSize(size int64) Option {
+	return func(w *Worker) {
+		w.maxResourceSize = size
+	}
+}
+
+// MaxTotalSize limits the bytes of all resources inlined into a page.
+func MaxTotalSize(size int64) Option {
+	return func(w *Worker) {
+		w.maxTotalSize = size
+	}
+}
+
+// MaxResources limits the number of resources inlined into a page.
+func MaxResources(n int) Option {
+	return func(w *Worker) {
+		w.maxResources = n
+	}
+}
+
+func UserAgent(ua string) Option {
+	return func(w *Worker) {
+		w.userAgent = ua
+	}
+}
+
+// AllowPrivateNetworks lets the worker fetch loopback and private addresses.
+func AllowPrivateNetworks() Option {
+	return func(w *Worker) {
+		w.allowPrivate = true
+	}
+}
@@ -0,0 +1,460 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// maxCSSDepth bounds inlining of stylesheets imported by stylesheets.
+const maxCSSDepth = 2
+
+var (
+	ErrNotHTML  = errors.New("not an html page")
+	ErrTooLarge = errors.New("response is too large")
+)
+
+var (
+	cssURL    = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)`)
+	cssImport = regexp.MustCompile(`@import\s+(?:"([^"]*)"|'([^']*)')`)
+
+	// closingStyle would end a <style> element early, raw text cannot be escaped otherwise
+	closingStyle = regexp.MustCompile(`(?i)</style`)
+)
+
+// StatusError is a response with an unexpected status code.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.Code)
+}
+
+type page struct {
+	html       []byte
+	source     *url.URL // final URL of the page
+	resources  int
+	archivedAt time.Time
+}
+
+// builder turns a page into a self-contained document: stylesheets and images are
+// inlined as data URIs, scripts and frames are dropped, other links are made absolute.
+type builder struct {
+	w     *Worker
+	cache map[string]string // resource URL -> its replacement
+	total int64
+	count int
+}
+
+func (w *Worker) build(ctx context.Context, target string) (page, error) {
+	body, contentType, source, err := w.fetch(ctx, target, w.maxPageSize)
+	if err != nil {
+		return page{}, err
+	}
+
+	if contentType != "text/html" && contentType != "application/xhtml+xml" {
+		return page{}, fmt.Errorf("%w: %s", ErrNotHTML, contentType)
+	}
+
+	doc, err := html.ParseWithOptions(bytes.NewReader(body), html.ParseOptionEnableScripting(false))
+	if err != nil {
+		return page{}, err
+	}
+
+	b := &builder{w: w, cache: make(map[string]string)}
+	b.walk(ctx, doc, source)
+
+	annotate(doc, source)
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return page{}, err
+	}
+
+	return page{
+		html:       buf.Bytes(),
+		source:     source,
+		resources:  b.count,
+		archivedAt: time.Now(),
+	}, nil
+}
+
+func (b *builder) walk(ctx context.Context, n *html.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		if c.Type == html.ElementNode && !b.element(ctx, n, c, base) {
+			n.RemoveChild(c)
+			c = next
+
+			continue
+		}
+
+		if c.Type == html.ElementNode && c.DataAtom == atom.Noscript {
+			// scripts are gone, so the fallback content is what a reader should see
+			for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+				c.RemoveChild(gc)
+				n.InsertBefore(gc, c)
+			}
+
+			n.RemoveChild(c)
+			c = next
+
+			continue
+		}
+
+		b.walk(ctx, c, base)
+		c = next
+	}
+}
+
+// element rewrites the node in place and reports whether it should be kept.
+func (b *builder) element(ctx context.Context, parent, n *html.Node, base *url.URL) bool {
+	switch n.DataAtom {
+	case atom.Script, atom.Base, atom.Iframe, atom.Frame, atom.Object, atom.Embed:
+		return false
+	case atom.Meta:
+		equiv := strings.ToLower(attr(n, "http-equiv"))
+		if equiv == "refresh" || equiv == "content-type" || hasAttr(n, "charset") {
+			return false
+		}
+	case atom.Link:
+		if !b.link(ctx, n, base) {
+			return false
+		}
+	case atom.Img:
+		if src := attr(n, "src"); src != "" {
+			setAttr(n, "src", b.resource(ctx, src, base, 0))
+		}
+
+		removeAttr(n, "srcset")
+		removeAttr(n, "sizes")
+	case atom.Source:
+		if parent.DataAtom == atom.Picture {
+			return false // the <img> fallback of the picture is inlined instead
+		}
+
+		absolutize(n, "src", base)
+	case atom.Style:
+		if text := n.FirstChild; text != nil && text.Type == html.TextNode {
+			text.Data = b.css(ctx, text.Data, base, 0)
+		}
+	case atom.A, atom.Area:
+		absolutize(n, "href", base)
+	case atom.Form:
+		absolutize(n, "action", base)
+	case atom.Video, atom.Audio, atom.Track:
+		absolutize(n, "src", base)
+		absolutize(n, "poster", base)
+	}
+
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if strings.HasPrefix(strings.ToLower(a.Key), "on") {
+			continue // event handlers are scripts too
+		}
+
+		if strings.EqualFold(a.Key, "style") {
+			a.Val = b.css(ctx, a.Val, base, 0)
+		}
+
+		attrs = append(attrs, a)
+	}
+
+	n.Attr = attrs
+
+	return true
+}
+
+func (b *builder) link(ctx context.Context, n *html.Node, base *url.URL) bool {
+	href := attr(n, "href")
+	rels := strings.Fields(strings.ToLower(attr(n, "rel")))
+
+	for _, rel := range rels {
+		switch rel {
+		case "stylesheet":
+			css, ok := b.stylesheet(ctx, href, base)
+			if !ok {
+				absolutize(n, "href", base)
+				return true
+			}
+
+			// turn <link rel=stylesheet> into a <style> keeping its media query
+			media := attr(n, "media")
+
+			n.Data, n.DataAtom, n.Attr = "style", atom.Style, nil
+			if media != "" {
+				setAttr(n, "media", media)
+			}
+
+			n.AppendChild(&html.Node{Type: html.TextNode, Data: closingStyle.ReplaceAllString(css, `<\/style`)})
+
+			return true
+		case "icon", "apple-touch-icon":
+			setAttr(n, "href", b.resource(ctx, href, base, 0))
+			return true
+		case "preload", "prefetch", "modulepreload", "preconnect", "dns-prefetch", "manifest":
+			return false
+		}
+	}
+
+	absolutize(n, "href", base)
+
+	return true
+}
+
+// stylesheet fetches an external stylesheet and inlines its own references.
+func (b *builder) stylesheet(ctx context.Context, href string, base *url.URL) (string, bool) {
+	target, ok := b.reserve(href, base)
+	if !ok {
+		return "", false
+	}
+
+	body, _, final, err := b.fetch(ctx, target)
+	if err != nil {
+		return "", false
+	}
+
+	return b.css(ctx, string(body), final, 1), true
+}
+
+// css replaces url() and @import references with data URIs.
+func (b *builder) css(ctx context.Context, css string, base *url.URL, depth int) string {
+	css = cssImport.ReplaceAllStringFunc(css, func(m string) string {
+		sub := cssImport.FindStringSubmatch(m)
+		return `@import url("` + firstNonEmpty(sub[1:]...) + `")`
+	})
+
+	return cssURL.ReplaceAllStringFunc(css, func(m string) string {
+		sub := cssURL.FindStringSubmatch(m)
+		return `url("` + b.resource(ctx, firstNonEmpty(sub[1:]...), base, depth) + `")`
+	})
+}
+
+// resource returns a data URI of the referenced resource,
+// or its absolute URL when it cannot or may not be inlined.
+func (b *builder) resource(ctx context.Context, ref string, base *url.URL, depth int) string {
+	target, ok := b.reserve(ref, base)
+	if !ok {
+		return target
+	}
+
+	body, contentType, final, err := b.fetch(ctx, target)
+	if err != nil {
+		b.cache[target] = target
+		return target
+	}
+
+	if contentType == "text/css" && depth < maxCSSDepth {
+		body = []byte(b.css(ctx, string(body), final, depth+1))
+	}
+
+	data := "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(body)
+	b.cache[target] = data
+
+	return data
+}
+
+// reserve resolves the reference and tells whether it should be fetched:
+// data URIs, fragments and already seen resources are not.
+func (b *builder) reserve(ref string, base *url.URL) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(strings.ToLower(ref), "data:") {
+		return ref, false
+	}
+
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ref, false
+	}
+
+	u.Fragment = ""
+	target := u.String()
+
+	if cached, ok := b.cache[target]; ok {
+		return cached, false
+	}
+
+	if b.count >= b.w.maxResources {
+		return target, false
+	}
+
+	return target, true
+}
+
+func (b *builder) fetch(ctx context.Context, target string) ([]byte, string, *url.URL, error) {
+	limit := min(b.w.maxResourceSize, b.w.maxTotalSize-b.total)
+	if limit <= 0 {
+		return nil, "", nil, ErrTooLarge
+	}
+
+	body, contentType, final, err := b.w.fetch(ctx, target, limit)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	b.count++
+	b.total += int64(len(body))
+
+	if contentType == "" {
+		contentType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	return body, contentType, final, nil
+}
+
+// fetch reads at most limit bytes of the target, text is converted to UTF-8.
+func (w *Worker) fetch(ctx context.Context, target string, limit int64) ([]byte, string, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, http.NoBody)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	req.Header.Set("User-Agent", w.userAgent)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", nil, &StatusError{Code: resp.StatusCode}
+	}
+
+	header := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(header)
+
+	var body io.Reader = resp.Body
+	if strings.HasPrefix(mediaType, "text/") || mediaType == "application/xhtml+xml" {
+		if body, err = charset.NewReader(resp.Body, header); err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, "", nil, fmt.Errorf("%w: %s", ErrTooLarge, target)
+	}
+
+	return data, mediaType, resp.Request.URL, nil
+}
+
+// annotate declares the encoding of the archive and records where it comes from.
+// The archive time is kept out of the document, so an unchanged page dedupes in the blob store.
+func annotate(doc *html.Node, source *url.URL) {
+	head := find(doc, atom.Head)
+	if head == nil {
+		return
+	}
+
+	meta := []*html.Node{
+		{Type: html.ElementNode, Data: "meta", DataAtom: atom.Meta, Attr: []html.Attribute{
+			{Key: "charset", Val: "utf-8"},
+		}},
+		{Type: html.ElementNode, Data: "meta", DataAtom: atom.Meta, Attr: []html.Attribute{
+			{Key: "name", Val: "archive-source"},
+			{Key: "content", Val: source.String()},
+		}},
+	}
+
+	for i := len(meta) - 1; i >= 0; i-- {
+		head.InsertBefore(meta[i], head.FirstChild)
+	}
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, a); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func absolutize(n *html.Node, key string, base *url.URL) {
+	ref := attr(n, key)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return
+	}
+
+	if u, err := base.Parse(strings.TrimSpace(ref)); err == nil {
+		setAttr(n, key, u.String())
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func removeAttr(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if !strings.EqualFold(a.Key, key) {
+			attrs = append(attrs, a)
+		}
+	}
+
+	n.Attr = attrs
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,233 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"bookmarks/internal/model"
+	"bookmarks/pkg/blob"
+	"bookmarks/pkg/http/egress"
+)
+
+var (
+	ErrWorkerStopped = errors.New("archive worker is stopped")
+	ErrQueueFull     = errors.New("archive queue is full")
+	ErrNotURL        = errors.New("only url bookmarks can be archived")
+)
+
+type Repository interface {
+	SaveArchive(ctx context.Context, archive model.Archive) error
+}
+
+// Worker saves self-contained copies of URL bookmark pages into a blob store in the background.
+type Worker struct {
+	repo   Repository
+	store  blob.Store
+	log    *slog.Logger
+	client *http.Client
+
+	mu      sync.Mutex
+	queue   chan model.Bookmark
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	stopped bool
+
+	workers         int
+	queueSize       int
+	timeout         time.Duration
+	maxPageSize     int64
+	maxResourceSize int64
+	maxTotalSize    int64
+	maxResources    int
+	userAgent       string
+	allowPrivate    bool
+}
+
+func New(logger *slog.Logger, repo Repository, store blob.Store, options ...Option) *Worker {
+	w := &Worker{
+		repo:            repo,
+		store:           store,
+		log:             logger,
+		workers:         2,
+		queueSize:       256,
+		timeout:         time.Minute,
+		maxPageSize:     5 << 20,
+		maxResourceSize: 2 << 20,
+		maxTotalSize:    20 << 20,
+		maxResources:    100,
+		userAgent:       "bookmarks-bot/1.0",
+	}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	clientOptions := []egress.Option{egress.Timeout(w.timeout)}
+	if w.allowPrivate {
+		clientOptions = append(clientOptions, egress.AllowPrivateNetworks())
+	}
+
+	w.client = egress.NewClient(clientOptions...)
+
+	return w
+}
+
+// Start runs the worker pool until Shutdown.
+func (w *Worker) Start() {
+	const op = "service.archive.Start"
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w.mu.Lock()
+	w.queue = make(chan model.Bookmark, w.queueSize)
+	w.cancel = cancel
+	w.mu.Unlock()
+
+	for range w.workers {
+		w.wg.Go(func() {
+			for bookmark := range w.queue {
+				w.process(ctx, bookmark)
+			}
+		})
+	}
+
+	w.log.Info("Start", slog.String("op", op), slog.Int("workers", w.workers))
+}
+
+// Enqueue schedules an URL bookmark for archiving without blocking the caller.
+func (w *Worker) Enqueue(bookmark model.Bookmark) error {
+	const op = "service.archive.Enqueue"
+
+	if bookmark.Kind != model.KindURL {
+		return fmt.Errorf("%s: %w", op, ErrNotURL)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.queue == nil || w.stopped {
+		return fmt.Errorf("%s: %w", op, ErrWorkerStopped)
+	}
+
+	select {
+	case w.queue <- bookmark:
+		return nil
+	default:
+		w.log.Warn(ErrQueueFull.Error(), slog.String("op", op), slog.String("uuid", bookmark.Uuid.String()))
+		return fmt.Errorf("%s: %w", op, ErrQueueFull)
+	}
+}
+
+// Shutdown stops accepting bookmarks and waits for the queued ones,
+// in-flight requests are cancelled when ctx is done.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	const op = "service.archive.Shutdown"
+
+	w.mu.Lock()
+	if w.queue == nil || w.stopped {
+		w.mu.Unlock()
+		return nil
+	}
+
+	w.stopped = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+
+	w.cancel()
+	<-done
+
+	w.log.Info("Shutdown", slog.String("op", op))
+
+	return err
+}
+
+// Open returns the archived HTML page.
+func (w *Worker) Open(archive model.Archive) (io.ReadCloser, error) {
+	const op = "service.archive.Open"
+
+	r, err := w.store.Open(archive.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// Archive fetches the bookmarked page with its stylesheets and images,
+// stores the self-contained result and records it for the bookmark.
+func (w *Worker) Archive(ctx context.Context, bookmark model.Bookmark) (model.Archive, error) {
+	const op = "service.archive.Archive"
+
+	if bookmark.Kind != model.KindURL {
+		return model.Archive{}, fmt.Errorf("%s: %w", op, ErrNotURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	page, err := w.build(ctx, bookmark.Value)
+	if err != nil {
+		return model.Archive{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stored, err := w.store.Put(bytes.NewReader(page.html))
+	if err != nil {
+		return model.Archive{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	archive := model.Archive{
+		Uuid:       bookmark.Uuid,
+		Digest:     stored.Digest,
+		Size:       stored.Size,
+		Resources:  page.resources,
+		SourceURL:  page.source.String(),
+		ArchivedAt: page.archivedAt,
+	}
+
+	if err := w.repo.SaveArchive(ctx, archive); err != nil {
+		return model.Archive{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return archive, nil
+}
+
+func (w *Worker) process(ctx context.Context, bookmark model.Bookmark) {
+	const op = "service.archive.process"
+
+	log := w.log.With(
+		slog.String("op", op),
+		slog.String("uuid", bookmark.Uuid.String()),
+	)
+
+	archive, err := w.Archive(ctx, bookmark)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	log.Info("archived",
+		slog.String("digest", archive.Digest),
+		slog.Int64("size", archive.Size),
+		slog.Int("resources", archive.Resources),
+	)
+}
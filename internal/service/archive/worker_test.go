@@ -0,0 +1,243 @@
+package archive
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	repo "bookmarks/internal/repository/bookmark"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/pkg/blob"
+	"bookmarks/pkg/blob/fsstore"
+	"bookmarks/pkg/http/egress"
+)
+
+// pixel is a 1x1 transparent PNG.
+var pixel, _ = base64.StdEncoding.DecodeString(
+	"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII=")
+
+const article = `<!doctype html>
+<html><head>
+	<meta charset="windows-1252">
+	<title>Article</title>
+	<link rel="stylesheet" href="/css/style.css" media="screen">
+	<link rel="preload" href="/font.woff2">
+	<script src="/app.js"></script>
+</head><body onload="track()">
+	<h1 style="background: url('/img/bg.png')">Caf` + "\xe9" + `</h1>
+	<img src="img/logo.png" srcset="/img/logo@2x.png 2x" alt="logo">
+	<img src="/img/missing.png">
+	<a href="/next">next</a>
+	<iframe src="https://ads.example.com"></iframe>
+	<noscript><p>no script</p></noscript>
+</body></html>`
+
+func TestArchive_Inline(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	bookmark := makeBookmark(t, repository, server.URL+"/article")
+	worker, store := makeWorker(t, repository)
+
+	archive, err := worker.Archive(context.Background(), bookmark)
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/article", archive.SourceURL)
+	require.Equal(t, 5, archive.Resources) // style, import, background, font in css, logo
+
+	stored, err := repository.GetArchive(t.Context(), bookmark.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, archive.Digest, stored.Digest)
+
+	doc := readBlob(t, store, archive.Digest)
+
+	require.NotContains(t, doc, "<script")
+	require.NotContains(t, doc, "<iframe")
+	require.NotContains(t, doc, "onload")
+	require.NotContains(t, doc, "srcset")
+	require.NotContains(t, doc, "preload")
+	require.NotContains(t, doc, "/css/style.css")
+	require.NotContains(t, doc, "windows-1252")
+	require.Contains(t, doc, `<meta charset="utf-8"/>`)
+	require.Contains(t, doc, "Café")
+	require.Contains(t, doc, `<style media="screen">`)
+	require.Contains(t, doc, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(pixel))
+	require.Contains(t, doc, `@import url("data:text/css;base64,`)
+	require.Contains(t, doc, `href="`+server.URL+`/next"`)
+	require.Contains(t, doc, `src="`+server.URL+`/img/missing.png"`)
+	require.Contains(t, doc, "<p>no script</p>")
+	require.NotContains(t, doc, "&lt;/style")
+	require.NotContains(t, doc, "</style><b>")
+}
+
+func TestArchive_Dedupe(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	first := makeBookmark(t, repository, server.URL+"/article")
+	second := makeBookmark(t, repository, server.URL+"/article?copy=1")
+	worker, _ := makeWorker(t, repository)
+
+	a, err := worker.Archive(context.Background(), first)
+	require.NoError(t, err)
+
+	b, err := worker.Archive(context.Background(), first)
+	require.NoError(t, err)
+	require.Equal(t, a.Digest, b.Digest)
+
+	c, err := worker.Archive(context.Background(), second)
+	require.NoError(t, err)
+	require.NotEqual(t, a.Digest, c.Digest) // the source URL is recorded in the document
+}
+
+func TestArchive_Limits(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	bookmark := makeBookmark(t, repository, server.URL+"/article")
+	worker, store := makeWorker(t, repository, MaxResources(1))
+
+	archive, err := worker.Archive(context.Background(), bookmark)
+	require.NoError(t, err)
+	require.Equal(t, 1, archive.Resources)
+
+	doc := readBlob(t, store, archive.Digest)
+	require.Contains(t, doc, `src="`+server.URL+`/img/logo.png"`)
+
+	text := makeBookmark(t, repository, "just a note")
+	_, err = worker.Archive(context.Background(), text)
+	require.ErrorIs(t, err, ErrNotURL)
+
+	image := makeBookmark(t, repository, server.URL+"/img/logo.png")
+	_, err = worker.Archive(context.Background(), image)
+	require.ErrorIs(t, err, ErrNotHTML)
+
+	worker, _ = makeWorker(t, repository, MaxPageSize(64))
+	_, err = worker.Archive(context.Background(), bookmark)
+	require.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestArchive_DenyPrivate(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	bookmark := makeBookmark(t, repository, server.URL+"/article")
+
+	store, err := fsstore.New(fsstore.Dir(t.TempDir()))
+	require.NoError(t, err)
+
+	worker := New(slog.New(slog.DiscardHandler), repository, store)
+
+	_, err = worker.Archive(context.Background(), bookmark)
+	require.ErrorIs(t, err, egress.ErrPrivateAddress)
+}
+
+func TestWorker_Enqueue(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	bookmark := makeBookmark(t, repository, server.URL+"/article")
+	worker, store := makeWorker(t, repository)
+
+	require.ErrorIs(t, worker.Enqueue(bookmark), ErrWorkerStopped)
+
+	worker.Start()
+	require.NoError(t, worker.Enqueue(bookmark))
+	require.NoError(t, worker.Shutdown(context.Background()))
+
+	archive, err := repository.GetArchive(t.Context(), bookmark.Uuid)
+	require.NoError(t, err)
+
+	r, err := worker.Open(archive)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	_, err = store.Stat(archive.Digest)
+	require.NoError(t, err)
+}
+
+func newSite() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1252")
+		_, _ = fmt.Fprint(w, article)
+	})
+	mux.HandleFunc("/css/style.css", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		_, _ = fmt.Fprint(w, `@import "more.css"; body { background: url(../img/bg.png) } </style><b>`)
+	})
+	mux.HandleFunc("/css/more.css", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		_, _ = fmt.Fprint(w, `@font-face { src: url("/font.woff2") }`)
+	})
+	mux.HandleFunc("/font.woff2", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "font/woff2")
+		_, _ = fmt.Fprint(w, "wOF2")
+	})
+	mux.HandleFunc("/img/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "missing") {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pixel)
+	})
+
+	return mux
+}
+
+func makeWorker(t *testing.T, repository Repository, options ...Option) (*Worker, blob.Store) {
+	t.Helper()
+
+	store, err := fsstore.New(fsstore.Dir(t.TempDir()))
+	require.NoError(t, err)
+
+	options = append([]Option{AllowPrivateNetworks()}, options...)
+
+	return New(slog.New(slog.DiscardHandler), repository, store, options...), store
+}
+
+func readBlob(t *testing.T, store blob.Store, digest string) string {
+	t.Helper()
+
+	r, err := store.Open(digest)
+	require.NoError(t, err)
+
+	defer r.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, digest, blob.Digest(data))
+
+	return string(data)
+}
+
+type creator interface {
+	Create(ctx context.Context, bookmark model.Bookmark) (model.Bookmark, error)
+}
+
+func makeBookmark(t *testing.T, repository creator, value string) model.Bookmark {
+	t.Helper()
+
+	bookmark, err := model.NewBookmark("title", value)
+	require.NoError(t, err)
+
+	bookmark, err = repository.Create(t.Context(), bookmark)
+	require.NoError(t, err)
+
+	return bookmark
+}
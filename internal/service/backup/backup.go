@@ -0,0 +1,361 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/pkg/sqlite"
+)
+
+var (
+	ErrBackupNotFound   = errors.New("backup not found")
+	ErrInvalidBackup    = errors.New("invalid backup")
+	ErrChecksumMismatch = errors.New("backup does not match its manifest checksum")
+	ErrSchemaNewer      = errors.New("backup schema is newer than this build supports")
+	ErrDatabaseInUse    = errors.New("database is in use, stop the server")
+)
+
+const (
+	createKind = "backup.create"
+
+	prefix       = "bookmarks-"
+	ext          = ".db"
+	manifestExt  = ".json"
+	nameTimeForm = "20060102T150405.000Z"
+
+	// orphanAge spares a backup whose manifest another process is still writing
+	orphanAge = time.Minute
+)
+
+// Database writes a consistent copy of itself, see sqlite.Sqlite.BackupTo.
+type Database interface {
+	BackupTo(path string) error
+}
+
+// Queue runs the scheduled backups, see jobs.Queue.
+type Queue interface {
+	Handle(kind string, handler jobs.Handler)
+	Schedule(name, spec, kind string, payload any) error
+}
+
+// manifest is stored next to its backup as <name>.json.
+type manifest struct {
+	File          string    `json:"file"`
+	Size          int64     `json:"size"`
+	SHA256        string    `json:"sha256"`
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Service takes online backups of the database into a directory, keeping the newest ones.
+type Service struct {
+	db  Database
+	log *slog.Logger
+
+	mu sync.Mutex // one backup at a time
+
+	dir       string
+	retention int
+}
+
+func New(logger *slog.Logger, db Database, options ...Option) *Service {
+	s := &Service{
+		db:        db,
+		log:       logger,
+		dir:       "./storage/backups",
+		retention: 7,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	s.retention = max(s.retention, 1)
+
+	return s
+}
+
+// Schedule takes a backup on every run time of spec, see jobs.ParseSchedule.
+// The backups run on the queue, so instances sharing the storage take each one once.
+func (s *Service) Schedule(queue Queue, spec string) error {
+	queue.Handle(createKind, func(ctx context.Context, _ model.Job) error {
+		_, err := s.Backup(ctx)
+		return err
+	})
+
+	return queue.Schedule(createKind, spec, createKind, struct{}{})
+}
+
+// Backup copies the database while it is in use, writes the manifest and removes
+// the backups beyond the retention.
+func (s *Service) Backup(ctx context.Context) (model.Backup, error) {
+	const op = "service.backup.Backup"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().UTC()
+	name := prefix + now.Format(nameTimeForm) + ext
+	path := filepath.Join(s.dir, name)
+
+	// the copy gets its final name once it is complete, a crash leaves a .tmp file only
+	tmp := path + ".tmp"
+	defer os.Remove(tmp) //nolint:errcheck // a no-op once renamed
+
+	if err := s.db.BackupTo(tmp); err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	backup, err := inspect(tmp)
+	if err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	backup.Name = name
+	backup.CreatedAt = now
+
+	if err := os.Rename(tmp, path); err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := writeManifest(path, backup); err != nil {
+		_ = os.Remove(path)
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("backup created",
+		slog.String("op", op),
+		slog.String("name", name),
+		slog.Int64("size", backup.Size),
+		slog.Int("schema_version", backup.SchemaVersion),
+	)
+
+	s.prune()
+
+	return backup, nil
+}
+
+// List returns the backups of the directory, newest first. A manifest that cannot be read
+// is logged and left out.
+func (s *Service) List() ([]model.Backup, error) {
+	const op = "service.backup.List"
+
+	backups, err := s.list()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return backups, nil
+}
+
+// Path returns the file of the named backup.
+func (s *Service) Path(name string) (string, error) {
+	const op = "service.backup.Path"
+
+	if name != filepath.Base(name) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return "", fmt.Errorf("%s: %w: %q", op, ErrBackupNotFound, name)
+	}
+
+	path := filepath.Join(s.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%s: %w: %q", op, ErrBackupNotFound, name)
+	}
+
+	return path, nil
+}
+
+// prune removes the backups beyond the retention and the backup files left without a manifest,
+// e.g. by a crash between the copy and its manifest.
+func (s *Service) prune() {
+	const op = "service.backup.prune"
+
+	backups, err := s.list()
+	if err != nil {
+		s.log.Error(err.Error(), slog.String("op", op))
+		return
+	}
+
+	for _, backup := range backups[min(s.retention, len(backups)):] {
+		path := filepath.Join(s.dir, backup.Name)
+
+		for _, file := range []string{path, manifestPath(path)} {
+			if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				s.log.Error(err.Error(), slog.String("op", op))
+			}
+		}
+
+		s.log.Info("backup removed", slog.String("op", op), slog.String("name", backup.Name))
+	}
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, prefix+"*"+ext))
+	if err != nil {
+		s.log.Error(err.Error(), slog.String("op", op))
+		return
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(manifestPath(path)); !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < orphanAge {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			s.log.Error(err.Error(), slog.String("op", op))
+			continue
+		}
+
+		s.log.Warn("backup without manifest removed", slog.String("op", op), slog.String("name", filepath.Base(path)))
+	}
+}
+
+// list reads the manifests of the directory, newest first.
+func (s *Service) list() ([]model.Backup, error) {
+	const op = "service.backup.list"
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, prefix+"*"+manifestExt))
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]model.Backup, 0, len(paths))
+
+	for _, path := range paths {
+		m, err := readManifest(path)
+		if err != nil {
+			s.log.Warn("backup skipped", slog.String("op", op), slog.String("manifest", filepath.Base(path)), slog.Any("error", err))
+			continue
+		}
+
+		backups = append(backups, m.backup())
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// inspect reads the size, checksum and schema version of a backup file.
+func inspect(path string) (model.Backup, error) {
+	size, sum, err := checksum(path)
+	if err != nil {
+		return model.Backup{}, err
+	}
+
+	version, err := schemaVersion(path)
+	if err != nil {
+		return model.Backup{}, err
+	}
+
+	return model.Backup{Name: filepath.Base(path), Size: size, SHA256: sum, SchemaVersion: version}, nil
+}
+
+func checksum(path string) (int64, string, error) {
+	file, err := os.Open(path) //nolint:gosec // a backup path
+	if err != nil {
+		return 0, "", err
+	}
+
+	defer file.Close() //nolint:errcheck
+
+	hash := sha256.New()
+
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// schemaVersion opens the backup read-only, it is never created nor migrated.
+func schemaVersion(path string) (int, error) {
+	db, err := open(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer db.DB.Close() //nolint:errcheck
+
+	return db.Version()
+}
+
+func open(path string) (*sqlite.Sqlite, error) {
+	return sqlite.New(sqlite.SourceName("file:" + path + "?mode=ro"))
+}
+
+func manifestPath(path string) string {
+	return strings.TrimSuffix(path, ext) + manifestExt
+}
+
+func writeManifest(path string, backup model.Backup) error {
+	data, err := json.MarshalIndent(manifest{
+		File:          backup.Name,
+		Size:          backup.Size,
+		SHA256:        backup.SHA256,
+		SchemaVersion: backup.SchemaVersion,
+		CreatedAt:     backup.CreatedAt,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := manifestPath(path) + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, manifestPath(path))
+}
+
+func readManifest(path string) (manifest, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // a manifest path
+	if err != nil {
+		return manifest{}, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return manifest{}, fmt.Errorf("%w: manifest %s: %w", ErrInvalidBackup, filepath.Base(path), err)
+	}
+
+	return m, nil
+}
+
+func (m manifest) backup() model.Backup {
+	return model.Backup{
+		Name:          m.File,
+		Size:          m.Size,
+		SHA256:        m.SHA256,
+		SchemaVersion: m.SchemaVersion,
+		CreatedAt:     m.CreatedAt,
+	}
+}
@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/internal/storage/sqlite"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func makeDatabase(t *testing.T, path string, values ...string) *pkgsql.Sqlite {
+	t.Helper()
+
+	driver, err := pkgsql.New(pkgsql.SourceName(path))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = driver.DB.Close() })
+
+	_, err = sqlite.NewBookmark(driver)
+	require.NoError(t, err)
+
+	for _, value := range values {
+		_, err = driver.DB.Exec("INSERT INTO bookmark(uuid, title, value, created_at) VALUES(?, ?, ?, ?)",
+			value, "title", value, time.Now())
+		require.NoError(t, err)
+	}
+
+	return driver
+}
+
+func count(t *testing.T, path string) int {
+	t.Helper()
+
+	driver, err := pkgsql.New(pkgsql.SourceName(path))
+	require.NoError(t, err)
+
+	defer driver.DB.Close() //nolint:errcheck
+
+	var n int
+	require.NoError(t, driver.DB.QueryRow("SELECT COUNT(*) FROM bookmark").Scan(&n))
+
+	return n
+}
+
+func TestBackup_Retention(t *testing.T) {
+	dir := t.TempDir()
+	driver := makeDatabase(t, filepath.Join(dir, "main.db"), "a", "b")
+	s := New(slog.New(slog.DiscardHandler), driver, Dir(filepath.Join(dir, "backups")), Retention(2))
+
+	var names []string
+
+	for range 3 {
+		backup, err := s.Backup(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, sqlite.SchemaVersion(), backup.SchemaVersion)
+		require.Positive(t, backup.Size)
+		require.Len(t, backup.SHA256, 64)
+
+		names = append(names, backup.Name)
+
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	backups, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Equal(t, names[2], backups[0].Name)
+	require.Equal(t, names[1], backups[1].Name)
+
+	_, err = s.Path(names[0])
+	require.ErrorIs(t, err, ErrBackupNotFound)
+
+	_, err = s.Path("../main.db")
+	require.ErrorIs(t, err, ErrBackupNotFound)
+
+	path, err := s.Path(names[2])
+	require.NoError(t, err)
+	require.Equal(t, 2, count(t, path))
+
+	files, err := filepath.Glob(filepath.Join(dir, "backups", "*"))
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+	driver := makeDatabase(t, filepath.Join(dir, "main.db"), "a", "b")
+	s := New(slog.New(slog.DiscardHandler), driver, Dir(dir))
+
+	backup, err := s.Backup(context.Background())
+	require.NoError(t, err)
+
+	path, err := s.Path(backup.Name)
+	require.NoError(t, err)
+
+	verified, err := Verify(path, sqlite.SchemaVersion())
+	require.NoError(t, err)
+	require.Equal(t, backup, verified)
+
+	_, err = Verify(path, sqlite.SchemaVersion()-1)
+	require.ErrorIs(t, err, ErrSchemaNewer)
+
+	target := filepath.Join(dir, "restored.db")
+	makeDatabase(t, target, "c")
+
+	// a server holds the shared lock while it runs
+	unlock, err := pkgsql.LockShared(target)
+	require.NoError(t, err)
+
+	_, err = Restore(path, target, sqlite.SchemaVersion())
+	require.ErrorIs(t, err, ErrDatabaseInUse)
+	require.Equal(t, 1, count(t, target))
+	require.NoError(t, unlock())
+
+	restored, err := Restore(path, target, sqlite.SchemaVersion())
+	require.NoError(t, err)
+	require.Equal(t, backup.Name, restored.Name)
+	require.Equal(t, 2, count(t, target))
+	require.Equal(t, 1, count(t, target+".pre-restore"))
+}
+
+func TestList_Unreadable(t *testing.T) {
+	dir := t.TempDir()
+	driver := makeDatabase(t, filepath.Join(dir, "main.db"), "a")
+	s := New(slog.New(slog.DiscardHandler), driver, Dir(dir), Retention(5))
+
+	backup, err := s.Backup(context.Background())
+	require.NoError(t, err)
+
+	// a torn manifest keeps its backup out of the list, the others are listed
+	broken := filepath.Join(dir, prefix+"20000101T000000.000Z")
+	require.NoError(t, os.WriteFile(broken+ext, []byte("backup"), 0o600))
+	require.NoError(t, os.WriteFile(broken+manifestExt, []byte(`{"file":`), 0o600))
+
+	list, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, backup.Name, list[0].Name)
+
+	// a backup without a manifest is removed once it is old enough
+	old := filepath.Join(dir, prefix+"20000102T000000.000Z"+ext)
+	fresh := filepath.Join(dir, prefix+"20000103T000000.000Z"+ext)
+	require.NoError(t, os.WriteFile(old, []byte("backup"), 0o600))
+	require.NoError(t, os.WriteFile(fresh, []byte("backup"), 0o600))
+	require.NoError(t, os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+	require.NoError(t, os.Chtimes(broken+ext, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+	_, err = s.Backup(context.Background())
+	require.NoError(t, err)
+
+	_, err = os.Stat(old)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.FileExists(t, fresh)
+	require.FileExists(t, broken+ext)
+}
+
+func TestVerify_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	driver := makeDatabase(t, filepath.Join(dir, "main.db"), "a")
+	s := New(slog.New(slog.DiscardHandler), driver, Dir(dir))
+
+	backup, err := s.Backup(context.Background())
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, backup.Name)
+
+	_, err = Verify(filepath.Join(dir, "missing.db"), sqlite.SchemaVersion())
+	require.ErrorIs(t, err, ErrBackupNotFound)
+
+	_, err = Verify(filepath.Join(dir, "main.db"), sqlite.SchemaVersion())
+	require.ErrorIs(t, err, ErrInvalidBackup)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString("tampered")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	target := filepath.Join(dir, "target.db")
+
+	_, err = Restore(path, target, sqlite.SchemaVersion())
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+
+	_, err = os.Stat(target)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+type queue struct {
+	handlers  map[string]jobs.Handler
+	schedules map[string]string
+}
+
+func (q *queue) Handle(kind string, handler jobs.Handler) {
+	q.handlers[kind] = handler
+}
+
+func (q *queue) Schedule(name, spec, _ string, _ any) error {
+	if _, err := jobs.ParseSchedule(spec); err != nil {
+		return err
+	}
+
+	q.schedules[name] = spec
+
+	return nil
+}
+
+func TestSchedule(t *testing.T) {
+	dir := t.TempDir()
+	driver := makeDatabase(t, filepath.Join(dir, "main.db"))
+	s := New(slog.New(slog.DiscardHandler), driver, Dir(dir))
+	q := &queue{handlers: map[string]jobs.Handler{}, schedules: map[string]string{}}
+
+	require.ErrorIs(t, s.Schedule(q, "@sometimes"), jobs.ErrInvalidSchedule)
+	require.NoError(t, s.Schedule(q, "@daily"))
+	require.Equal(t, "@daily", q.schedules[createKind])
+
+	require.NoError(t, q.handlers[createKind](context.Background(), model.Job{Kind: createKind}))
+
+	backups, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+}
@@ -0,0 +1,17 @@
+package backup
+
+type Option func(*Service)
+
+// Dir sets the directory the backups and their manifests are written to.
+func Dir(path string) Option {
+	return func(s *Service) {
+		s.dir = path
+	}
+}
+
+// Retention sets how many of the newest backups are kept, the older ones are removed after a backup.
+func Retention(n int) Option {
+	return func(s *Service) {
+		s.retention = n
+	}
+}
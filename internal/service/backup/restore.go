@@ -0,0 +1,169 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"bookmarks/internal/model"
+	"bookmarks/pkg/sqlite"
+)
+
+// Verify checks the backup at path against its manifest: the checksum, the integrity of
+// the database and its schema version, which must not be newer than schemaVersion.
+// An older schema is migrated when the restored database is opened.
+func Verify(path string, schemaVersion int) (model.Backup, error) {
+	const op = "service.backup.Verify"
+
+	if _, err := os.Stat(path); err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w: %s", op, ErrBackupNotFound, path)
+	}
+
+	m, err := readManifest(manifestPath(path))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return model.Backup{}, fmt.Errorf("%s: %w: no manifest %s", op, ErrInvalidBackup, filepath.Base(manifestPath(path)))
+		}
+
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if m.File != filepath.Base(path) {
+		return model.Backup{}, fmt.Errorf("%s: %w: manifest is for %s", op, ErrInvalidBackup, m.File)
+	}
+
+	size, sum, err := checksum(path)
+	if err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if size != m.Size || sum != m.SHA256 {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, ErrChecksumMismatch)
+	}
+
+	db, err := open(path)
+	if err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer db.DB.Close() //nolint:errcheck
+
+	problems, err := db.IntegrityCheck()
+	if err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidBackup, err)
+	}
+
+	if len(problems) > 0 {
+		return model.Backup{}, fmt.Errorf("%s: %w: %s", op, ErrInvalidBackup, problems[0])
+	}
+
+	version, err := db.Version()
+	if err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch {
+	case version == 0:
+		return model.Backup{}, fmt.Errorf("%s: %w: not a bookmarks database", op, ErrInvalidBackup)
+	case version != m.SchemaVersion:
+		return model.Backup{}, fmt.Errorf("%s: %w: schema version %d, manifest says %d", op, ErrInvalidBackup, version, m.SchemaVersion)
+	case version > schemaVersion:
+		return model.Backup{}, fmt.Errorf("%s: %w: version %d, supported %d", op, ErrSchemaNewer, version, schemaVersion)
+	}
+
+	return m.backup(), nil
+}
+
+// Restore verifies the backup at path and swaps it in place of the database at target.
+// The replaced database and its WAL files are kept with the .pre-restore suffix.
+// The server must be stopped: an open connection keeps using the replaced file, so
+// ErrDatabaseInUse is returned while a server holds the lock of target, see sqlite.LockShared.
+func Restore(path, target string, schemaVersion int) (model.Backup, error) {
+	const op = "service.backup.Restore"
+
+	// the lock is held until the swap is done, a server starting meanwhile fails
+	unlock, err := sqlite.Lock(target)
+	if err != nil {
+		if errors.Is(err, sqlite.ErrLocked) {
+			return model.Backup{}, fmt.Errorf("%s: %w: %s", op, ErrDatabaseInUse, target)
+		}
+
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer unlock() //nolint:errcheck
+
+	backup, err := Verify(path, schemaVersion)
+	if err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the copy is written next to the target, so the swap is a rename
+	tmp := target + ".restore"
+	defer os.Remove(tmp) //nolint:errcheck // a no-op once renamed
+
+	sum, err := copyFile(path, tmp)
+	if err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sum != backup.SHA256 {
+		return model.Backup{}, fmt.Errorf("%s: %w: copy to %s", op, ErrChecksumMismatch, tmp)
+	}
+
+	// a WAL left next to the restored file would be replayed into it
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		kept := target + ".pre-restore" + suffix
+		if err := os.Remove(kept); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		err := os.Rename(target+suffix, kept)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := os.Rename(tmp, target); err != nil {
+		return model.Backup{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return backup, nil
+}
+
+// copyFile copies and syncs src to dst, it returns the checksum of the written data.
+func copyFile(src, dst string) (string, error) {
+	in, err := os.Open(src) //nolint:gosec // a backup path
+	if err != nil {
+		return "", err
+	}
+
+	defer in.Close() //nolint:errcheck
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:gosec // next to the database
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+
+	_, err = io.Copy(io.MultiWriter(out, hash), in)
+	if err == nil {
+		err = out.Sync()
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,59 @@
+package bookmark
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/tracing"
+)
+
+// The batch reads serve the bookmarks of a page in one storage call each,
+// a bookmark without the data is missing from the result.
+
+// MetadataOf returns the link metadata of the given bookmarks by uuid.
+func (s *service) MetadataOf(ctx context.Context, uuids []uuid.UUID) (_ map[uuid.UUID]model.Metadata, err error) {
+	const op = "service.bookmark.MetadataOf"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	metadata, err := s.repo.ListMetadata(ctx, uuids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return metadata, nil
+}
+
+// HealthOf returns the current link health of the given bookmarks by uuid.
+func (s *service) HealthOf(ctx context.Context, uuids []uuid.UUID) (_ map[uuid.UUID]model.LinkHealth, err error) {
+	const op = "service.bookmark.HealthOf"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	health, err := s.repo.ListLinkHealth(ctx, uuids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return health, nil
+}
+
+// ChecksOf returns the last limit link checks of the given bookmarks by uuid, newest first.
+func (s *service) ChecksOf(ctx context.Context, uuids []uuid.UUID, limit int) (_ map[uuid.UUID][]model.LinkCheck, err error) {
+	const op = "service.bookmark.ChecksOf"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	checks, err := s.repo.ListRecentLinkChecks(ctx, uuids, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return checks, nil
+}
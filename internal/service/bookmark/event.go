@@ -0,0 +1,31 @@
+package bookmark
+
+import (
+	"time"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/repository"
+)
+
+// bookmarkEvents is the domain event of a bookmark change,
+// the repository writes it to the outbox in the transaction of the change.
+func bookmarkEvents(change repository.Change, bookmark model.Bookmark) []model.Event {
+	var eventType model.EventType
+
+	switch change {
+	case repository.ChangeCreated:
+		eventType = model.BookmarkAppended
+	case repository.ChangeUpdated:
+		eventType = model.BookmarkChanged
+	case repository.ChangeDeleted:
+		eventType = model.BookmarkDeleted
+	default:
+		return nil
+	}
+
+	return []model.Event{{
+		Type:       eventType,
+		Bookmark:   bookmark,
+		OccurredAt: time.Now(),
+	}}
+}
@@ -1,70 +1,200 @@
 package bookmark
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 
 	"github.com/google/uuid"
 
+	"bookmarks/internal/logger"
 	"bookmarks/internal/model"
 	"bookmarks/internal/repository"
+	"bookmarks/internal/tracing"
 )
 
 var (
-	ErrBookmarkExists   = errors.New("bookmark already exists")
-	ErrBookmarkNotFound = errors.New("bookmark not found")
+	ErrBookmarkExists      = errors.New("bookmark already exists")
+	ErrBookmarkNotFound    = errors.New("bookmark not found")
+	ErrBookmarkDeleted     = errors.New("bookmark was deleted")
+	ErrBookmarkChanged     = errors.New("bookmark was changed since the given version")
+	ErrInvalidConflictMode = errors.New("invalid conflict mode")
+	ErrMetadataNotFound    = errors.New("bookmark metadata not found")
+	ErrHealthNotFound      = errors.New("link health not found")
+	ErrArchiveNotFound     = errors.New("bookmark archive not found")
+	ErrArchiveDisabled     = errors.New("archiving is disabled")
+	ErrArchiveUnavailable  = errors.New("archiving is unavailable")
+	ErrNotArchivable       = errors.New("only url bookmarks can be archived")
 )
 
+// ConflictMode tells Append what to do when the value is already bookmarked.
+// An empty mode means ConflictError, an unknown one fails with ErrInvalidConflictMode.
+type ConflictMode string
+
+const (
+	ConflictError       ConflictMode = "error"
+	ConflictIgnore      ConflictMode = "ignore"
+	ConflictUpdateTitle ConflictMode = "update_title"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListFilter selects a page of bookmarks, newest first.
+type ListFilter struct {
+	Kind   model.Kind
+	Health model.HealthStatus
+	Limit  int
+	Offset int
+}
+
+// Normalize applies the default limit and clamps the page bounds.
+func (f ListFilter) Normalize() ListFilter {
+	switch {
+	case f.Limit <= 0:
+		f.Limit = DefaultListLimit
+	case f.Limit > MaxListLimit:
+		f.Limit = MaxListLimit
+	}
+
+	f.Offset = max(f.Offset, 0)
+
+	return f
+}
+
 type Repository interface {
-	Create(bookmark model.Bookmark) (model.Bookmark, error)
-	Update(bookmark model.Bookmark) (model.Bookmark, error)
-	GetByUUID(uuid uuid.UUID) (model.Bookmark, error)
-	GetByValue(val string) (model.Bookmark, error)
-	Delete(uuid uuid.UUID) error
+	Create(ctx context.Context, bookmark model.Bookmark) (model.Bookmark, error)
+	Append(ctx context.Context, bookmark model.Bookmark, mode repository.OnConflict, emit repository.Emit) (model.Bookmark, error)
+	Update(ctx context.Context, bookmark model.Bookmark, version int64, emit repository.Emit) (model.Bookmark, error)
+	GetByUUID(ctx context.Context, uuid uuid.UUID) (model.Bookmark, error)
+	GetByValue(ctx context.Context, val string) (model.Bookmark, error)
+	List(ctx context.Context, filter repository.Filter) ([]model.Bookmark, error)
+	Delete(ctx context.Context, uuid uuid.UUID, version int64, emit repository.Emit) error
+	GetMetadata(ctx context.Context, uuid uuid.UUID) (model.Metadata, error)
+	ListMetadata(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]model.Metadata, error)
+	GetLinkHealth(ctx context.Context, uuid uuid.UUID) (model.LinkHealth, error)
+	ListLinkHealth(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]model.LinkHealth, error)
+	ListLinkChecks(ctx context.Context, uuid uuid.UUID, limit int) ([]model.LinkCheck, error)
+	ListRecentLinkChecks(ctx context.Context, uuids []uuid.UUID, limit int) (map[uuid.UUID][]model.LinkCheck, error)
+	GetArchive(ctx context.Context, uuid uuid.UUID) (model.Archive, error)
+	Changes(ctx context.Context, since int64, limit int) (model.ChangeSet, error)
+}
+
+// Enricher fetches link metadata of a new bookmark in the background.
+type Enricher interface {
+	Enqueue(bookmark model.Bookmark)
+}
+
+// Archiver saves a self-contained copy of an URL bookmark page in the background.
+type Archiver interface {
+	Enqueue(bookmark model.Bookmark) error
+	Open(archive model.Archive) (io.ReadCloser, error)
+}
+
+type Option func(*service)
+
+// Enrichment enqueues every created URL bookmark to the enricher.
+func Enrichment(enricher Enricher) Option {
+	return func(s *service) {
+		s.enricher = enricher
+	}
+}
+
+// Archiving lets clients request page archives, with onAppend every created URL bookmark is archived.
+func Archiving(archiver Archiver, onAppend bool) Option {
+	return func(s *service) {
+		s.archiver = archiver
+		s.archiveOnAppend = onAppend
+	}
 }
 
 type service struct {
-	repo Repository
+	repo            Repository
+	enricher        Enricher
+	archiver        Archiver
+	archiveOnAppend bool
 }
 
-func NewService(repo Repository) *service {
-	return &service{repo: repo}
+func NewService(repo Repository, options ...Option) *service {
+	s := &service{repo: repo}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
 }
 
-func (s *service) Append(title, val string) (model.Bookmark, error) {
+// Append stores a new bookmark in one atomic storage call.
+// The returned flag reports whether the bookmark was created; on conflict
+// the stored bookmark is returned, with ErrBookmarkExists in ConflictError mode.
+func (s *service) Append(ctx context.Context, title, val string, kind model.Kind, mode ConflictMode) (_ model.Bookmark, _ bool, err error) {
 	const op = "service.bookmark.Append"
 
-	// exists
-	bookmark, err := s.repo.GetByValue(val)
-	if err == nil {
-		return bookmark, fmt.Errorf("%s: %w", op, ErrBookmarkExists)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	onConflict, err := mode.onConflict()
+	if err != nil {
+		return model.Bookmark{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bookmark, err := model.NewBookmarkOfKind(title, val, kind)
+	if err != nil {
+		return model.Bookmark{}, false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if errors.Is(err, repository.ErrNotFound) {
-		bookmark, err = model.NewBookmark(title, val)
-		if err != nil {
-			return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	entity, err := s.repo.Append(ctx, bookmark, onConflict, bookmarkEvents)
+	if err != nil {
+		if errors.Is(err, repository.ErrExists) {
+			return entity, false, fmt.Errorf("%s: %w", op, ErrBookmarkExists)
 		}
 
-		bookmark, err = s.repo.Create(bookmark)
+		return model.Bookmark{}, false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err != nil {
-		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	created := entity.Uuid == bookmark.Uuid
+	if created {
+		s.created(ctx, entity)
 	}
 
-	return bookmark, nil
+	return entity, created, nil
 }
 
-func (s *service) View(u string) (model.Bookmark, error) {
+// created hands a new bookmark to the background workers.
+func (s *service) created(ctx context.Context, bookmark model.Bookmark) {
+	if s.enricher != nil {
+		s.enricher.Enqueue(bookmark)
+	}
+
+	if s.archiveOnAppend && bookmark.Kind == model.KindURL {
+		// a dropped archive can be requested later
+		if err := s.archiver.Enqueue(bookmark); err != nil {
+			logger.FromContext(ctx).Warn("archive not enqueued",
+				slog.String("op", "service.bookmark.created"),
+				slog.String("uuid", bookmark.Uuid.String()),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+func (s *service) View(ctx context.Context, u string) (_ model.Bookmark, err error) {
 	const op = "service.bookmark.View"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
 	uuid, err := uuid.Parse(u)
 	if err != nil {
 		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	bookmark, err := s.repo.GetByUUID(uuid)
+	bookmark, err := s.repo.GetByUUID(ctx, uuid)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return model.Bookmark{}, ErrBookmarkNotFound
@@ -76,18 +206,206 @@ func (s *service) View(u string) (model.Bookmark, error) {
 	return bookmark, nil
 }
 
-func (s *service) Change() {
+// List returns a page of bookmarks, the limit is clamped to MaxListLimit.
+func (s *service) List(ctx context.Context, filter ListFilter) (_ []model.Bookmark, err error) {
+	const op = "service.bookmark.List"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	filter = filter.Normalize()
+
+	bookmarks, err := s.repo.List(ctx, repository.Filter{
+		Kind:   string(filter.Kind),
+		Health: string(filter.Health),
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bookmarks, nil
 }
 
-func (s *service) Delete(u string) error {
+// Metadata returns the link metadata fetched for an URL bookmark.
+func (s *service) Metadata(ctx context.Context, u string) (_ model.Metadata, err error) {
+	const op = "service.bookmark.Metadata"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	bookmark, err := s.View(ctx, u)
+	if err != nil {
+		if errors.Is(err, ErrBookmarkNotFound) {
+			return model.Metadata{}, ErrBookmarkNotFound
+		}
+
+		return model.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	metadata, err := s.repo.GetMetadata(ctx, bookmark.Uuid)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.Metadata{}, ErrMetadataNotFound
+		}
+
+		return model.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return metadata, nil
+}
+
+// Health returns the current state of an URL bookmark link and its recent checks, newest first.
+func (s *service) Health(ctx context.Context, u string) (_ model.LinkHealth, _ []model.LinkCheck, err error) {
+	const op = "service.bookmark.Health"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	bookmark, err := s.View(ctx, u)
+	if err != nil {
+		if errors.Is(err, ErrBookmarkNotFound) {
+			return model.LinkHealth{}, nil, ErrBookmarkNotFound
+		}
+
+		return model.LinkHealth{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	health, err := s.repo.GetLinkHealth(ctx, bookmark.Uuid)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.LinkHealth{}, nil, ErrHealthNotFound
+		}
+
+		return model.LinkHealth{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	checks, err := s.repo.ListLinkChecks(ctx, bookmark.Uuid, 0)
+	if err != nil {
+		return model.LinkHealth{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return health, checks, nil
+}
+
+// RequestArchive schedules archiving of the page an URL bookmark points to.
+func (s *service) RequestArchive(ctx context.Context, u string) (err error) {
+	const op = "service.bookmark.RequestArchive"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if s.archiver == nil {
+		return ErrArchiveDisabled
+	}
+
+	bookmark, err := s.View(ctx, u)
+	if err != nil {
+		if errors.Is(err, ErrBookmarkNotFound) {
+			return ErrBookmarkNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if bookmark.Kind != model.KindURL {
+		return ErrNotArchivable
+	}
+
+	if err := s.archiver.Enqueue(bookmark); err != nil {
+		return fmt.Errorf("%s: %w: %w", op, ErrArchiveUnavailable, err)
+	}
+
+	return nil
+}
+
+// Archive returns the latest archive of an URL bookmark page, the caller closes the reader.
+func (s *service) Archive(ctx context.Context, u string) (_ model.Archive, _ io.ReadCloser, err error) {
+	const op = "service.bookmark.Archive"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if s.archiver == nil {
+		return model.Archive{}, nil, ErrArchiveDisabled
+	}
+
+	bookmark, err := s.View(ctx, u)
+	if err != nil {
+		if errors.Is(err, ErrBookmarkNotFound) {
+			return model.Archive{}, nil, ErrBookmarkNotFound
+		}
+
+		return model.Archive{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	archive, err := s.repo.GetArchive(ctx, bookmark.Uuid)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.Archive{}, nil, ErrArchiveNotFound
+		}
+
+		return model.Archive{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r, err := s.archiver.Open(archive)
+	if err != nil {
+		return model.Archive{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return archive, r, nil
+}
+
+// Change replaces the title and value of a bookmark still at version. The bookmark changed since
+// is returned with ErrBookmarkChanged, the owner of a taken value with ErrBookmarkExists.
+func (s *service) Change(ctx context.Context, u, title, val string, kind model.Kind, version int64) (_ model.Bookmark, err error) {
+	const op = "service.bookmark.Change"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	uuid, err := uuid.Parse(u)
+	if err != nil {
+		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bookmark, err := model.NewBookmarkOfKind(title, val, kind)
+	if err != nil {
+		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bookmark.Uuid = uuid
+
+	entity, err := s.repo.Update(ctx, bookmark, version, bookmarkEvents)
+	switch {
+	case err == nil:
+		return entity, nil
+	case errors.Is(err, repository.ErrConflict):
+		return entity, fmt.Errorf("%s: %w", op, ErrBookmarkChanged)
+	case errors.Is(err, repository.ErrExists):
+		return entity, fmt.Errorf("%s: %w", op, ErrBookmarkExists)
+	case errors.Is(err, repository.ErrDeleted):
+		return model.Bookmark{}, ErrBookmarkDeleted
+	case errors.Is(err, repository.ErrNotFound):
+		return model.Bookmark{}, ErrBookmarkNotFound
+	default:
+		return model.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+}
+
+func (s *service) Delete(ctx context.Context, u string) (err error) {
 	const op = "service.bookmark.Delete"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
 	uuid, err := uuid.Parse(u)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := s.repo.Delete(uuid); err != nil {
+	if err := s.repo.Delete(ctx, uuid, 0, bookmarkEvents); err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return ErrBookmarkNotFound
 		}
@@ -97,3 +415,16 @@ func (s *service) Delete(u string) error {
 
 	return nil
 }
+
+func (m ConflictMode) onConflict() (repository.OnConflict, error) {
+	switch m {
+	case ConflictError, "":
+		return repository.OnConflictError, nil
+	case ConflictIgnore:
+		return repository.OnConflictIgnore, nil
+	case ConflictUpdateTitle:
+		return repository.OnConflictUpdateTitle, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidConflictMode, string(m))
+	}
+}
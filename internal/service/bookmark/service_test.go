@@ -1,11 +1,18 @@
 package bookmark
 
 import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/stretchr/testify/require"
 
+	"bookmarks/internal/model"
+	"bookmarks/internal/repository"
 	"bookmarks/internal/repository/bookmark"
 	"bookmarks/internal/storage/memory"
 )
@@ -18,8 +25,9 @@ func TestAppend_Success(t *testing.T) {
 	title := gofakeit.Word()
 	value := gofakeit.CarModel()
 
-	bookmark, err := srv.Append(title, value)
+	bookmark, created, err := srv.Append(t.Context(), title, value, model.KindAuto, ConflictError)
 	require.NoError(t, err)
+	require.True(t, created)
 	require.Equal(t, title, bookmark.Title)
 	require.Equal(t, value, bookmark.Value)
 }
@@ -31,10 +39,255 @@ func TestAppend_ErrExists(t *testing.T) {
 
 	value := gofakeit.CarModel()
 
-	_, err := srv.Append(gofakeit.Word(), value)
+	first, _, err := srv.Append(t.Context(), gofakeit.Word(), value, model.KindAuto, ConflictError)
 	require.NoError(t, err)
 
-	_, err = srv.Append(gofakeit.Word(), value)
+	existing, created, err := srv.Append(t.Context(), gofakeit.Word(), value, model.KindAuto, ConflictError)
 	require.Error(t, err)
 	require.ErrorIs(t, err, ErrBookmarkExists)
+	require.False(t, created)
+	require.Equal(t, first.Uuid, existing.Uuid)
+}
+
+func TestAppend_ConflictIgnore(t *testing.T) {
+	storage := memory.NewBookmarkStorage()
+	repo := bookmark.NewRepository(storage)
+	srv := NewService(repo)
+
+	value := gofakeit.CarModel()
+
+	first, _, err := srv.Append(t.Context(), "first", value, model.KindAuto, ConflictError)
+	require.NoError(t, err)
+
+	existing, created, err := srv.Append(t.Context(), "second", value, model.KindAuto, ConflictIgnore)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, first.Uuid, existing.Uuid)
+	require.Equal(t, "first", existing.Title)
+}
+
+func TestAppend_ConflictUpdateTitle(t *testing.T) {
+	storage := memory.NewBookmarkStorage()
+	repo := bookmark.NewRepository(storage)
+	srv := NewService(repo)
+
+	value := gofakeit.CarModel()
+
+	first, _, err := srv.Append(t.Context(), "first", value, model.KindAuto, ConflictError)
+	require.NoError(t, err)
+
+	existing, created, err := srv.Append(t.Context(), "second", value, model.KindAuto, ConflictUpdateTitle)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, first.Uuid, existing.Uuid)
+	require.Equal(t, "second", existing.Title)
+
+	stored, err := srv.View(t.Context(), first.Uuid.String())
+	require.NoError(t, err)
+	require.Equal(t, "second", stored.Title)
+}
+
+func TestEvents(t *testing.T) {
+	storage := memory.NewBookmarkStorage()
+	repo := bookmark.NewRepository(storage)
+	srv := NewService(repo)
+
+	value := gofakeit.CarModel()
+
+	first, _, err := srv.Append(t.Context(), "first", value, model.KindAuto, ConflictError)
+	require.NoError(t, err)
+
+	_, _, err = srv.Append(t.Context(), "ignored", value, model.KindAuto, ConflictIgnore)
+	require.NoError(t, err)
+	_, _, err = srv.Append(t.Context(), "second", value, model.KindAuto, ConflictUpdateTitle)
+	require.NoError(t, err)
+	require.NoError(t, srv.Delete(t.Context(), first.Uuid.String()))
+
+	events, err := repo.PendingEvents(t.Context(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	for i, expected := range []model.EventType{model.BookmarkAppended, model.BookmarkChanged, model.BookmarkDeleted} {
+		require.Equal(t, expected, events[i].Type)
+		require.Equal(t, first.Uuid, events[i].Bookmark.Uuid)
+		require.False(t, events[i].OccurredAt.IsZero())
+	}
+
+	require.Equal(t, "second", events[2].Bookmark.Title)
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	storage := memory.NewBookmarkStorage()
+	repo := bookmark.NewRepository(storage)
+	srv := NewService(repo)
+
+	const workers = 16
+	value := gofakeit.CarModel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+		exists  int
+	)
+
+	for range workers {
+		wg.Go(func() {
+			_, ok, err := srv.Append(t.Context(), gofakeit.Word(), value, model.KindAuto, ConflictError)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if ok {
+				created++
+			}
+
+			if errors.Is(err, ErrBookmarkExists) {
+				exists++
+			}
+		})
+	}
+
+	wg.Wait()
+
+	require.Equal(t, 1, created)
+	require.Equal(t, workers-1, exists)
+}
+
+func TestConflictMode(t *testing.T) {
+	tests := []struct {
+		mode ConflictMode
+		want repository.OnConflict
+		err  error
+	}{
+		{mode: "", want: repository.OnConflictError},
+		{mode: "error", want: repository.OnConflictError},
+		{mode: "ignore", want: repository.OnConflictIgnore},
+		{mode: "update_title", want: repository.OnConflictUpdateTitle},
+		{mode: "replace", err: ErrInvalidConflictMode},
+	}
+
+	for _, tt := range tests {
+		mode, err := tt.mode.onConflict()
+		if tt.err != nil {
+			require.ErrorIs(t, err, tt.err)
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, tt.want, mode)
+	}
+}
+
+func TestList_FilterKind(t *testing.T) {
+	storage := memory.NewBookmarkStorage()
+	repo := bookmark.NewRepository(storage)
+	srv := NewService(repo)
+
+	values := []string{"https://example.com/a", "https://example.com/b", "git status", "remember the milk"}
+	for _, value := range values {
+		_, _, err := srv.Append(t.Context(), gofakeit.Word(), value, model.KindAuto, ConflictError)
+		require.NoError(t, err)
+	}
+
+	urls, err := srv.List(t.Context(), ListFilter{Kind: model.KindURL})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	require.Equal(t, "https://example.com/b", urls[0].Value)
+
+	all, err := srv.List(t.Context(), ListFilter{Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	rest, err := srv.List(t.Context(), ListFilter{Limit: 3, Offset: 3})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	require.Equal(t, "https://example.com/a", rest[0].Value)
+}
+
+func TestListFilter_Normalize(t *testing.T) {
+	require.Equal(t, DefaultListLimit, ListFilter{}.Normalize().Limit)
+	require.Equal(t, MaxListLimit, ListFilter{Limit: MaxListLimit + 1}.Normalize().Limit)
+	require.Equal(t, 0, ListFilter{Offset: -1}.Normalize().Offset)
+}
+
+func TestHealth(t *testing.T) {
+	storage := memory.NewBookmarkStorage()
+	repo := bookmark.NewRepository(storage)
+	srv := NewService(repo)
+
+	entity, _, err := srv.Append(t.Context(), "", "https://example.com/"+gofakeit.Word(), model.KindAuto, ConflictError)
+	require.NoError(t, err)
+
+	_, _, err = srv.Health(t.Context(), entity.Uuid.String())
+	require.ErrorIs(t, err, ErrHealthNotFound)
+
+	_, _, err = srv.Health(t.Context(), gofakeit.UUID())
+	require.ErrorIs(t, err, ErrBookmarkNotFound)
+
+	for range 2 {
+		_, err = repo.RecordLinkCheck(t.Context(), model.LinkCheck{Uuid: entity.Uuid, StatusCode: 404, CheckedAt: time.Now()}, 2, 10)
+		require.NoError(t, err)
+	}
+
+	health, checks, err := srv.Health(t.Context(), entity.Uuid.String())
+	require.NoError(t, err)
+	require.Equal(t, model.HealthBroken, health.Status)
+	require.Len(t, checks, 2)
+
+	broken, err := srv.List(t.Context(), ListFilter{Health: model.HealthBroken})
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+}
+
+func TestArchive(t *testing.T) {
+	storage := memory.NewBookmarkStorage()
+	repo := bookmark.NewRepository(storage)
+
+	entity, _, err := NewService(repo).Append(t.Context(), "", "https://example.com/"+gofakeit.Word(), model.KindAuto, ConflictError)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, NewService(repo).RequestArchive(t.Context(), entity.Uuid.String()), ErrArchiveDisabled)
+
+	archiver := &fakeArchiver{}
+	srv := NewService(repo, Archiving(archiver, true))
+
+	appended, _, err := srv.Append(t.Context(), "", "https://example.com/"+gofakeit.Word(), model.KindAuto, ConflictError)
+	require.NoError(t, err)
+
+	note, _, err := srv.Append(t.Context(), gofakeit.Word(), "remember the milk", model.KindAuto, ConflictError)
+	require.NoError(t, err)
+	require.Len(t, archiver.queued, 1)
+	require.Equal(t, appended.Uuid, archiver.queued[0].Uuid)
+
+	require.NoError(t, srv.RequestArchive(t.Context(), entity.Uuid.String()))
+	require.Len(t, archiver.queued, 2)
+	require.ErrorIs(t, srv.RequestArchive(t.Context(), note.Uuid.String()), ErrNotArchivable)
+	require.ErrorIs(t, srv.RequestArchive(t.Context(), gofakeit.UUID()), ErrBookmarkNotFound)
+
+	_, _, err = srv.Archive(t.Context(), entity.Uuid.String())
+	require.ErrorIs(t, err, ErrArchiveNotFound)
+
+	require.NoError(t, repo.SaveArchive(t.Context(), model.Archive{Uuid: entity.Uuid, Digest: "sha256:page", Size: 4}))
+
+	archive, r, err := srv.Archive(t.Context(), entity.Uuid.String())
+	require.NoError(t, err)
+	require.Equal(t, "sha256:page", archive.Digest)
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "sha256:page", string(data))
+}
+
+type fakeArchiver struct {
+	queued []model.Bookmark
+}
+
+func (a *fakeArchiver) Enqueue(bookmark model.Bookmark) error {
+	a.queued = append(a.queued, bookmark)
+	return nil
+}
+
+func (a *fakeArchiver) Open(archive model.Archive) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(archive.Digest)), nil
 }
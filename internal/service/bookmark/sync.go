@@ -0,0 +1,302 @@
+package bookmark
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/logger"
+	"bookmarks/internal/model"
+	"bookmarks/internal/repository"
+	"bookmarks/internal/tracing"
+)
+
+var (
+	ErrInvalidSyncToken = errors.New("invalid sync token")
+	ErrResyncRequired   = errors.New("sync token is unknown, a full resync is required")
+	ErrInvalidMutation  = errors.New("invalid mutation")
+	ErrTooManyMutations = errors.New("too many mutations")
+)
+
+const (
+	DefaultSyncLimit = 100
+	MaxSyncLimit     = 500
+	MaxPushMutations = 100
+)
+
+// syncTokenPrefix versions the token format, the token stays opaque to clients.
+const syncTokenPrefix = "v1."
+
+// EncodeSyncToken makes the opaque token of a change sequence version.
+func EncodeSyncToken(version int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(version, 10)))
+}
+
+// ParseSyncToken returns the version of a token, an empty token starts from scratch.
+func ParseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidSyncToken
+	}
+
+	digits, ok := strings.CutPrefix(string(raw), syncTokenPrefix)
+	if !ok {
+		return 0, ErrInvalidSyncToken
+	}
+
+	version, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || version < 0 {
+		return 0, ErrInvalidSyncToken
+	}
+
+	return version, nil
+}
+
+// Changes returns the bookmark writes after token in version order and the token to continue from.
+// The limit is clamped to MaxSyncLimit; when the page is cut, More is set and the next page follows the token.
+// A token ahead of the server (e.g. after its data was reset) returns ErrResyncRequired.
+func (s *service) Changes(ctx context.Context, token string, limit int) (_ model.ChangeSet, _ string, err error) {
+	const op = "service.bookmark.Changes"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	since, err := ParseSyncToken(token)
+	if err != nil {
+		return model.ChangeSet{}, "", err
+	}
+
+	switch {
+	case limit <= 0:
+		limit = DefaultSyncLimit
+	case limit > MaxSyncLimit:
+		limit = MaxSyncLimit
+	}
+
+	set, err := s.repo.Changes(ctx, since, limit)
+	if err != nil {
+		return model.ChangeSet{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if set.Version < since {
+		return model.ChangeSet{}, "", ErrResyncRequired
+	}
+
+	return set, EncodeSyncToken(set.Version), nil
+}
+
+// MutationOp is the kind of a client change pushed to the server.
+type MutationOp string
+
+const (
+	MutationCreate MutationOp = "create"
+	MutationUpdate MutationOp = "update"
+	MutationDelete MutationOp = "delete"
+)
+
+// Mutation is a client change. Create may carry the uuid the client assigned offline,
+// update and delete carry the version the client last saw; a delete without it is unconditional.
+type Mutation struct {
+	Op      MutationOp
+	Uuid    string
+	Title   string
+	Value   string
+	Kind    model.Kind
+	Version int64
+}
+
+// PushStatus is the outcome of a mutation.
+type PushStatus string
+
+const (
+	PushApplied  PushStatus = "applied"
+	PushConflict PushStatus = "conflict"
+	PushRejected PushStatus = "rejected"
+)
+
+// PushResult reports a mutation outcome. Bookmark is the stored bookmark after an applied write
+// and the server side of a conflict; Deleted reports that the bookmark is gone.
+type PushResult struct {
+	Uuid     string
+	Status   PushStatus
+	Err      error
+	Bookmark model.Bookmark
+	Deleted  bool
+}
+
+// Push applies client mutations in order, each on its own. A mutation that can not be applied
+// is reported in its result; an error is returned only when the storage fails.
+func (s *service) Push(ctx context.Context, mutations []Mutation) (_ []PushResult, err error) {
+	const op = "service.bookmark.Push"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if len(mutations) > MaxPushMutations {
+		return nil, ErrTooManyMutations
+	}
+
+	log := logger.FromContext(ctx).With(slog.String("op", op))
+
+	results := make([]PushResult, 0, len(mutations))
+
+	for _, m := range mutations {
+		var (
+			result PushResult
+			err    error
+		)
+
+		switch m.Op {
+		case MutationCreate:
+			result, err = s.pushCreate(ctx, m)
+		case MutationUpdate:
+			result, err = s.pushUpdate(ctx, m)
+		case MutationDelete:
+			result, err = s.pushDelete(ctx, m)
+		default:
+			result = rejected(m.Uuid, fmt.Errorf("%w: unknown op %q", ErrInvalidMutation, m.Op))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if result.Status != PushApplied {
+			log.Debug("mutation not applied",
+				slog.String("uuid", result.Uuid),
+				slog.String("mutation", string(m.Op)),
+				slog.String("status", string(result.Status)),
+				slog.Any("error", result.Err),
+			)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// pushCreate stores a bookmark under the client uuid. A retried create of the same uuid is applied again,
+// a value bookmarked under another uuid or a deleted uuid is a conflict.
+func (s *service) pushCreate(ctx context.Context, m Mutation) (PushResult, error) {
+	bookmark, err := model.NewBookmarkOfKind(m.Title, m.Value, m.Kind)
+	if err != nil {
+		return rejected(m.Uuid, err), nil
+	}
+
+	if m.Uuid != "" {
+		if bookmark.Uuid, err = uuid.Parse(m.Uuid); err != nil {
+			return rejected(m.Uuid, fmt.Errorf("%w: %w", ErrInvalidMutation, err)), nil
+		}
+	}
+
+	entity, err := s.repo.Append(ctx, bookmark, repository.OnConflictError, bookmarkEvents)
+	switch {
+	case err == nil:
+		s.created(ctx, entity)
+
+		return applied(entity), nil
+	case errors.Is(err, repository.ErrDeleted):
+		return deleted(bookmark.Uuid.String()), nil
+	case !errors.Is(err, repository.ErrExists):
+		return PushResult{}, err
+	}
+
+	stored, err := s.repo.GetByUUID(ctx, bookmark.Uuid)
+	switch {
+	case err == nil:
+		return applied(stored), nil
+	case !errors.Is(err, repository.ErrNotFound):
+		return PushResult{}, err
+	}
+
+	return PushResult{
+		Uuid:     bookmark.Uuid.String(),
+		Status:   PushConflict,
+		Err:      ErrBookmarkExists,
+		Bookmark: entity,
+	}, nil
+}
+
+// pushUpdate replaces the title and value of a bookmark still at the version the client saw.
+func (s *service) pushUpdate(ctx context.Context, m Mutation) (PushResult, error) {
+	if _, err := uuid.Parse(m.Uuid); err != nil {
+		return rejected(m.Uuid, fmt.Errorf("%w: %w", ErrInvalidMutation, err)), nil
+	}
+
+	entity, err := s.Change(ctx, m.Uuid, m.Title, m.Value, m.Kind, m.Version)
+	switch {
+	case err == nil:
+		return applied(entity), nil
+	case errors.Is(err, ErrBookmarkChanged):
+		return PushResult{Uuid: m.Uuid, Status: PushConflict, Err: ErrBookmarkChanged, Bookmark: entity}, nil
+	case errors.Is(err, ErrBookmarkExists):
+		return PushResult{Uuid: m.Uuid, Status: PushRejected, Err: ErrBookmarkExists, Bookmark: entity}, nil
+	case errors.Is(err, ErrBookmarkDeleted):
+		return deleted(m.Uuid), nil
+	case errors.Is(err, ErrBookmarkNotFound):
+		return rejected(m.Uuid, ErrBookmarkNotFound), nil
+	case isInvalidBookmark(err):
+		return rejected(m.Uuid, err), nil
+	default:
+		return PushResult{}, err
+	}
+}
+
+// pushDelete removes a bookmark still at the version the client saw, deleting it again is applied.
+func (s *service) pushDelete(ctx context.Context, m Mutation) (PushResult, error) {
+	id, err := uuid.Parse(m.Uuid)
+	if err != nil {
+		return rejected(m.Uuid, fmt.Errorf("%w: %w", ErrInvalidMutation, err)), nil
+	}
+
+	err = s.repo.Delete(ctx, id, m.Version, bookmarkEvents)
+	switch {
+	case err == nil, errors.Is(err, repository.ErrDeleted):
+		return PushResult{Uuid: m.Uuid, Status: PushApplied, Deleted: true}, nil
+	case errors.Is(err, repository.ErrNotFound):
+		return rejected(m.Uuid, ErrBookmarkNotFound), nil
+	case !errors.Is(err, repository.ErrConflict):
+		return PushResult{}, err
+	}
+
+	current, err := s.repo.GetByUUID(ctx, id)
+	switch {
+	case err == nil:
+		return PushResult{Uuid: m.Uuid, Status: PushConflict, Err: ErrBookmarkChanged, Bookmark: current}, nil
+	case errors.Is(err, repository.ErrNotFound):
+		// deleted by someone else in between
+		return PushResult{Uuid: m.Uuid, Status: PushApplied, Deleted: true}, nil
+	default:
+		return PushResult{}, err
+	}
+}
+
+func applied(bookmark model.Bookmark) PushResult {
+	return PushResult{Uuid: bookmark.Uuid.String(), Status: PushApplied, Bookmark: bookmark}
+}
+
+func rejected(uuid string, err error) PushResult {
+	return PushResult{Uuid: uuid, Status: PushRejected, Err: err}
+}
+
+func deleted(uuid string) PushResult {
+	return PushResult{Uuid: uuid, Status: PushConflict, Err: ErrBookmarkDeleted, Deleted: true}
+}
+
+// isInvalidBookmark reports a bookmark rejected by validation.
+func isInvalidBookmark(err error) bool {
+	return errors.Is(err, model.ErrInvalidTitle) ||
+		errors.Is(err, model.ErrInvalidValue) ||
+		errors.Is(err, model.ErrInvalidKind)
+}
@@ -0,0 +1,117 @@
+package bookmark
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/repository/bookmark"
+	"bookmarks/internal/storage/memory"
+)
+
+func TestSyncToken(t *testing.T) {
+	for _, version := range []int64{0, 1, 1 << 40} {
+		got, err := ParseSyncToken(EncodeSyncToken(version))
+		require.NoError(t, err)
+		require.Equal(t, version, got)
+	}
+
+	since, err := ParseSyncToken("")
+	require.NoError(t, err)
+	require.Zero(t, since)
+
+	for _, token := range []string{"42", "!!", EncodeSyncToken(1)[1:]} {
+		_, err := ParseSyncToken(token)
+		require.ErrorIs(t, err, ErrInvalidSyncToken)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	srv := NewService(bookmark.NewRepository(memory.NewBookmarkStorage()))
+
+	set, token, err := srv.Changes(t.Context(), "", 0)
+	require.NoError(t, err)
+	require.Empty(t, set.Bookmarks)
+
+	created, _, err := srv.Append(t.Context(), "first", "https://example.com/first", "", ConflictError)
+	require.NoError(t, err)
+
+	set, next, err := srv.Changes(t.Context(), token, 0)
+	require.NoError(t, err)
+	require.Len(t, set.Bookmarks, 1)
+	require.Equal(t, created.Uuid, set.Bookmarks[0].Uuid)
+	require.NotEqual(t, token, next)
+
+	require.NoError(t, srv.Delete(t.Context(), created.Uuid.String()))
+
+	set, _, err = srv.Changes(t.Context(), next, 0)
+	require.NoError(t, err)
+	require.Empty(t, set.Bookmarks)
+	require.Len(t, set.Tombstones, 1)
+
+	// the server lost its data since the token was issued
+	_, _, err = NewService(bookmark.NewRepository(memory.NewBookmarkStorage())).Changes(t.Context(), next, 0)
+	require.ErrorIs(t, err, ErrResyncRequired)
+}
+
+func TestPush(t *testing.T) {
+	srv := NewService(bookmark.NewRepository(memory.NewBookmarkStorage()))
+
+	id := uuid.NewString()
+	create := Mutation{Op: MutationCreate, Uuid: id, Title: "offline", Value: "https://example.com/offline"}
+
+	results, err := srv.Push(t.Context(), []Mutation{create, create, {Op: "rename"}, {Op: MutationCreate, Value: "no title"}})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	require.Equal(t, PushApplied, results[0].Status)
+	require.Equal(t, id, results[0].Bookmark.Uuid.String())
+	require.Equal(t, PushApplied, results[1].Status, "a retried create is applied")
+	require.Equal(t, PushRejected, results[2].Status)
+	require.ErrorIs(t, results[2].Err, ErrInvalidMutation)
+	require.Equal(t, PushRejected, results[3].Status)
+
+	version := results[0].Bookmark.Version
+
+	results, err = srv.Push(t.Context(), []Mutation{
+		{Op: MutationCreate, Uuid: uuid.NewString(), Title: "copy", Value: create.Value},
+		{Op: MutationUpdate, Uuid: id, Version: version, Title: "renamed", Value: create.Value},
+		{Op: MutationUpdate, Uuid: id, Version: version, Title: "stale", Value: create.Value},
+		{Op: MutationDelete, Uuid: id, Version: version},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, PushConflict, results[0].Status)
+	require.ErrorIs(t, results[0].Err, ErrBookmarkExists)
+	require.Equal(t, id, results[0].Bookmark.Uuid.String())
+
+	require.Equal(t, PushApplied, results[1].Status)
+	require.Equal(t, "renamed", results[1].Bookmark.Title)
+
+	require.Equal(t, PushConflict, results[2].Status)
+	require.ErrorIs(t, results[2].Err, ErrBookmarkChanged)
+	require.Equal(t, "renamed", results[2].Bookmark.Title)
+
+	require.Equal(t, PushConflict, results[3].Status)
+	require.Equal(t, results[1].Bookmark.Version, results[3].Bookmark.Version)
+
+	results, err = srv.Push(t.Context(), []Mutation{
+		{Op: MutationDelete, Uuid: id, Version: results[1].Bookmark.Version},
+		{Op: MutationDelete, Uuid: id},
+		{Op: MutationUpdate, Uuid: id, Version: version, Title: "gone", Value: create.Value},
+		create,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, PushApplied, results[0].Status)
+	require.True(t, results[0].Deleted)
+	require.Equal(t, PushApplied, results[1].Status, "a repeated delete is applied")
+	require.Equal(t, PushConflict, results[2].Status)
+	require.ErrorIs(t, results[2].Err, ErrBookmarkDeleted)
+	require.Equal(t, PushConflict, results[3].Status)
+	require.True(t, results[3].Deleted)
+
+	_, err = srv.Push(t.Context(), make([]Mutation, MaxPushMutations+1))
+	require.ErrorIs(t, err, ErrTooManyMutations)
+}
@@ -0,0 +1,72 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"bookmarks/internal/model"
+	"bookmarks/pkg/http/egress"
+)
+
+var ErrNotHTML = errors.New("not an html page")
+
+// StatusError is a response with an unexpected status code.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.Code)
+}
+
+// fetch reads at most maxBodySize bytes of the page and extracts its metadata.
+func (w *Worker) fetch(ctx context.Context, target *url.URL) (model.Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), http.NoBody)
+	if err != nil {
+		return model.Metadata{}, err
+	}
+
+	req.Header.Set("User-Agent", w.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return model.Metadata{}, err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return model.Metadata{}, &StatusError{Code: resp.StatusCode}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return model.Metadata{}, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	metadata, err := parseHTML(io.LimitReader(resp.Body, w.maxBodySize), resp.Request.URL)
+	if err != nil {
+		return model.Metadata{}, err
+	}
+
+	return metadata, nil
+}
+
+// retryable reports whether another attempt may succeed.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= http.StatusInternalServerError || statusErr.Code == http.StatusTooManyRequests
+	}
+
+	return !errors.Is(err, ErrNotHTML) &&
+		!errors.Is(err, egress.ErrPrivateAddress) &&
+		!errors.Is(err, egress.ErrTooManyRedirects) &&
+		!errors.Is(err, context.Canceled)
+}
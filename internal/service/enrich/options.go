@@ -0,0 +1,60 @@
+package enrich
+
+import "time"
+
+type Option func(*Worker)
+
+// Workers sets the number of pages fetched at the same time.
+func Workers(n int) Option {
+	return func(w *Worker) {
+		w.workers = n
+	}
+}
+
+// PerHost limits concurrent requests to a single host.
+func PerHost(n int) Option {
+	return func(w *Worker) {
+		w.perHost = n
+	}
+}
+
+// QueueSize sets how many bookmarks may wait for a worker before Enqueue drops them.
+func QueueSize(n int) Option {
+	return func(w *Worker) {
+		w.queueSize = n
+	}
+}
+
+func Timeout(timeout time.Duration) Option {
+	return func(w *Worker) {
+		w.timeout = timeout
+	}
+}
+
+// MaxBodySize limits the bytes of a page read for metadata.
+func MaxBodySize(size int64) Option {
+	return func(w *Worker) {
+		w.maxBodySize = size
+	}
+}
+
+// Retries sets the attempts made after the first failed one, with exponential backoff.
+func Retries(n int, backoff time.Duration) Option {
+	return func(w *Worker) {
+		w.retries = n
+		w.backoff = backoff
+	}
+}
+
+func UserAgent(ua string) Option {
+	return func(w *Worker) {
+		w.userAgent = ua
+	}
+}
+
+// AllowPrivateNetworks lets the worker fetch loopback and private addresses.
+func AllowPrivateNetworks() Option {
+	return func(w *Worker) {
+		w.allowPrivate = true
+	}
+}
@@ -0,0 +1,144 @@
+package enrich
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"bookmarks/internal/model"
+)
+
+const maxTextLength = 512
+
+// parseHTML extracts page metadata from the document head, Open Graph tags take precedence.
+// Relative links are resolved against base, the final URL of the response.
+func parseHTML(r io.Reader, base *url.URL) (model.Metadata, error) {
+	var (
+		metadata          model.Metadata
+		title, ogTitle    string
+		desc, ogDesc      string
+		inTitle, seenBody bool
+	)
+
+	z := html.NewTokenizer(r)
+
+	for !seenBody {
+		tt := z.Next()
+
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return model.Metadata{}, err
+			}
+
+			seenBody = true
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if atom.Lookup(name) == atom.Title {
+				inTitle = false
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := readAttrs(z, hasAttr)
+
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = tt == html.StartTagToken
+			case atom.Body:
+				seenBody = true
+			case atom.Meta:
+				key := strings.ToLower(attrs["property"] + attrs["name"])
+				switch key {
+				case "og:title":
+					ogTitle = attrs["content"]
+				case "og:description":
+					ogDesc = attrs["content"]
+				case "description":
+					desc = attrs["content"]
+				case "og:image":
+					metadata.Image = resolve(base, attrs["content"])
+				case "og:url":
+					if metadata.CanonicalURL == "" {
+						metadata.CanonicalURL = resolve(base, attrs["content"])
+					}
+				}
+			case atom.Link:
+				rels := strings.Fields(strings.ToLower(attrs["rel"]))
+				for _, rel := range rels {
+					switch rel {
+					case "canonical":
+						metadata.CanonicalURL = resolve(base, attrs["href"])
+					case "icon", "apple-touch-icon":
+						if metadata.Favicon == "" {
+							metadata.Favicon = resolve(base, attrs["href"])
+						}
+					}
+				}
+			}
+		}
+	}
+
+	metadata.Title = clean(firstNonEmpty(ogTitle, title))
+	metadata.Description = clean(firstNonEmpty(ogDesc, desc))
+
+	if metadata.Favicon == "" {
+		metadata.Favicon = resolve(base, "/favicon.ico")
+	}
+
+	return metadata, nil
+}
+
+func readAttrs(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		attrs[string(key)] = string(val)
+	}
+
+	return attrs
+}
+
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+
+	return u.String()
+}
+
+// clean folds whitespace and cuts overly long texts.
+func clean(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > maxTextLength {
+		text = string(runes[:maxTextLength])
+	}
+
+	return text
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+
+	return ""
+}
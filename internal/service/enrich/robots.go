@@ -0,0 +1,207 @@
+package enrich
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const robotsTTL = time.Hour
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+type robotsEntry struct {
+	rules     []robotsRule
+	expiresAt time.Time
+}
+
+// robotsCache keeps parsed robots.txt per origin.
+type robotsCache struct {
+	client    *http.Client
+	userAgent string
+	maxSize   int64
+
+	mu      sync.Mutex
+	origins map[string]robotsEntry
+}
+
+func newRobotsCache(client *http.Client, userAgent string, maxSize int64) *robotsCache {
+	return &robotsCache{
+		client:    client,
+		userAgent: userAgent,
+		maxSize:   maxSize,
+		origins:   make(map[string]robotsEntry),
+	}
+}
+
+// allowed reports whether robots.txt of the target origin lets us fetch the target.
+func (c *robotsCache) allowed(ctx context.Context, target *url.URL) (bool, error) {
+	origin := target.Scheme + "://" + target.Host
+
+	c.mu.Lock()
+	entry, ok := c.origins[origin]
+	c.mu.Unlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		rules, err := c.load(ctx, origin)
+		if err != nil {
+			return false, err
+		}
+
+		entry = robotsEntry{rules: rules, expiresAt: time.Now().Add(robotsTTL)}
+
+		c.mu.Lock()
+		c.origins[origin] = entry
+		c.mu.Unlock()
+	}
+
+	return match(entry.rules, target.EscapedPath()+querySuffix(target)), nil
+}
+
+// load follows RFC 9309: a missing file allows everything, an unreachable one disallows everything.
+func (c *robotsCache) load(ctx context.Context, origin string) ([]robotsRule, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return []robotsRule{{allow: false, length: 1, pattern: regexp.MustCompile("^/")}}, nil
+	case resp.StatusCode >= http.StatusBadRequest:
+		return nil, nil
+	}
+
+	return parseRobots(io.LimitReader(resp.Body, c.maxSize), c.userAgent), nil
+}
+
+// parseRobots returns the rules of the group naming our agent, or of the "*" group.
+func parseRobots(r io.Reader, userAgent string) []robotsRule {
+	agent := strings.ToLower(strings.SplitN(userAgent, "/", 2)[0])
+
+	var (
+		specific, wildcard []robotsRule
+		inAgents           bool // consecutive user-agent lines share a group
+		matchSpecific      bool
+		matchWildcard      bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				matchSpecific, matchWildcard = false, false
+			}
+
+			inAgents = true
+			name := strings.ToLower(val)
+
+			switch {
+			case name == "*":
+				matchWildcard = true
+			case name != "" && strings.Contains(agent, name):
+				matchSpecific = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+
+			if val == "" {
+				continue
+			}
+
+			rule := robotsRule{allow: key == "allow", length: len(val), pattern: compileRobots(val)}
+			if matchSpecific {
+				specific = append(specific, rule)
+			}
+
+			if matchWildcard {
+				wildcard = append(wildcard, rule)
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if specific != nil {
+		return specific
+	}
+
+	return wildcard
+}
+
+// compileRobots turns a path pattern with "*" and a trailing "$" into a regexp.
+func compileRobots(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+
+	return regexp.MustCompile(expr)
+}
+
+// match applies the longest matching rule, allow wins a tie.
+func match(rules []robotsRule, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	allowed, length := true, -1
+
+	for _, rule := range rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+
+		if rule.length > length || (rule.length == length && rule.allow) {
+			allowed, length = rule.allow, rule.length
+		}
+	}
+
+	return allowed
+}
+
+func querySuffix(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+
+	return "?" + u.RawQuery
+}
@@ -0,0 +1,341 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/repository"
+	"bookmarks/pkg/http/egress"
+)
+
+var ErrWorkerStopped = errors.New("enrichment worker is stopped")
+
+type Repository interface {
+	GetByUUID(ctx context.Context, uuid uuid.UUID) (model.Bookmark, error)
+	UpdateAutoTitle(ctx context.Context, uuid uuid.UUID, title string, emit repository.Emit) error
+	SaveMetadata(ctx context.Context, metadata model.Metadata) error
+}
+
+// Worker fetches metadata of URL bookmarks in the background.
+type Worker struct {
+	repo   Repository
+	log    *slog.Logger
+	client *http.Client
+	robots *robotsCache
+
+	mu      sync.Mutex
+	hosts   map[string]*host // the hosts being fetched
+	parked  int              // the bookmarks waiting for their host
+	queue   chan model.Bookmark
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	stopped bool
+
+	workers      int
+	perHost      int
+	queueSize    int
+	timeout      time.Duration
+	maxBodySize  int64
+	retries      int
+	backoff      time.Duration
+	userAgent    string
+	allowPrivate bool
+}
+
+// host counts the requests to a host, the bookmarks beyond the per host limit wait in line
+// for a worker done with the host instead of holding one up.
+type host struct {
+	active  int
+	waiting []pending
+}
+
+type pending struct {
+	bookmark model.Bookmark
+	target   *url.URL
+}
+
+func New(logger *slog.Logger, repo Repository, options ...Option) *Worker {
+	w := &Worker{
+		repo:        repo,
+		log:         logger,
+		hosts:       make(map[string]*host),
+		workers:     4,
+		perHost:     2,
+		queueSize:   1024,
+		timeout:     10 * time.Second,
+		maxBodySize: 1 << 20,
+		retries:     2,
+		backoff:     time.Second,
+		userAgent:   "bookmarks-bot/1.0",
+	}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	clientOptions := []egress.Option{egress.Timeout(w.timeout)}
+	if w.allowPrivate {
+		clientOptions = append(clientOptions, egress.AllowPrivateNetworks())
+	}
+
+	w.client = egress.NewClient(clientOptions...)
+	w.robots = newRobotsCache(w.client, w.userAgent, w.maxBodySize)
+
+	return w
+}
+
+// Start runs the worker pool until Shutdown.
+func (w *Worker) Start() {
+	const op = "service.enrich.Start"
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w.mu.Lock()
+	w.queue = make(chan model.Bookmark, w.queueSize)
+	w.cancel = cancel
+	w.mu.Unlock()
+
+	for range w.workers {
+		w.wg.Go(func() {
+			for bookmark := range w.queue {
+				w.process(ctx, bookmark)
+			}
+		})
+	}
+
+	w.log.Info("Start", slog.String("op", op), slog.Int("workers", w.workers))
+}
+
+// Enqueue schedules an URL bookmark for enrichment, other kinds are ignored.
+// It never blocks the caller: a bookmark is dropped when the queue is full.
+func (w *Worker) Enqueue(bookmark model.Bookmark) {
+	const op = "service.enrich.Enqueue"
+
+	if bookmark.Kind != model.KindURL {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.queue == nil || w.stopped {
+		w.log.Warn(ErrWorkerStopped.Error(), slog.String("op", op), slog.String("uuid", bookmark.Uuid.String()))
+		return
+	}
+
+	select {
+	case w.queue <- bookmark:
+	default:
+		w.log.Warn("queue is full", slog.String("op", op), slog.String("uuid", bookmark.Uuid.String()))
+	}
+}
+
+// Shutdown stops accepting bookmarks and waits for the queued ones,
+// in-flight requests are cancelled when ctx is done.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	const op = "service.enrich.Shutdown"
+
+	w.mu.Lock()
+	if w.queue == nil || w.stopped {
+		w.mu.Unlock()
+		return nil
+	}
+
+	w.stopped = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+
+	w.cancel()
+	<-done
+
+	w.log.Info("Shutdown", slog.String("op", op))
+
+	return err
+}
+
+func (w *Worker) process(ctx context.Context, bookmark model.Bookmark) {
+	const op = "service.enrich.process"
+
+	target, err := url.Parse(bookmark.Value)
+	if err != nil {
+		w.log.Error(err.Error(), slog.String("op", op), slog.String("uuid", bookmark.Uuid.String()))
+		return
+	}
+
+	next := pending{bookmark: bookmark, target: target}
+	if !w.acquire(next) {
+		return
+	}
+
+	// the worker holding a slot of the host enriches the bookmarks waiting for it
+	for {
+		w.enrich(ctx, next.bookmark, next.target)
+
+		var ok bool
+		if next, ok = w.release(target.Host); !ok {
+			return
+		}
+	}
+}
+
+func (w *Worker) enrich(ctx context.Context, bookmark model.Bookmark, target *url.URL) {
+	const op = "service.enrich.process"
+
+	log := w.log.With(
+		slog.String("op", op),
+		slog.String("uuid", bookmark.Uuid.String()),
+	)
+
+	metadata := w.fetchWithRetry(ctx, target)
+	metadata.Uuid = bookmark.Uuid
+
+	if err := w.repo.SaveMetadata(ctx, metadata); err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	if metadata.Status != model.MetadataOK || metadata.Title == "" {
+		log.Info("metadata", slog.String("status", string(metadata.Status)), slog.String("error", metadata.Error))
+		return
+	}
+
+	// the title may have been changed by the user since the bookmark was enqueued
+	if err := w.repo.UpdateAutoTitle(ctx, bookmark.Uuid, metadata.Title, titleEvents); err != nil {
+		log.Error(err.Error())
+	}
+}
+
+// titleEvents is the domain event of a fetched title replacing the derived one,
+// the repository writes it to the outbox in the transaction of the change.
+func titleEvents(_ repository.Change, bookmark model.Bookmark) []model.Event {
+	return []model.Event{{
+		Type:       model.BookmarkChanged,
+		Bookmark:   bookmark,
+		OccurredAt: time.Now(),
+	}}
+}
+
+func (w *Worker) fetchWithRetry(ctx context.Context, target *url.URL) model.Metadata {
+	allowed, err := w.robots.allowed(ctx, target)
+	if err != nil {
+		return failed(1, err)
+	}
+
+	if !allowed {
+		return model.Metadata{
+			Status:    model.MetadataDisallowed,
+			Attempts:  1,
+			FetchedAt: time.Now(),
+		}
+	}
+
+	backoff := w.backoff
+
+	for attempt := 1; ; attempt++ {
+		metadata, err := w.fetch(ctx, target)
+		if err == nil {
+			metadata.Status = model.MetadataOK
+			metadata.Attempts = attempt
+			metadata.FetchedAt = time.Now()
+
+			return metadata
+		}
+
+		if attempt > w.retries || !retryable(err) {
+			return failed(attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return failed(attempt, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// acquire takes a slot of the host, or puts the bookmark in line when the host is busy.
+// A bookmark is dropped when as many bookmarks as the queue holds are already in line.
+func (w *Worker) acquire(p pending) bool {
+	const op = "service.enrich.acquire"
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	h, ok := w.hosts[p.target.Host]
+	if !ok {
+		h = &host{}
+		w.hosts[p.target.Host] = h
+	}
+
+	if h.active < max(w.perHost, 1) {
+		h.active++
+		return true
+	}
+
+	if w.parked >= w.queueSize {
+		w.log.Warn("queue is full", slog.String("op", op), slog.String("uuid", p.bookmark.Uuid.String()))
+		return false
+	}
+
+	h.waiting = append(h.waiting, p)
+	w.parked++
+
+	return false
+}
+
+// release hands the slot to the next bookmark waiting for the host, if any,
+// the host is forgotten once its last request is done.
+func (w *Worker) release(name string) (pending, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	h := w.hosts[name]
+
+	if len(h.waiting) > 0 {
+		next := h.waiting[0]
+		h.waiting = h.waiting[1:]
+		w.parked--
+
+		return next, true
+	}
+
+	h.active--
+	if h.active == 0 {
+		delete(w.hosts, name)
+	}
+
+	return pending{}, false
+}
+
+func failed(attempts int, err error) model.Metadata {
+	return model.Metadata{
+		Status:    model.MetadataFailed,
+		Error:     err.Error(),
+		Attempts:  attempts,
+		FetchedAt: time.Now(),
+	}
+}
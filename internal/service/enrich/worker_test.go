@@ -0,0 +1,336 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	repo "bookmarks/internal/repository/bookmark"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/pkg/http/egress"
+)
+
+const page = `<!doctype html>
+<html><head>
+	<title> Example   page </title>
+	<meta name="description" content="Plain description">
+	<meta property="og:description" content="Open Graph description">
+	<meta property="og:image" content="/img/cover.png">
+	<link rel="canonical" href="https://example.com/article">
+	<link rel="shortcut icon" href="/static/icon.png">
+</head><body><h1>Body</h1></body></html>`
+
+func TestEnrich_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	auto := makeBookmark(t, repository, "", server.URL+"/article")
+	named := makeBookmark(t, repository, "my title", server.URL+"/named")
+
+	runWorker(repository, []model.Bookmark{auto, named})
+
+	metadata, err := repository.GetMetadata(t.Context(), auto.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.MetadataOK, metadata.Status)
+	require.Equal(t, "Example page", metadata.Title)
+	require.Equal(t, "Open Graph description", metadata.Description)
+	require.Equal(t, "https://example.com/article", metadata.CanonicalURL)
+	require.Equal(t, server.URL+"/static/icon.png", metadata.Favicon)
+	require.Equal(t, server.URL+"/img/cover.png", metadata.Image)
+	require.Equal(t, 1, metadata.Attempts)
+
+	bookmark, err := repository.GetByUUID(t.Context(), auto.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, "Example page", bookmark.Title)
+
+	// the fetched title is a change of the bookmark, the kept one is not
+	events, err := repository.PendingEvents(t.Context(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, model.BookmarkChanged, events[0].Type)
+	require.Equal(t, auto.Uuid, events[0].Bookmark.Uuid)
+	require.Equal(t, "Example page", events[0].Bookmark.Title)
+
+	bookmark, err = repository.GetByUUID(t.Context(), named.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, "my title", bookmark.Title)
+}
+
+func TestEnrich_RobotsDisallow(t *testing.T) {
+	var fetched atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			_, _ = fmt.Fprint(w, "User-agent: *\nAllow: /public\n\nUser-agent: bookmarks-bot\nDisallow: /private\n")
+			return
+		}
+
+		fetched.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	private := makeBookmark(t, repository, "", server.URL+"/private/page")
+	public := makeBookmark(t, repository, "", server.URL+"/public/page")
+
+	runWorker(repository, []model.Bookmark{private, public})
+
+	metadata, err := repository.GetMetadata(t.Context(), private.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.MetadataDisallowed, metadata.Status)
+
+	metadata, err = repository.GetMetadata(t.Context(), public.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.MetadataOK, metadata.Status)
+	require.Equal(t, int32(1), fetched.Load())
+}
+
+func TestEnrich_Retry(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/robots.txt":
+			http.NotFound(w, r)
+		case r.URL.Path == "/gone":
+			http.Error(w, "gone", http.StatusGone)
+		case calls.Add(1) == 1:
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = fmt.Fprint(w, page)
+		}
+	}))
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	flaky := makeBookmark(t, repository, "", server.URL+"/flaky")
+	gone := makeBookmark(t, repository, "", server.URL+"/gone")
+
+	runWorker(repository, []model.Bookmark{flaky, gone})
+
+	metadata, err := repository.GetMetadata(t.Context(), flaky.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.MetadataOK, metadata.Status)
+	require.Equal(t, 2, metadata.Attempts)
+
+	metadata, err = repository.GetMetadata(t.Context(), gone.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.MetadataFailed, metadata.Status)
+	require.Equal(t, 1, metadata.Attempts)
+	require.Contains(t, metadata.Error, "410")
+}
+
+func TestEnrich_MaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, "<html><head><!--"+strings.Repeat("x", 4096)+"--><title>Late</title></head></html>")
+	}))
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	bookmark := makeBookmark(t, repository, "", server.URL+"/large")
+
+	runWorker(repository, []model.Bookmark{bookmark}, MaxBodySize(1024))
+
+	metadata, err := repository.GetMetadata(t.Context(), bookmark.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.MetadataOK, metadata.Status)
+	require.Empty(t, metadata.Title)
+
+	stored, err := repository.GetByUUID(t.Context(), bookmark.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, bookmark.Title, stored.Title)
+}
+
+func TestEnrich_PerHost(t *testing.T) {
+	var inFlight, peak atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+
+	var bookmarks []model.Bookmark
+	for i := range 6 {
+		bookmarks = append(bookmarks, makeBookmark(t, repository, "", fmt.Sprintf("%s/page/%d", server.URL, i)))
+	}
+
+	runWorker(repository, bookmarks, Workers(4), PerHost(2))
+
+	require.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestEnrich_BusyHost(t *testing.T) {
+	release := make(chan struct{})
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+
+		<-release
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, page)
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, page)
+	}))
+	defer fast.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+
+	worker := New(slog.New(slog.DiscardHandler), repository, AllowPrivateNetworks(), Workers(2), PerHost(1))
+	worker.Start()
+
+	var bookmarks []model.Bookmark
+	for i := range 3 {
+		bookmark := makeBookmark(t, repository, "", fmt.Sprintf("%s/page/%d", slow.URL, i))
+		bookmarks = append(bookmarks, bookmark)
+		worker.Enqueue(bookmark)
+	}
+
+	other := makeBookmark(t, repository, "", fast.URL+"/page")
+	worker.Enqueue(other)
+
+	// the bookmarks of the slow host wait for it without holding up the second worker
+	require.Eventually(t, func() bool {
+		_, err := repository.GetMetadata(t.Context(), other.Uuid)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	close(release)
+	require.NoError(t, worker.Shutdown(context.Background()))
+
+	for _, bookmark := range bookmarks {
+		metadata, err := repository.GetMetadata(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, model.MetadataOK, metadata.Status)
+	}
+
+	require.Empty(t, worker.hosts)
+	require.Zero(t, worker.parked)
+}
+
+func TestEnrich_DenyPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	bookmark := makeBookmark(t, repository, "", server.URL+"/local")
+
+	worker := New(slog.New(slog.DiscardHandler), repository, Retries(0, 0))
+	worker.Start()
+	worker.Enqueue(bookmark)
+	require.NoError(t, worker.Shutdown(context.Background()))
+
+	metadata, err := repository.GetMetadata(t.Context(), bookmark.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.MetadataFailed, metadata.Status)
+	require.Contains(t, metadata.Error, egress.ErrPrivateAddress.Error())
+}
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+# comment
+User-agent: other
+Disallow: /
+
+User-agent: *
+Disallow: /admin
+Allow: /admin/public$
+Disallow: /*.pdf$
+`), "bookmarks-bot/1.0")
+
+	require.True(t, match(rules, "/"))
+	require.False(t, match(rules, "/admin/users"))
+	require.True(t, match(rules, "/admin/public"))
+	require.False(t, match(rules, "/docs/file.pdf"))
+	require.True(t, match(rules, "/docs/file.pdf?download=1"))
+}
+
+// runWorker enriches the bookmarks and waits for the queue to drain.
+func runWorker(repository Repository, bookmarks []model.Bookmark, options ...Option) {
+	options = append([]Option{AllowPrivateNetworks(), Retries(2, time.Millisecond), Timeout(time.Second)}, options...)
+
+	worker := New(slog.New(slog.DiscardHandler), repository, options...)
+	worker.Start()
+
+	for _, bookmark := range bookmarks {
+		worker.Enqueue(bookmark)
+	}
+
+	_ = worker.Shutdown(context.Background())
+}
+
+type creator interface {
+	Create(ctx context.Context, bookmark model.Bookmark) (model.Bookmark, error)
+}
+
+func makeBookmark(t *testing.T, repository creator, title, value string) model.Bookmark {
+	t.Helper()
+
+	bookmark, err := model.NewBookmark(title, value)
+	require.NoError(t, err)
+
+	bookmark, err = repository.Create(t.Context(), bookmark)
+	require.NoError(t, err)
+
+	return bookmark
+}
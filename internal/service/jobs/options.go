@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"time"
+
+	"bookmarks/internal/model"
+)
+
+type Option func(*Queue)
+
+// Workers sets the number of jobs run at the same time.
+func Workers(n int) Option {
+	return func(q *Queue) {
+		q.workers = n
+	}
+}
+
+// PollInterval sets how often the queue looks for due jobs when it is not woken up by Enqueue.
+func PollInterval(interval time.Duration) Option {
+	return func(q *Queue) {
+		q.pollInterval = interval
+	}
+}
+
+// Lease sets for how long a job is held by a worker without a heartbeat,
+// the job of a crashed worker is resumed once its lease expires.
+func Lease(d time.Duration) Option {
+	return func(q *Queue) {
+		q.lease = d
+	}
+}
+
+// Backoff sets the delay before the first retry, doubled on every attempt up to limit.
+func Backoff(base, limit time.Duration) Option {
+	return func(q *Queue) {
+		q.backoff = base
+		q.maxBackoff = limit
+	}
+}
+
+// MaxAttempts sets the default number of attempts of a job.
+func MaxAttempts(n int) Option {
+	return func(q *Queue) {
+		q.maxAttempts = n
+	}
+}
+
+// Owner names the queue in job leases, it must be unique among the running instances.
+func Owner(name string) Option {
+	return func(q *Queue) {
+		q.owner = name
+	}
+}
+
+// Retention enables an hourly purge of the jobs finished longer than d ago.
+func Retention(d time.Duration) Option {
+	return func(q *Queue) {
+		q.retention = d
+	}
+}
+
+type JobOption func(*model.Job)
+
+// At delays the job until the given time.
+func At(t time.Time) JobOption {
+	return func(j *model.Job) {
+		j.RunAt = t
+	}
+}
+
+// Attempts overrides the default number of attempts of the queue.
+func Attempts(n int) JobOption {
+	return func(j *model.Job) {
+		j.MaxAttempts = n
+	}
+}
+
+// Unique prevents enqueuing another job with the same key until the job is purged.
+func Unique(key string) JobOption {
+	return func(j *model.Job) {
+		j.UniqueKey = key
+	}
+}
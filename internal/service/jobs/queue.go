@@ -0,0 +1,614 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+)
+
+const purgeKind = "jobs.purge"
+
+var (
+	ErrJobNotFound  = errors.New("job not found")
+	ErrJobNotFailed = errors.New("only failed jobs can be retried")
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListFilter selects a page of jobs, newest first.
+type ListFilter struct {
+	Status model.JobStatus
+	Kind   string
+	Limit  int
+	Offset int
+}
+
+// Normalize applies the default limit and clamps the page bounds.
+func (f ListFilter) Normalize() ListFilter {
+	switch {
+	case f.Limit <= 0:
+		f.Limit = DefaultListLimit
+	case f.Limit > MaxListLimit:
+		f.Limit = MaxListLimit
+	}
+
+	f.Offset = max(f.Offset, 0)
+
+	return f
+}
+
+type Repository interface {
+	Enqueue(job model.Job) (model.Job, error)
+	Lease(lease core.Lease) ([]model.Job, error)
+	ExtendLease(id int64, owner string, until time.Time) error
+	Complete(id int64, owner string, at time.Time) error
+	Release(id int64, owner string, at time.Time) error
+	Fail(id int64, owner, message string, at, retryAt time.Time) error
+	Get(id int64) (model.Job, error)
+	List(filter core.JobFilter) ([]model.Job, error)
+	Retry(id int64, at time.Time) (model.Job, error)
+	Purge(before time.Time) (int, error)
+	OldestDue(kinds []string, now time.Time) (time.Time, error)
+}
+
+// Handler runs a job, the job is retried when it returns an error not marked Permanent.
+type Handler func(ctx context.Context, job model.Job) error
+
+type schedule struct {
+	name     string
+	kind     string
+	payload  json.RawMessage
+	schedule Schedule
+}
+
+// Queue runs durable background jobs stored by the repository.
+// Several instances may share the same storage: a job is run by the instance holding its lease.
+type Queue struct {
+	repo Repository
+	log  *slog.Logger
+
+	mu        sync.Mutex
+	handlers  map[string]Handler
+	schedules []schedule
+	started   bool
+	stopped   bool
+
+	wake    chan struct{}
+	slots   chan struct{} // one per running job
+	cancel  context.CancelFunc
+	abort   context.CancelFunc
+	loops   sync.WaitGroup // dispatcher and scheduler
+	running sync.WaitGroup // jobs
+
+	workers      int
+	pollInterval time.Duration
+	lease        time.Duration
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	maxAttempts  int
+	owner        string
+	retention    time.Duration
+}
+
+func New(logger *slog.Logger, repo Repository, options ...Option) *Queue {
+	q := &Queue{
+		repo:         repo,
+		log:          logger,
+		handlers:     make(map[string]Handler),
+		wake:         make(chan struct{}, 1),
+		workers:      4,
+		pollInterval: 5 * time.Second,
+		lease:        time.Minute,
+		backoff:      10 * time.Second,
+		maxBackoff:   time.Hour,
+		maxAttempts:  5,
+		owner:        defaultOwner(),
+	}
+
+	for _, opt := range options {
+		opt(q)
+	}
+
+	q.workers = max(q.workers, 1)
+
+	if q.retention > 0 {
+		q.Handle(purgeKind, q.purge)
+		q.schedules = append(q.schedules, schedule{
+			name:     purgeKind,
+			kind:     purgeKind,
+			payload:  json.RawMessage("{}"),
+			schedule: every(time.Hour),
+		})
+	}
+
+	return q
+}
+
+// Handle registers the handler of a job kind, jobs of unknown kinds are left in the queue.
+func (q *Queue) Handle(kind string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[kind] = handler
+}
+
+// Enqueue stores a job of the given kind, the payload is encoded as JSON.
+// A job with a taken unique key is reported with repository.ErrExists.
+func (q *Queue) Enqueue(kind string, payload any, options ...JobOption) (model.Job, error) {
+	const op = "service.jobs.Enqueue"
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return model.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	job := model.Job{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: q.maxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+	}
+
+	for _, opt := range options {
+		opt(&job)
+	}
+
+	job, err = q.repo.Enqueue(job)
+	if err != nil {
+		return model.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !job.RunAt.After(now) {
+		q.notify()
+	}
+
+	return job, nil
+}
+
+// Schedule enqueues a job of the kind on every run time of spec, see ParseSchedule.
+// The name identifies the schedule among the instances sharing the storage,
+// each run time is enqueued once. Run times missed while no instance was up are skipped.
+func (q *Queue) Schedule(name, spec, kind string, payload any) error {
+	const op = "service.jobs.Schedule"
+
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started {
+		return fmt.Errorf("%s: schedule %q: queue is already started", op, name)
+	}
+
+	q.schedules = append(q.schedules, schedule{name: name, kind: kind, payload: data, schedule: s})
+
+	return nil
+}
+
+func (q *Queue) List(filter ListFilter) ([]model.Job, error) {
+	const op = "service.jobs.List"
+
+	filter = filter.Normalize()
+
+	jobs, err := q.repo.List(core.JobFilter{
+		Status: string(filter.Status),
+		Kind:   filter.Kind,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+func (q *Queue) Job(id int64) (model.Job, error) {
+	const op = "service.jobs.Job"
+
+	job, err := q.repo.Get(id)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return model.Job{}, ErrJobNotFound
+		}
+
+		return model.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// Retry queues a failed job again with a fresh set of attempts.
+func (q *Queue) Retry(id int64) (model.Job, error) {
+	const op = "service.jobs.Retry"
+
+	job, err := q.repo.Retry(id, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrNotFound):
+			return model.Job{}, ErrJobNotFound
+		case errors.Is(err, core.ErrConflict):
+			return model.Job{}, ErrJobNotFailed
+		}
+
+		return model.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	q.notify()
+
+	return job, nil
+}
+
+// Lag is how long the oldest due job of a handled kind has been waiting, zero when none is.
+func (q *Queue) Lag() (time.Duration, error) {
+	const op = "service.jobs.Lag"
+
+	now := time.Now()
+
+	runAt, err := q.repo.OldestDue(q.kinds(), now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if runAt.IsZero() {
+		return 0, nil
+	}
+
+	return max(now.Sub(runAt), 0), nil
+}
+
+// Start runs the workers and the schedules until Shutdown.
+func (q *Queue) Start() {
+	const op = "service.jobs.Start"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobCtx, abort := context.WithCancel(context.Background())
+
+	q.mu.Lock()
+	q.started = true
+	q.slots = make(chan struct{}, q.workers)
+	q.cancel = cancel
+	q.abort = abort
+	schedules := slices.Clone(q.schedules)
+	q.mu.Unlock()
+
+	q.loops.Go(func() { q.dispatch(ctx, jobCtx) })
+	q.loops.Go(func() { q.runSchedules(ctx, schedules) })
+
+	q.log.Info("Start", slog.String("op", op), slog.String("owner", q.owner), slog.Int("workers", q.workers))
+}
+
+// Shutdown stops leasing jobs and waits for the running ones.
+// When ctx is done first the running jobs are cancelled and queued again right away,
+// the interrupted attempts are not counted.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	const op = "service.jobs.Shutdown"
+
+	q.mu.Lock()
+	if !q.started || q.stopped {
+		q.mu.Unlock()
+		return nil
+	}
+
+	q.stopped = true
+	q.cancel()
+	q.mu.Unlock()
+
+	q.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		q.running.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+
+	q.abort()
+	<-done
+
+	q.log.Info("Shutdown", slog.String("op", op))
+
+	return err
+}
+
+// dispatch leases as many jobs as there are free workers whenever woken up or polled.
+func (q *Queue) dispatch(ctx, jobCtx context.Context) {
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.poll(jobCtx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+func (q *Queue) poll(ctx context.Context) {
+	const op = "service.jobs.poll"
+
+	free := cap(q.slots) - len(q.slots)
+	if free == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	jobs, err := q.repo.Lease(core.Lease{
+		Owner: q.owner,
+		Kinds: q.kinds(),
+		Now:   now,
+		Until: now.Add(q.lease),
+		Limit: free,
+	})
+	if err != nil {
+		q.log.Error(err.Error(), slog.String("op", op))
+		return
+	}
+
+	for _, job := range jobs {
+		q.slots <- struct{}{}
+
+		q.running.Go(func() {
+			defer func() {
+				<-q.slots
+				q.notify()
+			}()
+
+			q.run(ctx, job)
+		})
+	}
+}
+
+// kinds are the job kinds with a handler, the queue leases only these.
+func (q *Queue) kinds() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	return kinds
+}
+
+func (q *Queue) run(ctx context.Context, job model.Job) {
+	const op = "service.jobs.run"
+
+	log := q.log.With(
+		slog.String("op", op),
+		slog.Int64("id", job.ID),
+		slog.String("kind", job.Kind),
+		slog.Int("attempt", job.Attempts),
+	)
+
+	q.mu.Lock()
+	handler := q.handlers[job.Kind]
+	q.mu.Unlock()
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heartbeat := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		q.heartbeat(heartbeat, cancel, job, log)
+	}()
+
+	err := call(jobCtx, handler, job)
+
+	close(heartbeat)
+	<-stopped
+
+	now := time.Now()
+
+	if err == nil {
+		if err := q.repo.Complete(job.ID, q.owner, now); err != nil {
+			log.Error(err.Error())
+		}
+
+		return
+	}
+
+	if ctx.Err() != nil {
+		// interrupted by Shutdown, not the fault of the job
+		log.Warn(err.Error(), slog.Bool("interrupted", true))
+
+		if err := q.repo.Release(job.ID, q.owner, now); err != nil {
+			log.Error(err.Error())
+		}
+
+		return
+	}
+
+	var retryAt time.Time
+	if !isPermanent(err) && job.Attempts < job.MaxAttempts {
+		retryAt = now.Add(q.delay(job.Attempts))
+	}
+
+	log.Warn(err.Error(), slog.Bool("retry", !retryAt.IsZero()))
+
+	if err := q.repo.Fail(job.ID, q.owner, err.Error(), now, retryAt); err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	// a short backoff should not wait for the next poll
+	if wait := retryAt.Sub(now); !retryAt.IsZero() && wait < q.pollInterval {
+		time.AfterFunc(wait, q.notify)
+	}
+}
+
+// heartbeat extends the lease of a running job, the job is cancelled if another worker took it over.
+func (q *Queue) heartbeat(done <-chan struct{}, cancel context.CancelFunc, job model.Job, log *slog.Logger) {
+	ticker := time.NewTicker(max(q.lease/3, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		err := q.repo.ExtendLease(job.ID, q.owner, time.Now().Add(q.lease))
+		switch {
+		case errors.Is(err, core.ErrLeaseLost):
+			log.Warn(err.Error())
+			cancel()
+
+			return
+		case err != nil:
+			log.Error(err.Error())
+		}
+	}
+}
+
+// runSchedules enqueues the scheduled jobs on their run times.
+func (q *Queue) runSchedules(ctx context.Context, schedules []schedule) {
+	next := make([]time.Time, len(schedules))
+
+	now := time.Now()
+	for i, s := range schedules {
+		next[i] = s.schedule.Next(now)
+	}
+
+	for {
+		var earliest time.Time
+		for _, t := range next {
+			if !t.IsZero() && (earliest.IsZero() || t.Before(earliest)) {
+				earliest = t
+			}
+		}
+
+		if earliest.IsZero() {
+			<-ctx.Done()
+			return
+		}
+
+		timer := time.NewTimer(time.Until(earliest))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for i, s := range schedules {
+			if next[i].IsZero() || next[i].After(now) {
+				continue
+			}
+
+			q.enqueueScheduled(s, next[i])
+			next[i] = s.schedule.Next(now)
+		}
+	}
+}
+
+func (q *Queue) enqueueScheduled(s schedule, at time.Time) {
+	const op = "service.jobs.enqueueScheduled"
+
+	key := "schedule:" + s.name + ":" + strconv.FormatInt(at.Unix(), 10)
+
+	_, err := q.Enqueue(s.kind, s.payload, At(at), Unique(key))
+	switch {
+	case errors.Is(err, core.ErrExists):
+		// another instance enqueued this run
+	case err != nil:
+		q.log.Error(err.Error(), slog.String("op", op), slog.String("schedule", s.name))
+	}
+}
+
+func (q *Queue) purge(_ context.Context, _ model.Job) error {
+	const op = "service.jobs.purge"
+
+	purged, err := q.repo.Purge(time.Now().Add(-q.retention))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	q.log.Info("purged", slog.String("op", op), slog.Int("jobs", purged))
+
+	return nil
+}
+
+// delay returns the backoff after the given attempt: base, 2*base, 4*base... up to the limit.
+func (q *Queue) delay(attempt int) time.Duration {
+	d := q.backoff
+	for range attempt - 1 {
+		if d >= q.maxBackoff {
+			break
+		}
+
+		d *= 2
+	}
+
+	return min(d, q.maxBackoff)
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// call runs the handler, a panic fails the attempt instead of the process.
+func call(ctx context.Context, handler Handler, job model.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+func defaultOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+}
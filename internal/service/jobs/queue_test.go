@@ -0,0 +1,245 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	repo "bookmarks/internal/repository/job"
+	"bookmarks/internal/storage/memory"
+)
+
+type mail struct {
+	To string `json:"to"`
+}
+
+var sendMail = NewTask[mail]("mail.send")
+
+func TestQueue_Run(t *testing.T) {
+	repository := repo.NewRepository(memory.NewJobStorage())
+	queue := makeQueue(repository)
+
+	received := make(chan string, 1)
+	sendMail.Handle(queue, func(_ context.Context, payload mail) error {
+		received <- payload.To
+		return nil
+	})
+
+	queue.Start()
+	defer queue.Shutdown(context.Background()) //nolint:errcheck
+
+	job, err := sendMail.Enqueue(queue, mail{To: "user@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, "user@example.com", <-received)
+
+	waitStatus(t, repository, job.ID, model.JobSucceeded)
+}
+
+func TestQueue_Retry(t *testing.T) {
+	repository := repo.NewRepository(memory.NewJobStorage())
+	queue := makeQueue(repository)
+
+	var calls atomic.Int32
+	queue.Handle("flaky", func(context.Context, model.Job) error {
+		if calls.Add(1) < 3 {
+			return errors.New("temporary")
+		}
+
+		return nil
+	})
+	queue.Handle("broken", func(context.Context, model.Job) error {
+		return errors.New("always")
+	})
+	queue.Handle("invalid", func(context.Context, model.Job) error {
+		return Permanent(errors.New("invalid input"))
+	})
+	queue.Handle("panic", func(context.Context, model.Job) error {
+		panic("unexpected")
+	})
+
+	queue.Start()
+	defer queue.Shutdown(context.Background()) //nolint:errcheck
+
+	flaky, err := queue.Enqueue("flaky", nil)
+	require.NoError(t, err)
+	broken, err := queue.Enqueue("broken", nil, Attempts(2))
+	require.NoError(t, err)
+	invalid, err := queue.Enqueue("invalid", nil)
+	require.NoError(t, err)
+	panicked, err := queue.Enqueue("panic", nil, Attempts(1))
+	require.NoError(t, err)
+
+	job := waitStatus(t, repository, flaky.ID, model.JobSucceeded)
+	require.Equal(t, 3, job.Attempts)
+
+	job = waitStatus(t, repository, broken.ID, model.JobFailed)
+	require.Equal(t, 2, job.Attempts)
+	require.Equal(t, "always", job.LastError)
+
+	job = waitStatus(t, repository, invalid.ID, model.JobFailed)
+	require.Equal(t, 1, job.Attempts)
+
+	job = waitStatus(t, repository, panicked.ID, model.JobFailed)
+	require.Contains(t, job.LastError, "panic: unexpected")
+}
+
+func TestQueue_ResumeExpiredLease(t *testing.T) {
+	repository := repo.NewRepository(memory.NewJobStorage())
+
+	job, err := repository.Enqueue(model.Job{Kind: "mail.send", Payload: []byte(`{}`), MaxAttempts: 3, RunAt: time.Now()})
+	require.NoError(t, err)
+
+	// a worker that leased the job and crashed
+	now := time.Now()
+	_, err = repository.Lease(core.Lease{Owner: "crashed", Kinds: []string{"mail.send"}, Now: now, Until: now.Add(50 * time.Millisecond), Limit: 1})
+	require.NoError(t, err)
+
+	queue := makeQueue(repository, PollInterval(10*time.Millisecond))
+	sendMail.Handle(queue, func(context.Context, mail) error { return nil })
+
+	queue.Start()
+	defer queue.Shutdown(context.Background()) //nolint:errcheck
+
+	job = waitStatus(t, repository, job.ID, model.JobSucceeded)
+	require.Equal(t, 2, job.Attempts)
+}
+
+func TestQueue_ShutdownRequeues(t *testing.T) {
+	repository := repo.NewRepository(memory.NewJobStorage())
+	queue := makeQueue(repository)
+
+	started := make(chan struct{})
+	queue.Handle("long", func(ctx context.Context, _ model.Job) error {
+		close(started)
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+
+	queue.Start()
+
+	job, err := queue.Enqueue("long", nil, Attempts(1))
+	require.NoError(t, err)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, queue.Shutdown(ctx), context.DeadlineExceeded)
+
+	// an interrupted job is not failed and keeps its attempts
+	job, err = repository.Get(job.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.JobQueued, job.Status)
+	require.Zero(t, job.Attempts)
+}
+
+func TestQueue_ScheduleOnce(t *testing.T) {
+	repository := repo.NewRepository(memory.NewJobStorage())
+	at := time.Date(2026, 10, 19, 3, 0, 0, 0, time.UTC)
+
+	// two instances sharing the storage
+	for range 2 {
+		queue := makeQueue(repository)
+		require.NoError(t, sendMail.Schedule(queue, "nightly", "0 3 * * *", mail{To: "admin"}))
+		queue.enqueueScheduled(queue.schedules[0], at)
+	}
+
+	jobs, err := repository.List(core.JobFilter{Kind: sendMail.Kind()})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, at, jobs[0].RunAt)
+	require.JSONEq(t, `{"to":"admin"}`, string(jobs[0].Payload))
+
+	queue := makeQueue(repository)
+	queue.Start()
+	defer queue.Shutdown(context.Background()) //nolint:errcheck
+
+	require.Error(t, queue.Schedule("late", "@hourly", "mail.send", nil))
+}
+
+func TestQueue_Delay(t *testing.T) {
+	queue := New(slog.New(slog.DiscardHandler), nil, Backoff(time.Second, 10*time.Second))
+
+	require.Equal(t, time.Second, queue.delay(1))
+	require.Equal(t, 2*time.Second, queue.delay(2))
+	require.Equal(t, 8*time.Second, queue.delay(4))
+	require.Equal(t, 10*time.Second, queue.delay(5))
+	require.Equal(t, 10*time.Second, queue.delay(100))
+}
+
+func TestQueue_AdminRetry(t *testing.T) {
+	repository := repo.NewRepository(memory.NewJobStorage())
+	queue := makeQueue(repository)
+
+	var fail atomic.Bool
+	fail.Store(true)
+
+	queue.Handle("report", func(context.Context, model.Job) error {
+		if fail.Load() {
+			return errors.New("upstream down")
+		}
+
+		return nil
+	})
+
+	queue.Start()
+	defer queue.Shutdown(context.Background()) //nolint:errcheck
+
+	job, err := queue.Enqueue("report", nil, Attempts(1))
+	require.NoError(t, err)
+	waitStatus(t, repository, job.ID, model.JobFailed)
+
+	failed, err := queue.List(ListFilter{Status: model.JobFailed})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+
+	_, err = queue.Job(12345)
+	require.ErrorIs(t, err, ErrJobNotFound)
+
+	fail.Store(false)
+
+	_, err = queue.Retry(job.ID)
+	require.NoError(t, err)
+	waitStatus(t, repository, job.ID, model.JobSucceeded)
+
+	_, err = queue.Retry(job.ID)
+	require.ErrorIs(t, err, ErrJobNotFailed)
+}
+
+func makeQueue(repository Repository, options ...Option) *Queue {
+	options = append([]Option{
+		Workers(2),
+		PollInterval(time.Second),
+		Lease(time.Second),
+		Backoff(time.Millisecond, 5*time.Millisecond),
+	}, options...)
+
+	return New(slog.New(slog.DiscardHandler), repository, options...)
+}
+
+type getter interface {
+	Get(id int64) (model.Job, error)
+}
+
+func waitStatus(t *testing.T, repository getter, id int64, status model.JobStatus) model.Job {
+	t.Helper()
+
+	var job model.Job
+
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = repository.Get(id)
+
+		return err == nil && job.Status == status
+	}, 2*time.Second, 5*time.Millisecond)
+
+	return job
+}
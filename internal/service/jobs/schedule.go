@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule yields the run times of a recurring job.
+type Schedule interface {
+	// Next returns the first run time strictly after the given time, zero when there is none.
+	Next(after time.Time) time.Time
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule accepts a five field cron expression (minute hour day-of-month month day-of-week),
+// a macro such as @daily or @hourly, or "@every <duration>".
+// Cron expressions are evaluated in the location of the time passed to Next.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || interval < time.Second {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, spec)
+		}
+
+		return every(interval), nil
+	}
+
+	if expr, ok := macros[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q: expected 5 fields", ErrInvalidSchedule, spec)
+	}
+
+	var (
+		c   cron
+		err error
+	)
+
+	bounds := []struct {
+		set      *uint64
+		min, max int
+	}{
+		{&c.minute, 0, 59},
+		{&c.hour, 0, 23},
+		{&c.dom, 1, 31},
+		{&c.month, 1, 12},
+		{&c.dow, 0, 7},
+	}
+
+	for i, field := range fields {
+		*bounds[i].set, err = parseField(field, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", ErrInvalidSchedule, spec, err)
+		}
+	}
+
+	// 7 is an alias of sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+
+	return c, nil
+}
+
+// every runs at multiples of the interval since the zero time,
+// so instances sharing the queue agree on the run times.
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	d := time.Duration(e)
+
+	return after.Truncate(d).Add(d)
+}
+
+type cron struct {
+	minute, hour, dom, month, dow uint64 // bit sets of the allowed values
+	domAny, dowAny                bool
+}
+
+func (c cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+
+	// a valid expression matches within a few years (february 29th)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case !has(c.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(c.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !has(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// day follows cron: when both day fields are restricted, matching either one is enough.
+func (c cron) day(t time.Time) bool {
+	dom, dow := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<value) != 0
+}
+
+// parseField reads a comma separated list of "*", "n", "a-b", each with an optional "/step".
+func parseField(field string, low, high int) (uint64, error) {
+	var set uint64
+
+	for part := range strings.SplitSeq(field, ",") {
+		rng, step, stepped := strings.Cut(part, "/")
+
+		first, last := low, high
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+
+			var err error
+			if first, err = parseValue(a, low, high); err != nil {
+				return 0, err
+			}
+
+			if last, err = parseValue(b, low, high); err != nil {
+				return 0, err
+			}
+
+			if first > last {
+				return 0, fmt.Errorf("range %q is reversed", rng)
+			}
+		default:
+			value, err := parseValue(rng, low, high)
+			if err != nil {
+				return 0, err
+			}
+
+			first = value
+			if !stepped {
+				last = value
+			}
+		}
+
+		increment := 1
+		if stepped {
+			n, err := strconv.Atoi(step)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("step %q is not a positive number", step)
+			}
+
+			increment = n
+		}
+
+		for value := first; value <= last; value += increment {
+			set |= 1 << value
+		}
+	}
+
+	return set, nil
+}
+
+func parseValue(s string, low, high int) (int, error) {
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+
+	if value < low || value > high {
+		return 0, fmt.Errorf("%d is out of range %d-%d", value, low, high)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"bookmarks/internal/model"
+)
+
+// Task binds a job kind to the type of its payload.
+//
+//	var digest = jobs.NewTask[DigestPayload]("digest.send")
+//
+//	digest.Handle(queue, func(ctx context.Context, p DigestPayload) error { ... })
+//	digest.Enqueue(queue, DigestPayload{...})
+type Task[T any] struct {
+	kind string
+}
+
+func NewTask[T any](kind string) Task[T] {
+	return Task[T]{kind: kind}
+}
+
+func (t Task[T]) Kind() string {
+	return t.kind
+}
+
+// Handle registers fn as the handler of the task, a payload that does not decode fails the job for good.
+func (t Task[T]) Handle(q *Queue, fn func(ctx context.Context, payload T) error) {
+	q.Handle(t.kind, func(ctx context.Context, job model.Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+
+		return fn(ctx, payload)
+	})
+}
+
+func (t Task[T]) Enqueue(q *Queue, payload T, options ...JobOption) (model.Job, error) {
+	return q.Enqueue(t.kind, payload, options...)
+}
+
+// Schedule enqueues the task on every run time of spec, see ParseSchedule.
+func (t Task[T]) Schedule(q *Queue, name, spec string, payload T) error {
+	return q.Schedule(name, spec, t.kind, payload)
+}
+
+type permanentError struct {
+	err error
+}
+
+// Permanent marks an error the job must not be retried for.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+
+	return errors.As(err, &permanent)
+}
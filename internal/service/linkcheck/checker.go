@@ -0,0 +1,262 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"bookmarks/internal/model"
+	"bookmarks/pkg/http/egress"
+)
+
+type Repository interface {
+	DueForCheck(ctx context.Context, before time.Time, limit int) ([]model.Bookmark, error)
+	RecordLinkCheck(ctx context.Context, check model.LinkCheck, threshold, keep int) (model.LinkHealth, error)
+}
+
+// Checker periodically requests bookmarked URLs and records whether they still resolve.
+type Checker struct {
+	repo   Repository
+	log    *slog.Logger
+	client *http.Client
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	interval     time.Duration
+	recheckAfter time.Duration
+	concurrency  int
+	timeout      time.Duration
+	threshold    int
+	batchSize    int
+	history      int
+	userAgent    string
+	allowPrivate bool
+}
+
+func New(logger *slog.Logger, repo Repository, options ...Option) *Checker {
+	c := &Checker{
+		repo:         repo,
+		log:          logger,
+		interval:     time.Hour,
+		recheckAfter: 24 * time.Hour,
+		concurrency:  4,
+		timeout:      10 * time.Second,
+		threshold:    3,
+		batchSize:    100,
+		history:      20,
+		userAgent:    "bookmarks-bot/1.0",
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	clientOptions := []egress.Option{egress.Timeout(c.timeout)}
+	if c.allowPrivate {
+		clientOptions = append(clientOptions, egress.AllowPrivateNetworks())
+	}
+
+	c.client = egress.NewClient(clientOptions...)
+
+	return c
+}
+
+// Start runs a check of the due links right away and then every interval until Shutdown.
+func (c *Checker) Start() {
+	const op = "service.linkcheck.Start"
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c.mu.Lock()
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	c.mu.Unlock()
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := c.Run(ctx); err != nil {
+				c.log.Error(err.Error(), slog.String("op", op))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	c.log.Info("Start", slog.String("op", op), slog.Duration("interval", c.interval))
+}
+
+// Shutdown stops the schedule, a run in progress is cancelled.
+func (c *Checker) Shutdown(ctx context.Context) error {
+	const op = "service.linkcheck.Shutdown"
+
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel = nil
+	c.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+
+	c.log.Info("Shutdown", slog.String("op", op))
+
+	return nil
+}
+
+// Run checks every link not checked within RecheckAfter and returns the number of recorded checks.
+func (c *Checker) Run(ctx context.Context) (int, error) {
+	const op = "service.linkcheck.Run"
+
+	before := time.Now().Add(-c.recheckAfter)
+	total := 0
+
+	for ctx.Err() == nil {
+		bookmarks, err := c.repo.DueForCheck(ctx, before, c.batchSize)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+
+		recorded := c.checkAll(ctx, bookmarks)
+		total += recorded
+
+		// a batch that could not be recorded would be returned again
+		if len(bookmarks) < c.batchSize || recorded == 0 {
+			break
+		}
+	}
+
+	return total, nil
+}
+
+// checkAll checks the bookmarks concurrently and returns the number of recorded checks.
+func (c *Checker) checkAll(ctx context.Context, bookmarks []model.Bookmark) int {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		recorded int
+	)
+
+	sem := make(chan struct{}, max(c.concurrency, 1))
+
+	for _, bookmark := range bookmarks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return recorded
+		}
+
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			if c.record(ctx, bookmark) {
+				mu.Lock()
+				recorded++
+				mu.Unlock()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	return recorded
+}
+
+func (c *Checker) record(ctx context.Context, bookmark model.Bookmark) bool {
+	const op = "service.linkcheck.record"
+
+	log := c.log.With(
+		slog.String("op", op),
+		slog.String("uuid", bookmark.Uuid.String()),
+	)
+
+	check := c.Check(ctx, bookmark.Value)
+	if ctx.Err() != nil {
+		return false // the check was cut by shutdown, it says nothing about the link
+	}
+
+	check.Uuid = bookmark.Uuid
+
+	health, err := c.repo.RecordLinkCheck(ctx, check, c.threshold, c.history)
+	if err != nil {
+		log.Error(err.Error())
+		return false
+	}
+
+	if health.Status == model.HealthBroken && health.ConsecutiveFailures == max(c.threshold, 1) {
+		log.Warn("link is broken",
+			slog.Int("status_code", health.StatusCode),
+			slog.String("error", health.Error),
+		)
+	}
+
+	return true
+}
+
+// Check requests the URL with HEAD, falling back to GET for servers
+// that do not answer HEAD properly. A final status below 400 is a success.
+func (c *Checker) Check(ctx context.Context, target string) model.LinkCheck {
+	check := c.request(ctx, http.MethodHead, target)
+	if !check.OK && ctx.Err() == nil {
+		check = c.request(ctx, http.MethodGet, target)
+	}
+
+	return check
+}
+
+func (c *Checker) request(ctx context.Context, method, target string) model.LinkCheck {
+	check := model.LinkCheck{CheckedAt: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, http.NoBody)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	check.Latency = time.Since(check.CheckedAt)
+
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	_ = resp.Body.Close() // the body is not needed, closing it early cancels the transfer
+
+	check.StatusCode = resp.StatusCode
+	check.OK = resp.StatusCode < http.StatusBadRequest
+
+	if final := resp.Request.URL.String(); final != target {
+		check.RedirectTo = final
+	}
+
+	if !check.OK {
+		check.Error = http.StatusText(resp.StatusCode)
+	}
+
+	return check
+}
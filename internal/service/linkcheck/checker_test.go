@@ -0,0 +1,156 @@
+package linkcheck
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	repo "bookmarks/internal/repository/bookmark"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/pkg/http/egress"
+)
+
+func TestRun_Broken(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	ok := makeBookmark(t, repository, server.URL+"/ok")
+	gone := makeBookmark(t, repository, server.URL+"/gone")
+	moved := makeBookmark(t, repository, server.URL+"/moved")
+	noHead := makeBookmark(t, repository, server.URL+"/no-head")
+	makeBookmark(t, repository, "plain text note")
+
+	checker := New(
+		slog.New(slog.DiscardHandler),
+		repository,
+		AllowPrivateNetworks(),
+		RecheckAfter(0),
+		FailureThreshold(2),
+		BatchSize(2),
+		History(5),
+	)
+
+	checked, err := checker.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4, checked)
+
+	health, err := repository.GetLinkHealth(t.Context(), gone.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.HealthFailing, health.Status)
+	require.Equal(t, http.StatusNotFound, health.StatusCode)
+
+	_, err = checker.Run(context.Background())
+	require.NoError(t, err)
+
+	health, err = repository.GetLinkHealth(t.Context(), gone.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, model.HealthBroken, health.Status)
+	require.Equal(t, 2, health.ConsecutiveFailures)
+
+	checks, err := repository.ListLinkChecks(t.Context(), gone.Uuid, 0)
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+
+	for _, bookmark := range []model.Bookmark{ok, moved, noHead} {
+		health, err := repository.GetLinkHealth(t.Context(), bookmark.Uuid)
+		require.NoError(t, err)
+		require.Equal(t, model.HealthOK, health.Status, bookmark.Value)
+		require.Equal(t, http.StatusOK, health.StatusCode)
+	}
+
+	health, err = repository.GetLinkHealth(t.Context(), moved.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/ok", health.RedirectTo)
+}
+
+func TestRun_RecheckAfter(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	makeBookmark(t, repository, server.URL+"/ok")
+
+	checker := New(slog.New(slog.DiscardHandler), repository, AllowPrivateNetworks(), RecheckAfter(time.Hour))
+
+	checked, err := checker.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, checked)
+
+	checked, err = checker.Run(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, checked)
+}
+
+func TestCheck_DenyPrivate(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	checker := New(slog.New(slog.DiscardHandler), nil)
+
+	check := checker.Check(context.Background(), server.URL+"/ok")
+	require.False(t, check.OK)
+	require.Zero(t, check.StatusCode)
+	require.Contains(t, check.Error, egress.ErrPrivateAddress.Error())
+}
+
+func TestStartShutdown(t *testing.T) {
+	server := httptest.NewServer(newSite())
+	defer server.Close()
+
+	repository := repo.NewRepository(memory.NewBookmarkStorage())
+	bookmark := makeBookmark(t, repository, server.URL+"/ok")
+
+	checker := New(slog.New(slog.DiscardHandler), repository, AllowPrivateNetworks(), Interval(time.Hour))
+	checker.Start()
+
+	require.Eventually(t, func() bool {
+		_, err := repository.GetLinkHealth(t.Context(), bookmark.Uuid)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, checker.Shutdown(context.Background()))
+	require.NoError(t, checker.Shutdown(context.Background()))
+}
+
+func newSite() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return mux
+}
+
+type creator interface {
+	Create(ctx context.Context, bookmark model.Bookmark) (model.Bookmark, error)
+}
+
+func makeBookmark(t *testing.T, repository creator, value string) model.Bookmark {
+	t.Helper()
+
+	bookmark, err := model.NewBookmark("title", value)
+	require.NoError(t, err)
+
+	bookmark, err = repository.Create(t.Context(), bookmark)
+	require.NoError(t, err)
+
+	return bookmark
+}
@@ -0,0 +1,66 @@
+package linkcheck
+
+import "time"
+
+type Option func(*Checker)
+
+// Interval sets how often the checker looks for links due for a check.
+func Interval(interval time.Duration) Option {
+	return func(c *Checker) {
+		c.interval = interval
+	}
+}
+
+// RecheckAfter sets the minimal time between two checks of the same link.
+func RecheckAfter(d time.Duration) Option {
+	return func(c *Checker) {
+		c.recheckAfter = d
+	}
+}
+
+// Concurrency sets the number of links checked at the same time.
+func Concurrency(n int) Option {
+	return func(c *Checker) {
+		c.concurrency = n
+	}
+}
+
+func Timeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = timeout
+	}
+}
+
+// FailureThreshold sets the consecutive failed checks after which a link is broken.
+func FailureThreshold(n int) Option {
+	return func(c *Checker) {
+		c.threshold = n
+	}
+}
+
+// BatchSize sets how many due links are read from the repository at once.
+func BatchSize(n int) Option {
+	return func(c *Checker) {
+		c.batchSize = n
+	}
+}
+
+// History sets how many checks are kept per link.
+func History(n int) Option {
+	return func(c *Checker) {
+		c.history = n
+	}
+}
+
+func UserAgent(ua string) Option {
+	return func(c *Checker) {
+		c.userAgent = ua
+	}
+}
+
+// AllowPrivateNetworks lets the checker request loopback and private addresses.
+func AllowPrivateNetworks() Option {
+	return func(c *Checker) {
+		c.allowPrivate = true
+	}
+}
@@ -0,0 +1,47 @@
+package outbox
+
+import "time"
+
+type Option func(*Relay)
+
+// Sinks adds the sinks every event is published to.
+func Sinks(sinks ...Sink) Option {
+	return func(r *Relay) {
+		r.sinks = append(r.sinks, sinks...)
+	}
+}
+
+// Interval sets how often the relay looks for events to publish.
+func Interval(interval time.Duration) Option {
+	return func(r *Relay) {
+		r.interval = interval
+	}
+}
+
+// BatchSize sets how many pending events are read from the repository at once.
+func BatchSize(n int) Option {
+	return func(r *Relay) {
+		r.batchSize = n
+	}
+}
+
+// Timeout limits the publishing of one event to one sink.
+func Timeout(timeout time.Duration) Option {
+	return func(r *Relay) {
+		r.timeout = timeout
+	}
+}
+
+// Retention enables an hourly purge of the events dispatched longer than d ago.
+func Retention(d time.Duration) Option {
+	return func(r *Relay) {
+		r.retention = d
+	}
+}
+
+// MaxAttempts sets how many runs an event may fail before it is dead and not published anymore.
+func MaxAttempts(n int) Option {
+	return func(r *Relay) {
+		r.maxAttempts = n
+	}
+}
@@ -0,0 +1,250 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+)
+
+const purgeInterval = time.Hour
+
+type Repository interface {
+	PendingEvents(ctx context.Context, after int64, limit int) ([]model.Event, error)
+	MarkDispatched(ctx context.Context, ids []int64, at time.Time) error
+	FailEvent(ctx context.Context, id int64, reason string, maxAttempts int, at time.Time) (bool, error)
+	PurgeEvents(ctx context.Context, before time.Time) (int, error)
+}
+
+// Relay publishes the events of the outbox to the sinks and marks them dispatched.
+// Delivery is at least once: an event is published again until every sink accepts it.
+// Events of a bookmark are published in the order they were written, an event
+// that failed holds back the later events of its bookmark until the next run.
+// An event failing maxAttempts runs is dead: it is not published anymore and
+// no longer holds back its bookmark.
+type Relay struct {
+	repo  Repository
+	log   *slog.Logger
+	sinks []Sink
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	done     chan struct{}
+	purgedAt time.Time
+
+	interval    time.Duration
+	batchSize   int
+	timeout     time.Duration
+	retention   time.Duration
+	maxAttempts int
+}
+
+func New(logger *slog.Logger, repo Repository, options ...Option) *Relay {
+	r := &Relay{
+		repo:        repo,
+		log:         logger,
+		interval:    time.Second,
+		batchSize:   100,
+		timeout:     10 * time.Second,
+		maxAttempts: 10,
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Start publishes the pending events right away and then every interval until Shutdown.
+func (r *Relay) Start() {
+	const op = "service.outbox.Start"
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	r.mu.Unlock()
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.Run(ctx); err != nil {
+				r.log.Error(err.Error(), slog.String("op", op))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	r.log.Info("Start", slog.String("op", op), slog.Duration("interval", r.interval), slog.Int("sinks", len(r.sinks)))
+}
+
+// Shutdown stops the relay, a publish in progress is cancelled and its event published again on the next start.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	const op = "service.outbox.Shutdown"
+
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel = nil
+	r.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+
+	r.log.Info("Shutdown", slog.String("op", op))
+
+	return nil
+}
+
+// Run publishes the pending events and returns the number of dispatched events.
+func (r *Relay) Run(ctx context.Context) (int, error) {
+	const op = "service.outbox.Run"
+
+	total := 0
+	after := int64(0)
+	held := make(map[uuid.UUID]struct{})
+
+	for ctx.Err() == nil {
+		// the events held back so far are paged past, not read again
+		events, err := r.repo.PendingEvents(ctx, after, r.batchSize)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// the published events are marked even when a shutdown cut the batch, or they are published again
+		dispatched := r.publishAll(ctx, events, held)
+		if err := r.repo.MarkDispatched(context.WithoutCancel(ctx), dispatched, time.Now()); err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+
+		total += len(dispatched)
+
+		if len(events) < r.batchSize {
+			break
+		}
+
+		after = events[len(events)-1].ID
+	}
+
+	if err := r.purge(ctx); err != nil {
+		return total, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return total, nil
+}
+
+// publishAll publishes the events in order and returns the ids of the events every sink accepted.
+// The bookmarks of the events that failed are added to held.
+func (r *Relay) publishAll(ctx context.Context, events []model.Event, held map[uuid.UUID]struct{}) []int64 {
+	dispatched := make([]int64, 0, len(events))
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if _, ok := held[event.Bookmark.Uuid]; ok {
+			continue
+		}
+
+		if err := r.publish(ctx, event); err != nil {
+			held[event.Bookmark.Uuid] = struct{}{}
+			r.fail(ctx, event, err)
+
+			continue
+		}
+
+		dispatched = append(dispatched, event.ID)
+	}
+
+	return dispatched
+}
+
+func (r *Relay) publish(ctx context.Context, event model.Event) error {
+	for _, sink := range r.sinks {
+		ctx, cancel := context.WithTimeout(ctx, r.timeout)
+		err := sink.Publish(ctx, event)
+		cancel()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fail counts the failed publish of an event, a publish cut by a shutdown is not counted.
+func (r *Relay) fail(ctx context.Context, event model.Event, cause error) {
+	const op = "service.outbox.fail"
+
+	log := r.log.With(
+		slog.String("op", op),
+		slog.Int64("event_id", event.ID),
+		slog.String("type", string(event.Type)),
+		slog.String("uuid", event.Bookmark.Uuid.String()),
+	)
+
+	if ctx.Err() != nil {
+		log.Warn(cause.Error(), slog.Bool("interrupted", true))
+		return
+	}
+
+	dead, err := r.repo.FailEvent(ctx, event.ID, cause.Error(), r.maxAttempts, time.Now())
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	if dead {
+		log.Error("event is dead, it is not published anymore", slog.String("error", cause.Error()))
+		return
+	}
+
+	log.Warn(cause.Error())
+}
+
+func (r *Relay) purge(ctx context.Context) error {
+	const op = "service.outbox.purge"
+
+	if r.retention <= 0 || time.Since(r.purgedAt) < purgeInterval {
+		return nil
+	}
+
+	n, err := r.repo.PurgeEvents(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		return err
+	}
+
+	r.purgedAt = time.Now()
+
+	if n > 0 {
+		r.log.Info("purged", slog.String("op", op), slog.Int("events", n))
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	core "bookmarks/internal/repository"
+	"bookmarks/internal/repository/bookmark"
+	"bookmarks/internal/storage/memory"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []model.Event
+	fail   map[string]bool // bookmark titles the sink rejects
+}
+
+func (r *recorder) Publish(_ context.Context, event model.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fail[event.Bookmark.Title] {
+		return errors.New("sink unavailable")
+	}
+
+	r.events = append(r.events, event)
+
+	return nil
+}
+
+func (r *recorder) titles() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	titles := make([]string, 0, len(r.events))
+	for _, event := range r.events {
+		titles = append(titles, string(event.Type)+" "+event.Bookmark.Title)
+	}
+
+	return titles
+}
+
+func TestRelay_Run(t *testing.T) {
+	repo := bookmark.NewRepository(memory.NewBookmarkStorage())
+	sink := &recorder{fail: map[string]bool{"b-renamed": true}}
+	relay := New(slog.New(slog.DiscardHandler), repo, Sinks(sink), BatchSize(2))
+
+	a := appendBookmark(t, repo, "a", "https://example.com/a")
+	b := appendBookmark(t, repo, "b", "https://example.com/b")
+	require.NoError(t, repo.Delete(t.Context(), a.Uuid, 0, emit))
+
+	renamed, err := model.NewBookmark("b-renamed", b.Value)
+	require.NoError(t, err)
+	_, err = repo.Append(t.Context(), renamed, core.OnConflictUpdateTitle, emit)
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(t.Context(), b.Uuid, 0, emit))
+
+	n, err := relay.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	// the failed change holds back the deletion of its bookmark
+	require.Equal(t, []string{"bookmark.appended a", "bookmark.appended b", "bookmark.deleted a"}, sink.titles())
+
+	sink.fail = nil
+
+	n, err = relay.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []string{
+		"bookmark.appended a",
+		"bookmark.appended b",
+		"bookmark.deleted a",
+		"bookmark.changed b-renamed",
+		"bookmark.deleted b-renamed",
+	}, sink.titles())
+
+	pending, err := repo.PendingEvents(t.Context(), 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestRelay_HeldBatch(t *testing.T) {
+	repo := bookmark.NewRepository(memory.NewBookmarkStorage())
+	sink := &recorder{fail: map[string]bool{"a": true}}
+	relay := New(slog.New(slog.DiscardHandler), repo, Sinks(sink), BatchSize(2), MaxAttempts(2))
+
+	a := appendBookmark(t, repo, "a", "https://example.com/a")
+	require.NoError(t, repo.Delete(t.Context(), a.Uuid, 0, emit))
+	appendBookmark(t, repo, "b", "https://example.com/b")
+
+	// a batch held back entirely does not starve the bookmarks after it
+	n, err := relay.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"bookmark.appended b"}, sink.titles())
+
+	// the second failure kills the event, it holds back its bookmark no more
+	n, err = relay.Run(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	sink.fail = nil
+
+	n, err = relay.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"bookmark.appended b", "bookmark.deleted a"}, sink.titles())
+
+	pending, err := repo.PendingEvents(t.Context(), 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestRelay_Start(t *testing.T) {
+	repo := bookmark.NewRepository(memory.NewBookmarkStorage())
+	sink := &recorder{}
+	relay := New(slog.New(slog.DiscardHandler), repo, Sinks(sink), Interval(10*time.Millisecond), Retention(time.Nanosecond))
+
+	relay.Start()
+
+	appendBookmark(t, repo, "a", "https://example.com/a")
+
+	require.Eventually(t, func() bool {
+		return len(sink.titles()) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, relay.Shutdown(context.Background()))
+	require.NoError(t, relay.Shutdown(context.Background()))
+}
+
+type appender interface {
+	Append(ctx context.Context, bookmark model.Bookmark, mode core.OnConflict, emit core.Emit) (model.Bookmark, error)
+}
+
+func appendBookmark(t *testing.T, repo appender, title, value string) model.Bookmark {
+	t.Helper()
+
+	entity, err := model.NewBookmark(title, value)
+	require.NoError(t, err)
+
+	entity, err = repo.Append(t.Context(), entity, core.OnConflictError, emit)
+	require.NoError(t, err)
+
+	return entity
+}
+
+func emit(change core.Change, bookmark model.Bookmark) []model.Event {
+	types := map[core.Change]model.EventType{
+		core.ChangeCreated: model.BookmarkAppended,
+		core.ChangeUpdated: model.BookmarkChanged,
+		core.ChangeDeleted: model.BookmarkDeleted,
+	}
+
+	return []model.Event{{Type: types[change], Bookmark: bookmark, OccurredAt: time.Now()}}
+}
@@ -0,0 +1,41 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+
+	"bookmarks/internal/model"
+)
+
+// Sink delivers events to another system. An event may be published more than once:
+// a sink should be idempotent on the event ID.
+type Sink interface {
+	Publish(ctx context.Context, event model.Event) error
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(ctx context.Context, event model.Event) error
+
+func (f SinkFunc) Publish(ctx context.Context, event model.Event) error {
+	return f(ctx, event)
+}
+
+type logSink struct {
+	log *slog.Logger
+}
+
+// LogSink writes every event to the log.
+func LogSink(log *slog.Logger) Sink {
+	return logSink{log: log}
+}
+
+func (s logSink) Publish(ctx context.Context, event model.Event) error {
+	s.log.InfoContext(ctx, "event",
+		slog.Int64("id", event.ID),
+		slog.String("type", string(event.Type)),
+		slog.String("uuid", event.Bookmark.Uuid.String()),
+		slog.Time("occurred_at", event.OccurredAt),
+	)
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+var (
+	ErrSchemaOutdated = errors.New("schema is outdated, run migrate")
+	ErrQueueLagging   = errors.New("job queue is lagging")
+	ErrLowDiskSpace   = errors.New("low disk space")
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Versioner interface {
+	Version() (int, error)
+}
+
+type LagReporter interface {
+	Lag() (time.Duration, error)
+}
+
+// Storage checks the storage answers a ping.
+func Storage(p Pinger) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		return nil, p.Ping(ctx)
+	}
+}
+
+// Migrations checks the schema has the latest version the app knows about.
+func Migrations(v Versioner, latest int) Check {
+	return func(_ context.Context) (map[string]any, error) {
+		version, err := v.Version()
+		if err != nil {
+			return nil, err
+		}
+
+		details := map[string]any{"version": version, "latest": latest}
+		if version < latest {
+			return details, ErrSchemaOutdated
+		}
+
+		return details, nil
+	}
+}
+
+// QueueLag checks the oldest due job has been waiting for less than limit.
+func QueueLag(q LagReporter, limit time.Duration) Check {
+	return func(_ context.Context) (map[string]any, error) {
+		lag, err := q.Lag()
+		if err != nil {
+			return nil, err
+		}
+
+		details := map[string]any{"lag_ms": lag.Milliseconds(), "limit_ms": limit.Milliseconds()}
+		if lag > limit {
+			return details, fmt.Errorf("%w: oldest due job waits for %s", ErrQueueLagging, lag.Round(time.Second))
+		}
+
+		return details, nil
+	}
+}
+
+// DiskSpace checks the file system holding path has at least minFree bytes available.
+// It passes on the platforms where the free space is unknown.
+func DiskSpace(path string, minFree uint64) Check {
+	return func(_ context.Context) (map[string]any, error) {
+		dir, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		free, err := freeSpace(dir)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return map[string]any{"path": dir, "supported": false}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		details := map[string]any{"path": dir, "free_bytes": free, "min_free_bytes": minFree}
+		if free < minFree {
+			return details, ErrLowDiskSpace
+		}
+
+		return details, nil
+	}
+}
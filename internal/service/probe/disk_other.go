@@ -0,0 +1,9 @@
+//go:build !(linux || darwin)
+
+package probe
+
+import "errors"
+
+func freeSpace(_ string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
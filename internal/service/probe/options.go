@@ -0,0 +1,19 @@
+package probe
+
+import "time"
+
+type Option func(*Registry)
+
+// Timeout bounds every check, a check still running after it fails.
+func Timeout(d time.Duration) Option {
+	return func(r *Registry) {
+		r.timeout = d
+	}
+}
+
+// CacheTTL sets how long a report is returned without running the checks again.
+func CacheTTL(d time.Duration) Option {
+	return func(r *Registry) {
+		r.cacheTTL = d
+	}
+}
@@ -0,0 +1,221 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusFailing Status = "failing"
+)
+
+// Check reports the state of a dependency, the details are reported whether it fails or not.
+type Check func(ctx context.Context) (details map[string]any, err error)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status     Status         `json:"status"`
+	DurationMS int64          `json:"duration_ms"`
+	Error      string         `json:"error,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+// Report is the readiness of the app: it is ok when every check is.
+type Report struct {
+	Status    Status            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Checks    map[string]Result `json:"checks,omitempty"`
+}
+
+// OK reports whether the app is ready.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type check struct {
+	name  string
+	check Check
+}
+
+// Registry runs the readiness checks of the app.
+//
+// A report is kept for the cache TTL and the checks run once for all the probes
+// arriving meanwhile, so probes polling the app often do not load its dependencies.
+type Registry struct {
+	log *slog.Logger
+
+	mu     sync.Mutex // held while the checks run
+	checks []check
+	report Report
+
+	shutdown atomic.Bool
+
+	timeout  time.Duration
+	cacheTTL time.Duration
+}
+
+func New(logger *slog.Logger, options ...Option) *Registry {
+	r := &Registry{
+		log:      logger,
+		timeout:  2 * time.Second,
+		cacheTTL: 5 * time.Second,
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Register adds a check, a check registered under the same name replaces it.
+func (r *Registry) Register(name string, c Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i].check = c
+			r.report = Report{}
+
+			return
+		}
+	}
+
+	r.checks = append(r.checks, check{name: name, check: c})
+	r.report = Report{}
+}
+
+// Ready runs the checks, or returns the report of a previous run younger than the cache TTL.
+// It fails without running the checks once Shutdown is called.
+func (r *Registry) Ready(ctx context.Context) Report {
+	if r.shutdown.Load() {
+		return Report{Status: StatusFailing, Error: ErrShuttingDown.Error(), CheckedAt: time.Now()}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.report.CheckedAt.IsZero() && time.Since(r.report.CheckedAt) < r.cacheTTL {
+		return r.report
+	}
+
+	// the report is shared by the probes, a cancelled one does not fail the others
+	report := r.run(context.WithoutCancel(ctx))
+
+	if report.Status != r.report.Status {
+		r.logChange(report)
+	}
+
+	r.report = report
+
+	return report
+}
+
+// Shutdown fails the readiness from now on, the load balancers stop sending requests
+// while the server drains the ones in flight.
+func (r *Registry) Shutdown() {
+	if r.shutdown.CompareAndSwap(false, true) {
+		r.log.Info("readiness failing: shutting down", slog.String("op", "service.probe.Shutdown"))
+	}
+}
+
+// run runs the checks concurrently, each one within the timeout.
+func (r *Registry) run(ctx context.Context) Report {
+	results := make([]Result, len(r.checks))
+
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Go(func() {
+			results[i] = r.runCheck(ctx, c.check)
+		})
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make(map[string]Result, len(r.checks)),
+	}
+
+	for i, c := range r.checks {
+		report.Checks[c.name] = results[i]
+
+		if results[i].Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+
+	return report
+}
+
+func (r *Registry) runCheck(ctx context.Context, c Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	type outcome struct {
+		details map[string]any
+		err     error
+	}
+
+	// a check ignoring its context must not hold the probes past the timeout
+	done := make(chan outcome, 1)
+	go func() {
+		details, err := c(ctx)
+		done <- outcome{details: details, err: err}
+	}()
+
+	var res outcome
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res = outcome{err: fmt.Errorf("timed out after %s", r.timeout)}
+	}
+
+	result := Result{
+		Status:     StatusOK,
+		DurationMS: time.Since(start).Milliseconds(),
+		Details:    res.details,
+	}
+
+	if res.err != nil {
+		result.Status = StatusFailing
+		result.Error = res.err.Error()
+	}
+
+	return result
+}
+
+func (r *Registry) logChange(report Report) {
+	const op = "service.probe.Ready"
+
+	if report.OK() {
+		r.log.Info("readiness ok", slog.String("op", op))
+		return
+	}
+
+	failing := make([]string, 0, len(report.Checks))
+	for name, result := range report.Checks {
+		if result.Status != StatusOK {
+			failing = append(failing, name+": "+result.Error)
+		}
+	}
+
+	slices.Sort(failing)
+
+	r.log.Warn("readiness failing", slog.String("op", op), slog.Any("checks", failing))
+}
@@ -0,0 +1,158 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReady(t *testing.T) {
+	probes := New(slog.New(slog.DiscardHandler), CacheTTL(0))
+	probes.Register("storage", func(context.Context) (map[string]any, error) {
+		return nil, nil
+	})
+	probes.Register("migrations", func(context.Context) (map[string]any, error) {
+		return map[string]any{"version": 3}, nil
+	})
+
+	report := probes.Ready(t.Context())
+	require.True(t, report.OK())
+	require.Len(t, report.Checks, 2)
+	require.Equal(t, StatusOK, report.Checks["migrations"].Status)
+	require.Equal(t, map[string]any{"version": 3}, report.Checks["migrations"].Details)
+
+	probes.Register("disk", func(context.Context) (map[string]any, error) {
+		return map[string]any{"free_bytes": 1}, ErrLowDiskSpace
+	})
+
+	report = probes.Ready(t.Context())
+	require.False(t, report.OK())
+	require.Equal(t, StatusFailing, report.Checks["disk"].Status)
+	require.Equal(t, ErrLowDiskSpace.Error(), report.Checks["disk"].Error)
+	require.Equal(t, map[string]any{"free_bytes": 1}, report.Checks["disk"].Details)
+	require.Equal(t, StatusOK, report.Checks["storage"].Status)
+}
+
+func TestReady_Cached(t *testing.T) {
+	var runs atomic.Int32
+
+	probes := New(slog.New(slog.DiscardHandler), CacheTTL(time.Hour))
+	probes.Register("slow", func(context.Context) (map[string]any, error) {
+		runs.Add(1)
+		time.Sleep(20 * time.Millisecond)
+
+		return nil, nil
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			require.True(t, probes.Ready(t.Context()).OK())
+		})
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), runs.Load())
+}
+
+func TestReady_Timeout(t *testing.T) {
+	probes := New(slog.New(slog.DiscardHandler), Timeout(10*time.Millisecond))
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	// the check ignores its context
+	probes.Register("stuck", func(context.Context) (map[string]any, error) {
+		<-release
+		return nil, nil
+	})
+
+	start := time.Now()
+	report := probes.Ready(t.Context())
+	require.Less(t, time.Since(start), time.Second)
+	require.False(t, report.OK())
+	require.Contains(t, report.Checks["stuck"].Error, "timed out")
+}
+
+func TestReady_CancelledProbe(t *testing.T) {
+	probes := New(slog.New(slog.DiscardHandler))
+	probes.Register("storage", func(ctx context.Context) (map[string]any, error) {
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	require.True(t, probes.Ready(ctx).OK())
+}
+
+func TestReady_Shutdown(t *testing.T) {
+	var runs atomic.Int32
+
+	probes := New(slog.New(slog.DiscardHandler), CacheTTL(time.Hour))
+	probes.Register("storage", func(context.Context) (map[string]any, error) {
+		runs.Add(1)
+		return nil, nil
+	})
+
+	require.True(t, probes.Ready(t.Context()).OK())
+
+	probes.Shutdown()
+
+	report := probes.Ready(t.Context())
+	require.False(t, report.OK())
+	require.Equal(t, ErrShuttingDown.Error(), report.Error)
+	require.Equal(t, int32(1), runs.Load())
+}
+
+func TestChecks(t *testing.T) {
+	details, err := Migrations(version(5), 5)(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"version": 5, "latest": 5}, details)
+
+	_, err = Migrations(version(4), 5)(t.Context())
+	require.ErrorIs(t, err, ErrSchemaOutdated)
+
+	_, err = QueueLag(lag(time.Second), time.Minute)(t.Context())
+	require.NoError(t, err)
+
+	details, err = QueueLag(lag(time.Hour), time.Minute)(t.Context())
+	require.ErrorIs(t, err, ErrQueueLagging)
+	require.Equal(t, time.Hour.Milliseconds(), details["lag_ms"])
+
+	_, err = Storage(pinger{errors.New("database is locked")})(t.Context())
+	require.EqualError(t, err, "database is locked")
+
+	details, err = DiskSpace(t.TempDir(), 1)(t.Context())
+	require.NoError(t, err)
+	require.NotEmpty(t, details["path"])
+
+	_, err = DiskSpace(t.TempDir(), 1<<62)(t.Context())
+	require.ErrorIs(t, err, ErrLowDiskSpace)
+}
+
+type version int
+
+func (v version) Version() (int, error) {
+	return int(v), nil
+}
+
+type lag time.Duration
+
+func (l lag) Lag() (time.Duration, error) {
+	return time.Duration(l), nil
+}
+
+type pinger struct {
+	err error
+}
+
+func (p pinger) Ping(context.Context) error {
+	return p.err
+}
@@ -0,0 +1,33 @@
+package seed
+
+import "time"
+
+type Option func(*Seeder)
+
+// Seed sets the seed of the generator, the same seed and Until give the same bookmarks.
+func Seed(seed uint64) Option {
+	return func(s *Seeder) {
+		s.seed = seed
+	}
+}
+
+// Until sets the creation time of the newest possible bookmark, DefaultUntil by default.
+func Until(t time.Time) Option {
+	return func(s *Seeder) {
+		s.until = t
+	}
+}
+
+// Span sets how far back from Until the bookmarks are created.
+func Span(d time.Duration) Option {
+	return func(s *Seeder) {
+		s.span = d
+	}
+}
+
+// BatchSize sets how many bookmarks are written in one storage call.
+func BatchSize(n int) Option {
+	return func(s *Seeder) {
+		s.batchSize = n
+	}
+}
@@ -0,0 +1,308 @@
+package seed
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/storage"
+)
+
+var ErrExhausted = errors.New("no more distinct values to generate")
+
+// attempts bounds the retries of a bookmark whose value was already generated.
+const attempts = 20
+
+// Storage takes the generated bookmarks in bulk, see sqlite.Sqlite.CreateMany.
+type Storage interface {
+	CreateMany(ctx context.Context, records []storage.Bookmark) (int, error)
+}
+
+// Result counts the written bookmarks, skipped ones were already in the storage.
+type Result struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+}
+
+// DefaultUntil is the creation time of the newest possible bookmark without Until,
+// a fixed instant: the same seed gives the same bookmarks on any day.
+var DefaultUntil = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Seeder fills a storage with generated bookmarks for load tests and demos:
+// mostly links, then notes, shell snippets and contacts, more of them recent.
+// The bookmarks have no tags nor folders, the model has none.
+//
+// The uuids derive from the seed too, so seeding again with the same options skips
+// every bookmark. The outbox is bypassed: no events are published for them.
+type Seeder struct {
+	storage Storage
+	log     *slog.Logger
+
+	seed      uint64
+	until     time.Time
+	span      time.Duration
+	batchSize int
+}
+
+func New(logger *slog.Logger, storage Storage, options ...Option) *Seeder {
+	s := &Seeder{
+		storage:   storage,
+		log:       logger,
+		seed:      1,
+		until:     DefaultUntil,
+		span:      2 * 365 * 24 * time.Hour,
+		batchSize: 1000,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	s.batchSize = max(s.batchSize, 1)
+	s.span = max(s.span, time.Hour)
+
+	return s
+}
+
+// Run generates n bookmarks and writes them in batches.
+func (s *Seeder) Run(ctx context.Context, n int) (Result, error) {
+	const op = "service.seed.Run"
+
+	g := &generator{
+		faker: gofakeit.New(s.seed),
+		until: s.until.UTC(),
+		span:  s.span,
+		taken: make(map[string]struct{}, n),
+	}
+
+	var result Result
+
+	for done := 0; done < n; {
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		records := make([]storage.Bookmark, 0, min(s.batchSize, n-done))
+		for range cap(records) {
+			record, err := g.bookmark()
+			if err != nil {
+				return result, fmt.Errorf("%s: %w", op, err)
+			}
+
+			records = append(records, record)
+		}
+
+		created, err := s.storage.CreateMany(ctx, records)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		done += len(records)
+		result.Created += created
+		result.Skipped += len(records) - created
+
+		s.log.Info("batch seeded",
+			slog.String("op", op),
+			slog.Int("done", done),
+			slog.Int("created", result.Created),
+			slog.Int("skipped", result.Skipped),
+		)
+	}
+
+	return result, nil
+}
+
+type generator struct {
+	faker *gofakeit.Faker
+	until time.Time
+	span  time.Duration
+	taken map[string]struct{} // canonical values generated so far
+}
+
+// bookmark returns the next bookmark, its value differs from all generated before.
+func (g *generator) bookmark() (storage.Bookmark, error) {
+	for range attempts {
+		kind, title, value := g.value()
+
+		bookmark, err := model.NewBookmarkOfKind(title, value, kind)
+		if err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%w: %q", err, value)
+		}
+
+		if _, taken := g.taken[bookmark.CanonicalValue]; taken {
+			continue
+		}
+
+		g.taken[bookmark.CanonicalValue] = struct{}{}
+
+		createdAt := g.createdAt()
+
+		return storage.Bookmark{
+			Uuid:           g.uuid(createdAt).String(),
+			Title:          bookmark.Title,
+			TitleAuto:      bookmark.TitleAuto,
+			Value:          bookmark.Value,
+			Kind:           string(bookmark.Kind),
+			CanonicalValue: bookmark.CanonicalValue,
+			CreatedAt:      createdAt,
+		}, nil
+	}
+
+	return storage.Bookmark{}, fmt.Errorf("%w after %d bookmarks", ErrExhausted, len(g.taken))
+}
+
+// value picks the kind, 60% links, 20% notes, 15% snippets and 5% contacts, and generates a bookmark of it.
+func (g *generator) value() (model.Kind, string, string) {
+	switch n := g.faker.IntRange(1, 100); {
+	case n <= 60:
+		return model.KindURL, g.linkTitle(), g.link()
+	case n <= 80:
+		return model.KindText, g.faker.Phrase(), g.note()
+	case n <= 95:
+		return model.KindCode, g.faker.Verb() + " " + g.faker.Noun(), g.snippet()
+	default:
+		return model.KindContact, g.faker.Name(), g.contact()
+	}
+}
+
+// linkTitle is empty for a third of the links, their title derives from the URL.
+func (g *generator) linkTitle() string {
+	switch g.faker.IntRange(1, 6) {
+	case 1, 2:
+		return ""
+	case 3:
+		return g.faker.BookTitle()
+	case 4:
+		return g.faker.HackerPhrase()
+	case 5:
+		return g.faker.ProductName()
+	default:
+		return g.faker.Sentence()
+	}
+}
+
+func (g *generator) link() string {
+	f := g.faker
+
+	switch f.IntRange(1, 10) {
+	case 1:
+		return "https://github.com/" + g.username() + "/" + g.slug(1)
+	case 2:
+		return fmt.Sprintf("https://stackoverflow.com/questions/%d/%s", f.IntRange(1_000_000, 79_999_999), g.slug(4))
+	case 3:
+		return fmt.Sprintf("https://news.ycombinator.com/item?id=%d", f.IntRange(10_000_000, 43_999_999))
+	case 4:
+		return "https://en.wikipedia.org/wiki/" + strings.ReplaceAll(g.slug(2), "-", "_")
+	case 5:
+		return "https://www.youtube.com/watch?v=" + f.Lexify("???????????")
+	case 6:
+		// the tracking parameters are dropped from the canonical value
+		return "https://" + f.DomainName() + "/blog/" + g.slug(3) + "?utm_source=newsletter&utm_medium=email"
+	default:
+		return "https://" + f.DomainName() + "/" + g.slug(f.IntRange(1, 3))
+	}
+}
+
+func (g *generator) note() string {
+	if g.faker.IntRange(1, 4) == 1 {
+		return g.faker.Question()
+	}
+
+	return g.faker.Sentence() + " " + g.faker.Sentence()
+}
+
+func (g *generator) snippet() string {
+	f := g.faker
+
+	switch f.IntRange(1, 7) {
+	case 1:
+		return "git checkout -b feature/" + g.slug(2)
+	case 2:
+		return fmt.Sprintf("docker run --rm -p %d:80 %s/%s", f.IntRange(3000, 9999), g.username(), g.slug(1))
+	case 3:
+		return "kubectl logs -f deploy/" + g.slug(2) + " -n " + g.slug(1)
+	case 4:
+		return "ssh " + g.username() + "@" + f.IPv4Address()
+	case 5:
+		return "curl -s https://" + f.DomainName() + "/api/v1/" + g.slug(1) + " | jq ."
+	case 6:
+		return fmt.Sprintf("grep -rn %q --include=*.go .", f.Verb()+g.slug(1))
+	default:
+		return f.IPv4Address()
+	}
+}
+
+func (g *generator) contact() string {
+	if g.faker.Bool() {
+		return g.faker.Email()
+	}
+
+	return "+1 " + g.faker.Numerify("(###) ###-####")
+}
+
+// username is a login of a faked person, lowercase and without spaces.
+func (g *generator) username() string {
+	return strings.ToLower(strings.ReplaceAll(g.faker.Username(), " ", ""))
+}
+
+func (g *generator) slug(words int) string {
+	parts := make([]string, 0, words)
+	for range words {
+		parts = append(parts, strings.ReplaceAll(strings.ToLower(g.faker.Noun()), " ", "-"))
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// createdAt leans to the recent past, to the day time and to the working days:
+// people bookmark more the more they use the app.
+func (g *generator) createdAt() time.Time {
+	f := g.faker
+
+	u := f.Float64Range(0, 1)
+	day := g.until.Add(-time.Duration(u * u * float64(g.span))).Truncate(24 * time.Hour)
+
+	// half of the weekend bookmarks move to the friday before
+	switch weekday := day.Weekday(); {
+	case weekday == time.Saturday && f.Bool():
+		day = day.AddDate(0, 0, -1)
+	case weekday == time.Sunday && f.Bool():
+		day = day.AddDate(0, 0, -2)
+	}
+
+	hour := f.IntRange(0, 23)
+	if hour < 8 && f.IntRange(1, 4) > 1 {
+		hour += 10 // few bookmarks at night
+	}
+
+	t := day.Add(time.Duration(hour)*time.Hour +
+		time.Duration(f.IntRange(0, 3599))*time.Second +
+		time.Duration(f.IntRange(0, 999))*time.Millisecond)
+
+	for t.After(g.until) {
+		t = t.AddDate(0, 0, -1)
+	}
+
+	return t
+}
+
+// uuid returns a version 7 uuid of the creation time with the random bits of the generator.
+func (g *generator) uuid(createdAt time.Time) uuid.UUID {
+	var id uuid.UUID
+
+	binary.BigEndian.PutUint64(id[8:], g.faker.Uint64())
+	binary.BigEndian.PutUint64(id[:8], uint64(createdAt.UnixMilli())<<16|uint64(g.faker.Uint16())) //nolint:gosec // a positive time
+
+	id[6] = id[6]&0x0f | 0x70 // version 7
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+
+	return id
+}
@@ -0,0 +1,104 @@
+package seed
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/internal/storage/sqlite"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+var until = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func list(t *testing.T, s interface {
+	List(ctx context.Context, filter repository.Filter) ([]storage.Bookmark, error)
+},
+) []storage.Bookmark {
+	t.Helper()
+
+	records, err := s.List(t.Context(), repository.Filter{})
+	require.NoError(t, err)
+
+	for i := range records {
+		records[i].Version = 0
+		records[i].CreatedAt = records[i].CreatedAt.UTC()
+	}
+
+	return records
+}
+
+func TestRun(t *testing.T) {
+	driver, err := pkgsql.New(pkgsql.SourceName(filepath.Join(t.TempDir(), "main.db")))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = driver.DB.Close() })
+
+	db, err := sqlite.NewBookmark(driver)
+	require.NoError(t, err)
+
+	mem := memory.NewBookmarkStorage()
+	span := 90 * 24 * time.Hour
+
+	for _, s := range []Storage{db, mem} {
+		result, err := New(slog.New(slog.DiscardHandler), s, Seed(42), Until(until), Span(span), BatchSize(64)).
+			Run(context.Background(), 500)
+		require.NoError(t, err)
+		require.Equal(t, Result{Created: 500}, result)
+	}
+
+	records := list(t, db)
+	require.Len(t, records, 500)
+	require.Equal(t, list(t, mem), records, "the same seed generates the same bookmarks")
+
+	kinds := make(map[string]int)
+
+	for _, record := range records {
+		require.False(t, record.CreatedAt.After(until))
+		require.True(t, record.CreatedAt.After(until.Add(-span-24*time.Hour)))
+
+		_, err := model.NewBookmarkOfKind(record.Title, record.Value, model.Kind(record.Kind))
+		require.NoError(t, err, record.Value)
+
+		kinds[record.Kind]++
+	}
+
+	require.Greater(t, kinds[string(model.KindURL)], kinds[string(model.KindText)])
+	require.Positive(t, kinds[string(model.KindCode)])
+	require.Positive(t, kinds[string(model.KindContact)])
+
+	// seeding again skips the bookmarks already stored
+	result, err := New(slog.New(slog.DiscardHandler), db, Seed(42), Until(until), Span(span)).Run(context.Background(), 500)
+	require.NoError(t, err)
+	require.Equal(t, Result{Skipped: 500}, result)
+
+	result, err = New(slog.New(slog.DiscardHandler), db, Seed(7), Until(until), Span(span)).Run(context.Background(), 100)
+	require.NoError(t, err)
+	require.Equal(t, 100, result.Created+result.Skipped)
+	require.Positive(t, result.Created)
+}
+
+func TestRun_DefaultUntil(t *testing.T) {
+	first, second := memory.NewBookmarkStorage(), memory.NewBookmarkStorage()
+
+	_, err := New(slog.New(slog.DiscardHandler), first, Seed(42)).Run(context.Background(), 50)
+	require.NoError(t, err)
+
+	_, err = New(slog.New(slog.DiscardHandler), second, Seed(42)).Run(context.Background(), 50)
+	require.NoError(t, err)
+
+	records := list(t, first)
+	require.Equal(t, list(t, second), records)
+
+	for _, record := range records {
+		require.False(t, record.CreatedAt.After(DefaultUntil))
+	}
+}
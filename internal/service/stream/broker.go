@@ -0,0 +1,198 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"bookmarks/internal/model"
+)
+
+var ErrClosed = errors.New("event stream closed")
+
+// Message is an event numbered in the order the broker received it.
+type Message struct {
+	ID    int64
+	Event model.Event
+}
+
+// Broker fans the outbox events out to the open streams, it is an outbox sink.
+//
+// The relay may publish an event again or after later events of other bookmarks,
+// so messages are numbered by the broker and an event is kept once. The numbers
+// start from the boot time: an id of a previous process is outside the history.
+type Broker struct {
+	log *slog.Logger
+
+	mu      sync.Mutex
+	seq     int64
+	recent  []Message          // the last messages, oldest first
+	seen    map[int64]struct{} // outbox ids of recent
+	streams map[*Subscription]struct{}
+	closed  bool
+
+	history   int
+	buffer    int
+	heartbeat time.Duration
+}
+
+func New(logger *slog.Logger, options ...Option) *Broker {
+	b := &Broker{
+		log:       logger,
+		seq:       time.Now().UnixMicro(),
+		seen:      make(map[int64]struct{}),
+		streams:   make(map[*Subscription]struct{}),
+		history:   1000,
+		buffer:    64,
+		heartbeat: 15 * time.Second,
+	}
+
+	for _, opt := range options {
+		opt(b)
+	}
+
+	return b
+}
+
+// Heartbeat is the interval of the keep-alive comments of a stream.
+func (b *Broker) Heartbeat() time.Duration {
+	return b.heartbeat
+}
+
+// Publish numbers the event and sends it to the subscribers, a subscriber with a full buffer is disconnected.
+func (b *Broker) Publish(_ context.Context, event model.Event) error {
+	const op = "service.stream.Publish"
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.seen[event.ID]; ok {
+		return nil
+	}
+
+	b.seq++
+	msg := Message{ID: b.seq, Event: event}
+
+	b.recent = append(b.recent, msg)
+	b.seen[event.ID] = struct{}{}
+
+	if over := len(b.recent) - b.history; over > 0 {
+		for _, old := range b.recent[:over] {
+			delete(b.seen, old.Event.ID)
+		}
+
+		b.recent = slices.Delete(b.recent, 0, over)
+	}
+
+	for sub := range b.streams {
+		if !sub.accepts(event.Type) {
+			continue
+		}
+
+		select {
+		case sub.events <- msg:
+		default:
+			b.log.Warn("subscriber too slow", slog.String("op", op), slog.Int64("id", msg.ID))
+			b.drop(sub)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe opens a stream of the events of types, every type when types is empty.
+// A positive lastID resumes after that message: the retained messages are replayed,
+// or Missed is set when the history does not reach back to it.
+func (b *Broker) Subscribe(lastID int64, types []model.EventType) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &Subscription{
+		Last:   b.seq,
+		broker: b,
+		events: make(chan Message, b.buffer),
+		types:  types,
+	}
+
+	if lastID > 0 {
+		// the history holds every number after b.seq-len(b.recent)
+		if lastID > b.seq || lastID < b.seq-int64(len(b.recent)) {
+			sub.Missed = true
+		} else {
+			for _, msg := range b.recent {
+				if msg.ID > lastID && sub.accepts(msg.Event.Type) {
+					sub.Replay = append(sub.Replay, msg)
+				}
+			}
+		}
+	}
+
+	b.streams[sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Close ends every stream and refuses new ones, events are still numbered and kept.
+func (b *Broker) Close() {
+	const op = "service.stream.Close"
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+
+	n := len(b.streams)
+	for sub := range b.streams {
+		b.drop(sub)
+	}
+
+	b.log.Info("Close", slog.String("op", op), slog.Int("streams", n))
+}
+
+func (b *Broker) drop(sub *Subscription) {
+	delete(b.streams, sub)
+	close(sub.events)
+}
+
+// Subscription is an open stream, its events channel is closed when the broker ends the stream.
+type Subscription struct {
+	// Last is the id of the last message before the stream was opened.
+	Last int64
+	// Replay holds the messages after the resumed id, the live ones follow on Events.
+	Replay []Message
+	// Missed reports the resumed id is no longer in the history, the client must reload its state.
+	Missed bool
+
+	broker *Broker
+	events chan Message
+	types  []model.EventType
+}
+
+func (s *Subscription) Events() <-chan Message {
+	return s.events
+}
+
+// Close unsubscribes, the broker may have ended the stream already.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if _, ok := s.broker.streams[s]; ok {
+		s.broker.drop(s)
+	}
+}
+
+func (s *Subscription) accepts(eventType model.EventType) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, eventType)
+}
@@ -0,0 +1,131 @@
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+)
+
+func TestBroker_Resume(t *testing.T) {
+	broker := New(slog.New(slog.DiscardHandler), History(3))
+
+	live, err := broker.Subscribe(0, nil)
+	require.NoError(t, err)
+	require.Empty(t, live.Replay)
+
+	for id := range int64(4) {
+		publish(t, broker, id+1, model.BookmarkAppended)
+	}
+
+	// published again by the relay
+	publish(t, broker, 4, model.BookmarkAppended)
+
+	ids := receive(t, live, 4)
+	require.Len(t, live.Events(), 0)
+
+	sub, err := broker.Subscribe(ids[1], nil)
+	require.NoError(t, err)
+	require.False(t, sub.Missed)
+	require.Len(t, sub.Replay, 2)
+	require.Equal(t, ids[2], sub.Replay[0].ID)
+	require.Equal(t, int64(4), sub.Replay[1].Event.ID)
+
+	sub, err = broker.Subscribe(ids[3], nil)
+	require.NoError(t, err)
+	require.False(t, sub.Missed)
+	require.Empty(t, sub.Replay)
+
+	sub, err = broker.Subscribe(ids[0], nil)
+	require.NoError(t, err)
+	require.False(t, sub.Missed)
+	require.Len(t, sub.Replay, 3)
+
+	// the first message left the history
+	sub, err = broker.Subscribe(ids[0]-1, nil)
+	require.NoError(t, err)
+	require.True(t, sub.Missed)
+	require.Equal(t, ids[3], sub.Last)
+
+	// an id of another process
+	sub, err = broker.Subscribe(ids[3]+1000, nil)
+	require.NoError(t, err)
+	require.True(t, sub.Missed)
+}
+
+func TestBroker_Filter(t *testing.T) {
+	broker := New(slog.New(slog.DiscardHandler))
+
+	sub, err := broker.Subscribe(0, []model.EventType{model.BookmarkDeleted})
+	require.NoError(t, err)
+
+	publish(t, broker, 1, model.BookmarkAppended)
+	publish(t, broker, 2, model.BookmarkDeleted)
+
+	msg := <-sub.Events()
+	require.Equal(t, model.BookmarkDeleted, msg.Event.Type)
+	require.Len(t, sub.Events(), 0)
+
+	resumed, err := broker.Subscribe(msg.ID-2, []model.EventType{model.BookmarkAppended})
+	require.NoError(t, err)
+	require.Len(t, resumed.Replay, 1)
+	require.Equal(t, model.BookmarkAppended, resumed.Replay[0].Event.Type)
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	broker := New(slog.New(slog.DiscardHandler), Buffer(1))
+
+	slow, err := broker.Subscribe(0, nil)
+	require.NoError(t, err)
+
+	publish(t, broker, 1, model.BookmarkAppended)
+	publish(t, broker, 2, model.BookmarkChanged)
+
+	_, ok := <-slow.Events()
+	require.True(t, ok)
+	_, ok = <-slow.Events()
+	require.False(t, ok)
+
+	// closing a dropped subscription is safe
+	slow.Close()
+}
+
+func TestBroker_Close(t *testing.T) {
+	broker := New(slog.New(slog.DiscardHandler))
+
+	sub, err := broker.Subscribe(0, nil)
+	require.NoError(t, err)
+
+	broker.Close()
+
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+
+	_, err = broker.Subscribe(0, nil)
+	require.ErrorIs(t, err, ErrClosed)
+
+	require.NoError(t, broker.Publish(context.Background(), model.Event{ID: 1, Type: model.BookmarkAppended}))
+	sub.Close()
+}
+
+func publish(t *testing.T, broker *Broker, id int64, eventType model.EventType) {
+	t.Helper()
+
+	require.NoError(t, broker.Publish(context.Background(), model.Event{ID: id, Type: eventType}))
+}
+
+func receive(t *testing.T, sub *Subscription, n int) []int64 {
+	t.Helper()
+
+	ids := make([]int64, 0, n)
+	for range n {
+		msg, ok := <-sub.Events()
+		require.True(t, ok)
+		ids = append(ids, msg.ID)
+	}
+
+	return ids
+}
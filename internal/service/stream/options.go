@@ -0,0 +1,26 @@
+package stream
+
+import "time"
+
+type Option func(*Broker)
+
+// History sets how many events are kept for clients resuming with Last-Event-ID.
+func History(n int) Option {
+	return func(b *Broker) {
+		b.history = n
+	}
+}
+
+// Buffer sets how many events a subscriber may fall behind before it is disconnected.
+func Buffer(n int) Option {
+	return func(b *Broker) {
+		b.buffer = n
+	}
+}
+
+// Heartbeat sets how often an idle stream sends a comment, it keeps proxies from closing the connection.
+func Heartbeat(interval time.Duration) Option {
+	return func(b *Broker) {
+		b.heartbeat = interval
+	}
+}
@@ -0,0 +1,79 @@
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// checkpoint is the progress of a copy, written after every batch. The version is
+// the one of the last source bookmark copied, the copy goes on after it.
+type checkpoint struct {
+	Key       string    `json:"key"`
+	Version   int64     `json:"version"`
+	Result    Result    `json:"result"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// load returns the saved progress, a zero checkpoint when the copy starts afresh.
+func (c *Copier) load() (checkpoint, error) {
+	if c.checkpoint == "" {
+		return checkpoint{}, nil
+	}
+
+	data, err := os.ReadFile(c.checkpoint)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return checkpoint{Key: c.key}, nil
+		}
+
+		return checkpoint{}, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return checkpoint{}, fmt.Errorf("%w: %s: %w", ErrInvalidCheckpoint, c.checkpoint, err)
+	}
+
+	if cp.Key != c.key {
+		return checkpoint{}, fmt.Errorf("%w: %s is for %q", ErrInvalidCheckpoint, c.checkpoint, cp.Key)
+	}
+
+	return cp, nil
+}
+
+func (c *Copier) save(cp checkpoint) error {
+	if c.checkpoint == "" {
+		return nil
+	}
+
+	cp.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := c.checkpoint + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.checkpoint)
+}
+
+// done removes the checkpoint of a finished copy.
+func (c *Copier) done() error {
+	if c.checkpoint == "" {
+		return nil
+	}
+
+	if err := os.Remove(c.checkpoint); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
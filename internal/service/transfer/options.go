@@ -0,0 +1,20 @@
+package transfer
+
+type Option func(*Copier)
+
+// BatchSize sets how many bookmarks are read from the source at a time.
+func BatchSize(n int) Option {
+	return func(c *Copier) {
+		c.batchSize = n
+	}
+}
+
+// Checkpoint keeps the progress in the file at path after every batch, a copy
+// interrupted for any reason resumes from it. The key tells the copies apart,
+// a checkpoint left by another pair of storages is refused.
+func Checkpoint(path, key string) Option {
+	return func(c *Copier) {
+		c.checkpoint = path
+		c.key = key
+	}
+}
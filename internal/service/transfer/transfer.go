@@ -0,0 +1,251 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+var ErrInvalidCheckpoint = errors.New("invalid checkpoint")
+
+// Source is the storage the bookmarks are read from, any bookmark storage satisfies it.
+type Source interface {
+	List(ctx context.Context, filter repository.Filter) ([]storage.Bookmark, error)
+	Changes(ctx context.Context, since int64, limit int) (storage.ChangeSet, error)
+	ListMetadata(ctx context.Context, uuids []uuid.UUID) ([]storage.Metadata, error)
+	ListLinkHealth(ctx context.Context, uuids []uuid.UUID) ([]storage.LinkHealth, error)
+	ListRecentLinkChecks(ctx context.Context, uuids []uuid.UUID, limit int) ([]storage.LinkCheck, error)
+	GetArchive(ctx context.Context, uuid uuid.UUID) (storage.Archive, error)
+}
+
+// Target is the storage the bookmarks are written to, it is read back by Verify.
+type Target interface {
+	Source
+	Create(ctx context.Context, record storage.Bookmark, mode repository.OnConflict, emit repository.EmitRecord) (storage.Bookmark, error)
+	GetByUUID(ctx context.Context, uuid uuid.UUID) (storage.Bookmark, error)
+	SaveMetadata(ctx context.Context, metadata storage.Metadata) error
+	SaveLinkCheck(ctx context.Context, check storage.LinkCheck, fold repository.FoldHealth, keep int) error
+	SaveArchive(ctx context.Context, archive storage.Archive) error
+}
+
+// Conflict is a source bookmark the target holds differently, it is left as it is.
+type Conflict struct {
+	Uuid   string `json:"uuid"`
+	Reason string `json:"reason"`
+}
+
+// Result counts the bookmarks of a copy. Skipped ones were already in the target, as they are in the source.
+type Result struct {
+	Copied    int        `json:"copied"`
+	Skipped   int        `json:"skipped"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// Copier copies the bookmarks, with their metadata, link health, link check history
+// and archive records, keeping the uuids and the creation times. The target assigns
+// its own versions. Tombstones, the outbox, jobs and webhooks are not copied.
+//
+// The source is read in pages in the order of its versions, the version of the last
+// bookmark read is the cursor kept in the checkpoint: a bookmark deleted from the source
+// during a copy does not shift the pages, one changed meanwhile is read again.
+// A copy is safe to run again: bookmarks already in the target are skipped.
+// Bookmarks the migration left without a canonical value are copied without one.
+type Copier struct {
+	source Source
+	target Target
+	log    *slog.Logger
+
+	batchSize  int
+	checkpoint string
+	key        string
+}
+
+func New(logger *slog.Logger, source Source, target Target, options ...Option) *Copier {
+	c := &Copier{
+		source:    source,
+		target:    target,
+		log:       logger,
+		batchSize: 500,
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	c.batchSize = max(c.batchSize, 1)
+
+	return c
+}
+
+// Copy copies the source into the target, resuming from the checkpoint when there is one.
+// The checkpoint is removed once the last batch is copied.
+func (c *Copier) Copy(ctx context.Context) (Result, error) {
+	const op = "service.transfer.Copy"
+
+	cp, err := c.load()
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cp.Version > 0 {
+		c.log.Info("copy resumed", slog.String("op", op), slog.Int64("version", cp.Version))
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return cp.Result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		records, err := c.page(ctx, c.source, cp.Version)
+		if err != nil {
+			return cp.Result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if len(records) == 0 {
+			break
+		}
+
+		if err := c.copyBatch(ctx, records, &cp.Result); err != nil {
+			return cp.Result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		cp.Version = records[len(records)-1].Version
+
+		if err := c.save(cp); err != nil {
+			return cp.Result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		c.log.Info("batch copied",
+			slog.String("op", op),
+			slog.Int64("version", cp.Version),
+			slog.Int("copied", cp.Result.Copied),
+			slog.Int("skipped", cp.Result.Skipped),
+			slog.Int("conflicts", len(cp.Result.Conflicts)),
+		)
+	}
+
+	if err := c.done(); err != nil {
+		return cp.Result, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cp.Result, nil
+}
+
+// page reads the next batch of bookmarks written after the version, in the order of their versions.
+func (c *Copier) page(ctx context.Context, s Source, after int64) ([]storage.Bookmark, error) {
+	set, err := s.Changes(ctx, after, c.batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return set.Bookmarks, nil
+}
+
+func (c *Copier) copyBatch(ctx context.Context, records []storage.Bookmark, result *Result) error {
+	related, err := readRelated(ctx, c.source, records)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		held, err := c.copyBookmark(ctx, record, result)
+		if err != nil {
+			return err
+		}
+
+		if !held {
+			continue
+		}
+
+		if err := c.copyRelated(ctx, related[record.Uuid]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyBookmark creates the bookmark in the target, it tells whether the target holds it as in the source.
+// A bookmark already in the target was copied by an earlier run, its related rows may not be.
+func (c *Copier) copyBookmark(ctx context.Context, record storage.Bookmark, result *Result) (bool, error) {
+	existing, err := c.target.GetByUUID(ctx, uuid.MustParse(record.Uuid))
+	switch {
+	case err == nil:
+		if bookmarkSum(existing) != bookmarkSum(record) {
+			result.Conflicts = append(result.Conflicts, Conflict{Uuid: record.Uuid, Reason: "differs in the target"})
+			return false, nil
+		}
+
+		result.Skipped++
+
+		return true, nil
+	case !errors.Is(err, repository.ErrNotFound):
+		return false, err
+	}
+
+	stored, err := c.target.Create(ctx, record, repository.OnConflictIgnore, nil)
+	switch {
+	case errors.Is(err, repository.ErrDeleted):
+		result.Conflicts = append(result.Conflicts, Conflict{Uuid: record.Uuid, Reason: "deleted in the target"})
+		return false, nil
+	case err != nil:
+		return false, err
+	case stored.Uuid != record.Uuid:
+		result.Conflicts = append(result.Conflicts, Conflict{Uuid: record.Uuid, Reason: "value taken by " + stored.Uuid})
+		return false, nil
+	}
+
+	result.Copied++
+
+	return true, nil
+}
+
+// copyRelated writes the rows attached to a bookmark, each write replaces the previous one.
+func (c *Copier) copyRelated(ctx context.Context, r row) error {
+	if r.Metadata != nil {
+		if err := c.target.SaveMetadata(ctx, *r.Metadata); err != nil {
+			return err
+		}
+	}
+
+	if r.Archive != nil {
+		if err := c.target.SaveArchive(ctx, *r.Archive); err != nil {
+			return err
+		}
+	}
+
+	if r.Health == nil || len(r.Checks) == 0 {
+		return nil
+	}
+
+	// the history is appended, so it is written once only
+	written, err := c.target.ListRecentLinkChecks(ctx, []uuid.UUID{uuid.MustParse(r.Bookmark.Uuid)}, 1)
+	if err != nil {
+		return err
+	}
+
+	if len(written) > 0 {
+		return nil
+	}
+
+	checks := slices.Clone(r.Checks)
+	slices.Reverse(checks) // oldest first
+
+	// the health is copied as it is, not folded from the history
+	health := func(*storage.LinkHealth) storage.LinkHealth { return *r.Health }
+
+	for _, check := range checks {
+		if err := c.target.SaveLinkCheck(ctx, check, health, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/internal/storage/pgsql"
+	"bookmarks/internal/storage/sqlite"
+	"bookmarks/pkg/postgres/postgrestest"
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func makeSqlite(t *testing.T) Target {
+	t.Helper()
+
+	driver, err := pkgsql.New(pkgsql.SourceName(filepath.Join(t.TempDir(), "main.db")))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = driver.DB.Close() })
+
+	s, err := sqlite.NewBookmark(driver)
+	require.NoError(t, err)
+
+	return s
+}
+
+// fill stores n bookmarks with metadata, and every second one with link checks and an archive.
+func fill(t *testing.T, s Target, n int) {
+	t.Helper()
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 123456789, time.UTC)
+
+	for i := range n {
+		id := uuid.NewString()
+		value := fmt.Sprintf("https://example.com/%d", i)
+
+		_, err := s.Create(t.Context(), storage.Bookmark{
+			Uuid:           id,
+			Title:          fmt.Sprintf("title %d", i),
+			Value:          value,
+			Kind:           "url",
+			CanonicalValue: value,
+			CreatedAt:      created.Add(time.Duration(i) * time.Minute),
+		}, repository.OnConflictError, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, s.SaveMetadata(t.Context(), storage.Metadata{
+			Uuid: id, Title: "page", Status: "ok", Attempts: 1, FetchedAt: created,
+		}))
+
+		if i%2 == 1 {
+			continue
+		}
+
+		health := storage.LinkHealth{
+			Uuid: id, Status: "broken", StatusCode: 404, Latency: 120 * time.Millisecond,
+			ConsecutiveFailures: 1, CheckedAt: created.Add(time.Hour),
+		}
+		fold := func(*storage.LinkHealth) storage.LinkHealth { return health }
+
+		require.NoError(t, s.SaveLinkCheck(t.Context(), storage.LinkCheck{
+			Uuid: id, OK: true, StatusCode: 200, Latency: 80 * time.Millisecond, CheckedAt: created,
+		}, fold, 0))
+		require.NoError(t, s.SaveLinkCheck(t.Context(), storage.LinkCheck{
+			Uuid: id, StatusCode: 404, Latency: 120 * time.Millisecond, CheckedAt: created.Add(time.Hour),
+		}, fold, 0))
+
+		require.NoError(t, s.SaveArchive(t.Context(), storage.Archive{
+			Uuid: id, Digest: "sha256:" + id, Size: 2048, Resources: 3, SourceURL: value, ArchivedAt: created,
+		}))
+	}
+}
+
+func makeMemory(*testing.T) Target {
+	return memory.NewBookmarkStorage()
+}
+
+// makePgsql opens a storage in a fresh postgres schema, the test is skipped without postgrestest.EnvURL.
+func makePgsql(t *testing.T) Target {
+	t.Helper()
+
+	s, err := pgsql.NewBookmark(postgrestest.New(t))
+	require.NoError(t, err)
+
+	return s
+}
+
+// pairs are the storages copied from and to.
+var pairs = []struct {
+	name   string
+	source func(t *testing.T) Target
+	target func(t *testing.T) Target
+}{
+	{name: "memory to sqlite", source: makeMemory, target: makeSqlite},
+	{name: "sqlite to memory", source: makeSqlite, target: makeMemory},
+	{name: "sqlite to sqlite", source: makeSqlite, target: makeSqlite},
+	{name: "sqlite to pgsql", source: makeSqlite, target: makePgsql},
+	{name: "pgsql to memory", source: makePgsql, target: makeMemory},
+	{name: "pgsql to pgsql", source: makePgsql, target: makePgsql},
+}
+
+func TestCopy(t *testing.T) {
+	for _, tt := range pairs {
+		t.Run(tt.name, func(t *testing.T) {
+			source, target := tt.source(t), tt.target(t)
+			fill(t, source, 7)
+
+			c := New(slog.New(slog.DiscardHandler), source, target, BatchSize(3))
+
+			result, err := c.Copy(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, Result{Copied: 7}, result)
+
+			report, err := c.Verify(context.Background())
+			require.NoError(t, err)
+			require.True(t, report.OK(), report)
+			require.Equal(t, 7, report.TargetCount)
+
+			records, err := target.List(t.Context(), repository.Filter{})
+			require.NoError(t, err)
+
+			checks, err := target.ListRecentLinkChecks(t.Context(), []uuid.UUID{uuid.MustParse(records[len(records)-1].Uuid)}, 0)
+			require.NoError(t, err)
+			require.Len(t, checks, 2)
+			require.Equal(t, 404, checks[0].StatusCode)
+
+			// a second run has nothing to copy
+			result, err = c.Copy(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, Result{Skipped: 7}, result)
+		})
+	}
+}
+
+func TestCopy_Resume(t *testing.T) {
+	source, target := memory.NewBookmarkStorage(), makeSqlite(t)
+	fill(t, source, 5)
+
+	path := filepath.Join(t.TempDir(), "copy.json")
+
+	c := New(slog.New(slog.DiscardHandler), source, target, BatchSize(2), Checkpoint(path, "memory -> sqlite"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// interrupted after the first batch
+	records, err := c.page(t.Context(), source, 0)
+	require.NoError(t, err)
+	require.NoError(t, c.copyBatch(t.Context(), records, &Result{}))
+	require.NoError(t, c.save(checkpoint{Key: "memory -> sqlite", Version: records[1].Version, Result: Result{Copied: 2}}))
+
+	cancel()
+
+	_, err = c.Copy(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = New(slog.New(slog.DiscardHandler), source, target, Checkpoint(path, "other")).Copy(context.Background())
+	require.ErrorIs(t, err, ErrInvalidCheckpoint)
+
+	result, err := c.Copy(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, Result{Copied: 5}, result)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	report, err := c.Verify(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.OK(), report)
+}
+
+func TestCopy_DeletedDuringCopy(t *testing.T) {
+	source, target := memory.NewBookmarkStorage(), makeSqlite(t)
+	fill(t, source, 5)
+
+	path := filepath.Join(t.TempDir(), "copy.json")
+	c := New(slog.New(slog.DiscardHandler), source, target, BatchSize(2), Checkpoint(path, "memory -> sqlite"))
+
+	records, err := c.page(t.Context(), source, 0)
+	require.NoError(t, err)
+	require.NoError(t, c.copyBatch(t.Context(), records, &Result{}))
+	require.NoError(t, c.save(checkpoint{Key: "memory -> sqlite", Version: records[1].Version, Result: Result{Copied: 2}}))
+
+	// a copied bookmark is deleted before the copy resumes, the rest are still read
+	require.NoError(t, source.Delete(t.Context(), uuid.MustParse(records[0].Uuid), 0, nil))
+
+	result, err := c.Copy(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, Result{Copied: 5}, result)
+
+	report, err := c.Verify(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4, report.SourceCount)
+	require.Equal(t, 5, report.TargetCount)
+	require.Empty(t, report.Missing)
+	require.Empty(t, report.Mismatched)
+}
+
+func TestCopy_Conflicts(t *testing.T) {
+	source, target := memory.NewBookmarkStorage(), memory.NewBookmarkStorage()
+	fill(t, source, 3)
+
+	records, err := source.List(t.Context(), repository.Filter{})
+	require.NoError(t, err)
+
+	// the same uuid with another title, and the value of another bookmark under a new uuid
+	changed := records[0]
+	changed.Title = "changed"
+	_, err = target.Create(t.Context(), changed, repository.OnConflictError, nil)
+	require.NoError(t, err)
+
+	taken := records[1]
+	taken.Uuid = uuid.NewString()
+	_, err = target.Create(t.Context(), taken, repository.OnConflictError, nil)
+	require.NoError(t, err)
+
+	c := New(slog.New(slog.DiscardHandler), source, target)
+
+	result, err := c.Copy(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Copied)
+	// the source is read oldest first
+	require.Equal(t, []Conflict{
+		{Uuid: records[1].Uuid, Reason: "value taken by " + taken.Uuid},
+		{Uuid: records[0].Uuid, Reason: "differs in the target"},
+	}, result.Conflicts)
+
+	report, err := c.Verify(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, []string{records[1].Uuid}, report.Missing)
+	require.Equal(t, []string{records[0].Uuid}, report.Mismatched)
+	require.Equal(t, 3, report.SourceCount)
+	require.Equal(t, 3, report.TargetCount)
+}
+
+func TestCopy_NoCanonicalValue(t *testing.T) {
+	for _, tt := range pairs {
+		t.Run(tt.name, func(t *testing.T) {
+			source, target := tt.source(t), tt.target(t)
+			fill(t, source, 1)
+
+			// the rows a migration left without a canonical value do not collide with each other
+			created := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+			for i := range 2 {
+				_, err := source.Create(t.Context(), storage.Bookmark{
+					Uuid:      uuid.NewString(),
+					Title:     "duplicate",
+					Value:     "https://example.com/duplicate",
+					Kind:      "url",
+					CreatedAt: created.Add(time.Duration(i) * time.Minute),
+				}, repository.OnConflictError, nil)
+				require.NoError(t, err)
+			}
+
+			c := New(slog.New(slog.DiscardHandler), source, target)
+
+			result, err := c.Copy(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, Result{Copied: 3}, result)
+
+			report, err := c.Verify(context.Background())
+			require.NoError(t, err)
+			require.True(t, report.OK(), report)
+
+			records, err := target.List(t.Context(), repository.Filter{})
+			require.NoError(t, err)
+			require.Len(t, records, 3)
+			require.Empty(t, records[0].CanonicalValue)
+			require.Empty(t, records[1].CanonicalValue)
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// Report compares the source and the target of a copy.
+type Report struct {
+	SourceCount int      `json:"source_count"`
+	TargetCount int      `json:"target_count"`
+	Missing     []string `json:"missing,omitempty"`    // source bookmarks not in the target
+	Mismatched  []string `json:"mismatched,omitempty"` // bookmarks whose checksums differ
+}
+
+// OK tells whether the target holds the source and nothing else.
+func (r Report) OK() bool {
+	return r.SourceCount == r.TargetCount && len(r.Missing) == 0 && len(r.Mismatched) == 0
+}
+
+// Verify compares the counts of both storages and the checksum of every source
+// bookmark, taken over the bookmark and its related rows, with its target copy.
+func (c *Copier) Verify(ctx context.Context) (Report, error) {
+	const op = "service.transfer.Verify"
+
+	var report Report
+
+	for version := int64(0); ; {
+		if err := ctx.Err(); err != nil {
+			return Report{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		records, err := c.page(ctx, c.source, version)
+		if err != nil {
+			return Report{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if len(records) == 0 {
+			break
+		}
+
+		report.SourceCount += len(records)
+		version = records[len(records)-1].Version
+
+		if err := c.verifyBatch(ctx, records, &report); err != nil {
+			return Report{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	for version := int64(0); ; {
+		records, err := c.page(ctx, c.target, version)
+		if err != nil {
+			return Report{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if len(records) == 0 {
+			break
+		}
+
+		report.TargetCount += len(records)
+		version = records[len(records)-1].Version
+	}
+
+	return report, nil
+}
+
+func (c *Copier) verifyBatch(ctx context.Context, records []storage.Bookmark, report *Report) error {
+	source, err := readRelated(ctx, c.source, records)
+	if err != nil {
+		return err
+	}
+
+	copies := make([]storage.Bookmark, 0, len(records))
+
+	for _, record := range records {
+		stored, err := c.target.GetByUUID(ctx, uuid.MustParse(record.Uuid))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				report.Missing = append(report.Missing, record.Uuid)
+				continue
+			}
+
+			return err
+		}
+
+		copies = append(copies, stored)
+	}
+
+	target, err := readRelated(ctx, c.target, copies)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range copies {
+		if source[record.Uuid].sum() != target[record.Uuid].sum() {
+			report.Mismatched = append(report.Mismatched, record.Uuid)
+		}
+	}
+
+	return nil
+}
+
+// row is a bookmark with its related rows.
+type row struct {
+	Bookmark storage.Bookmark
+	Metadata *storage.Metadata
+	Health   *storage.LinkHealth
+	Archive  *storage.Archive
+	Checks   []storage.LinkCheck // newest first
+}
+
+// readRelated reads the related rows of the bookmarks, keyed by uuid.
+func readRelated(ctx context.Context, s Source, records []storage.Bookmark) (map[string]row, error) {
+	rows := make(map[string]row, len(records))
+	uuids := make([]uuid.UUID, 0, len(records))
+
+	for _, record := range records {
+		id, err := uuid.Parse(record.Uuid)
+		if err != nil {
+			return nil, fmt.Errorf("bookmark %q: %w", record.Uuid, err)
+		}
+
+		uuids = append(uuids, id)
+
+		r := row{Bookmark: record}
+
+		archive, err := s.GetArchive(ctx, id)
+		switch {
+		case err == nil:
+			r.Archive = &archive
+		case !errors.Is(err, repository.ErrNotFound):
+			return nil, err
+		}
+
+		rows[record.Uuid] = r
+	}
+
+	metadata, err := s.ListMetadata(ctx, uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range metadata {
+		r := rows[m.Uuid]
+		r.Metadata = &m
+		rows[m.Uuid] = r
+	}
+
+	health, err := s.ListLinkHealth(ctx, uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range health {
+		r := rows[h.Uuid]
+		r.Health = &h
+		rows[h.Uuid] = r
+	}
+
+	checks, err := s.ListRecentLinkChecks(ctx, uuids, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, check := range checks {
+		r := rows[check.Uuid]
+		r.Checks = append(r.Checks, check)
+		rows[check.Uuid] = r
+	}
+
+	return rows, nil
+}
+
+// sum is the checksum of the row as every storage keeps it: times in UTC to the
+// microsecond, latencies to the millisecond, without the storage-assigned version.
+func (r row) sum() string {
+	r.Bookmark = normalizeBookmark(r.Bookmark)
+
+	if r.Metadata != nil {
+		m := *r.Metadata
+		m.FetchedAt = normalizeTime(m.FetchedAt)
+		r.Metadata = &m
+	}
+
+	if r.Health != nil {
+		h := *r.Health
+		h.Latency = h.Latency.Truncate(time.Millisecond)
+		h.CheckedAt = normalizeTime(h.CheckedAt)
+		r.Health = &h
+	}
+
+	if r.Archive != nil {
+		a := *r.Archive
+		a.ArchivedAt = normalizeTime(a.ArchivedAt)
+		r.Archive = &a
+	}
+
+	checks := make([]storage.LinkCheck, 0, len(r.Checks))
+	for _, check := range r.Checks {
+		check.Latency = check.Latency.Truncate(time.Millisecond)
+		check.CheckedAt = normalizeTime(check.CheckedAt)
+		checks = append(checks, check)
+	}
+
+	r.Checks = checks
+
+	return checksum(r)
+}
+
+func bookmarkSum(record storage.Bookmark) string {
+	return checksum(normalizeBookmark(record))
+}
+
+func normalizeBookmark(record storage.Bookmark) storage.Bookmark {
+	record.CreatedAt = normalizeTime(record.CreatedAt)
+	record.Version = 0
+
+	return record
+}
+
+func normalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func checksum(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err) // plain structs always marshal
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/repository"
+	"bookmarks/internal/service/jobs"
+)
+
+const (
+	deliverKind = "webhook.deliver"
+	maxDrain    = 64 << 10 // bytes of a response read to reuse the connection
+)
+
+// Envelope is the JSON body of a delivery.
+type Envelope struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Bookmark   Bookmark  `json:"bookmark"`
+}
+
+type Bookmark struct {
+	Uuid      uuid.UUID `json:"uuid"`
+	Title     string    `json:"title"`
+	Value     string    `json:"value"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type deliveryPayload struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+	Event     Envelope  `json:"event"`
+}
+
+// Publish queues a delivery of the event to every active webhook subscribed to its type.
+// A delivery already queued for the event is not queued again.
+func (s *Service) Publish(_ context.Context, event model.Event) error {
+	const op = "service.webhook.Publish"
+
+	webhooks, err := s.repo.List()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	envelope := Envelope{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		OccurredAt: event.OccurredAt,
+		Bookmark: Bookmark{
+			Uuid:      event.Bookmark.Uuid,
+			Title:     event.Bookmark.Title,
+			Value:     event.Bookmark.Value,
+			Kind:      string(event.Bookmark.Kind),
+			CreatedAt: event.Bookmark.CreatedAt,
+		},
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Active || !webhook.Subscribed(event.Type) {
+			continue
+		}
+
+		_, err := s.queue.Enqueue(
+			deliverKind,
+			deliveryPayload{WebhookID: webhook.ID, Event: envelope},
+			jobs.Attempts(s.attempts),
+			jobs.Unique(fmt.Sprintf("webhook:%s:%d", webhook.ID, event.ID)),
+		)
+		if err != nil && !errors.Is(err, repository.ErrExists) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// deliver posts the event to the webhook and logs the attempt,
+// a webhook disabled or deleted meanwhile is skipped.
+func (s *Service) deliver(ctx context.Context, job model.Job) error {
+	const op = "service.webhook.deliver"
+
+	var payload deliveryPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("%s: decode payload: %w", op, err))
+	}
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("webhook_id", payload.WebhookID.String()),
+		slog.Int64("event_id", payload.Event.ID),
+		slog.Int("attempt", job.Attempts),
+	)
+
+	webhook, err := s.repo.Get(payload.WebhookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !webhook.Active {
+		log.Debug("webhook is disabled, delivery skipped")
+		return nil
+	}
+
+	body, err := json.Marshal(payload.Event)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("%s: %w", op, err))
+	}
+
+	start := time.Now()
+	statusCode, deliverErr := s.post(ctx, webhook, payload.Event, body)
+
+	if ctx.Err() != nil {
+		return ctx.Err() // cut by shutdown, not the fault of the receiver
+	}
+
+	delivery := model.WebhookDelivery{
+		WebhookID:   webhook.ID,
+		EventID:     payload.Event.ID,
+		EventType:   model.EventType(payload.Event.Type),
+		Attempt:     job.Attempts,
+		Success:     deliverErr == nil,
+		StatusCode:  statusCode,
+		Duration:    time.Since(start),
+		DeliveredAt: time.Now(),
+	}
+
+	if deliverErr != nil {
+		delivery.Error = deliverErr.Error()
+	}
+
+	webhook, err = s.repo.RecordDelivery(delivery, s.disableAfter, s.history)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+
+		log.Error(err.Error())
+	}
+
+	if deliverErr == nil {
+		return nil
+	}
+
+	if err == nil && !webhook.Active {
+		log.Warn("webhook disabled after repeated failure", slog.Int("failures", webhook.Failures))
+		return jobs.Permanent(deliverErr)
+	}
+
+	return deliverErr
+}
+
+// post sends a signed delivery, any status but 2xx is a failure.
+func (s *Service) post(ctx context.Context, webhook model.Webhook, event Envelope, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
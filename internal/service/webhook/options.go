@@ -0,0 +1,46 @@
+package webhook
+
+import "time"
+
+type Option func(*Service)
+
+// Timeout limits one delivery request.
+func Timeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.timeout = timeout
+	}
+}
+
+// Attempts sets how many times a delivery is tried, the job queue backs off between the attempts.
+func Attempts(n int) Option {
+	return func(s *Service) {
+		s.attempts = n
+	}
+}
+
+// DisableAfter sets the consecutive failed deliveries after which a webhook is disabled.
+func DisableAfter(n int) Option {
+	return func(s *Service) {
+		s.disableAfter = n
+	}
+}
+
+// History sets how many deliveries are kept per webhook.
+func History(n int) Option {
+	return func(s *Service) {
+		s.history = n
+	}
+}
+
+func UserAgent(ua string) Option {
+	return func(s *Service) {
+		s.userAgent = ua
+	}
+}
+
+// AllowPrivateNetworks lets webhooks target loopback and private addresses.
+func AllowPrivateNetworks() Option {
+	return func(s *Service) {
+		s.allowPrivate = true
+	}
+}
@@ -0,0 +1,236 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/model"
+	"bookmarks/internal/repository"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/pkg/http/egress"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListFilter selects a page of the delivery log, newest first.
+type ListFilter struct {
+	Limit  int
+	Offset int
+}
+
+// Normalize applies the default limit and clamps the page bounds.
+func (f ListFilter) Normalize() ListFilter {
+	switch {
+	case f.Limit <= 0:
+		f.Limit = DefaultListLimit
+	case f.Limit > MaxListLimit:
+		f.Limit = MaxListLimit
+	}
+
+	f.Offset = max(f.Offset, 0)
+
+	return f
+}
+
+// Subscription is the part of a webhook set by the client.
+type Subscription struct {
+	URL    string
+	Events []model.EventType // empty means every event
+	Secret string            // empty generates a secret on create and keeps it on update
+	Active *bool             // nil keeps the webhook active on create and unchanged on update
+}
+
+type Repository interface {
+	Create(webhook model.Webhook) error
+	Update(webhook model.Webhook) error
+	Get(id uuid.UUID) (model.Webhook, error)
+	List() ([]model.Webhook, error)
+	Delete(id uuid.UUID) error
+	RecordDelivery(delivery model.WebhookDelivery, disableAfter, keep int) (model.Webhook, error)
+	ListDeliveries(id uuid.UUID, limit, offset int) ([]model.WebhookDelivery, error)
+}
+
+// Queue runs the deliveries, see jobs.Queue.
+type Queue interface {
+	Handle(kind string, handler jobs.Handler)
+	Enqueue(kind string, payload any, options ...jobs.JobOption) (model.Job, error)
+}
+
+// Service manages webhook subscriptions and delivers bookmark events to them.
+// It is an outbox sink: every published event is queued as one delivery job per subscribed webhook.
+type Service struct {
+	repo   Repository
+	queue  Queue
+	log    *slog.Logger
+	client *http.Client
+
+	timeout      time.Duration
+	attempts     int
+	disableAfter int
+	history      int
+	userAgent    string
+	allowPrivate bool
+}
+
+func New(logger *slog.Logger, repo Repository, queue Queue, options ...Option) *Service {
+	s := &Service{
+		repo:         repo,
+		queue:        queue,
+		log:          logger,
+		timeout:      10 * time.Second,
+		attempts:     8,
+		disableAfter: 15,
+		history:      100,
+		userAgent:    "bookmarks-webhook/1.0",
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	// a redirected POST would be replayed as GET
+	clientOptions := []egress.Option{egress.Timeout(s.timeout), egress.MaxRedirects(0)}
+	if s.allowPrivate {
+		clientOptions = append(clientOptions, egress.AllowPrivateNetworks())
+	}
+
+	s.client = egress.NewClient(clientOptions...)
+
+	queue.Handle(deliverKind, s.deliver)
+
+	return s
+}
+
+func (s *Service) Create(sub Subscription) (model.Webhook, error) {
+	const op = "service.webhook.Create"
+
+	webhook, err := model.NewWebhook(sub.URL, sub.Events, sub.Secret)
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sub.Active != nil {
+		webhook.Active = *sub.Active
+	}
+
+	if err := s.repo.Create(webhook); err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhook, nil
+}
+
+// Update replaces the subscription of the webhook, activating a webhook clears its failures.
+func (s *Service) Update(id string, sub Subscription) (model.Webhook, error) {
+	const op = "service.webhook.Update"
+
+	webhook, err := s.Webhook(id)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	if err := webhook.Configure(sub.URL, sub.Events, sub.Secret); err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sub.Active != nil {
+		if *sub.Active && !webhook.Active {
+			webhook.Failures = 0
+			webhook.DisabledAt = time.Time{}
+		}
+
+		webhook.Active = *sub.Active
+	}
+
+	webhook.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(webhook); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.Webhook{}, ErrWebhookNotFound
+		}
+
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) Webhook(id string) (model.Webhook, error) {
+	const op = "service.webhook.Webhook"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	webhook, err := s.repo.Get(uuid)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.Webhook{}, ErrWebhookNotFound
+		}
+
+		return model.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) List() ([]model.Webhook, error) {
+	const op = "service.webhook.List"
+
+	webhooks, err := s.repo.List()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webhooks, nil
+}
+
+// Delete removes the webhook, its queued deliveries are dropped.
+func (s *Service) Delete(id string) error {
+	const op = "service.webhook.Delete"
+
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.Delete(uuid); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWebhookNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Deliveries returns a page of the delivery log of the webhook, newest first.
+func (s *Service) Deliveries(id string, filter ListFilter) ([]model.WebhookDelivery, error) {
+	const op = "service.webhook.Deliveries"
+
+	webhook, err := s.Webhook(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter = filter.Normalize()
+
+	deliveries, err := s.repo.ListDeliveries(webhook.ID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,228 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/model"
+	jobRepo "bookmarks/internal/repository/job"
+	webhookRepo "bookmarks/internal/repository/webhook"
+	"bookmarks/internal/service/jobs"
+	"bookmarks/internal/storage/memory"
+)
+
+// receiver is an endpoint answering with the next status of its script, 200 once the script is over.
+type receiver struct {
+	*httptest.Server
+
+	secret   string
+	script   []int
+	calls    atomic.Int32
+	received chan Envelope
+}
+
+func newReceiver(t *testing.T, secret string, script ...int) *receiver {
+	t.Helper()
+
+	r := &receiver{secret: secret, script: script, received: make(chan Envelope, 10)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		err = Verify(r.secret, req.Header.Get(HeaderSignature), req.Header.Get(HeaderTimestamp), body, time.Minute)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var envelope Envelope
+		require.NoError(t, json.Unmarshal(body, &envelope))
+		require.Equal(t, envelope.Type, req.Header.Get(HeaderEvent))
+
+		if call := int(r.calls.Add(1)); call <= len(r.script) {
+			w.WriteHeader(r.script[call-1])
+			return
+		}
+
+		r.received <- envelope
+	}))
+
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func TestDeliver_Signed(t *testing.T) {
+	srv, _ := makeService(t)
+	recv := newReceiver(t, "s3cret")
+
+	all, err := srv.Create(Subscription{URL: recv.URL, Secret: "s3cret"})
+	require.NoError(t, err)
+	_, err = srv.Create(Subscription{URL: recv.URL, Secret: "s3cret", Events: []model.EventType{model.BookmarkDeleted}})
+	require.NoError(t, err)
+
+	event := makeEvent(7, model.BookmarkAppended)
+	require.NoError(t, srv.Publish(context.Background(), event))
+	// the relay publishes an event again until it is marked dispatched
+	require.NoError(t, srv.Publish(context.Background(), event))
+
+	envelope := <-recv.received
+	require.Equal(t, int64(7), envelope.ID)
+	require.Equal(t, "bookmark.appended", envelope.Type)
+	require.Equal(t, event.Bookmark.Uuid, envelope.Bookmark.Uuid)
+
+	deliveries := waitDeliveries(t, srv, all.ID.String(), 1)
+	require.True(t, deliveries[0].Success)
+	require.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+
+	select {
+	case envelope := <-recv.received:
+		t.Fatalf("unexpected delivery of event %d", envelope.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDeliver_Retry(t *testing.T) {
+	srv, _ := makeService(t)
+	recv := newReceiver(t, "s3cret", http.StatusServiceUnavailable, http.StatusInternalServerError)
+
+	webhook, err := srv.Create(Subscription{URL: recv.URL, Secret: "s3cret"})
+	require.NoError(t, err)
+	require.NoError(t, srv.Publish(context.Background(), makeEvent(1, model.BookmarkChanged)))
+
+	<-recv.received
+
+	deliveries := waitDeliveries(t, srv, webhook.ID.String(), 3)
+	require.True(t, deliveries[0].Success)
+	require.Equal(t, 3, deliveries[0].Attempt)
+	require.False(t, deliveries[2].Success)
+	require.Equal(t, http.StatusServiceUnavailable, deliveries[2].StatusCode)
+	require.Equal(t, "unexpected status 503", deliveries[2].Error)
+
+	webhook, err = srv.Webhook(webhook.ID.String())
+	require.NoError(t, err)
+	require.True(t, webhook.Active)
+	require.Zero(t, webhook.Failures)
+}
+
+func TestDeliver_Disable(t *testing.T) {
+	srv, jobsRepo := makeService(t, DisableAfter(3))
+	recv := newReceiver(t, "other secret")
+
+	webhook, err := srv.Create(Subscription{URL: recv.URL, Secret: "s3cret"})
+	require.NoError(t, err)
+	require.NoError(t, srv.Publish(context.Background(), makeEvent(1, model.BookmarkDeleted)))
+
+	waitDeliveries(t, srv, webhook.ID.String(), 3)
+
+	require.Eventually(t, func() bool {
+		failed, err := jobsRepo.List(jobs.ListFilter{Status: model.JobFailed})
+		return err == nil && len(failed) == 1 && failed[0].Attempts == 3
+	}, 2*time.Second, 5*time.Millisecond)
+
+	webhook, err = srv.Webhook(webhook.ID.String())
+	require.NoError(t, err)
+	require.False(t, webhook.Active)
+	require.Equal(t, 3, webhook.Failures)
+	require.False(t, webhook.DisabledAt.IsZero())
+
+	// a disabled webhook gets no deliveries until activated again
+	require.NoError(t, srv.Publish(context.Background(), makeEvent(2, model.BookmarkDeleted)))
+
+	active := true
+	webhook, err = srv.Update(webhook.ID.String(), Subscription{URL: recv.URL, Active: &active})
+	require.NoError(t, err)
+	require.True(t, webhook.Active)
+	require.Zero(t, webhook.Failures)
+	require.Equal(t, "s3cret", webhook.Secret)
+
+	deliveries, err := srv.Deliveries(webhook.ID.String(), ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	now := time.Now().Unix()
+	signature := Sign("secret", now, body)
+
+	require.NoError(t, Verify("secret", signature, itoa(now), body, time.Minute))
+	require.ErrorIs(t, Verify("other", signature, itoa(now), body, time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify("secret", signature, itoa(now), []byte(`{"id":2}`), time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify("secret", signature, itoa(now+1), body, time.Minute), ErrInvalidSignature)
+
+	stale := now - 3600
+	require.ErrorIs(t, Verify("secret", Sign("secret", stale, body), itoa(stale), body, time.Minute), ErrStaleTimestamp)
+}
+
+func TestCreate_Invalid(t *testing.T) {
+	srv, _ := makeService(t)
+
+	_, err := srv.Create(Subscription{URL: "ftp://example.com"})
+	require.ErrorIs(t, err, model.ErrInvalidWebhookURL)
+
+	_, err = srv.Create(Subscription{URL: "https://example.com", Events: []model.EventType{"bookmark.viewed"}})
+	require.ErrorIs(t, err, model.ErrInvalidEventType)
+
+	_, err = srv.Webhook("0193a1b2-0000-7000-8000-000000000001")
+	require.ErrorIs(t, err, ErrWebhookNotFound)
+	require.ErrorIs(t, srv.Delete("0193a1b2-0000-7000-8000-000000000001"), ErrWebhookNotFound)
+}
+
+// makeService returns a service delivering through a running queue.
+func makeService(t *testing.T, options ...Option) (*Service, *jobs.Queue) {
+	t.Helper()
+
+	queue := jobs.New(
+		slog.New(slog.DiscardHandler),
+		jobRepo.NewRepository(memory.NewJobStorage()),
+		jobs.PollInterval(time.Second),
+		jobs.Backoff(time.Millisecond, 5*time.Millisecond),
+	)
+
+	options = append([]Option{AllowPrivateNetworks(), Timeout(time.Second)}, options...)
+	srv := New(slog.New(slog.DiscardHandler), webhookRepo.NewRepository(memory.NewWebhookStorage()), queue, options...)
+
+	queue.Start()
+	t.Cleanup(func() { _ = queue.Shutdown(context.Background()) })
+
+	return srv, queue
+}
+
+func makeEvent(id int64, eventType model.EventType) model.Event {
+	bookmark, err := model.NewBookmark("title", "https://example.com/")
+	if err != nil {
+		panic(err)
+	}
+
+	return model.Event{ID: id, Type: eventType, Bookmark: bookmark, OccurredAt: time.Now()}
+}
+
+func waitDeliveries(t *testing.T, srv *Service, id string, n int) []model.WebhookDelivery {
+	t.Helper()
+
+	var deliveries []model.WebhookDelivery
+
+	require.Eventually(t, func() bool {
+		var err error
+		deliveries, err = srv.Deliveries(id, ListFilter{})
+
+		return err == nil && len(deliveries) == n
+	}, 2*time.Second, 5*time.Millisecond)
+
+	return deliveries
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
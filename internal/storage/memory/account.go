@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+type accounts struct {
+	mu     sync.RWMutex
+	users  map[string]storage.User  // name -> user
+	tokens map[string]storage.Token // hash -> token
+}
+
+func NewAccountStorage() *accounts {
+	return &accounts{
+		users:  make(map[string]storage.User),
+		tokens: make(map[string]storage.Token),
+	}
+}
+
+// CreateUser inserts the user, a taken name is ErrExists.
+func (db *accounts) CreateUser(record storage.User) error {
+	const op = "storage.account.CreateUser"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.users[record.Name]; exists {
+		return fmt.Errorf("%s: %w", op, repository.ErrExists)
+	}
+
+	db.users[record.Name] = record
+
+	return nil
+}
+
+func (db *accounts) GetUser(name string) (storage.User, error) {
+	const op = "storage.account.GetUser"
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	record, exists := db.users[name]
+	if !exists {
+		return storage.User{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return record, nil
+}
+
+// CreateToken inserts the token, the user must exist.
+func (db *accounts) CreateToken(record storage.Token) error {
+	const op = "storage.account.CreateToken"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.userByID(record.UserID); !ok {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	if _, exists := db.tokens[record.Hash]; exists {
+		return fmt.Errorf("%s: %w", op, repository.ErrExists)
+	}
+
+	db.tokens[record.Hash] = record
+
+	return nil
+}
+
+// FindToken returns the token of the hash with its user.
+func (db *accounts) FindToken(hash string) (storage.Token, storage.User, error) {
+	const op = "storage.account.FindToken"
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	token, exists := db.tokens[hash]
+	if !exists {
+		return storage.Token{}, storage.User{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	user, ok := db.userByID(token.UserID)
+	if !ok {
+		return storage.Token{}, storage.User{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return token, user, nil
+}
+
+// userByID finds a user, the caller holds the lock.
+func (db *accounts) userByID(id string) (storage.User, bool) {
+	for _, user := range db.users {
+		if user.ID == id {
+			return user, true
+		}
+	}
+
+	return storage.User{}, false
+}
@@ -1,7 +1,10 @@
 package memory
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -12,61 +15,176 @@ import (
 )
 
 type db struct {
-	mu    sync.RWMutex
-	table map[uuid.UUID]*storage.Bookmark
-	uiVal map[string]*storage.Bookmark // unique index by value
+	mu       sync.RWMutex
+	table    map[string]*storage.Bookmark
+	ixVal    map[string]*storage.Bookmark // index by value
+	uiCanon  map[string]*storage.Bookmark // unique index by canonical value
+	metadata map[string]storage.Metadata
+	health   map[string]storage.LinkHealth
+	checks   map[string][]storage.LinkCheck // oldest first
+	archives map[string]storage.Archive
+	outbox   []storage.Event // in id order
+	eventSeq int64
+
+	version    int64 // the change sequence
+	tombstones map[string]storage.Tombstone
 }
 
 func NewBookmarkStorage() *db {
 	return &db{
-		table: make(map[uuid.UUID]*storage.Bookmark),
-		uiVal: make(map[string]*storage.Bookmark),
+		table:    make(map[string]*storage.Bookmark),
+		ixVal:    make(map[string]*storage.Bookmark),
+		uiCanon:  make(map[string]*storage.Bookmark),
+		metadata: make(map[string]storage.Metadata),
+		health:   make(map[string]storage.LinkHealth),
+		checks:   make(map[string][]storage.LinkCheck),
+		archives: make(map[string]storage.Archive),
+
+		tombstones: make(map[string]storage.Tombstone),
 	}
 }
 
-func (db *db) Create(
-	uuid uuid.UUID,
-	title string,
-	val string,
-	time time.Time,
-) (storage.Bookmark, error) {
+// Create inserts the record, emit is called when a record is inserted or its title updated on conflict.
+// The uuid of a deleted bookmark is not taken again, ErrDeleted is returned for it.
+func (db *db) Create(_ context.Context, record storage.Bookmark, mode repository.OnConflict, emit repository.EmitRecord) (storage.Bookmark, error) {
 	const op = "storage.bookmark.Create"
 
-	record := storage.Bookmark{
-		Uuid:      uuid.String(),
-		Title:     title,
-		Value:     val,
-		CreatedAt: time,
-	}
-
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	if _, exists := db.table[uuid]; exists {
+	if _, exists := db.table[record.Uuid]; exists {
 		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, repository.ErrExists)
 	}
 
-	if _, exists := db.uiVal[val]; exists {
-		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, repository.ErrExists)
+	if _, deleted := db.tombstones[record.Uuid]; deleted {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, repository.ErrDeleted)
 	}
 
-	db.table[uuid] = &record
-	db.uiVal[val] = &record
+	if existing, exists := db.uiCanon[record.CanonicalValue]; exists {
+		switch mode {
+		case repository.OnConflictIgnore:
+		case repository.OnConflictUpdateTitle:
+			updated := *existing
+			updated.Title = record.Title
+			updated.TitleAuto = record.TitleAuto
+			updated.Version = db.version + 1
+
+			if err := db.appendEvents(emit, repository.ChangeUpdated, updated); err != nil {
+				return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+			}
+
+			*existing = updated
+			db.version++
+		default:
+			return *existing, fmt.Errorf("%s: %w", op, repository.ErrExists)
+		}
+
+		return *existing, nil
+	}
+
+	record.Version = db.version + 1
+
+	if err := db.appendEvents(emit, repository.ChangeCreated, record); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db.version++
+
+	db.table[record.Uuid] = &record
+	db.index(&record)
 
 	return record, nil
 }
 
-func (db *db) Update(uuid uuid.UUID, title string) (storage.Bookmark, error) {
-	return storage.Bookmark{}, nil
+// CreateMany inserts the records, skipping those whose uuid or value is taken or deleted,
+// and returns the number inserted. No outbox records are written.
+func (db *db) CreateMany(_ context.Context, records []storage.Bookmark) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	inserted := 0
+
+	for _, record := range records {
+		_, exists := db.table[record.Uuid]
+		_, deleted := db.tombstones[record.Uuid]
+		_, taken := db.uiCanon[record.CanonicalValue]
+
+		if exists || deleted || taken {
+			continue
+		}
+
+		db.version++
+		record.Version = db.version
+
+		db.table[record.Uuid] = &record
+		db.index(&record)
+
+		inserted++
+	}
+
+	return inserted, nil
+}
+
+// Update replaces the record if it is still at version, emit is called with the updated record.
+// A record changed since is returned with ErrConflict, the owner of a taken value with ErrExists.
+func (db *db) Update(_ context.Context, record storage.Bookmark, version int64, emit repository.EmitRecord) (storage.Bookmark, error) {
+	const op = "storage.bookmark.Update"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	current, err := db.find(record.Uuid)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if current.Version != version {
+		return *current, fmt.Errorf("%s: %w", op, repository.ErrConflict)
+	}
+
+	if owner, exists := db.uiCanon[record.CanonicalValue]; exists && owner != current {
+		return *owner, fmt.Errorf("%s: %w", op, repository.ErrExists)
+	}
+
+	updated := *current
+	updated.Title = record.Title
+	updated.TitleAuto = record.TitleAuto
+	updated.Value = record.Value
+	updated.Kind = record.Kind
+	updated.CanonicalValue = record.CanonicalValue
+	updated.Version = db.version + 1
+
+	if err := db.appendEvents(emit, repository.ChangeUpdated, updated); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db.version++
+
+	delete(db.ixVal, current.Value)
+	delete(db.uiCanon, current.CanonicalValue)
+
+	*current = updated
+	db.index(current)
+
+	return updated, nil
+}
+
+// index adds the record to the value indexes, a record without a canonical value never collides.
+func (db *db) index(record *storage.Bookmark) {
+	db.ixVal[record.Value] = record
+
+	if record.CanonicalValue != "" {
+		db.uiCanon[record.CanonicalValue] = record
+	}
 }
 
-func (db *db) GetByUUID(uuid uuid.UUID) (storage.Bookmark, error) {
+func (db *db) GetByUUID(_ context.Context, uuid uuid.UUID) (storage.Bookmark, error) {
 	const op = "storage.bookmark.GetByUUID"
 
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	record, exists := db.table[uuid]
+	record, exists := db.table[uuid.String()]
 	if !exists {
 		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
 	}
@@ -74,13 +192,13 @@ func (db *db) GetByUUID(uuid uuid.UUID) (storage.Bookmark, error) {
 	return *record, nil
 }
 
-func (db *db) GetByValue(val string) (storage.Bookmark, error) {
+func (db *db) GetByValue(_ context.Context, val string) (storage.Bookmark, error) {
 	const op = "storage.storage.GetByValue"
 
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	record, exists := db.uiVal[val]
+	record, exists := db.ixVal[val]
 	if !exists {
 		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
 	}
@@ -88,19 +206,185 @@ func (db *db) GetByValue(val string) (storage.Bookmark, error) {
 	return *record, nil
 }
 
-func (db *db) Delete(uuid uuid.UUID) error {
+// List returns bookmarks newest first.
+func (db *db) List(_ context.Context, filter repository.Filter) ([]storage.Bookmark, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	records := make([]storage.Bookmark, 0, len(db.table))
+	for _, record := range db.table {
+		if filter.Kind != "" && record.Kind != filter.Kind {
+			continue
+		}
+
+		if filter.Health != "" && db.health[record.Uuid].Status != filter.Health {
+			continue
+		}
+
+		records = append(records, *record)
+	}
+
+	slices.SortFunc(records, func(a, b storage.Bookmark) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.Uuid, a.Uuid))
+	})
+
+	return paginate(records, filter.Limit, filter.Offset), nil
+}
+
+// Delete removes the bookmark with everything attached to it and leaves a tombstone,
+// emit is called with the deleted record. A positive version must match the record, ErrConflict otherwise.
+func (db *db) Delete(_ context.Context, uuid uuid.UUID, version int64, emit repository.EmitRecord) error {
 	const op = "storage.bookmark.Delete"
 
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	record, exists := db.table[uuid]
+	record, err := db.find(uuid.String())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if version > 0 && record.Version != version {
+		return fmt.Errorf("%s: %w", op, repository.ErrConflict)
+	}
+
+	deleted := *record
+	deleted.Version = db.version + 1
+
+	if err := db.appendEvents(emit, repository.ChangeDeleted, deleted); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	db.version++
+	db.tombstones[record.Uuid] = storage.Tombstone{
+		Uuid:      record.Uuid,
+		Version:   deleted.Version,
+		DeletedAt: time.Now().UTC(),
+	}
+
+	delete(db.table, record.Uuid)
+	delete(db.ixVal, record.Value)
+	delete(db.uiCanon, record.CanonicalValue)
+	delete(db.metadata, record.Uuid)
+	delete(db.health, record.Uuid)
+	delete(db.checks, record.Uuid)
+	delete(db.archives, record.Uuid)
+
+	return nil
+}
+
+// UpdateAutoTitle replaces the title unless the user has set it, emit is called with the updated record.
+func (db *db) UpdateAutoTitle(_ context.Context, uuid uuid.UUID, title string, emit repository.EmitRecord) error {
+	const op = "storage.bookmark.UpdateAutoTitle"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, exists := db.table[uuid.String()]
 	if !exists {
 		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
 	}
 
-	delete(db.table, uuid)
-	delete(db.uiVal, record.Value)
+	if !record.TitleAuto {
+		return nil
+	}
+
+	updated := *record
+	updated.Title = title
+	updated.Version = db.version + 1
+
+	if err := db.appendEvents(emit, repository.ChangeUpdated, updated); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	db.version++
+	*record = updated
 
 	return nil
 }
+
+func (db *db) SaveMetadata(_ context.Context, metadata storage.Metadata) error {
+	const op = "storage.bookmark.SaveMetadata"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.table[metadata.Uuid]; !exists {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	db.metadata[metadata.Uuid] = metadata
+
+	return nil
+}
+
+func (db *db) GetMetadata(_ context.Context, uuid uuid.UUID) (storage.Metadata, error) {
+	const op = "storage.bookmark.GetMetadata"
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	metadata, exists := db.metadata[uuid.String()]
+	if !exists {
+		return storage.Metadata{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return metadata, nil
+}
+
+// ListMetadata returns the metadata fetched for the given bookmarks, bookmarks without it are skipped.
+func (db *db) ListMetadata(_ context.Context, uuids []uuid.UUID) ([]storage.Metadata, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	records := make([]storage.Metadata, 0, len(uuids))
+	for _, id := range uuids {
+		if metadata, exists := db.metadata[id.String()]; exists {
+			records = append(records, metadata)
+		}
+	}
+
+	return records, nil
+}
+
+func (db *db) SaveArchive(_ context.Context, archive storage.Archive) error {
+	const op = "storage.bookmark.SaveArchive"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.table[archive.Uuid]; !exists {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	db.archives[archive.Uuid] = archive
+
+	return nil
+}
+
+func (db *db) GetArchive(_ context.Context, uuid uuid.UUID) (storage.Archive, error) {
+	const op = "storage.bookmark.GetArchive"
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	archive, exists := db.archives[uuid.String()]
+	if !exists {
+		return storage.Archive{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return archive, nil
+}
+
+func paginate[T any](records []T, limit, offset int) []T {
+	if offset >= len(records) {
+		return []T{}
+	}
+
+	records = records[offset:]
+	if limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+
+	return records
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// DueForCheck returns URL bookmarks never checked or last checked before the given time,
+// least recently checked first.
+func (db *db) DueForCheck(_ context.Context, before time.Time, limit int) ([]storage.Bookmark, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	records := make([]storage.Bookmark, 0)
+	for _, record := range db.table {
+		if record.Kind != "url" {
+			continue
+		}
+
+		if health, checked := db.health[record.Uuid]; checked && !health.CheckedAt.Before(before) {
+			continue
+		}
+
+		records = append(records, *record)
+	}
+
+	slices.SortFunc(records, func(a, b storage.Bookmark) int {
+		return cmp.Or(
+			db.health[a.Uuid].CheckedAt.Compare(db.health[b.Uuid].CheckedAt),
+			cmp.Compare(a.Uuid, b.Uuid),
+		)
+	})
+
+	return paginate(records, limit, 0), nil
+}
+
+// SaveLinkCheck appends the check to the history, keeping the last keep entries,
+// and folds it into the current health of the link.
+func (db *db) SaveLinkCheck(_ context.Context, check storage.LinkCheck, fold repository.FoldHealth, keep int) error {
+	const op = "storage.bookmark.SaveLinkCheck"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.table[check.Uuid]; !exists {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	checks := append(db.checks[check.Uuid], check)
+	if keep > 0 && len(checks) > keep {
+		checks = slices.Clone(checks[len(checks)-keep:])
+	}
+
+	var current *storage.LinkHealth
+	if health, checked := db.health[check.Uuid]; checked {
+		current = &health
+	}
+
+	db.checks[check.Uuid] = checks
+	db.health[check.Uuid] = fold(current)
+
+	return nil
+}
+
+func (db *db) GetLinkHealth(_ context.Context, uuid uuid.UUID) (storage.LinkHealth, error) {
+	const op = "storage.bookmark.GetLinkHealth"
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	health, exists := db.health[uuid.String()]
+	if !exists {
+		return storage.LinkHealth{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return health, nil
+}
+
+// ListLinkChecks returns the check history of a link, newest first.
+func (db *db) ListLinkChecks(_ context.Context, uuid uuid.UUID, limit int) ([]storage.LinkCheck, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	checks := slices.Clone(db.checks[uuid.String()])
+	slices.Reverse(checks)
+
+	if checks == nil {
+		checks = []storage.LinkCheck{}
+	}
+
+	return paginate(checks, limit, 0), nil
+}
+
+// ListLinkHealth returns the current health of the given links, links never checked are skipped.
+func (db *db) ListLinkHealth(_ context.Context, uuids []uuid.UUID) ([]storage.LinkHealth, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	records := make([]storage.LinkHealth, 0, len(uuids))
+	for _, id := range uuids {
+		if health, exists := db.health[id.String()]; exists {
+			records = append(records, health)
+		}
+	}
+
+	return records, nil
+}
+
+// ListRecentLinkChecks returns the last limit checks of each of the given links, newest first.
+func (db *db) ListRecentLinkChecks(_ context.Context, uuids []uuid.UUID, limit int) ([]storage.LinkCheck, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	checks := make([]storage.LinkCheck, 0)
+	for _, id := range uuids {
+		recent := slices.Clone(db.checks[id.String()])
+		slices.Reverse(recent)
+
+		checks = append(checks, paginate(recent, limit, 0)...)
+	}
+
+	return checks, nil
+}
@@ -0,0 +1,305 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+type jobs struct {
+	mu     sync.Mutex
+	table  map[int64]*storage.Job
+	unique map[string]int64 // unique key -> id
+	nextID int64
+}
+
+func NewJobStorage() *jobs {
+	return &jobs{
+		table:  make(map[int64]*storage.Job),
+		unique: make(map[string]int64),
+	}
+}
+
+func (db *jobs) Enqueue(record storage.Job) (storage.Job, error) {
+	const op = "storage.job.Enqueue"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if record.UniqueKey != "" {
+		if _, exists := db.unique[record.UniqueKey]; exists {
+			return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrExists)
+		}
+	}
+
+	db.nextID++
+
+	record.ID = db.nextID
+	record.Attempts = 0
+	record.LeaseOwner = ""
+	record.LeasedUntil = time.Time{}
+	record.LastError = ""
+	record.UpdatedAt = record.CreatedAt
+	record.FinishedAt = time.Time{}
+
+	db.table[record.ID] = &record
+	if record.UniqueKey != "" {
+		db.unique[record.UniqueKey] = record.ID
+	}
+
+	return record, nil
+}
+
+// Lease takes due queued jobs and running jobs whose lease has expired,
+// the oldest first. Every lease counts as an attempt: a job whose last attempt
+// ran out its lease fails instead of being taken again.
+func (db *jobs) Lease(lease repository.Lease) ([]storage.Job, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	due := make([]*storage.Job, 0)
+	for _, record := range db.table {
+		if !slices.Contains(lease.Kinds, record.Kind) {
+			continue
+		}
+
+		queued := record.Status == "queued" && !record.RunAt.After(lease.Now)
+		expired := record.Status == "running" && record.LeasedUntil.Before(lease.Now)
+
+		if expired && record.Attempts >= record.MaxAttempts {
+			record.Status = "failed"
+			record.LeasedUntil = time.Time{}
+			record.LastError = repository.LeaseExpired
+			record.UpdatedAt = lease.Now
+			record.FinishedAt = lease.Now
+
+			continue
+		}
+
+		if queued || expired {
+			due = append(due, record)
+		}
+	}
+
+	slices.SortFunc(due, func(a, b *storage.Job) int {
+		return cmp.Or(a.RunAt.Compare(b.RunAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	records := make([]storage.Job, 0)
+	for _, record := range paginate(due, lease.Limit, 0) {
+		record.Status = "running"
+		record.Attempts++
+		record.LeaseOwner = lease.Owner
+		record.LeasedUntil = lease.Until
+		record.UpdatedAt = lease.Now
+
+		records = append(records, *record)
+	}
+
+	return records, nil
+}
+
+func (db *jobs) ExtendLease(id int64, owner string, until time.Time) error {
+	const op = "storage.job.ExtendLease"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, err := db.leased(id, owner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	record.LeasedUntil = until
+
+	return nil
+}
+
+func (db *jobs) Complete(id int64, owner string, at time.Time) error {
+	const op = "storage.job.Complete"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, err := db.leased(id, owner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	record.Status = "succeeded"
+	record.LeasedUntil = time.Time{}
+	record.LastError = ""
+	record.UpdatedAt = at
+	record.FinishedAt = at
+
+	return nil
+}
+
+// Release queues a running job again at once and gives its attempt back.
+func (db *jobs) Release(id int64, owner string, at time.Time) error {
+	const op = "storage.job.Release"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, err := db.leased(id, owner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	record.Status = "queued"
+	record.Attempts = max(record.Attempts-1, 0)
+	record.RunAt = at
+	record.LeasedUntil = time.Time{}
+	record.UpdatedAt = at
+
+	return nil
+}
+
+// Fail records the error of the attempt. The job is queued again at retryAt,
+// or fails for good when retryAt is zero.
+func (db *jobs) Fail(id int64, owner, message string, at, retryAt time.Time) error {
+	const op = "storage.job.Fail"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, err := db.leased(id, owner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if retryAt.IsZero() {
+		record.Status = "failed"
+		record.FinishedAt = at
+	} else {
+		record.Status = "queued"
+		record.RunAt = retryAt
+	}
+
+	record.LeasedUntil = time.Time{}
+	record.LastError = message
+	record.UpdatedAt = at
+
+	return nil
+}
+
+func (db *jobs) Get(id int64) (storage.Job, error) {
+	const op = "storage.job.Get"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, exists := db.table[id]
+	if !exists {
+		return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return *record, nil
+}
+
+// List returns jobs newest first.
+func (db *jobs) List(filter repository.JobFilter) ([]storage.Job, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	records := make([]storage.Job, 0)
+	for _, record := range db.table {
+		if filter.Status != "" && record.Status != filter.Status {
+			continue
+		}
+
+		if filter.Kind != "" && record.Kind != filter.Kind {
+			continue
+		}
+
+		records = append(records, *record)
+	}
+
+	slices.SortFunc(records, func(a, b storage.Job) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return paginate(records, filter.Limit, filter.Offset), nil
+}
+
+// Retry queues a failed job again with a fresh set of attempts.
+func (db *jobs) Retry(id int64, at time.Time) (storage.Job, error) {
+	const op = "storage.job.Retry"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, exists := db.table[id]
+	if !exists {
+		return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	if record.Status != "failed" {
+		return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrConflict)
+	}
+
+	record.Status = "queued"
+	record.Attempts = 0
+	record.RunAt = at
+	record.LeaseOwner = ""
+	record.UpdatedAt = at
+	record.FinishedAt = time.Time{}
+
+	return *record, nil
+}
+
+// Purge deletes succeeded and failed jobs finished before the given time.
+func (db *jobs) Purge(before time.Time) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var purged int
+	for id, record := range db.table {
+		if record.Status != "succeeded" && record.Status != "failed" || !record.FinishedAt.Before(before) {
+			continue
+		}
+
+		delete(db.table, id)
+		if record.UniqueKey != "" {
+			delete(db.unique, record.UniqueKey)
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (db *jobs) OldestDue(kinds []string, now time.Time) (time.Time, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var oldest time.Time
+	for _, record := range db.table {
+		if record.Status != "queued" || record.RunAt.After(now) || !slices.Contains(kinds, record.Kind) {
+			continue
+		}
+
+		if oldest.IsZero() || record.RunAt.Before(oldest) {
+			oldest = record.RunAt
+		}
+	}
+
+	return oldest, nil
+}
+
+// leased returns the job if it is still leased by the owner.
+func (db *jobs) leased(id int64, owner string) (*storage.Job, error) {
+	record, exists := db.table[id]
+	if !exists || record.Status != "running" || record.LeaseOwner != owner {
+		return nil, repository.ErrLeaseLost
+	}
+
+	return record, nil
+}
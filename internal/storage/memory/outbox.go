@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// appendEvents writes the events of a change to the outbox, the caller holds the lock.
+func (db *db) appendEvents(emit repository.EmitRecord, change repository.Change, record storage.Bookmark) error {
+	if emit == nil {
+		return nil
+	}
+
+	events, err := emit(change, record)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		db.eventSeq++
+		event.ID = db.eventSeq
+		event.DispatchedAt = time.Time{}
+		db.outbox = append(db.outbox, event)
+	}
+
+	return nil
+}
+
+// PendingEvents returns the events after the given id neither dispatched nor dead yet, in the order they were written.
+func (db *db) PendingEvents(_ context.Context, after int64, limit int) ([]storage.Event, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	events := make([]storage.Event, 0)
+	for _, event := range db.outbox {
+		if len(events) == limit {
+			break
+		}
+
+		if event.ID > after && event.DispatchedAt.IsZero() && event.DeadAt.IsZero() {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (db *db) MarkDispatched(_ context.Context, ids []int64, at time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.outbox {
+		if db.outbox[i].DispatchedAt.IsZero() && slices.Contains(ids, db.outbox[i].ID) {
+			db.outbox[i].DispatchedAt = at
+		}
+	}
+
+	return nil
+}
+
+// FailEvent counts a failed publish of a pending event, the event is dead once it failed maxAttempts times.
+// It reports whether the event is dead.
+func (db *db) FailEvent(_ context.Context, id int64, reason string, maxAttempts int, at time.Time) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.outbox {
+		event := &db.outbox[i]
+		if event.ID != id || !event.DispatchedAt.IsZero() || !event.DeadAt.IsZero() {
+			continue
+		}
+
+		event.Attempts++
+		event.LastError = reason
+
+		if event.Attempts >= maxAttempts {
+			event.DeadAt = at
+		}
+
+		return !event.DeadAt.IsZero(), nil
+	}
+
+	return false, nil
+}
+
+// PurgeEvents deletes the events dispatched or dead before the given time.
+func (db *db) PurgeEvents(_ context.Context, before time.Time) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	n := len(db.outbox)
+	db.outbox = slices.DeleteFunc(db.outbox, func(event storage.Event) bool {
+		return !event.DispatchedAt.IsZero() && event.DispatchedAt.Before(before) ||
+			!event.DeadAt.IsZero() && event.DeadAt.Before(before)
+	})
+
+	return n - len(db.outbox), nil
+}
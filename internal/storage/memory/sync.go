@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// Changes returns up to limit bookmarks and up to limit tombstones written after since
+// along with the version of the last write.
+func (db *db) Changes(_ context.Context, since int64, limit int) (storage.ChangeSet, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	set := storage.ChangeSet{
+		Bookmarks:  make([]storage.Bookmark, 0),
+		Tombstones: make([]storage.Tombstone, 0),
+		Version:    db.version,
+	}
+
+	for _, record := range db.table {
+		if record.Version > since {
+			set.Bookmarks = append(set.Bookmarks, *record)
+		}
+	}
+
+	for _, tombstone := range db.tombstones {
+		if tombstone.Version > since {
+			set.Tombstones = append(set.Tombstones, tombstone)
+		}
+	}
+
+	slices.SortFunc(set.Bookmarks, func(a, b storage.Bookmark) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	slices.SortFunc(set.Tombstones, func(a, b storage.Tombstone) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	set.Bookmarks = set.Bookmarks[:min(len(set.Bookmarks), limit)]
+	set.Tombstones = set.Tombstones[:min(len(set.Tombstones), limit)]
+
+	return set, nil
+}
+
+// find returns the record of uuid, ErrDeleted when it left a tombstone. The caller holds the lock.
+func (db *db) find(uuid string) (*storage.Bookmark, error) {
+	if record, exists := db.table[uuid]; exists {
+		return record, nil
+	}
+
+	if _, deleted := db.tombstones[uuid]; deleted {
+		return nil, repository.ErrDeleted
+	}
+
+	return nil, repository.ErrNotFound
+}
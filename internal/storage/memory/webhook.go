@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+type webhooks struct {
+	mu         sync.Mutex
+	table      map[string]storage.Webhook
+	deliveries map[string][]storage.WebhookDelivery // oldest first
+	nextID     int64
+}
+
+func NewWebhookStorage() *webhooks {
+	return &webhooks{
+		table:      make(map[string]storage.Webhook),
+		deliveries: make(map[string][]storage.WebhookDelivery),
+	}
+}
+
+func (db *webhooks) Create(record storage.Webhook) error {
+	const op = "storage.webhook.Create"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.table[record.ID]; exists {
+		return fmt.Errorf("%s: %w", op, repository.ErrExists)
+	}
+
+	record.Events = slices.Clone(record.Events)
+	db.table[record.ID] = record
+
+	return nil
+}
+
+func (db *webhooks) Update(record storage.Webhook) error {
+	const op = "storage.webhook.Update"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	existing, exists := db.table[record.ID]
+	if !exists {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	record.Events = slices.Clone(record.Events)
+	record.CreatedAt = existing.CreatedAt
+	db.table[record.ID] = record
+
+	return nil
+}
+
+func (db *webhooks) Get(id string) (storage.Webhook, error) {
+	const op = "storage.webhook.Get"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, exists := db.table[id]
+	if !exists {
+		return storage.Webhook{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	record.Events = slices.Clone(record.Events)
+
+	return record, nil
+}
+
+// List returns the webhooks oldest first.
+func (db *webhooks) List() ([]storage.Webhook, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	records := make([]storage.Webhook, 0, len(db.table))
+	for _, record := range db.table {
+		record.Events = slices.Clone(record.Events)
+		records = append(records, record)
+	}
+
+	slices.SortFunc(records, func(a, b storage.Webhook) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return records, nil
+}
+
+// Delete removes the webhook with its delivery log.
+func (db *webhooks) Delete(id string) error {
+	const op = "storage.webhook.Delete"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.table[id]; !exists {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	delete(db.table, id)
+	delete(db.deliveries, id)
+
+	return nil
+}
+
+// RecordDelivery logs the delivery keeping the last keep ones and counts the consecutive failures,
+// the webhook is disabled once they reach disableAfter. The updated webhook is returned.
+func (db *webhooks) RecordDelivery(delivery storage.WebhookDelivery, disableAfter, keep int) (storage.Webhook, error) {
+	const op = "storage.webhook.RecordDelivery"
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, exists := db.table[delivery.WebhookID]
+	if !exists {
+		return storage.Webhook{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	if delivery.Success {
+		record.Failures = 0
+	} else {
+		record.Failures++
+
+		if record.Active && disableAfter > 0 && record.Failures >= disableAfter {
+			record.Active = false
+			record.DisabledAt = delivery.DeliveredAt
+		}
+	}
+
+	db.table[record.ID] = record
+
+	db.nextID++
+	delivery.ID = db.nextID
+
+	deliveries := append(db.deliveries[record.ID], delivery)
+	if keep > 0 && len(deliveries) > keep {
+		deliveries = slices.Clone(deliveries[len(deliveries)-keep:])
+	}
+
+	db.deliveries[record.ID] = deliveries
+
+	record.Events = slices.Clone(record.Events)
+
+	return record, nil
+}
+
+// ListDeliveries returns a page of the delivery log of the webhook, newest first.
+func (db *webhooks) ListDeliveries(id string, limit, offset int) ([]storage.WebhookDelivery, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	deliveries := slices.Clone(db.deliveries[id])
+	slices.Reverse(deliveries)
+
+	return paginate(deliveries, limit, offset), nil
+}
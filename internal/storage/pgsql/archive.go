@@ -0,0 +1,74 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// SaveArchive replaces the archive of the bookmark,
+// the blob of a replaced archive stays in the blob store.
+func (s *Pgsql) SaveArchive(ctx context.Context, archive storage.Archive) error {
+	const op = "storage.bookmark.SaveArchive"
+
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO bookmark_archive(uuid, digest, size, resources, source_url, archived_at)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE EXISTS (SELECT 1 FROM bookmark WHERE uuid = $1)
+		ON CONFLICT(uuid) DO UPDATE SET
+			digest = excluded.digest,
+			size = excluded.size,
+			resources = excluded.resources,
+			source_url = excluded.source_url,
+			archived_at = excluded.archived_at
+		`,
+		archive.Uuid,
+		archive.Digest,
+		archive.Size,
+		archive.Resources,
+		archive.SourceURL,
+		archive.ArchivedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Pgsql) GetArchive(ctx context.Context, uuid uuid.UUID) (storage.Archive, error) {
+	const op = "storage.bookmark.GetArchive"
+
+	var archive storage.Archive
+
+	err := s.pool.QueryRow(ctx, `
+		SELECT uuid, digest, size, resources, source_url, archived_at
+		FROM bookmark_archive WHERE uuid = $1
+		`, uuid.String()).Scan(
+		&archive.Uuid,
+		&archive.Digest,
+		&archive.Size,
+		&archive.Resources,
+		&archive.SourceURL,
+		&archive.ArchivedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.Archive{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.Archive{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return archive, nil
+}
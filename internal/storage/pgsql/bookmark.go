@@ -1,15 +1,401 @@
 package pgsql
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
 	"bookmarks/pkg/postgres"
 )
 
+const (
+	bookmarkColumns = "uuid, title, title_auto, value, kind, canonical_value, created_at, version"
+	selectQuery     = "SELECT " + bookmarkColumns + " FROM bookmark"
+)
+
+// Pgsql keeps the bookmarks with their metadata, link health and archives.
+// It serves as a source and a target of the storage copy, and numbers every write
+// and delete with the change sequence of the delta sync like the other storages.
 type Pgsql struct {
 	pool *pgxpool.Pool
 }
 
 func NewBookmark(p *postgres.Pgsql) (*Pgsql, error) {
+	const op = "storage.pgsql.NewBookmark"
+
+	queries := []string{
+		`
+		CREATE TABLE IF NOT EXISTS bookmark(
+			uuid TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			title_auto BOOLEAN NOT NULL DEFAULT FALSE,
+			value TEXT NOT NULL,
+			kind TEXT NOT NULL DEFAULT 'text',
+			canonical_value TEXT,
+			created_at TIMESTAMPTZ NOT NULL,
+			version BIGINT NOT NULL);
+		`,
+		`CREATE INDEX IF NOT EXISTS ix_value ON bookmark(value);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS ui_canonical_value ON bookmark(canonical_value);`,
+		`CREATE INDEX IF NOT EXISTS ix_kind_created_at ON bookmark(kind, created_at);`,
+		`CREATE INDEX IF NOT EXISTS ix_created_at ON bookmark(created_at);`,
+		`CREATE INDEX IF NOT EXISTS ix_bookmark_version ON bookmark(version);`,
+		`
+		CREATE TABLE IF NOT EXISTS bookmark_tombstone(
+			uuid TEXT PRIMARY KEY,
+			version BIGINT NOT NULL,
+			deleted_at TIMESTAMPTZ NOT NULL);
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS change_sequence(
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			value BIGINT NOT NULL);
+		`,
+		`INSERT INTO change_sequence(id, value) VALUES(1, 0) ON CONFLICT(id) DO NOTHING;`,
+		`
+		CREATE TABLE IF NOT EXISTS bookmark_metadata(
+			uuid TEXT PRIMARY KEY REFERENCES bookmark(uuid) ON DELETE CASCADE,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL,
+			canonical_url TEXT NOT NULL,
+			favicon TEXT NOT NULL,
+			image TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL,
+			attempts INTEGER NOT NULL,
+			fetched_at TIMESTAMPTZ NOT NULL);
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS link_health(
+			uuid TEXT PRIMARY KEY REFERENCES bookmark(uuid) ON DELETE CASCADE,
+			status TEXT NOT NULL,
+			status_code INTEGER NOT NULL,
+			redirect_to TEXT NOT NULL,
+			latency_ms BIGINT NOT NULL,
+			error TEXT NOT NULL,
+			consecutive_failures INTEGER NOT NULL,
+			checked_at TIMESTAMPTZ NOT NULL);
+		`,
+		`CREATE INDEX IF NOT EXISTS ix_link_health_status ON link_health(status);`,
+		`CREATE INDEX IF NOT EXISTS ix_link_health_checked_at ON link_health(checked_at);`,
+		`
+		CREATE TABLE IF NOT EXISTS link_check(
+			id BIGSERIAL PRIMARY KEY,
+			uuid TEXT NOT NULL REFERENCES bookmark(uuid) ON DELETE CASCADE,
+			ok BOOLEAN NOT NULL,
+			status_code INTEGER NOT NULL,
+			redirect_to TEXT NOT NULL,
+			latency_ms BIGINT NOT NULL,
+			error TEXT NOT NULL,
+			checked_at TIMESTAMPTZ NOT NULL);
+		`,
+		`CREATE INDEX IF NOT EXISTS ix_link_check_uuid ON link_check(uuid, id);`,
+		`
+		CREATE TABLE IF NOT EXISTS bookmark_archive(
+			uuid TEXT PRIMARY KEY REFERENCES bookmark(uuid) ON DELETE CASCADE,
+			digest TEXT NOT NULL,
+			size BIGINT NOT NULL,
+			resources INTEGER NOT NULL,
+			source_url TEXT NOT NULL,
+			archived_at TIMESTAMPTZ NOT NULL);
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS outbox(
+			id BIGSERIAL PRIMARY KEY,
+			type TEXT NOT NULL,
+			aggregate_id TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			occurred_at TIMESTAMPTZ NOT NULL,
+			dispatched_at TIMESTAMPTZ);
+		`,
+		`CREATE INDEX IF NOT EXISTS ix_outbox_dispatched_at ON outbox(dispatched_at);`,
+	}
+
+	for _, query := range queries {
+		if _, err := p.Pool.Exec(context.Background(), query); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
 	return &Pgsql{pool: p.Pool}, nil
 }
+
+// Create inserts the record, emit is called when a row is inserted or its title updated on conflict.
+// The uuid of a deleted bookmark is not taken again, ErrDeleted is returned for it.
+func (s *Pgsql) Create(ctx context.Context, record storage.Bookmark, mode repository.OnConflict, emit repository.EmitRecord) (storage.Bookmark, error) {
+	const op = "storage.bookmark.Create"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	record.Version, err = nextVersion(ctx, tx)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var deleted bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM bookmark_tombstone WHERE uuid = $1)`, record.Uuid).Scan(&deleted); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deleted {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, repository.ErrDeleted)
+	}
+
+	tag, err := tx.Exec(ctx, insertQuery(mode),
+		record.Uuid,
+		record.Title,
+		record.TitleAuto,
+		record.Value,
+		record.Kind,
+		record.CanonicalValue,
+		record.CreatedAt,
+		record.Version,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, repository.ErrExists)
+		}
+
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	inserted := record.Uuid
+
+	// the value is taken: read the surviving row inside the same transaction,
+	// a row without a canonical value never collides
+	if record.CanonicalValue != "" && (tag.RowsAffected() == 0 || mode == repository.OnConflictUpdateTitle) {
+		record, err = scanBookmark(tx.QueryRow(ctx, selectQuery+" WHERE canonical_value = $1", record.CanonicalValue))
+		if err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if tag.RowsAffected() > 0 {
+		change := repository.ChangeCreated
+		if record.Uuid != inserted {
+			change = repository.ChangeUpdated
+		}
+
+		if err := insertEvents(ctx, tx, emit, change, record); err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 && mode == repository.OnConflictError {
+		return record, fmt.Errorf("%s: %w", op, repository.ErrExists)
+	}
+
+	return record, nil
+}
+
+// CreateMany inserts the records in one transaction, skipping those whose uuid or value
+// is taken or deleted, and returns the number inserted. No outbox records are written.
+func (s *Pgsql) CreateMany(ctx context.Context, records []storage.Bookmark) (int, error) {
+	const op = "storage.bookmark.CreateMany"
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	// the records take the versions up to last, skipped ones leave gaps
+	var last int64
+	err = tx.QueryRow(ctx, `UPDATE change_sequence SET value = value + $1 WHERE id = 1 RETURNING value`, len(records)).Scan(&last)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	batch := &pgx.Batch{}
+
+	for i, record := range records {
+		batch.Queue(`
+			INSERT INTO bookmark(uuid, title, title_auto, value, kind, canonical_value, created_at, version)
+			SELECT $1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8
+			WHERE NOT EXISTS (SELECT 1 FROM bookmark_tombstone WHERE uuid = $1)
+			ON CONFLICT DO NOTHING
+			`,
+			record.Uuid,
+			record.Title,
+			record.TitleAuto,
+			record.Value,
+			record.Kind,
+			record.CanonicalValue,
+			record.CreatedAt,
+			last-int64(len(records)-1-i),
+		)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+
+	inserted := 0
+
+	for range records {
+		tag, err := results.Exec()
+		if err != nil {
+			_ = results.Close()
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		inserted += int(tag.RowsAffected())
+	}
+
+	if err := results.Close(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inserted, nil
+}
+
+func (s *Pgsql) GetByUUID(ctx context.Context, uuid uuid.UUID) (storage.Bookmark, error) {
+	const op = "storage.bookmark.GetByUUID"
+
+	record, err := scanBookmark(s.pool.QueryRow(ctx, selectQuery+" WHERE uuid = $1", uuid.String()))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return storage.Bookmark{}, repository.ErrNotFound
+		}
+
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return record, nil
+}
+
+// List returns bookmarks newest first.
+func (s *Pgsql) List(ctx context.Context, filter repository.Filter) ([]storage.Bookmark, error) {
+	const op = "storage.bookmark.List"
+
+	var limit *int
+	if filter.Limit > 0 {
+		limit = &filter.Limit
+	}
+
+	rows, err := s.pool.Query(ctx,
+		selectQuery+`
+		WHERE ($1 = '' OR kind = $1)
+		AND ($4 = '' OR uuid IN (SELECT uuid FROM link_health WHERE status = $4))
+		ORDER BY created_at DESC, uuid DESC
+		LIMIT $2 OFFSET $3`,
+		filter.Kind,
+		limit,
+		filter.Offset,
+		filter.Health,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	records, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.Bookmark, error) {
+		return scanBookmark(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// insertQuery stores an empty canonical value as NULL, such rows never collide.
+func insertQuery(mode repository.OnConflict) string {
+	const query = `
+		INSERT INTO bookmark(uuid, title, title_auto, value, kind, canonical_value, created_at, version)
+		VALUES($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		ON CONFLICT(canonical_value) DO `
+
+	if mode == repository.OnConflictUpdateTitle {
+		return query + "UPDATE SET title = excluded.title, title_auto = excluded.title_auto, version = excluded.version"
+	}
+
+	return query + "NOTHING"
+}
+
+func insertEvents(ctx context.Context, tx pgx.Tx, emit repository.EmitRecord, change repository.Change, record storage.Bookmark) error {
+	if emit == nil {
+		return nil
+	}
+
+	events, err := emit(change, record)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO outbox(type, aggregate_id, payload, occurred_at)
+			VALUES($1, $2, $3, $4)
+			`,
+			event.Type,
+			event.AggregateID,
+			event.Payload,
+			event.OccurredAt.UTC(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanBookmark(row pgx.Row) (storage.Bookmark, error) {
+	var (
+		record    storage.Bookmark
+		canonical *string // NULL for rows without a canonical value
+	)
+
+	err := row.Scan(
+		&record.Uuid,
+		&record.Title,
+		&record.TitleAuto,
+		&record.Value,
+		&record.Kind,
+		&canonical,
+		&record.CreatedAt,
+		&record.Version,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.Bookmark{}, repository.ErrNotFound
+		}
+
+		return storage.Bookmark{}, err
+	}
+
+	if canonical != nil {
+		record.CanonicalValue = *canonical
+	}
+
+	return record, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" // unique_violation
+}
@@ -0,0 +1,220 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// SaveLinkCheck appends the check to the history, keeping the last keep entries,
+// and folds it into the current health of the link. The bookmark row is locked
+// for the transaction, concurrent checks of the link are folded one after the other.
+func (s *Pgsql) SaveLinkCheck(ctx context.Context, check storage.LinkCheck, fold repository.FoldHealth, keep int) error {
+	const op = "storage.bookmark.SaveLinkCheck"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	var exists int
+
+	err = tx.QueryRow(ctx, `SELECT 1 FROM bookmark WHERE uuid = $1 FOR NO KEY UPDATE`, check.Uuid).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO link_check(uuid, ok, status_code, redirect_to, latency_ms, error, checked_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		`,
+		check.Uuid,
+		check.OK,
+		check.StatusCode,
+		check.RedirectTo,
+		check.Latency.Milliseconds(),
+		check.Error,
+		check.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if keep > 0 {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM link_check WHERE uuid = $1 AND id NOT IN (
+				SELECT id FROM link_check WHERE uuid = $1 ORDER BY id DESC LIMIT $2)
+			`, check.Uuid, keep)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var (
+		current *storage.LinkHealth
+		stored  storage.LinkHealth
+		latency int64
+	)
+
+	err = tx.QueryRow(ctx, `
+		SELECT uuid, status, status_code, redirect_to, latency_ms, error, consecutive_failures, checked_at
+		FROM link_health WHERE uuid = $1
+		`, check.Uuid).Scan(
+		&stored.Uuid,
+		&stored.Status,
+		&stored.StatusCode,
+		&stored.RedirectTo,
+		&latency,
+		&stored.Error,
+		&stored.ConsecutiveFailures,
+		&stored.CheckedAt,
+	)
+
+	switch {
+	case err == nil:
+		stored.Latency = time.Duration(latency) * time.Millisecond
+		current = &stored
+	case !errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	health := fold(current)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO link_health(
+			uuid, status, status_code, redirect_to, latency_ms, error, consecutive_failures, checked_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT(uuid) DO UPDATE SET
+			status = excluded.status,
+			status_code = excluded.status_code,
+			redirect_to = excluded.redirect_to,
+			latency_ms = excluded.latency_ms,
+			error = excluded.error,
+			consecutive_failures = excluded.consecutive_failures,
+			checked_at = excluded.checked_at
+		`,
+		health.Uuid,
+		health.Status,
+		health.StatusCode,
+		health.RedirectTo,
+		health.Latency.Milliseconds(),
+		health.Error,
+		health.ConsecutiveFailures,
+		health.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListLinkHealth returns the current health of the given links, links never checked are skipped.
+func (s *Pgsql) ListLinkHealth(ctx context.Context, uuids []uuid.UUID) ([]storage.LinkHealth, error) {
+	const op = "storage.bookmark.ListLinkHealth"
+
+	if len(uuids) == 0 {
+		return []storage.LinkHealth{}, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT uuid, status, status_code, redirect_to, latency_ms, error, consecutive_failures, checked_at
+		FROM link_health WHERE uuid = ANY($1)`,
+		uuidStrings(uuids),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	records, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.LinkHealth, error) {
+		var (
+			health  storage.LinkHealth
+			latency int64
+		)
+
+		err := row.Scan(
+			&health.Uuid,
+			&health.Status,
+			&health.StatusCode,
+			&health.RedirectTo,
+			&latency,
+			&health.Error,
+			&health.ConsecutiveFailures,
+			&health.CheckedAt,
+		)
+		health.Latency = time.Duration(latency) * time.Millisecond
+
+		return health, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// ListRecentLinkChecks returns the last limit checks of each of the given links, newest first.
+func (s *Pgsql) ListRecentLinkChecks(ctx context.Context, uuids []uuid.UUID, limit int) ([]storage.LinkCheck, error) {
+	const op = "storage.bookmark.ListRecentLinkChecks"
+
+	if len(uuids) == 0 {
+		return []storage.LinkCheck{}, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT uuid, ok, status_code, redirect_to, latency_ms, error, checked_at
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY uuid ORDER BY id DESC) AS n
+			FROM link_check WHERE uuid = ANY($1)
+		) AS recent
+		WHERE $2 <= 0 OR n <= $2
+		ORDER BY uuid, n`,
+		uuidStrings(uuids),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	checks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.LinkCheck, error) {
+		var (
+			check   storage.LinkCheck
+			latency int64
+		)
+
+		err := row.Scan(
+			&check.Uuid,
+			&check.OK,
+			&check.StatusCode,
+			&check.RedirectTo,
+			&latency,
+			&check.Error,
+			&check.CheckedAt,
+		)
+		check.Latency = time.Duration(latency) * time.Millisecond
+
+		return check, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return checks, nil
+}
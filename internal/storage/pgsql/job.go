@@ -0,0 +1,436 @@
+package pgsql
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/pkg/postgres"
+)
+
+const jobColumns = `id, kind, payload, unique_key, status, attempts, max_attempts, run_at,
+	lease_owner, leased_until, last_error, created_at, updated_at, finished_at`
+
+// Jobs keeps the background job queue in the jobs table,
+// concurrent workers lease with FOR UPDATE SKIP LOCKED.
+type Jobs struct {
+	pool *pgxpool.Pool
+}
+
+func NewJob(p *postgres.Pgsql) (*Jobs, error) {
+	const op = "storage.pgsql.NewJob"
+
+	queries := []string{
+		`
+		CREATE TABLE IF NOT EXISTS jobs(
+			id BIGSERIAL PRIMARY KEY,
+			kind TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			unique_key TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL,
+			max_attempts INTEGER NOT NULL,
+			run_at TIMESTAMPTZ NOT NULL,
+			lease_owner TEXT NOT NULL,
+			leased_until TIMESTAMPTZ,
+			last_error TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL,
+			finished_at TIMESTAMPTZ);
+		`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS ui_jobs_unique_key ON jobs(unique_key) WHERE unique_key <> '';`,
+		`CREATE INDEX IF NOT EXISTS ix_jobs_status_run_at ON jobs(status, run_at);`,
+		`CREATE INDEX IF NOT EXISTS ix_jobs_finished_at ON jobs(finished_at);`,
+	}
+
+	for _, query := range queries {
+		if _, err := p.Pool.Exec(context.Background(), query); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return &Jobs{pool: p.Pool}, nil
+}
+
+func (s *Jobs) Enqueue(record storage.Job) (storage.Job, error) {
+	const op = "storage.job.Enqueue"
+
+	row := s.pool.QueryRow(context.Background(), `
+		INSERT INTO jobs(kind, payload, unique_key, status, attempts, max_attempts, run_at,
+			lease_owner, last_error, created_at, updated_at)
+		VALUES($1, $2, $3, $4, 0, $5, $6, '', '', $7, $7)
+		RETURNING `+jobColumns,
+		record.Kind,
+		record.Payload,
+		record.UniqueKey,
+		record.Status,
+		record.MaxAttempts,
+		record.RunAt,
+		record.CreatedAt,
+	)
+
+	job, err := scanJob(row)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrExists)
+		}
+
+		return storage.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// Lease takes due queued jobs and running jobs whose lease has expired,
+// the oldest first. Every lease counts as an attempt: a job whose last attempt
+// ran out its lease fails instead of being taken again.
+func (s *Jobs) Lease(lease repository.Lease) ([]storage.Job, error) {
+	const op = "storage.job.Lease"
+
+	if len(lease.Kinds) == 0 || lease.Limit <= 0 {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	_, err = tx.Exec(ctx, `
+		UPDATE jobs SET
+			status = 'failed',
+			leased_until = NULL,
+			last_error = $3,
+			updated_at = $1,
+			finished_at = $1
+		WHERE kind = ANY($2)
+			AND status = 'running' AND leased_until < $1 AND attempts >= max_attempts
+		`,
+		lease.Now,
+		lease.Kinds,
+		repository.LeaseExpired,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH due AS (
+			SELECT id AS due_id FROM jobs
+			WHERE kind = ANY($4)
+				AND ((status = 'queued' AND run_at <= $3)
+					OR (status = 'running' AND leased_until < $3 AND attempts < max_attempts))
+			ORDER BY run_at, id
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED)
+		UPDATE jobs SET
+			status = 'running',
+			attempts = attempts + 1,
+			lease_owner = $1,
+			leased_until = $2,
+			updated_at = $3
+		FROM due WHERE id = due_id
+		RETURNING `+jobColumns,
+		lease.Owner,
+		lease.Until,
+		lease.Now,
+		lease.Kinds,
+		lease.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jobs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.Job, error) {
+		return scanJob(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// RETURNING does not follow the ORDER BY of the CTE
+	slices.SortFunc(jobs, func(a, b storage.Job) int {
+		return cmp.Or(a.RunAt.Compare(b.RunAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return jobs, nil
+}
+
+func (s *Jobs) ExtendLease(id int64, owner string, until time.Time) error {
+	const op = "storage.job.ExtendLease"
+
+	tag, err := s.pool.Exec(context.Background(), `
+		UPDATE jobs SET leased_until = $3
+		WHERE id = $1 AND status = 'running' AND lease_owner = $2
+		`,
+		id,
+		owner,
+		until,
+	)
+
+	return leaseResult(op, tag, err)
+}
+
+func (s *Jobs) Complete(id int64, owner string, at time.Time) error {
+	const op = "storage.job.Complete"
+
+	tag, err := s.pool.Exec(context.Background(), `
+		UPDATE jobs SET
+			status = 'succeeded',
+			leased_until = NULL,
+			last_error = '',
+			updated_at = $3,
+			finished_at = $3
+		WHERE id = $1 AND status = 'running' AND lease_owner = $2
+		`,
+		id,
+		owner,
+		at,
+	)
+
+	return leaseResult(op, tag, err)
+}
+
+// Release queues a running job again at once and gives its attempt back.
+func (s *Jobs) Release(id int64, owner string, at time.Time) error {
+	const op = "storage.job.Release"
+
+	tag, err := s.pool.Exec(context.Background(), `
+		UPDATE jobs SET
+			status = 'queued',
+			attempts = GREATEST(attempts - 1, 0),
+			run_at = $3,
+			leased_until = NULL,
+			updated_at = $3
+		WHERE id = $1 AND status = 'running' AND lease_owner = $2
+		`,
+		id,
+		owner,
+		at,
+	)
+
+	return leaseResult(op, tag, err)
+}
+
+// Fail records the error of the attempt. The job is queued again at retryAt,
+// or fails for good when retryAt is zero.
+func (s *Jobs) Fail(id int64, owner, message string, at, retryAt time.Time) error {
+	const op = "storage.job.Fail"
+
+	status, runAt, finishedAt := "failed", (*time.Time)(nil), &at
+	if !retryAt.IsZero() {
+		status, runAt, finishedAt = "queued", &retryAt, nil
+	}
+
+	tag, err := s.pool.Exec(context.Background(), `
+		UPDATE jobs SET
+			status = $3,
+			run_at = COALESCE($4, run_at),
+			leased_until = NULL,
+			last_error = $5,
+			updated_at = $6,
+			finished_at = $7
+		WHERE id = $1 AND status = 'running' AND lease_owner = $2
+		`,
+		id,
+		owner,
+		status,
+		runAt,
+		message,
+		at,
+		finishedAt,
+	)
+
+	return leaseResult(op, tag, err)
+}
+
+func (s *Jobs) Get(id int64) (storage.Job, error) {
+	const op = "storage.job.Get"
+
+	job, err := scanJob(s.pool.QueryRow(context.Background(), `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// List returns jobs newest first.
+func (s *Jobs) List(filter repository.JobFilter) ([]storage.Job, error) {
+	const op = "storage.job.List"
+
+	var limit *int
+	if filter.Limit > 0 {
+		limit = &filter.Limit
+	}
+
+	rows, err := s.pool.Query(context.Background(), `
+		SELECT `+jobColumns+` FROM jobs
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR kind = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+		`,
+		filter.Status,
+		filter.Kind,
+		limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jobs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.Job, error) {
+		return scanJob(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+// Retry queues a failed job again with a fresh set of attempts.
+func (s *Jobs) Retry(id int64, at time.Time) (storage.Job, error) {
+	const op = "storage.job.Retry"
+
+	row := s.pool.QueryRow(context.Background(), `
+		UPDATE jobs SET
+			status = 'queued',
+			attempts = 0,
+			run_at = $2,
+			lease_owner = '',
+			updated_at = $2,
+			finished_at = NULL
+		WHERE id = $1 AND status = 'failed'
+		RETURNING `+jobColumns,
+		id,
+		at,
+	)
+
+	job, err := scanJob(row)
+	if err == nil {
+		return job, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return storage.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.Get(id); err != nil {
+		return storage.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrConflict)
+}
+
+// Purge deletes succeeded and failed jobs finished before the given time.
+func (s *Jobs) Purge(before time.Time) (int, error) {
+	const op = "storage.job.Purge"
+
+	tag, err := s.pool.Exec(context.Background(), `
+		DELETE FROM jobs
+		WHERE status IN ('succeeded', 'failed') AND finished_at < $1
+		`, before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// OldestDue returns the run time of the oldest queued job of the kinds due at now, zero without due jobs.
+func (s *Jobs) OldestDue(kinds []string, now time.Time) (time.Time, error) {
+	const op = "storage.job.OldestDue"
+
+	if len(kinds) == 0 {
+		return time.Time{}, nil
+	}
+
+	var runAt time.Time
+	err := s.pool.QueryRow(context.Background(), `
+		SELECT run_at FROM jobs
+		WHERE status = 'queued' AND run_at <= $1 AND kind = ANY($2)
+		ORDER BY run_at
+		LIMIT 1
+		`, now, kinds).Scan(&runAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return runAt, nil
+}
+
+// leaseResult reports ErrLeaseLost when an update guarded by the lease owner changed nothing.
+func leaseResult(op string, tag pgconn.CommandTag, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrLeaseLost)
+	}
+
+	return nil
+}
+
+func scanJob(row pgx.Row) (storage.Job, error) {
+	var (
+		job                     storage.Job
+		leasedUntil, finishedAt *time.Time
+	)
+
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Payload,
+		&job.UniqueKey,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.LeaseOwner,
+		&leasedUntil,
+		&job.LastError,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return storage.Job{}, err
+	}
+
+	if leasedUntil != nil {
+		job.LeasedUntil = *leasedUntil
+	}
+
+	if finishedAt != nil {
+		job.FinishedAt = *finishedAt
+	}
+
+	return job, nil
+}
@@ -0,0 +1,104 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+func (s *Pgsql) SaveMetadata(ctx context.Context, metadata storage.Metadata) error {
+	const op = "storage.bookmark.SaveMetadata"
+
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO bookmark_metadata(
+			uuid, title, description, canonical_url, favicon, image, status, error, attempts, fetched_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		WHERE EXISTS (SELECT 1 FROM bookmark WHERE uuid = $1)
+		ON CONFLICT(uuid) DO UPDATE SET
+			title = excluded.title,
+			description = excluded.description,
+			canonical_url = excluded.canonical_url,
+			favicon = excluded.favicon,
+			image = excluded.image,
+			status = excluded.status,
+			error = excluded.error,
+			attempts = excluded.attempts,
+			fetched_at = excluded.fetched_at
+		`,
+		metadata.Uuid,
+		metadata.Title,
+		metadata.Description,
+		metadata.CanonicalURL,
+		metadata.Favicon,
+		metadata.Image,
+		metadata.Status,
+		metadata.Error,
+		metadata.Attempts,
+		metadata.FetchedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ListMetadata returns the metadata fetched for the given bookmarks, bookmarks without it are skipped.
+func (s *Pgsql) ListMetadata(ctx context.Context, uuids []uuid.UUID) ([]storage.Metadata, error) {
+	const op = "storage.bookmark.ListMetadata"
+
+	if len(uuids) == 0 {
+		return []storage.Metadata{}, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT uuid, title, description, canonical_url, favicon, image, status, error, attempts, fetched_at
+		FROM bookmark_metadata WHERE uuid = ANY($1)`,
+		uuidStrings(uuids),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	records, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.Metadata, error) {
+		var metadata storage.Metadata
+
+		err := row.Scan(
+			&metadata.Uuid,
+			&metadata.Title,
+			&metadata.Description,
+			&metadata.CanonicalURL,
+			&metadata.Favicon,
+			&metadata.Image,
+			&metadata.Status,
+			&metadata.Error,
+			&metadata.Attempts,
+			&metadata.FetchedAt,
+		)
+
+		return metadata, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+func uuidStrings(uuids []uuid.UUID) []string {
+	ids := make([]string, 0, len(uuids))
+	for _, id := range uuids {
+		ids = append(ids, id.String())
+	}
+
+	return ids
+}
@@ -0,0 +1,231 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// Changes returns up to limit bookmarks and up to limit tombstones written after since,
+// read in one snapshot along with the version of the last write.
+func (s *Pgsql) Changes(ctx context.Context, since int64, limit int) (storage.ChangeSet, error) {
+	const op = "storage.bookmark.Changes"
+
+	// writers hold the sequence row until commit, a snapshot never sees a version without its row
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	rows, err := tx.Query(ctx, selectQuery+" WHERE version > $1 ORDER BY version LIMIT $2", since, limit)
+	if err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bookmarks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.Bookmark, error) {
+		return scanBookmark(row)
+	})
+	if err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT uuid, version, deleted_at FROM bookmark_tombstone
+		WHERE version > $1
+		ORDER BY version
+		LIMIT $2
+		`, since, limit)
+	if err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tombstones, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.Tombstone, error) {
+		var tombstone storage.Tombstone
+		err := row.Scan(&tombstone.Uuid, &tombstone.Version, &tombstone.DeletedAt)
+
+		return tombstone, err
+	})
+	if err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	set := storage.ChangeSet{
+		Bookmarks:  bookmarks,
+		Tombstones: tombstones,
+	}
+
+	if err := tx.QueryRow(ctx, `SELECT value FROM change_sequence WHERE id = 1`).Scan(&set.Version); err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return set, nil
+}
+
+// Update replaces the row if it is still at version, emit is called with the updated row.
+// A row changed since is returned with ErrConflict, the owner of a taken value with ErrExists.
+func (s *Pgsql) Update(ctx context.Context, record storage.Bookmark, version int64, emit repository.EmitRecord) (storage.Bookmark, error) {
+	const op = "storage.bookmark.Update"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	record.Version, err = nextVersion(ctx, tx)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// a savepoint keeps the transaction usable to read the owner after a unique violation
+	nested, err := tx.Begin(ctx)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := scanBookmark(nested.QueryRow(ctx, `
+		UPDATE bookmark
+		SET title = $1, title_auto = $2, value = $3, kind = $4, canonical_value = NULLIF($5, ''), version = $6
+		WHERE uuid = $7 AND version = $8
+		RETURNING `+bookmarkColumns,
+		record.Title,
+		record.TitleAuto,
+		record.Value,
+		record.Kind,
+		record.CanonicalValue,
+		record.Version,
+		record.Uuid,
+		version,
+	))
+	if err != nil {
+		_ = nested.Rollback(ctx)
+	} else if err := nested.Commit(ctx); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch {
+	case isUniqueViolation(err):
+		owner, err := scanBookmark(tx.QueryRow(ctx, selectQuery+" WHERE canonical_value = $1", record.CanonicalValue))
+		if err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return owner, fmt.Errorf("%s: %w", op, repository.ErrExists)
+	case errors.Is(err, repository.ErrNotFound):
+		current, err := findBookmark(ctx, tx, record.Uuid)
+		if err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return current, fmt.Errorf("%s: %w", op, repository.ErrConflict)
+	case err != nil:
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertEvents(ctx, tx, emit, repository.ChangeUpdated, updated); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// Delete removes the bookmark, its metadata, health and archive cascade, and leaves a tombstone,
+// emit is called with the deleted row. A positive version must match the row, ErrConflict otherwise.
+func (s *Pgsql) Delete(ctx context.Context, uuid uuid.UUID, version int64, emit repository.EmitRecord) error {
+	const op = "storage.bookmark.Delete"
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	deleted, err := nextVersion(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	record, err := scanBookmark(tx.QueryRow(ctx,
+		`DELETE FROM bookmark WHERE uuid = $1 AND ($2::BIGINT = 0 OR version = $2) RETURNING `+bookmarkColumns,
+		uuid.String(),
+		version,
+	))
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := findBookmark(ctx, tx, uuid.String()); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, repository.ErrConflict)
+	}
+
+	record.Version = deleted
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO bookmark_tombstone(uuid, version, deleted_at) VALUES($1, $2, $3)`,
+		record.Uuid,
+		record.Version,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertEvents(ctx, tx, emit, repository.ChangeDeleted, record); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// nextVersion takes the next number of the change sequence. The sequence row stays locked
+// until commit, so versions are committed in order.
+func nextVersion(ctx context.Context, tx pgx.Tx) (int64, error) {
+	var version int64
+	if err := tx.QueryRow(ctx, `UPDATE change_sequence SET value = value + 1 WHERE id = 1 RETURNING value`).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// findBookmark returns the row of uuid, ErrDeleted when it left a tombstone.
+func findBookmark(ctx context.Context, tx pgx.Tx, uuid string) (storage.Bookmark, error) {
+	record, err := scanBookmark(tx.QueryRow(ctx, selectQuery+" WHERE uuid = $1", uuid))
+	if !errors.Is(err, repository.ErrNotFound) {
+		return record, err
+	}
+
+	var deleted bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM bookmark_tombstone WHERE uuid = $1)`, uuid).Scan(&deleted); err != nil {
+		return storage.Bookmark{}, err
+	}
+
+	if deleted {
+		return storage.Bookmark{}, repository.ErrDeleted
+	}
+
+	return storage.Bookmark{}, repository.ErrNotFound
+}
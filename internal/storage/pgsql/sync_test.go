@@ -0,0 +1,192 @@
+package pgsql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/pkg/postgres/postgrestest"
+)
+
+func newBookmarkStorage(t *testing.T) *Pgsql {
+	t.Helper()
+
+	s, err := NewBookmark(postgrestest.New(t))
+	require.NoError(t, err)
+
+	return s
+}
+
+func makeRecord(value string) storage.Bookmark {
+	return storage.Bookmark{
+		Uuid:           uuid.NewString(),
+		Title:          value,
+		Value:          value,
+		Kind:           "url",
+		CanonicalValue: value,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+func create(t *testing.T, s *Pgsql, value string) storage.Bookmark {
+	t.Helper()
+
+	record, err := s.Create(t.Context(), makeRecord(value), repository.OnConflictError, nil)
+	require.NoError(t, err)
+
+	return record
+}
+
+func TestChanges(t *testing.T) {
+	s := newBookmarkStorage(t)
+
+	first := create(t, s, "https://example.com/1")
+	second := create(t, s, "https://example.com/2")
+	third := create(t, s, "https://example.com/3")
+	require.Less(t, first.Version, second.Version)
+	require.Less(t, second.Version, third.Version)
+
+	require.NoError(t, s.Delete(t.Context(), uuid.MustParse(first.Uuid), first.Version, nil))
+
+	set, err := s.Changes(t.Context(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, set.Bookmarks, 2)
+	require.Equal(t, second.Uuid, set.Bookmarks[0].Uuid)
+	require.Equal(t, third.Uuid, set.Bookmarks[1].Uuid)
+	require.Len(t, set.Tombstones, 1)
+	require.Equal(t, first.Uuid, set.Tombstones[0].Uuid)
+	require.Greater(t, set.Tombstones[0].Version, third.Version)
+	require.Equal(t, set.Tombstones[0].Version, set.Version)
+
+	// each list is cut at the limit, the version is the last write of the storage
+	page, err := s.Changes(t.Context(), 0, 1)
+	require.NoError(t, err)
+	require.Len(t, page.Bookmarks, 1)
+	require.Equal(t, second.Uuid, page.Bookmarks[0].Uuid)
+	require.Len(t, page.Tombstones, 1)
+	require.Equal(t, set.Version, page.Version)
+
+	page, err = s.Changes(t.Context(), second.Version, 1)
+	require.NoError(t, err)
+	require.Len(t, page.Bookmarks, 1)
+	require.Equal(t, third.Uuid, page.Bookmarks[0].Uuid)
+
+	page, err = s.Changes(t.Context(), set.Version, 10)
+	require.NoError(t, err)
+	require.Empty(t, page.Bookmarks)
+	require.Empty(t, page.Tombstones)
+	require.Equal(t, set.Version, page.Version)
+
+	// a bulk insert takes versions too, one per record
+	records := []storage.Bookmark{makeRecord("https://example.com/4"), makeRecord("https://example.com/5")}
+	inserted, err := s.CreateMany(t.Context(), records)
+	require.NoError(t, err)
+	require.Equal(t, 2, inserted)
+
+	page, err = s.Changes(t.Context(), set.Version, 10)
+	require.NoError(t, err)
+	require.Len(t, page.Bookmarks, 2)
+	require.Greater(t, page.Bookmarks[0].Version, set.Version)
+	require.Equal(t, page.Bookmarks[1].Version, page.Version)
+}
+
+func TestUpdate_Version(t *testing.T) {
+	s := newBookmarkStorage(t)
+
+	stored := create(t, s, "https://example.com/update")
+
+	changed := stored
+	changed.Title = "changed"
+
+	updated, err := s.Update(t.Context(), changed, stored.Version, nil)
+	require.NoError(t, err)
+	require.Equal(t, "changed", updated.Title)
+	require.Greater(t, updated.Version, stored.Version)
+
+	// a stale version returns the current row
+	changed.Title = "stale"
+	current, err := s.Update(t.Context(), changed, stored.Version, nil)
+	require.ErrorIs(t, err, repository.ErrConflict)
+	require.Equal(t, "changed", current.Title)
+	require.Equal(t, updated.Version, current.Version)
+
+	// a taken value returns its owner and leaves the row as it is
+	other := create(t, s, "https://example.com/other")
+
+	changed.Value = other.Value
+	changed.CanonicalValue = other.CanonicalValue
+	owner, err := s.Update(t.Context(), changed, updated.Version, nil)
+	require.ErrorIs(t, err, repository.ErrExists)
+	require.Equal(t, other.Uuid, owner.Uuid)
+
+	unchanged, err := s.GetByUUID(t.Context(), uuid.MustParse(stored.Uuid))
+	require.NoError(t, err)
+	require.Equal(t, updated.Version, unchanged.Version)
+	require.Equal(t, stored.Value, unchanged.Value)
+
+	_, err = s.Update(t.Context(), makeRecord("https://example.com/none"), 1, nil)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+	require.NotErrorIs(t, err, repository.ErrDeleted)
+}
+
+func TestDelete_Version(t *testing.T) {
+	s := newBookmarkStorage(t)
+
+	stored := create(t, s, "https://example.com/delete")
+	id := uuid.MustParse(stored.Uuid)
+
+	changed := stored
+	changed.Title = "changed"
+
+	updated, err := s.Update(t.Context(), changed, stored.Version, nil)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, s.Delete(t.Context(), id, stored.Version, nil), repository.ErrConflict)
+
+	_, err = s.GetByUUID(t.Context(), id)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Delete(t.Context(), id, updated.Version, nil))
+	require.ErrorIs(t, s.Delete(t.Context(), id, 0, nil), repository.ErrDeleted)
+	require.ErrorIs(t, s.Delete(t.Context(), uuid.New(), 0, nil), repository.ErrNotFound)
+
+	_, err = s.GetByUUID(t.Context(), id)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = s.Update(t.Context(), changed, updated.Version, nil)
+	require.ErrorIs(t, err, repository.ErrDeleted)
+}
+
+func TestTombstone_Reuse(t *testing.T) {
+	s := newBookmarkStorage(t)
+
+	stored := create(t, s, "https://example.com/tombstone")
+	require.NoError(t, s.Delete(t.Context(), uuid.MustParse(stored.Uuid), 0, nil))
+
+	// the uuid of a deleted bookmark is not taken again
+	again := makeRecord("https://example.com/again")
+	again.Uuid = stored.Uuid
+
+	_, err := s.Create(t.Context(), again, repository.OnConflictError, nil)
+	require.ErrorIs(t, err, repository.ErrDeleted)
+
+	inserted, err := s.CreateMany(t.Context(), []storage.Bookmark{again})
+	require.NoError(t, err)
+	require.Zero(t, inserted)
+
+	// its value is free for a new bookmark
+	reused := create(t, s, stored.Value)
+	require.NotEqual(t, stored.Uuid, reused.Uuid)
+
+	set, err := s.Changes(t.Context(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, set.Bookmarks, 1)
+	require.Equal(t, reused.Uuid, set.Bookmarks[0].Uuid)
+	require.Len(t, set.Tombstones, 1)
+	require.Equal(t, stored.Uuid, set.Tombstones[0].Uuid)
+	require.Less(t, set.Tombstones[0].Version, reused.Version)
+}
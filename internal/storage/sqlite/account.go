@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/pkg/sqlite"
+)
+
+// Accounts keeps the users and their API tokens.
+type Accounts struct {
+	db *sql.DB
+}
+
+func NewAccount(sqlite *sqlite.Sqlite) (*Accounts, error) {
+	const op = "storage.sqlite.NewAccount"
+
+	err := migrate(sqlite)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Accounts{db: sqlite.DB}, nil
+}
+
+// CreateUser inserts the user, a taken name is ErrExists.
+func (s *Accounts) CreateUser(record storage.User) error {
+	const op = "storage.account.CreateUser"
+
+	_, err := s.db.Exec(`INSERT INTO account_user(id, name, admin, created_at) VALUES(?, ?, ?, ?)`,
+		record.ID,
+		record.Name,
+		record.Admin,
+		record.CreatedAt.UTC(),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, repository.ErrExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Accounts) GetUser(name string) (storage.User, error) {
+	const op = "storage.account.GetUser"
+
+	var record storage.User
+
+	err := s.db.QueryRow(`SELECT id, name, admin, created_at FROM account_user WHERE name = ?`, name).
+		Scan(&record.ID, &record.Name, &record.Admin, &record.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.User{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return record, nil
+}
+
+// CreateToken inserts the token, the user must exist.
+func (s *Accounts) CreateToken(record storage.Token) error {
+	const op = "storage.account.CreateToken"
+
+	res, err := s.db.Exec(`
+		INSERT INTO api_token(id, user_id, name, hash, created_at, expires_at)
+		SELECT ?1, id, ?3, ?4, ?5, ?6 FROM account_user WHERE id = ?2
+		`,
+		record.ID,
+		record.UserID,
+		record.Name,
+		record.Hash,
+		record.CreatedAt.UTC(),
+		nullTime(record.ExpiresAt),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, repository.ErrExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+// FindToken returns the token of the hash with its user.
+func (s *Accounts) FindToken(hash string) (storage.Token, storage.User, error) {
+	const op = "storage.account.FindToken"
+
+	var (
+		token     storage.Token
+		user      storage.User
+		expiresAt sql.NullTime
+	)
+
+	err := s.db.QueryRow(`
+		SELECT t.id, t.user_id, t.name, t.hash, t.created_at, t.expires_at, u.id, u.name, u.admin, u.created_at
+		FROM api_token t JOIN account_user u ON u.id = t.user_id
+		WHERE t.hash = ?
+		`, hash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.Hash,
+		&token.CreatedAt,
+		&expiresAt,
+		&user.ID,
+		&user.Name,
+		&user.Admin,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Token{}, storage.User{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.Token{}, storage.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if expiresAt.Valid {
+		token.ExpiresAt = expiresAt.Time
+	}
+
+	return token, user, nil
+}
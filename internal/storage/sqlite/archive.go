@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// SaveArchive replaces the archive of the bookmark,
+// the blob of a replaced archive stays in the blob store.
+func (s *Sqlite) SaveArchive(ctx context.Context, archive storage.Archive) error {
+	const op = "storage.bookmark.SaveArchive"
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO bookmark_archive(uuid, digest, size, resources, source_url, archived_at)
+		SELECT ?1, ?2, ?3, ?4, ?5, ?6
+		WHERE EXISTS (SELECT 1 FROM bookmark WHERE uuid = ?1)
+		ON CONFLICT(uuid) DO UPDATE SET
+			digest = excluded.digest,
+			size = excluded.size,
+			resources = excluded.resources,
+			source_url = excluded.source_url,
+			archived_at = excluded.archived_at
+		`,
+		archive.Uuid,
+		archive.Digest,
+		archive.Size,
+		archive.Resources,
+		archive.SourceURL,
+		archive.ArchivedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Sqlite) GetArchive(ctx context.Context, uuid uuid.UUID) (storage.Archive, error) {
+	const op = "storage.bookmark.GetArchive"
+
+	var archive storage.Archive
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT uuid, digest, size, resources, source_url, archived_at
+		FROM bookmark_archive WHERE uuid = ?
+		`, uuid.String()).Scan(
+		&archive.Uuid,
+		&archive.Digest,
+		&archive.Size,
+		&archive.Resources,
+		&archive.SourceURL,
+		&archive.ArchivedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Archive{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.Archive{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return archive, nil
+}
@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -14,6 +15,11 @@ import (
 	"bookmarks/pkg/sqlite"
 )
 
+const (
+	bookmarkColumns = "uuid, title, title_auto, value, kind, canonical_value, created_at, version"
+	selectQuery     = "SELECT " + bookmarkColumns + " FROM bookmark"
+)
+
 type Sqlite struct {
 	db *sql.DB
 }
@@ -21,7 +27,7 @@ type Sqlite struct {
 func NewBookmark(sqlite *sqlite.Sqlite) (*Sqlite, error) {
 	const op = "storage.sqlite.New"
 
-	err := sqlite.Migrate()
+	err := migrate(sqlite)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -29,77 +35,248 @@ func NewBookmark(sqlite *sqlite.Sqlite) (*Sqlite, error) {
 	return &Sqlite{db: sqlite.DB}, nil
 }
 
-func (s *Sqlite) Create(
-	uuid uuid.UUID,
-	title, val string,
-	time time.Time,
-) (storage.Bookmark, error) {
+// OpenBookmark serves the bookmarks of a database without migrating it, its schema must be
+// the current one: ErrSchemaOutdated for an older schema, ErrSchemaNewer for a newer one.
+func OpenBookmark(sqlite *sqlite.Sqlite) (*Sqlite, error) {
+	const op = "storage.sqlite.OpenBookmark"
+
+	version, err := CheckSchema(sqlite)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if version > SchemaVersion() {
+		return nil, fmt.Errorf("%s: %w: version %d of %d", op, ErrSchemaNewer, version, SchemaVersion())
+	}
+
+	return &Sqlite{db: sqlite.DB}, nil
+}
+
+// Create inserts the record, emit is called when a row is inserted or its title updated on conflict.
+// The uuid of a deleted bookmark is not taken again, ErrDeleted is returned for it.
+func (s *Sqlite) Create(ctx context.Context, record storage.Bookmark, mode repository.OnConflict, emit repository.EmitRecord) (storage.Bookmark, error) {
 	const op = "storage.bookmark.Create"
 
-	tx, err := s.db.Begin()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	stmt, err := s.db.Prepare(`
-		INSERT INTO bookmark(uuid, title, value, created_at)
-		VALUES(?, ?, ?, ?)
-		`)
+	defer tx.Rollback() //nolint:errcheck
+
+	record.Version, err = nextVersion(ctx, tx)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := checkTombstone(ctx, tx, record.Uuid); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, insertQuery(mode))
 	if err != nil {
 		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	defer stmt.Close()
 
-	_, err = stmt.Exec(uuid.String(), title, val, time)
+	res, err := stmt.ExecContext(ctx,
+		record.Uuid,
+		record.Title,
+		record.TitleAuto,
+		record.Value,
+		record.Kind,
+		record.CanonicalValue,
+		record.CreatedAt,
+		record.Version,
+	)
 	if err != nil {
-		var sqliteErr sqlite3.Error
-		if errors.As(err, &sqliteErr) {
+		if isUniqueViolation(err) {
 			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, repository.ErrExists)
 		}
 
 		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	inserted := record.Uuid
+
+	// the value is taken: read the surviving row inside the same transaction,
+	// a row without a canonical value never collides
+	if record.CanonicalValue != "" && (rowAffected == 0 || mode == repository.OnConflictUpdateTitle) {
+		record, err = scanBookmark(tx.QueryRowContext(ctx, selectQuery+" WHERE canonical_value = ?", record.CanonicalValue))
+		if err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if rowAffected > 0 {
+		change := repository.ChangeCreated
+		if record.Uuid != inserted {
+			change = repository.ChangeUpdated
+		}
+
+		if err := insertEvents(ctx, tx, emit, change, record); err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return storage.Bookmark{
-		Uuid:      uuid.String(),
-		Title:     title,
-		Value:     val,
-		CreatedAt: time,
-	}, nil
+	if rowAffected == 0 && mode == repository.OnConflictError {
+		return record, fmt.Errorf("%s: %w", op, repository.ErrExists)
+	}
+
+	return record, nil
 }
 
-func (s *Sqlite) Update(
-	uuid uuid.UUID,
-	title string,
-) (storage.Bookmark, error) {
-	return storage.Bookmark{}, nil
+// CreateMany inserts the records in one transaction, skipping those whose uuid or value
+// is taken or deleted, and returns the number inserted. No outbox records are written.
+func (s *Sqlite) CreateMany(ctx context.Context, records []storage.Bookmark) (int, error) {
+	const op = "storage.bookmark.CreateMany"
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	// the records take the versions up to last, skipped ones leave gaps
+	var last int64
+	if err := tx.QueryRowContext(ctx, `UPDATE change_sequence SET value = value + ? RETURNING value`, len(records)).Scan(&last); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO bookmark(uuid, title, title_auto, value, kind, canonical_value, created_at, version)
+		SELECT ?1, ?2, ?3, ?4, ?5, NULLIF(?6, ''), ?7, ?8
+		WHERE NOT EXISTS (SELECT 1 FROM bookmark_tombstone WHERE uuid = ?1)
+		ON CONFLICT DO NOTHING
+		`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	inserted := 0
+
+	for i, record := range records {
+		res, err := stmt.ExecContext(ctx,
+			record.Uuid,
+			record.Title,
+			record.TitleAuto,
+			record.Value,
+			record.Kind,
+			record.CanonicalValue,
+			record.CreatedAt,
+			last-int64(len(records)-1-i),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		rowAffected, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		inserted += int(rowAffected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inserted, nil
+}
+
+// Update replaces the row if it is still at version, emit is called with the updated row.
+// A row changed since is returned with ErrConflict, the owner of a taken value with ErrExists.
+func (s *Sqlite) Update(ctx context.Context, record storage.Bookmark, version int64, emit repository.EmitRecord) (storage.Bookmark, error) {
+	const op = "storage.bookmark.Update"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	record.Version, err = nextVersion(ctx, tx)
+	if err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := scanBookmark(tx.QueryRowContext(ctx, `
+		UPDATE bookmark
+		SET title = ?, title_auto = ?, value = ?, kind = ?, canonical_value = NULLIF(?, ''), version = ?
+		WHERE uuid = ? AND version = ?
+		RETURNING `+bookmarkColumns,
+		record.Title,
+		record.TitleAuto,
+		record.Value,
+		record.Kind,
+		record.CanonicalValue,
+		record.Version,
+		record.Uuid,
+		version,
+	))
+
+	switch {
+	case isUniqueViolation(err):
+		owner, err := scanBookmark(tx.QueryRowContext(ctx, selectQuery+" WHERE canonical_value = ?", record.CanonicalValue))
+		if err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return owner, fmt.Errorf("%s: %w", op, repository.ErrExists)
+	case errors.Is(err, repository.ErrNotFound):
+		current, err := findBookmark(ctx, tx, record.Uuid)
+		if err != nil {
+			return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return current, fmt.Errorf("%s: %w", op, repository.ErrConflict)
+	case err != nil:
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertEvents(ctx, tx, emit, repository.ChangeUpdated, updated); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.Bookmark{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
 }
 
-func (s *Sqlite) GetByUUID(uuid uuid.UUID) (storage.Bookmark, error) {
+func (s *Sqlite) GetByUUID(ctx context.Context, uuid uuid.UUID) (storage.Bookmark, error) {
 	const op = "storage.bookmark.GetByUUID"
 
-	stmt, err := s.db.Prepare("SELECT uuid, title, value, created_at FROM bookmark WHERE uuid = ?")
+	stmt, err := s.db.PrepareContext(ctx, selectQuery+" WHERE uuid = ?")
 	if err != nil {
 		return storage.Bookmark{}, fmt.Errorf("%s: prepare statement: %w", op, err)
 	}
 
 	defer stmt.Close()
 
-	var record storage.Bookmark
-
-	err = stmt.QueryRow(uuid).Scan(
-		&record.Uuid,
-		&record.Title,
-		&record.Value,
-		&record.CreatedAt,
-	)
+	record, err := scanBookmark(stmt.QueryRowContext(ctx, uuid.String()))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, repository.ErrNotFound) {
 			return storage.Bookmark{}, repository.ErrNotFound
 		}
 
@@ -109,26 +286,19 @@ func (s *Sqlite) GetByUUID(uuid uuid.UUID) (storage.Bookmark, error) {
 	return record, nil
 }
 
-func (s *Sqlite) GetByValue(val string) (storage.Bookmark, error) {
+func (s *Sqlite) GetByValue(ctx context.Context, val string) (storage.Bookmark, error) {
 	const op = "storage.bookmark.GetByValue"
 
-	stmt, err := s.db.Prepare("SELECT uuid, title, value, created_at FROM bookmark WHERE value = ?")
+	stmt, err := s.db.PrepareContext(ctx, selectQuery+" WHERE value = ?")
 	if err != nil {
 		return storage.Bookmark{}, fmt.Errorf("%s: prepare statement: %w", op, err)
 	}
 
 	defer stmt.Close()
 
-	var record storage.Bookmark
-
-	err = stmt.QueryRow(val).Scan(
-		&record.Uuid,
-		&record.Title,
-		&record.Value,
-		&record.CreatedAt,
-	)
+	record, err := scanBookmark(stmt.QueryRowContext(ctx, val))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, repository.ErrNotFound) {
 			return storage.Bookmark{}, repository.ErrNotFound
 		}
 
@@ -138,33 +308,206 @@ func (s *Sqlite) GetByValue(val string) (storage.Bookmark, error) {
 	return record, nil
 }
 
-func (s *Sqlite) Delete(uuid uuid.UUID) error {
+// List returns bookmarks newest first.
+func (s *Sqlite) List(ctx context.Context, filter repository.Filter) ([]storage.Bookmark, error) {
+	const op = "storage.bookmark.List"
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		selectQuery+`
+		WHERE (?1 = '' OR kind = ?1)
+		AND (?4 = '' OR uuid IN (SELECT uuid FROM link_health WHERE status = ?4))
+		ORDER BY created_at DESC, uuid DESC
+		LIMIT ?2 OFFSET ?3`,
+		filter.Kind,
+		limit,
+		filter.Offset,
+		filter.Health,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	records := make([]storage.Bookmark, 0)
+	for rows.Next() {
+		record, err := scanBookmark(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// Delete removes the bookmark with everything attached to it and leaves a tombstone,
+// emit is called with the deleted row. A positive version must match the row, ErrConflict otherwise.
+func (s *Sqlite) Delete(ctx context.Context, uuid uuid.UUID, version int64, emit repository.EmitRecord) error {
 	const op = "storage.bookmark.Delete"
 
-	stmt, err := s.db.Prepare(`DELETE FROM bookmark WHERE uuid=?`)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	defer stmt.Close()
+	defer tx.Rollback() //nolint:errcheck
 
-	res, err := stmt.Exec(uuid.String())
+	deleted, err := nextVersion(ctx, tx)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return repository.ErrNotFound
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	record, err := scanBookmark(tx.QueryRowContext(ctx,
+		`DELETE FROM bookmark WHERE uuid = ?1 AND (?2 = 0 OR version = ?2) RETURNING `+bookmarkColumns,
+		uuid.String(),
+		version,
+	))
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := findBookmark(ctx, tx, uuid.String()); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", op, repository.ErrConflict)
+	}
+
+	record.Version = deleted
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO bookmark_tombstone(uuid, version, deleted_at) VALUES(?, ?, ?)`,
+		record.Uuid,
+		record.Version,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertEvents(ctx, tx, emit, repository.ChangeDeleted, record); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, table := range []string{"bookmark_metadata", "link_health", "link_check", "bookmark_archive"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE uuid=?`, uuid.String()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
 		}
+	}
 
+	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowAffected, err := res.RowsAffected()
+	return nil
+}
+
+// UpdateAutoTitle replaces the title unless the user has set it, emit is called with the updated row.
+func (s *Sqlite) UpdateAutoTitle(ctx context.Context, uuid uuid.UUID, title string, emit repository.EmitRecord) error {
+	const op = "storage.bookmark.UpdateAutoTitle"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	version, err := nextVersion(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	record, err := scanBookmark(tx.QueryRowContext(ctx,
+		`UPDATE bookmark SET title = ?, version = ? WHERE uuid = ? AND title_auto = 1 RETURNING `+bookmarkColumns,
+		title,
+		version,
+		uuid.String(),
+	))
 	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := findBookmark(ctx, tx, uuid.String()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	}
+
+	if err := insertEvents(ctx, tx, emit, repository.ChangeUpdated, record); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowAffected == 0 {
-		return repository.ErrNotFound
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
+
+// insertQuery stores an empty canonical value as NULL, such rows never collide.
+func insertQuery(mode repository.OnConflict) string {
+	const query = `
+		INSERT INTO bookmark(uuid, title, title_auto, value, kind, canonical_value, created_at, version)
+		VALUES(?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?)
+		ON CONFLICT(canonical_value) DO `
+
+	if mode == repository.OnConflictUpdateTitle {
+		return query + "UPDATE SET title = excluded.title, title_auto = excluded.title_auto, version = excluded.version"
+	}
+
+	return query + "NOTHING"
+}
+
+func scanBookmark(row interface{ Scan(dest ...any) error }) (storage.Bookmark, error) {
+	var (
+		record    storage.Bookmark
+		canonical sql.NullString // NULL for rows left aside by the backfill
+	)
+
+	err := row.Scan(
+		&record.Uuid,
+		&record.Title,
+		&record.TitleAuto,
+		&record.Value,
+		&record.Kind,
+		&canonical,
+		&record.CreatedAt,
+		&record.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Bookmark{}, repository.ErrNotFound
+		}
+
+		return storage.Bookmark{}, err
+	}
+
+	record.CanonicalValue = canonical.String
+
+	return record, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) ||
+		errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintPrimaryKey)
+}
@@ -0,0 +1,355 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// DueForCheck returns URL bookmarks never checked or last checked before the given time,
+// least recently checked first.
+func (s *Sqlite) DueForCheck(ctx context.Context, before time.Time, limit int) ([]storage.Bookmark, error) {
+	const op = "storage.bookmark.DueForCheck"
+
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.uuid, b.title, b.title_auto, b.value, b.kind, b.canonical_value, b.created_at, b.version
+		FROM bookmark b LEFT JOIN link_health h ON h.uuid = b.uuid
+		WHERE b.kind = 'url' AND (h.uuid IS NULL OR h.checked_at < ?1)
+		ORDER BY h.checked_at IS NOT NULL, h.checked_at, b.uuid
+		LIMIT ?2`,
+		before,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	records := make([]storage.Bookmark, 0)
+	for rows.Next() {
+		record, err := scanBookmark(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// SaveLinkCheck appends the check to the history, keeping the last keep entries,
+// and folds it into the current health of the link. The health is read after the first
+// write of the transaction, the write lock orders concurrent checks.
+func (s *Sqlite) SaveLinkCheck(ctx context.Context, check storage.LinkCheck, fold repository.FoldHealth, keep int) error {
+	const op = "storage.bookmark.SaveLinkCheck"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO link_check(uuid, ok, status_code, redirect_to, latency_ms, error, checked_at)
+		SELECT ?1, ?2, ?3, ?4, ?5, ?6, ?7
+		WHERE EXISTS (SELECT 1 FROM bookmark WHERE uuid = ?1)
+		`,
+		check.Uuid,
+		check.OK,
+		check.StatusCode,
+		check.RedirectTo,
+		check.Latency.Milliseconds(),
+		check.Error,
+		check.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	if keep > 0 {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM link_check WHERE uuid = ?1 AND id NOT IN (
+				SELECT id FROM link_check WHERE uuid = ?1 ORDER BY id DESC LIMIT ?2)
+			`, check.Uuid, keep)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var current *storage.LinkHealth
+
+	switch health, err := scanHealth(tx.QueryRowContext(ctx, selectHealthQuery+" WHERE uuid = ?", check.Uuid)); {
+	case err == nil:
+		current = &health
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	health := fold(current)
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO link_health(
+			uuid, status, status_code, redirect_to, latency_ms, error, consecutive_failures, checked_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(uuid) DO UPDATE SET
+			status = excluded.status,
+			status_code = excluded.status_code,
+			redirect_to = excluded.redirect_to,
+			latency_ms = excluded.latency_ms,
+			error = excluded.error,
+			consecutive_failures = excluded.consecutive_failures,
+			checked_at = excluded.checked_at
+		`,
+		health.Uuid,
+		health.Status,
+		health.StatusCode,
+		health.RedirectTo,
+		health.Latency.Milliseconds(),
+		health.Error,
+		health.ConsecutiveFailures,
+		health.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Sqlite) GetLinkHealth(ctx context.Context, uuid uuid.UUID) (storage.LinkHealth, error) {
+	const op = "storage.bookmark.GetLinkHealth"
+
+	health, err := scanHealth(s.db.QueryRowContext(ctx, selectHealthQuery+" WHERE uuid = ?", uuid.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.LinkHealth{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.LinkHealth{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return health, nil
+}
+
+const selectHealthQuery = `
+	SELECT uuid, status, status_code, redirect_to, latency_ms, error, consecutive_failures, checked_at
+	FROM link_health`
+
+func scanHealth(row *sql.Row) (storage.LinkHealth, error) {
+	var (
+		health  storage.LinkHealth
+		latency int64
+	)
+
+	err := row.Scan(
+		&health.Uuid,
+		&health.Status,
+		&health.StatusCode,
+		&health.RedirectTo,
+		&latency,
+		&health.Error,
+		&health.ConsecutiveFailures,
+		&health.CheckedAt,
+	)
+	if err != nil {
+		return storage.LinkHealth{}, err
+	}
+
+	health.Latency = time.Duration(latency) * time.Millisecond
+
+	return health, nil
+}
+
+// ListLinkChecks returns the check history of a link, newest first.
+func (s *Sqlite) ListLinkChecks(ctx context.Context, uuid uuid.UUID, limit int) ([]storage.LinkCheck, error) {
+	const op = "storage.bookmark.ListLinkChecks"
+
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT uuid, ok, status_code, redirect_to, latency_ms, error, checked_at
+		FROM link_check WHERE uuid = ?
+		ORDER BY id DESC
+		LIMIT ?
+		`, uuid.String(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	checks := make([]storage.LinkCheck, 0)
+	for rows.Next() {
+		var (
+			check   storage.LinkCheck
+			latency int64
+		)
+
+		err := rows.Scan(
+			&check.Uuid,
+			&check.OK,
+			&check.StatusCode,
+			&check.RedirectTo,
+			&latency,
+			&check.Error,
+			&check.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		check.Latency = time.Duration(latency) * time.Millisecond
+		checks = append(checks, check)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return checks, nil
+}
+
+// ListLinkHealth returns the current health of the given links, links never checked are skipped.
+func (s *Sqlite) ListLinkHealth(ctx context.Context, uuids []uuid.UUID) ([]storage.LinkHealth, error) {
+	const op = "storage.bookmark.ListLinkHealth"
+
+	if len(uuids) == 0 {
+		return []storage.LinkHealth{}, nil
+	}
+
+	params, args := uuidParams(uuids)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT uuid, status, status_code, redirect_to, latency_ms, error, consecutive_failures, checked_at
+		FROM link_health WHERE uuid IN (`+params+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	records := make([]storage.LinkHealth, 0, len(uuids))
+	for rows.Next() {
+		var (
+			health  storage.LinkHealth
+			latency int64
+		)
+
+		err := rows.Scan(
+			&health.Uuid,
+			&health.Status,
+			&health.StatusCode,
+			&health.RedirectTo,
+			&latency,
+			&health.Error,
+			&health.ConsecutiveFailures,
+			&health.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		health.Latency = time.Duration(latency) * time.Millisecond
+		records = append(records, health)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// ListRecentLinkChecks returns the last limit checks of each of the given links, newest first.
+func (s *Sqlite) ListRecentLinkChecks(ctx context.Context, uuids []uuid.UUID, limit int) ([]storage.LinkCheck, error) {
+	const op = "storage.bookmark.ListRecentLinkChecks"
+
+	if len(uuids) == 0 {
+		return []storage.LinkCheck{}, nil
+	}
+
+	params, args := uuidParams(uuids)
+	args = append(args, limit)
+	limitParam := fmt.Sprintf("?%d", len(args))
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT uuid, ok, status_code, redirect_to, latency_ms, error, checked_at
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY uuid ORDER BY id DESC) AS n
+			FROM link_check WHERE uuid IN (`+params+`)
+		)
+		WHERE `+limitParam+` <= 0 OR n <= `+limitParam+`
+		ORDER BY uuid, n`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	checks := make([]storage.LinkCheck, 0)
+	for rows.Next() {
+		var (
+			check   storage.LinkCheck
+			latency int64
+		)
+
+		err := rows.Scan(
+			&check.Uuid,
+			&check.OK,
+			&check.StatusCode,
+			&check.RedirectTo,
+			&latency,
+			&check.Error,
+			&check.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		check.Latency = time.Duration(latency) * time.Millisecond
+		checks = append(checks, check)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return checks, nil
+}
@@ -0,0 +1,436 @@
+package sqlite
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/pkg/sqlite"
+)
+
+const jobColumns = `id, kind, payload, unique_key, status, attempts, max_attempts, run_at,
+	lease_owner, leased_until, last_error, created_at, updated_at, finished_at`
+
+// Jobs keeps the background job queue in the jobs table.
+// Times are stored in UTC: DATETIME columns are compared as text.
+type Jobs struct {
+	db *sql.DB
+}
+
+func NewJob(sqlite *sqlite.Sqlite) (*Jobs, error) {
+	const op = "storage.sqlite.NewJob"
+
+	err := migrate(sqlite)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Jobs{db: sqlite.DB}, nil
+}
+
+func (s *Jobs) Enqueue(record storage.Job) (storage.Job, error) {
+	const op = "storage.job.Enqueue"
+
+	row := s.db.QueryRow(`
+		INSERT INTO jobs(kind, payload, unique_key, status, attempts, max_attempts, run_at,
+			lease_owner, last_error, created_at, updated_at)
+		VALUES(?, ?, ?, ?, 0, ?, ?, '', '', ?, ?)
+		RETURNING `+jobColumns,
+		record.Kind,
+		record.Payload,
+		record.UniqueKey,
+		record.Status,
+		record.MaxAttempts,
+		record.RunAt.UTC(),
+		record.CreatedAt.UTC(),
+		record.CreatedAt.UTC(),
+	)
+
+	job, err := scanJob(row)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrExists)
+		}
+
+		return storage.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// Lease takes due queued jobs and running jobs whose lease has expired,
+// the oldest first. Every lease counts as an attempt: a job whose last attempt
+// ran out its lease fails instead of being taken again.
+func (s *Jobs) Lease(lease repository.Lease) ([]storage.Job, error) {
+	const op = "storage.job.Lease"
+
+	if len(lease.Kinds) == 0 || lease.Limit <= 0 {
+		return nil, nil
+	}
+
+	args := []any{lease.Owner, lease.Until.UTC(), lease.Now.UTC(), lease.Limit}
+	params := make([]string, 0, len(lease.Kinds))
+	for _, kind := range lease.Kinds {
+		args = append(args, kind)
+		params = append(params, fmt.Sprintf("?%d", len(args)))
+	}
+
+	kinds := strings.Join(params, ", ")
+	message := fmt.Sprintf("?%d", len(args)+1)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.Exec(`
+		UPDATE jobs SET
+			status = 'failed',
+			leased_until = NULL,
+			last_error = `+message+`,
+			updated_at = ?3,
+			finished_at = ?3
+		WHERE kind IN (`+kinds+`)
+			AND status = 'running' AND leased_until < ?3 AND attempts >= max_attempts
+		`,
+		append(args, repository.LeaseExpired)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := tx.Query(`
+		UPDATE jobs SET
+			status = 'running',
+			attempts = attempts + 1,
+			lease_owner = ?1,
+			leased_until = ?2,
+			updated_at = ?3
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE kind IN (`+kinds+`)
+				AND ((status = 'queued' AND run_at <= ?3) OR (status = 'running' AND leased_until < ?3 AND attempts < max_attempts))
+			ORDER BY run_at, id
+			LIMIT ?4)
+		RETURNING `+jobColumns,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jobs, err := scanJobs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// RETURNING does not follow the ORDER BY of the subquery
+	slices.SortFunc(jobs, func(a, b storage.Job) int {
+		return cmp.Or(a.RunAt.Compare(b.RunAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return jobs, nil
+}
+
+func (s *Jobs) ExtendLease(id int64, owner string, until time.Time) error {
+	const op = "storage.job.ExtendLease"
+
+	res, err := s.db.Exec(`
+		UPDATE jobs SET leased_until = ?3
+		WHERE id = ?1 AND status = 'running' AND lease_owner = ?2
+		`,
+		id,
+		owner,
+		until.UTC(),
+	)
+
+	return leaseResult(op, res, err)
+}
+
+func (s *Jobs) Complete(id int64, owner string, at time.Time) error {
+	const op = "storage.job.Complete"
+
+	res, err := s.db.Exec(`
+		UPDATE jobs SET
+			status = 'succeeded',
+			leased_until = NULL,
+			last_error = '',
+			updated_at = ?3,
+			finished_at = ?3
+		WHERE id = ?1 AND status = 'running' AND lease_owner = ?2
+		`,
+		id,
+		owner,
+		at.UTC(),
+	)
+
+	return leaseResult(op, res, err)
+}
+
+// Release queues a running job again at once and gives its attempt back.
+func (s *Jobs) Release(id int64, owner string, at time.Time) error {
+	const op = "storage.job.Release"
+
+	res, err := s.db.Exec(`
+		UPDATE jobs SET
+			status = 'queued',
+			attempts = MAX(attempts - 1, 0),
+			run_at = ?3,
+			leased_until = NULL,
+			updated_at = ?3
+		WHERE id = ?1 AND status = 'running' AND lease_owner = ?2
+		`,
+		id,
+		owner,
+		at.UTC(),
+	)
+
+	return leaseResult(op, res, err)
+}
+
+// Fail records the error of the attempt. The job is queued again at retryAt,
+// or fails for good when retryAt is zero.
+func (s *Jobs) Fail(id int64, owner, message string, at, retryAt time.Time) error {
+	const op = "storage.job.Fail"
+
+	status, runAt, finishedAt := "failed", sql.NullTime{}, sql.NullTime{Time: at.UTC(), Valid: true}
+	if !retryAt.IsZero() {
+		status, runAt, finishedAt = "queued", sql.NullTime{Time: retryAt.UTC(), Valid: true}, sql.NullTime{}
+	}
+
+	res, err := s.db.Exec(`
+		UPDATE jobs SET
+			status = ?3,
+			run_at = COALESCE(?4, run_at),
+			leased_until = NULL,
+			last_error = ?5,
+			updated_at = ?6,
+			finished_at = ?7
+		WHERE id = ?1 AND status = 'running' AND lease_owner = ?2
+		`,
+		id,
+		owner,
+		status,
+		runAt,
+		message,
+		at.UTC(),
+		finishedAt,
+	)
+
+	return leaseResult(op, res, err)
+}
+
+func (s *Jobs) Get(id int64) (storage.Job, error) {
+	const op = "storage.job.Get"
+
+	job, err := scanJob(s.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// List returns jobs newest first.
+func (s *Jobs) List(filter repository.JobFilter) ([]storage.Job, error) {
+	const op = "storage.job.List"
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+jobColumns+` FROM jobs
+		WHERE (?1 = '' OR status = ?1) AND (?2 = '' OR kind = ?2)
+		ORDER BY id DESC
+		LIMIT ?3 OFFSET ?4
+		`,
+		filter.Status,
+		filter.Kind,
+		limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jobs, err := scanJobs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+// Retry queues a failed job again with a fresh set of attempts.
+func (s *Jobs) Retry(id int64, at time.Time) (storage.Job, error) {
+	const op = "storage.job.Retry"
+
+	row := s.db.QueryRow(`
+		UPDATE jobs SET
+			status = 'queued',
+			attempts = 0,
+			run_at = ?2,
+			lease_owner = '',
+			updated_at = ?2,
+			finished_at = NULL
+		WHERE id = ?1 AND status = 'failed'
+		RETURNING `+jobColumns,
+		id,
+		at.UTC(),
+	)
+
+	job, err := scanJob(row)
+	if err == nil {
+		return job, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return storage.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := s.Get(id); err != nil {
+		return storage.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return storage.Job{}, fmt.Errorf("%s: %w", op, repository.ErrConflict)
+}
+
+// Purge deletes succeeded and failed jobs finished before the given time.
+func (s *Jobs) Purge(before time.Time) (int, error) {
+	const op = "storage.job.Purge"
+
+	res, err := s.db.Exec(`
+		DELETE FROM jobs
+		WHERE status IN ('succeeded', 'failed') AND finished_at < ?
+		`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(rowAffected), nil
+}
+
+// OldestDue returns the run time of the oldest queued job of the kinds due at now, zero without due jobs.
+func (s *Jobs) OldestDue(kinds []string, now time.Time) (time.Time, error) {
+	const op = "storage.job.OldestDue"
+
+	if len(kinds) == 0 {
+		return time.Time{}, nil
+	}
+
+	args := []any{now.UTC()}
+	params := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		args = append(args, kind)
+		params = append(params, fmt.Sprintf("?%d", len(args)))
+	}
+
+	var runAt time.Time
+	err := s.db.QueryRow(`
+		SELECT run_at FROM jobs
+		WHERE status = 'queued' AND run_at <= ?1 AND kind IN (`+strings.Join(params, ", ")+`)
+		ORDER BY run_at
+		LIMIT 1
+		`, args...).Scan(&runAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return runAt, nil
+}
+
+// leaseResult reports ErrLeaseLost when an update guarded by the lease owner changed nothing.
+func leaseResult(op string, res sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrLeaseLost)
+	}
+
+	return nil
+}
+
+func scanJobs(rows *sql.Rows) ([]storage.Job, error) {
+	defer rows.Close()
+
+	jobs := make([]storage.Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func scanJob(row interface{ Scan(dest ...any) error }) (storage.Job, error) {
+	var (
+		job                     storage.Job
+		leasedUntil, finishedAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Payload,
+		&job.UniqueKey,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.LeaseOwner,
+		&leasedUntil,
+		&job.LastError,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return storage.Job{}, err
+	}
+
+	job.LeasedUntil = leasedUntil.Time
+	job.FinishedAt = finishedAt.Time
+
+	return job, nil
+}
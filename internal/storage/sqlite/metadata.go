@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+func (s *Sqlite) SaveMetadata(ctx context.Context, metadata storage.Metadata) error {
+	const op = "storage.bookmark.SaveMetadata"
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO bookmark_metadata(
+			uuid, title, description, canonical_url, favicon, image, status, error, attempts, fetched_at)
+		SELECT ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10
+		WHERE EXISTS (SELECT 1 FROM bookmark WHERE uuid = ?1)
+		ON CONFLICT(uuid) DO UPDATE SET
+			title = excluded.title,
+			description = excluded.description,
+			canonical_url = excluded.canonical_url,
+			favicon = excluded.favicon,
+			image = excluded.image,
+			status = excluded.status,
+			error = excluded.error,
+			attempts = excluded.attempts,
+			fetched_at = excluded.fetched_at
+		`,
+		metadata.Uuid,
+		metadata.Title,
+		metadata.Description,
+		metadata.CanonicalURL,
+		metadata.Favicon,
+		metadata.Image,
+		metadata.Status,
+		metadata.Error,
+		metadata.Attempts,
+		metadata.FetchedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Sqlite) GetMetadata(ctx context.Context, uuid uuid.UUID) (storage.Metadata, error) {
+	const op = "storage.bookmark.GetMetadata"
+
+	var metadata storage.Metadata
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT uuid, title, description, canonical_url, favicon, image, status, error, attempts, fetched_at
+		FROM bookmark_metadata WHERE uuid = ?
+		`, uuid.String()).Scan(
+		&metadata.Uuid,
+		&metadata.Title,
+		&metadata.Description,
+		&metadata.CanonicalURL,
+		&metadata.Favicon,
+		&metadata.Image,
+		&metadata.Status,
+		&metadata.Error,
+		&metadata.Attempts,
+		&metadata.FetchedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Metadata{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.Metadata{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return metadata, nil
+}
+
+// ListMetadata returns the metadata fetched for the given bookmarks, bookmarks without it are skipped.
+func (s *Sqlite) ListMetadata(ctx context.Context, uuids []uuid.UUID) ([]storage.Metadata, error) {
+	const op = "storage.bookmark.ListMetadata"
+
+	if len(uuids) == 0 {
+		return []storage.Metadata{}, nil
+	}
+
+	params, args := uuidParams(uuids)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT uuid, title, description, canonical_url, favicon, image, status, error, attempts, fetched_at
+		FROM bookmark_metadata WHERE uuid IN (`+params+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	records := make([]storage.Metadata, 0, len(uuids))
+	for rows.Next() {
+		var metadata storage.Metadata
+
+		err := rows.Scan(
+			&metadata.Uuid,
+			&metadata.Title,
+			&metadata.Description,
+			&metadata.CanonicalURL,
+			&metadata.Favicon,
+			&metadata.Image,
+			&metadata.Status,
+			&metadata.Error,
+			&metadata.Attempts,
+			&metadata.FetchedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		records = append(records, metadata)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// uuidParams returns the placeholders of an IN list and their arguments.
+func uuidParams(uuids []uuid.UUID) (string, []any) {
+	params := make([]string, 0, len(uuids))
+	args := make([]any, 0, len(uuids))
+
+	for _, id := range uuids {
+		args = append(args, id.String())
+		params = append(params, fmt.Sprintf("?%d", len(args)))
+	}
+
+	return strings.Join(params, ", "), args
+}
@@ -0,0 +1,432 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"bookmarks/internal/model"
+	"bookmarks/pkg/sqlite"
+)
+
+// migrations is the schema history, append only.
+func migrations() []sqlite.Migration {
+	return []sqlite.Migration{
+		sqlite.Exec(
+			`
+			CREATE TABLE IF NOT EXISTS bookmark(
+				uuid TEXT PRIMARY KEY,
+				title TEXT NOT NULL,
+				value TEXT NOT NULL,
+				created_at DATETIME NOT NULL);
+			`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS ui_value ON bookmark(value);`,
+		),
+		sqlite.Exec(
+			`ALTER TABLE bookmark ADD COLUMN canonical_value TEXT;`,
+		),
+		backfillCanonicalValue,
+		sqlite.Exec(
+			`DROP INDEX IF EXISTS ui_value;`,
+			`CREATE INDEX IF NOT EXISTS ix_value ON bookmark(value);`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS ui_canonical_value ON bookmark(canonical_value);`,
+		),
+		sqlite.Exec(
+			`ALTER TABLE bookmark ADD COLUMN kind TEXT NOT NULL DEFAULT 'text';`,
+			`CREATE INDEX IF NOT EXISTS ix_kind_created_at ON bookmark(kind, created_at);`,
+			`CREATE INDEX IF NOT EXISTS ix_created_at ON bookmark(created_at);`,
+		),
+		backfillKind,
+		sqlite.Exec(
+			`ALTER TABLE bookmark ADD COLUMN title_auto INTEGER NOT NULL DEFAULT 0;`,
+			`
+			CREATE TABLE IF NOT EXISTS bookmark_metadata(
+				uuid TEXT PRIMARY KEY,
+				title TEXT NOT NULL,
+				description TEXT NOT NULL,
+				canonical_url TEXT NOT NULL,
+				favicon TEXT NOT NULL,
+				image TEXT NOT NULL,
+				status TEXT NOT NULL,
+				error TEXT NOT NULL,
+				attempts INTEGER NOT NULL,
+				fetched_at DATETIME NOT NULL);
+			`,
+		),
+		sqlite.Exec(
+			`
+			CREATE TABLE IF NOT EXISTS link_health(
+				uuid TEXT PRIMARY KEY,
+				status TEXT NOT NULL,
+				status_code INTEGER NOT NULL,
+				redirect_to TEXT NOT NULL,
+				latency_ms INTEGER NOT NULL,
+				error TEXT NOT NULL,
+				consecutive_failures INTEGER NOT NULL,
+				checked_at DATETIME NOT NULL);
+			`,
+			`CREATE INDEX IF NOT EXISTS ix_link_health_status ON link_health(status);`,
+			`CREATE INDEX IF NOT EXISTS ix_link_health_checked_at ON link_health(checked_at);`,
+			`
+			CREATE TABLE IF NOT EXISTS link_check(
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				uuid TEXT NOT NULL,
+				ok INTEGER NOT NULL,
+				status_code INTEGER NOT NULL,
+				redirect_to TEXT NOT NULL,
+				latency_ms INTEGER NOT NULL,
+				error TEXT NOT NULL,
+				checked_at DATETIME NOT NULL);
+			`,
+			`CREATE INDEX IF NOT EXISTS ix_link_check_uuid ON link_check(uuid, id);`,
+		),
+		sqlite.Exec(
+			`
+			CREATE TABLE IF NOT EXISTS bookmark_archive(
+				uuid TEXT PRIMARY KEY,
+				digest TEXT NOT NULL,
+				size INTEGER NOT NULL,
+				resources INTEGER NOT NULL,
+				source_url TEXT NOT NULL,
+				archived_at DATETIME NOT NULL);
+			`,
+		),
+		sqlite.Exec(
+			`
+			CREATE TABLE IF NOT EXISTS jobs(
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				kind TEXT NOT NULL,
+				payload BLOB NOT NULL,
+				unique_key TEXT NOT NULL,
+				status TEXT NOT NULL,
+				attempts INTEGER NOT NULL,
+				max_attempts INTEGER NOT NULL,
+				run_at DATETIME NOT NULL,
+				lease_owner TEXT NOT NULL,
+				leased_until DATETIME,
+				last_error TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL,
+				finished_at DATETIME);
+			`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS ui_jobs_unique_key ON jobs(unique_key) WHERE unique_key <> '';`,
+			`CREATE INDEX IF NOT EXISTS ix_jobs_status_run_at ON jobs(status, run_at);`,
+			`CREATE INDEX IF NOT EXISTS ix_jobs_finished_at ON jobs(finished_at);`,
+		),
+		sqlite.Exec(
+			`
+			CREATE TABLE IF NOT EXISTS outbox(
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				type TEXT NOT NULL,
+				aggregate_id TEXT NOT NULL,
+				payload BLOB NOT NULL,
+				occurred_at DATETIME NOT NULL,
+				dispatched_at DATETIME);
+			`,
+			`CREATE INDEX IF NOT EXISTS ix_outbox_dispatched_at ON outbox(dispatched_at);`,
+		),
+		sqlite.Exec(
+			`
+			CREATE TABLE IF NOT EXISTS webhook(
+				id TEXT PRIMARY KEY,
+				url TEXT NOT NULL,
+				events TEXT NOT NULL,
+				secret TEXT NOT NULL,
+				active INTEGER NOT NULL,
+				failures INTEGER NOT NULL,
+				disabled_at DATETIME,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL);
+			`,
+			`
+			CREATE TABLE IF NOT EXISTS webhook_delivery(
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				webhook_id TEXT NOT NULL,
+				event_id INTEGER NOT NULL,
+				event_type TEXT NOT NULL,
+				attempt INTEGER NOT NULL,
+				success INTEGER NOT NULL,
+				status_code INTEGER NOT NULL,
+				error TEXT NOT NULL,
+				duration_ms INTEGER NOT NULL,
+				delivered_at DATETIME NOT NULL);
+			`,
+			`CREATE INDEX IF NOT EXISTS ix_webhook_delivery_webhook_id ON webhook_delivery(webhook_id, id);`,
+		),
+		sqlite.Exec(
+			// existing rows are numbered in insertion order
+			`ALTER TABLE bookmark ADD COLUMN version INTEGER NOT NULL DEFAULT 0;`,
+			`UPDATE bookmark SET version = rowid;`,
+			`CREATE INDEX IF NOT EXISTS ix_bookmark_version ON bookmark(version);`,
+			`
+			CREATE TABLE IF NOT EXISTS bookmark_tombstone(
+				uuid TEXT PRIMARY KEY,
+				version INTEGER NOT NULL,
+				deleted_at DATETIME NOT NULL);
+			`,
+			`CREATE INDEX IF NOT EXISTS ix_bookmark_tombstone_version ON bookmark_tombstone(version);`,
+			`
+			CREATE TABLE IF NOT EXISTS change_sequence(
+				id INTEGER PRIMARY KEY CHECK (id = 1),
+				value INTEGER NOT NULL);
+			`,
+			`INSERT INTO change_sequence(id, value) SELECT 1, COALESCE(MAX(version), 0) FROM bookmark;`,
+		),
+		sqlite.Exec(
+			`ALTER TABLE outbox ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE outbox ADD COLUMN last_error TEXT NOT NULL DEFAULT '';`,
+			`ALTER TABLE outbox ADD COLUMN dead_at DATETIME;`,
+		),
+		sqlite.Exec(
+			`
+			CREATE TABLE IF NOT EXISTS account_user(
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE,
+				created_at DATETIME NOT NULL);
+			`,
+			`
+			CREATE TABLE IF NOT EXISTS api_token(
+				id TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL REFERENCES account_user(id) ON DELETE CASCADE,
+				name TEXT NOT NULL,
+				hash TEXT NOT NULL UNIQUE,
+				created_at DATETIME NOT NULL,
+				expires_at DATETIME);
+			`,
+			`CREATE INDEX IF NOT EXISTS ix_api_token_user_id ON api_token(user_id);`,
+		),
+		sqlite.Exec(
+			`ALTER TABLE account_user ADD COLUMN admin INTEGER NOT NULL DEFAULT 0;`,
+		),
+	}
+}
+
+// backfillCanonicalValue fills canonical_value for rows stored before canonicalization.
+// Rows collapsing into an already taken canonical value are kept with NULL,
+// the oldest bookmark owns the canonical value. Collisions lists them.
+func backfillCanonicalValue(tx *sql.Tx) error {
+	const op = "storage.sqlite.backfillCanonicalValue"
+
+	rows, err := tx.Query(`
+		SELECT uuid, value FROM bookmark
+		WHERE canonical_value IS NULL
+		ORDER BY created_at, uuid
+		`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	canonical := make(map[string]string) // uuid -> canonical value
+	taken := make(map[string]struct{})
+
+	for rows.Next() {
+		var uuid, value string
+		if err := rows.Scan(&uuid, &value); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		val := model.CanonicalValue(value)
+		if _, exists := taken[val]; exists {
+			continue
+		}
+
+		taken[val] = struct{}{}
+		canonical[uuid] = val
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.Prepare(`UPDATE bookmark SET canonical_value = ? WHERE uuid = ?`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	for uuid, val := range canonical {
+		if _, err := stmt.Exec(val, uuid); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Collision is a bookmark the canonical value backfill left without one:
+// its value canonicalizes to the value of an older bookmark, the owner.
+type Collision struct {
+	Uuid           string `json:"uuid"`
+	Value          string `json:"value"`
+	CanonicalValue string `json:"canonical_value"`
+	Owner          string `json:"owner,omitempty"` // empty once the owner is deleted
+}
+
+// canonicalBackfill is the version of the schema with backfillCanonicalValue applied.
+const canonicalBackfill = 3
+
+// Collisions returns the bookmarks left without a canonical value, oldest first, none before the backfill.
+// They are not deduplicated until they are merged into their owner or deleted.
+func Collisions(driver *sqlite.Sqlite) ([]Collision, error) {
+	const op = "storage.sqlite.Collisions"
+
+	version, err := driver.Version()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if version < canonicalBackfill {
+		return nil, nil
+	}
+
+	rows, err := driver.DB.Query(`
+		SELECT uuid, value FROM bookmark
+		WHERE canonical_value IS NULL
+		ORDER BY created_at, uuid
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var collisions []Collision
+
+	for rows.Next() {
+		var c Collision
+		if err := rows.Scan(&c.Uuid, &c.Value); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		c.CanonicalValue = model.CanonicalValue(c.Value)
+		collisions = append(collisions, c)
+	}
+
+	_ = rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i, c := range collisions {
+		err := driver.DB.QueryRow(`SELECT uuid FROM bookmark WHERE canonical_value = ?`, c.CanonicalValue).Scan(&collisions[i].Owner)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return collisions, nil
+}
+
+// backfillKind detects the kind of rows stored before typed values.
+func backfillKind(tx *sql.Tx) error {
+	const op = "storage.sqlite.backfillKind"
+
+	rows, err := tx.Query(`SELECT uuid, value FROM bookmark`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	kinds := make(map[string]model.Kind) // uuid -> kind
+	for rows.Next() {
+		var uuid, value string
+		if err := rows.Scan(&uuid, &value); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if kind := model.DetectKind(value); kind != model.KindText {
+			kinds[uuid] = kind
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.Prepare(`UPDATE bookmark SET kind = ? WHERE uuid = ?`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer stmt.Close()
+
+	for uuid, kind := range kinds {
+		if _, err := stmt.Exec(string(kind), uuid); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// migrate applies the pending migrations. A baseline database, its bookmark table created
+// before the versioned migrations, starts after the first one: the baseline ran the first
+// statement of its schema only and may hold duplicate values the unique index would refuse,
+// the canonical value backfill keeps them out of deduplication instead.
+func migrate(driver *sqlite.Sqlite) error {
+	version, err := driver.Version()
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		var tables int
+		if err := driver.DB.QueryRow(
+			`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'bookmark'`,
+		).Scan(&tables); err != nil {
+			return err
+		}
+
+		if tables > 0 {
+			if _, err := driver.DB.Exec("PRAGMA user_version = 1"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return driver.Migrate(migrations())
+}
+
+// Migrate applies the pending migrations of all storages, it returns the schema version before and after.
+func Migrate(driver *sqlite.Sqlite) (from, to int, err error) {
+	const op = "storage.sqlite.Migrate"
+
+	if from, err = driver.Version(); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = migrate(driver); err != nil {
+		return from, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if to, err = driver.Version(); err != nil {
+		return from, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return from, to, nil
+}
+
+// SchemaVersion is the version of the schema with all migrations applied.
+func SchemaVersion() int {
+	return len(migrations())
+}
+
+// CheckSchema returns the schema version of the database, ErrSchemaOutdated unless it is the current one.
+func CheckSchema(driver *sqlite.Sqlite) (int, error) {
+	const op = "storage.sqlite.CheckSchema"
+
+	version, err := driver.Version()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if version < SchemaVersion() {
+		return version, fmt.Errorf("%s: %w: version %d of %d", op, ErrSchemaOutdated, version, SchemaVersion())
+	}
+
+	return version, nil
+}
@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pkgsql "bookmarks/pkg/sqlite"
+)
+
+func TestMigrate_Backfill(t *testing.T) {
+	dbSourceName := "../../../storage/test_migration.db"
+	_ = os.Remove(dbSourceName)
+
+	driver, err := pkgsql.New(pkgsql.SourceName(dbSourceName))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = driver.DB.Close()
+		_ = os.Remove(dbSourceName)
+	})
+
+	// the baseline schema, its unique index on value was never created
+	_, err = driver.DB.Exec(`
+		CREATE TABLE bookmark(
+			uuid TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			value TEXT NOT NULL,
+			created_at DATETIME NOT NULL);
+		`)
+	require.NoError(t, err)
+
+	now := time.Now()
+	rows := []struct {
+		uuid, value string
+		createdAt   time.Time
+	}{
+		{uuid: "0193a1b2-0000-7000-8000-000000000001", value: "https://Example.com/a/?utm_source=x", createdAt: now},
+		{uuid: "0193a1b2-0000-7000-8000-000000000002", value: "https://example.com/a", createdAt: now.Add(time.Second)},
+		{uuid: "0193a1b2-0000-7000-8000-000000000003", value: "  some   text ", createdAt: now},
+		{uuid: "0193a1b2-0000-7000-8000-000000000004", value: "  some   text ", createdAt: now.Add(time.Second)},
+	}
+
+	for _, row := range rows {
+		_, err = driver.DB.Exec(
+			"INSERT INTO bookmark(uuid, title, value, created_at) VALUES(?, ?, ?, ?)",
+			row.uuid, "title", row.value, row.createdAt,
+		)
+		require.NoError(t, err)
+	}
+
+	storage, err := NewBookmark(driver)
+	require.NoError(t, err)
+
+	version, err := driver.Version()
+	require.NoError(t, err)
+	require.Equal(t, len(migrations()), version)
+
+	var canonical *string
+
+	require.NoError(t, driver.DB.QueryRow("SELECT canonical_value FROM bookmark WHERE uuid = ?", rows[0].uuid).Scan(&canonical))
+	require.NotNil(t, canonical)
+	require.Equal(t, "https://example.com/a", *canonical)
+
+	require.NoError(t, driver.DB.QueryRow("SELECT canonical_value FROM bookmark WHERE uuid = ?", rows[1].uuid).Scan(&canonical))
+	require.Nil(t, canonical)
+
+	require.NoError(t, driver.DB.QueryRow("SELECT canonical_value FROM bookmark WHERE uuid = ?", rows[2].uuid).Scan(&canonical))
+	require.NotNil(t, canonical)
+	require.Equal(t, "some text", *canonical)
+
+	record, err := storage.GetByValue(t.Context(), "https://example.com/a")
+	require.NoError(t, err)
+	require.Empty(t, record.CanonicalValue)
+	require.Equal(t, "url", record.Kind)
+
+	record, err = storage.GetByValue(t.Context(), rows[2].value)
+	require.NoError(t, err)
+	require.Equal(t, "text", record.Kind)
+
+	collisions, err := Collisions(driver)
+	require.NoError(t, err)
+	require.Equal(t, []Collision{
+		{Uuid: rows[1].uuid, Value: rows[1].value, CanonicalValue: "https://example.com/a", Owner: rows[0].uuid},
+		{Uuid: rows[3].uuid, Value: rows[3].value, CanonicalValue: "some text", Owner: rows[2].uuid},
+	}, collisions)
+}
+
+func TestMigrate_Stats(t *testing.T) {
+	dbSourceName := "../../../storage/test_migration_stats.db"
+	_ = os.Remove(dbSourceName)
+
+	driver, err := pkgsql.New(pkgsql.SourceName(dbSourceName))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = driver.DB.Close()
+		_ = os.Remove(dbSourceName)
+	})
+
+	require.NoError(t, driver.Migrate(migrations()[:1]))
+
+	// the unique index on value of the first migration goes with the canonical values
+	var indexes int
+	require.NoError(t, driver.DB.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'ui_value'`).Scan(&indexes))
+	require.Equal(t, 1, indexes)
+
+	_, err = ReadStats(driver)
+	require.ErrorIs(t, err, ErrSchemaOutdated)
+
+	from, to, err := Migrate(driver)
+	require.NoError(t, err)
+	require.Equal(t, 1, from)
+	require.Equal(t, SchemaVersion(), to)
+
+	require.NoError(t, driver.DB.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'ui_value'`).Scan(&indexes))
+	require.Zero(t, indexes)
+
+	storage, err := NewBookmark(driver)
+	require.NoError(t, err)
+
+	_, err = driver.DB.Exec(
+		"INSERT INTO bookmark(uuid, title, value, kind, created_at) VALUES(?, ?, ?, ?, ?)",
+		"0193a1b2-0000-7000-8000-000000000001", "title", "https://example.com", "url", time.Now(),
+	)
+	require.NoError(t, err)
+
+	_, err = storage.GetByValue(t.Context(), "https://example.com")
+	require.NoError(t, err)
+
+	stats, err := ReadStats(driver)
+	require.NoError(t, err)
+	require.Equal(t, SchemaVersion(), stats.SchemaVersion)
+	require.Equal(t, 1, stats.Bookmarks)
+	require.Equal(t, map[string]int{"url": 1}, stats.BookmarksByKind)
+	require.Positive(t, stats.Size)
+
+	problems, err := driver.IntegrityCheck()
+	require.NoError(t, err)
+	require.Empty(t, problems)
+
+	duplicates, err := driver.UniqueIndexCheck()
+	require.NoError(t, err)
+	require.Empty(t, duplicates)
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// insertEvents writes the events of a change to the outbox within the transaction of the change.
+func insertEvents(ctx context.Context, tx *sql.Tx, emit repository.EmitRecord, change repository.Change, record storage.Bookmark) error {
+	if emit == nil {
+		return nil
+	}
+
+	events, err := emit(change, record)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO outbox(type, aggregate_id, payload, occurred_at)
+			VALUES(?, ?, ?, ?)
+			`,
+			event.Type,
+			event.AggregateID,
+			event.Payload,
+			event.OccurredAt.UTC(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PendingEvents returns the events after the given id neither dispatched nor dead yet, in the order they were written.
+func (s *Sqlite) PendingEvents(ctx context.Context, after int64, limit int) ([]storage.Event, error) {
+	const op = "storage.outbox.PendingEvents"
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, type, aggregate_id, payload, occurred_at, attempts, last_error
+		FROM outbox WHERE dispatched_at IS NULL AND dead_at IS NULL AND id > ?
+		ORDER BY id
+		LIMIT ?
+		`, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	events := make([]storage.Event, 0)
+	for rows.Next() {
+		var event storage.Event
+		if err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.AggregateID,
+			&event.Payload,
+			&event.OccurredAt,
+			&event.Attempts,
+			&event.LastError,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+func (s *Sqlite) MarkDispatched(ctx context.Context, ids []int64, at time.Time) error {
+	const op = "storage.outbox.MarkDispatched"
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := []any{at.UTC()}
+	params := make([]string, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+		params = append(params, fmt.Sprintf("?%d", len(args)))
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE outbox SET dispatched_at = ?1
+		WHERE dispatched_at IS NULL AND id IN (`+strings.Join(params, ", ")+`)`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FailEvent counts a failed publish of a pending event, the event is dead once it failed maxAttempts times.
+// It reports whether the event is dead.
+func (s *Sqlite) FailEvent(ctx context.Context, id int64, reason string, maxAttempts int, at time.Time) (bool, error) {
+	const op = "storage.outbox.FailEvent"
+
+	var dead bool
+
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE outbox SET
+			attempts = attempts + 1,
+			last_error = ?1,
+			dead_at = CASE WHEN attempts + 1 >= ?2 THEN ?3 END
+		WHERE id = ?4 AND dispatched_at IS NULL AND dead_at IS NULL
+		RETURNING dead_at IS NOT NULL
+		`,
+		reason,
+		maxAttempts,
+		at.UTC(),
+		id,
+	).Scan(&dead)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return dead, nil
+}
+
+// PurgeEvents deletes the events dispatched or dead before the given time.
+func (s *Sqlite) PurgeEvents(ctx context.Context, before time.Time) (int, error) {
+	const op = "storage.outbox.PurgeEvents"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM outbox WHERE dispatched_at < ?1 OR dead_at < ?1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(rowAffected), nil
+}
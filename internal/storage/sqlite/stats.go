@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+
+	"bookmarks/pkg/sqlite"
+)
+
+var (
+	ErrSchemaOutdated = errors.New("schema is outdated, run migrate")
+	ErrSchemaNewer    = errors.New("schema is newer than this build supports")
+)
+
+// Stats counts the stored records, the maps are keyed by kind or status.
+type Stats struct {
+	SchemaVersion    int            `json:"schema_version"`
+	Size             int64          `json:"size_bytes"`
+	Bookmarks        int            `json:"bookmarks"`
+	BookmarksByKind  map[string]int `json:"bookmarks_by_kind"`
+	Tombstones       int            `json:"tombstones"`
+	Metadata         map[string]int `json:"metadata"`
+	LinkHealth       map[string]int `json:"link_health"`
+	Archives         int            `json:"archives"`
+	ArchiveBytes     int64          `json:"archive_bytes"`
+	Jobs             map[string]int `json:"jobs"`
+	Webhooks         int            `json:"webhooks"`
+	WebhooksDisabled int            `json:"webhooks_disabled"`
+	OutboxPending    int            `json:"outbox_pending"`
+	OutboxDead       int            `json:"outbox_dead"`
+}
+
+// ReadStats counts the records of a database migrated to the current schema.
+func ReadStats(driver *sqlite.Sqlite) (Stats, error) {
+	const op = "storage.sqlite.ReadStats"
+
+	var (
+		stats Stats
+		err   error
+	)
+
+	if stats.SchemaVersion, err = CheckSchema(driver); err != nil {
+		return Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stats.Size, err = driver.Size(); err != nil {
+		return Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	counts := []struct {
+		query string
+		dest  []any
+	}{
+		{`SELECT COUNT(*) FROM bookmark`, []any{&stats.Bookmarks}},
+		{`SELECT COUNT(*) FROM bookmark_tombstone`, []any{&stats.Tombstones}},
+		{`SELECT COUNT(*), COALESCE(SUM(size), 0) FROM bookmark_archive`, []any{&stats.Archives, &stats.ArchiveBytes}},
+		{`SELECT COUNT(*), COUNT(disabled_at) FROM webhook`, []any{&stats.Webhooks, &stats.WebhooksDisabled}},
+		{`SELECT COUNT(*) FROM outbox WHERE dispatched_at IS NULL AND dead_at IS NULL`, []any{&stats.OutboxPending}},
+		{`SELECT COUNT(*) FROM outbox WHERE dead_at IS NOT NULL`, []any{&stats.OutboxDead}},
+	}
+
+	for _, c := range counts {
+		if err := driver.DB.QueryRow(c.query).Scan(c.dest...); err != nil {
+			return Stats{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	groups := []struct {
+		query string
+		dest  *map[string]int
+	}{
+		{`SELECT kind, COUNT(*) FROM bookmark GROUP BY kind`, &stats.BookmarksByKind},
+		{`SELECT status, COUNT(*) FROM bookmark_metadata GROUP BY status`, &stats.Metadata},
+		{`SELECT status, COUNT(*) FROM link_health GROUP BY status`, &stats.LinkHealth},
+		{`SELECT status, COUNT(*) FROM jobs GROUP BY status`, &stats.Jobs},
+	}
+
+	for _, g := range groups {
+		if *g.dest, err = countBy(driver, g.query); err != nil {
+			return Stats{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return stats, nil
+}
+
+func countBy(driver *sqlite.Sqlite, query string) (map[string]int, error) {
+	rows, err := driver.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := make(map[string]int)
+
+	for rows.Next() {
+		var (
+			key   string
+			count int
+		)
+
+		if err := rows.Scan(&key, &count); err != nil {
+			return nil, err
+		}
+
+		counts[key] = count
+	}
+
+	return counts, rows.Err()
+}
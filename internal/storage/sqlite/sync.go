@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+)
+
+// Changes returns up to limit bookmarks and up to limit tombstones written after since,
+// read in one transaction along with the version of the last write.
+func (s *Sqlite) Changes(ctx context.Context, since int64, limit int) (storage.ChangeSet, error) {
+	const op = "storage.bookmark.Changes"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	set := storage.ChangeSet{
+		Bookmarks:  make([]storage.Bookmark, 0),
+		Tombstones: make([]storage.Tombstone, 0),
+	}
+
+	rows, err := tx.QueryContext(ctx, selectQuery+" WHERE version > ? ORDER BY version LIMIT ?", since, limit)
+	if err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanBookmark(rows)
+		if err != nil {
+			return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		set.Bookmarks = append(set.Bookmarks, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		SELECT uuid, version, deleted_at FROM bookmark_tombstone
+		WHERE version > ?
+		ORDER BY version
+		LIMIT ?
+		`, since, limit)
+	if err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tombstone storage.Tombstone
+		if err := rows.Scan(&tombstone.Uuid, &tombstone.Version, &tombstone.DeletedAt); err != nil {
+			return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		set.Tombstones = append(set.Tombstones, tombstone)
+	}
+
+	if err := rows.Err(); err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.QueryRowContext(ctx, `SELECT value FROM change_sequence`).Scan(&set.Version); err != nil {
+		return storage.ChangeSet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return set, nil
+}
+
+// nextVersion takes the next number of the change sequence. Writers are serialized by sqlite,
+// so versions are committed in order; taking the number first locks the database for writing.
+func nextVersion(ctx context.Context, tx *sql.Tx) (int64, error) {
+	var version int64
+	if err := tx.QueryRowContext(ctx, `UPDATE change_sequence SET value = value + 1 RETURNING value`).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// checkTombstone returns ErrDeleted for the uuid of a deleted bookmark.
+func checkTombstone(ctx context.Context, tx *sql.Tx, uuid string) error {
+	var version int64
+
+	err := tx.QueryRowContext(ctx, `SELECT version FROM bookmark_tombstone WHERE uuid = ?`, uuid).Scan(&version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return repository.ErrDeleted
+	}
+}
+
+// findBookmark returns the row of uuid, ErrDeleted when it left a tombstone.
+func findBookmark(ctx context.Context, tx *sql.Tx, uuid string) (storage.Bookmark, error) {
+	record, err := scanBookmark(tx.QueryRowContext(ctx, selectQuery+" WHERE uuid = ?", uuid))
+	if !errors.Is(err, repository.ErrNotFound) {
+		return record, err
+	}
+
+	if err := checkTombstone(ctx, tx, uuid); err != nil {
+		return storage.Bookmark{}, err
+	}
+
+	return storage.Bookmark{}, repository.ErrNotFound
+}
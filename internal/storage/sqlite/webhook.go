@@ -0,0 +1,336 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bookmarks/internal/repository"
+	"bookmarks/internal/storage"
+	"bookmarks/pkg/sqlite"
+)
+
+const webhookColumns = "id, url, events, secret, active, failures, disabled_at, created_at, updated_at"
+
+// Webhooks keeps webhook subscriptions and their delivery log.
+type Webhooks struct {
+	db *sql.DB
+}
+
+func NewWebhook(sqlite *sqlite.Sqlite) (*Webhooks, error) {
+	const op = "storage.sqlite.NewWebhook"
+
+	err := migrate(sqlite)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Webhooks{db: sqlite.DB}, nil
+}
+
+func (s *Webhooks) Create(record storage.Webhook) error {
+	const op = "storage.webhook.Create"
+
+	_, err := s.db.Exec(`
+		INSERT INTO webhook(`+webhookColumns+`)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		record.ID,
+		record.URL,
+		strings.Join(record.Events, ","),
+		record.Secret,
+		record.Active,
+		record.Failures,
+		nullTime(record.DisabledAt),
+		record.CreatedAt.UTC(),
+		record.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, repository.ErrExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Webhooks) Update(record storage.Webhook) error {
+	const op = "storage.webhook.Update"
+
+	res, err := s.db.Exec(`
+		UPDATE webhook SET
+			url = ?2,
+			events = ?3,
+			secret = ?4,
+			active = ?5,
+			failures = ?6,
+			disabled_at = ?7,
+			updated_at = ?8
+		WHERE id = ?1
+		`,
+		record.ID,
+		record.URL,
+		strings.Join(record.Events, ","),
+		record.Secret,
+		record.Active,
+		record.Failures,
+		nullTime(record.DisabledAt),
+		record.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Webhooks) Get(id string) (storage.Webhook, error) {
+	const op = "storage.webhook.Get"
+
+	record, err := scanWebhook(s.db.QueryRow(`SELECT `+webhookColumns+` FROM webhook WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Webhook{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return record, nil
+}
+
+// List returns the webhooks oldest first.
+func (s *Webhooks) List() ([]storage.Webhook, error) {
+	const op = "storage.webhook.List"
+
+	rows, err := s.db.Query(`SELECT ` + webhookColumns + ` FROM webhook ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	records := make([]storage.Webhook, 0)
+	for rows.Next() {
+		record, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return records, nil
+}
+
+// Delete removes the webhook with its delivery log.
+func (s *Webhooks) Delete(id string) error {
+	const op = "storage.webhook.Delete"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	res, err := tx.Exec(`DELETE FROM webhook WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowAffected == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM webhook_delivery WHERE webhook_id = ?`, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecordDelivery logs the delivery keeping the last keep ones and counts the consecutive failures,
+// the webhook is disabled once they reach disableAfter. The updated webhook is returned.
+func (s *Webhooks) RecordDelivery(delivery storage.WebhookDelivery, disableAfter, keep int) (storage.Webhook, error) {
+	const op = "storage.webhook.RecordDelivery"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return storage.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	// the failure count is read before the update
+	record, err := scanWebhook(tx.QueryRow(`
+		UPDATE webhook SET
+			failures = CASE WHEN ?2 THEN 0 ELSE failures + 1 END,
+			active = CASE WHEN NOT ?2 AND ?3 > 0 AND failures + 1 >= ?3 THEN 0 ELSE active END,
+			disabled_at = CASE WHEN active = 1 AND NOT ?2 AND ?3 > 0 AND failures + 1 >= ?3 THEN ?4 ELSE disabled_at END
+		WHERE id = ?1
+		RETURNING `+webhookColumns,
+		delivery.WebhookID,
+		delivery.Success,
+		disableAfter,
+		delivery.DeliveredAt.UTC(),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Webhook{}, fmt.Errorf("%s: %w", op, repository.ErrNotFound)
+		}
+
+		return storage.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO webhook_delivery(
+			webhook_id, event_id, event_type, attempt, success, status_code, error, duration_ms, delivered_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Attempt,
+		delivery.Success,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.Duration.Milliseconds(),
+		delivery.DeliveredAt.UTC(),
+	)
+	if err != nil {
+		return storage.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if keep > 0 {
+		_, err := tx.Exec(`
+			DELETE FROM webhook_delivery WHERE webhook_id = ?1 AND id NOT IN (
+				SELECT id FROM webhook_delivery WHERE webhook_id = ?1 ORDER BY id DESC LIMIT ?2)
+			`, delivery.WebhookID, keep)
+		if err != nil {
+			return storage.Webhook{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return record, nil
+}
+
+// ListDeliveries returns a page of the delivery log of the webhook, newest first.
+func (s *Webhooks) ListDeliveries(id string, limit, offset int) ([]storage.WebhookDelivery, error) {
+	const op = "storage.webhook.ListDeliveries"
+
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, webhook_id, event_id, event_type, attempt, success, status_code, error, duration_ms, delivered_at
+		FROM webhook_delivery WHERE webhook_id = ?
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+		`, id, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]storage.WebhookDelivery, 0)
+	for rows.Next() {
+		var (
+			delivery storage.WebhookDelivery
+			duration int64
+		)
+
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventID,
+			&delivery.EventType,
+			&delivery.Attempt,
+			&delivery.Success,
+			&delivery.StatusCode,
+			&delivery.Error,
+			&duration,
+			&delivery.DeliveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		delivery.Duration = time.Duration(duration) * time.Millisecond
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+func scanWebhook(row interface{ Scan(dest ...any) error }) (storage.Webhook, error) {
+	var (
+		record     storage.Webhook
+		events     string
+		disabledAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&record.ID,
+		&record.URL,
+		&events,
+		&record.Secret,
+		&record.Active,
+		&record.Failures,
+		&disabledAt,
+		&record.CreatedAt,
+		&record.UpdatedAt,
+	)
+	if err != nil {
+		return storage.Webhook{}, err
+	}
+
+	if events != "" {
+		record.Events = strings.Split(events, ",")
+	}
+
+	record.DisabledAt = disabledAt.Time
+
+	return record, nil
+}
+
+// nullTime stores a zero time as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
@@ -5,8 +5,139 @@ import (
 )
 
 type Bookmark struct {
+	Uuid           string
+	Title          string
+	TitleAuto      bool
+	Value          string
+	Kind           string
+	CanonicalValue string
+	CreatedAt      time.Time
+	Version        int64
+}
+
+type Tombstone struct {
 	Uuid      string
-	Title     string
-	Value     string
+	Version   int64
+	DeletedAt time.Time
+}
+
+// ChangeSet holds up to a limit of the bookmarks and of the tombstones written after a version,
+// each in version order, and the version of the last write.
+type ChangeSet struct {
+	Bookmarks  []Bookmark
+	Tombstones []Tombstone
+	Version    int64
+}
+
+type Metadata struct {
+	Uuid         string
+	Title        string
+	Description  string
+	CanonicalURL string
+	Favicon      string
+	Image        string
+	Status       string
+	Error        string
+	Attempts     int
+	FetchedAt    time.Time
+}
+
+type LinkCheck struct {
+	Uuid       string
+	OK         bool
+	StatusCode int
+	RedirectTo string
+	Latency    time.Duration
+	Error      string
+	CheckedAt  time.Time
+}
+
+type LinkHealth struct {
+	Uuid                string
+	Status              string
+	StatusCode          int
+	RedirectTo          string
+	Latency             time.Duration
+	Error               string
+	ConsecutiveFailures int
+	CheckedAt           time.Time
+}
+
+type Archive struct {
+	Uuid       string
+	Digest     string
+	Size       int64
+	Resources  int
+	SourceURL  string
+	ArchivedAt time.Time
+}
+
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     []byte
+	UniqueKey   string
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LeaseOwner  string
+	LeasedUntil time.Time
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FinishedAt  time.Time
+}
+
+type Event struct {
+	ID           int64
+	Type         string
+	AggregateID  string
+	Payload      []byte
+	OccurredAt   time.Time
+	DispatchedAt time.Time
+	Attempts     int    // failed publishes
+	LastError    string // error of the last failed publish
+	DeadAt       time.Time
+}
+
+type Webhook struct {
+	ID         string
+	URL        string
+	Events     []string
+	Secret     string
+	Active     bool
+	Failures   int
+	DisabledAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type WebhookDelivery struct {
+	ID          int64
+	WebhookID   string
+	EventID     int64
+	EventType   string
+	Attempt     int
+	Success     bool
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+	DeliveredAt time.Time
+}
+
+type User struct {
+	ID        string
+	Name      string
+	Admin     bool
+	CreatedAt time.Time
+}
+
+type Token struct {
+	ID        string
+	UserID    string
+	Name      string
+	Hash      string
 	CreatedAt time.Time
+	ExpiresAt time.Time
 }
@@ -0,0 +1,42 @@
+package tracing
+
+import "io"
+
+type Option func(*Tracing)
+
+// ServiceName sets the service.name resource attribute of the spans.
+func ServiceName(name string) Option {
+	return func(t *Tracing) {
+		t.serviceName = name
+	}
+}
+
+// Exporter sets where the spans go, ExporterOTLP or ExporterStdout.
+func Exporter(exporter string) Option {
+	return func(t *Tracing) {
+		t.exporter = exporter
+	}
+}
+
+// Endpoint sets the URL of the OTLP/HTTP collector, e.g. http://localhost:4318.
+// Without it the OTEL_EXPORTER_OTLP_* variables apply.
+func Endpoint(url string) Option {
+	return func(t *Tracing) {
+		t.endpoint = url
+	}
+}
+
+// SampleRatio sets the share of the traces started here that are recorded,
+// a request coming with a traceparent follows the decision of its caller.
+func SampleRatio(ratio float64) Option {
+	return func(t *Tracing) {
+		t.sampleRatio = ratio
+	}
+}
+
+// Output sets the writer of the stdout exporter.
+func Output(w io.Writer) Option {
+	return func(t *Tracing) {
+		t.output = w
+	}
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Tracing exports the spans of the app. New installs it as the global tracer provider
+// along with the W3C trace context propagator, the instrumented layers use both.
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+
+	serviceName string
+	exporter    string
+	endpoint    string
+	sampleRatio float64
+	output      io.Writer
+}
+
+func New(ctx context.Context, options ...Option) (*Tracing, error) {
+	const op = "tracing.New"
+
+	t := &Tracing{
+		serviceName: "bookmarks",
+		exporter:    ExporterOTLP,
+		sampleRatio: 1,
+		output:      os.Stdout,
+	}
+
+	for _, opt := range options {
+		opt(t)
+	}
+
+	exporter, err := t.newExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(t.serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.sampleRatio))),
+	)
+
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return t, nil
+}
+
+func (t *Tracing) newExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	switch t.exporter {
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if t.endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(t.endpoint))
+		}
+
+		return otlptracehttp.New(ctx, options...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(t.output))
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, t.exporter)
+}
+
+// Shutdown exports the buffered spans and stops the exporter.
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	const op = "tracing.Shutdown"
+
+	if err := t.provider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"bookmarks/internal/tracing"
+)
+
+var errBroken = errors.New("broken")
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	return recorder
+}
+
+func TestStart_WithoutParent(t *testing.T) {
+	recorder := newRecorder(t)
+
+	ctx, span := tracing.Start(t.Context(), "orphan")
+	tracing.End(span, new(error))
+
+	require.Empty(t, tracing.TraceID(ctx))
+	require.Empty(t, recorder.Ended())
+}
+
+func TestEnd_RecordsError(t *testing.T) {
+	recorder := newRecorder(t)
+
+	ctx, root := tracing.Tracer().Start(t.Context(), "root")
+
+	operation := func(ctx context.Context) (err error) {
+		_, span := tracing.Start(ctx, "child")
+		defer tracing.End(span, &err)
+
+		return errBroken
+	}
+
+	require.ErrorIs(t, operation(ctx), errBroken)
+	root.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	child := spans[0]
+	require.Equal(t, "child", child.Name())
+	require.Equal(t, root.SpanContext().SpanID(), child.Parent().SpanID())
+	require.Equal(t, codes.Error, child.Status().Code)
+	require.Len(t, child.Events(), 1) // the recorded error
+	require.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestNew_UnknownExporter(t *testing.T) {
+	_, err := tracing.New(t.Context(), tracing.Exporter("zipkin"))
+	require.ErrorIs(t, err, tracing.ErrUnknownExporter)
+}
@@ -0,0 +1,52 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const algorithm = "sha256"
+
+var (
+	ErrNotFound      = errors.New("blob not found")
+	ErrInvalidDigest = errors.New("invalid blob digest")
+)
+
+// Blob is a stored content, addressed by the digest of its bytes.
+type Blob struct {
+	Digest string // "sha256:<hex>"
+	Size   int64
+}
+
+// Store keeps blobs addressed by the SHA-256 of their content,
+// putting the same content twice stores it once.
+type Store interface {
+	Put(r io.Reader) (Blob, error)
+	Open(digest string) (io.ReadCloser, error)
+	Stat(digest string) (Blob, error)
+	Delete(digest string) error
+}
+
+// Digest returns the address of the content.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return algorithm + ":" + hex.EncodeToString(sum[:])
+}
+
+// ParseDigest validates a digest and returns its hex encoded hash.
+func ParseDigest(digest string) (string, error) {
+	algo, sum, ok := strings.Cut(digest, ":")
+	if !ok || algo != algorithm || len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDigest, digest)
+	}
+
+	if _, err := hex.DecodeString(sum); err != nil || strings.ToLower(sum) != sum {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDigest, digest)
+	}
+
+	return sum, nil
+}
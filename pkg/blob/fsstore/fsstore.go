@@ -0,0 +1,145 @@
+package fsstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"bookmarks/pkg/blob"
+)
+
+// Store keeps blobs as files named by their hash: <dir>/sha256/<2 hex>/<hex>.
+// A blob is written to <dir>/tmp first and renamed into place, so readers never see a partial file.
+type Store struct {
+	dir string
+}
+
+func New(options ...Option) (*Store, error) {
+	const op = "fsstore.New"
+
+	s := &Store{dir: "./storage/blobs"}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	for _, dir := range []string{s.tmpDir(), filepath.Join(s.dir, "sha256")} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Store) Put(r io.Reader) (blob.Blob, error) {
+	const op = "fsstore.Put"
+
+	tmp, err := os.CreateTemp(s.tmpDir(), "blob-*")
+	if err != nil {
+		return blob.Blob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck // a no-op once renamed
+
+	hash := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return blob.Blob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	stored := blob.Blob{Digest: "sha256:" + sum, Size: size}
+
+	path := s.path(sum)
+	if _, err := os.Stat(path); err == nil {
+		return stored, nil // same content is already stored
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return blob.Blob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return blob.Blob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stored, nil
+}
+
+func (s *Store) Open(digest string) (io.ReadCloser, error) {
+	const op = "fsstore.Open"
+
+	sum, err := blob.ParseDigest(digest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	file, err := os.Open(s.path(sum))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, notFound(err))
+	}
+
+	return file, nil
+}
+
+func (s *Store) Stat(digest string) (blob.Blob, error) {
+	const op = "fsstore.Stat"
+
+	sum, err := blob.ParseDigest(digest)
+	if err != nil {
+		return blob.Blob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := os.Stat(s.path(sum))
+	if err != nil {
+		return blob.Blob{}, fmt.Errorf("%s: %w", op, notFound(err))
+	}
+
+	return blob.Blob{Digest: digest, Size: info.Size()}, nil
+}
+
+func (s *Store) Delete(digest string) error {
+	const op = "fsstore.Delete"
+
+	sum, err := blob.ParseDigest(digest)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Remove(s.path(sum)); err != nil {
+		return fmt.Errorf("%s: %w", op, notFound(err))
+	}
+
+	return nil
+}
+
+func (s *Store) path(sum string) string {
+	return filepath.Join(s.dir, "sha256", sum[:2], sum)
+}
+
+func (s *Store) tmpDir() string {
+	return filepath.Join(s.dir, "tmp")
+}
+
+func notFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return blob.ErrNotFound
+	}
+
+	return err
+}
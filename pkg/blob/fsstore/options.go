@@ -0,0 +1,10 @@
+package fsstore
+
+type Option func(*Store)
+
+// Dir sets the directory the blobs are kept in.
+func Dir(path string) Option {
+	return func(s *Store) {
+		s.dir = path
+	}
+}
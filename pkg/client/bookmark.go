@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conflict modes of Append.
+const (
+	ConflictError       = "error"
+	ConflictIgnore      = "ignore"
+	ConflictUpdateTitle = "update_title"
+)
+
+// Bookmark is a bookmark as the server writes it.
+type Bookmark struct {
+	Uuid           uuid.UUID
+	Title          string
+	TitleAuto      bool
+	Value          string
+	Kind           string
+	CanonicalValue string
+	CreatedAt      time.Time
+	Version        int64
+	Render         Render `json:"render"`
+}
+
+// Render hints how a client should show the value.
+type Render struct {
+	Href      string `json:"href,omitempty"`
+	Monospace bool   `json:"monospace,omitempty"`
+}
+
+// Metadata is the metadata fetched from the page of an URL bookmark.
+type Metadata struct {
+	Uuid         uuid.UUID
+	Title        string
+	Description  string
+	CanonicalURL string
+	Favicon      string
+	Image        string
+	Status       string
+	Error        string
+	Attempts     int
+	FetchedAt    time.Time
+}
+
+// HealthReport is the current state of a link with its recent checks, newest first.
+type HealthReport struct {
+	Health  LinkHealth  `json:"health"`
+	History []LinkCheck `json:"history"`
+}
+
+type LinkHealth struct {
+	Uuid                uuid.UUID
+	Status              string
+	StatusCode          int
+	RedirectTo          string
+	Latency             time.Duration
+	Error               string
+	ConsecutiveFailures int
+	CheckedAt           time.Time
+}
+
+type LinkCheck struct {
+	Uuid       uuid.UUID
+	OK         bool
+	StatusCode int
+	RedirectTo string
+	Latency    time.Duration
+	Error      string
+	CheckedAt  time.Time
+}
+
+// Archive is an archived page, a self-contained HTML document.
+type Archive struct {
+	ETag        string
+	ArchivedAt  time.Time
+	NotModified bool
+	Body        io.ReadCloser
+}
+
+type AppendRequest struct {
+	Title string `json:"title,omitempty"`
+	Value string `json:"value"`
+	Kind  string `json:"kind,omitempty"`
+	// OnConflict is one of the conflict modes, the server defaults to ConflictError
+	OnConflict string `json:"-"`
+}
+
+// ChangeRequest replaces the bookmark, Version is the one last read: a bookmark changed
+// since is not overwritten and the change fails with a conflict.
+type ChangeRequest struct {
+	Title   string `json:"title"`
+	Value   string `json:"value"`
+	Kind    string `json:"kind,omitempty"`
+	Version int64  `json:"version"`
+}
+
+type ListFilter struct {
+	Kind   string
+	Health string
+	Limit  int
+	Offset int
+}
+
+type Page struct {
+	Items  []Bookmark `json:"items"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+// Append saves a bookmark, created is false when the value was already bookmarked
+// and the conflict mode returned the stored one.
+func (c *Client) Append(ctx context.Context, r AppendRequest) (b Bookmark, created bool, err error) {
+	var query url.Values
+	if r.OnConflict != "" {
+		query = url.Values{"on_conflict": {r.OnConflict}}
+	}
+
+	status, err := c.do(ctx, call{method: http.MethodPost, path: "/v1/bookmark/append", query: query, in: r}, &b)
+	if err != nil {
+		return Bookmark{}, false, err
+	}
+
+	return b, status == http.StatusCreated, nil
+}
+
+// View reads the bookmark, a deleted one is not found.
+func (c *Client) View(ctx context.Context, id uuid.UUID) (Bookmark, error) {
+	var b Bookmark
+
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: bookmarkPath(id)}, &b); err != nil {
+		return Bookmark{}, err
+	}
+
+	return b, nil
+}
+
+// Change replaces the bookmark, it fails with ErrBookmarkChanged when the version is stale
+// and with ErrBookmarkExists when the new value is bookmarked by another one.
+func (c *Client) Change(ctx context.Context, id uuid.UUID, r ChangeRequest) (Bookmark, error) {
+	var b Bookmark
+
+	if _, err := c.do(ctx, call{method: http.MethodPost, path: bookmarkPath(id), in: r}, &b); err != nil {
+		return Bookmark{}, err
+	}
+
+	return b, nil
+}
+
+// Delete removes the bookmark, a sync client learns about it from a tombstone.
+func (c *Client) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, call{method: http.MethodDelete, path: bookmarkPath(id)}, nil)
+
+	return err
+}
+
+// List reads a page of bookmarks, newest first; the server caps the limit.
+func (c *Client) List(ctx context.Context, f ListFilter) (Page, error) {
+	query := url.Values{}
+
+	if f.Kind != "" {
+		query.Set("kind", f.Kind)
+	}
+
+	if f.Health != "" {
+		query.Set("health", f.Health)
+	}
+
+	if f.Limit > 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+
+	if f.Offset > 0 {
+		query.Set("offset", strconv.Itoa(f.Offset))
+	}
+
+	var page Page
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: "/v1/bookmarks", query: query}, &page); err != nil {
+		return Page{}, err
+	}
+
+	return page, nil
+}
+
+// All calls fn for every bookmark matching the filter, paging until the last page or an error of fn.
+func (c *Client) All(ctx context.Context, f ListFilter, fn func(Bookmark) error) error {
+	for {
+		page, err := c.List(ctx, f)
+		if err != nil {
+			return err
+		}
+
+		for _, b := range page.Items {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+
+		if len(page.Items) < page.Limit || len(page.Items) == 0 {
+			return nil
+		}
+
+		f.Offset = page.Offset + len(page.Items)
+		f.Limit = page.Limit
+	}
+}
+
+// Metadata reads the metadata fetched from the page of an URL bookmark.
+func (c *Client) Metadata(ctx context.Context, id uuid.UUID) (Metadata, error) {
+	var m Metadata
+
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: bookmarkPath(id) + "/metadata"}, &m); err != nil {
+		return Metadata{}, err
+	}
+
+	return m, nil
+}
+
+// Health reads the state of an URL bookmark link with its recent checks, newest first.
+func (c *Client) Health(ctx context.Context, id uuid.UUID) (HealthReport, error) {
+	var h HealthReport
+
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: bookmarkPath(id) + "/health"}, &h); err != nil {
+		return HealthReport{}, err
+	}
+
+	return h, nil
+}
+
+// RequestArchive queues saving a copy of the page of an URL bookmark.
+func (c *Client) RequestArchive(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, call{method: http.MethodPost, path: bookmarkPath(id) + "/archive"}, nil)
+
+	return err
+}
+
+// Archive opens the archived page, an Archive of the etag is not sent again: NotModified is set
+// and Body is empty then. The caller closes the body.
+func (c *Client) Archive(ctx context.Context, id uuid.UUID, etag string) (*Archive, error) {
+	header := http.Header{"Accept": {"text/html"}}
+	if etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.send(ctx, call{method: http.MethodGet, path: bookmarkPath(id) + "/archive", header: header})
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &Archive{
+		ETag:        resp.Header.Get("ETag"),
+		NotModified: resp.StatusCode == http.StatusNotModified,
+		Body:        resp.Body,
+	}
+
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		archive.ArchivedAt = t
+	}
+
+	return archive, nil
+}
+
+func bookmarkPath(id uuid.UUID) string {
+	return "/v1/bookmark/" + id.String()
+}
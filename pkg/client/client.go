@@ -0,0 +1,307 @@
+// Package client is a typed client of the bookmark REST API served by cmd/app.
+//
+// Error responses are returned as *APIError, it unwraps to the sentinel of the failure,
+// so errors.Is(err, client.ErrBookmarkNotFound) tells a missing bookmark.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidURL = errors.New("invalid server url")
+
+// RequestIDHeader carries the request id, both routers of the server adopt it.
+const RequestIDHeader = "X-Request-Id"
+
+type Option func(*Client)
+
+// HTTPClient replaces the default client with a 30s timeout.
+func HTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.http = c
+	}
+}
+
+// Auth signs every request, including the retried ones.
+func Auth(a Authenticator) Option {
+	return func(cl *Client) {
+		cl.auth = a
+	}
+}
+
+// BasicAuth sends the credentials with every request.
+func BasicAuth(user, password string) Option {
+	return Auth(AuthFunc(func(r *http.Request) error {
+		r.SetBasicAuth(user, password)
+		return nil
+	}))
+}
+
+// BearerToken sends the token in the Authorization header of every request.
+func BearerToken(token string) Option {
+	return Auth(AuthFunc(func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}))
+}
+
+// RequestID reads the request id sent to the server from the context, e.g. the id
+// of the request being served; by default it is the one set by WithRequestID.
+func RequestID(fn func(ctx context.Context) string) Option {
+	return func(cl *Client) {
+		cl.requestID = fn
+	}
+}
+
+// Retries sets how many times an idempotent call is retried after a network error
+// or a 429, 502, 503 or 504 response; 0 disables the retries.
+func Retries(n int) Option {
+	return func(cl *Client) {
+		cl.retries = n
+	}
+}
+
+// Backoff sets the delay before the first retry, doubled on every attempt up to limit.
+func Backoff(base, limit time.Duration) Option {
+	return func(cl *Client) {
+		cl.backoff = base
+		cl.maxBackoff = limit
+	}
+}
+
+// Authenticator adds the credentials to a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+// AuthFunc is an Authenticator of a function.
+type AuthFunc func(r *http.Request) error
+
+func (f AuthFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose calls are sent with the request id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type Client struct {
+	baseURL    *url.URL
+	http       *http.Client
+	auth       Authenticator
+	requestID  func(ctx context.Context) string
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// New returns a client of the server at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, options ...Option) (*Client, error) {
+	const op = "client.New"
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrInvalidURL, baseURL)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		http:       &http.Client{Timeout: 30 * time.Second},
+		requestID:  requestIDFrom,
+		retries:    2,
+		backoff:    200 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// call is a request of an endpoint.
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	in     any
+}
+
+// do sends the call and decodes a 2xx response into out, other responses become an *APIError.
+func (c *Client) do(ctx context.Context, cl call, out any) (int, error) {
+	resp, err := c.send(ctx, cl)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// send returns the 2xx or 304 response of the call, retrying an idempotent one.
+// The caller closes the body.
+func (c *Client) send(ctx context.Context, cl call) (*http.Response, error) {
+	var body []byte
+
+	if cl.in != nil {
+		data, err := json.Marshal(cl.in)
+		if err != nil {
+			return nil, err
+		}
+
+		body = data
+	}
+
+	retries := 0
+	if idempotent(cl.method) {
+		retries = c.retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, cl, body)
+
+		retry := attempt < retries && ctx.Err() == nil && (err != nil || retryable(resp.StatusCode))
+		if !retry {
+			if err != nil {
+				return nil, err
+			}
+
+			if resp.StatusCode < 200 || resp.StatusCode > 299 && resp.StatusCode != http.StatusNotModified {
+				defer resp.Body.Close() //nolint:errcheck
+				return nil, newAPIError(resp)
+			}
+
+			return resp, nil
+		}
+
+		delay := c.delay(attempt + 1)
+
+		if resp != nil {
+			delay = max(delay, min(retryAfter(resp), c.maxBackoff))
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, cl call, body []byte) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += cl.path
+	u.RawQuery = cl.query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, cl.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range cl.header {
+		req.Header[key] = values
+	}
+
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if id := c.requestID(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, fmt.Errorf("authenticate: %w", err)
+		}
+	}
+
+	return c.http.Do(req)
+}
+
+// delay returns the backoff after the given attempt: base, 2*base, 4*base... up to the limit.
+func (c *Client) delay(attempt int) time.Duration {
+	d := c.backoff
+	for range attempt - 1 {
+		if d >= c.maxBackoff {
+			break
+		}
+
+		d *= 2
+	}
+
+	return min(d, c.maxBackoff)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter reads the seconds of the Retry-After header, a date is not supported.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
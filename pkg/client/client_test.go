@@ -0,0 +1,326 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	fiberRouter "bookmarks/internal/handler/fiber"
+	fiberv1 "bookmarks/internal/handler/fiber/v1"
+	netRouter "bookmarks/internal/handler/net"
+	netv1 "bookmarks/internal/handler/net/v1"
+	accountRepo "bookmarks/internal/repository/account"
+	repo "bookmarks/internal/repository/bookmark"
+	"bookmarks/internal/service/account"
+	srv "bookmarks/internal/service/bookmark"
+	"bookmarks/internal/storage/memory"
+	"bookmarks/pkg/client"
+)
+
+// makeServers serves the bookmark API by both routers, each with its own storage.
+func makeServers(t *testing.T) map[string]*httptest.Server {
+	t.Helper()
+
+	logger := slog.New(slog.DiscardHandler)
+	chi := &http.Server{} //nolint:gosec // never listens
+	netRouter.Register(logger, netv1.NewHandler(makeService()))(chi)
+
+	app := fiber.New()
+	fiberRouter.Register(logger, fiberv1.NewHandler(makeService()))(app)
+
+	servers := map[string]*httptest.Server{
+		"chi":   httptest.NewServer(chi.Handler),
+		"fiber": httptest.NewServer(adaptor.FiberApp(app)),
+	}
+
+	for _, server := range servers {
+		t.Cleanup(server.Close)
+	}
+
+	return servers
+}
+
+func makeService() interface {
+	netv1.Service
+	fiberv1.Service
+} {
+	return srv.NewService(repo.NewRepository(memory.NewBookmarkStorage()))
+}
+
+func makeClient(t *testing.T, url string, options ...client.Option) *client.Client {
+	t.Helper()
+
+	c, err := client.New(url, options...)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestClient_Bookmark(t *testing.T) {
+	for name, server := range makeServers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := makeClient(t, server.URL)
+
+			created, ok, err := c.Append(ctx, client.AppendRequest{Title: "first", Value: "https://example.com/client"})
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, "url", created.Kind)
+			require.Equal(t, "https://example.com/client", created.Render.Href)
+
+			_, _, err = c.Append(ctx, client.AppendRequest{Title: "second", Value: "https://example.com/client"})
+			require.ErrorIs(t, err, client.ErrBookmarkExists)
+
+			var apiErr *client.APIError
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusConflict, apiErr.StatusCode)
+			require.NotEmpty(t, apiErr.RequestID)
+			require.Equal(t, created.Uuid, apiErr.Existing.Uuid)
+
+			existing, ok, err := c.Append(ctx, client.AppendRequest{
+				Title:      "second",
+				Value:      "https://example.com/client",
+				OnConflict: client.ConflictIgnore,
+			})
+			require.NoError(t, err)
+			require.False(t, ok)
+			require.Equal(t, "first", existing.Title)
+
+			_, _, err = c.Append(ctx, client.AppendRequest{Title: "text", Value: "some text", Kind: "url"})
+			require.ErrorIs(t, err, client.ErrInvalidBookmark)
+
+			viewed, err := c.View(ctx, created.Uuid)
+			require.NoError(t, err)
+			require.Equal(t, created.Version, viewed.Version)
+
+			change := client.ChangeRequest{Title: "renamed", Value: viewed.Value, Version: viewed.Version}
+
+			changed, err := c.Change(ctx, created.Uuid, change)
+			require.NoError(t, err)
+			require.Equal(t, "renamed", changed.Title)
+
+			_, err = c.Change(ctx, created.Uuid, change)
+			require.ErrorIs(t, err, client.ErrBookmarkChanged)
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, changed.Version, apiErr.Existing.Version)
+
+			page, err := c.List(ctx, client.ListFilter{Kind: "url"})
+			require.NoError(t, err)
+			require.Len(t, page.Items, 1)
+			require.Equal(t, srv.DefaultListLimit, page.Limit)
+
+			_, err = c.Metadata(ctx, created.Uuid)
+			require.ErrorIs(t, err, client.ErrMetadataNotFound)
+
+			_, err = c.Health(ctx, created.Uuid)
+			require.ErrorIs(t, err, client.ErrHealthNotFound)
+
+			err = c.RequestArchive(ctx, created.Uuid)
+			require.ErrorIs(t, err, client.ErrArchiveDisabled)
+
+			require.NoError(t, c.Delete(ctx, created.Uuid))
+
+			_, err = c.View(ctx, created.Uuid)
+			require.ErrorIs(t, err, client.ErrBookmarkNotFound)
+
+			err = c.Delete(ctx, uuid.New())
+			require.ErrorIs(t, err, client.ErrBookmarkNotFound)
+		})
+	}
+}
+
+func TestClient_Sync(t *testing.T) {
+	for name, server := range makeServers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := makeClient(t, server.URL)
+			id := uuid.NewString()
+
+			results, err := c.Push(ctx, []client.Mutation{
+				{Op: client.OpCreate, Uuid: id, Title: "offline", Value: "https://example.com/offline"},
+				{Op: client.OpUpdate, Uuid: uuid.NewString(), Title: "missing", Value: "value", Version: 1},
+			})
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			require.Equal(t, client.PushApplied, results[0].Status)
+			require.Equal(t, id, results[0].Uuid)
+			require.NotEqual(t, client.PushApplied, results[1].Status)
+
+			page, err := c.Changes(ctx, "", 0)
+			require.NoError(t, err)
+			require.Len(t, page.Changes, 1)
+			require.Equal(t, client.OpUpsert, page.Changes[0].Op)
+			require.Equal(t, id, page.Changes[0].Bookmark.Uuid.String())
+			require.NotEmpty(t, page.Token)
+
+			_, err = c.Changes(ctx, "not a token", 0)
+
+			var apiErr *client.APIError
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		})
+	}
+}
+
+func TestClient_RequestID(t *testing.T) {
+	for name, server := range makeServers(t) {
+		t.Run(name, func(t *testing.T) {
+			c := makeClient(t, server.URL)
+
+			_, err := c.View(client.WithRequestID(context.Background(), "req-42"), uuid.New())
+
+			var apiErr *client.APIError
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, "req-42", apiErr.RequestID)
+
+			type key struct{}
+
+			c = makeClient(t, server.URL, client.RequestID(func(ctx context.Context) string {
+				id, _ := ctx.Value(key{}).(string)
+				return id
+			}))
+
+			_, err = c.View(context.WithValue(context.Background(), key{}, "req-43"), uuid.New())
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, "req-43", apiErr.RequestID)
+		})
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls, failures atomic.Int32
+
+	api := makeServers(t)["chi"].Config.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		if failures.Load() > 0 {
+			failures.Add(-1)
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		api.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := makeClient(t, server.URL, client.Retries(2), client.Backoff(time.Millisecond, 5*time.Millisecond))
+
+	failures.Store(2)
+
+	_, err := c.List(ctx, client.ListFilter{})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, calls.Load())
+
+	calls.Store(0)
+	failures.Store(3)
+
+	_, err = c.List(ctx, client.ListFilter{})
+
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	require.Equal(t, "Service Unavailable", apiErr.Message)
+	require.EqualValues(t, 3, calls.Load())
+
+	// an append is not idempotent, it is sent once
+	calls.Store(0)
+	failures.Store(1)
+
+	_, _, err = c.Append(ctx, client.AppendRequest{Value: "https://example.com/retry"})
+	require.ErrorAs(t, err, &apiErr)
+	require.EqualValues(t, 1, calls.Load())
+
+	calls.Store(0)
+	failures.Store(1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = c.List(ctx, client.ListFilter{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestClient_Auth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, makeClient(t, server.URL, client.BearerToken("secret")).Delete(ctx, uuid.New()))
+
+	err := makeClient(t, server.URL, client.BasicAuth("user", "secret")).Delete(ctx, uuid.New())
+	require.ErrorIs(t, err, client.ErrUnauthorized)
+
+	errToken := errors.New("token expired")
+	c := makeClient(t, server.URL, client.Auth(client.AuthFunc(func(*http.Request) error {
+		return errToken
+	})))
+
+	require.ErrorIs(t, c.Delete(ctx, uuid.New()), errToken)
+}
+
+func TestClient_Token(t *testing.T) {
+	accounts := account.New(accountRepo.NewRepository(memory.NewAccountStorage()))
+
+	_, err := accounts.CreateUser("alice", false)
+	require.NoError(t, err)
+
+	_, secret, err := accounts.IssueToken("alice", "bmctl", time.Hour)
+	require.NoError(t, err)
+
+	logger := slog.New(slog.DiscardHandler)
+	chi := &http.Server{} //nolint:gosec // never listens
+	netRouter.Register(logger, netv1.NewHandler(makeService()), netRouter.Tokens(accounts), netRouter.Private())(chi)
+
+	app := fiber.New()
+	fiberRouter.Register(logger, fiberv1.NewHandler(makeService()), fiberRouter.Tokens(accounts), fiberRouter.Private())(app)
+
+	for name, handler := range map[string]http.Handler{"chi": chi.Handler, "fiber": adaptor.FiberApp(app)} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			ctx := t.Context()
+
+			request := client.AppendRequest{Value: "https://example.com"}
+
+			_, _, err := makeClient(t, server.URL).Append(ctx, request)
+			require.ErrorIs(t, err, client.ErrUnauthorized)
+
+			_, _, err = makeClient(t, server.URL, client.BearerToken("bmt_unknown")).Append(ctx, request)
+			require.ErrorIs(t, err, client.ErrUnauthorized)
+
+			bookmark, created, err := makeClient(t, server.URL, client.BearerToken(secret)).Append(ctx, request)
+			require.NoError(t, err)
+			require.True(t, created)
+			require.Equal(t, "https://example.com", bookmark.Value)
+		})
+	}
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	for _, url := range []string{"", "localhost:8080", "ftp://example.com", "http://"} {
+		_, err := client.New(url)
+		require.ErrorIs(t, err, client.ErrInvalidURL, url)
+	}
+}
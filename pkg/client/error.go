@@ -0,0 +1,118 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// The sentinels of the failures, matching the errors of the bookmark service.
+var (
+	ErrBookmarkNotFound = errors.New("bookmark not found")
+	ErrBookmarkExists   = errors.New("bookmark already exists")
+	ErrBookmarkChanged  = errors.New("bookmark was changed since the given version")
+	ErrInvalidBookmark  = errors.New("invalid bookmark")
+	ErrMetadataNotFound = errors.New("bookmark metadata not found")
+	ErrHealthNotFound   = errors.New("link health not found")
+	ErrArchiveNotFound  = errors.New("bookmark archive not found")
+	ErrNotArchivable    = errors.New("only url bookmarks can be archived")
+	ErrArchiveDisabled  = errors.New("archiving is disabled")
+	ErrResyncRequired   = errors.New("sync token is unknown, a full resync is required")
+	ErrUnauthorized     = errors.New("unauthorized")
+)
+
+const maxErrorBody = 64 << 10
+
+// APIError is an error response of the server: the handler.ErrorResponse shape,
+// a conflict carries the stored bookmark in Existing.
+type APIError struct {
+	StatusCode int
+	Message    string
+	RequestID  string
+	Existing   *Bookmark
+	// Err is the sentinel of the failure, nil for a status without one
+	Err error
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("%d %s (request %s)", e.StatusCode, e.Message, e.RequestID)
+	}
+
+	return fmt.Sprintf("%d %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+type errorResponse struct {
+	Error   string `json:"error"`
+	Context struct {
+		RequestID string `json:"request_id"`
+	} `json:"context"`
+	Existing *Bookmark `json:"existing"`
+}
+
+// newAPIError reads the error response, a body that is not the JSON error of a handler
+// is reported by its status text.
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+	}
+
+	var body errorResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(&body); err == nil && body.Error != "" {
+		e.Message = body.Error
+		e.RequestID = body.Context.RequestID
+
+		if body.Existing != nil && body.Existing.Uuid != uuid.Nil {
+			e.Existing = body.Existing
+		}
+	}
+
+	e.Err = sentinelOf(e.StatusCode, e.Message)
+
+	return e
+}
+
+// sentinelOf maps an error response to its sentinel, the message tells apart the
+// failures sharing a status.
+func sentinelOf(status int, message string) error {
+	switch status {
+	case http.StatusNotFound:
+		for _, err := range []error{ErrMetadataNotFound, ErrHealthNotFound, ErrArchiveNotFound} {
+			if strings.Contains(message, err.Error()) {
+				return err
+			}
+		}
+
+		return ErrBookmarkNotFound
+	case http.StatusConflict:
+		if strings.Contains(message, ErrBookmarkChanged.Error()) {
+			return ErrBookmarkChanged
+		}
+
+		return ErrBookmarkExists
+	case http.StatusUnprocessableEntity:
+		if strings.Contains(message, ErrNotArchivable.Error()) {
+			return ErrNotArchivable
+		}
+
+		return ErrInvalidBookmark
+	case http.StatusGone:
+		return ErrResyncRequired
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusNotImplemented:
+		return ErrArchiveDisabled
+	default:
+		return nil
+	}
+}
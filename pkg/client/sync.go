@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ops of a change and a mutation.
+const (
+	OpUpsert = "upsert"
+	OpDelete = "delete"
+	OpCreate = "create"
+	OpUpdate = "update"
+)
+
+// Outcomes of a pushed mutation.
+const (
+	PushApplied  = "applied"
+	PushConflict = "conflict"
+	PushRejected = "rejected"
+)
+
+// Change is a bookmark write after the sync token: an upsert carries the bookmark
+// as stored now, a delete its tombstone.
+type Change struct {
+	Op        string     `json:"op"`
+	Version   int64      `json:"version"`
+	Bookmark  *Bookmark  `json:"bookmark,omitempty"`
+	Uuid      *uuid.UUID `json:"uuid,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// SyncPage is a page of the changes in version order, Token fetches the next changes.
+type SyncPage struct {
+	Changes []Change `json:"changes"`
+	Token   string   `json:"token"`
+	More    bool     `json:"more"`
+}
+
+// Mutation is a client change: create may carry a client assigned uuid,
+// update and delete carry the version the client last saw.
+type Mutation struct {
+	Op      string `json:"op"`
+	Uuid    string `json:"uuid,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	Version int64  `json:"version,omitempty"`
+}
+
+// PushResult is the outcome of a pushed mutation, a conflict carries the server side bookmark.
+type PushResult struct {
+	Uuid     string    `json:"uuid,omitempty"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Bookmark *Bookmark `json:"bookmark,omitempty"`
+	Deleted  bool      `json:"deleted,omitempty"`
+}
+
+// Changes reads the changes after the token, an empty token starts from scratch.
+// A token the server no longer knows fails with ErrResyncRequired.
+func (c *Client) Changes(ctx context.Context, token string, limit int) (SyncPage, error) {
+	query := url.Values{}
+
+	if token != "" {
+		query.Set("since", token)
+	}
+
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var page SyncPage
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: "/v1/sync", query: query}, &page); err != nil {
+		return SyncPage{}, err
+	}
+
+	return page, nil
+}
+
+// Push applies the mutations in order, the results follow their order.
+func (c *Client) Push(ctx context.Context, mutations []Mutation) ([]PushResult, error) {
+	var out struct {
+		Results []PushResult `json:"results"`
+	}
+
+	in := struct {
+		Mutations []Mutation `json:"mutations"`
+	}{mutations}
+
+	if _, err := c.do(ctx, call{method: http.MethodPost, path: "/v1/sync", in: in}, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Results, nil
+}
@@ -0,0 +1,959 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: bookmark/v1/bookmark.proto
+
+package bookmarkv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Kind int32
+
+const (
+	Kind_KIND_UNSPECIFIED Kind = 0 // detect from the value
+	Kind_KIND_URL         Kind = 1
+	Kind_KIND_TEXT        Kind = 2
+	Kind_KIND_CODE        Kind = 3
+	Kind_KIND_CONTACT     Kind = 4
+)
+
+// Enum value maps for Kind.
+var (
+	Kind_name = map[int32]string{
+		0: "KIND_UNSPECIFIED",
+		1: "KIND_URL",
+		2: "KIND_TEXT",
+		3: "KIND_CODE",
+		4: "KIND_CONTACT",
+	}
+	Kind_value = map[string]int32{
+		"KIND_UNSPECIFIED": 0,
+		"KIND_URL":         1,
+		"KIND_TEXT":        2,
+		"KIND_CODE":        3,
+		"KIND_CONTACT":     4,
+	}
+)
+
+func (x Kind) Enum() *Kind {
+	p := new(Kind)
+	*p = x
+	return p
+}
+
+func (x Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_bookmark_v1_bookmark_proto_enumTypes[0].Descriptor()
+}
+
+func (Kind) Type() protoreflect.EnumType {
+	return &file_bookmark_v1_bookmark_proto_enumTypes[0]
+}
+
+func (x Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Kind.Descriptor instead.
+func (Kind) EnumDescriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{0}
+}
+
+type ConflictMode int32
+
+const (
+	ConflictMode_CONFLICT_MODE_UNSPECIFIED  ConflictMode = 0 // same as CONFLICT_MODE_ERROR
+	ConflictMode_CONFLICT_MODE_ERROR        ConflictMode = 1
+	ConflictMode_CONFLICT_MODE_IGNORE       ConflictMode = 2
+	ConflictMode_CONFLICT_MODE_UPDATE_TITLE ConflictMode = 3
+)
+
+// Enum value maps for ConflictMode.
+var (
+	ConflictMode_name = map[int32]string{
+		0: "CONFLICT_MODE_UNSPECIFIED",
+		1: "CONFLICT_MODE_ERROR",
+		2: "CONFLICT_MODE_IGNORE",
+		3: "CONFLICT_MODE_UPDATE_TITLE",
+	}
+	ConflictMode_value = map[string]int32{
+		"CONFLICT_MODE_UNSPECIFIED":  0,
+		"CONFLICT_MODE_ERROR":        1,
+		"CONFLICT_MODE_IGNORE":       2,
+		"CONFLICT_MODE_UPDATE_TITLE": 3,
+	}
+)
+
+func (x ConflictMode) Enum() *ConflictMode {
+	p := new(ConflictMode)
+	*p = x
+	return p
+}
+
+func (x ConflictMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConflictMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_bookmark_v1_bookmark_proto_enumTypes[1].Descriptor()
+}
+
+func (ConflictMode) Type() protoreflect.EnumType {
+	return &file_bookmark_v1_bookmark_proto_enumTypes[1]
+}
+
+func (x ConflictMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ConflictMode.Descriptor instead.
+func (ConflictMode) EnumDescriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{1}
+}
+
+type Bookmark struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Uuid           string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Title          string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	TitleAuto      bool                   `protobuf:"varint,3,opt,name=title_auto,json=titleAuto,proto3" json:"title_auto,omitempty"`
+	Value          string                 `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Kind           Kind                   `protobuf:"varint,5,opt,name=kind,proto3,enum=bookmark.v1.Kind" json:"kind,omitempty"`
+	CanonicalValue string                 `protobuf:"bytes,6,opt,name=canonical_value,json=canonicalValue,proto3" json:"canonical_value,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Version        int64                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Bookmark) Reset() {
+	*x = Bookmark{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bookmark) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bookmark) ProtoMessage() {}
+
+func (x *Bookmark) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bookmark.ProtoReflect.Descriptor instead.
+func (*Bookmark) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Bookmark) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Bookmark) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Bookmark) GetTitleAuto() bool {
+	if x != nil {
+		return x.TitleAuto
+	}
+	return false
+}
+
+func (x *Bookmark) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Bookmark) GetKind() Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Kind_KIND_UNSPECIFIED
+}
+
+func (x *Bookmark) GetCanonicalValue() string {
+	if x != nil {
+		return x.CanonicalValue
+	}
+	return ""
+}
+
+func (x *Bookmark) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Bookmark) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type AppendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Kind          Kind                   `protobuf:"varint,3,opt,name=kind,proto3,enum=bookmark.v1.Kind" json:"kind,omitempty"`
+	OnConflict    ConflictMode           `protobuf:"varint,4,opt,name=on_conflict,json=onConflict,proto3,enum=bookmark.v1.ConflictMode" json:"on_conflict,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendRequest) Reset() {
+	*x = AppendRequest{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendRequest) ProtoMessage() {}
+
+func (x *AppendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendRequest.ProtoReflect.Descriptor instead.
+func (*AppendRequest) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AppendRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *AppendRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *AppendRequest) GetKind() Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Kind_KIND_UNSPECIFIED
+}
+
+func (x *AppendRequest) GetOnConflict() ConflictMode {
+	if x != nil {
+		return x.OnConflict
+	}
+	return ConflictMode_CONFLICT_MODE_UNSPECIFIED
+}
+
+type AppendResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bookmark      *Bookmark              `protobuf:"bytes,1,opt,name=bookmark,proto3" json:"bookmark,omitempty"`
+	Created       bool                   `protobuf:"varint,2,opt,name=created,proto3" json:"created,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendResponse) Reset() {
+	*x = AppendResponse{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendResponse) ProtoMessage() {}
+
+func (x *AppendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendResponse.ProtoReflect.Descriptor instead.
+func (*AppendResponse) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AppendResponse) GetBookmark() *Bookmark {
+	if x != nil {
+		return x.Bookmark
+	}
+	return nil
+}
+
+func (x *AppendResponse) GetCreated() bool {
+	if x != nil {
+		return x.Created
+	}
+	return false
+}
+
+type ViewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ViewRequest) Reset() {
+	*x = ViewRequest{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ViewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ViewRequest) ProtoMessage() {}
+
+func (x *ViewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ViewRequest.ProtoReflect.Descriptor instead.
+func (*ViewRequest) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ViewRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+type ChangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Kind          Kind                   `protobuf:"varint,4,opt,name=kind,proto3,enum=bookmark.v1.Kind" json:"kind,omitempty"`
+	Version       int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeRequest) Reset() {
+	*x = ChangeRequest{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeRequest) ProtoMessage() {}
+
+func (x *ChangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeRequest.ProtoReflect.Descriptor instead.
+func (*ChangeRequest) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ChangeRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *ChangeRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *ChangeRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *ChangeRequest) GetKind() Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Kind_KIND_UNSPECIFIED
+}
+
+func (x *ChangeRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{6}
+}
+
+type ListRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  Kind                   `protobuf:"varint,1,opt,name=kind,proto3,enum=bookmark.v1.Kind" json:"kind,omitempty"`
+	// health filters URL bookmarks by link health: ok, failing or broken
+	Health        string `protobuf:"bytes,2,opt,name=health,proto3" json:"health,omitempty"`
+	Limit         int32  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListRequest) GetKind() Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Kind_KIND_UNSPECIFIED
+}
+
+func (x *ListRequest) GetHealth() string {
+	if x != nil {
+		return x.Health
+	}
+	return ""
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Bookmark            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListResponse) GetItems() []*Bookmark {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ListResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type WatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// types filters the events: bookmark.appended, bookmark.changed, bookmark.deleted
+	Types         []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	LastEventId   int64    `protobuf:"varint,2,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WatchRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetLastEventId() int64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// type is the event type, or reset when the resumed stream missed events and the state must be reloaded
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	Bookmark      *Bookmark              `protobuf:"bytes,4,opt,name=bookmark,proto3" json:"bookmark,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_bookmark_v1_bookmark_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_bookmark_v1_bookmark_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Event) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *Event) GetBookmark() *Bookmark {
+	if x != nil {
+		return x.Bookmark
+	}
+	return nil
+}
+
+var File_bookmark_v1_bookmark_proto protoreflect.FileDescriptor
+
+const file_bookmark_v1_bookmark_proto_rawDesc = "" +
+	"\n" +
+	"\x1abookmark/v1/bookmark.proto\x12\vbookmark.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8e\x02\n" +
+	"\bBookmark\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1d\n" +
+	"\n" +
+	"title_auto\x18\x03 \x01(\bR\ttitleAuto\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\tR\x05value\x12%\n" +
+	"\x04kind\x18\x05 \x01(\x0e2\x11.bookmark.v1.KindR\x04kind\x12'\n" +
+	"\x0fcanonical_value\x18\x06 \x01(\tR\x0ecanonicalValue\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x18\n" +
+	"\aversion\x18\b \x01(\x03R\aversion\"\x9e\x01\n" +
+	"\rAppendRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12%\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x11.bookmark.v1.KindR\x04kind\x12:\n" +
+	"\von_conflict\x18\x04 \x01(\x0e2\x19.bookmark.v1.ConflictModeR\n" +
+	"onConflict\"]\n" +
+	"\x0eAppendResponse\x121\n" +
+	"\bbookmark\x18\x01 \x01(\v2\x15.bookmark.v1.BookmarkR\bbookmark\x12\x18\n" +
+	"\acreated\x18\x02 \x01(\bR\acreated\"!\n" +
+	"\vViewRequest\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\"\x90\x01\n" +
+	"\rChangeRequest\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12%\n" +
+	"\x04kind\x18\x04 \x01(\x0e2\x11.bookmark.v1.KindR\x04kind\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\"#\n" +
+	"\rDeleteRequest\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\"\x10\n" +
+	"\x0eDeleteResponse\"z\n" +
+	"\vListRequest\x12%\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x11.bookmark.v1.KindR\x04kind\x12\x16\n" +
+	"\x06health\x18\x02 \x01(\tR\x06health\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\"i\n" +
+	"\fListResponse\x12+\n" +
+	"\x05items\x18\x01 \x03(\v2\x15.bookmark.v1.BookmarkR\x05items\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"H\n" +
+	"\fWatchRequest\x12\x14\n" +
+	"\x05types\x18\x01 \x03(\tR\x05types\x12\"\n" +
+	"\rlast_event_id\x18\x02 \x01(\x03R\vlastEventId\"\x9b\x01\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12;\n" +
+	"\voccurred_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x121\n" +
+	"\bbookmark\x18\x04 \x01(\v2\x15.bookmark.v1.BookmarkR\bbookmark*Z\n" +
+	"\x04Kind\x12\x14\n" +
+	"\x10KIND_UNSPECIFIED\x10\x00\x12\f\n" +
+	"\bKIND_URL\x10\x01\x12\r\n" +
+	"\tKIND_TEXT\x10\x02\x12\r\n" +
+	"\tKIND_CODE\x10\x03\x12\x10\n" +
+	"\fKIND_CONTACT\x10\x04*\x80\x01\n" +
+	"\fConflictMode\x12\x1d\n" +
+	"\x19CONFLICT_MODE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13CONFLICT_MODE_ERROR\x10\x01\x12\x18\n" +
+	"\x14CONFLICT_MODE_IGNORE\x10\x02\x12\x1e\n" +
+	"\x1aCONFLICT_MODE_UPDATE_TITLE\x10\x032\x84\x03\n" +
+	"\x0fBookmarkService\x12A\n" +
+	"\x06Append\x12\x1a.bookmark.v1.AppendRequest\x1a\x1b.bookmark.v1.AppendResponse\x127\n" +
+	"\x04View\x12\x18.bookmark.v1.ViewRequest\x1a\x15.bookmark.v1.Bookmark\x12;\n" +
+	"\x06Change\x12\x1a.bookmark.v1.ChangeRequest\x1a\x15.bookmark.v1.Bookmark\x12A\n" +
+	"\x06Delete\x12\x1a.bookmark.v1.DeleteRequest\x1a\x1b.bookmark.v1.DeleteResponse\x12;\n" +
+	"\x04List\x12\x18.bookmark.v1.ListRequest\x1a\x19.bookmark.v1.ListResponse\x128\n" +
+	"\x05Watch\x12\x19.bookmark.v1.WatchRequest\x1a\x12.bookmark.v1.Event0\x01B+Z)bookmarks/pkg/grpc/bookmark/v1;bookmarkv1b\x06proto3"
+
+var (
+	file_bookmark_v1_bookmark_proto_rawDescOnce sync.Once
+	file_bookmark_v1_bookmark_proto_rawDescData []byte
+)
+
+func file_bookmark_v1_bookmark_proto_rawDescGZIP() []byte {
+	file_bookmark_v1_bookmark_proto_rawDescOnce.Do(func() {
+		file_bookmark_v1_bookmark_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bookmark_v1_bookmark_proto_rawDesc), len(file_bookmark_v1_bookmark_proto_rawDesc)))
+	})
+	return file_bookmark_v1_bookmark_proto_rawDescData
+}
+
+var file_bookmark_v1_bookmark_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_bookmark_v1_bookmark_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_bookmark_v1_bookmark_proto_goTypes = []any{
+	(Kind)(0),                     // 0: bookmark.v1.Kind
+	(ConflictMode)(0),             // 1: bookmark.v1.ConflictMode
+	(*Bookmark)(nil),              // 2: bookmark.v1.Bookmark
+	(*AppendRequest)(nil),         // 3: bookmark.v1.AppendRequest
+	(*AppendResponse)(nil),        // 4: bookmark.v1.AppendResponse
+	(*ViewRequest)(nil),           // 5: bookmark.v1.ViewRequest
+	(*ChangeRequest)(nil),         // 6: bookmark.v1.ChangeRequest
+	(*DeleteRequest)(nil),         // 7: bookmark.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 8: bookmark.v1.DeleteResponse
+	(*ListRequest)(nil),           // 9: bookmark.v1.ListRequest
+	(*ListResponse)(nil),          // 10: bookmark.v1.ListResponse
+	(*WatchRequest)(nil),          // 11: bookmark.v1.WatchRequest
+	(*Event)(nil),                 // 12: bookmark.v1.Event
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+}
+var file_bookmark_v1_bookmark_proto_depIdxs = []int32{
+	0,  // 0: bookmark.v1.Bookmark.kind:type_name -> bookmark.v1.Kind
+	13, // 1: bookmark.v1.Bookmark.created_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: bookmark.v1.AppendRequest.kind:type_name -> bookmark.v1.Kind
+	1,  // 3: bookmark.v1.AppendRequest.on_conflict:type_name -> bookmark.v1.ConflictMode
+	2,  // 4: bookmark.v1.AppendResponse.bookmark:type_name -> bookmark.v1.Bookmark
+	0,  // 5: bookmark.v1.ChangeRequest.kind:type_name -> bookmark.v1.Kind
+	0,  // 6: bookmark.v1.ListRequest.kind:type_name -> bookmark.v1.Kind
+	2,  // 7: bookmark.v1.ListResponse.items:type_name -> bookmark.v1.Bookmark
+	13, // 8: bookmark.v1.Event.occurred_at:type_name -> google.protobuf.Timestamp
+	2,  // 9: bookmark.v1.Event.bookmark:type_name -> bookmark.v1.Bookmark
+	3,  // 10: bookmark.v1.BookmarkService.Append:input_type -> bookmark.v1.AppendRequest
+	5,  // 11: bookmark.v1.BookmarkService.View:input_type -> bookmark.v1.ViewRequest
+	6,  // 12: bookmark.v1.BookmarkService.Change:input_type -> bookmark.v1.ChangeRequest
+	7,  // 13: bookmark.v1.BookmarkService.Delete:input_type -> bookmark.v1.DeleteRequest
+	9,  // 14: bookmark.v1.BookmarkService.List:input_type -> bookmark.v1.ListRequest
+	11, // 15: bookmark.v1.BookmarkService.Watch:input_type -> bookmark.v1.WatchRequest
+	4,  // 16: bookmark.v1.BookmarkService.Append:output_type -> bookmark.v1.AppendResponse
+	2,  // 17: bookmark.v1.BookmarkService.View:output_type -> bookmark.v1.Bookmark
+	2,  // 18: bookmark.v1.BookmarkService.Change:output_type -> bookmark.v1.Bookmark
+	8,  // 19: bookmark.v1.BookmarkService.Delete:output_type -> bookmark.v1.DeleteResponse
+	10, // 20: bookmark.v1.BookmarkService.List:output_type -> bookmark.v1.ListResponse
+	12, // 21: bookmark.v1.BookmarkService.Watch:output_type -> bookmark.v1.Event
+	16, // [16:22] is the sub-list for method output_type
+	10, // [10:16] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_bookmark_v1_bookmark_proto_init() }
+func file_bookmark_v1_bookmark_proto_init() {
+	if File_bookmark_v1_bookmark_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bookmark_v1_bookmark_proto_rawDesc), len(file_bookmark_v1_bookmark_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bookmark_v1_bookmark_proto_goTypes,
+		DependencyIndexes: file_bookmark_v1_bookmark_proto_depIdxs,
+		EnumInfos:         file_bookmark_v1_bookmark_proto_enumTypes,
+		MessageInfos:      file_bookmark_v1_bookmark_proto_msgTypes,
+	}.Build()
+	File_bookmark_v1_bookmark_proto = out.File
+	file_bookmark_v1_bookmark_proto_goTypes = nil
+	file_bookmark_v1_bookmark_proto_depIdxs = nil
+}
@@ -0,0 +1,71 @@
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrPrivateAddress   = errors.New("private network address")
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+type options struct {
+	timeout      time.Duration
+	maxRedirects int
+	allowPrivate bool
+}
+
+// NewClient returns an http.Client for user supplied URLs:
+// it refuses loopback, private and link-local addresses unless AllowPrivateNetworks is given.
+func NewClient(opts ...Option) *http.Client {
+	o := &options{
+		timeout:      10 * time.Second,
+		maxRedirects: 5,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dialer := &net.Dialer{Timeout: o.timeout}
+	if !o.allowPrivate {
+		dialer.Control = denyPrivate
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil // a proxy would hide the dialed address from denyPrivate
+
+	return &http.Client{
+		Timeout:   o.timeout,
+		Transport: transport,
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if len(via) > o.maxRedirects {
+				return ErrTooManyRedirects
+			}
+
+			return nil
+		},
+	}
+}
+
+// denyPrivate refuses connections to loopback, private and link-local addresses.
+func denyPrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package egress
+
+import "time"
+
+type Option func(*options)
+
+func Timeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+func MaxRedirects(n int) Option {
+	return func(o *options) {
+		o.maxRedirects = n
+	}
+}
+
+func AllowPrivateNetworks() Option {
+	return func(o *options) {
+		o.allowPrivate = true
+	}
+}
@@ -2,6 +2,7 @@ package fiberserver
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -24,6 +25,7 @@ type server struct {
 
 	prefork         bool
 	address         string
+	tlsConfig       *tls.Config
 	readTimeout     time.Duration
 	writeTimeout    time.Duration
 	idleTimeout     time.Duration
@@ -72,6 +74,7 @@ func (s *server) Start() {
 	s.eg.Go(func() error {
 		err := s.app.Listen(s.address, fiber.ListenConfig{
 			EnablePrefork: s.prefork,
+			TLSConfig:     s.tlsConfig,
 		})
 		if err != nil {
 			s.notify <- err
@@ -84,7 +87,7 @@ func (s *server) Start() {
 		return nil
 	})
 
-	s.log.Info("Start", slog.String("op", op))
+	s.log.Info("Start", slog.String("op", op), slog.Bool("tls", s.tlsConfig != nil))
 }
 
 func (s *server) Notify() <-chan error {
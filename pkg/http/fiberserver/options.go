@@ -1,6 +1,9 @@
 package fiberserver
 
-import "time"
+import (
+	"crypto/tls"
+	"time"
+)
 
 type Option func(*server)
 
@@ -39,3 +42,10 @@ func IdleTimeout(timeout time.Duration) Option {
 		s.idleTimeout = timeout
 	}
 }
+
+// TLS serves HTTPS with the config, e.g. the one of a tlsconfig.Loader.
+func TLS(config *tls.Config) Option {
+	return func(s *server) {
+		s.tlsConfig = config
+	}
+}
@@ -0,0 +1,157 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"bookmarks/pkg/http"
+)
+
+type server struct {
+	ctx context.Context //nolint:containedctx
+	eg  *errgroup.Group
+	log *slog.Logger
+
+	app    *grpc.Server
+	health *health.Server
+	notify chan error
+
+	reflection      bool
+	address         string
+	serverOptions   []grpc.ServerOption
+	shutdownHooks   []func()
+	shutdownTimeout time.Duration
+}
+
+// New makes a gRPC server with the standard health service, handler registers the services.
+// Every registered service reports SERVING until Shutdown.
+func New(
+	logger *slog.Logger,
+	handler func(s *grpc.Server),
+	options ...Option,
+) http.Server {
+	group, ctx := errgroup.WithContext(context.Background())
+	group.SetLimit(1) // Run only one goroutine
+
+	s := &server{
+		app:        nil,
+		ctx:        ctx,
+		eg:         group,
+		log:        logger,
+		health:     health.NewServer(),
+		notify:     make(chan error, 1),
+		reflection: false,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	app := grpc.NewServer(s.serverOptions...)
+
+	handler(app)
+
+	healthpb.RegisterHealthServer(app, s.health)
+	for name := range app.GetServiceInfo() {
+		s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	if s.reflection {
+		reflection.Register(app)
+	}
+
+	s.app = app
+
+	return s
+}
+
+func (s *server) Start() {
+	const op = "http.grpc.Start"
+
+	s.eg.Go(func() error {
+		err := s.serve()
+		if err != nil {
+			s.notify <- err
+
+			close(s.notify)
+
+			return err
+		}
+
+		return nil
+	})
+
+	s.log.Info("Start", slog.String("op", op))
+}
+
+func (s *server) serve() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	return s.app.Serve(listener)
+}
+
+func (s *server) Notify() <-chan error {
+	return s.notify
+}
+
+// Shutdown reports NOT_SERVING, runs the shutdown hooks and waits for the calls in flight;
+// the calls still running after the shutdown timeout are cancelled.
+func (s *server) Shutdown() error {
+	const op = "http.grpc.Shutdown"
+	var shutdownErrors []error
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	s.health.Shutdown()
+
+	for _, hook := range s.shutdownHooks {
+		hook()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.app.GracefulStop()
+		close(stopped)
+	}()
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.shutdownTimeout)
+	defer cancel()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.app.Stop()
+
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			log.Error(ctx.Err().Error())
+
+			shutdownErrors = append(shutdownErrors, fmt.Errorf("%s: %w", op, ctx.Err()))
+		}
+	}
+
+	err := s.eg.Wait()
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		log.Error(err.Error())
+
+		shutdownErrors = append(shutdownErrors, fmt.Errorf("%s: %w", op, err))
+	}
+
+	log.Info("Shutdown")
+
+	return errors.Join(shutdownErrors...)
+}
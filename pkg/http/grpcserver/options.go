@@ -0,0 +1,43 @@
+package grpcserver
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type Option func(*server)
+
+// ReflectionEnable lets tools like grpcurl discover the services.
+func ReflectionEnable() Option {
+	return func(s *server) {
+		s.reflection = true
+	}
+}
+
+func Address(address string) Option {
+	return func(s *server) {
+		s.address = address
+	}
+}
+
+// ServerOptions are passed to grpc.NewServer, e.g. the interceptors.
+func ServerOptions(options ...grpc.ServerOption) Option {
+	return func(s *server) {
+		s.serverOptions = append(s.serverOptions, options...)
+	}
+}
+
+// ShutdownHooks run when the server starts shutting down, before it waits for the calls in flight:
+// they end the long-running streams.
+func ShutdownHooks(hooks ...func()) Option {
+	return func(s *server) {
+		s.shutdownHooks = append(s.shutdownHooks, hooks...)
+	}
+}
+
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(s *server) {
+		s.shutdownTimeout = timeout
+	}
+}
@@ -2,6 +2,7 @@ package netserver
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -22,6 +23,7 @@ type server struct {
 	notify chan error
 
 	address         string
+	tlsConfig       *tls.Config
 	readTimeout     time.Duration
 	writeTimeout    time.Duration
 	idleTimeout     time.Duration
@@ -55,6 +57,15 @@ func New(
 		IdleTimeout:  s.idleTimeout,
 	}
 
+	if s.tlsConfig != nil {
+		// a config picked per client keeps these protocols, the server would only add them to its copy
+		if len(s.tlsConfig.NextProtos) == 0 {
+			s.tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+		}
+
+		app.TLSConfig = s.tlsConfig
+	}
+
 	handler(app)
 
 	s.app = app
@@ -66,7 +77,14 @@ func (s *server) Start() {
 	const op = "http.net.Start"
 
 	s.eg.Go(func() error {
-		err := s.app.ListenAndServe()
+		var err error
+		if s.tlsConfig != nil {
+			// the certificates come from the config
+			err = s.app.ListenAndServeTLS("", "")
+		} else {
+			err = s.app.ListenAndServe()
+		}
+
 		if err != nil {
 			s.notify <- err
 
@@ -78,7 +96,7 @@ func (s *server) Start() {
 		return nil
 	})
 
-	s.log.Info("Start", slog.String("op", op))
+	s.log.Info("Start", slog.String("op", op), slog.Bool("tls", s.tlsConfig != nil))
 }
 
 func (s *server) Notify() <-chan error {
@@ -1,6 +1,9 @@
 package netserver
 
-import "time"
+import (
+	"crypto/tls"
+	"time"
+)
 
 type Option func(*server)
 
@@ -33,3 +36,10 @@ func IdleTimeout(timeout time.Duration) Option {
 		s.idleTimeout = timeout
 	}
 }
+
+// TLS serves HTTPS with the config, e.g. the one of a tlsconfig.Loader.
+func TLS(config *tls.Config) Option {
+	return func(s *server) {
+		s.tlsConfig = config
+	}
+}
@@ -0,0 +1,59 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+)
+
+// Identity is the client of a mutual TLS connection, as its verified certificate names it.
+type Identity struct {
+	CommonName     string    `json:"common_name"`
+	Organization   []string  `json:"organization,omitempty"`
+	DNSNames       []string  `json:"dns_names,omitempty"`
+	EmailAddresses []string  `json:"email_addresses,omitempty"`
+	URIs           []string  `json:"uris,omitempty"`
+	SerialNumber   string    `json:"serial_number"`
+	Issuer         string    `json:"issuer"`
+	NotAfter       time.Time `json:"not_after"`
+}
+
+// ClientIdentity returns the identity of the client certificate verified in the handshake,
+// a certificate presented without a client CA to verify it is ignored.
+func ClientIdentity(state *tls.ConnectionState) (Identity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+
+	cert := state.VerifiedChains[0][0]
+
+	uris := make([]string, 0, len(cert.URIs))
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+
+	return Identity{
+		CommonName:     cert.Subject.CommonName,
+		Organization:   cert.Subject.Organization,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		URIs:           uris,
+		SerialNumber:   cert.SerialNumber.String(),
+		Issuer:         cert.Issuer.String(),
+		NotAfter:       cert.NotAfter,
+	}, true
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying the identity of the client.
+func NewContext(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity of the client, false when the request came without a verified certificate.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+
+	return id, ok
+}
@@ -0,0 +1,33 @@
+package tlsconfig
+
+import "time"
+
+type Option func(*Loader)
+
+// ClientCA requires the clients to present a certificate signed by a CA of the PEM file.
+func ClientCA(file string) Option {
+	return func(l *Loader) {
+		l.clientCAFile = file
+	}
+}
+
+// MinVersion sets the oldest TLS version accepted, TLS 1.2 by default.
+func MinVersion(version uint16) Option {
+	return func(l *Loader) {
+		l.minVersion = version
+	}
+}
+
+// CipherSuites restricts the TLS 1.2 cipher suites, Go picks secure ones by default.
+func CipherSuites(ids []uint16) Option {
+	return func(l *Loader) {
+		l.cipherSuites = ids
+	}
+}
+
+// WatchInterval sets how often the files are checked for changes, zero reloads them on SIGHUP only.
+func WatchInterval(interval time.Duration) Option {
+	return func(l *Loader) {
+		l.interval = interval
+	}
+}
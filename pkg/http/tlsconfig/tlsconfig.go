@@ -0,0 +1,277 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrNoClientCA         = errors.New("no certificate in the client CA file")
+	ErrUnknownVersion     = errors.New("unknown TLS version")
+	ErrUnknownCipherSuite = errors.New("unknown cipher suite")
+)
+
+// Loader keeps the certificate of a server and the CA of its clients up to date with their files.
+//
+// The files are read again when one of them changes or on SIGHUP. The new certificate is used
+// by the next handshakes, the open connections are kept. A file that fails to load, e.g. while
+// it is being written, leaves the previous certificate in place until the next change.
+type Loader struct {
+	log *slog.Logger
+
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	minVersion   uint16
+	cipherSuites []uint16
+	interval     time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamps    map[string]stamp // the files as they were loaded
+
+	cancel context.CancelFunc
+	loops  sync.WaitGroup
+}
+
+// stamp tells a changed file apart without reading it.
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// New loads the certificate and key files, and the client CA file when it is set.
+func New(logger *slog.Logger, certFile, keyFile string, options ...Option) (*Loader, error) {
+	const op = "tlsconfig.New"
+
+	l := &Loader{
+		log:        logger,
+		certFile:   certFile,
+		keyFile:    keyFile,
+		minVersion: tls.VersionTLS12,
+		interval:   30 * time.Second,
+	}
+
+	for _, opt := range options {
+		opt(l)
+	}
+
+	if err := l.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return l, nil
+}
+
+// Config returns the TLS config of a server: every handshake takes the certificate and the
+// client CA loaded last. With a client CA the clients must present a certificate signed by it.
+func (l *Loader) Config() *tls.Config {
+	base := &tls.Config{
+		MinVersion:   l.minVersion,
+		CipherSuites: l.cipherSuites,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			l.mu.RLock()
+			defer l.mu.RUnlock()
+
+			return l.cert, nil
+		},
+	}
+
+	// the server may set the ALPN protocols of base up to its first handshake
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+
+		c := base.Clone()
+		c.GetConfigForClient = nil
+		c.Certificates = []tls.Certificate{*l.cert}
+
+		if l.clientCAs != nil {
+			c.ClientAuth = tls.RequireAndVerifyClientCert
+			c.ClientCAs = l.clientCAs
+		}
+
+		return c, nil
+	}
+
+	return base
+}
+
+// Reload reads the files again, the previous certificate is kept when one fails to load.
+func (l *Loader) Reload() error {
+	const op = "tlsconfig.Reload"
+
+	stamps, err := l.stat()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var clientCAs *x509.CertPool
+	if l.clientCAFile != "" {
+		pem, err := os.ReadFile(l.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s: %w: %s", op, ErrNoClientCA, l.clientCAFile)
+		}
+	}
+
+	l.mu.Lock()
+	l.cert = &cert
+	l.clientCAs = clientCAs
+	l.stamps = stamps
+	l.mu.Unlock()
+
+	attrs := []any{slog.String("op", op), slog.String("cert_file", l.certFile)}
+	if cert.Leaf != nil {
+		attrs = append(attrs, slog.String("subject", cert.Leaf.Subject.String()), slog.Time("not_after", cert.Leaf.NotAfter))
+	}
+
+	l.log.Info("certificate loaded", attrs...)
+
+	return nil
+}
+
+// Start reloads the files on SIGHUP and, with a watch interval, when they change.
+func (l *Loader) Start() {
+	const op = "tlsconfig.Start"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	l.loops.Go(func() {
+		defer signal.Stop(hup)
+
+		var tick <-chan time.Time
+		if l.interval > 0 {
+			ticker := time.NewTicker(l.interval)
+			defer ticker.Stop()
+
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				l.reload("SIGHUP")
+			case <-tick:
+				if l.changed() {
+					l.reload("file changed")
+				}
+			}
+		}
+	})
+
+	l.log.Info("Start", slog.String("op", op), slog.Duration("interval", l.interval))
+}
+
+// Shutdown stops watching the files.
+func (l *Loader) Shutdown(_ context.Context) error {
+	if l.cancel != nil {
+		l.cancel()
+	}
+
+	l.loops.Wait()
+
+	return nil
+}
+
+func (l *Loader) reload(reason string) {
+	if err := l.Reload(); err != nil {
+		l.log.Error(err.Error(), slog.String("op", "tlsconfig.Start"), slog.String("reason", reason))
+	}
+}
+
+// changed reports whether a file differs from the loaded one.
+func (l *Loader) changed() bool {
+	stamps, err := l.stat()
+	if err != nil {
+		// a file being replaced may be missing for a moment
+		return false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for name, s := range stamps {
+		if l.stamps[name] != s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *Loader) stat() (map[string]stamp, error) {
+	files := []string{l.certFile, l.keyFile}
+	if l.clientCAFile != "" {
+		files = append(files, l.clientCAFile)
+	}
+
+	stamps := make(map[string]stamp, len(files))
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+
+		stamps[name] = stamp{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	return stamps, nil
+}
+
+// ParseVersion parses a TLS version: 1.2 or 1.3, the older ones are insecure.
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("%w: %q, want 1.2 or 1.3", ErrUnknownVersion, version)
+}
+
+// ParseCipherSuites parses the names of secure cipher suites, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+// They apply to TLS 1.2, the suites of TLS 1.3 are not configurable.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	suites := tls.CipherSuites()
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(suites, func(s *tls.CipherSuite) bool { return s.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCipherSuite, name)
+		}
+
+		ids = append(ids, suites[i].ID)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,294 @@
+package tlsconfig_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/pkg/http/tlsconfig"
+)
+
+func TestLoader_Reload(t *testing.T) {
+	ca := newCA(t, "server ca")
+	files := writeCert(t, t.TempDir(), "server", ca.issue(t, "one", x509.ExtKeyUsageServerAuth))
+
+	certs, err := tlsconfig.New(slog.New(slog.DiscardHandler), files.cert, files.key, tlsconfig.WatchInterval(0))
+	require.NoError(t, err)
+
+	addr := serve(t, certs.Config())
+
+	conn := dial(t, addr, &tls.Config{RootCAs: ca.pool(), MinVersion: tls.VersionTLS12})
+	require.Equal(t, "one", conn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+
+	writeCert(t, filepath.Dir(files.cert), "server", ca.issue(t, "two", x509.ExtKeyUsageServerAuth))
+	require.NoError(t, certs.Reload())
+
+	fresh := dial(t, addr, &tls.Config{RootCAs: ca.pool(), MinVersion: tls.VersionTLS12})
+	require.Equal(t, "two", fresh.ConnectionState().PeerCertificates[0].Subject.CommonName)
+
+	// the open connection is kept
+	require.Equal(t, "ok", get(t, conn))
+
+	// a broken file leaves the certificate in place
+	require.NoError(t, os.WriteFile(files.cert, []byte("not a certificate"), 0o600))
+	require.Error(t, certs.Reload())
+
+	fresh = dial(t, addr, &tls.Config{RootCAs: ca.pool(), MinVersion: tls.VersionTLS12})
+	require.Equal(t, "two", fresh.ConnectionState().PeerCertificates[0].Subject.CommonName)
+}
+
+func TestLoader_Watch(t *testing.T) {
+	ca := newCA(t, "server ca")
+	files := writeCert(t, t.TempDir(), "server", ca.issue(t, "one", x509.ExtKeyUsageServerAuth))
+
+	certs, err := tlsconfig.New(slog.New(slog.DiscardHandler), files.cert, files.key, tlsconfig.WatchInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	certs.Start()
+	t.Cleanup(func() { _ = certs.Shutdown(t.Context()) })
+
+	addr := serve(t, certs.Config())
+
+	// the modification time may not change within the resolution of the file system
+	time.Sleep(20 * time.Millisecond)
+	writeCert(t, filepath.Dir(files.cert), "server", ca.issue(t, "renewed", x509.ExtKeyUsageServerAuth))
+
+	require.Eventually(t, func() bool {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.pool(), MinVersion: tls.VersionTLS12})
+		if err != nil {
+			return false
+		}
+
+		defer conn.Close() //nolint:errcheck
+
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName == "renewed"
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestLoader_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+
+	serverCA := newCA(t, "server ca")
+	clientCA := newCA(t, "client ca")
+	files := writeCert(t, dir, "server", serverCA.issue(t, "localhost", x509.ExtKeyUsageServerAuth))
+
+	caFile := filepath.Join(dir, "clients.pem")
+	require.NoError(t, os.WriteFile(caFile, clientCA.pem, 0o600))
+
+	certs, err := tlsconfig.New(slog.New(slog.DiscardHandler), files.cert, files.key,
+		tlsconfig.ClientCA(caFile),
+		tlsconfig.MinVersion(tls.VersionTLS13),
+	)
+	require.NoError(t, err)
+
+	addr := serve(t, certs.Config())
+
+	client := clientCA.issue(t, "backup-agent", x509.ExtKeyUsageClientAuth)
+	conn := dial(t, addr, &tls.Config{
+		RootCAs:      serverCA.pool(),
+		Certificates: []tls.Certificate{client},
+		MinVersion:   tls.VersionTLS13,
+	})
+	require.Equal(t, "backup-agent", get(t, conn))
+
+	// TLS 1.3 reports a rejected client certificate on the first read
+	conn = dial(t, addr, &tls.Config{RootCAs: serverCA.pool(), MinVersion: tls.VersionTLS13})
+	require.Error(t, request(conn))
+
+	stranger := newCA(t, "other ca").issue(t, "stranger", x509.ExtKeyUsageClientAuth)
+	conn = dial(t, addr, &tls.Config{
+		RootCAs:      serverCA.pool(),
+		Certificates: []tls.Certificate{stranger},
+		MinVersion:   tls.VersionTLS13,
+	})
+	require.Error(t, request(conn))
+
+	_, err = tls.Dial("tcp", addr, &tls.Config{RootCAs: serverCA.pool(), MaxVersion: tls.VersionTLS12})
+	require.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	version, err := tlsconfig.ParseVersion("1.3")
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), version)
+
+	_, err = tlsconfig.ParseVersion("1.0")
+	require.ErrorIs(t, err, tlsconfig.ErrUnknownVersion)
+
+	suites, err := tlsconfig.ParseCipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"})
+	require.NoError(t, err)
+	require.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, suites)
+
+	// insecure suites are refused
+	_, err = tlsconfig.ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+	require.ErrorIs(t, err, tlsconfig.ErrUnknownCipherSuite)
+}
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T, name string) authority {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (a authority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.cert)
+
+	return pool
+}
+
+// issue signs a certificate for localhost named cn.
+func (a authority) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+type certFiles struct {
+	cert, key string
+}
+
+func writeCert(t *testing.T, dir, name string, cert tls.Certificate) certFiles {
+	t.Helper()
+
+	key, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+
+	files := certFiles{cert: filepath.Join(dir, name+".crt"), key: filepath.Join(dir, name+".key")}
+
+	require.NoError(t, os.WriteFile(files.cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600))
+	require.NoError(t, os.WriteFile(files.key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0o600))
+
+	return files
+}
+
+// serve answers the common name of the client certificate, or ok without one.
+func serve(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &http.Server{
+		ReadHeaderTimeout: time.Second,
+		TLSConfig:         config,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id, ok := tlsconfig.ClientIdentity(r.TLS); ok {
+				_, _ = io.WriteString(w, id.CommonName)
+				return
+			}
+
+			_, _ = io.WriteString(w, "ok")
+		}),
+	}
+
+	go func() { _ = server.ServeTLS(ln, "", "") }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return ln.Addr().String()
+}
+
+func dial(t *testing.T, addr string, config *tls.Config) *tls.Conn {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", addr, config)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func request(conn *tls.Conn) error {
+	req, err := http.NewRequest(http.MethodGet, "https://localhost/", nil)
+	if err != nil {
+		return err
+	}
+
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func get(t *testing.T, conn *tls.Conn) string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, "https://localhost/", nil)
+	require.NoError(t, err)
+	require.NoError(t, req.Write(conn))
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
@@ -0,0 +1,254 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrDuplicateComponent = errors.New("component registered twice")
+	ErrUnknownDependency  = errors.New("unknown dependency")
+	ErrDependencyCycle    = errors.New("dependency cycle")
+	ErrStopTimeout        = errors.New("not stopped within the shutdown budget")
+)
+
+// Component is a part of the app with a lifetime. Start and Stop are optional:
+// a resource opened when it is built, e.g. a database, only needs a Stop.
+type Component struct {
+	Name string
+	// DependsOn names the components started before this one and stopped after it
+	DependsOn []string
+	Start     func(ctx context.Context) error
+	Stop      func(ctx context.Context) error
+	// Notify reports a failure of the running component, e.g. a server that stopped listening; it ends the run
+	Notify <-chan error
+}
+
+// Lifecycle starts the components of the app in dependency order and stops them in reverse.
+type Lifecycle struct {
+	log        *slog.Logger
+	components []Component
+
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+}
+
+func New(logger *slog.Logger, options ...Option) *Lifecycle {
+	l := &Lifecycle{
+		log:             logger,
+		shutdownTimeout: 30 * time.Second,
+		signals:         []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+
+	for _, opt := range options {
+		opt(l)
+	}
+
+	return l
+}
+
+// Register adds a component, the components without a dependency between them start in registration order.
+func (l *Lifecycle) Register(c Component) {
+	l.components = append(l.components, c)
+}
+
+// grace is how long a stop hook may run once the shutdown timeout is over.
+const grace = 100 * time.Millisecond
+
+type failure struct {
+	name string
+	err  error
+}
+
+// Run starts the components and blocks until ctx is done, a signal arrives or a component fails.
+// Then the started components are stopped in reverse order, all of them within the shutdown timeout.
+// The failure ending the run and the stop errors are returned joined, a signal is not an error.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	const op = "lifecycle.Run"
+
+	log := l.log.With(slog.String("op", op))
+
+	order, err := l.order()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// without signals Notify would relay all of them
+	signals := make(chan os.Signal, 1)
+	if len(l.signals) > 0 {
+		signal.Notify(signals, l.signals...)
+		defer signal.Stop(signals)
+	}
+
+	quit := make(chan struct{})
+	defer close(quit)
+
+	failures := make(chan failure, len(order))
+
+	started := make([]Component, 0, len(order))
+	for _, c := range order {
+		if c.Start != nil {
+			begin := time.Now()
+
+			if err := c.Start(ctx); err != nil {
+				log.Error("start failed", slog.String("component", c.Name), slog.String("error", err.Error()))
+
+				return errors.Join(fmt.Errorf("%s: start %s: %w", op, c.Name, err), l.stop(started))
+			}
+
+			log.Debug("started", slog.String("component", c.Name), slog.Duration("duration", time.Since(begin)))
+		}
+
+		started = append(started, c)
+
+		if c.Notify != nil {
+			go watch(c, failures, quit)
+		}
+	}
+
+	log.Info("started", slog.Int("components", len(started)))
+
+	var cause error
+
+	select {
+	case <-ctx.Done():
+		log.Info("context done", slog.String("reason", context.Cause(ctx).Error()))
+	case s := <-signals:
+		log.Info("signal", slog.String("signal", s.String()))
+	case f := <-failures:
+		log.Error("component failed", slog.String("component", f.name), slog.String("error", f.err.Error()))
+
+		cause = fmt.Errorf("%s: %s: %w", op, f.name, f.err)
+	}
+
+	return errors.Join(cause, l.stop(started))
+}
+
+// watch forwards the first failure of the component until the run ends.
+func watch(c Component, failures chan<- failure, quit <-chan struct{}) {
+	select {
+	case err, ok := <-c.Notify:
+		if ok && err != nil {
+			failures <- failure{name: c.Name, err: err}
+		}
+	case <-quit:
+	}
+}
+
+// stop stops the components in reverse order within the shutdown timeout. Once the timeout
+// is over the remaining components are still stopped, with a done context.
+func (l *Lifecycle) stop(started []Component) error {
+	const op = "lifecycle.Stop"
+
+	log := l.log.With(slog.String("op", op))
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	for _, c := range slices.Backward(started) {
+		if c.Stop == nil {
+			continue
+		}
+
+		begin := time.Now()
+
+		if err := stopWithin(ctx, c); err != nil {
+			log.Error("stop failed", slog.String("component", c.Name), slog.String("error", err.Error()))
+
+			errs = append(errs, fmt.Errorf("%s: %s: %w", op, c.Name, err))
+
+			continue
+		}
+
+		log.Debug("stopped", slog.String("component", c.Name), slog.Duration("duration", time.Since(begin)))
+	}
+
+	log.Info("stopped", slog.Int("components", len(started)), slog.Int("errors", len(errs)))
+
+	return errors.Join(errs...)
+}
+
+// stopWithin waits for the stop hook until ctx is done, a hook ignoring its context is left behind.
+func stopWithin(ctx context.Context, c Component) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// the hooks run after the timeout get a moment to release their resources
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(grace):
+			return ErrStopTimeout
+		}
+	}
+}
+
+// order sorts the components so that every one comes after its dependencies,
+// otherwise keeping the registration order.
+func (l *Lifecycle) order() ([]Component, error) {
+	index := make(map[string]int, len(l.components))
+	for i, c := range l.components {
+		if _, ok := index[c.Name]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateComponent, c.Name)
+		}
+
+		index[c.Name] = i
+	}
+
+	for _, c := range l.components {
+		for _, dep := range c.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, c.Name, dep)
+			}
+		}
+	}
+
+	order := make([]Component, 0, len(l.components))
+	placed := make([]bool, len(l.components))
+
+	for len(order) < len(l.components) {
+		progress := false
+
+		for i, c := range l.components {
+			waiting := slices.ContainsFunc(c.DependsOn, func(dep string) bool { return !placed[index[dep]] })
+			if placed[i] || waiting {
+				continue
+			}
+
+			order = append(order, c)
+			placed[i] = true
+			progress = true
+
+			// the earliest registered component ready to start goes first
+			break
+		}
+
+		if !progress {
+			var waiting []string
+			for i, c := range l.components {
+				if !placed[i] {
+					waiting = append(waiting, c.Name)
+				}
+			}
+
+			return nil, fmt.Errorf("%w: %v", ErrDependencyCycle, waiting)
+		}
+	}
+
+	return order, nil
+}
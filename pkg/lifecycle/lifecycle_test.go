@@ -0,0 +1,151 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"bookmarks/pkg/lifecycle"
+)
+
+// journal records the hooks in the order they run.
+type journal struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (j *journal) add(entry string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = append(j.entries, entry)
+}
+
+func (j *journal) component(name string, dependsOn ...string) lifecycle.Component {
+	return lifecycle.Component{
+		Name:      name,
+		DependsOn: dependsOn,
+		Start: func(context.Context) error {
+			j.add("start " + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			j.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func TestRun_Order(t *testing.T) {
+	var j journal
+
+	app := makeLifecycle()
+	app.Register(j.component("server", "db", "queue"))
+	app.Register(j.component("queue", "db"))
+	app.Register(j.component("tracing"))
+	app.Register(j.component("db"))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	require.NoError(t, app.Run(ctx))
+	require.Equal(t, []string{
+		"start tracing", "start db", "start queue", "start server",
+		"stop server", "stop queue", "stop db", "stop tracing",
+	}, j.entries)
+}
+
+func TestRun_StartFailure(t *testing.T) {
+	var j journal
+
+	failing := j.component("queue", "db")
+	failing.Start = func(context.Context) error { return errors.New("no workers") }
+
+	app := makeLifecycle()
+	app.Register(j.component("db"))
+	app.Register(failing)
+	app.Register(j.component("server", "queue"))
+
+	err := app.Run(t.Context())
+	require.ErrorContains(t, err, "start queue: no workers")
+	require.Equal(t, []string{"start db", "stop db"}, j.entries)
+}
+
+func TestRun_ComponentFailure(t *testing.T) {
+	var j journal
+
+	notify := make(chan error, 1)
+	notify <- errors.New("address already in use")
+
+	server := j.component("server", "db")
+	server.Notify = notify
+
+	app := makeLifecycle()
+	app.Register(j.component("db"))
+	app.Register(server)
+
+	err := app.Run(t.Context())
+	require.ErrorContains(t, err, "server: address already in use")
+	require.Equal(t, []string{"start db", "start server", "stop server", "stop db"}, j.entries)
+}
+
+func TestRun_ShutdownBudget(t *testing.T) {
+	var j journal
+
+	stuck := j.component("stuck", "db")
+	stuck.Stop = func(context.Context) error {
+		select {} // ignores its context
+	}
+
+	broken := j.component("broken", "db")
+	broken.Stop = func(context.Context) error { return errors.New("flush failed") }
+
+	app := makeLifecycle(lifecycle.ShutdownTimeout(50 * time.Millisecond))
+	app.Register(j.component("db"))
+	app.Register(broken)
+	app.Register(stuck)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	start := time.Now()
+	err := app.Run(ctx)
+	require.Less(t, time.Since(start), time.Second)
+
+	require.ErrorIs(t, err, lifecycle.ErrStopTimeout)
+	require.ErrorContains(t, err, "broken: flush failed")
+
+	// the components after the stuck one are still stopped
+	require.Equal(t, []string{"start db", "start broken", "start stuck", "stop db"}, j.entries)
+}
+
+func TestRun_InvalidDependencies(t *testing.T) {
+	var j journal
+
+	app := makeLifecycle()
+	app.Register(j.component("server", "db"))
+	require.ErrorIs(t, app.Run(t.Context()), lifecycle.ErrUnknownDependency)
+
+	app = makeLifecycle()
+	app.Register(j.component("a", "b"))
+	app.Register(j.component("b", "a"))
+	require.ErrorIs(t, app.Run(t.Context()), lifecycle.ErrDependencyCycle)
+
+	app = makeLifecycle()
+	app.Register(j.component("db"))
+	app.Register(j.component("db"))
+	require.ErrorIs(t, app.Run(t.Context()), lifecycle.ErrDuplicateComponent)
+
+	require.Empty(t, j.entries)
+}
+
+func makeLifecycle(options ...lifecycle.Option) *lifecycle.Lifecycle {
+	options = append([]lifecycle.Option{lifecycle.Signals()}, options...)
+
+	return lifecycle.New(slog.New(slog.DiscardHandler), options...)
+}
@@ -0,0 +1,22 @@
+package lifecycle
+
+import (
+	"os"
+	"time"
+)
+
+type Option func(*Lifecycle)
+
+// ShutdownTimeout is the budget of stopping all the components.
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(l *Lifecycle) {
+		l.shutdownTimeout = timeout
+	}
+}
+
+// Signals replaces the signals ending the run, os.Interrupt and SIGTERM by default; none leaves them to ctx.
+func Signals(signals ...os.Signal) Option {
+	return func(l *Lifecycle) {
+		l.signals = signals
+	}
+}
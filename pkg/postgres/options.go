@@ -21,3 +21,10 @@ func ConnTimeout(timeout time.Duration) Option {
 		c.connTimeout = timeout
 	}
 }
+
+// Tracing records a span of every query run with a traced context, with its SQL text.
+func Tracing() Option {
+	return func(c *Pgsql) {
+		c.tracing = true
+	}
+}
@@ -12,6 +12,7 @@ type Pgsql struct {
 	maxPoolSize  int
 	connAttempts int
 	connTimeout  time.Duration
+	tracing      bool
 
 	Pool *pgxpool.Pool
 }
@@ -36,6 +37,10 @@ func New(url string, opts ...Option) (*Pgsql, error) {
 
 	poolConfig.MaxConns = int32(pg.maxPoolSize) //nolint:gosec // skip integer overflow conversion int -> int32
 
+	if pg.tracing {
+		poolConfig.ConnConfig.Tracer = newTracer()
+	}
+
 	for pg.connAttempts > 0 {
 		pg.Pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 		if err == nil {
@@ -53,3 +58,10 @@ func New(url string, opts ...Option) (*Pgsql, error) {
 
 	return pg, nil
 }
+
+// Close closes the pool, it waits for the connections in use to be released.
+func (p *Pgsql) Close() {
+	if p.Pool != nil {
+		p.Pool.Close()
+	}
+}
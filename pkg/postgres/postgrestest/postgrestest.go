@@ -0,0 +1,71 @@
+// Package postgrestest opens postgres databases for tests.
+package postgrestest
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"bookmarks/pkg/postgres"
+)
+
+// EnvURL names the variable holding the postgres:// url of the test database.
+const EnvURL = "BOOKMARKS_TEST_POSTGRES"
+
+// Enabled tells whether EnvURL is set, tests running on several storages check it
+// to leave postgres out rather than be skipped.
+func Enabled() bool {
+	return os.Getenv(EnvURL) != ""
+}
+
+// New returns a connection to a fresh schema of the test database, dropped when the test ends.
+// The test is skipped when EnvURL is not set.
+func New(t *testing.T) *postgres.Pgsql {
+	t.Helper()
+
+	if !Enabled() {
+		t.Skip(EnvURL + " is not set")
+	}
+
+	dsn := os.Getenv(EnvURL)
+
+	admin, err := postgres.New(dsn)
+	if err != nil {
+		t.Fatalf("postgrestest: %v", err)
+	}
+
+	t.Cleanup(admin.Close)
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	if _, err := admin.Pool.Exec(t.Context(), "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("postgrestest: %v", err)
+	}
+
+	// the test context is done by the time the cleanups run
+	t.Cleanup(func() {
+		_, _ = admin.Pool.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("postgrestest: %v", err)
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	pg, err := postgres.New(u.String(), postgres.MaxPoolSize(4))
+	if err != nil {
+		t.Fatalf("postgrestest: %v", err)
+	}
+
+	t.Cleanup(pg.Close)
+
+	return pg
+}
@@ -0,0 +1,8 @@
+package sqlite
+
+import "errors"
+
+var ErrLocked = errors.New("database is locked by another process")
+
+// lockExt names the lock file kept next to the database.
+const lockExt = ".lock"
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin)
+
+package sqlite
+
+// Lock does not lock on this platform, the database is never reported as locked.
+func Lock(_ string) (func() error, error) {
+	return func() error { return nil }, nil
+}
+
+// LockShared does not lock on this platform, see Lock.
+func LockShared(_ string) (func() error, error) {
+	return func() error { return nil }, nil
+}
@@ -0,0 +1,57 @@
+//go:build linux || darwin
+
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Lock takes the exclusive lock of the database at path, ErrLocked while another process
+// holds it. It is held until release is called or the process exits.
+func Lock(path string) (func() error, error) {
+	const op = "sqlite.Lock"
+
+	release, err := flock(path, syscall.LOCK_EX)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return release, nil
+}
+
+// LockShared takes a lock of the database at path that other processes share,
+// ErrLocked while one of them holds the exclusive lock.
+func LockShared(path string) (func() error, error) {
+	const op = "sqlite.LockShared"
+
+	release, err := flock(path, syscall.LOCK_SH)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return release, nil
+}
+
+// flock locks the lock file next to the database without waiting, the file is left behind.
+func flock(path string, how int) (func() error, error) {
+	file, err := os.OpenFile(path+lockExt, os.O_RDWR|os.O_CREATE, 0o600) //nolint:gosec // next to the database
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB); err != nil { //nolint:gosec // a valid descriptor
+		_ = file.Close()
+
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+		}
+
+		return nil, err
+	}
+
+	// closing the file releases the lock
+	return file.Close, nil
+}
@@ -0,0 +1,330 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Duplicate is a key stored more than once in the table of a unique index.
+type Duplicate struct {
+	Index string   `json:"index"`
+	Table string   `json:"table"`
+	Key   []string `json:"key"`
+	Count int      `json:"count"`
+}
+
+// IntegrityCheck returns the problems PRAGMA integrity_check and foreign_key_check find,
+// none for a sound database.
+func (s *Sqlite) IntegrityCheck() ([]string, error) {
+	const op = "sqlite.IntegrityCheck"
+
+	problems, err := s.column("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(problems) == 1 && problems[0] == "ok" {
+		problems = nil
+	}
+
+	rows, err := s.DB.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table, parent string
+			rowid         *int64
+			fk            int
+		)
+
+		if err := rows.Scan(&table, &rowid, &parent, &fk); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		problems = append(problems, fmt.Sprintf("foreign key %d of %s row %v refers to a missing %s row", fk, table, rowid, parent))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return problems, nil
+}
+
+// UniqueIndexCheck scans the tables, not their indexes, for the keys stored more than once
+// in a unique index: a damaged index may miss them. Keys with a NULL are distinct in SQLite,
+// an index on expressions is skipped.
+func (s *Sqlite) UniqueIndexCheck() ([]Duplicate, error) {
+	const op = "sqlite.UniqueIndexCheck"
+
+	tables, err := s.column("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var duplicates []Duplicate
+
+	for _, table := range tables {
+		indexes, err := s.uniqueIndexes(table)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, table, err)
+		}
+
+		for _, index := range indexes {
+			found, err := s.duplicates(table, index)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", op, index.name, err)
+			}
+
+			duplicates = append(duplicates, found...)
+		}
+	}
+
+	return duplicates, nil
+}
+
+// Vacuum rebuilds the database file, the free pages are returned to the file system.
+func (s *Sqlite) Vacuum() error {
+	const op = "sqlite.Vacuum"
+
+	if _, err := s.DB.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// BackupTo writes a consistent copy of the database to path with VACUUM INTO, writers are not blocked.
+// The file at path must not exist.
+func (s *Sqlite) BackupTo(path string) error {
+	const op = "sqlite.BackupTo"
+
+	if _, err := s.DB.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Size returns the size of the database in bytes, the pages in use and the free ones.
+func (s *Sqlite) Size() (int64, error) {
+	const op = "sqlite.Size"
+
+	var pages, size int64
+
+	if err := s.DB.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.DB.QueryRow("PRAGMA page_size").Scan(&size); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pages * size, nil
+}
+
+// Ping checks the database is reachable and writable: it sets the schema version to itself in a
+// transaction that is rolled back, so a read-only file or directory fails it without changing anything.
+func (s *Sqlite) Ping(ctx context.Context) error {
+	const op = "sqlite.Ping"
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	var version int
+	if err := tx.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+type uniqueIndex struct {
+	name    string
+	columns []string
+	where   string // the condition of a partial index
+}
+
+func (s *Sqlite) uniqueIndexes(table string) ([]uniqueIndex, error) {
+	rows, err := s.DB.Query("SELECT name, \"unique\", partial FROM pragma_index_list(?)", table)
+	if err != nil {
+		return nil, err
+	}
+
+	type listed struct {
+		name    string
+		partial bool
+	}
+
+	var list []listed
+
+	for rows.Next() {
+		var (
+			l      listed
+			unique bool
+		)
+
+		if err := rows.Scan(&l.name, &unique, &l.partial); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+
+		if unique {
+			list = append(list, l)
+		}
+	}
+
+	_ = rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	indexes := make([]uniqueIndex, 0, len(list))
+
+	for _, l := range list {
+		index := uniqueIndex{name: l.name}
+
+		columns, err := s.column("SELECT name FROM pragma_index_info(?) ORDER BY seqno", l.name)
+		if err != nil {
+			return nil, err
+		}
+
+		// an expression has no name
+		if len(columns) == 0 || containsEmpty(columns) {
+			continue
+		}
+
+		index.columns = columns
+
+		if l.partial {
+			var sql string
+			if err := s.DB.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?", l.name).Scan(&sql); err != nil {
+				return nil, err
+			}
+
+			if i := strings.LastIndex(strings.ToUpper(sql), " WHERE "); i >= 0 {
+				index.where = strings.TrimSuffix(strings.TrimSpace(sql[i+len(" WHERE "):]), ";")
+			}
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+func (s *Sqlite) duplicates(table string, index uniqueIndex) ([]Duplicate, error) {
+	columns := make([]string, 0, len(index.columns))
+	conditions := make([]string, 0, len(index.columns)+1)
+
+	for _, column := range index.columns {
+		columns = append(columns, quote(column))
+		conditions = append(conditions, quote(column)+" IS NOT NULL")
+	}
+
+	if index.where != "" {
+		conditions = append(conditions, "("+index.where+")")
+	}
+
+	key := strings.Join(columns, ", ")
+
+	// NOT INDEXED reads the table even when the index is damaged
+	//nolint:gosec // the names come from the schema and are quoted
+	query := fmt.Sprintf(
+		"SELECT %s, COUNT(*) FROM %s NOT INDEXED WHERE %s GROUP BY %s HAVING COUNT(*) > 1",
+		castText(index.columns), quote(table), strings.Join(conditions, " AND "), key,
+	)
+
+	rows, err := s.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var duplicates []Duplicate
+
+	for rows.Next() {
+		values := make([]string, len(index.columns))
+		dest := make([]any, 0, len(values)+1)
+
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		d := Duplicate{Index: index.name, Table: table, Key: values}
+		dest = append(dest, &d.Count)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		duplicates = append(duplicates, d)
+	}
+
+	return duplicates, rows.Err()
+}
+
+// column reads the first column of the rows of the query.
+func (s *Sqlite) column(query string, args ...any) ([]string, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var value *string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+
+		if value == nil {
+			values = append(values, "")
+		} else {
+			values = append(values, *value)
+		}
+	}
+
+	return values, rows.Err()
+}
+
+func castText(columns []string) string {
+	casts := make([]string, 0, len(columns))
+	for _, column := range columns {
+		casts = append(casts, "CAST("+quote(column)+" AS TEXT)")
+	}
+
+	return strings.Join(casts, ", ")
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func containsEmpty(values []string) bool {
+	for _, v := range values {
+		if v == "" {
+			return true
+		}
+	}
+
+	return false
+}
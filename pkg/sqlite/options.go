@@ -13,3 +13,10 @@ func SourceName(name string) Option {
 		s.dataSourceName = name
 	}
 }
+
+// Tracing records a span of every statement run with a traced context, with its SQL text.
+func Tracing() Option {
+	return func(s *Sqlite) {
+		s.tracing = true
+	}
+}
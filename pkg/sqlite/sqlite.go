@@ -1,15 +1,26 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Sqlite struct {
 	driverName, dataSourceName string
+	tracing                    bool
 	DB                         *sql.DB
 }
 
+// Migration is a single schema step. Steps are applied once, in order,
+// and the number of applied steps is kept in PRAGMA user_version.
+type Migration func(tx *sql.Tx) error
+
 func New(options ...Option) (*Sqlite, error) {
 	const op = "sqlite.New"
 
@@ -21,7 +32,12 @@ func New(options ...Option) (*Sqlite, error) {
 		opt(sqlite)
 	}
 
-	db, err := sql.Open(sqlite.driverName, sqlite.dataSourceName)
+	open := sql.Open
+	if sqlite.tracing {
+		open = openTraced
+	}
+
+	db, err := open(sqlite.driverName, sqlite.dataSourceName)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -31,35 +47,93 @@ func New(options ...Option) (*Sqlite, error) {
 	return sqlite, nil
 }
 
-func (s *Sqlite) Migrate() error {
-	const op = "sqlite.Migrate"
+// openTraced opens the database with a driver recording the statements of the traced contexts,
+// the rows and the connection housekeeping are left out.
+func openTraced(driverName, dataSourceName string) (*sql.DB, error) {
+	return otelsql.Open(driverName, dataSourceName,
+		otelsql.WithAttributes(semconv.DBSystemNameSQLite),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			OmitConnectorConnect: true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
+}
 
-	for _, query := range tableSlice() {
-		stmt, err := s.DB.Prepare(query)
-		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
-		}
+// Close closes the database, the queries in flight finish first.
+func (s *Sqlite) Close() error {
+	const op = "sqlite.Close"
 
-		defer stmt.Close()
+	if err := s.DB.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-		_, err = stmt.Exec()
-		if err != nil {
-			return fmt.Errorf("%s: %w", op, err)
+	return nil
+}
+
+// Migrate applies the migrations that are not applied yet, each one in its own transaction.
+func (s *Sqlite) Migrate(migrations []Migration) error {
+	const op = "sqlite.Migrate"
+
+	version, err := s.Version()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if err := s.apply(i+1, migrations[i]); err != nil {
+			return fmt.Errorf("%s: version %d: %w", op, i+1, err)
 		}
 	}
 
 	return nil
 }
 
-func tableSlice() []string {
-	return []string{
-		`
-		CREATE TABLE IF NOT EXISTS bookmark(
-			uuid TEXT PRIMARY KEY,
-			title TEXT NOT NULL,
-			value TEXT NOT NULL,
-			created_at DATETIME NOT NULL);
-		CREATE UNIQUE INDEX IF NOT EXISTS ui_value ON bookmark(value);
-		`,
+// Version returns the number of applied migrations.
+func (s *Sqlite) Version() (int, error) {
+	const op = "sqlite.Version"
+
+	var version int
+	if err := s.DB.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, nil
+}
+
+func (s *Sqlite) apply(version int, migration Migration) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := migration(tx); err != nil {
+		return err
+	}
+
+	// PRAGMA does not accept bind parameters
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Exec returns a migration running the statements one by one:
+// a prepared statement executes only the first statement of a query.
+func Exec(queries ...string) Migration {
+	return func(tx *sql.Tx) error {
+		for _, query := range queries {
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	}
 }